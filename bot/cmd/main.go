@@ -5,8 +5,7 @@ import (
 	grpcserver "bot/internal/server/grpc_server"
 	httpserver "bot/internal/server/http_server"
 	"context"
-	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
@@ -22,17 +21,21 @@ func main() {
 	// Инициализируем общие зависимости
 	deps, err := dependencies.InitDependencies(ctx)
 	if err != nil {
-		log.Fatalf("Failed to initialize dependencies: %v", err)
+		slog.Error("failed to initialize dependencies", "error", err)
+		os.Exit(1)
 	}
 
 	// Создаем HTTP-сервер бота
-	httpServer, err := httpserver.NewBotGateway(ctx, deps.BotServerconfig.HTTPServerConfig, deps.BotConfig, deps.BotHttpHandler)
+	httpServer, err := httpserver.NewBotGateway(ctx, deps.BotServerconfig.HTTPServerConfig, deps.BotConfig, deps.BotHttpHandler, deps.BotHealth)
 	if err != nil {
 		panic("Failed to create server!")
 	}
 
 	// Создаём GRPC-сервер бота (для обработки сообщений по grpc от сервера основной логики)
-	grpcBotServer := grpcserver.NewBotGRPCServer(deps.BotGrpcHandler, deps.BotServerconfig.GRPCServerConfig)
+	grpcBotServer := grpcserver.NewBotGRPCServer(deps.BotGrpcHandler, deps.BotHealth.GRPCServer(), deps.BotServerconfig.GRPCServerConfig)
+
+	// запускаем периодические проверки зависимостей (статус для gRPC health и /readyz)
+	go deps.BotHealth.Run(ctx)
 
 	// создаём канал, который бдут реагировать на системные сигналы
 	sigChan := make(chan os.Signal, 1)
@@ -40,45 +43,48 @@ func main() {
 
 	// Запуск HTTP сервера бота
 	go func() {
-		fmt.Printf("🚀 HTTP сервер бота запускается на %s\n", deps.BotServerconfig.HTTPServerConfig.Addr())
 		if err := httpServer.Run(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Server failed: %v", err)
+			slog.Error("http server failed", "error", err)
+			os.Exit(1)
 		}
 	}()
 
 	// запуск GRPC сервера бота
 	go func() {
-		fmt.Printf("🚀 GRPC сервер бота запускается на %s\n", deps.BotServerconfig.GRPCServerConfig.Addr())
 		if err := grpcBotServer.Run(); err != nil {
-			log.Fatalf("GRPC Server --- failed: %v", err)
+			slog.Error("grpc server failed", "error", err)
+			os.Exit(1)
 		}
 	}()
 
 	// Ожидание сигнала
 	<-sigChan
-	fmt.Println("\n🛑 Остановка сервера бота...")
+	slog.Info("stopping bot service")
+
+	// сообщаем оркестратору и серверу основной логики, что бот больше не принимает трафик
+	deps.BotHealth.Shutdown()
 
 	// Graceful shutdown
 	shutdownCtx, shutdownCancel := context.WithTimeout(ctx, 30*time.Second)
 	defer shutdownCancel()
 
 	// Останавливаем HTTP сервер (ждем текущие запросы)
-	fmt.Println("Останавливаем HTTP сервер бота ...")
+	slog.Info("stopping HTTP server")
 	if err := httpServer.Shutdown(shutdownCtx); err != nil {
-		log.Printf("Error during server shutdown: %v", err)
+		slog.Error("error during server shutdown", "error", err)
 	}
 
 	// Останавливаем GRPC сервер (ждем текущие запросы)
-	fmt.Println("Останавливаем GRPC biz сервер...")
+	slog.Info("stopping gRPC server")
 	if err := grpcBotServer.Shutdown(shutdownCtx); err != nil {
-		log.Printf("Error during server shutdown: %v", err)
+		slog.Error("error during server shutdown", "error", err)
 	}
 
 	// Закрываем зависимости при выходе
 	err = deps.Close()
 	if err != nil {
-		log.Printf("Error during resourses closing: %v", err)
+		slog.Error("error during resources closing", "error", err)
 	}
 
-	fmt.Println("👋 Bot Серверы остановлены")
+	slog.Info("bot servers stopped")
 }
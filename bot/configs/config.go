@@ -13,6 +13,11 @@ import (
 type BotServiceConfig struct {
 	HTTPServerConfig *config.BotHttpServerConfig
 	GRPCServerConfig *configs.GRPCServerConfig
+	GRPCClientConfig *configs.GRPCClientConfig // подключение к серверу основной логики (бот шлёт туда обновления от Telegram)
+	OfflineConfig    *config.OfflineModeConfig // поведение при недоступности сервера основной логики
+	HealthConfig     *configs.HealthConfig     // проверки здоровья (gRPC health, /healthz, /readyz)
+	LoggerConfig     *configs.LoggerConfig     // конфиг логгера
+	TracingConfig    *configs.TracingConfig    // конфиг трассировки (OpenTelemetry)
 }
 
 // путь к .env файлу
@@ -39,8 +44,50 @@ func LoadBotServiceConfig() (*BotServiceConfig, error) {
 		return nil, fmt.Errorf("Error during loading config: %s\n", err.Error())
 	}
 
+	// загружаем данные из .yml файла для grpcClientConfig
+	grpcClientConfig, err := configs.LoadYAMLConfig[configs.GRPCClientConfig](os.Getenv("BOT_GRPC_CLIENT_CONFIG_ADDRESS_STRING"), UseDefaultBotGRPCClientConfig)
+	if err != nil {
+		return nil, fmt.Errorf("Error during loading config: %s\n", err.Error())
+	}
+
+	// загружаем настройки офлайн режима
+	offlineConfig, err := configs.LoadYAMLConfig[config.OfflineModeConfig](os.Getenv("BOT_OFFLINE_CONFIG_ADDRESS_STRING"), config.UseDefaultOfflineModeConfig)
+	if err != nil {
+		return nil, fmt.Errorf("Error during loading config: %s\n", err.Error())
+	}
+
+	// загружаем конфиг проверок здоровья
+	healthConfig, err := configs.LoadYAMLConfig[configs.HealthConfig](os.Getenv("BOT_HEALTH_CONFIG_ADDRESS_STRING"), configs.UseDefaultHealthConfig)
+	if err != nil {
+		return nil, fmt.Errorf("Error during loading config: %s\n", err.Error())
+	}
+
+	// загружаем конфиг логгера
+	loggerConfig, err := configs.LoadYAMLConfig[configs.LoggerConfig](os.Getenv("BOT_LOGGER_CONFIG_ADDRESS_STRING"), configs.UseDefaultLoggerConfig)
+	if err != nil {
+		return nil, fmt.Errorf("Error during loading config: %s\n", err.Error())
+	}
+
+	// загружаем конфиг трассировки
+	tracingConfig, err := configs.LoadYAMLConfig[configs.TracingConfig](os.Getenv("BOT_TRACING_CONFIG_ADDRESS_STRING"), configs.UseDefaultTracingConfig)
+	if err != nil {
+		return nil, fmt.Errorf("Error during loading config: %s\n", err.Error())
+	}
+
 	return &BotServiceConfig{
 		HTTPServerConfig: httpServerConfig,
 		GRPCServerConfig: grpcServerConfig,
+		GRPCClientConfig: grpcClientConfig,
+		OfflineConfig:    offlineConfig,
+		HealthConfig:     healthConfig,
+		LoggerConfig:     loggerConfig,
+		TracingConfig:    tracingConfig,
 	}, nil
 }
+
+// дэфолтный конфиг grpc клиента бота (сервер основной логики слушает :50051)
+func UseDefaultBotGRPCClientConfig() *configs.GRPCClientConfig {
+	config := configs.UseDefaultGRPCClientConfig()
+	config.Port = "50051"
+	return config
+}
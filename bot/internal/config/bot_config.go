@@ -12,7 +12,6 @@ type BotConfig struct {
 	BotToken    string `yaml:"bot_token"`    // BotToken - это уникальный идентификатор бота в Telegram (Выдается @BotFather при создании бота)
 	WebhookURL  string `yaml:"webhook_url"`  // Публичный HTTPS URL, на который Telegram будет отправлять обновления
 	WebhookPort string `yaml:"webhook_port"` // Локальный порт, на котором бот слушает входящие вебхуки. Обычно 8080, 8443 или 443 (для HTTPS)
-	GRPCServer  string `yaml:"grpc_server"`  // адрес gRPC сервера, к которому подключается бот, Бот выступает как gRPC клиент и шлет сюда обновления от Telegram
 	// Стандартные значения:
 	//   - "development" - локальная разработка (больше логов, debug режим)
 	//   - "staging" - тестовый сервер (похоже на production, но с тестовыми данными)
@@ -44,7 +43,6 @@ func UseDefaultBotConfig() *BotConfig {
 		BotToken:    "123456:ABC",
 		WebhookURL:  "https://example.com/webhook",
 		WebhookPort: "8080",
-		GRPCServer:  "localhost:50051",
 		Environment: "production",
 	}
 }
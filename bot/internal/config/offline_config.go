@@ -0,0 +1,28 @@
+package config
+
+import (
+	"pkg/configs"
+	"time"
+)
+
+// OfflineModeConfig - настройки работы шлюза, когда сервер основной логики недоступен
+type OfflineModeConfig struct {
+	CircuitBreaker configs.CircuitBreakerConfig `yaml:"circuit_breaker"` // пороги срабатывания предохранителя для gRPC клиента
+	QueuePath      string                       `yaml:"queue_path"`      // файл локальной очереди необработанных update
+	MaxQueueSize   int                          `yaml:"max_queue_size"`  // максимальный размер очереди (0 - без ограничений)
+	ReplayInterval time.Duration                `yaml:"replay_interval"` // как часто пробуем отправить накопленные update
+	ReplayTimeout  time.Duration                `yaml:"replay_timeout"`  // таймаут на обработку одного update при повторной отправке
+	OfflineReply   string                       `yaml:"offline_reply"`   // ответ пользователю (не чаще одного раза за время недоступности)
+}
+
+// дэфолтный конфиг для офлайн режима
+func UseDefaultOfflineModeConfig() *OfflineModeConfig {
+	return &OfflineModeConfig{
+		CircuitBreaker: *configs.UseDefaultCircuitBreakerConfig(),
+		QueuePath:      "data/offline_updates.queue",
+		MaxQueueSize:   10000,
+		ReplayInterval: 5 * time.Second,
+		ReplayTimeout:  30 * time.Second,
+		OfflineReply:   "🔌 Сервер временно недоступен. Мы получили ваше сообщение и ответим, как только связь восстановится.",
+	}
+}
@@ -3,10 +3,17 @@ package dependencies
 import (
 	"bot/configs"
 	"bot/internal/config"
+	botmetrics "bot/internal/metrics"
 	grpcclient "bot/internal/server/grpc_client"
 	handlersgrpc "bot/internal/server/grpc_server/handlers_grpc"
 	"bot/internal/server/http_server/handlers"
+	offlinequeue "bot/internal/server/offline_queue"
 	"bot/internal/server/service"
+	pb "global_models/grpc/bot"
+	"log/slog"
+	"pkg/health"
+	"pkg/logger"
+	"pkg/tracing"
 	"sync"
 
 	httpclient "bot/internal/server/http_client"
@@ -14,6 +21,7 @@ import (
 
 	"fmt"
 	"runtime"
+	"time"
 )
 
 // определяем зависимости для сервиса ботов
@@ -24,23 +32,35 @@ type BotServiceDependencies struct {
 	BotHTTPClient   *httpclient.BotHTTPClient    // клиент для работы по HTTP
 	BotHttpHandler  *handlers.BotHttpHandler     // хэндлер для http сервера бота
 	BotGrpcHandler  *handlersgrpc.BotGRPCHandler // хэндлер для grpc сервера бота
+	BotHealth       *health.Checker              // проверки здоровья (gRPC health, /healthz, /readyz)
 
-	closeOnce sync.Once // для того, чтобы функция освобождения ресурсов выполнилась только 1 раз
-	closeErr  error
+	stopReplay    func()               // остановка фоновой отправки накопленных update
+	shutdownTrace tracing.ShutdownFunc // дописывает накопленные спаны при остановке
+	replayWG      sync.WaitGroup       // ожидание завершения фоновой отправки
+	closeOnce     sync.Once            // для того, чтобы функция освобождения ресурсов выполнилась только 1 раз
+	closeErr      error
 }
 
 // InitDependencies инициализирует общие зависимости для bot_service
 func InitDependencies(ctx context.Context) (*BotServiceDependencies, error) {
-	// Получаем количество CPU
-	currentMaxProcs := runtime.GOMAXPROCS(-1)
-	fmt.Printf("Текущее значение GOMAXPROCS: %d\n", currentMaxProcs)
-
 	// Получаем конфигурацию (сервиса бота)
 	serviceConf, err := configs.LoadBotServiceConfig()
 	if err != nil {
 		return nil, fmt.Errorf("failed to load service config: %w", err)
 	}
 
+	// настраиваем логгер (json/text по ENV, уровень из конфига)
+	logger.Init(serviceConf.LoggerConfig)
+
+	// Получаем количество CPU
+	slog.Info("runtime", "gomaxprocs", runtime.GOMAXPROCS(-1))
+
+	// настраиваем трассировку (экспортер из конфига)
+	shutdownTrace, err := tracing.Init(ctx, serviceConf.TracingConfig, "bot-gateway")
+	if err != nil {
+		return nil, fmt.Errorf("failed to init tracing: %w", err)
+	}
+
 	// получаем конфигурацию бота
 	botConf, err := config.LoadBotConfig()
 	if err != nil {
@@ -48,7 +68,7 @@ func InitDependencies(ctx context.Context) (*BotServiceDependencies, error) {
 	}
 
 	// создаём клиент, который может общаться по grpc
-	botGrpcClient, err := grpcclient.NewBotGrpcClient(botConf.GRPCServer)
+	botGrpcClient, err := grpcclient.NewBotGrpcClient(serviceConf.GRPCClientConfig, &serviceConf.OfflineConfig.CircuitBreaker)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create botGRPC client: %w", err)
 	}
@@ -56,8 +76,26 @@ func InitDependencies(ctx context.Context) (*BotServiceDependencies, error) {
 	// создаём клиент, который может общаться по HTTP
 	botHTTPClient := httpclient.NewClient(botConf.BotToken)
 
+	// создаём проверки здоровья: без Telegram бот не готов,
+	// недоступность сервера основной логики только отображаем - на этот случай есть офлайн режим
+	botHealth := health.NewChecker(serviceConf.HealthConfig, []string{pb.BotService_ServiceDesc.ServiceName},
+		health.Check{Name: "telegram", Critical: true, Fn: botHTTPClient.GetMe},
+		health.Check{Name: "logic_server", Critical: false, Fn: botGrpcClient.CheckHealth},
+	)
+
+	// создаём очередь для update, которые не удалось передать серверу основной логики
+	offlineQueue, err := offlinequeue.NewFileQueue(serviceConf.OfflineConfig.QueuePath, serviceConf.OfflineConfig.MaxQueueSize)
+	if err != nil {
+		botGrpcClient.Close()
+		return nil, fmt.Errorf("failed to create offline queue: %w", err)
+	}
+	if n := offlineQueue.Len(); n > 0 {
+		slog.Warn("offline queue contains unprocessed updates", "count", n)
+	}
+	botmetrics.RegisterQueueDepth(offlineQueue.Len)
+
 	// создаём сервисный слой для бота
-	botService := service.NewBotService(botGrpcClient, botHTTPClient)
+	botService := service.NewBotService(botGrpcClient, botHTTPClient, offlineQueue, serviceConf.OfflineConfig)
 
 	// создаём хэндлер для http сервера бота
 	botHttpHandler := handlers.NewBotHandler(botService)
@@ -65,14 +103,27 @@ func InitDependencies(ctx context.Context) (*BotServiceDependencies, error) {
 	// сощдаём хэндлер для grpc сервера бота
 	botGrpcHandler := handlersgrpc.NewBotGRPCHandler(botService)
 
-	return &BotServiceDependencies{
+	deps := &BotServiceDependencies{
 		BotConfig:       botConf,
 		BotServerconfig: serviceConf,
 		BotGrpcClient:   botGrpcClient,
 		BotHTTPClient:   botHTTPClient,
 		BotHttpHandler:  botHttpHandler,
 		BotGrpcHandler:  botGrpcHandler,
-	}, nil
+		BotHealth:       botHealth,
+		shutdownTrace:   shutdownTrace,
+	}
+
+	// запускаем фоновую отправку накопленных update на сервер основной логики
+	replayCtx, stopReplay := context.WithCancel(ctx)
+	deps.stopReplay = stopReplay
+	deps.replayWG.Add(1)
+	go func() {
+		defer deps.replayWG.Done()
+		botService.RunReplay(replayCtx)
+	}()
+
+	return deps, nil
 }
 
 // метод структуры зависимостей для осбобождения ресурсов
@@ -80,6 +131,12 @@ func (d *BotServiceDependencies) Close() error {
 	d.closeOnce.Do(func() {
 		var errs []error
 
+		// останавливаем фоновую отправку накопленных update (до закрытия gRPC клиента)
+		if d.stopReplay != nil {
+			d.stopReplay()
+			d.replayWG.Wait()
+		}
+
 		// Закрываем gRPC клиент (В ПЕРВУЮ ОЧЕРЕДЬ!)
 		if d.BotGrpcClient != nil {
 			if err := d.BotGrpcClient.Close(); err != nil {
@@ -87,6 +144,15 @@ func (d *BotServiceDependencies) Close() error {
 			}
 		}
 
+		// дописываем накопленные спаны (в последнюю очередь, чтобы попали спаны остановки)
+		if d.shutdownTrace != nil {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			if err := d.shutdownTrace(ctx); err != nil {
+				errs = append(errs, fmt.Errorf("tracing: %w", err))
+			}
+			cancel()
+		}
+
 		// проверяем аггрегированные ошибки
 		if len(errs) > 0 {
 			d.closeErr = fmt.Errorf("close errors: %v", errs)
@@ -95,7 +161,7 @@ func (d *BotServiceDependencies) Close() error {
 
 	// выводим сообщение, что ресурсы освобождены
 	if d.closeErr == nil {
-		fmt.Println("Ресурсы - освобождены")
+		slog.Info("resources released")
 	}
 
 	return d.closeErr
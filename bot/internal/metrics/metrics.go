@@ -0,0 +1,119 @@
+// Пакет metrics - prometheus метрики шлюза бота: входящие update, обращения к Telegram API и офлайн очередь
+package metrics
+
+import (
+	"net/http"
+	"path"
+	"pkg/metrics"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// типы update (значения метки type)
+const (
+	UpdateMessage  = "message"  // текстовое сообщение
+	UpdateCommand  = "command"  // команда (/start, /help ...)
+	UpdateCallback = "callback" // нажатие inline кнопки
+)
+
+// результаты обработки update (значения метки result)
+const (
+	ResultOK     = "ok"     // сервер обработал update, ответ отправлен
+	ResultQueued = "queued" // сервер недоступен, update сохранён в офлайн очередь
+	ResultError  = "error"  // update не обработан
+)
+
+var (
+	// количество входящих update по типу
+	updatesTotal = metrics.MustRegister(prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "bot_updates_total",
+		Help: "Telegram updates received by the gateway, by type.",
+	}, []string{"type"}))
+
+	// длительность обработки update (от получения до отправки ответа в Telegram)
+	updateDuration = metrics.MustRegister(prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "bot_update_processing_seconds",
+		Help:    "Time from receiving a Telegram update to sending the reply, by type and result.",
+		Buckets: []float64{.01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+	}, []string{"type", "result"}))
+
+	// обращения к Telegram Bot API по методу и коду ошибки
+	telegramRequests = metrics.MustRegister(prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "telegram_api_requests_total",
+		Help: "Telegram Bot API calls, by API method and error_code (0 - success).",
+	}, []string{"method", "error_code"}))
+
+	// длительность обращений к Telegram Bot API
+	telegramDuration = metrics.MustRegister(prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "telegram_api_request_duration_seconds",
+		Help:    "Duration of Telegram Bot API calls, by API method.",
+		Buckets: []float64{.05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60},
+	}, []string{"method"}))
+)
+
+// UpdateObserver замеряет обработку одного update
+type UpdateObserver struct {
+	updateType string
+	start      time.Time
+}
+
+// ObserveUpdate учитывает входящий update и начинает замер его обработки
+func ObserveUpdate(updateType string) *UpdateObserver {
+	updatesTotal.WithLabelValues(updateType).Inc()
+	return &UpdateObserver{updateType: updateType, start: time.Now()}
+}
+
+// Done записывает длительность обработки update с результатом
+func (o *UpdateObserver) Done(result string) {
+	updateDuration.WithLabelValues(o.updateType, result).Observe(time.Since(o.start).Seconds())
+}
+
+// RegisterQueueDepth публикует текущую длину офлайн очереди (update, ждущие сервер основной логики)
+func RegisterQueueDepth(depth func() int) {
+	metrics.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "bot_offline_queue_depth",
+		Help: "Updates waiting in the offline queue for the logic server.",
+	}, func() float64 { return float64(depth()) }))
+}
+
+// TelegramTransport оборачивает http.RoundTripper метриками обращений к Telegram Bot API.
+// Telegram возвращает error_code, совпадающий с HTTP статусом ответа, поэтому код берётся из статуса
+func TelegramTransport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &telegramTransport{base: base}
+}
+
+// RoundTripper с метриками Telegram Bot API
+type telegramTransport struct {
+	base http.RoundTripper
+}
+
+// метод для выполнения запроса с замером
+func (t *telegramTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// метод API - последний сегмент пути (токен бота в метки не попадает)
+	method := path.Base(req.URL.Path)
+
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	telegramDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+
+	telegramRequests.WithLabelValues(method, telegramErrorCode(resp, err)).Inc()
+	return resp, err
+}
+
+// функция для получения кода ошибки Telegram из ответа
+func telegramErrorCode(resp *http.Response, err error) string {
+	switch {
+	case err != nil:
+		// ответа нет - сеть, таймаут или отмена запроса
+		return "network"
+	case resp.StatusCode == http.StatusOK:
+		return "0"
+	default:
+		return strconv.Itoa(resp.StatusCode)
+	}
+}
@@ -0,0 +1,56 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestTelegramTransport(t *testing.T) {
+	t.Run("метод и error_code берутся из запроса и статуса ответа", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusTooManyRequests)
+		}))
+		defer srv.Close()
+
+		counter := telegramRequests.WithLabelValues("sendMessage", "429")
+		before := testutil.ToFloat64(counter)
+
+		client := &http.Client{Transport: TelegramTransport(nil)}
+		resp, err := client.Post(srv.URL+"/bot123:SECRET/sendMessage", "application/json", nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		resp.Body.Close()
+
+		if got := testutil.ToFloat64(counter); got != before+1 {
+			t.Errorf("want %v, got %v", before+1, got)
+		}
+	})
+
+	t.Run("без ответа - network", func(t *testing.T) {
+		counter := telegramRequests.WithLabelValues("getMe", "network")
+		before := testutil.ToFloat64(counter)
+
+		client := &http.Client{Transport: TelegramTransport(nil)}
+		if _, err := client.Get("http://127.0.0.1:1/bot123/getMe"); err == nil {
+			t.Fatal("want connection error")
+		}
+
+		if got := testutil.ToFloat64(counter); got != before+1 {
+			t.Errorf("want %v, got %v", before+1, got)
+		}
+	})
+}
+
+func TestObserveUpdate(t *testing.T) {
+	t.Run("update учитывается по типу", func(t *testing.T) {
+		before := testutil.ToFloat64(updatesTotal.WithLabelValues(UpdateCallback))
+		ObserveUpdate(UpdateCallback).Done(ResultOK)
+		if got := testutil.ToFloat64(updatesTotal.WithLabelValues(UpdateCallback)); got != before+1 {
+			t.Errorf("want %v, got %v", before+1, got)
+		}
+	})
+}
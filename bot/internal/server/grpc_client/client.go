@@ -2,10 +2,16 @@ package grpc_client
 
 import (
 	"context"
-	"fmt"
+	"errors"
+	"log/slog"
+	"pkg/circuitbreaker"
+	"pkg/configs"
+	"pkg/grpcconn"
+	"pkg/health"
 
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	// Импортируем сгенерированный из proto файла пакет
 	// pb - это псевдоним (alias) для удобства использования
@@ -15,31 +21,38 @@ import (
 // BotGrpcClient представляет gRPC клиент для сервиса бота
 // Инкапсулирует соединение и сгенерированный клиент
 type BotGrpcClient struct {
-	conn       *grpc.ClientConn    // Физическое соединение с сервером
-	grpcClient pb.BotServiceClient // Сгенерированный клиент для вызова методов
+	conn       *grpc.ClientConn                // Физическое соединение с сервером
+	grpcClient pb.BotServiceClient             // Сгенерированный клиент для вызова методов
+	breaker    *circuitbreaker.Breaker         // предохранитель для ProcessUpdate (не долбим недоступный сервер)
+	health     func(ctx context.Context) error // проверка доступности сервера основной логики (grpc.health.v1)
 }
 
 // NewBotGrpcClient создает новый gRPC клиент и устанавливает соединение с сервером
-// serverAddr - адрес сервера в формате "host:port" (например "localhost:50051")
-func NewBotGrpcClient(serverAddr string) (*BotGrpcClient, error) {
-	// Создаем клиент
-	conn, err := grpc.NewClient(
-		serverAddr,
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-	)
+// config - адрес сервера, политика повторов, таймауты, TLS и токен (сервер по умолчанию "localhost:50051")
+// breakerConf - пороги срабатывания предохранителя
+func NewBotGrpcClient(config *configs.GRPCClientConfig, breakerConf *configs.CircuitBreakerConfig) (*BotGrpcClient, error) {
+	serverAddr := config.Addr()
+
+	// Создаем соединение (повторы, дедлайн по умолчанию, keepalive, TLS и токен - из конфига)
+	conn, err := grpcconn.NewClient(config, pb.BotService_ServiceDesc.ServiceName)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create gRPC client: %w", err)
+		return nil, err
 	}
 
-	// Инициируем соединение
-	conn.Connect()
-
 	// Проверяем состояние сразу
 	client := pb.NewBotServiceClient(conn)
 
+	// создаём предохранитель и логируем смену его состояния
+	breaker := circuitbreaker.New(breakerConf)
+	breaker.OnStateChange(func(from, to circuitbreaker.State) {
+		slog.Warn("circuit breaker state changed", "logic_server", serverAddr, "from", from.String(), "to", to.String())
+	})
+
 	return &BotGrpcClient{
 		conn:       conn,
 		grpcClient: client,
+		breaker:    breaker,
+		health:     health.GRPCPeerCheck(conn, pb.BotService_ServiceDesc.ServiceName),
 	}, nil
 }
 
@@ -50,13 +63,55 @@ func (c *BotGrpcClient) Close() error {
 }
 
 // ProcessUpdate отправляет запрос на обработку обновления от Telegram
+// Если сервер недавно многократно не отвечал, запрос не отправляется и возвращается circuitbreaker.ErrOpen
 func (c *BotGrpcClient) ProcessUpdate(ctx context.Context, req *pb.UpdateRequest) (*pb.UpdateResponse, error) {
+	if err := c.breaker.Allow(); err != nil {
+		return nil, err
+	}
+
 	// Вызываем сгенерированный метод клиента
 	// Запрос автоматически сериализуется в protobuf и отправляется по gRPC
-	return c.grpcClient.ProcessUpdate(ctx, req)
+	resp, err := c.grpcClient.ProcessUpdate(ctx, req)
+
+	// сообщаем предохранителю результат вызова
+	switch {
+	case IsUnavailable(err):
+		c.breaker.RecordFailure()
+	case status.Code(err) == codes.Canceled:
+		// запрос отменили мы сами - о доступности сервера это ничего не говорит
+		c.breaker.RecordIgnored()
+	default:
+		// сервер ответил (в том числе ошибкой вроде InvalidArgument) - значит он доступен
+		c.breaker.RecordSuccess()
+	}
+
+	return resp, err
+}
+
+// CheckHealth проверяет, что сервер основной логики доступен и готов обрабатывать update
+// (идёт мимо предохранителя, чтобы видеть реальное состояние сервера)
+func (c *BotGrpcClient) CheckHealth(ctx context.Context) error {
+	return c.health(ctx)
 }
 
 // SendMessage отправляет запрос на отправку сообщения от бота
 func (c *BotGrpcClient) SendMessage(ctx context.Context, req *pb.SendMessageRequest) (*pb.SendMessageResponse, error) {
 	return c.grpcClient.SendMessage(ctx, req)
 }
+
+// IsUnavailable сообщает, что ошибка означает недоступность сервера основной логики
+// (цепь разомкнута, нет соединения, сервер не успел ответить или перегружен)
+func IsUnavailable(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, circuitbreaker.ErrOpen) {
+		return true
+	}
+
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Aborted:
+		return true
+	}
+	return false
+}
@@ -1,6 +1,11 @@
 package handlersgrpc
 
-import "bot/internal/server/service"
+import (
+	"bot/internal/server/service"
+	"context"
+
+	pb "global_models/grpc/bot"
+)
 
 // На этом слое остается только транспортная логика (преобразование данных и управление запросом/ответом)
 type BotGRPCHandler struct {
@@ -13,3 +18,8 @@ func NewBotGRPCHandler(service *service.BotService) *BotGRPCHandler {
 		Service: service,
 	}
 }
+
+// метод отправки сообщения по запросу сервера основной логики
+func (h *BotGRPCHandler) SendMessage(ctx context.Context, req *pb.SendMessageRequest) *pb.SendMessageResponse {
+	return h.Service.SendMessage(ctx, req)
+}
@@ -0,0 +1,22 @@
+package grpcserver
+
+import (
+	"context"
+	"log/slog"
+	"pkg/logger"
+
+	pb "global_models/grpc/bot"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// SendMessage - сервер основной логики просит отправить сообщение клиенту (например, ответ мастера из админки)
+func (s *BotGRPCServer) SendMessage(ctx context.Context, req *pb.SendMessageRequest) (*pb.SendMessageResponse, error) {
+	if req.ChatId == 0 || req.Text == "" {
+		return nil, status.Error(codes.InvalidArgument, "chat_id and text are required")
+	}
+	slog.InfoContext(ctx, "send message request", "chat_id", logger.MaskID(req.ChatId))
+
+	return s.Handler.SendMessage(ctx, req), nil
+}
@@ -5,11 +5,15 @@ import (
 	"context"
 	"fmt"
 	pb "global_models/grpc/bot" // Импортируем сгенерированные protobuf - это как контракт, по которому бот и сервер будут общаться
-	"log"
+	"log/slog"
 	"net"
 	"pkg/configs"
+	"pkg/grpcsecurity"
+	"pkg/interceptors"
+	"pkg/tracing"
 
 	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/keepalive"
 	"google.golang.org/grpc/reflection"
 )
@@ -20,13 +24,15 @@ type BotGRPCServer struct {
 	server                           *grpc.Server                 // Сам сервер, который слушает входящие подключения
 	Handler                          *handlersgrpc.BotGRPCHandler // Бизнес-логика для сообщений (экземпляр хэндлера)
 	config                           *configs.GRPCServerConfig    // конфиг grpc сервера
+	health                           healthpb.HealthServer        // стандартный grpc.health.v1 (статус от проверок зависимостей)
 }
 
 // конструктор для grpc сервера бота
-func NewBotGRPCServer(handler *handlersgrpc.BotGRPCHandler, cfg *configs.GRPCServerConfig) *BotGRPCServer {
+func NewBotGRPCServer(handler *handlersgrpc.BotGRPCHandler, healthServer healthpb.HealthServer, cfg *configs.GRPCServerConfig) *BotGRPCServer {
 	return &BotGRPCServer{
 		Handler: handler,
 		config:  cfg,
+		health:  healthServer,
 	}
 }
 
@@ -49,19 +55,40 @@ func (s *BotGRPCServer) Run() error {
 	}
 
 	// Создаем gRPC сервер с опциями
-	s.server = grpc.NewServer(
+	opts := []grpc.ServerOption{
 		grpc.KeepaliveParams(keepaliveParams),        // Добавляем проверки соединения
 		grpc.MaxRecvMsgSize(s.config.MaxRecvMsgSize), // Максимальный размер принимаемого сообщения - 10 МБ
 		grpc.MaxSendMsgSize(s.config.MaxSendMsgSize), // Максимальный размер отправляемого сообщения - тоже 10 МБ
-	)
+	}
+
+	// Добавляем цепочку interceptor-ов (recovery, access-лог, request-id, метрики, дедлайны)
+	opts = append(opts, interceptors.ServerOptions(&s.config.Interceptors, nil)...)
+
+	// Добавляем трассировку входящих вызовов (продолжаем трейс клиента)
+	opts = append(opts, tracing.ServerOptions()...)
+
+	// Добавляем TLS / mTLS и проверку токена (если включены в конфиге)
+	securityOpts, err := grpcsecurity.ServerOptions(s.config)
+	if err != nil {
+		lis.Close()
+		return fmt.Errorf("failed to configure grpc security: %w", err)
+	}
+	opts = append(opts, securityOpts...)
+
+	s.server = grpc.NewServer(opts...)
 
 	// Регистрируем наш сервис - говорим: "Этот сервер умеет работать с ботом по таким-то правилам"
 	pb.RegisterBotServiceServer(s.server, s)
 
+	// Регистрируем grpc.health.v1 - по нему сервер основной логики и оркестратор проверяют готовность бота
+	if s.health != nil {
+		healthpb.RegisterHealthServer(s.server, s.health)
+	}
+
 	// Регистрируем reflection для инструментов отладки (grpcurl и т.д.)
 	reflection.Register(s.server)
 
-	log.Printf("gRPC server listening on :%s", s.config.Port)
+	slog.Info("gRPC server listening", "port", s.config.Port)
 
 	// Запускаем сервер в бесконечный цикл приема сообщений
 	// Serve - блокирующая операция, выполняется пока сервер не остановят
@@ -86,7 +113,7 @@ func (s *BotGRPCServer) Shutdown(ctx context.Context) error {
 		s.server.Stop() // Грубо останавливаем все соединения
 		return ctx.Err()
 	case <-stopped:
-		log.Println("gRPC server shutdown completed")
+		slog.Info("gRPC server shutdown completed")
 		return nil
 	}
 }
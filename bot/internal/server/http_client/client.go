@@ -2,11 +2,16 @@
 package httpclient
 
 import (
+	botmetrics "bot/internal/metrics"
 	"bot/internal/server/http_client/converter"
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
+	"pkg/tracing"
 	"time"
 
 	pb "global_models/grpc/bot" // Импорт сгенерированных protobuf структур
@@ -26,8 +31,8 @@ type BotHTTPClient struct {
 func NewClient(token string) *BotHTTPClient {
 	return &BotHTTPClient{
 		token:   token,
-		Http:    &http.Client{Timeout: 10 * time.Second},              // Важно: таймаут защищает от зависания запросов
-		baseURL: fmt.Sprintf("https://api.telegram.org/bot%s", token), // Формируем базовый URL согласно документации Telegram
+		Http:    &http.Client{Timeout: 10 * time.Second, Transport: botmetrics.TelegramTransport(tracing.Transport("telegram", nil))}, // Важно: таймаут защищает от зависания запросов
+		baseURL: fmt.Sprintf("https://api.telegram.org/bot%s", token),                                                                 // Формируем базовый URL согласно документации Telegram
 	}
 }
 
@@ -77,11 +82,39 @@ func (c *BotHTTPClient) SetWebhook(url string) error {
 	return nil
 }
 
+// GetMe проверяет токен бота и доступность Telegram API (метод getMe)
+func (c *BotHTTPClient) GetMe(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/getMe", c.baseURL), nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.Http.Do(req)
+	if err != nil {
+		// в ошибке net/http есть URL с токеном - его не выводим
+		return fmt.Errorf("telegram getMe request failed")
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Ok          bool   `json:"ok"`
+		Description string `json:"description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("failed to decode getMe response: %w", err)
+	}
+
+	if !result.Ok {
+		return fmt.Errorf("telegram getMe failed: %s", result.Description)
+	}
+	return nil
+}
+
 // SendMessage отправляет текстовое сообщение в чат
 // chatID: ID получателя (пользователя или группы)
 // text: текст сообщения
 // replyMarkup: опциональная клавиатура (inline или обычная)
-func (c *BotHTTPClient) SendMessage(chatID int64, text string, replyMarkup interface{}) error {
+func (c *BotHTTPClient) SendMessage(ctx context.Context, chatID int64, text string, replyMarkup interface{}) error {
 	// Формируем URL для метода sendMessage
 	url := fmt.Sprintf("%s/sendMessage", c.baseURL)
 
@@ -102,11 +135,17 @@ func (c *BotHTTPClient) SendMessage(chatID int64, text string, replyMarkup inter
 		return err
 	}
 
-	// Отпрявляем запрос
-	resp, err := c.Http.Post(url, "application/json", bytes.NewBuffer(jsonBody))
+	// Отпрявляем запрос (контекст связывает запрос с трейсом update)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(jsonBody))
 	if err != nil {
 		return err
 	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.Http.Do(req)
+	if err != nil {
+		return fmt.Errorf("telegram sendMessage request failed: %w", withoutURL(err))
+	}
 
 	defer resp.Body.Close()
 
@@ -129,7 +168,7 @@ func (c *BotHTTPClient) SendMessage(chatID int64, text string, replyMarkup inter
 // SendOutgoingMessages конвертирует gRPC ответы в Telegram формат и отправляет
 // messages: массив исходящих сообщений от gRPC сервера
 // Это ключевой метод, связывающий gRPC сервер и Telegram API
-func (c *BotHTTPClient) SendOutgoingMessages(messages []*pb.OutgoingMessage) error {
+func (c *BotHTTPClient) SendOutgoingMessages(ctx context.Context, messages []*pb.OutgoingMessage) error {
 	// Проходим по всем сообщениям, которые нужно отправить
 	for _, msg := range messages {
 		var replyMarkup interface{}
@@ -146,9 +185,18 @@ func (c *BotHTTPClient) SendOutgoingMessages(messages []*pb.OutgoingMessage) err
 		}
 
 		// Отправляем сообщение через Telegram API
-		if err := c.SendMessage(msg.ChatId, msg.Text, replyMarkup); err != nil {
+		if err := c.SendMessage(ctx, msg.ChatId, msg.Text, replyMarkup); err != nil {
 			return err
 		}
 	}
 	return nil
 }
+
+// withoutURL убирает из ошибки net/http адрес запроса - в нём лежит токен бота
+func withoutURL(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err
+	}
+	return err
+}
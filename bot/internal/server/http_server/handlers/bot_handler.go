@@ -2,14 +2,23 @@ package handlers
 
 import (
 	"bot/internal/domain"
+	botmetrics "bot/internal/metrics"
 	"bot/internal/server/http_server/converter"
 	"bot/internal/server/service"
 	"context"
-	"log"
+	"errors"
+	"log/slog"
 	"net/http"
-	"time"
+	"pkg/logger"
+	"pkg/metrics"
+	"pkg/tracing"
+	"strings"
+
+	pb "global_models/grpc/bot"
 
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	tele "gopkg.in/telebot.v4"
 )
 
@@ -42,9 +51,34 @@ func (h *BotHttpHandler) HandleWebhook(c *gin.Context) {
 	// Шаг 1: Конвертируем Telegram формат в gRPC формат
 	grpcUpdate := converter.ConvertToGRPCUpdate(&update)
 
+	// все логи по update (в обоих сервисах) связываем по update_id
+	ctx := logger.WithCorrelationID(c.Request.Context(), logger.UpdateCorrelationID(grpcUpdate.UpdateId))
+	ctx, span := startUpdateSpan(ctx, "telegram.webhook", grpcUpdate)
+	defer span.End()
+
+	// замеряем обработку update (результат уточняется по ходу обработки)
+	observer := botmetrics.ObserveUpdate(updateType(grpcUpdate))
+	result := botmetrics.ResultError
+	defer func() { observer.Done(result) }()
+	if isStartCommand(grpcUpdate) {
+		// в режиме webhook /start уходит на сервер основной логики, но начало воронки считает шлюз
+		metrics.BusinessEvent(metrics.EventStart)
+	}
+
 	// Шаг 2: Отправляем на gRPC сервер для бизнес-логики
-	// c.Request.Context() передает контекст HTTP запроса в gRPC вызов
-	resp, err := h.BotService.ProcessUpdate(c.Request.Context(), grpcUpdate)
+	// ctx (на базе контекста HTTP запроса) передаётся в gRPC вызов
+	resp, err := h.BotService.ProcessUpdate(ctx, grpcUpdate)
+	if errors.Is(err, service.ErrUpdateQueued) {
+		// update сохранён и будет обработан позже - Telegram повторять его не нужно
+		if notice, ok := h.BotService.OfflineNotice(grpcUpdate); ok {
+			if err := h.BotService.SendHTTPMessages(ctx, []*pb.OutgoingMessage{notice}); err != nil {
+				slog.ErrorContext(ctx, "failed to send offline notice", "error", err)
+			}
+		}
+		result = botmetrics.ResultQueued
+		c.JSON(http.StatusOK, gin.H{"status": "queued"})
+		return
+	}
 	if err != nil {
 		// Ошибка связи с gRPC сервером
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -53,7 +87,8 @@ func (h *BotHttpHandler) HandleWebhook(c *gin.Context) {
 
 	// Шаг 3: Если сервер вернул сообщения для отправки - отправляем их в Telegram
 	if resp.Success && len(resp.Messages) > 0 {
-		if err := h.BotService.SendHTTPMessages(resp.Messages); err != nil {
+		if err := h.BotService.SendHTTPMessages(ctx, resp.Messages); err != nil {
+			slog.ErrorContext(ctx, "failed to send reply to telegram", "error", err)
 			// Важно: даже если не удалось отправить ответ, мы не возвращаем ошибку Telegram
 			// Иначе Telegram будет повторно отправлять тот же update
 			c.JSON(http.StatusOK, gin.H{"status": "processed but failed to send response"})
@@ -63,6 +98,7 @@ func (h *BotHttpHandler) HandleWebhook(c *gin.Context) {
 
 	// Успешная обработка - возвращаем 200 OK
 	// Telegram ожидает 200, чтобы не переотправлять update
+	result = botmetrics.ResultOK
 	c.JSON(http.StatusOK, gin.H{"status": "ok"})
 }
 
@@ -73,89 +109,118 @@ func (h *BotHttpHandler) HandleBotMessage(c tele.Context) error {
 	update, err := converter.ConvertToUpdate(c)
 	if err != nil {
 		// Логируем ошибку конвертации
-		log.Printf("❌ Ошибка конвертации update: %v", err)
+		slog.Error("failed to convert update", "error", err)
 		return c.Send("⚠️ Внутренняя ошибка формата")
 	}
 
-	// Создаём стандартный контекст для бизнес-логики
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
 	// Конвертируем Telegram формат в gRPC формат
 	grpcUpdate := converter.ConvertToGRPCUpdate(update)
 
+	// Создаём контекст для бизнес-логики с correlation id update
+	// (дедлайн вызова задаётся в конфиге grpc клиента - timeout)
+	ctx := logger.WithCorrelationID(context.Background(), logger.UpdateCorrelationID(grpcUpdate.UpdateId))
+	ctx, span := startUpdateSpan(ctx, "telegram.message", grpcUpdate)
+	defer span.End()
+
+	observer := botmetrics.ObserveUpdate(updateType(grpcUpdate))
+	result := botmetrics.ResultError
+	defer func() { observer.Done(result) }()
+
 	// Отправляем на gRPC сервер для бизнес-логики
 	// передает контекст логики в gRPC вызов
 	resp, err := h.BotService.ProcessUpdate(ctx, grpcUpdate)
+	if errors.Is(err, service.ErrUpdateQueued) {
+		// сервер недоступен, но сообщение сохранено - предупреждаем пользователя (один раз)
+		result = botmetrics.ResultQueued
+		if notice, ok := h.BotService.OfflineNotice(grpcUpdate); ok {
+			return c.Send(notice.Text)
+		}
+		return nil
+	}
 	if err != nil {
-		log.Printf("❌ Ошибка gRPC: %v", err)
+		slog.ErrorContext(ctx, "failed to process update", "error", err)
 
 		// Отправляем пользователю понятное сообщение
 		return c.Send("🔌 Сервер временно недоступен. Попробуйте позже.")
 	}
 
 	if !resp.Success {
-		log.Printf("⚠️ gRPC вернул ошибку: %v", resp.Error)
+		slog.WarnContext(ctx, "logic server returned error", "error", resp.Error)
 		return c.Send("⚠️ Не удалось обработать запрос")
 	}
 
 	// Если сервер вернул сообщения для отправки - отправляем их в Telegram
 	if len(resp.Messages) > 0 {
 		// тут вызывается http клиент из сервисного слоя и передаёт ответ боту
-		if err := h.BotService.SendHTTPMessages(resp.Messages); err != nil {
-			log.Printf("❌ Ошибка отправки через HTTP клиент: %v", err)
+		if err := h.BotService.SendHTTPMessages(ctx, resp.Messages); err != nil {
+			slog.ErrorContext(ctx, "failed to send reply to telegram", "error", err)
 			// Не возвращаем ошибку в Telegram, чтобы не было ретраев
 			c.Send("⚠️ Сообщение получено, но не доставлено")
 			return nil
 		}
 	}
+	result = botmetrics.ResultOK
 	return nil
 }
 
 // хэндлер для обработки callback-запросов от inline клавиатур от телеграмм бота в polling режиме
 func (h *BotHttpHandler) HandleBotCallback(c tele.Context) error {
-	log.Printf("📞 Получен callback: data=%s", c.Callback().Data)
-
 	// 1️⃣ Конвертируем
 	update, err := converter.ConvertToUpdate(c)
 	if err != nil {
-		log.Printf("❌ Ошибка конвертации: %v", err)
+		slog.Error("failed to convert callback", "error", err)
 		c.Respond(&tele.CallbackResponse{
 			Text: "❌ Ошибка",
 		})
 		return err
 	}
 
-	// 2️⃣ Создаём контекст с таймаутом
+	// 2️⃣ Конвертируем в gRPC формат
 	grpcUpdate := converter.ConvertToGRPCUpdate(update)
 
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
+	// дедлайн вызова задаётся в конфиге grpc клиента - timeout
+	ctx := logger.WithCorrelationID(context.Background(), logger.UpdateCorrelationID(grpcUpdate.UpdateId))
+	ctx, span := startUpdateSpan(ctx, "telegram.callback", grpcUpdate)
+	defer span.End()
+
+	observer := botmetrics.ObserveUpdate(botmetrics.UpdateCallback)
+	result := botmetrics.ResultError
+	defer func() { observer.Done(result) }()
+	slog.InfoContext(ctx, "callback received", "data", c.Callback().Data)
 
 	// 3️⃣ Отправляем запрос
 	resp, err := h.BotService.ProcessUpdate(ctx, grpcUpdate)
 
 	// 4️⃣ Отвечаем на callback (всегда!)
+	if errors.Is(err, service.ErrUpdateQueued) {
+		// нажатие сохранено и будет обработано, когда сервер станет доступен
+		result = botmetrics.ResultQueued
+		text := "⏳ Запрос принят"
+		if notice, ok := h.BotService.OfflineNotice(grpcUpdate); ok {
+			text = notice.Text
+		}
+		return c.Respond(&tele.CallbackResponse{
+			Text: text,
+		})
+	}
 	if err != nil {
-		log.Printf("❌ Ошибка gRPC: %v", err)
+		slog.ErrorContext(ctx, "failed to process callback", "error", err)
 		return c.Respond(&tele.CallbackResponse{
 			Text: "❌ Сервер недоступен",
 		})
 	}
 
 	if !resp.Success {
-		log.Printf("⚠️ Ошибка: %v", resp.Error)
+		slog.WarnContext(ctx, "logic server returned error", "error", resp.Error)
 		return c.Respond(&tele.CallbackResponse{
 			Text: "⚠️ " + resp.Error,
 		})
 	}
 
-	log.Println(resp.Messages)
-
 	// 5️⃣ Отправляем сообщения
 	if len(resp.Messages) > 0 {
-		if err := h.BotService.SendHTTPMessages(resp.Messages); err != nil {
-			log.Printf("❌ Ошибка отправки: %v", err)
+		if err := h.BotService.SendHTTPMessages(ctx, resp.Messages); err != nil {
+			slog.ErrorContext(ctx, "failed to send reply to telegram", "error", err)
 			return c.Respond(&tele.CallbackResponse{
 				Text: "⚠️ Частичный успех",
 			})
@@ -163,7 +228,38 @@ func (h *BotHttpHandler) HandleBotCallback(c tele.Context) error {
 	}
 
 	// 6️⃣ Успех!
+	result = botmetrics.ResultOK
 	return c.Respond(&tele.CallbackResponse{
 		Text: "✓ Готово!",
 	})
 }
+
+// функция для открытия корневого спана обработки update - с него начинается трейс,
+// который продолжается на сервере основной логики, в БД, кэше и при отправке ответа в Telegram
+func startUpdateSpan(ctx context.Context, name string, update *pb.UpdateRequest) (context.Context, trace.Span) {
+	return tracing.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(attribute.Int64("telegram.update_id", update.UpdateId)),
+	)
+}
+
+// функция для определения типа update (метка type в метриках)
+func updateType(update *pb.UpdateRequest) string {
+	switch {
+	case update.CallbackQuery != nil:
+		return botmetrics.UpdateCallback
+	case update.Message != nil && strings.HasPrefix(update.Message.Text, "/"):
+		return botmetrics.UpdateCommand
+	default:
+		return botmetrics.UpdateMessage
+	}
+}
+
+// функция для проверки, что update - команда /start (в том числе с параметром: "/start menu")
+func isStartCommand(update *pb.UpdateRequest) bool {
+	if update.Message == nil {
+		return false
+	}
+	command := strings.Fields(update.Message.Text)
+	return len(command) > 0 && (command[0] == "/start" || strings.HasPrefix(command[0], "/start@"))
+}
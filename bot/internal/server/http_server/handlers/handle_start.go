@@ -1,6 +1,12 @@
 package handlers
 
 import (
+	botmetrics "bot/internal/metrics"
+	"bot/internal/server/http_server/converter"
+	"context"
+	"log/slog"
+	"pkg/logger"
+	"pkg/metrics"
 	"strings"
 
 	tele "gopkg.in/telebot.v4"
@@ -8,6 +14,9 @@ import (
 
 // хэндлер для обработки команды /start от телеграмм бота в polling режиме
 func (h *BotHttpHandler) HandleBotStart(c tele.Context) error {
+	// на /start отвечает шлюз (сервер основной логики только получает его для учёта), поэтому метрику начала воронки считаем здесь
+	observer := botmetrics.ObserveUpdate(botmetrics.UpdateCommand)
+	metrics.BusinessEvent(metrics.EventStart)
 
 	args := strings.Fields(c.Text())
 
@@ -42,8 +51,37 @@ func (h *BotHttpHandler) HandleBotStart(c tele.Context) error {
 				{Text: "🏠 Главное меню"},
 				{Text: "❓ Помощь"},
 			},
+			{
+				{Text: "🙋 Позвать мастера"}, // сервер основной логики переводит чат на мастера
+			},
 		},
 	}
 
-	return c.Send(welcomeMsg, replyMarkup)
+	if err := c.Send(welcomeMsg, replyMarkup); err != nil {
+		observer.Done(botmetrics.ResultError)
+		return err
+	}
+	observer.Done(botmetrics.ResultOK)
+
+	h.recordStart(c)
+	return nil
+}
+
+// метод передачи /start (с параметром - источником прихода) серверу основной логики:
+// на команду ответил шлюз, но журнал сообщений и воронка аналитики ведутся на сервере
+func (h *BotHttpHandler) recordStart(c tele.Context) {
+	update, err := converter.ConvertToUpdate(c)
+	if err != nil {
+		slog.Error("failed to convert /start", "error", err)
+		return
+	}
+	grpcUpdate := converter.ConvertToGRPCUpdate(update)
+
+	ctx := logger.WithCorrelationID(context.Background(), logger.UpdateCorrelationID(grpcUpdate.UpdateId))
+	ctx, span := startUpdateSpan(ctx, "telegram.start", grpcUpdate)
+	defer span.End()
+
+	if err := h.BotService.RecordUpdate(ctx, grpcUpdate); err != nil {
+		slog.WarnContext(ctx, "failed to record /start on logic server", "error", err)
+	}
 }
@@ -2,11 +2,14 @@ package httpserver
 
 import (
 	"bot/internal/config"
+	botmetrics "bot/internal/metrics"
 	"bot/internal/server/http_server/handlers"
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
+	"pkg/health"
+	"pkg/metrics"
 	"pkg/middleware"
 	"sync"
 	"time"
@@ -22,6 +25,7 @@ type BotGateway struct {
 	config     *config.BotHttpServerConfig // конфиг http сервера на базе общего конфига
 	botConfig  *config.BotConfig           // конфиг бота
 	Handler    *handlers.BotHttpHandler    // хэндлер
+	health     *health.Checker             // проверки здоровья (/healthz, /readyz)
 	stopChan   chan struct{}               // канал для синхронизации горутин
 
 	// Добавляем поля для Telegram бота (этот бот будет использоваться только в longpolling режиме)
@@ -32,7 +36,7 @@ type BotGateway struct {
 }
 
 // Конструктор для сервера
-func NewBotGateway(ctx context.Context, config *config.BotHttpServerConfig, botConf *config.BotConfig, handler *handlers.BotHttpHandler) (*BotGateway, error) {
+func NewBotGateway(ctx context.Context, config *config.BotHttpServerConfig, botConf *config.BotConfig, handler *handlers.BotHttpHandler, checker *health.Checker) (*BotGateway, error) {
 	// создаём экземпляр роутера
 	router := gin.Default()
 	err := router.SetTrustedProxies(nil)
@@ -40,12 +44,8 @@ func NewBotGateway(ctx context.Context, config *config.BotHttpServerConfig, botC
 		return nil, err
 	}
 
-	// Добавляем middleware для проброса контекста
-	router.Use(func(c *gin.Context) {
-		ctx := context.WithValue(c.Request.Context(), "request_id", c.GetHeader("X-Request-ID"))
-		c.Request = c.Request.WithContext(ctx)
-		c.Next()
-	})
+	// Добавляем middleware для correlation ID (X-Request-ID) в контексте запроса
+	router.Use(middleware.RequestIDMiddleware())
 
 	router.Use(middleware.CORSMiddleware()) // используем для всех маршруторв работу с CORS
 
@@ -54,6 +54,7 @@ func NewBotGateway(ctx context.Context, config *config.BotHttpServerConfig, botC
 		config:    config,
 		botConfig: botConf,
 		Handler:   handler,
+		health:    checker,
 		stopChan:  make(chan struct{}),
 	}, nil
 }
@@ -72,6 +73,8 @@ func (a *BotGateway) SetUpPollingRoutes() error {
 	pref := tele.Settings{
 		Token:  a.botConfig.BotToken,                        // добавьте это поле в ваш конфиг
 		Poller: &tele.LongPoller{Timeout: 30 * time.Second}, // интервал запросов к телеграмм на обновления
+		// клиент по умолчанию telebot, но с метриками обращений к Telegram API (таймаут больше таймаута long polling)
+		Client: &http.Client{Timeout: time.Minute, Transport: botmetrics.TelegramTransport(nil)},
 	}
 
 	// Создаём бота
@@ -90,7 +93,7 @@ func (a *BotGateway) SetUpPollingRoutes() error {
 	a.botWg.Add(1) // добавляем 1 горутину в вэйт группу
 	go a.runBot()  // запускаем бот в отдельной горутине
 
-	log.Println("Long polling бот успешно запущен в фоновом режиме")
+	slog.Info("long polling bot started in background")
 	return nil
 }
 
@@ -118,7 +121,7 @@ func (a *BotGateway) registerBotHandlers() {
 func (a *BotGateway) runBot() {
 	defer a.botWg.Done()
 
-	log.Println("Telegram bot (long polling) started")
+	slog.Info("telegram bot (long polling) started")
 
 	// Запускаем бота. Start() блокируется, поэтому мы в горутине
 	go func() {
@@ -128,18 +131,26 @@ func (a *BotGateway) runBot() {
 	// Ожидаем сигнала завершения
 	select {
 	case <-a.botCtx.Done():
-		log.Println("Получен сигнал остановки бота")
+		slog.Info("bot stop signal received")
 	case <-a.stopChan:
-		log.Println("Получен сигнал остановки сервера")
+		slog.Info("server stop signal received")
 	}
 
 	// Останавливаем бота корректно
 	a.telegramBot.Stop()
-	log.Println("Telegram bot (long polling) stopped")
+	slog.Info("telegram bot (long polling) stopped")
 }
 
 // Метод для запуска сервера
 func (a *BotGateway) Run() error {
+	// пробы для оркестратора доступны в любом режиме
+	if a.health != nil {
+		a.health.RegisterRoutes(a.router)
+	}
+
+	// метрики prometheus
+	metrics.RegisterRoutes(a.router)
+
 	switch a.config.Mode {
 	case "webhook":
 		a.SetUpWebHookRoutes()
@@ -154,13 +165,13 @@ func (a *BotGateway) Run() error {
 	}
 	// Используем обычный порт для HTTP
 	a.httpServer.Addr = a.config.Addr()
-	log.Printf("Starting HTTP server on %s in %s mode", a.config.Addr(), a.config.Mode)
+	slog.Info("starting HTTP server", "addr", a.config.Addr(), "mode", a.config.Mode)
 	return a.httpServer.ListenAndServe()
 }
 
 // Метод для graceful shutdown
 func (a *BotGateway) Shutdown(ctx context.Context) error {
-	log.Println("Начинаем graceful shutdown...")
+	slog.Info("graceful shutdown started")
 
 	// 1️⃣ Сначала закрываем HTTP сервер (перестаем принимать новые запросы)
 	// Это важно сделать первым, чтобы новые запросы не пошли в уже закрывающиеся клиенты
@@ -170,7 +181,7 @@ func (a *BotGateway) Shutdown(ctx context.Context) error {
 
 	// 2️⃣ Если бот запущен в polling режиме, останавливаем его
 	if a.telegramBot != nil {
-		log.Println("Останавливаем Telegram бота...")
+		slog.Info("stopping telegram bot")
 		a.botCancel() // Отправляем сигнал остановки
 
 		// Ждём завершения с таймаутом
@@ -182,9 +193,9 @@ func (a *BotGateway) Shutdown(ctx context.Context) error {
 
 		select {
 		case <-done:
-			log.Println("Telegram бот остановлен")
+			slog.Info("telegram bot stopped")
 		case <-time.After(5 * time.Second):
-			log.Println("Таймаут при остановке бота")
+			slog.Warn("timeout while stopping telegram bot")
 		}
 	}
 
@@ -194,9 +205,6 @@ func (a *BotGateway) Shutdown(ctx context.Context) error {
 	//  Даем время завершить текущие операции (например, отправку сообщений)
 	time.Sleep(1 * time.Second)
 
-	//  Для Telegram клиента - просто логируем (можно ничего не делать)
-	log.Println("Telegram клиент: ресурсы будут очищены сборщиком мусора")
-
-	log.Println("HTTP BOT Server shutdown completed")
+	slog.Info("HTTP bot server shutdown completed")
 	return nil
 }
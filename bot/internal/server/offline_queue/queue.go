@@ -0,0 +1,322 @@
+// Пакет offlinequeue - локальная устойчивая к перезапускам очередь update,
+// которые не удалось передать серверу основной логики.
+//
+// Очередь хранится в двух файлах:
+//   - журнал (path): первая строка - заголовок "#<id журнала>", дальше одна строка на update
+//     (base64 от protobuf сериализации UpdateRequest). Добавление дописывает строку в конец и делает fsync;
+//   - позиция чтения (path + ".offset"): "<id журнала> <смещение первого необработанного update>".
+//     Удаление первого элемента только сдвигает позицию - O(1) независимо от размера очереди.
+//
+// Прочитанная часть журнала отбрасывается, когда очередь опустела или прочитанное заняло больше половины
+// журнала: оставшиеся update переписываются в новый журнал с новым id. Если процесс упал между заменой журнала
+// и записью позиции, id в позиции не совпадёт с журналом и чтение начнётся с его начала - в нём уже только
+// необработанные update. Update, удалённый из очереди непосредственно перед падением, может быть отправлен повторно.
+package offlinequeue
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	pb "global_models/grpc/bot"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// ErrQueueFull - очередь заполнена, update не сохранён
+var ErrQueueFull = errors.New("offline queue is full")
+
+// журнал переписывается не раньше, чем прочитанная часть дорастёт до этого размера
+const compactMinBytes = 1 << 20
+
+// entry - update в очереди и позиция в журнале сразу после его строки
+type entry struct {
+	req *pb.UpdateRequest
+	end int64
+}
+
+// FileQueue - FIFO очередь update на базе журнала с сохраняемой позицией чтения
+type FileQueue struct {
+	mu      sync.Mutex
+	path    string  // путь к журналу очереди
+	maxSize int     // максимальное количество элементов (0 - без ограничений)
+	logID   string  // id текущего журнала
+	start   int64   // начало данных журнала (после заголовка)
+	offset  int64   // позиция первого необработанного update
+	size    int64   // размер журнала
+	items   []entry // необработанные update в памяти
+}
+
+// конструктор очереди: загружает ранее сохранённые update из журнала (если он есть)
+func NewFileQueue(path string, maxSize int) (*FileQueue, error) {
+	if path == "" {
+		return nil, fmt.Errorf("queue path cannot be empty")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create queue directory: %w", err)
+	}
+
+	q := &FileQueue{
+		path:    path,
+		maxSize: maxSize,
+	}
+
+	if err := q.load(); err != nil {
+		return nil, err
+	}
+	if q.logID == "" {
+		// журнала ещё нет или он старого формата - создаём журнал с заголовком
+		if err := q.compact(q.items); err != nil {
+			return nil, err
+		}
+	}
+
+	return q, nil
+}
+
+// Push добавляет update в конец очереди
+func (q *FileQueue) Push(req *pb.UpdateRequest) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.maxSize > 0 && len(q.items) >= q.maxSize {
+		return ErrQueueFull
+	}
+
+	line, err := encode(req)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(q.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open queue file: %w", err)
+	}
+	defer file.Close()
+
+	n, err := file.WriteString(line + "\n")
+	if err != nil {
+		return fmt.Errorf("failed to write queue file: %w", err)
+	}
+	if err := file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync queue file: %w", err)
+	}
+
+	q.size += int64(n)
+	q.items = append(q.items, entry{req: req, end: q.size})
+	return nil
+}
+
+// Peek возвращает первый update, не удаляя его из очереди
+func (q *FileQueue) Peek() (*pb.UpdateRequest, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.items) == 0 {
+		return nil, false
+	}
+	return q.items[0].req, true
+}
+
+// Pop удаляет первый update из очереди
+func (q *FileQueue) Pop() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.items) == 0 {
+		return nil
+	}
+
+	next := q.items[0].end
+	rest := q.items[1:]
+
+	// прочитанное больше не нужно: очередь пуста или журнал наполовину из обработанных update
+	read := next - q.start
+	if len(rest) == 0 || (read >= compactMinBytes && read > q.size-next) {
+		return q.compact(rest)
+	}
+
+	if err := q.writeOffset(q.logID, next); err != nil {
+		return err
+	}
+	q.items, q.offset = rest, next
+	return nil
+}
+
+// Len возвращает количество update в очереди
+func (q *FileQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.items)
+}
+
+// offsetPath возвращает путь к файлу позиции чтения
+func (q *FileQueue) offsetPath() string {
+	return q.path + ".offset"
+}
+
+// load читает журнал с сохранённой позиции (вызывается из конструктора)
+func (q *FileQueue) load() error {
+	data, err := os.ReadFile(q.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read queue file: %w", err)
+	}
+
+	// оборванную при падении последнюю строку отрезаем, иначе следующая запись склеится с ней
+	if complete := bytes.LastIndexByte(data, '\n') + 1; complete < len(data) {
+		if err := os.Truncate(q.path, int64(complete)); err != nil {
+			return fmt.Errorf("failed to truncate torn queue record: %w", err)
+		}
+		data = data[:complete]
+	}
+	q.size = int64(len(data))
+
+	// заголовок есть у журналов этого формата; в старом формате (без позиции чтения) данные идут с начала
+	if header, _, ok := bytes.Cut(data, []byte("\n")); ok && bytes.HasPrefix(header, []byte("#")) {
+		q.logID = string(header[1:])
+		q.start = int64(len(header) + 1)
+	}
+
+	q.offset = q.start
+	if id, offset, ok := q.readOffset(); ok && id == q.logID && offset >= q.start && offset <= q.size {
+		q.offset = offset
+	}
+
+	reader := bufio.NewReader(bytes.NewReader(data[q.offset:]))
+	pos := q.offset
+	for {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		pos += int64(len(line))
+
+		req, err := decode(string(bytes.TrimSuffix(line, []byte("\n"))))
+		if err != nil {
+			// повреждённую строку пропускаем
+			continue
+		}
+		q.items = append(q.items, entry{req: req, end: pos})
+	}
+	return nil
+}
+
+// readOffset читает сохранённую позицию чтения (false - её нет или она повреждена)
+func (q *FileQueue) readOffset() (string, int64, bool) {
+	data, err := os.ReadFile(q.offsetPath())
+	if err != nil {
+		return "", 0, false
+	}
+	id, value, ok := strings.Cut(strings.TrimSpace(string(data)), " ")
+	if !ok {
+		return "", 0, false
+	}
+	offset, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return "", 0, false
+	}
+	return id, offset, true
+}
+
+// writeOffset атомарно сохраняет позицию чтения (вызывается под мьютексом)
+func (q *FileQueue) writeOffset(id string, offset int64) error {
+	return writeFileAtomic(q.offsetPath(), []byte(id+" "+strconv.FormatInt(offset, 10)+"\n"))
+}
+
+// compact переписывает items (необработанные update) в новый журнал и сбрасывает позицию чтения
+// (вызывается под мьютексом)
+func (q *FileQueue) compact(items []entry) error {
+	random := make([]byte, 8)
+	if _, err := rand.Read(random); err != nil {
+		return fmt.Errorf("failed to generate queue id: %w", err)
+	}
+	logID := hex.EncodeToString(random)
+
+	var buf bytes.Buffer
+	buf.WriteString("#" + logID + "\n")
+	start := int64(buf.Len())
+	ends := make([]int64, len(items))
+	for i, item := range items {
+		line, err := encode(item.req)
+		if err != nil {
+			return err
+		}
+		buf.WriteString(line + "\n")
+		ends[i] = int64(buf.Len())
+	}
+
+	if err := writeFileAtomic(q.path, buf.Bytes()); err != nil {
+		return err
+	}
+	q.items = make([]entry, len(items))
+	for i, item := range items {
+		q.items[i] = entry{req: item.req, end: ends[i]}
+	}
+	q.logID, q.start, q.offset, q.size = logID, start, start, int64(buf.Len())
+
+	// журнал уже заменён; без записанной позиции id не совпадёт, и чтение начнётся с начала нового журнала
+	return q.writeOffset(logID, start)
+}
+
+// writeFileAtomic атомарно заменяет содержимое файла (временный файл + fsync + rename)
+func writeFileAtomic(path string, data []byte) error {
+	tmpPath := path + ".tmp"
+
+	file, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to create temp queue file: %w", err)
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write temp queue file: %w", err)
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to sync temp queue file: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to close temp queue file: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to replace queue file: %w", err)
+	}
+	return nil
+}
+
+// encode сериализует update в строку файла очереди
+func encode(req *pb.UpdateRequest) (string, error) {
+	data, err := proto.Marshal(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal update: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(data), nil
+}
+
+// decode восстанавливает update из строки файла очереди
+func decode(line string) (*pb.UpdateRequest, error) {
+	data, err := base64.StdEncoding.DecodeString(line)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &pb.UpdateRequest{}
+	if err := proto.Unmarshal(data, req); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
@@ -0,0 +1,205 @@
+package offlinequeue
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	pb "global_models/grpc/bot"
+)
+
+// функция создания очереди в каталоге теста
+func newTestQueue(t *testing.T, path string, maxSize int) *FileQueue {
+	t.Helper()
+	q, err := NewFileQueue(path, maxSize)
+	if err != nil {
+		t.Fatalf("NewFileQueue: %v", err)
+	}
+	return q
+}
+
+// функция добавления update с указанными id
+func pushAll(t *testing.T, q *FileQueue, ids ...int64) {
+	t.Helper()
+	for _, id := range ids {
+		if err := q.Push(&pb.UpdateRequest{UpdateId: id}); err != nil {
+			t.Fatalf("Push(%d): %v", id, err)
+		}
+	}
+}
+
+// функция извлечения всех update по порядку
+func drain(t *testing.T, q *FileQueue) []int64 {
+	t.Helper()
+	var ids []int64
+	for {
+		req, ok := q.Peek()
+		if !ok {
+			return ids
+		}
+		ids = append(ids, req.UpdateId)
+		if err := q.Pop(); err != nil {
+			t.Fatalf("Pop: %v", err)
+		}
+	}
+}
+
+// функция сравнения порядка update
+func assertIDs(t *testing.T, got []int64, want ...int64) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("получили %v, ожидали %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("получили %v, ожидали %v", got, want)
+		}
+	}
+}
+
+func TestFileQueue(t *testing.T) {
+	t.Run("порядок добавления сохраняется", func(t *testing.T) {
+		q := newTestQueue(t, filepath.Join(t.TempDir(), "q"), 0)
+		pushAll(t, q, 1, 2, 3)
+		if q.Len() != 3 {
+			t.Fatalf("Len = %d", q.Len())
+		}
+		assertIDs(t, drain(t, q), 1, 2, 3)
+		if err := q.Pop(); err != nil {
+			t.Errorf("Pop пустой очереди: %v", err)
+		}
+	})
+
+	t.Run("очередь и позиция чтения переживают перезапуск", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "data", "q")
+		q := newTestQueue(t, path, 0)
+		pushAll(t, q, 1, 2, 3, 4)
+		for range 2 {
+			if err := q.Pop(); err != nil {
+				t.Fatalf("Pop: %v", err)
+			}
+		}
+
+		restarted := newTestQueue(t, path, 0)
+		pushAll(t, restarted, 5)
+		assertIDs(t, drain(t, restarted), 3, 4, 5)
+
+		if empty := newTestQueue(t, path, 0); empty.Len() != 0 {
+			t.Errorf("после опустошения очередь пуста и после перезапуска: %d", empty.Len())
+		}
+	})
+
+	t.Run("удаление не переписывает журнал", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "q")
+		q := newTestQueue(t, path, 0)
+		pushAll(t, q, 1, 2, 3)
+		before, _ := os.ReadFile(path)
+
+		if err := q.Pop(); err != nil {
+			t.Fatalf("Pop: %v", err)
+		}
+		after, _ := os.ReadFile(path)
+		if string(before) != string(after) {
+			t.Error("Pop изменил журнал - должна сдвигаться только позиция чтения")
+		}
+	})
+
+	t.Run("прочитанная часть журнала отбрасывается", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "q")
+		q := newTestQueue(t, path, 0)
+		payload := strings.Repeat("x", 64*1024)
+		for i := range 40 {
+			if err := q.Push(&pb.UpdateRequest{UpdateId: int64(i), Message: &pb.Message{Text: payload}}); err != nil {
+				t.Fatalf("Push: %v", err)
+			}
+		}
+		full, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("Stat: %v", err)
+		}
+		for range 30 {
+			if err := q.Pop(); err != nil {
+				t.Fatalf("Pop: %v", err)
+			}
+		}
+
+		// после сжатия в журнале только необработанные update и прочитанное после сжатия
+		compacted, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("Stat: %v", err)
+		}
+		if compacted.Size() > full.Size()/2 {
+			t.Errorf("журнал не сжат: %d байт из %d для %d update", compacted.Size(), full.Size(), q.Len())
+		}
+		restarted := newTestQueue(t, path, 0)
+		ids := drain(t, restarted)
+		if len(ids) != 10 || ids[0] != 30 || ids[9] != 39 {
+			t.Errorf("после сжатия: %v", ids)
+		}
+	})
+
+	t.Run("заполненная очередь не принимает update", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "q")
+		q := newTestQueue(t, path, 2)
+		pushAll(t, q, 1, 2)
+		if err := q.Push(&pb.UpdateRequest{UpdateId: 3}); !errors.Is(err, ErrQueueFull) {
+			t.Fatalf("ожидали ErrQueueFull, получили %v", err)
+		}
+
+		if err := q.Pop(); err != nil {
+			t.Fatalf("Pop: %v", err)
+		}
+		pushAll(t, q, 3)
+		assertIDs(t, drain(t, newTestQueue(t, path, 2)), 2, 3)
+	})
+
+	t.Run("оборванная запись отрезается при загрузке", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "q")
+		q := newTestQueue(t, path, 0)
+		pushAll(t, q, 1)
+		file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o600)
+		if err != nil {
+			t.Fatalf("open: %v", err)
+		}
+		file.WriteString("CgIIAg") // строка без перевода строки - падение посреди записи
+		file.Close()
+
+		restarted := newTestQueue(t, path, 0)
+		pushAll(t, restarted, 2)
+		assertIDs(t, drain(t, newTestQueue(t, path, 0)), 1, 2)
+	})
+
+	t.Run("позиция от другого журнала не применяется", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "q")
+		q := newTestQueue(t, path, 0)
+		pushAll(t, q, 1, 2)
+		// падение между заменой журнала и записью позиции: позиция осталась от старого журнала
+		if err := os.WriteFile(path+".offset", []byte("0000 999\n"), 0o600); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+		assertIDs(t, drain(t, newTestQueue(t, path, 0)), 1, 2)
+	})
+
+	t.Run("журнал старого формата читается целиком", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "q")
+		var lines []string
+		for _, id := range []int64{7, 8} {
+			line, err := encode(&pb.UpdateRequest{UpdateId: id})
+			if err != nil {
+				t.Fatalf("encode: %v", err)
+			}
+			lines = append(lines, line)
+		}
+		if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o600); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+
+		q := newTestQueue(t, path, 0)
+		if err := q.Pop(); err != nil {
+			t.Fatalf("Pop: %v", err)
+		}
+		assertIDs(t, drain(t, newTestQueue(t, path, 0)), 8)
+	})
+}
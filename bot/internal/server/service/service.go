@@ -1,41 +1,197 @@
 package service
 
 import (
+	"bot/internal/config"
 	grpcclient "bot/internal/server/grpc_client"
 	httpclient "bot/internal/server/http_client"
+	offlinequeue "bot/internal/server/offline_queue"
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"pkg/logger"
+	"sync"
+	"time"
 
 	pb "global_models/grpc/bot"
 )
 
+// ErrUpdateQueued - сервер основной логики недоступен, update сохранён в локальную очередь
+// и будет обработан, когда связь восстановится
+var ErrUpdateQueued = errors.New("logic server is unavailable, update queued")
+
+// ErrServerUnavailable - сервер основной логики недоступен, а сохранить update не удалось
+var ErrServerUnavailable = errors.New("logic server is unavailable")
+
 // структура сервисного слоя бота
 type BotService struct {
 	grpcClient *grpcclient.BotGrpcClient // Для отправки данных в gRPC сервер
 	hTTPClient *httpclient.BotHTTPClient // Для отправки ответов в Telegram
+
+	// офлайн режим
+	queue         *offlinequeue.FileQueue   // update, которые не удалось передать серверу
+	offlineConf   *config.OfflineModeConfig // настройки офлайн режима
+	notifiedMu    sync.Mutex
+	notifiedUsers map[int64]struct{} // пользователи, которым уже отправлен офлайн ответ
 }
 
 // конструктор для создания сервисного слоя бота
-func NewBotService(grpcClient *grpcclient.BotGrpcClient, tgClient *httpclient.BotHTTPClient) *BotService {
+func NewBotService(grpcClient *grpcclient.BotGrpcClient, tgClient *httpclient.BotHTTPClient, queue *offlinequeue.FileQueue, offlineConf *config.OfflineModeConfig) *BotService {
 	return &BotService{
-		grpcClient: grpcClient,
-		hTTPClient: tgClient,
+		grpcClient:    grpcClient,
+		hTTPClient:    tgClient,
+		queue:         queue,
+		offlineConf:   offlineConf,
+		notifiedUsers: make(map[int64]struct{}),
 	}
 }
 
 // метод сервисного слоя бота для обработки обновелния от телеграмм и отправки ответа
 func (b *BotService) ProcessUpdate(ctx context.Context, req *pb.UpdateRequest) (*pb.UpdateResponse, error) {
+	// пока в очереди есть необработанные update, новые ставим за ними, чтобы сохранить порядок
+	if b.queue.Len() > 0 {
+		return nil, b.enqueue(ctx, req)
+	}
+
 	resp, err := b.grpcClient.ProcessUpdate(ctx, req)
 	if err != nil {
-		log.Printf("Ошибка от GRPC сервера: %v\n", err)
-		return nil, fmt.Errorf("Ошибка связи с gRPC сервером")
+		if grpcclient.IsUnavailable(err) {
+			slog.WarnContext(ctx, "logic server unavailable, update queued", "update_id", req.UpdateId, "error", err)
+			return nil, b.enqueue(ctx, req)
+		}
+		return nil, fmt.Errorf("logic server error: %w", err)
 	}
 
 	return resp, nil
 }
 
+// метод передачи update серверу основной логики только для учёта: ответ сервера не отправляется
+// (в режиме polling на /start отвечает шлюз, а журнал и аналитика ведутся на сервере).
+// При недоступности сервера update не ставится в очередь: при повторной отправке клиент получил бы второй ответ
+func (b *BotService) RecordUpdate(ctx context.Context, req *pb.UpdateRequest) error {
+	if _, err := b.grpcClient.ProcessUpdate(ctx, req); err != nil {
+		return fmt.Errorf("logic server error: %w", err)
+	}
+	return nil
+}
+
 // метод сервисного слоя бота для отправки обработанных сообщений по http
-func (b *BotService) SendHTTPMessages(msgs []*pb.OutgoingMessage) error {
-	return b.hTTPClient.SendOutgoingMessages(msgs)
+func (b *BotService) SendHTTPMessages(ctx context.Context, msgs []*pb.OutgoingMessage) error {
+	return b.hTTPClient.SendOutgoingMessages(ctx, msgs)
+}
+
+// метод сервисного слоя бота для отправки сообщения по запросу сервера основной логики (сообщения из админки).
+// Ошибку Telegram возвращаем в ответе: сервер сохранит сообщение со статусом failed
+func (b *BotService) SendMessage(ctx context.Context, req *pb.SendMessageRequest) *pb.SendMessageResponse {
+	msg := &pb.OutgoingMessage{ChatId: req.ChatId, Text: req.Text, ReplyMarkup: req.ReplyMarkup}
+	if err := b.hTTPClient.SendOutgoingMessages(ctx, []*pb.OutgoingMessage{msg}); err != nil {
+		slog.WarnContext(ctx, "failed to send message from logic server", "chat_id", logger.MaskID(req.ChatId), "error", err)
+		return &pb.SendMessageResponse{Success: false, Error: err.Error()}
+	}
+	return &pb.SendMessageResponse{Success: true}
+}
+
+// OfflineNotice возвращает ответ пользователю о недоступности сервера.
+// Каждому пользователю ответ отправляется не больше одного раза за время недоступности
+func (b *BotService) OfflineNotice(req *pb.UpdateRequest) (*pb.OutgoingMessage, bool) {
+	userID, chatID := updateParticipants(req)
+	if userID == 0 || b.offlineConf.OfflineReply == "" {
+		return nil, false
+	}
+
+	b.notifiedMu.Lock()
+	defer b.notifiedMu.Unlock()
+
+	if _, ok := b.notifiedUsers[userID]; ok {
+		return nil, false
+	}
+	b.notifiedUsers[userID] = struct{}{}
+
+	return &pb.OutgoingMessage{
+		ChatId: chatID,
+		Text:   b.offlineConf.OfflineReply,
+	}, true
+}
+
+// RunReplay периодически отправляет накопленные update на сервер (блокирующий метод, запускать в горутине)
+func (b *BotService) RunReplay(ctx context.Context) {
+	ticker := time.NewTicker(b.offlineConf.ReplayInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			b.replayQueued(ctx)
+		}
+	}
+}
+
+// метод для отправки накопленных update по порядку, пока сервер отвечает
+func (b *BotService) replayQueued(ctx context.Context) {
+	for ctx.Err() == nil {
+		req, ok := b.queue.Peek()
+		if !ok {
+			// очередь пуста - связь восстановлена, при следующем сбое снова предупредим пользователей
+			b.resetOfflineNotices()
+			return
+		}
+
+		// у каждого update из очереди свой correlation id - тот же, что был бы при обычной обработке
+		updCtx := logger.WithCorrelationID(ctx, logger.UpdateCorrelationID(req.UpdateId))
+
+		replayCtx, cancel := context.WithTimeout(updCtx, b.offlineConf.ReplayTimeout)
+		resp, err := b.grpcClient.ProcessUpdate(replayCtx, req)
+		cancel()
+
+		if grpcclient.IsUnavailable(err) {
+			// сервер всё ещё недоступен - попробуем на следующем тике
+			return
+		}
+
+		if err != nil {
+			// сервер отклонил update - повторять его бессмысленно
+			slog.WarnContext(updCtx, "queued update rejected by server and dropped", "update_id", req.UpdateId, "error", err)
+		} else if resp.Success && len(resp.Messages) > 0 {
+			if err := b.SendHTTPMessages(updCtx, resp.Messages); err != nil {
+				slog.ErrorContext(updCtx, "failed to send reply for queued update", "update_id", req.UpdateId, "error", err)
+			}
+		}
+
+		if err := b.queue.Pop(); err != nil {
+			slog.ErrorContext(updCtx, "failed to remove update from queue", "update_id", req.UpdateId, "error", err)
+			return
+		}
+	}
+}
+
+// метод для сохранения update в очередь
+func (b *BotService) enqueue(ctx context.Context, req *pb.UpdateRequest) error {
+	if err := b.queue.Push(req); err != nil {
+		slog.ErrorContext(ctx, "failed to queue update", "update_id", req.UpdateId, "error", err)
+		return fmt.Errorf("%w: %w", ErrServerUnavailable, err)
+	}
+	return ErrUpdateQueued
+}
+
+// метод для сброса списка пользователей, получивших офлайн ответ
+func (b *BotService) resetOfflineNotices() {
+	b.notifiedMu.Lock()
+	defer b.notifiedMu.Unlock()
+
+	if len(b.notifiedUsers) > 0 {
+		b.notifiedUsers = make(map[int64]struct{})
+	}
+}
+
+// updateParticipants возвращает ID пользователя и чата из update
+func updateParticipants(req *pb.UpdateRequest) (userID, chatID int64) {
+	switch {
+	case req.Message != nil:
+		return req.Message.UserId, req.Message.ChatId
+	case req.CallbackQuery != nil:
+		return req.CallbackQuery.UserId, req.CallbackQuery.ChatId
+	}
+	return 0, 0
 }
@@ -0,0 +1,254 @@
+package service
+
+import (
+	"bot/internal/config"
+	grpcclient "bot/internal/server/grpc_client"
+	offlinequeue "bot/internal/server/offline_queue"
+	"context"
+	"errors"
+	"net"
+	"path/filepath"
+	"pkg/configs"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	pb "global_models/grpc/bot"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// сервер основной логики для тестов: запоминает порядок update и может отклонять их
+type logicServer struct {
+	pb.UnimplementedBotServiceServer
+	mu       sync.Mutex
+	received []int64
+	reject   map[int64]bool // update, которые сервер отклоняет (InvalidArgument)
+}
+
+func (s *logicServer) ProcessUpdate(ctx context.Context, req *pb.UpdateRequest) (*pb.UpdateResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.received = append(s.received, req.UpdateId)
+	if s.reject[req.UpdateId] {
+		return nil, status.Error(codes.InvalidArgument, "bad update")
+	}
+	return &pb.UpdateResponse{Success: true}, nil
+}
+
+// метод возвращает полученные сервером update
+func (s *logicServer) updates() []int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]int64(nil), s.received...)
+}
+
+// тестовое окружение: сервис бота, очередь и адрес сервера основной логики (пока сервер не запущен - он недоступен)
+type offlineEnv struct {
+	service *BotService
+	queue   *offlinequeue.FileQueue
+	addr    string
+	server  *logicServer
+}
+
+// функция создания окружения с остановленным сервером основной логики
+func newOfflineEnv(t *testing.T, maxQueueSize int) *offlineEnv {
+	t.Helper()
+
+	// свободный порт: слушаем и сразу закрываем - сервер "упал"
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	addr := lis.Addr().(*net.TCPAddr)
+	lis.Close()
+
+	clientConf := configs.UseDefaultGRPCClientConfig()
+	clientConf.Host, clientConf.Port = "127.0.0.1", strconv.Itoa(addr.Port)
+	clientConf.TimeOut = 2 * time.Second
+	clientConf.MaxRetries = 0
+	clientConf.RetryableCodes = nil
+	clientConf.MaxReconnectBackoff = 50 * time.Millisecond
+	breakerConf := configs.UseDefaultCircuitBreakerConfig()
+	breakerConf.FailureThreshold = 1000 // предохранитель в этих тестах не размыкается
+
+	client, err := grpcclient.NewBotGrpcClient(clientConf, breakerConf)
+	if err != nil {
+		t.Fatalf("NewBotGrpcClient: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+
+	queue, err := offlinequeue.NewFileQueue(filepath.Join(t.TempDir(), "q"), maxQueueSize)
+	if err != nil {
+		t.Fatalf("NewFileQueue: %v", err)
+	}
+
+	offlineConf := config.UseDefaultOfflineModeConfig()
+	offlineConf.ReplayInterval = 10 * time.Millisecond
+	offlineConf.ReplayTimeout = 2 * time.Second
+	return &offlineEnv{
+		service: NewBotService(client, nil, queue, offlineConf),
+		queue:   queue,
+		addr:    addr.String(),
+		server:  &logicServer{reject: map[int64]bool{}},
+	}
+}
+
+// метод запуска сервера основной логики на том же адресе - связь восстановлена
+func (e *offlineEnv) recover(t *testing.T) {
+	t.Helper()
+	lis, err := net.Listen("tcp", e.addr)
+	if err != nil {
+		t.Fatalf("listen %s: %v", e.addr, err)
+	}
+	server := grpc.NewServer()
+	pb.RegisterBotServiceServer(server, e.server)
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+}
+
+// метод повторяет отправку очереди, пока клиент переподключается к серверу (как тикер RunReplay)
+func (e *offlineEnv) replayUntilEmpty(t *testing.T) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for e.queue.Len() > 0 && time.Now().Before(deadline) {
+		e.service.replayQueued(context.Background())
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// функция update сообщения пользователя
+func messageUpdate(updateID, userID int64) *pb.UpdateRequest {
+	return &pb.UpdateRequest{UpdateId: updateID, Message: &pb.Message{UserId: userID, ChatId: userID, Text: "привет"}}
+}
+
+func TestBotServiceOffline(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("недоступный сервер - update в очереди", func(t *testing.T) {
+		env := newOfflineEnv(t, 0)
+		if _, err := env.service.ProcessUpdate(ctx, messageUpdate(1, 10)); !errors.Is(err, ErrUpdateQueued) {
+			t.Fatalf("ожидали ErrUpdateQueued, получили %v", err)
+		}
+		if env.queue.Len() != 1 {
+			t.Errorf("в очереди %d update", env.queue.Len())
+		}
+	})
+
+	t.Run("пока очередь не пуста, новые update встают за ней", func(t *testing.T) {
+		env := newOfflineEnv(t, 0)
+		env.service.ProcessUpdate(ctx, messageUpdate(1, 10))
+		env.recover(t)
+
+		// сервер уже доступен, но update 2 не должен обогнать update 1
+		if _, err := env.service.ProcessUpdate(ctx, messageUpdate(2, 10)); !errors.Is(err, ErrUpdateQueued) {
+			t.Fatalf("ожидали ErrUpdateQueued, получили %v", err)
+		}
+		if got := env.server.updates(); len(got) != 0 {
+			t.Errorf("сервер получил update в обход очереди: %v", got)
+		}
+	})
+
+	t.Run("заполненная очередь - ErrServerUnavailable", func(t *testing.T) {
+		env := newOfflineEnv(t, 1)
+		env.service.ProcessUpdate(ctx, messageUpdate(1, 10))
+		_, err := env.service.ProcessUpdate(ctx, messageUpdate(2, 10))
+		if !errors.Is(err, ErrServerUnavailable) || !errors.Is(err, offlinequeue.ErrQueueFull) {
+			t.Errorf("ожидали ErrServerUnavailable и ErrQueueFull, получили %v", err)
+		}
+	})
+
+	t.Run("офлайн ответ - один раз на пользователя за время недоступности", func(t *testing.T) {
+		env := newOfflineEnv(t, 0)
+		if _, ok := env.service.OfflineNotice(messageUpdate(1, 10)); !ok {
+			t.Fatal("первое сообщение получает офлайн ответ")
+		}
+		if _, ok := env.service.OfflineNotice(messageUpdate(2, 10)); ok {
+			t.Error("повторный офлайн ответ тому же пользователю")
+		}
+		if msg, ok := env.service.OfflineNotice(messageUpdate(3, 11)); !ok || msg.ChatId != 11 {
+			t.Errorf("другой пользователь: %v %v", msg, ok)
+		}
+		if _, ok := env.service.OfflineNotice(&pb.UpdateRequest{UpdateId: 4}); ok {
+			t.Error("update без пользователя не получает ответ")
+		}
+
+		// очередь опустела - связь восстановлена, при следующем сбое пользователь снова получит ответ
+		env.service.replayQueued(ctx)
+		if _, ok := env.service.OfflineNotice(messageUpdate(5, 10)); !ok {
+			t.Error("после восстановления связи офлайн ответ снова отправляется")
+		}
+	})
+
+	t.Run("после восстановления очередь отправляется по порядку", func(t *testing.T) {
+		env := newOfflineEnv(t, 0)
+		for id := int64(1); id <= 4; id++ {
+			env.service.ProcessUpdate(ctx, messageUpdate(id, 10))
+		}
+
+		// сервер всё ещё недоступен - очередь не трогаем
+		env.service.replayQueued(ctx)
+		if env.queue.Len() != 4 {
+			t.Fatalf("при недоступном сервере очередь не должна меняться: %d", env.queue.Len())
+		}
+
+		env.server.reject[2] = true // отклонённый сервером update удаляется, а не блокирует очередь
+		env.recover(t)
+		env.replayUntilEmpty(t)
+
+		got := env.server.updates()
+		if len(got) != 4 || got[0] != 1 || got[1] != 2 || got[2] != 3 || got[3] != 4 {
+			t.Errorf("порядок отправки: %v", got)
+		}
+		if env.queue.Len() != 0 {
+			t.Errorf("очередь не опустела: %d", env.queue.Len())
+		}
+	})
+
+	t.Run("учёт update не ставит его в очередь", func(t *testing.T) {
+		env := newOfflineEnv(t, 0)
+		if err := env.service.RecordUpdate(ctx, messageUpdate(1, 10)); err == nil {
+			t.Error("недоступный сервер - ожидали ошибку")
+		}
+		if env.queue.Len() != 0 {
+			t.Errorf("update для учёта попал в очередь: %d", env.queue.Len())
+		}
+
+		env.recover(t)
+		deadline := time.Now().Add(5 * time.Second)
+		for len(env.server.updates()) == 0 && time.Now().Before(deadline) {
+			env.service.RecordUpdate(ctx, messageUpdate(2, 10))
+			time.Sleep(10 * time.Millisecond)
+		}
+		if got := env.server.updates(); len(got) == 0 || got[0] != 2 {
+			t.Errorf("сервер получил %v", got)
+		}
+	})
+
+	t.Run("RunReplay отправляет очередь по таймеру до отмены контекста", func(t *testing.T) {
+		env := newOfflineEnv(t, 0)
+		env.service.ProcessUpdate(ctx, messageUpdate(1, 10))
+		env.recover(t)
+
+		replayCtx, cancel := context.WithCancel(ctx)
+		done := make(chan struct{})
+		go func() {
+			env.service.RunReplay(replayCtx)
+			close(done)
+		}()
+
+		deadline := time.Now().Add(5 * time.Second)
+		for env.queue.Len() > 0 && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+		}
+		cancel()
+		<-done
+
+		if env.queue.Len() != 0 || len(env.server.updates()) != 1 {
+			t.Errorf("очередь: %d, сервер получил %v", env.queue.Len(), env.server.updates())
+		}
+	})
+}
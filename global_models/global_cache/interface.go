@@ -2,10 +2,27 @@ package global_cache
 
 import (
 	"context"
+	"errors"
 	"time"
 )
 
-// KeyValueStore - абстракция key-value хранилища
+// ErrNotFound - ключ отсутствует в хранилище (реализации обязаны возвращать именно эту ошибку)
+var ErrNotFound = errors.New("cache: key not found")
+
+// ErrWrongType - операция не подходит к типу значения ключа (например, LPush к строке)
+var ErrWrongType = errors.New("cache: operation against a key holding the wrong kind of value")
+
+// ErrNotInteger - значение ключа (или поля хэша) не является целым числом (IncrBy, HIncrBy)
+var ErrNotInteger = errors.New("cache: value is not an integer")
+
+// ZMember - элемент упорядоченного множества
+type ZMember struct {
+	Member string
+	Score  float64
+}
+
+// KeyValueStore - абстракция key-value хранилища.
+// Семантика операций совпадает с одноимёнными командами Redis
 type Cache interface {
 	// Основные CRUD операции
 	Set(ctx context.Context, key string, value []byte, expiration time.Duration) error
@@ -14,10 +31,74 @@ type Cache interface {
 	Delete(ctx context.Context, key string) error
 	Exists(ctx context.Context, key string) (bool, error)
 
+	// Атомарные операции
+	// SetNX записывает значение, только если ключа ещё нет (true - если запись произошла)
+	SetNX(ctx context.Context, key string, value []byte, expiration time.Duration) (bool, error)
+	// GetSet записывает значение и возвращает предыдущее (ErrNotFound, если ключа не было).
+	// Время жизни ключа сбрасывается, как у GETSET
+	GetSet(ctx context.Context, key string, value []byte) ([]byte, error)
+	// Incr - IncrBy на 1
+	Incr(ctx context.Context, key string, expiration time.Duration) (int64, error)
+	// IncrBy увеличивает число в ключе на delta и возвращает результат (отсутствующий ключ - 0).
+	// expiration > 0 задаёт время жизни ключу, у которого его ещё нет: окно счётчика
+	// отсчитывается от первого увеличения и не продлевается следующими
+	IncrBy(ctx context.Context, key string, delta int64, expiration time.Duration) (int64, error)
+	// SetNXFenced записывает значение, только если ключа ещё нет, и только тогда увеличивает счётчик fenceKey
+	// (счётчик без срока жизни). В ключ пишется "<новое значение счётчика>.<suffix>", возвращается это значение
+	// (0 - ключ уже есть). Нужен для захвата блокировки с fencing token: номер выдаётся в порядке захватов
+	SetNXFenced(ctx context.Context, key, fenceKey string, suffix []byte, expiration time.Duration) (int64, error)
+	// CompareAndDelete удаляет ключ, только если его значение равно expected (true - если удалён).
+	// Нужен для снятия блокировки только её владельцем
+	CompareAndDelete(ctx context.Context, key string, expected []byte) (bool, error)
+	// CompareAndExpire задаёт время жизни ключу, только если его значение равно expected (true - если задано).
+	// Нужен для продления блокировки только её владельцем
+	CompareAndExpire(ctx context.Context, key string, expected []byte, expiration time.Duration) (bool, error)
+
+	// Хэши
+	HSet(ctx context.Context, key string, values map[string][]byte) error
+	HGet(ctx context.Context, key, field string) ([]byte, error) // ErrNotFound - нет ключа или поля
+	HGetAll(ctx context.Context, key string) (map[string][]byte, error)
+	HDel(ctx context.Context, key string, fields ...string) error
+	HIncrBy(ctx context.Context, key, field string, delta int64) (int64, error)
+
+	// Списки (индексы LRange - как в Redis: отрицательные считаются с конца, stop включительно)
+	LPush(ctx context.Context, key string, values ...[]byte) (int64, error) // возвращает длину списка
+	RPush(ctx context.Context, key string, values ...[]byte) (int64, error) // возвращает длину списка
+	LPop(ctx context.Context, key string) ([]byte, error)                   // ErrNotFound - список пуст
+	LRange(ctx context.Context, key string, start, stop int64) ([][]byte, error)
+	LLen(ctx context.Context, key string) (int64, error)
+
+	// Упорядоченные множества
+	ZAdd(ctx context.Context, key string, members ...ZMember) error
+	ZRem(ctx context.Context, key string, members ...string) error
+	ZScore(ctx context.Context, key, member string) (float64, error) // ErrNotFound - нет элемента
+	// ZRangeByScore возвращает элементы с min <= score <= max по возрастанию score (limit <= 0 - все)
+	ZRangeByScore(ctx context.Context, key string, min, max float64, limit int64) ([]ZMember, error)
+	ZCard(ctx context.Context, key string) (int64, error)
+
 	// TTL операции
 	Expire(ctx context.Context, key string, expiration time.Duration) error
+	// TTL возвращает оставшееся время жизни: -1 - без срока, -2 - ключа нет
 	TTL(ctx context.Context, key string) (time.Duration, error)
 
 	// Управление соединением
+	Ping(ctx context.Context) error // проверка доступности хранилища (для health-check)
 	Close() error
 }
+
+// Loader - кэш с чтением через загрузчик (необязательное расширение Cache).
+// GetOrLoad возвращает значение ключа, а при промахе вызывает load и кэширует результат на ttl.
+// Ошибка ErrNotFound из load тоже кэшируется (ненадолго), чтобы отсутствующие данные не запрашивались при каждом чтении
+type Loader interface {
+	GetOrLoad(ctx context.Context, key string, ttl time.Duration, load func(ctx context.Context) ([]byte, error)) ([]byte, error)
+}
+
+// PubSub - рассылка сообщений всем подписчикам канала (семантика PUBLISH/SUBSCRIBE Redis:
+// доставка не гарантируется, сообщения во время разрыва соединения теряются)
+type PubSub interface {
+	Publish(ctx context.Context, channel string, payload []byte) error
+	// Subscribe вызывает handler для каждого сообщения канала, пока не отменён ctx.
+	// handler вызывается с nil, когда подписка установлена (в том числе после восстановления соединения):
+	// сообщения, опубликованные до этого, не придут
+	Subscribe(ctx context.Context, channel string, handler func(payload []byte)) error
+}
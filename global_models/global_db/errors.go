@@ -0,0 +1,35 @@
+package global_db
+
+import "errors"
+
+// типизированные ошибки БД: адаптер оборачивает ошибки драйвера в *Error,
+// поэтому проверка делается через errors.Is(err, global_db.ErrNoRows) без разбора текста ошибки
+var (
+	ErrNoRows               = errors.New("no rows in result set")
+	ErrUniqueViolation      = errors.New("unique violation")
+	ErrForeignKeyViolation  = errors.New("foreign key violation")
+	ErrSerializationFailure = errors.New("serialization failure")
+	ErrDeadlock             = errors.New("deadlock detected")
+)
+
+// Error - ошибка драйвера с типом (одна из ошибок выше) и деталями Postgres
+type Error struct {
+	Kind       error  // ErrNoRows, ErrUniqueViolation, ...
+	Code       string // SQLSTATE (пустой для ErrNoRows)
+	Constraint string // нарушенное ограничение (для нарушений целостности)
+	Err        error  // исходная ошибка драйвера
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap даёт errors.Is/As доступ и к типу, и к исходной ошибке драйвера
+func (e *Error) Unwrap() []error {
+	return []error{e.Kind, e.Err}
+}
+
+// IsRetryable сообщает, что транзакцию можно повторить целиком (ошибка сериализации или deadlock)
+func IsRetryable(err error) bool {
+	return errors.Is(err, ErrSerializationFailure) || errors.Is(err, ErrDeadlock)
+}
@@ -1,7 +1,10 @@
 // Абстракция для самой БД
 package global_db
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // Pool — абстракция БД, общая для всех
 type Pool interface {
@@ -9,6 +12,16 @@ type Pool interface {
 	QueryRow(ctx context.Context, sql string, args ...any) Row
 	Query(ctx context.Context, sql string, args ...any) (Rows, error)
 	Begin(ctx context.Context) (Tx, error)
+	// BeginTx открывает транзакцию с заданным уровнем изоляции и режимом доступа
+	// (обычно используется не напрямую, а через WithTx)
+	BeginTx(ctx context.Context, opts TxOptions) (Tx, error)
+	// SendBatch отправляет очередь запросов одним обращением к БД; результаты читаются в порядке Queue
+	SendBatch(ctx context.Context, batch *Batch) BatchResults
+	// CopyFrom массово загружает строки в таблицу (COPY FROM STDIN), возвращает количество записанных строк.
+	// Значения в rows идут в порядке columns
+	CopyFrom(ctx context.Context, table string, columns []string, rows [][]any) (int64, error)
+	Ping(ctx context.Context) error // проверка доступности БД (для health-check)
+	Stat() PoolStat                 // статистика пула соединений (для метрик)
 	Close() error
 }
 
@@ -32,4 +45,73 @@ type Tx interface {
 	Exec(ctx context.Context, sql string, arguments ...any) (int64, error)
 	QueryRow(ctx context.Context, sql string, arguments ...any) Row
 	Query(ctx context.Context, sql string, arguments ...any) (Rows, error)
+	// CopyFrom - как у Pool, но внутри транзакции (например, загрузка во временную таблицу)
+	CopyFrom(ctx context.Context, table string, columns []string, rows [][]any) (int64, error)
+	// SendBatch - как у Pool, но внутри транзакции
+	SendBatch(ctx context.Context, batch *Batch) BatchResults
+}
+
+// уровень изоляции транзакции (пустой - уровень по умолчанию сервера БД)
+type IsoLevel string
+
+const (
+	ReadCommitted  IsoLevel = "read committed"
+	RepeatableRead IsoLevel = "repeatable read"
+	Serializable   IsoLevel = "serializable"
+)
+
+// параметры транзакции
+type TxOptions struct {
+	IsoLevel IsoLevel
+	ReadOnly bool
+	// MaxRetries - сколько раз WithTx повторяет транзакцию после ошибки сериализации или deadlock
+	// (0 - DefaultTxRetries, отрицательное значение - без повторов)
+	MaxRetries int
+}
+
+// один запрос пакета
+type BatchQuery struct {
+	SQL  string
+	Args []any
+}
+
+// Batch - очередь запросов, отправляемых в БД одним обращением (SendBatch)
+type Batch struct {
+	queries []BatchQuery
+}
+
+// метод добавляет запрос в пакет
+func (b *Batch) Queue(sql string, args ...any) {
+	b.queries = append(b.queries, BatchQuery{SQL: sql, Args: args})
+}
+
+// метод возвращает количество запросов в пакете
+func (b *Batch) Len() int {
+	return len(b.queries)
+}
+
+// метод возвращает запросы пакета в порядке добавления (для адаптеров и декораторов)
+func (b *Batch) Queries() []BatchQuery {
+	return b.queries
+}
+
+// абстракция для результатов пакета: каждый вызов Exec/QueryRow/Query читает результат следующего запроса.
+// Close обязателен - до него соединение занято
+type BatchResults interface {
+	Exec() (int64, error)
+	QueryRow() Row
+	Query() (Rows, error)
+	Close() error
+}
+
+// статистика пула соединений
+type PoolStat struct {
+	AcquiredConns        int32         // соединений в работе
+	IdleConns            int32         // свободных соединений
+	TotalConns           int32         // всего соединений в пуле
+	MaxConns             int32         // максимальный размер пула
+	AcquireCount         int64         // успешных получений соединения
+	EmptyAcquireCount    int64         // получений, которым пришлось ждать свободное соединение
+	CanceledAcquireCount int64         // получений, отменённых контекстом
+	AcquireDuration      time.Duration // суммарное время ожидания соединений
 }
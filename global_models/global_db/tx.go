@@ -0,0 +1,67 @@
+package global_db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+)
+
+// DefaultTxRetries - повторы транзакции по умолчанию (TxOptions.MaxRetries == 0)
+const DefaultTxRetries = 3
+
+// базовая пауза перед повтором транзакции (растёт вдвое с каждой попыткой)
+const txRetryBackoff = 10 * time.Millisecond
+
+// WithTx выполняет fn в транзакции: Commit, если fn вернула nil, иначе (и при панике) - Rollback.
+// Ошибка сериализации или deadlock (в fn или при Commit) повторяет транзакцию целиком,
+// поэтому fn не должна иметь побочных эффектов вне транзакции
+func WithTx(ctx context.Context, db Pool, opts TxOptions, fn func(ctx context.Context, tx Tx) error) error {
+	retries := opts.MaxRetries
+	if retries == 0 {
+		retries = DefaultTxRetries
+	}
+
+	for attempt := 0; ; attempt++ {
+		err := runTx(ctx, db, opts, fn)
+		if err == nil || !IsRetryable(err) || attempt >= retries {
+			return err
+		}
+
+		// конкурирующие транзакции расходятся по времени за счёт случайной паузы
+		backoff := txRetryBackoff << attempt
+		backoff = backoff/2 + rand.N(backoff/2+1)
+		select {
+		case <-ctx.Done():
+			return errors.Join(err, ctx.Err())
+		case <-time.After(backoff):
+		}
+	}
+}
+
+// функция для выполнения одной попытки транзакции
+func runTx(ctx context.Context, db Pool, opts TxOptions, fn func(ctx context.Context, tx Tx) error) (err error) {
+	tx, err := db.BeginTx(ctx, opts)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback(ctx)
+			panic(p)
+		}
+		if err != nil {
+			tx.Rollback(ctx)
+		}
+	}()
+
+	if err := fn(ctx, tx); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
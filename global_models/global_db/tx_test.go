@@ -0,0 +1,133 @@
+package global_db
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// фейковая БД: считает транзакции и их завершение
+type fakePool struct {
+	Pool
+	opts      TxOptions
+	begins    int
+	commits   int
+	rollbacks int
+	commitErr []error // ошибки Commit по очереди попыток
+}
+
+func (p *fakePool) BeginTx(ctx context.Context, opts TxOptions) (Tx, error) {
+	p.begins++
+	p.opts = opts
+	return &fakeTx{pool: p}, nil
+}
+
+type fakeTx struct {
+	Tx
+	pool *fakePool
+}
+
+func (t *fakeTx) Commit(ctx context.Context) error {
+	if len(t.pool.commitErr) > 0 {
+		err := t.pool.commitErr[0]
+		t.pool.commitErr = t.pool.commitErr[1:]
+		if err != nil {
+			return err
+		}
+	}
+	t.pool.commits++
+	return nil
+}
+
+func (t *fakeTx) Rollback(ctx context.Context) error {
+	t.pool.rollbacks++
+	return nil
+}
+
+func serializationError() error {
+	return &Error{Kind: ErrSerializationFailure, Code: "40001", Err: errors.New("could not serialize access")}
+}
+
+func TestWithTx(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("успех - commit с заданными параметрами", func(t *testing.T) {
+		pool := &fakePool{}
+		opts := TxOptions{IsoLevel: Serializable, ReadOnly: true}
+		if err := WithTx(ctx, pool, opts, func(ctx context.Context, tx Tx) error { return nil }); err != nil {
+			t.Fatalf("WithTx: %v", err)
+		}
+		if pool.commits != 1 || pool.rollbacks != 0 {
+			t.Errorf("commit=%d rollback=%d", pool.commits, pool.rollbacks)
+		}
+		if pool.opts.IsoLevel != Serializable || !pool.opts.ReadOnly {
+			t.Errorf("параметры транзакции не переданы: %+v", pool.opts)
+		}
+	})
+
+	t.Run("ошибка fn - rollback без повтора", func(t *testing.T) {
+		pool := &fakePool{}
+		want := errors.New("validation failed")
+		err := WithTx(ctx, pool, TxOptions{}, func(ctx context.Context, tx Tx) error { return want })
+		if !errors.Is(err, want) {
+			t.Errorf("ожидали %v, получили %v", want, err)
+		}
+		if pool.begins != 1 || pool.rollbacks != 1 || pool.commits != 0 {
+			t.Errorf("begin=%d commit=%d rollback=%d", pool.begins, pool.commits, pool.rollbacks)
+		}
+	})
+
+	t.Run("ошибка сериализации повторяется", func(t *testing.T) {
+		pool := &fakePool{}
+		calls := 0
+		err := WithTx(ctx, pool, TxOptions{IsoLevel: Serializable}, func(ctx context.Context, tx Tx) error {
+			calls++
+			if calls < 3 {
+				return serializationError()
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("WithTx: %v", err)
+		}
+		if calls != 3 || pool.commits != 1 || pool.rollbacks != 2 {
+			t.Errorf("вызовов=%d commit=%d rollback=%d", calls, pool.commits, pool.rollbacks)
+		}
+	})
+
+	t.Run("ошибка сериализации при commit тоже повторяется", func(t *testing.T) {
+		pool := &fakePool{commitErr: []error{serializationError()}}
+		if err := WithTx(ctx, pool, TxOptions{}, func(ctx context.Context, tx Tx) error { return nil }); err != nil {
+			t.Fatalf("WithTx: %v", err)
+		}
+		if pool.begins != 2 || pool.commits != 1 {
+			t.Errorf("begin=%d commit=%d", pool.begins, pool.commits)
+		}
+	})
+
+	t.Run("повторы ограничены", func(t *testing.T) {
+		pool := &fakePool{}
+		err := WithTx(ctx, pool, TxOptions{MaxRetries: 2}, func(ctx context.Context, tx Tx) error {
+			return &Error{Kind: ErrDeadlock, Code: "40P01", Err: errors.New("deadlock detected")}
+		})
+		if !errors.Is(err, ErrDeadlock) {
+			t.Errorf("ожидали ErrDeadlock, получили %v", err)
+		}
+		if pool.begins != 3 {
+			t.Errorf("попыток %d, ожидали 3", pool.begins)
+		}
+	})
+
+	t.Run("паника - rollback и паника дальше", func(t *testing.T) {
+		pool := &fakePool{}
+		defer func() {
+			if recover() == nil {
+				t.Error("паника потеряна")
+			}
+			if pool.rollbacks != 1 {
+				t.Errorf("rollback=%d", pool.rollbacks)
+			}
+		}()
+		WithTx(ctx, pool, TxOptions{}, func(ctx context.Context, tx Tx) error { panic("boom") })
+	})
+}
@@ -16,5 +16,54 @@ type GRPCInterface interface {
 }
 
 type BizHTTPHandlerInterface interface {
-	EchoServer(c *gin.Context)
+	// ответы на неизвестный маршрут и метод
+	NotFound(c *gin.Context)
+	MethodNotAllowed(c *gin.Context)
+
+	// вход в админку и проверка роли (viewer, manager, owner) для маршрутов
+	LoginTelegram(c *gin.Context)
+	RefreshSession(c *gin.Context)
+	Logout(c *gin.Context)
+	CurrentAdmin(c *gin.Context)
+	RequireRole(role string) gin.HandlerFunc
+
+	// администраторы админки
+	ListAdmins(c *gin.Context)
+	SetAdminRole(c *gin.Context)
+	RemoveAdmin(c *gin.Context)
+
+	// пользователи, переписка и журнал нажатий
+	ListUsers(c *gin.Context)
+	GetUser(c *gin.Context)
+	ListUserMessages(c *gin.Context)
+	ListCallbacks(c *gin.Context)
+
+	// консоль оператора: диалоги с мастером, ответы клиенту и события чатов
+	ListHandoffs(c *gin.Context)
+	OpenHandoff(c *gin.Context)
+	CloseHandoff(c *gin.Context)
+	SendUserMessage(c *gin.Context)
+	StreamUserEvents(c *gin.Context)
+	StreamEvents(c *gin.Context)
+
+	// заявки клиентов
+	ListLeads(c *gin.Context)
+	GetLead(c *gin.Context)
+	UpdateLead(c *gin.Context)
+
+	// аналитика
+	GetFunnelStats(c *gin.Context)
+	GetSourceStats(c *gin.Context)
+	GetRetention(c *gin.Context)
+
+	// настройки бизнеса
+	GetSettings(c *gin.Context)
+	UpdateSettings(c *gin.Context)
+
+	// управление задачами планировщика
+	ListSchedulerJobs(c *gin.Context)
+	GetSchedulerJob(c *gin.Context)
+	PauseSchedulerJob(c *gin.Context)
+	ResumeSchedulerJob(c *gin.Context)
+	TriggerSchedulerJob(c *gin.Context)
 }
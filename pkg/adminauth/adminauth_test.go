@@ -0,0 +1,153 @@
+package adminauth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testBotToken = "123456:ABC-test-token"
+
+// функция подписи полей так, как это делает Telegram
+func signLogin(fields map[string]string, botToken string) map[string]string {
+	var lines []string
+	for key, value := range fields {
+		lines = append(lines, key+"="+value)
+	}
+	slices.Sort(lines)
+	secret := sha256.Sum256([]byte(botToken))
+	mac := hmac.New(sha256.New, secret[:])
+	mac.Write([]byte(strings.Join(lines, "\n")))
+
+	signed := map[string]string{"hash": hex.EncodeToString(mac.Sum(nil))}
+	for key, value := range fields {
+		signed[key] = value
+	}
+	return signed
+}
+
+func TestVerifyLogin(t *testing.T) {
+	now := time.Now()
+	fields := map[string]string{
+		"id":         "42",
+		"first_name": "Анна",
+		"username":   "anna",
+		"auth_date":  strconv.FormatInt(now.Add(-time.Minute).Unix(), 10),
+	}
+
+	t.Run("верная подпись", func(t *testing.T) {
+		login, err := VerifyLogin(signLogin(fields, testBotToken), testBotToken, time.Hour, now)
+		if err != nil {
+			t.Fatalf("VerifyLogin: %v", err)
+		}
+		if login.TelegramID != 42 || login.Username != "anna" || login.FirstName != "Анна" {
+			t.Errorf("поля: %+v", login)
+		}
+	})
+
+	t.Run("подмена поля или чужой токен бота", func(t *testing.T) {
+		signed := signLogin(fields, testBotToken)
+		signed["id"] = "43"
+		if _, err := VerifyLogin(signed, testBotToken, time.Hour, now); !errors.Is(err, ErrBadSignature) {
+			t.Errorf("подмена id: %v", err)
+		}
+		if _, err := VerifyLogin(signLogin(fields, "other:token"), testBotToken, time.Hour, now); !errors.Is(err, ErrBadSignature) {
+			t.Errorf("чужой токен: %v", err)
+		}
+		delete(signed, "hash")
+		if _, err := VerifyLogin(signed, testBotToken, time.Hour, now); !errors.Is(err, ErrBadSignature) {
+			t.Errorf("без hash: %v", err)
+		}
+	})
+
+	t.Run("неизвестные поля входят в подпись", func(t *testing.T) {
+		extended := map[string]string{"photo_url": "https://t.me/i/a.jpg"}
+		for key, value := range fields {
+			extended[key] = value
+		}
+		if _, err := VerifyLogin(signLogin(extended, testBotToken), testBotToken, time.Hour, now); err != nil {
+			t.Errorf("VerifyLogin: %v", err)
+		}
+	})
+
+	t.Run("устаревшие данные", func(t *testing.T) {
+		signed := signLogin(fields, testBotToken)
+		if _, err := VerifyLogin(signed, testBotToken, 30*time.Second, now); !errors.Is(err, ErrLoginExpired) {
+			t.Errorf("старше maxAge: %v", err)
+		}
+		if _, err := VerifyLogin(signed, testBotToken, time.Hour, now.Add(-time.Hour)); !errors.Is(err, ErrLoginExpired) {
+			t.Errorf("из будущего: %v", err)
+		}
+	})
+}
+
+func TestSigner(t *testing.T) {
+	secret := strings.Repeat("s", MinSecretLength)
+	signer, err := NewSigner(secret, time.Minute)
+	if err != nil {
+		t.Fatalf("NewSigner: %v", err)
+	}
+	now := time.Now()
+
+	t.Run("выпуск и проверка", func(t *testing.T) {
+		token, issued, err := signer.Sign(Claims{Subject: 42, Role: "owner", SessionID: 7}, now)
+		if err != nil {
+			t.Fatalf("Sign: %v", err)
+		}
+		claims, err := signer.Parse(token, now.Add(30*time.Second))
+		if err != nil {
+			t.Fatalf("Parse: %v", err)
+		}
+		if *claims != *issued || claims.Subject != 42 || claims.Role != "owner" || claims.SessionID != 7 {
+			t.Errorf("claims: %+v", claims)
+		}
+		if _, err := signer.Parse(token, now.Add(time.Minute)); !errors.Is(err, ErrTokenExpired) {
+			t.Errorf("истёкший токен: %v", err)
+		}
+	})
+
+	t.Run("подделка", func(t *testing.T) {
+		token, _, _ := signer.Sign(Claims{Subject: 42, Role: "viewer"}, now)
+		other, _ := NewSigner(strings.Repeat("x", MinSecretLength), time.Minute)
+		forged, _, _ := other.Sign(Claims{Subject: 42, Role: "owner"}, now)
+
+		header, rest, _ := strings.Cut(token, ".")
+		_, signature, _ := strings.Cut(rest, ".")
+		_, forgedRest, _ := strings.Cut(forged, ".")
+		forgedPayload, _, _ := strings.Cut(forgedRest, ".")
+
+		for name, bad := range map[string]string{
+			"чужой секрет":        forged,
+			"подмена содержимого": header + "." + forgedPayload + "." + signature,
+			"alg none":            "eyJhbGciOiJub25lIn0." + forgedPayload + ".",
+			"мусор":               "abc",
+		} {
+			if _, err := signer.Parse(bad, now); !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("%s: %v", name, err)
+			}
+		}
+	})
+
+	t.Run("короткий секрет", func(t *testing.T) {
+		if _, err := NewSigner("short", time.Minute); err == nil {
+			t.Error("ожидали ошибку")
+		}
+	})
+}
+
+func TestRefreshToken(t *testing.T) {
+	token, hash, err := NewRefreshToken()
+	if err != nil {
+		t.Fatalf("NewRefreshToken: %v", err)
+	}
+	other, _, _ := NewRefreshToken()
+	if token == other || !hmac.Equal(hash, HashRefreshToken(token)) {
+		t.Errorf("токены должны быть случайными, хэш - детерминированным")
+	}
+}
@@ -0,0 +1,91 @@
+package adminauth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ошибки проверки данных Telegram Login Widget
+var (
+	ErrBadSignature = errors.New("telegram login: bad signature")
+	ErrLoginExpired = errors.New("telegram login: auth data is too old")
+	ErrBadLogin     = errors.New("telegram login: malformed auth data")
+)
+
+// допустимое расхождение часов: auth_date немного в будущем не считается подделкой
+const clockSkew = time.Minute
+
+// LoginData - пользователь, подтверждённый Telegram Login Widget
+type LoginData struct {
+	TelegramID int64
+	Username   string
+	FirstName  string
+	LastName   string
+	PhotoURL   string
+	AuthDate   time.Time
+}
+
+// VerifyLogin проверяет подпись данных виджета (https://core.telegram.org/widgets/login#checking-authorization).
+// fields - все поля, пришедшие от виджета, включая hash (неизвестные поля тоже входят в подпись);
+// maxAge - сколько действительны данные после auth_date (0 - без ограничения)
+func VerifyLogin(fields map[string]string, botToken string, maxAge time.Duration, now time.Time) (*LoginData, error) {
+	hash, ok := fields["hash"]
+	if !ok || botToken == "" {
+		return nil, ErrBadSignature
+	}
+	got, err := hex.DecodeString(hash)
+	if err != nil {
+		return nil, ErrBadSignature
+	}
+
+	// data-check-string: пары key=value, кроме hash, по алфавиту через \n
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		if key != "hash" {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+	lines := make([]string, 0, len(keys))
+	for _, key := range keys {
+		lines = append(lines, key+"="+fields[key])
+	}
+
+	// ключ подписи - SHA256 от токена бота
+	secret := sha256.Sum256([]byte(botToken))
+	mac := hmac.New(sha256.New, secret[:])
+	mac.Write([]byte(strings.Join(lines, "\n")))
+	if !hmac.Equal(mac.Sum(nil), got) {
+		return nil, ErrBadSignature
+	}
+
+	// подпись верна - разбираем поля
+	id, err := strconv.ParseInt(fields["id"], 10, 64)
+	if err != nil || id <= 0 {
+		return nil, fmt.Errorf("%w: id", ErrBadLogin)
+	}
+	authUnix, err := strconv.ParseInt(fields["auth_date"], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: auth_date", ErrBadLogin)
+	}
+	authDate := time.Unix(authUnix, 0)
+	if authDate.After(now.Add(clockSkew)) || (maxAge > 0 && now.Sub(authDate) > maxAge) {
+		return nil, ErrLoginExpired
+	}
+
+	return &LoginData{
+		TelegramID: id,
+		Username:   fields["username"],
+		FirstName:  fields["first_name"],
+		LastName:   fields["last_name"],
+		PhotoURL:   fields["photo_url"],
+		AuthDate:   authDate,
+	}, nil
+}
@@ -0,0 +1,115 @@
+package adminauth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+// ошибки проверки токена доступа
+var (
+	ErrInvalidToken = errors.New("invalid access token")
+	ErrTokenExpired = errors.New("access token expired")
+)
+
+// минимальная длина секрета подписи (HS256 - не короче размера хэша)
+const MinSecretLength = 32
+
+// заголовок JWT: подписываем только HS256, другие алгоритмы не принимаются
+var jwtHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// Claims - содержимое токена доступа
+type Claims struct {
+	Subject   int64  `json:"sub"`  // telegram_id администратора
+	Role      string `json:"role"` // роль на момент выдачи (обновляется при refresh)
+	SessionID int64  `json:"sid"`  // сессия, в рамках которой выдан токен
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// метод возвращает время истечения токена
+func (c *Claims) Expires() time.Time {
+	return time.Unix(c.ExpiresAt, 0)
+}
+
+// Signer - выпуск и проверка токенов доступа (JWT, HS256)
+type Signer struct {
+	secret []byte
+	ttl    time.Duration
+}
+
+// конструктор для Signer (secret не короче MinSecretLength)
+func NewSigner(secret string, ttl time.Duration) (*Signer, error) {
+	if len(secret) < MinSecretLength {
+		return nil, errors.New("token secret is too short")
+	}
+	if ttl <= 0 {
+		return nil, errors.New("token ttl must be positive")
+	}
+	return &Signer{secret: []byte(secret), ttl: ttl}, nil
+}
+
+// Sign выпускает токен доступа (IssuedAt и ExpiresAt заполняются от now)
+func (s *Signer) Sign(claims Claims, now time.Time) (string, *Claims, error) {
+	claims.IssuedAt = now.Unix()
+	claims.ExpiresAt = now.Add(s.ttl).Unix()
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", nil, err
+	}
+	unsigned := jwtHeader + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return unsigned + "." + s.signature(unsigned), &claims, nil
+}
+
+// Parse проверяет подпись и срок токена
+func (s *Signer) Parse(token string, now time.Time) (*Claims, error) {
+	header, rest, ok := strings.Cut(token, ".")
+	if !ok || header != jwtHeader {
+		return nil, ErrInvalidToken
+	}
+	payload, signature, ok := strings.Cut(rest, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(s.signature(header+"."+payload))) {
+		return nil, ErrInvalidToken
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	var claims Claims
+	if err := json.Unmarshal(raw, &claims); err != nil || claims.Subject <= 0 {
+		return nil, ErrInvalidToken
+	}
+	if !now.Before(claims.Expires()) {
+		return nil, ErrTokenExpired
+	}
+	return &claims, nil
+}
+
+// метод подписи заголовка и содержимого
+func (s *Signer) signature(unsigned string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(unsigned))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// NewRefreshToken возвращает случайный токен обновления и его хэш (в хранилище кладётся только хэш)
+func NewRefreshToken() (token string, hash []byte, err error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", nil, err
+	}
+	token = base64.RawURLEncoding.EncodeToString(buf)
+	return token, HashRefreshToken(token), nil
+}
+
+// HashRefreshToken возвращает хэш токена обновления для поиска в хранилище
+func HashRefreshToken(token string) []byte {
+	sum := sha256.Sum256([]byte(token))
+	return sum[:]
+}
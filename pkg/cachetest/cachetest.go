@@ -0,0 +1,471 @@
+// Пакет cachetest - общий набор тестов поведения global_cache.Cache.
+// Его прогоняют все реализации (Redis, память), чтобы они были взаимозаменяемы
+package cachetest
+
+import (
+	"context"
+	"errors"
+	"global_models/global_cache"
+	"math"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Factory возвращает пустой кэш для одного теста
+type Factory func(t *testing.T) global_cache.Cache
+
+// Advance сдвигает время кэша вперёд (time.Sleep для настоящих хранилищ, FastForward для miniredis)
+type Advance func(d time.Duration)
+
+// RunContract прогоняет набор тестов на реализации кэша
+func RunContract(t *testing.T, newCache Factory, advance Advance) {
+	t.Run("строки", func(t *testing.T) { testStrings(t, newCache) })
+	t.Run("атомарные операции", func(t *testing.T) { testAtomic(t, newCache) })
+	t.Run("продление по значению", func(t *testing.T) { testCompareAndExpire(t, newCache, advance) })
+	t.Run("хэши", func(t *testing.T) { testHashes(t, newCache) })
+	t.Run("списки", func(t *testing.T) { testLists(t, newCache) })
+	t.Run("упорядоченные множества", func(t *testing.T) { testSortedSets(t, newCache) })
+	t.Run("время жизни", func(t *testing.T) { testTTL(t, newCache, advance) })
+}
+
+func testStrings(t *testing.T, newCache Factory) {
+	ctx := context.Background()
+
+	t.Run("отсутствующий ключ - ErrNotFound", func(t *testing.T) {
+		cache := newCache(t)
+		if _, err := cache.Get(ctx, "missing"); !errors.Is(err, global_cache.ErrNotFound) {
+			t.Errorf("Get: ожидали ErrNotFound, получили %v", err)
+		}
+		if _, err := cache.GetBytes(ctx, "missing"); !errors.Is(err, global_cache.ErrNotFound) {
+			t.Errorf("GetBytes: ожидали ErrNotFound, получили %v", err)
+		}
+		if ok, err := cache.Exists(ctx, "missing"); err != nil || ok {
+			t.Errorf("Exists: %v, %v", ok, err)
+		}
+	})
+
+	t.Run("запись, чтение и удаление", func(t *testing.T) {
+		cache := newCache(t)
+		if err := cache.Set(ctx, "key", []byte("value"), 0); err != nil {
+			t.Fatalf("Set: %v", err)
+		}
+		if got, err := cache.Get(ctx, "key"); err != nil || got != "value" {
+			t.Errorf("Get: %q, %v", got, err)
+		}
+		if ok, _ := cache.Exists(ctx, "key"); !ok {
+			t.Error("Exists: ключ не найден")
+		}
+		if err := cache.Delete(ctx, "key"); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+		if _, err := cache.GetBytes(ctx, "key"); !errors.Is(err, global_cache.ErrNotFound) {
+			t.Errorf("после Delete: %v", err)
+		}
+		if err := cache.Delete(ctx, "key"); err != nil {
+			t.Errorf("удаление отсутствующего ключа: %v", err)
+		}
+	})
+
+	t.Run("операция над ключом другого типа - ErrWrongType", func(t *testing.T) {
+		cache := newCache(t)
+		cache.Set(ctx, "key", []byte("value"), 0)
+		if _, err := cache.LPush(ctx, "key", []byte("x")); !errors.Is(err, global_cache.ErrWrongType) {
+			t.Errorf("LPush: ожидали ErrWrongType, получили %v", err)
+		}
+		if _, err := cache.HGet(ctx, "key", "field"); !errors.Is(err, global_cache.ErrWrongType) {
+			t.Errorf("HGet: ожидали ErrWrongType, получили %v", err)
+		}
+	})
+}
+
+func testAtomic(t *testing.T, newCache Factory) {
+	ctx := context.Background()
+
+	t.Run("SetNX пишет только новый ключ", func(t *testing.T) {
+		cache := newCache(t)
+		if ok, err := cache.SetNX(ctx, "lock", []byte("a"), time.Minute); err != nil || !ok {
+			t.Fatalf("первый SetNX: %v, %v", ok, err)
+		}
+		if ok, err := cache.SetNX(ctx, "lock", []byte("b"), time.Minute); err != nil || ok {
+			t.Fatalf("второй SetNX: %v, %v", ok, err)
+		}
+		if got, _ := cache.Get(ctx, "lock"); got != "a" {
+			t.Errorf("значение перезаписано: %q", got)
+		}
+	})
+
+	t.Run("GetSet возвращает предыдущее значение", func(t *testing.T) {
+		cache := newCache(t)
+		if _, err := cache.GetSet(ctx, "key", []byte("first")); !errors.Is(err, global_cache.ErrNotFound) {
+			t.Errorf("первый GetSet: ожидали ErrNotFound, получили %v", err)
+		}
+		prev, err := cache.GetSet(ctx, "key", []byte("second"))
+		if err != nil || string(prev) != "first" {
+			t.Errorf("второй GetSet: %q, %v", prev, err)
+		}
+		if got, _ := cache.Get(ctx, "key"); got != "second" {
+			t.Errorf("значение: %q", got)
+		}
+	})
+
+	t.Run("Incr и IncrBy", func(t *testing.T) {
+		cache := newCache(t)
+		if n, err := cache.Incr(ctx, "counter", 0); err != nil || n != 1 {
+			t.Fatalf("Incr: %d, %v", n, err)
+		}
+		if n, err := cache.IncrBy(ctx, "counter", 10, 0); err != nil || n != 11 {
+			t.Fatalf("IncrBy: %d, %v", n, err)
+		}
+		if n, err := cache.IncrBy(ctx, "counter", -20, 0); err != nil || n != -9 {
+			t.Fatalf("IncrBy отрицательный: %d, %v", n, err)
+		}
+		if got, _ := cache.Get(ctx, "counter"); got != "-9" {
+			t.Errorf("значение счётчика строкой: %q", got)
+		}
+	})
+
+	t.Run("время жизни счётчика задаётся первым увеличением", func(t *testing.T) {
+		cache := newCache(t)
+		cache.Incr(ctx, "window", time.Minute)
+		cache.Incr(ctx, "window", time.Hour)
+
+		ttl, err := cache.TTL(ctx, "window")
+		if err != nil {
+			t.Fatalf("TTL: %v", err)
+		}
+		if ttl <= 0 || ttl > time.Minute {
+			t.Errorf("TTL счётчика %v, ожидали (0, 1m]", ttl)
+		}
+	})
+
+	t.Run("Incr не числа - ErrNotInteger", func(t *testing.T) {
+		cache := newCache(t)
+		cache.Set(ctx, "text", []byte("abc"), 0)
+		if _, err := cache.Incr(ctx, "text", 0); !errors.Is(err, global_cache.ErrNotInteger) {
+			t.Errorf("ожидали ErrNotInteger, получили %v", err)
+		}
+	})
+
+	t.Run("одновременные Incr не теряют увеличения", func(t *testing.T) {
+		cache := newCache(t)
+		const workers, perWorker = 10, 20
+
+		var wg sync.WaitGroup
+		for range workers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for range perWorker {
+					if _, err := cache.Incr(ctx, "counter", time.Minute); err != nil {
+						t.Errorf("Incr: %v", err)
+						return
+					}
+				}
+			}()
+		}
+		wg.Wait()
+
+		if got, _ := cache.Get(ctx, "counter"); got != "200" {
+			t.Errorf("счётчик %s, ожидали 200", got)
+		}
+	})
+
+	t.Run("SetNXFenced выдаёт номер только при записи", func(t *testing.T) {
+		cache := newCache(t)
+
+		if fence, err := cache.SetNXFenced(ctx, "lock", "lock:fence", []byte("a"), time.Minute); err != nil || fence != 1 {
+			t.Fatalf("первый захват: %d, %v", fence, err)
+		}
+		if got, _ := cache.Get(ctx, "lock"); got != "1.a" {
+			t.Errorf("значение %q, ожидали 1.a", got)
+		}
+		if ttl, _ := cache.TTL(ctx, "lock"); ttl <= 0 {
+			t.Errorf("время жизни не задано: %v", ttl)
+		}
+
+		if fence, err := cache.SetNXFenced(ctx, "lock", "lock:fence", []byte("b"), time.Minute); err != nil || fence != 0 {
+			t.Fatalf("занятый ключ: %d, %v", fence, err)
+		}
+		if got, _ := cache.Get(ctx, "lock:fence"); got != "1" {
+			t.Errorf("неудачный захват увеличил счётчик: %s", got)
+		}
+
+		cache.Delete(ctx, "lock")
+		if fence, err := cache.SetNXFenced(ctx, "lock", "lock:fence", []byte("c"), time.Minute); err != nil || fence != 2 {
+			t.Errorf("захват после удаления: %d, %v", fence, err)
+		}
+	})
+
+	t.Run("CompareAndDelete удаляет только своё значение", func(t *testing.T) {
+		cache := newCache(t)
+		cache.Set(ctx, "lock", []byte("owner-1"), time.Minute)
+
+		if ok, err := cache.CompareAndDelete(ctx, "lock", []byte("owner-2")); err != nil || ok {
+			t.Fatalf("чужое значение: %v, %v", ok, err)
+		}
+		if exists, _ := cache.Exists(ctx, "lock"); !exists {
+			t.Fatal("ключ удалён чужим владельцем")
+		}
+		if ok, err := cache.CompareAndDelete(ctx, "lock", []byte("owner-1")); err != nil || !ok {
+			t.Fatalf("своё значение: %v, %v", ok, err)
+		}
+		if exists, _ := cache.Exists(ctx, "lock"); exists {
+			t.Error("ключ не удалён")
+		}
+		if ok, err := cache.CompareAndDelete(ctx, "lock", []byte("owner-1")); err != nil || ok {
+			t.Errorf("отсутствующий ключ: %v, %v", ok, err)
+		}
+	})
+}
+
+func testCompareAndExpire(t *testing.T, newCache Factory, advance Advance) {
+	ctx := context.Background()
+
+	t.Run("продлевает только своё значение", func(t *testing.T) {
+		cache := newCache(t)
+		cache.Set(ctx, "lock", []byte("owner-1"), 100*time.Millisecond)
+
+		if ok, err := cache.CompareAndExpire(ctx, "lock", []byte("owner-2"), time.Minute); err != nil || ok {
+			t.Fatalf("чужое значение: %v, %v", ok, err)
+		}
+		if ok, err := cache.CompareAndExpire(ctx, "lock", []byte("owner-1"), time.Minute); err != nil || !ok {
+			t.Fatalf("своё значение: %v, %v", ok, err)
+		}
+
+		advance(200 * time.Millisecond)
+		if got, err := cache.Get(ctx, "lock"); err != nil || got != "owner-1" {
+			t.Errorf("продлённый ключ истёк: %q, %v", got, err)
+		}
+	})
+
+	t.Run("отсутствующий ключ не создаётся", func(t *testing.T) {
+		cache := newCache(t)
+		if ok, err := cache.CompareAndExpire(ctx, "lock", []byte("owner-1"), time.Minute); err != nil || ok {
+			t.Errorf("CompareAndExpire: %v, %v", ok, err)
+		}
+		if exists, _ := cache.Exists(ctx, "lock"); exists {
+			t.Error("ключ создан")
+		}
+	})
+}
+
+func testHashes(t *testing.T, newCache Factory) {
+	ctx := context.Background()
+
+	t.Run("запись и чтение полей", func(t *testing.T) {
+		cache := newCache(t)
+		err := cache.HSet(ctx, "fsm", map[string][]byte{"state": []byte("awaiting_phone"), "step": []byte("2")})
+		if err != nil {
+			t.Fatalf("HSet: %v", err)
+		}
+		if got, err := cache.HGet(ctx, "fsm", "state"); err != nil || string(got) != "awaiting_phone" {
+			t.Errorf("HGet: %q, %v", got, err)
+		}
+		if _, err := cache.HGet(ctx, "fsm", "missing"); !errors.Is(err, global_cache.ErrNotFound) {
+			t.Errorf("HGet отсутствующего поля: %v", err)
+		}
+
+		all, err := cache.HGetAll(ctx, "fsm")
+		if err != nil || len(all) != 2 || string(all["step"]) != "2" {
+			t.Errorf("HGetAll: %v, %v", all, err)
+		}
+	})
+
+	t.Run("HDel и пустой хэш", func(t *testing.T) {
+		cache := newCache(t)
+		cache.HSet(ctx, "fsm", map[string][]byte{"state": []byte("x")})
+		if err := cache.HDel(ctx, "fsm", "state"); err != nil {
+			t.Fatalf("HDel: %v", err)
+		}
+		all, err := cache.HGetAll(ctx, "fsm")
+		if err != nil || len(all) != 0 {
+			t.Errorf("HGetAll после удаления: %v, %v", all, err)
+		}
+		if exists, _ := cache.Exists(ctx, "fsm"); exists {
+			t.Error("пустой хэш должен исчезнуть")
+		}
+	})
+
+	t.Run("HIncrBy", func(t *testing.T) {
+		cache := newCache(t)
+		if n, err := cache.HIncrBy(ctx, "stats", "clicks", 2); err != nil || n != 2 {
+			t.Fatalf("HIncrBy: %d, %v", n, err)
+		}
+		if n, err := cache.HIncrBy(ctx, "stats", "clicks", 3); err != nil || n != 5 {
+			t.Fatalf("HIncrBy: %d, %v", n, err)
+		}
+	})
+}
+
+func testLists(t *testing.T, newCache Factory) {
+	ctx := context.Background()
+
+	t.Run("порядок LPush и RPush", func(t *testing.T) {
+		cache := newCache(t)
+		cache.RPush(ctx, "queue", []byte("a"), []byte("b"))
+		n, err := cache.LPush(ctx, "queue", []byte("y"), []byte("z"))
+		if err != nil || n != 4 {
+			t.Fatalf("LPush: %d, %v", n, err)
+		}
+
+		got, err := cache.LRange(ctx, "queue", 0, -1)
+		if err != nil {
+			t.Fatalf("LRange: %v", err)
+		}
+		if want := []string{"z", "y", "a", "b"}; !slices.Equal(toStrings(got), want) {
+			t.Errorf("LRange: %v, ожидали %v", toStrings(got), want)
+		}
+		if got, _ := cache.LRange(ctx, "queue", 1, 2); !slices.Equal(toStrings(got), []string{"y", "a"}) {
+			t.Errorf("LRange 1..2: %v", toStrings(got))
+		}
+		if got, _ := cache.LRange(ctx, "queue", -2, 100); !slices.Equal(toStrings(got), []string{"a", "b"}) {
+			t.Errorf("LRange -2..100: %v", toStrings(got))
+		}
+	})
+
+	t.Run("LPop до пустого списка", func(t *testing.T) {
+		cache := newCache(t)
+		cache.RPush(ctx, "queue", []byte("1"), []byte("2"))
+
+		for _, want := range []string{"1", "2"} {
+			got, err := cache.LPop(ctx, "queue")
+			if err != nil || string(got) != want {
+				t.Fatalf("LPop: %q, %v, ожидали %q", got, err, want)
+			}
+		}
+		if _, err := cache.LPop(ctx, "queue"); !errors.Is(err, global_cache.ErrNotFound) {
+			t.Errorf("LPop пустого: %v", err)
+		}
+		if n, err := cache.LLen(ctx, "queue"); err != nil || n != 0 {
+			t.Errorf("LLen: %d, %v", n, err)
+		}
+		if exists, _ := cache.Exists(ctx, "queue"); exists {
+			t.Error("пустой список должен исчезнуть")
+		}
+	})
+
+	t.Run("LRange отсутствующего списка - пусто", func(t *testing.T) {
+		cache := newCache(t)
+		got, err := cache.LRange(ctx, "missing", 0, -1)
+		if err != nil || len(got) != 0 {
+			t.Errorf("LRange: %v, %v", got, err)
+		}
+	})
+}
+
+func testSortedSets(t *testing.T, newCache Factory) {
+	ctx := context.Background()
+
+	t.Run("выборка по диапазону score", func(t *testing.T) {
+		cache := newCache(t)
+		err := cache.ZAdd(ctx, "timers",
+			global_cache.ZMember{Member: "c", Score: 30},
+			global_cache.ZMember{Member: "a", Score: 10},
+			global_cache.ZMember{Member: "b", Score: 20},
+		)
+		if err != nil {
+			t.Fatalf("ZAdd: %v", err)
+		}
+
+		got, err := cache.ZRangeByScore(ctx, "timers", math.Inf(-1), 25, 0)
+		if err != nil {
+			t.Fatalf("ZRangeByScore: %v", err)
+		}
+		if want := []global_cache.ZMember{{Member: "a", Score: 10}, {Member: "b", Score: 20}}; !slices.Equal(got, want) {
+			t.Errorf("ZRangeByScore: %v, ожидали %v", got, want)
+		}
+
+		limited, _ := cache.ZRangeByScore(ctx, "timers", 0, math.Inf(1), 2)
+		if len(limited) != 2 || limited[0].Member != "a" {
+			t.Errorf("ZRangeByScore с limit: %v", limited)
+		}
+	})
+
+	t.Run("ZAdd меняет score, ZRem удаляет", func(t *testing.T) {
+		cache := newCache(t)
+		cache.ZAdd(ctx, "timers", global_cache.ZMember{Member: "a", Score: 1})
+		cache.ZAdd(ctx, "timers", global_cache.ZMember{Member: "a", Score: 5})
+
+		if score, err := cache.ZScore(ctx, "timers", "a"); err != nil || score != 5 {
+			t.Errorf("ZScore: %v, %v", score, err)
+		}
+		if n, _ := cache.ZCard(ctx, "timers"); n != 1 {
+			t.Errorf("ZCard: %d", n)
+		}
+
+		if err := cache.ZRem(ctx, "timers", "a"); err != nil {
+			t.Fatalf("ZRem: %v", err)
+		}
+		if _, err := cache.ZScore(ctx, "timers", "a"); !errors.Is(err, global_cache.ErrNotFound) {
+			t.Errorf("ZScore удалённого: %v", err)
+		}
+		if n, _ := cache.ZCard(ctx, "timers"); n != 0 {
+			t.Errorf("ZCard после удаления: %d", n)
+		}
+	})
+}
+
+func testTTL(t *testing.T, newCache Factory, advance Advance) {
+	ctx := context.Background()
+
+	t.Run("TTL отсутствующего и бессрочного ключа", func(t *testing.T) {
+		cache := newCache(t)
+		if ttl, err := cache.TTL(ctx, "missing"); err != nil || ttl != -2 {
+			t.Errorf("TTL отсутствующего: %v, %v", ttl, err)
+		}
+		cache.Set(ctx, "key", []byte("v"), 0)
+		if ttl, err := cache.TTL(ctx, "key"); err != nil || ttl != -1 {
+			t.Errorf("TTL бессрочного: %v, %v", ttl, err)
+		}
+	})
+
+	t.Run("ключ исчезает по истечении времени жизни", func(t *testing.T) {
+		cache := newCache(t)
+		cache.Set(ctx, "short", []byte("v"), 100*time.Millisecond)
+		cache.Set(ctx, "long", []byte("v"), time.Minute)
+
+		if ttl, _ := cache.TTL(ctx, "long"); ttl <= 0 || ttl > time.Minute {
+			t.Errorf("TTL: %v", ttl)
+		}
+
+		advance(200 * time.Millisecond)
+		if _, err := cache.Get(ctx, "short"); !errors.Is(err, global_cache.ErrNotFound) {
+			t.Errorf("истёкший ключ читается: %v", err)
+		}
+		if _, err := cache.Get(ctx, "long"); err != nil {
+			t.Errorf("ключ с большим TTL пропал: %v", err)
+		}
+	})
+
+	t.Run("Expire задаёт время жизни существующему ключу", func(t *testing.T) {
+		cache := newCache(t)
+		cache.RPush(ctx, "queue", []byte("v"))
+		if err := cache.Expire(ctx, "queue", 100*time.Millisecond); err != nil {
+			t.Fatalf("Expire: %v", err)
+		}
+		advance(200 * time.Millisecond)
+		if exists, _ := cache.Exists(ctx, "queue"); exists {
+			t.Error("ключ не истёк")
+		}
+	})
+
+	t.Run("GetSet сбрасывает время жизни", func(t *testing.T) {
+		cache := newCache(t)
+		cache.Set(ctx, "key", []byte("v"), time.Minute)
+		cache.GetSet(ctx, "key", []byte("w"))
+		if ttl, _ := cache.TTL(ctx, "key"); ttl != -1 {
+			t.Errorf("TTL после GetSet: %v", ttl)
+		}
+	})
+}
+
+// функция для сравнения списков в тестах
+func toStrings(values [][]byte) []string {
+	result := make([]string, len(values))
+	for i, value := range values {
+		result[i] = string(value)
+	}
+	return result
+}
@@ -0,0 +1,85 @@
+package cachetest
+
+import (
+	"context"
+	"global_models/global_cache"
+	"testing"
+	"time"
+)
+
+// PubSubFactory возвращает реализацию PubSub для одного теста
+type PubSubFactory func(t *testing.T) global_cache.PubSub
+
+// RunPubSubContract прогоняет набор тестов на реализации PubSub
+func RunPubSubContract(t *testing.T, newPubSub PubSubFactory) {
+	t.Run("сообщение получают все подписчики канала", func(t *testing.T) {
+		ps := newPubSub(t)
+		first := subscribe(t, ps, "events")
+		second := subscribe(t, ps, "events")
+		other := subscribe(t, ps, "other")
+
+		if err := ps.Publish(context.Background(), "events", []byte("hello")); err != nil {
+			t.Fatalf("Publish: %v", err)
+		}
+		for i, messages := range []chan []byte{first, second} {
+			select {
+			case got := <-messages:
+				if string(got) != "hello" {
+					t.Errorf("подписчик %d получил %q", i, got)
+				}
+			case <-time.After(2 * time.Second):
+				t.Fatalf("подписчик %d не получил сообщение", i)
+			}
+		}
+		select {
+		case got := <-other:
+			t.Errorf("подписчик другого канала получил %q", got)
+		case <-time.After(50 * time.Millisecond):
+		}
+	})
+
+	t.Run("Subscribe завершается отменой контекста", func(t *testing.T) {
+		ps := newPubSub(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error, 1)
+		go func() { done <- ps.Subscribe(ctx, "events", func([]byte) {}) }()
+
+		cancel()
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Errorf("Subscribe: %v", err)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("Subscribe не завершился")
+		}
+	})
+}
+
+// функция подписки в фоне: возвращает канал сообщений после подтверждения подписки (handler(nil))
+func subscribe(t *testing.T, ps global_cache.PubSub, channel string) chan []byte {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	ready := make(chan struct{})
+	messages := make(chan []byte, 10)
+	go ps.Subscribe(ctx, channel, func(payload []byte) {
+		if payload == nil {
+			select {
+			case <-ready:
+			default:
+				close(ready)
+			}
+			return
+		}
+		messages <- payload
+	})
+
+	select {
+	case <-ready:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("подписка на %s не установлена", channel)
+	}
+	return messages
+}
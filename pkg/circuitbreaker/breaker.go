@@ -0,0 +1,185 @@
+// Пакет circuitbreaker реализует "предохранитель" для вызовов внешнего сервиса.
+//
+// Состояния:
+//   - Closed (замкнута) - запросы проходят, считаем ошибки подряд;
+//   - Open (разомкнута) - после FailureThreshold ошибок подряд запросы сразу отклоняются с ErrOpen;
+//   - HalfOpen (полуоткрыта) - через OpenTimeout пропускаем пробные запросы:
+//     успех замыкает цепь, ошибка снова размыкает.
+package circuitbreaker
+
+import (
+	"errors"
+	"pkg/configs"
+	"sync"
+	"time"
+)
+
+// ErrOpen - цепь разомкнута, запрос не выполняется
+var ErrOpen = errors.New("circuit breaker is open")
+
+// State - состояние предохранителя
+type State int
+
+const (
+	StateClosed State = iota
+	StateOpen
+	StateHalfOpen
+)
+
+// метод для текстового представления состояния (для логов)
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// Breaker - потокобезопасный circuit breaker
+type Breaker struct {
+	config *configs.CircuitBreakerConfig
+
+	mu        sync.Mutex
+	state     State
+	failures  int       // ошибок подряд в замкнутом состоянии
+	successes int       // успешных пробных запросов в полуоткрытом состоянии
+	probes    int       // пробных запросов в работе
+	openedAt  time.Time // когда цепь разомкнулась
+
+	onStateChange func(from, to State) // колбэк при смене состояния (опционально)
+	now           func() time.Time     // источник времени (подменяется в тестах)
+}
+
+// конструктор для circuit breaker
+func New(config *configs.CircuitBreakerConfig) *Breaker {
+	if config == nil {
+		config = configs.UseDefaultCircuitBreakerConfig()
+	}
+
+	return &Breaker{
+		config: config,
+		state:  StateClosed,
+		now:    time.Now,
+	}
+}
+
+// OnStateChange устанавливает колбэк, который вызывается при смене состояния.
+// Колбэк вызывается под блокировкой, поэтому внутри него нельзя обращаться к методам Breaker
+func (b *Breaker) OnStateChange(fn func(from, to State)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.onStateChange = fn
+}
+
+// State возвращает текущее состояние
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refresh()
+	return b.state
+}
+
+// Allow проверяет, можно ли выполнить запрос.
+// Если ошибки нет, вызывающий обязан сообщить результат через RecordSuccess или RecordFailure
+func (b *Breaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refresh()
+
+	switch b.state {
+	case StateOpen:
+		return ErrOpen
+	case StateHalfOpen:
+		if b.probes >= b.config.HalfOpenMaxRequests {
+			return ErrOpen
+		}
+		b.probes++
+	}
+
+	return nil
+}
+
+// RecordSuccess фиксирует успешный запрос
+func (b *Breaker) RecordSuccess() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case StateClosed:
+		b.failures = 0
+	case StateHalfOpen:
+		b.releaseProbe()
+		b.successes++
+		if b.successes >= b.config.SuccessThreshold {
+			b.setState(StateClosed)
+		}
+	}
+}
+
+// RecordFailure фиксирует неуспешный запрос
+func (b *Breaker) RecordFailure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case StateClosed:
+		b.failures++
+		if b.failures >= b.config.FailureThreshold {
+			b.setState(StateOpen)
+		}
+	case StateHalfOpen:
+		b.releaseProbe()
+		b.setState(StateOpen)
+	}
+}
+
+// RecordIgnored освобождает слот пробного запроса, не влияя на состояние
+// (например, запрос отменил сам клиент)
+func (b *Breaker) RecordIgnored() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == StateHalfOpen {
+		b.releaseProbe()
+	}
+}
+
+// refresh переводит разомкнутую цепь в полуоткрытое состояние по истечении OpenTimeout (вызывается под мьютексом)
+func (b *Breaker) refresh() {
+	if b.state == StateOpen && b.now().Sub(b.openedAt) >= b.config.OpenTimeout {
+		b.setState(StateHalfOpen)
+	}
+}
+
+// releaseProbe освобождает слот пробного запроса (вызывается под мьютексом)
+func (b *Breaker) releaseProbe() {
+	if b.probes > 0 {
+		b.probes--
+	}
+}
+
+// setState меняет состояние и сбрасывает счётчики (вызывается под мьютексом)
+func (b *Breaker) setState(to State) {
+	from := b.state
+	if from == to {
+		return
+	}
+
+	b.state = to
+	b.failures = 0
+	b.successes = 0
+	b.probes = 0
+	if to == StateOpen {
+		b.openedAt = b.now()
+	}
+
+	if b.onStateChange != nil {
+		b.onStateChange(from, to)
+	}
+}
@@ -0,0 +1,92 @@
+package circuitbreaker
+
+import (
+	"errors"
+	"pkg/configs"
+	"testing"
+	"time"
+)
+
+func newTestBreaker() (*Breaker, *time.Time) {
+	now := time.Unix(0, 0)
+	b := New(&configs.CircuitBreakerConfig{
+		FailureThreshold:    3,
+		OpenTimeout:         10 * time.Second,
+		HalfOpenMaxRequests: 1,
+		SuccessThreshold:    1,
+	})
+	b.now = func() time.Time { return now }
+	return b, &now
+}
+
+func TestBreaker(t *testing.T) {
+	t.Run("размыкается после порога ошибок подряд", func(t *testing.T) {
+		b, _ := newTestBreaker()
+
+		for i := 0; i < 3; i++ {
+			if err := b.Allow(); err != nil {
+				t.Fatalf("запрос %d не должен отклоняться: %v", i, err)
+			}
+			b.RecordFailure()
+		}
+
+		if b.State() != StateOpen {
+			t.Fatalf("ожидалось состояние open, получено %s", b.State())
+		}
+		if err := b.Allow(); !errors.Is(err, ErrOpen) {
+			t.Errorf("ожидалась ошибка ErrOpen, получено %v", err)
+		}
+	})
+
+	t.Run("успех сбрасывает счётчик ошибок", func(t *testing.T) {
+		b, _ := newTestBreaker()
+
+		b.RecordFailure()
+		b.RecordFailure()
+		b.RecordSuccess()
+		b.RecordFailure()
+
+		if b.State() != StateClosed {
+			t.Errorf("ожидалось состояние closed, получено %s", b.State())
+		}
+	})
+
+	t.Run("пробный запрос после таймаута замыкает цепь", func(t *testing.T) {
+		b, now := newTestBreaker()
+		for i := 0; i < 3; i++ {
+			b.RecordFailure()
+		}
+
+		*now = now.Add(10 * time.Second)
+
+		if err := b.Allow(); err != nil {
+			t.Fatalf("пробный запрос должен пройти: %v", err)
+		}
+		// второй пробный запрос одновременно не пропускаем
+		if err := b.Allow(); !errors.Is(err, ErrOpen) {
+			t.Errorf("ожидалась ошибка ErrOpen для второго пробного запроса, получено %v", err)
+		}
+
+		b.RecordSuccess()
+		if b.State() != StateClosed {
+			t.Errorf("ожидалось состояние closed, получено %s", b.State())
+		}
+	})
+
+	t.Run("ошибка пробного запроса снова размыкает цепь", func(t *testing.T) {
+		b, now := newTestBreaker()
+		for i := 0; i < 3; i++ {
+			b.RecordFailure()
+		}
+
+		*now = now.Add(10 * time.Second)
+		if err := b.Allow(); err != nil {
+			t.Fatalf("пробный запрос должен пройти: %v", err)
+		}
+		b.RecordFailure()
+
+		if b.State() != StateOpen {
+			t.Errorf("ожидалось состояние open, получено %s", b.State())
+		}
+	})
+}
@@ -0,0 +1,42 @@
+package configs
+
+import (
+	"os"
+	"time"
+)
+
+// конфиг входа в админку через Telegram Login Widget и сессий администраторов
+type AdminAuthConfig struct {
+	Enabled          bool          `yaml:"enabled"`            // false - API админки без входа (только для локального запуска)
+	BotTokenEnv      string        `yaml:"bot_token_env"`      // переменная окружения с токеном бота (ключ проверки подписи виджета)
+	SessionSecretEnv string        `yaml:"session_secret_env"` // переменная окружения с секретом подписи токенов доступа (от 32 символов)
+	LoginMaxAge      time.Duration `yaml:"login_max_age"`      // сколько действительны данные виджета после auth_date
+	AccessTTL        time.Duration `yaml:"access_ttl"`         // время жизни токена доступа (роль перечитывается при обновлении)
+	RefreshTTL       time.Duration `yaml:"refresh_ttl"`        // время жизни сессии (токена обновления)
+	CookieSecure     bool          `yaml:"cookie_secure"`      // куки только по HTTPS
+	CookieDomain     string        `yaml:"cookie_domain"`      // домен кук (пусто - текущий хост)
+	OwnerIDs         []int64       `yaml:"owner_ids"`          // telegram_id владельцев, добавляются при старте (чтобы было кому войти)
+}
+
+// метод получения токена бота из переменной окружения
+func (c *AdminAuthConfig) BotToken() string {
+	return os.Getenv(c.BotTokenEnv)
+}
+
+// метод получения секрета подписи из переменной окружения
+func (c *AdminAuthConfig) SessionSecret() string {
+	return os.Getenv(c.SessionSecretEnv)
+}
+
+// дэфолтный конфиг
+func UseDefaultAdminAuthConfig() *AdminAuthConfig {
+	return &AdminAuthConfig{
+		Enabled:          true,
+		BotTokenEnv:      "BOT_TOKEN",
+		SessionSecretEnv: "ADMIN_SESSION_SECRET",
+		LoginMaxAge:      24 * time.Hour,
+		AccessTTL:        15 * time.Minute,
+		RefreshTTL:       30 * 24 * time.Hour,
+		CookieSecure:     true,
+	}
+}
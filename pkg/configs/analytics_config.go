@@ -0,0 +1,17 @@
+package configs
+
+// конфиг аналитики: воронки, источники и когорты пересчитываются планировщиком в таблицы агрегатов
+type AnalyticsConfig struct {
+	RefreshSpec string `yaml:"refresh_spec"` // cron расписание пересчёта агрегатов
+	Days        int    `yaml:"days"`         // сколько последних дней пересчитываются дневные воронки
+	Weeks       int    `yaml:"weeks"`        // сколько последних недель пересчитываются недельные воронки и когорты
+}
+
+// дэфолтный конфиг
+func UseDefaultAnalyticsConfig() *AnalyticsConfig {
+	return &AnalyticsConfig{
+		RefreshSpec: "5 * * * *",
+		Days:        35,
+		Weeks:       12,
+	}
+}
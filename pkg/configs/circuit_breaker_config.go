@@ -0,0 +1,21 @@
+package configs
+
+import "time"
+
+// конфиг для circuit breaker (автоматический "предохранитель" для вызовов внешнего сервиса)
+type CircuitBreakerConfig struct {
+	FailureThreshold    int           `yaml:"failure_threshold"`      // сколько ошибок подряд размыкают цепь
+	OpenTimeout         time.Duration `yaml:"open_timeout"`           // сколько цепь остаётся разомкнутой до пробного запроса
+	HalfOpenMaxRequests int           `yaml:"half_open_max_requests"` // сколько пробных запросов одновременно пропускаем в полуоткрытом состоянии
+	SuccessThreshold    int           `yaml:"success_threshold"`      // сколько успешных пробных запросов замыкают цепь
+}
+
+// дэфолтный конфиг
+func UseDefaultCircuitBreakerConfig() *CircuitBreakerConfig {
+	return &CircuitBreakerConfig{
+		FailureThreshold:    5,
+		OpenTimeout:         30 * time.Second,
+		HalfOpenMaxRequests: 1,
+		SuccessThreshold:    1,
+	}
+}
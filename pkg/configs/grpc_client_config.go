@@ -7,10 +7,26 @@ import (
 
 // конфиг для grpc клиента
 type GRPCClientConfig struct {
-	Host       string        `yaml:"host"`       // хост сервера, к которому подключается клиент
-	Port       string        `yaml:"port"`       // порт сервра
-	TimeOut    time.Duration `yaml:"timeout"`    // Таймаут для запросов
-	MaxRetries int           `yml:"max_retries"` // Количество повторных попыток (опционально)
+	Host       string        `yaml:"host"`        // хост сервера, к которому подключается клиент
+	Port       string        `yaml:"port"`        // порт сервра
+	TimeOut    time.Duration `yaml:"timeout"`     // Таймаут для запросов (дедлайн вызова, если вызывающий не задал свой)
+	MaxRetries int           `yaml:"max_retries"` // Количество повторных попыток (опционально)
+
+	// политика повторов (применяется gRPC автоматически, только для RetryableCodes)
+	InitialBackoff    time.Duration `yaml:"initial_backoff"`    // пауза перед первым повтором
+	MaxBackoff        time.Duration `yaml:"max_backoff"`        // максимальная пауза между повторами
+	BackoffMultiplier float64       `yaml:"backoff_multiplier"` // множитель паузы для следующего повтора
+	RetryableCodes    []string      `yaml:"retryable_codes"`    // коды ответа, при которых повторяем вызов (например UNAVAILABLE)
+	WaitForReady      bool          `yaml:"wait_for_ready"`     // ждать восстановления соединения (в пределах дедлайна), а не падать сразу
+
+	// keepalive и переподключение
+	KeepaliveTime       time.Duration `yaml:"keepalive_time"`        // как часто пинговать сервер при простое
+	KeepaliveTimeout    time.Duration `yaml:"keepalive_timeout"`     // сколько ждать ответа на пинг
+	PermitWithoutStream bool          `yaml:"permit_without_stream"` // пинговать даже без активных вызовов
+	MaxReconnectBackoff time.Duration `yaml:"max_reconnect_backoff"` // максимальная пауза между попытками переподключения
+
+	TLS  TLSConfig  `yaml:"tls"`  // TLS / mTLS
+	Auth AuthConfig `yaml:"auth"` // аутентификация по общему секрету
 }
 
 // метод получения адреса
@@ -21,9 +37,20 @@ func (c *GRPCClientConfig) Addr() string {
 // дэфолтный конфиг
 func UseDefaultGRPCClientConfig() *GRPCClientConfig {
 	return &GRPCClientConfig{
-		Host:       "localhost",
-		Port:       "50050",
-		TimeOut:    30 * time.Second,
-		MaxRetries: 3,
+		Host:                "localhost",
+		Port:                "50050",
+		TimeOut:             30 * time.Second,
+		MaxRetries:          3,
+		InitialBackoff:      200 * time.Millisecond,
+		MaxBackoff:          5 * time.Second,
+		BackoffMultiplier:   2,
+		RetryableCodes:      []string{"UNAVAILABLE"},
+		WaitForReady:        false,
+		KeepaliveTime:       5 * time.Minute,
+		KeepaliveTimeout:    20 * time.Second,
+		PermitWithoutStream: false,
+		MaxReconnectBackoff: 30 * time.Second,
+		TLS:                 *UseDefaultTLSConfig(),
+		Auth:                *UseDefaultAuthConfig(),
 	}
 }
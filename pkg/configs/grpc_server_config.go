@@ -16,6 +16,10 @@ type GRPCServerConfig struct {
 	KeepaliveTimeout      time.Duration `yaml:"keepalive_timeout"`
 	MaxRecvMsgSize        int           `yaml:"max_recv_msg_size"`
 	MaxSendMsgSize        int           `yaml:"max_send_msg_size"`
+
+	Interceptors InterceptorsConfig `yaml:"interceptors"` // цепочка interceptor-ов (recovery, логи, метрики, дедлайны)
+	TLS          TLSConfig          `yaml:"tls"`          // TLS / mTLS
+	Auth         AuthConfig         `yaml:"auth"`         // аутентификация по общему секрету
 }
 
 // метод получения адреса
@@ -35,5 +39,8 @@ func UseDefaultGRPCServerConfig() *GRPCServerConfig {
 		KeepaliveTimeout:      20 * time.Second,
 		MaxRecvMsgSize:        10485760,
 		MaxSendMsgSize:        10485760,
+		Interceptors:          *UseDefaultInterceptorsConfig(),
+		TLS:                   *UseDefaultTLSConfig(),
+		Auth:                  *UseDefaultAuthConfig(),
 	}
 }
@@ -0,0 +1,21 @@
+package configs
+
+import "time"
+
+// конфиг диалога с оператором: пока диалог открыт, бот не отвечает клиенту
+type HandoffConfig struct {
+	InactivityTimeout time.Duration `yaml:"inactivity_timeout"` // диалог без сообщений дольше этого закрывается, бот снова отвечает
+	ExpireSpec        string        `yaml:"expire_spec"`        // cron расписание проверки неактивных диалогов
+	Channel           string        `yaml:"channel"`            // канал redis pub/sub для событий чатов (консоли на всех экземплярах)
+	KeepaliveInterval time.Duration `yaml:"keepalive_interval"` // как часто консоли отправляется пустое событие, чтобы прокси не закрыл поток
+}
+
+// дэфолтный конфиг
+func UseDefaultHandoffConfig() *HandoffConfig {
+	return &HandoffConfig{
+		InactivityTimeout: 30 * time.Minute,
+		ExpireSpec:        "* * * * *",
+		Channel:           "chat_events",
+		KeepaliveInterval: 25 * time.Second,
+	}
+}
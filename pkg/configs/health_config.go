@@ -0,0 +1,17 @@
+package configs
+
+import "time"
+
+// конфиг проверок здоровья сервиса (gRPC health и HTTP /healthz, /readyz)
+type HealthConfig struct {
+	Interval time.Duration `yaml:"interval"` // как часто проверять зависимости
+	Timeout  time.Duration `yaml:"timeout"`  // таймаут одной проверки
+}
+
+// дэфолтный конфиг
+func UseDefaultHealthConfig() *HealthConfig {
+	return &HealthConfig{
+		Interval: 10 * time.Second,
+		Timeout:  3 * time.Second,
+	}
+}
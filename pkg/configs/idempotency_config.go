@@ -0,0 +1,25 @@
+package configs
+
+import "time"
+
+// конфиг для слоя идемпотентности (защита от повторной обработки одного и того же update)
+type IdempotencyConfig struct {
+	Enabled      bool          `yaml:"enabled"`       // включена ли дедупликация
+	Prefix       string        `yaml:"prefix"`        // префикс ключей в кэше
+	ResultTTL    time.Duration `yaml:"result_ttl"`    // сколько храним результат обработки (Telegram может повторять доставку долго)
+	LockTTL      time.Duration `yaml:"lock_ttl"`      // время жизни метки "в обработке" (страховка на случай падения обработчика)
+	WaitTimeout  time.Duration `yaml:"wait_timeout"`  // сколько дубликат ждёт результата первой обработки
+	PollInterval time.Duration `yaml:"poll_interval"` // интервал опроса кэша при ожидании результата
+}
+
+// дэфолтный конфиг
+func UseDefaultIdempotencyConfig() *IdempotencyConfig {
+	return &IdempotencyConfig{
+		Enabled:      true,
+		Prefix:       "idempotency",
+		ResultTTL:    24 * time.Hour,
+		LockTTL:      30 * time.Second,
+		WaitTimeout:  20 * time.Second,
+		PollInterval: 100 * time.Millisecond,
+	}
+}
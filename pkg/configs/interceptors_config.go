@@ -0,0 +1,32 @@
+package configs
+
+import "time"
+
+// конфиг цепочки серверных gRPC interceptor-ов
+type InterceptorsConfig struct {
+	Recovery       bool                     `yaml:"recovery"`        // перехват паники в хэндлере (ответ codes.Internal вместо падения процесса)
+	AccessLog      bool                     `yaml:"access_log"`      // структурированный лог каждого вызова: метод, длительность, код
+	RequestID      bool                     `yaml:"request_id"`      // проброс request-id из метаданных (x-request-id) или генерация нового
+	Metrics        bool                     `yaml:"metrics"`         // prometheus счётчики и гистограммы по методам
+	DefaultTimeout time.Duration            `yaml:"default_timeout"` // дедлайн для вызовов, пришедших без дедлайна (0 - не ставим)
+	MethodTimeouts map[string]time.Duration `yaml:"method_timeouts"` // дедлайны для отдельных методов (ключ - полное имя, например /bot.BotService/ProcessUpdate)
+}
+
+// метод для получения дедлайна по умолчанию для метода
+func (c *InterceptorsConfig) TimeoutFor(fullMethod string) time.Duration {
+	if timeout, ok := c.MethodTimeouts[fullMethod]; ok {
+		return timeout
+	}
+	return c.DefaultTimeout
+}
+
+// дэфолтный конфиг
+func UseDefaultInterceptorsConfig() *InterceptorsConfig {
+	return &InterceptorsConfig{
+		Recovery:       true,
+		AccessLog:      true,
+		RequestID:      true,
+		Metrics:        true,
+		DefaultTimeout: 30 * time.Second,
+	}
+}
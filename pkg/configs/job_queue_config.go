@@ -0,0 +1,37 @@
+package configs
+
+import "time"
+
+// конфиг очереди фоновых задач (redis streams)
+type JobQueueConfig struct {
+	Enabled           bool          `yaml:"enabled"`            // false - задачи выполняются сразу, без очереди
+	Prefix            string        `yaml:"prefix"`             // префикс ключей очереди в redis
+	Workers           int           `yaml:"workers"`            // сколько задач экземпляр выполняет одновременно
+	MaxAttempts       int           `yaml:"max_attempts"`       // попыток выполнения, после которых задача уходит в dead-letter
+	RetryBackoff      time.Duration `yaml:"retry_backoff"`      // пауза перед второй попыткой (дальше удваивается)
+	MaxBackoff        time.Duration `yaml:"max_backoff"`        // максимальная пауза между попытками
+	JobTimeout        time.Duration `yaml:"job_timeout"`        // таймаут одной попытки
+	VisibilityTimeout time.Duration `yaml:"visibility_timeout"` // задача без подтверждения дольше этого забирается другим обработчиком (больше job_timeout)
+	ClaimInterval     time.Duration `yaml:"claim_interval"`     // как часто искать зависшие задачи
+	PollInterval      time.Duration `yaml:"poll_interval"`      // как часто переносить в очередь отложенные задачи, время которых пришло
+	BlockTimeout      time.Duration `yaml:"block_timeout"`      // сколько обработчик ждёт новую задачу за один запрос (и максимум задержки остановки)
+	MaxLen            int64         `yaml:"max_len"`            // примерная максимальная длина потоков задач и dead-letter
+}
+
+// дэфолтный конфиг
+func UseDefaultJobQueueConfig() *JobQueueConfig {
+	return &JobQueueConfig{
+		Enabled:           true,
+		Prefix:            "server_jobs",
+		Workers:           4,
+		MaxAttempts:       5,
+		RetryBackoff:      time.Second,
+		MaxBackoff:        5 * time.Minute,
+		JobTimeout:        30 * time.Second,
+		VisibilityTimeout: 2 * time.Minute,
+		ClaimInterval:     30 * time.Second,
+		PollInterval:      time.Second,
+		BlockTimeout:      2 * time.Second,
+		MaxLen:            100000,
+	}
+}
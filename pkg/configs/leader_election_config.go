@@ -0,0 +1,23 @@
+package configs
+
+import "time"
+
+// конфиг выбора лидера среди экземпляров сервиса (фоновые задачи выполняет только лидер)
+type LeaderElectionConfig struct {
+	Enabled       bool          `yaml:"enabled"`        // false - экземпляр всегда считает себя лидером (один экземпляр)
+	Name          string        `yaml:"name"`           // имя блокировки лидера (общее для всех экземпляров)
+	TTL           time.Duration `yaml:"ttl"`            // время жизни блокировки: за это время после падения лидера выбирается новый
+	RenewInterval time.Duration `yaml:"renew_interval"` // как часто лидер продлевает блокировку (меньше TTL)
+	RetryInterval time.Duration `yaml:"retry_interval"` // как часто остальные экземпляры пробуют стать лидером
+}
+
+// дэфолтный конфиг
+func UseDefaultLeaderElectionConfig() *LeaderElectionConfig {
+	return &LeaderElectionConfig{
+		Enabled:       true,
+		Name:          "biz-server-leader",
+		TTL:           15 * time.Second,
+		RenewInterval: 5 * time.Second,
+		RetryInterval: 5 * time.Second,
+	}
+}
@@ -0,0 +1,17 @@
+package configs
+
+// конфиг логгера
+type LoggerConfig struct {
+	Level     string `yaml:"level"`      // debug, info, warn, error
+	Format    string `yaml:"format"`     // json или text (пусто - выбирается по переменной окружения ENV)
+	AddSource bool   `yaml:"add_source"` // добавлять файл и строку вызова
+}
+
+// дэфолтный конфиг
+func UseDefaultLoggerConfig() *LoggerConfig {
+	return &LoggerConfig{
+		Level:     "info",
+		Format:    "",
+		AddSource: false,
+	}
+}
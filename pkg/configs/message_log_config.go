@@ -0,0 +1,27 @@
+package configs
+
+import "time"
+
+// конфиг асинхронной записи журнала сообщений и колбэков (буфер в памяти + пакетная запись в БД)
+type MessageLogConfig struct {
+	Async          bool          `yaml:"async"`           // писать журнал в фоне (false - синхронно, как раньше)
+	QueueSize      int           `yaml:"queue_size"`      // сколько записей может ждать в буфере
+	BatchSize      int           `yaml:"batch_size"`      // записей в одной пачке (при наборе пачка пишется сразу)
+	FlushInterval  time.Duration `yaml:"flush_interval"`  // неполная пачка пишется не реже этого интервала
+	EnqueueTimeout time.Duration `yaml:"enqueue_timeout"` // сколько обработчик update ждёт места в заполненном буфере (потом запись отбрасывается)
+	FlushTimeout   time.Duration `yaml:"flush_timeout"`   // таймаут записи одной пачки
+	MaxRetries     int           `yaml:"max_retries"`     // попыток записи пачки, после которых она отбрасывается
+}
+
+// дэфолтный конфиг
+func UseDefaultMessageLogConfig() *MessageLogConfig {
+	return &MessageLogConfig{
+		Async:          true,
+		QueueSize:      10000,
+		BatchSize:      500,
+		FlushInterval:  time.Second,
+		EnqueueTimeout: 50 * time.Millisecond,
+		FlushTimeout:   10 * time.Second,
+		MaxRetries:     5,
+	}
+}
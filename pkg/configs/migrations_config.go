@@ -0,0 +1,29 @@
+package configs
+
+// режимы проверки миграций при старте сервиса
+const (
+	MigrationsModeOff   = "off"   // миграции при старте не проверяются
+	MigrationsModeAuto  = "auto"  // недостающие миграции применяются при старте
+	MigrationsModeCheck = "check" // при непримененных миграциях сервис не запускается
+)
+
+// конфиг миграций схемы БД
+type MigrationsConfig struct {
+	Mode  string `yaml:"mode"`  // off, auto или check
+	Table string `yaml:"table"` // таблица с версиями примененных миграций
+	Dir   string `yaml:"dir"`   // каталог с исходниками миграций (для команды migrate create)
+}
+
+// Enabled сообщает, что миграции нужно проверять при старте
+func (c *MigrationsConfig) Enabled() bool {
+	return c.Mode != "" && c.Mode != MigrationsModeOff
+}
+
+// дэфолтный конфиг
+func UseDefaultMigrationsConfig() *MigrationsConfig {
+	return &MigrationsConfig{
+		Mode:  MigrationsModeCheck,
+		Table: "goose_db_version",
+		Dir:   "server/migrations",
+	}
+}
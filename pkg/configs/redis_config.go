@@ -8,8 +8,15 @@ import (
 	"github.com/go-redis/redis/v8"
 )
 
+// реализации кэша
+const (
+	RedisDriverRedis  = "redis"  // Redis (по умолчанию)
+	RedisDriverMemory = "memory" // кэш в памяти процесса - для локального запуска без Redis
+)
+
 // структура конфига для Redis
 type RedisConfig struct {
+	Driver          string        // реализация кэша: redis или memory (REDIS_DRIVER)
 	Host            string        // Хост, где расположен redis
 	Port            string        // Порт для подключения
 	Password        string        // Пароль
@@ -32,6 +39,16 @@ type RedisConfig struct {
 func NewRedisConfigFromEnv() (*RedisConfig, error) {
 	var errors []string
 
+	// кэш в памяти не требует настроек подключения
+	driver := getEnvWithDefault("REDIS_DRIVER", RedisDriverRedis)
+	switch driver {
+	case RedisDriverMemory:
+		return &RedisConfig{Driver: driver}, nil
+	case RedisDriverRedis:
+	default:
+		return nil, fmt.Errorf("REDIS_DRIVER must be %q or %q, got %q", RedisDriverRedis, RedisDriverMemory, driver)
+	}
+
 	// Получаем значени хоста (есть дефолтные значения)
 	host, err := getRequiredEnv("REDIS_HOST")
 	if err != nil {
@@ -132,6 +149,7 @@ func NewRedisConfigFromEnv() (*RedisConfig, error) {
 	}
 
 	return &RedisConfig{
+		Driver:          driver,          // реализация кэша
 		Host:            host,            // Хост, где расположен redis
 		Port:            port,            // Порт для подключения
 		Password:        pass,            // Пароль
@@ -150,6 +168,11 @@ func NewRedisConfigFromEnv() (*RedisConfig, error) {
 	}, nil
 }
 
+// метод сообщает, что вместо Redis используется кэш в памяти процесса
+func (r *RedisConfig) InMemory() bool {
+	return r.Driver == RedisDriverMemory
+}
+
 // для создания клиента redis необходимо передать указатель на структуру опций: *redis.Options
 func (r *RedisConfig) ToRedisOptions() *redis.Options {
 	return &redis.Options{
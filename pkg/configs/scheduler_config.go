@@ -0,0 +1,25 @@
+package configs
+
+import "time"
+
+// конфиг планировщика периодических задач и таймеров (задачи выполняет только лидер)
+type SchedulerConfig struct {
+	Enabled          bool          `yaml:"enabled"`           // false - задачи не запускаются (список и управление доступны)
+	TickInterval     time.Duration `yaml:"tick_interval"`     // как часто проверять задачи, время которых пришло
+	JobTimeout       time.Duration `yaml:"job_timeout"`       // таймаут одного запуска задачи
+	Timezone         string        `yaml:"timezone"`          // часовой пояс задач, у которых он не указан
+	BatchSize        int           `yaml:"batch_size"`        // сколько задач запускается за одну проверку
+	HistoryRetention time.Duration `yaml:"history_retention"` // сколько хранится история запусков
+}
+
+// дэфолтный конфиг
+func UseDefaultSchedulerConfig() *SchedulerConfig {
+	return &SchedulerConfig{
+		Enabled:          true,
+		TickInterval:     5 * time.Second,
+		JobTimeout:       5 * time.Minute,
+		Timezone:         "UTC",
+		BatchSize:        100,
+		HistoryRetention: 30 * 24 * time.Hour,
+	}
+}
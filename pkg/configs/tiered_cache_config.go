@@ -0,0 +1,23 @@
+package configs
+
+import "time"
+
+// конфиг двухуровневого кэша: LRU в памяти процесса перед redis
+type TieredCacheConfig struct {
+	Enabled     bool          `yaml:"enabled"`      // false - читать напрямую из redis
+	LocalSize   int           `yaml:"local_size"`   // максимум записей в памяти процесса (вытесняются давно не читанные)
+	LocalTTL    time.Duration `yaml:"local_ttl"`    // время жизни записи в памяти (ограничивает устаревание при потере сообщения сброса)
+	NegativeTTL time.Duration `yaml:"negative_ttl"` // сколько помнить, что данных нет в источнике (0 - не помнить)
+	Channel     string        `yaml:"channel"`      // канал redis pub/sub для сброса записей на других экземплярах
+}
+
+// дэфолтный конфиг
+func UseDefaultTieredCacheConfig() *TieredCacheConfig {
+	return &TieredCacheConfig{
+		Enabled:     true,
+		LocalSize:   10000,
+		LocalTTL:    30 * time.Second,
+		NegativeTTL: 30 * time.Second,
+		Channel:     "server_cache:invalidate",
+	}
+}
@@ -0,0 +1,44 @@
+package configs
+
+import (
+	"os"
+	"time"
+)
+
+// конфиг TLS для grpc сервера и клиента
+type TLSConfig struct {
+	Enabled        bool          `yaml:"enabled"`         // включить TLS (иначе соединение без шифрования)
+	CertFile       string        `yaml:"cert_file"`       // сертификат (для сервера - обязателен, для клиента - нужен только при mTLS)
+	KeyFile        string        `yaml:"key_file"`        // приватный ключ к сертификату
+	CAFile         string        `yaml:"ca_file"`         // CA для проверки другой стороны (пусто - системные CA)
+	ClientAuth     bool          `yaml:"client_auth"`     // только для сервера: требовать сертификат клиента, подписанный CA (mTLS)
+	ServerName     string        `yaml:"server_name"`     // только для клиента: имя сервера в сертификате (пусто - из адреса)
+	ReloadInterval time.Duration `yaml:"reload_interval"` // как часто проверять изменение файлов сертификатов (0 - не перечитывать)
+}
+
+// дэфолтный конфиг
+func UseDefaultTLSConfig() *TLSConfig {
+	return &TLSConfig{
+		Enabled:        false,
+		ReloadInterval: time.Minute,
+	}
+}
+
+// конфиг аутентификации по общему секрету (токен передаётся в метаданных каждого вызова)
+type AuthConfig struct {
+	Enabled  bool   `yaml:"enabled"`   // включить проверку (сервер) / передачу (клиент) токена
+	TokenEnv string `yaml:"token_env"` // имя переменной окружения с токеном (сам секрет в .yml не храним)
+}
+
+// метод получения токена из переменной окружения
+func (c *AuthConfig) Token() string {
+	return os.Getenv(c.TokenEnv)
+}
+
+// дэфолтный конфиг
+func UseDefaultAuthConfig() *AuthConfig {
+	return &AuthConfig{
+		Enabled:  false,
+		TokenEnv: "GRPC_AUTH_TOKEN",
+	}
+}
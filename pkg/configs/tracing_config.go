@@ -0,0 +1,31 @@
+package configs
+
+// экспортеры трейсов
+const (
+	TracingExporterNone   = "none"   // трейсы не собираются
+	TracingExporterStdout = "stdout" // трейсы пишутся в stdout (для локальной отладки)
+	TracingExporterOTLP   = "otlp"   // трейсы отправляются в коллектор по OTLP/gRPC
+)
+
+// конфиг распределённой трассировки (OpenTelemetry)
+type TracingConfig struct {
+	Exporter    string  `yaml:"exporter"`     // none, stdout или otlp
+	Endpoint    string  `yaml:"endpoint"`     // адрес OTLP коллектора (host:port)
+	Insecure    bool    `yaml:"insecure"`     // подключаться к коллектору без TLS
+	SampleRatio float64 `yaml:"sample_ratio"` // доля трейсов, которые сохраняем (0..1)
+}
+
+// Enabled сообщает, что трейсы нужно собирать
+func (c *TracingConfig) Enabled() bool {
+	return c.Exporter != "" && c.Exporter != TracingExporterNone
+}
+
+// дэфолтный конфиг
+func UseDefaultTracingConfig() *TracingConfig {
+	return &TracingConfig{
+		Exporter:    TracingExporterNone,
+		Endpoint:    "localhost:4317",
+		Insecure:    true,
+		SampleRatio: 1,
+	}
+}
@@ -0,0 +1,21 @@
+package configs
+
+import "time"
+
+// конфиг кэша пользователей и пакетной записи времени активности (last_seen_at)
+type UserCacheConfig struct {
+	Enabled           bool          `yaml:"enabled"`              // читать пользователей через кэш (redis)
+	TTL               time.Duration `yaml:"ttl"`                  // время жизни пользователя в кэше
+	LastSeenFlush     time.Duration `yaml:"last_seen_flush"`      // как часто накопленные last_seen_at пишутся в БД (0 - сразу)
+	LastSeenBatchSize int           `yaml:"last_seen_batch_size"` // при таком количестве накопленных пользователей запись не ждёт интервала
+}
+
+// дэфолтный конфиг
+func UseDefaultUserCacheConfig() *UserCacheConfig {
+	return &UserCacheConfig{
+		Enabled:           true,
+		TTL:               10 * time.Minute,
+		LastSeenFlush:     5 * time.Second,
+		LastSeenBatchSize: 500,
+	}
+}
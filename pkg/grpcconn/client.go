@@ -0,0 +1,162 @@
+// Пакет grpcconn - общий конструктор gRPC соединения для клиентов сервисов:
+// политика повторов, дедлайн по умолчанию, wait-for-ready, keepalive, TLS/токен и лог состояния соединения.
+package grpcconn
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"pkg/configs"
+	"pkg/grpcsecurity"
+	"pkg/interceptors"
+	"pkg/tracing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/backoff"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/keepalive"
+)
+
+// максимальное число попыток, которое принимает gRPC (остальное он молча обрезает)
+const maxAttemptsLimit = 5
+
+// NewClient создаёт соединение с сервером по конфигу.
+// service - полное имя gRPC сервиса (например "bot.BotService"), к методам которого применяется политика повторов
+func NewClient(conf *configs.GRPCClientConfig, service string, extra ...grpc.DialOption) (*grpc.ClientConn, error) {
+	// TLS / mTLS и токен
+	opts, err := grpcsecurity.DialOptions(conf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure grpc security: %w", err)
+	}
+
+	serviceConfig, err := ServiceConfig(conf, service)
+	if err != nil {
+		return nil, err
+	}
+
+	reconnect := backoff.DefaultConfig
+	if conf.MaxReconnectBackoff > 0 {
+		reconnect.MaxDelay = conf.MaxReconnectBackoff
+	}
+
+	opts = append(opts,
+		grpc.WithDefaultServiceConfig(serviceConfig),
+		grpc.WithConnectParams(grpc.ConnectParams{Backoff: reconnect}),
+		grpc.WithChainUnaryInterceptor(
+			interceptors.UnaryClientRequestID(),
+			interceptors.UnaryClientMetrics(interceptors.DefaultClientMetrics()),
+			UnaryClientDefaultTimeout(conf.TimeOut),
+		),
+	)
+	// спаны исходящих вызовов и передача контекста трейса серверу
+	opts = append(opts, tracing.DialOptions()...)
+	if conf.KeepaliveTime > 0 {
+		opts = append(opts, grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:                conf.KeepaliveTime,
+			Timeout:             conf.KeepaliveTimeout,
+			PermitWithoutStream: conf.PermitWithoutStream,
+		}))
+	}
+	opts = append(opts, extra...)
+
+	conn, err := grpc.NewClient(conf.Addr(), opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create gRPC client: %w", err)
+	}
+
+	// Инициируем соединение и следим за его состоянием
+	conn.Connect()
+	go logStateChanges(conn, conf.Addr())
+
+	return conn, nil
+}
+
+// структуры service config (формат описан в gRPC: doc/service_config.md)
+type serviceConfigJSON struct {
+	MethodConfig []methodConfigJSON `json:"methodConfig"`
+}
+
+type methodConfigJSON struct {
+	Name         []methodNameJSON `json:"name"`
+	WaitForReady bool             `json:"waitForReady,omitempty"`
+	RetryPolicy  *retryPolicyJSON `json:"retryPolicy,omitempty"`
+}
+
+type methodNameJSON struct {
+	Service string `json:"service"`
+}
+
+type retryPolicyJSON struct {
+	MaxAttempts          int      `json:"maxAttempts"`
+	InitialBackoff       string   `json:"initialBackoff"`
+	MaxBackoff           string   `json:"maxBackoff"`
+	BackoffMultiplier    float64  `json:"backoffMultiplier"`
+	RetryableStatusCodes []string `json:"retryableStatusCodes"`
+}
+
+// ServiceConfig собирает JSON service config с политикой повторов и wait-for-ready для методов сервиса
+func ServiceConfig(conf *configs.GRPCClientConfig, service string) (string, error) {
+	method := methodConfigJSON{
+		Name:         []methodNameJSON{{Service: service}},
+		WaitForReady: conf.WaitForReady,
+	}
+
+	if conf.MaxRetries > 0 && len(conf.RetryableCodes) > 0 {
+		attempts := min(conf.MaxRetries+1, maxAttemptsLimit)
+		multiplier := conf.BackoffMultiplier
+		if multiplier <= 0 {
+			multiplier = 1
+		}
+
+		method.RetryPolicy = &retryPolicyJSON{
+			MaxAttempts:          attempts,
+			InitialBackoff:       durationJSON(conf.InitialBackoff),
+			MaxBackoff:           durationJSON(conf.MaxBackoff),
+			BackoffMultiplier:    multiplier,
+			RetryableStatusCodes: conf.RetryableCodes,
+		}
+	}
+
+	data, err := json.Marshal(serviceConfigJSON{MethodConfig: []methodConfigJSON{method}})
+	if err != nil {
+		return "", fmt.Errorf("failed to build service config: %w", err)
+	}
+	return string(data), nil
+}
+
+// durationJSON переводит длительность в формат service config ("0.2s")
+func durationJSON(d time.Duration) string {
+	if d <= 0 {
+		d = 100 * time.Millisecond
+	}
+	return fmt.Sprintf("%gs", d.Seconds())
+}
+
+// UnaryClientDefaultTimeout ставит дедлайн вызову, если вызывающий не задал свой (timeout <= 0 - не ставит)
+func UnaryClientDefaultTimeout(timeout time.Duration) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if _, ok := ctx.Deadline(); !ok && timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// logStateChanges логирует смену состояния соединения, пока соединение не закрыто
+func logStateChanges(conn *grpc.ClientConn, addr string) {
+	state := conn.GetState()
+	for state != connectivity.Shutdown {
+		if !conn.WaitForStateChange(context.Background(), state) {
+			return
+		}
+		next := conn.GetState()
+		if next != connectivity.Shutdown {
+			slog.Info("grpc connection state changed", "addr", addr, "from", state.String(), "to", next.String())
+		}
+		state = next
+	}
+}
@@ -0,0 +1,132 @@
+package grpcconn
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"pkg/configs"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// flakyHealth отвечает UNAVAILABLE первые failures раз
+type flakyHealth struct {
+	*health.Server
+	failures int32
+	calls    atomic.Int32
+}
+
+func (f *flakyHealth) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	if f.calls.Add(1) <= f.failures {
+		return nil, status.Error(codes.Unavailable, "try again")
+	}
+	return f.Server.Check(ctx, req)
+}
+
+func startServer(t *testing.T, srv healthpb.HealthServer) string {
+	t.Helper()
+
+	server := grpc.NewServer()
+	healthpb.RegisterHealthServer(server, srv)
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+
+	return lis.Addr().String()
+}
+
+func testConfig(addr string) *configs.GRPCClientConfig {
+	host, port, _ := net.SplitHostPort(addr)
+	conf := configs.UseDefaultGRPCClientConfig()
+	conf.Host, conf.Port = host, port
+	conf.InitialBackoff = 10 * time.Millisecond
+	return conf
+}
+
+func TestClient(t *testing.T) {
+	t.Run("service config содержит политику повторов", func(t *testing.T) {
+		conf := configs.UseDefaultGRPCClientConfig()
+		conf.MaxRetries = 10
+
+		raw, err := ServiceConfig(conf, "bot.BotService")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var parsed serviceConfigJSON
+		if err := json.Unmarshal([]byte(raw), &parsed); err != nil {
+			t.Fatal(err)
+		}
+		policy := parsed.MethodConfig[0].RetryPolicy
+		if policy == nil || policy.MaxAttempts != maxAttemptsLimit || policy.InitialBackoff != "0.2s" {
+			t.Fatalf("неожиданная политика повторов: %s", raw)
+		}
+	})
+
+	t.Run("вызов повторяется при UNAVAILABLE", func(t *testing.T) {
+		srv := &flakyHealth{Server: health.NewServer(), failures: 2}
+		conn, err := NewClient(testConfig(startServer(t, srv)), "grpc.health.v1.Health")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+
+		if _, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{}); err != nil {
+			t.Fatalf("ожидался успех после повторов, получено: %v", err)
+		}
+		if got := srv.calls.Load(); got != 3 {
+			t.Fatalf("ожидалось 3 попытки, получено: %d", got)
+		}
+	})
+
+	t.Run("без повторов ошибка возвращается сразу", func(t *testing.T) {
+		srv := &flakyHealth{Server: health.NewServer(), failures: 1}
+		conf := testConfig(startServer(t, srv))
+		conf.MaxRetries = 0
+
+		conn, err := NewClient(conf, "grpc.health.v1.Health")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+
+		_, err = healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{})
+		if status.Code(err) != codes.Unavailable {
+			t.Fatalf("ожидался UNAVAILABLE, получено: %v", err)
+		}
+	})
+
+	t.Run("дедлайн по умолчанию ставится только если его нет", func(t *testing.T) {
+		interceptor := UnaryClientDefaultTimeout(time.Minute)
+
+		var deadline time.Time
+		var ok bool
+		invoker := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+			deadline, ok = ctx.Deadline()
+			return nil
+		}
+
+		_ = interceptor(context.Background(), "/m", nil, nil, nil, invoker)
+		if !ok || time.Until(deadline) > time.Minute {
+			t.Fatalf("ожидался дедлайн не позже минуты, получено: %v %v", deadline, ok)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+		defer cancel()
+		_ = interceptor(ctx, "/m", nil, nil, nil, invoker)
+		if time.Until(deadline) < 59*time.Minute {
+			t.Fatalf("дедлайн вызывающего не должен меняться, получено: %v", deadline)
+		}
+	})
+}
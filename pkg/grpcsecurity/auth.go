@@ -0,0 +1,80 @@
+package grpcsecurity
+
+import (
+	"context"
+	"crypto/subtle"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// authorizationKey - ключ метаданных с токеном
+const authorizationKey = "authorization"
+
+// bearerPrefix - префикс значения заголовка с токеном
+const bearerPrefix = "Bearer "
+
+// методы, доступные без токена (health-check для оркестратора)
+var publicMethodPrefixes = []string{
+	"/grpc.health.v1.Health/",
+}
+
+// UnaryServerAuth проверяет токен в метаданных каждого вызова (authorization: Bearer <token>)
+func UnaryServerAuth(token string) grpc.UnaryServerInterceptor {
+	expected := []byte(bearerPrefix + token)
+
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if isPublicMethod(info.FullMethod) {
+			return handler(ctx, req)
+		}
+
+		md, _ := metadata.FromIncomingContext(ctx)
+		values := md.Get(authorizationKey)
+		if len(values) == 0 {
+			return nil, status.Error(codes.Unauthenticated, "missing auth token")
+		}
+		if subtle.ConstantTimeCompare([]byte(values[0]), expected) != 1 {
+			return nil, status.Error(codes.Unauthenticated, "invalid auth token")
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// метод для проверки, доступен ли метод без токена
+func isPublicMethod(fullMethod string) bool {
+	for _, prefix := range publicMethodPrefixes {
+		if strings.HasPrefix(fullMethod, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// TokenCredentials - клиентские креды, добавляющие токен в метаданные каждого вызова
+type TokenCredentials struct {
+	token      string
+	requireTLS bool
+}
+
+// конструктор для TokenCredentials
+// requireTLS - запрещать отправку токена по незашифрованному соединению
+func NewTokenCredentials(token string, requireTLS bool) *TokenCredentials {
+	return &TokenCredentials{
+		token:      token,
+		requireTLS: requireTLS,
+	}
+}
+
+// GetRequestMetadata реализует credentials.PerRPCCredentials
+func (t *TokenCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{authorizationKey: bearerPrefix + t.token}, nil
+}
+
+// RequireTransportSecurity реализует credentials.PerRPCCredentials
+func (t *TokenCredentials) RequireTransportSecurity() bool {
+	return t.requireTLS
+}
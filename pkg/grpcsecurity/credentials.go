@@ -0,0 +1,160 @@
+// Пакет grpcsecurity - защита соединения между шлюзом бота и сервером основной логики:
+// TLS / mTLS с перечитыванием сертификатов и аутентификация по общему секрету.
+package grpcsecurity
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"pkg/configs"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// ServerOptions возвращает опции grpc.NewServer для TLS и проверки токена по конфигу сервера
+func ServerOptions(conf *configs.GRPCServerConfig) ([]grpc.ServerOption, error) {
+	var opts []grpc.ServerOption
+
+	if conf.TLS.Enabled {
+		creds, err := ServerCredentials(&conf.TLS)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, grpc.Creds(creds))
+	}
+
+	if conf.Auth.Enabled {
+		token := conf.Auth.Token()
+		if token == "" {
+			return nil, fmt.Errorf("grpc auth is enabled but %s is empty", conf.Auth.TokenEnv)
+		}
+		opts = append(opts, grpc.ChainUnaryInterceptor(UnaryServerAuth(token)))
+	}
+
+	return opts, nil
+}
+
+// DialOptions возвращает опции grpc.NewClient для TLS и передачи токена по конфигу клиента
+func DialOptions(conf *configs.GRPCClientConfig) ([]grpc.DialOption, error) {
+	var opts []grpc.DialOption
+
+	if conf.TLS.Enabled {
+		creds, err := ClientCredentials(&conf.TLS)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, grpc.WithTransportCredentials(creds))
+	} else {
+		opts = append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	}
+
+	if conf.Auth.Enabled {
+		token := conf.Auth.Token()
+		if token == "" {
+			return nil, fmt.Errorf("grpc auth is enabled but %s is empty", conf.Auth.TokenEnv)
+		}
+		opts = append(opts, grpc.WithPerRPCCredentials(NewTokenCredentials(token, conf.TLS.Enabled)))
+	}
+
+	return opts, nil
+}
+
+// ServerCredentials создаёт TLS креды сервера; при ClientAuth требует сертификат клиента (mTLS)
+func ServerCredentials(conf *configs.TLSConfig) (credentials.TransportCredentials, error) {
+	if conf.CertFile == "" || conf.KeyFile == "" {
+		return nil, errors.New("tls: cert_file and key_file are required for server")
+	}
+	if conf.ClientAuth && conf.CAFile == "" {
+		return nil, errors.New("tls: ca_file is required for client_auth")
+	}
+
+	// без client_auth пул CA не используется - ca_file не читаем и не отслеживаем
+	reloader, err := newCertReloader(conf, conf.ClientAuth)
+	if err != nil {
+		return nil, err
+	}
+
+	// конфиг собирается на каждое рукопожатие, чтобы подхватывать перечитанные сертификат и CA
+	return credentials.NewTLS(&tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			cfg := &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				Certificates: []tls.Certificate{*reloader.Certificate()},
+				NextProtos:   []string{"h2"},
+			}
+			if conf.ClientAuth {
+				cfg.ClientAuth = tls.RequireAndVerifyClientCert
+				cfg.ClientCAs = reloader.CAPool()
+			}
+			return cfg, nil
+		},
+	}), nil
+}
+
+// ClientCredentials создаёт TLS креды клиента; если заданы сертификат и ключ - предъявляет их серверу (mTLS)
+func ClientCredentials(conf *configs.TLSConfig) (credentials.TransportCredentials, error) {
+	if (conf.CertFile == "") != (conf.KeyFile == "") {
+		return nil, errors.New("tls: cert_file and key_file must be set together")
+	}
+
+	reloader, err := newCertReloader(conf, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return &clientCredentials{
+		TransportCredentials: credentials.NewTLS(&tls.Config{MinVersion: tls.VersionTLS12}),
+		conf:                 conf,
+		reloader:             reloader,
+		serverName:           conf.ServerName,
+	}, nil
+}
+
+// clientCredentials - TLS креды клиента, собирающие конфиг на каждое рукопожатие,
+// чтобы подхватывать перечитанные CA сервера и собственный сертификат.
+// ServerHandshake и Info берутся у встроенных кред - они не зависят от сертификатов
+type clientCredentials struct {
+	credentials.TransportCredentials
+
+	conf       *configs.TLSConfig
+	reloader   *certReloader
+	serverName string
+}
+
+// ClientHandshake реализует credentials.TransportCredentials
+func (c *clientCredentials) ClientHandshake(ctx context.Context, authority string, rawConn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	return credentials.NewTLS(c.tlsConfig()).ClientHandshake(ctx, authority, rawConn)
+}
+
+// Clone реализует credentials.TransportCredentials
+func (c *clientCredentials) Clone() credentials.TransportCredentials {
+	clone := *c
+	clone.TransportCredentials = c.TransportCredentials.Clone()
+	return &clone
+}
+
+// OverrideServerName реализует credentials.TransportCredentials
+func (c *clientCredentials) OverrideServerName(serverName string) error {
+	c.serverName = serverName
+	return nil
+}
+
+// tlsConfig собирает конфиг рукопожатия с актуальными CA и сертификатом
+func (c *clientCredentials) tlsConfig() *tls.Config {
+	cfg := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: c.serverName,
+		RootCAs:    c.reloader.CAPool(),
+	}
+	if c.conf.CertFile != "" {
+		cfg.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return c.reloader.Certificate(), nil
+		}
+	}
+	return cfg
+}
@@ -0,0 +1,160 @@
+package grpcsecurity
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log/slog"
+	"os"
+	"pkg/configs"
+	"sync"
+	"time"
+)
+
+// certReloader хранит сертификат и CA и перечитывает их, когда файлы меняются на диске
+// (проверка не чаще ReloadInterval, по времени изменения файлов)
+type certReloader struct {
+	config *configs.TLSConfig
+	loadCA bool // читать ли CAFile (серверу без client_auth пул CA не нужен)
+
+	mu        sync.RWMutex
+	cert      *tls.Certificate     // текущий сертификат (nil, если не задан)
+	caPool    *x509.CertPool       // текущий пул CA (nil - системные CA)
+	modTimes  map[string]time.Time // время изменения файлов на момент последней загрузки
+	lastCheck time.Time            // когда последний раз проверяли файлы
+
+	now func() time.Time // источник времени (подменяется в тестах)
+}
+
+// конструктор для certReloader: сразу загружает файлы, ошибка загрузки - ошибка конфигурации
+func newCertReloader(config *configs.TLSConfig, loadCA bool) (*certReloader, error) {
+	r := &certReloader{
+		config:   config,
+		loadCA:   loadCA,
+		modTimes: make(map[string]time.Time),
+		now:      time.Now,
+	}
+
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	r.lastCheck = r.now()
+
+	return r, nil
+}
+
+// Certificate возвращает актуальный сертификат
+func (r *certReloader) Certificate() *tls.Certificate {
+	r.maybeReload()
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert
+}
+
+// CAPool возвращает актуальный пул CA
+func (r *certReloader) CAPool() *x509.CertPool {
+	r.maybeReload()
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.caPool
+}
+
+// maybeReload перечитывает файлы, если прошёл ReloadInterval и хотя бы один файл изменился.
+// Ошибка перезагрузки (например, файл записан наполовину) не ломает работу - остаётся старый сертификат
+func (r *certReloader) maybeReload() {
+	if r.config.ReloadInterval <= 0 {
+		return
+	}
+
+	r.mu.Lock()
+	if r.now().Sub(r.lastCheck) < r.config.ReloadInterval {
+		r.mu.Unlock()
+		return
+	}
+	r.lastCheck = r.now()
+	changed := r.filesChanged()
+	r.mu.Unlock()
+
+	if !changed {
+		return
+	}
+
+	if err := r.load(); err != nil {
+		slog.Error("tls: failed to reload certificates, keeping previous ones", "error", err)
+		return
+	}
+	slog.Info("tls: certificates reloaded", "cert_file", r.config.CertFile)
+}
+
+// filesChanged сравнивает время изменения файлов с сохранённым (вызывается под мьютексом)
+func (r *certReloader) filesChanged() bool {
+	for _, path := range r.files() {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		if !info.ModTime().Equal(r.modTimes[path]) {
+			return true
+		}
+	}
+	return false
+}
+
+// load читает сертификат, ключ и CA с диска
+func (r *certReloader) load() error {
+	modTimes := make(map[string]time.Time)
+	for _, path := range r.files() {
+		info, err := os.Stat(path)
+		if err != nil {
+			return fmt.Errorf("failed to stat %s: %w", path, err)
+		}
+		modTimes[path] = info.ModTime()
+	}
+
+	var cert *tls.Certificate
+	if r.config.CertFile != "" || r.config.KeyFile != "" {
+		pair, err := tls.LoadX509KeyPair(r.config.CertFile, r.config.KeyFile)
+		if err != nil {
+			return fmt.Errorf("failed to load key pair: %w", err)
+		}
+		cert = &pair
+	}
+
+	var caPool *x509.CertPool
+	if r.loadCA && r.config.CAFile != "" {
+		pem, err := os.ReadFile(r.config.CAFile)
+		if err != nil {
+			return fmt.Errorf("failed to read CA file: %w", err)
+		}
+		caPool = x509.NewCertPool()
+		if !caPool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificates found in CA file %s", r.config.CAFile)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cert = cert
+	r.caPool = caPool
+	r.modTimes = modTimes
+
+	return nil
+}
+
+// files возвращает список заданных в конфиге файлов, которые читает reloader
+func (r *certReloader) files() []string {
+	paths := []string{r.config.CertFile, r.config.KeyFile}
+	if r.loadCA {
+		paths = append(paths, r.config.CAFile)
+	}
+
+	var files []string
+	for _, path := range paths {
+		if path != "" {
+			files = append(files, path)
+		}
+	}
+	return files
+}
@@ -0,0 +1,295 @@
+package grpcsecurity
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"pkg/configs"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// testCA - тестовый удостоверяющий центр
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+// newTestCA создаёт CA и сохраняет его сертификат в dir/ca.crt
+func newTestCA(t *testing.T, dir string) *testCA {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	writePEM(t, filepath.Join(dir, "ca.crt"), "CERTIFICATE", der)
+
+	return &testCA{cert: cert, key: key}
+}
+
+// issue выпускает сертификат и сохраняет его в dir/name.crt и dir/name.key
+func (ca *testCA) issue(t *testing.T, dir, name string, serial int64) (certFile, keyFile string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certFile = filepath.Join(dir, name+".crt")
+	keyFile = filepath.Join(dir, name+".key")
+	writePEM(t, certFile, "CERTIFICATE", der)
+	writePEM(t, keyFile, "EC PRIVATE KEY", keyDER)
+	return certFile, keyFile
+}
+
+func writePEM(t *testing.T, path, blockType string, der []byte) {
+	t.Helper()
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// startHealthServer запускает grpc сервер с health сервисом и возвращает адрес
+func startHealthServer(t *testing.T, conf *configs.GRPCServerConfig) string {
+	t.Helper()
+
+	opts, err := ServerOptions(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := grpc.NewServer(opts...)
+	healthpb.RegisterHealthServer(server, health.NewServer())
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+
+	return lis.Addr().String()
+}
+
+func checkHealth(t *testing.T, addr string, conf *configs.GRPCClientConfig) error {
+	t.Helper()
+
+	opts, err := DialOptions(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return checkHealthWith(t, addr, opts...)
+}
+
+// checkHealthWith вызывает health сервис по новому соединению с заданными опциями
+func checkHealthWith(t *testing.T, addr string, opts ...grpc.DialOption) error {
+	t.Helper()
+
+	conn, err := grpc.NewClient(addr, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err = healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+	return err
+}
+
+func TestSecurity(t *testing.T) {
+	t.Run("mTLS: клиент с сертификатом проходит, без сертификата - нет", func(t *testing.T) {
+		dir := t.TempDir()
+		ca := newTestCA(t, dir)
+		serverCert, serverKey := ca.issue(t, dir, "server", 2)
+		clientCert, clientKey := ca.issue(t, dir, "client", 3)
+
+		serverConf := configs.UseDefaultGRPCServerConfig()
+		serverConf.TLS = configs.TLSConfig{Enabled: true, CertFile: serverCert, KeyFile: serverKey, CAFile: filepath.Join(dir, "ca.crt"), ClientAuth: true}
+		addr := startHealthServer(t, serverConf)
+
+		clientConf := configs.UseDefaultGRPCClientConfig()
+		clientConf.TLS = configs.TLSConfig{Enabled: true, CertFile: clientCert, KeyFile: clientKey, CAFile: filepath.Join(dir, "ca.crt"), ServerName: "localhost"}
+		if err := checkHealth(t, addr, clientConf); err != nil {
+			t.Fatalf("ожидался успешный вызов, получено: %v", err)
+		}
+
+		clientConf.TLS.CertFile, clientConf.TLS.KeyFile = "", ""
+		if err := checkHealth(t, addr, clientConf); err == nil {
+			t.Fatal("ожидалась ошибка без клиентского сертификата")
+		}
+	})
+
+	t.Run("сертификат перечитывается после изменения файла", func(t *testing.T) {
+		dir := t.TempDir()
+		ca := newTestCA(t, dir)
+		certFile, keyFile := ca.issue(t, dir, "server", 10)
+
+		reloader, err := newCertReloader(&configs.TLSConfig{CertFile: certFile, KeyFile: keyFile, ReloadInterval: time.Second}, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		now := time.Now()
+		reloader.now = func() time.Time { return now }
+
+		first := reloader.Certificate().Leaf.SerialNumber.Int64()
+
+		// перевыпускаем сертификат и сдвигаем время изменения файлов
+		ca.issue(t, dir, "server", 11)
+		later := time.Now().Add(time.Minute)
+		os.Chtimes(certFile, later, later)
+		os.Chtimes(keyFile, later, later)
+
+		if got := reloader.Certificate().Leaf.SerialNumber.Int64(); got != first {
+			t.Fatalf("до истечения интервала сертификат не должен меняться, получено: %d", got)
+		}
+
+		now = now.Add(2 * time.Second)
+		if got := reloader.Certificate().Leaf.SerialNumber.Int64(); got != 11 {
+			t.Fatalf("ожидался перечитанный сертификат 11, получено: %d", got)
+		}
+	})
+
+	t.Run("без client_auth сервер не читает ca_file", func(t *testing.T) {
+		dir := t.TempDir()
+		ca := newTestCA(t, dir)
+		serverCert, serverKey := ca.issue(t, dir, "server", 20)
+
+		serverConf := configs.UseDefaultGRPCServerConfig()
+		serverConf.TLS = configs.TLSConfig{Enabled: true, CertFile: serverCert, KeyFile: serverKey, CAFile: filepath.Join(dir, "missing.crt")}
+		addr := startHealthServer(t, serverConf)
+
+		clientConf := configs.UseDefaultGRPCClientConfig()
+		clientConf.TLS = configs.TLSConfig{Enabled: true, CAFile: filepath.Join(dir, "ca.crt"), ServerName: "localhost"}
+		if err := checkHealth(t, addr, clientConf); err != nil {
+			t.Fatalf("ожидался успешный вызов, получено: %v", err)
+		}
+	})
+
+	t.Run("клиент перечитывает CA сервера после изменения файла", func(t *testing.T) {
+		serverDir, clientDir := t.TempDir(), t.TempDir()
+		ca := newTestCA(t, serverDir)
+		serverCert, serverKey := ca.issue(t, serverDir, "server", 30)
+		newTestCA(t, clientDir) // у клиента пока чужой CA
+
+		serverConf := configs.UseDefaultGRPCServerConfig()
+		serverConf.TLS = configs.TLSConfig{Enabled: true, CertFile: serverCert, KeyFile: serverKey}
+		addr := startHealthServer(t, serverConf)
+
+		caFile := filepath.Join(clientDir, "ca.crt")
+		creds, err := ClientCredentials(&configs.TLSConfig{Enabled: true, CAFile: caFile, ServerName: "localhost", ReloadInterval: time.Second})
+		if err != nil {
+			t.Fatal(err)
+		}
+		reloader := creds.(*clientCredentials).reloader
+		now := time.Now()
+		reloader.now = func() time.Time { return now }
+
+		if err := checkHealthWith(t, addr, grpc.WithTransportCredentials(creds)); err == nil {
+			t.Fatal("ожидалась ошибка проверки сертификата сервера чужим CA")
+		}
+
+		// выкладываем CA сервера на место старого
+		pem, err := os.ReadFile(filepath.Join(serverDir, "ca.crt"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(caFile, pem, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		later := time.Now().Add(time.Minute)
+		os.Chtimes(caFile, later, later)
+
+		now = now.Add(2 * time.Second)
+		if err := checkHealthWith(t, addr, grpc.WithTransportCredentials(creds)); err != nil {
+			t.Fatalf("ожидался успешный вызов с перечитанным CA, получено: %v", err)
+		}
+	})
+
+	t.Run("токен: без токена Unauthenticated, health доступен всем", func(t *testing.T) {
+		interceptor := UnaryServerAuth("secret")
+		handler := func(ctx context.Context, req any) (any, error) { return "ok", nil }
+		info := &grpc.UnaryServerInfo{FullMethod: "/bot.BotService/SendMessage"}
+
+		if _, err := interceptor(context.Background(), nil, info, handler); status.Code(err) != codes.Unauthenticated {
+			t.Fatalf("ожидался Unauthenticated, получено: %v", err)
+		}
+
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(authorizationKey, "Bearer wrong"))
+		if _, err := interceptor(ctx, nil, info, handler); status.Code(err) != codes.Unauthenticated {
+			t.Fatalf("ожидался Unauthenticated для неверного токена, получено: %v", err)
+		}
+
+		ctx = metadata.NewIncomingContext(context.Background(), metadata.Pairs(authorizationKey, "Bearer secret"))
+		if _, err := interceptor(ctx, nil, info, handler); err != nil {
+			t.Fatalf("ожидался успешный вызов, получено: %v", err)
+		}
+
+		healthInfo := &grpc.UnaryServerInfo{FullMethod: "/grpc.health.v1.Health/Check"}
+		if _, err := interceptor(context.Background(), nil, healthInfo, handler); err != nil {
+			t.Fatalf("health должен быть доступен без токена, получено: %v", err)
+		}
+	})
+
+	t.Run("токен передаётся клиентом по незашифрованному соединению", func(t *testing.T) {
+		t.Setenv("TEST_GRPC_TOKEN", "secret")
+
+		serverConf := configs.UseDefaultGRPCServerConfig()
+		serverConf.Auth = configs.AuthConfig{Enabled: true, TokenEnv: "TEST_GRPC_TOKEN"}
+		addr := startHealthServer(t, serverConf)
+
+		clientConf := configs.UseDefaultGRPCClientConfig()
+		clientConf.Auth = configs.AuthConfig{Enabled: true, TokenEnv: "TEST_GRPC_TOKEN"}
+		if err := checkHealth(t, addr, clientConf); err != nil {
+			t.Fatalf("ожидался успешный вызов, получено: %v", err)
+		}
+	})
+}
@@ -0,0 +1,173 @@
+// Пакет health - проверки здоровья сервиса.
+//
+// Checker периодически проверяет зависимости (БД, кэш, соседний сервис, внешний API)
+// и публикует результат сразу в двух видах:
+//   - стандартный gRPC сервис grpc.health.v1 (для grpc клиентов и оркестратора);
+//   - HTTP /healthz (процесс жив) и /readyz (критичные зависимости доступны).
+package health
+
+import (
+	"context"
+	"log/slog"
+	"pkg/configs"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// Check - проверка одной зависимости
+type Check struct {
+	Name     string                          // имя зависимости (postgres, redis, ...)
+	Critical bool                            // недоступность критичной зависимости делает сервис неготовым
+	Fn       func(ctx context.Context) error // сама проверка
+}
+
+// Result - результат проверки одной зависимости
+type Result struct {
+	Status    string    `json:"status"` // "ok" или "fail"
+	Critical  bool      `json:"critical"`
+	Error     string    `json:"error,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// Report - результат всех проверок
+type Report struct {
+	Ready  bool              `json:"ready"`
+	Checks map[string]Result `json:"checks"`
+}
+
+// Checker - периодическая проверка зависимостей
+type Checker struct {
+	config     *configs.HealthConfig
+	checks     []Check
+	grpcHealth *health.Server // реализация grpc.health.v1
+	services   []string       // имена gRPC сервисов, для которых выставляется статус
+
+	mu     sync.RWMutex
+	report Report
+}
+
+// конструктор для Checker
+// services - полные имена gRPC сервисов процесса (статус выставляется и для них, и для "" - сервера целиком)
+func NewChecker(config *configs.HealthConfig, services []string, checks ...Check) *Checker {
+	if config == nil {
+		config = configs.UseDefaultHealthConfig()
+	}
+
+	c := &Checker{
+		config:     config,
+		checks:     checks,
+		grpcHealth: health.NewServer(),
+		services:   services,
+		report:     Report{Checks: map[string]Result{}},
+	}
+
+	// до первой проверки сервис не готов
+	c.setServingStatus(false)
+	return c
+}
+
+// GRPCServer возвращает реализацию grpc.health.v1 для регистрации на gRPC сервере
+func (c *Checker) GRPCServer() healthpb.HealthServer {
+	return c.grpcHealth
+}
+
+// Run проверяет зависимости сразу и затем каждые Interval (блокирующий метод, запускать в горутине)
+func (c *Checker) Run(ctx context.Context) {
+	c.CheckNow(ctx)
+
+	ticker := time.NewTicker(c.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.CheckNow(ctx)
+		}
+	}
+}
+
+// CheckNow выполняет все проверки параллельно и обновляет статус
+func (c *Checker) CheckNow(ctx context.Context) Report {
+	results := make(map[string]Result, len(c.checks))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for _, check := range c.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			checkCtx, cancel := context.WithTimeout(ctx, c.config.Timeout)
+			defer cancel()
+
+			result := Result{Status: "ok", Critical: check.Critical, CheckedAt: time.Now()}
+			if err := check.Fn(checkCtx); err != nil {
+				result.Status = "fail"
+				result.Error = err.Error()
+			}
+
+			mu.Lock()
+			results[check.Name] = result
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	ready := true
+	for _, result := range results {
+		if result.Critical && result.Status != "ok" {
+			ready = false
+		}
+	}
+
+	c.mu.Lock()
+	wasReady := c.report.Ready
+	c.report = Report{Ready: ready, Checks: results}
+	c.mu.Unlock()
+
+	if wasReady != ready {
+		slog.Warn("health: readiness changed", "from", wasReady, "to", ready)
+	}
+	c.setServingStatus(ready)
+
+	return c.Report()
+}
+
+// Report возвращает результат последней проверки
+func (c *Checker) Report() Report {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	checks := make(map[string]Result, len(c.report.Checks))
+	for name, result := range c.report.Checks {
+		checks[name] = result
+	}
+	return Report{Ready: c.report.Ready, Checks: checks}
+}
+
+// Shutdown переводит все сервисы в NOT_SERVING (вызывать перед остановкой серверов, чтобы балансировщик убрал трафик)
+func (c *Checker) Shutdown() {
+	c.mu.Lock()
+	c.report.Ready = false
+	c.mu.Unlock()
+
+	c.grpcHealth.Shutdown()
+}
+
+// setServingStatus выставляет статус gRPC health для сервера и его сервисов
+func (c *Checker) setServingStatus(ready bool) {
+	status := healthpb.HealthCheckResponse_NOT_SERVING
+	if ready {
+		status = healthpb.HealthCheckResponse_SERVING
+	}
+
+	c.grpcHealth.SetServingStatus("", status)
+	for _, service := range c.services {
+		c.grpcHealth.SetServingStatus(service, status)
+	}
+}
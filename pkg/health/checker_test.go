@@ -0,0 +1,81 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"pkg/configs"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestChecker(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var dbErr error
+	checker := NewChecker(configs.UseDefaultHealthConfig(), []string{"bot.BotService"},
+		Check{Name: "postgres", Critical: true, Fn: func(ctx context.Context) error { return dbErr }},
+		Check{Name: "peer", Critical: false, Fn: func(ctx context.Context) error { return errors.New("down") }},
+	)
+
+	router := gin.New()
+	checker.RegisterRoutes(router)
+
+	readyz := func() int {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		return rec.Code
+	}
+	grpcStatus := func() healthpb.HealthCheckResponse_ServingStatus {
+		resp, err := checker.GRPCServer().Check(context.Background(), &healthpb.HealthCheckRequest{Service: "bot.BotService"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.Status
+	}
+
+	t.Run("до первой проверки сервис не готов", func(t *testing.T) {
+		if code := readyz(); code != http.StatusServiceUnavailable {
+			t.Fatalf("ожидался 503, получено: %d", code)
+		}
+		if status := grpcStatus(); status != healthpb.HealthCheckResponse_NOT_SERVING {
+			t.Fatalf("ожидался NOT_SERVING, получено: %s", status)
+		}
+	})
+
+	t.Run("некритичная зависимость не влияет на готовность", func(t *testing.T) {
+		report := checker.CheckNow(context.Background())
+		if !report.Ready || report.Checks["peer"].Status != "fail" {
+			t.Fatalf("неожиданный отчёт: %+v", report)
+		}
+		if code := readyz(); code != http.StatusOK {
+			t.Fatalf("ожидался 200, получено: %d", code)
+		}
+		if status := grpcStatus(); status != healthpb.HealthCheckResponse_SERVING {
+			t.Fatalf("ожидался SERVING, получено: %s", status)
+		}
+	})
+
+	t.Run("критичная зависимость недоступна", func(t *testing.T) {
+		dbErr = errors.New("connection refused")
+		checker.CheckNow(context.Background())
+
+		if code := readyz(); code != http.StatusServiceUnavailable {
+			t.Fatalf("ожидался 503, получено: %d", code)
+		}
+		if status := grpcStatus(); status != healthpb.HealthCheckResponse_NOT_SERVING {
+			t.Fatalf("ожидался NOT_SERVING, получено: %s", status)
+		}
+	})
+
+	t.Run("liveness не зависит от зависимостей", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("ожидался 200, получено: %d", rec.Code)
+		}
+	})
+}
@@ -0,0 +1,25 @@
+package health
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// GRPCPeerCheck проверяет соседний gRPC сервис через grpc.health.v1 (service "" - сервер целиком)
+func GRPCPeerCheck(conn grpc.ClientConnInterface, service string) func(ctx context.Context) error {
+	client := healthpb.NewHealthClient(conn)
+
+	return func(ctx context.Context) error {
+		resp, err := client.Check(ctx, &healthpb.HealthCheckRequest{Service: service})
+		if err != nil {
+			return err
+		}
+		if resp.Status != healthpb.HealthCheckResponse_SERVING {
+			return fmt.Errorf("peer status: %s", resp.Status)
+		}
+		return nil
+	}
+}
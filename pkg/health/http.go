@@ -0,0 +1,29 @@
+package health
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterRoutes добавляет /healthz и /readyz в роутер
+func (c *Checker) RegisterRoutes(router gin.IRoutes) {
+	router.GET("/healthz", c.LivenessHandler)
+	router.GET("/readyz", c.ReadinessHandler)
+}
+
+// LivenessHandler - процесс жив и отвечает (зависимости не проверяются, чтобы оркестратор не перезапускал сервис из-за БД)
+func (c *Checker) LivenessHandler(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// ReadinessHandler - критичные зависимости доступны, сервис готов принимать трафик
+func (c *Checker) ReadinessHandler(ctx *gin.Context) {
+	report := c.Report()
+
+	code := http.StatusOK
+	if !report.Ready {
+		code = http.StatusServiceUnavailable
+	}
+	ctx.JSON(code, report)
+}
@@ -0,0 +1,188 @@
+// Пакет idempotency защищает от повторной обработки одного и того же запроса
+// (например, update от Telegram, который пришёл повторно после таймаута).
+//
+// Результат первой обработки сохраняется в кэше по ключу, повторные запросы получают сохранённый результат.
+// Параллельные дубликаты не выполняются одновременно:
+//   - внутри процесса они ждут результата первого вызова;
+//   - между репликами используется метка SET NX с TTL, остальные реплики опрашивают кэш до появления результата.
+//     Значение метки - случайный токен владельца: снимает метку только тот, кто её поставил
+package idempotency
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"global_models/global_cache"
+	"log/slog"
+	"pkg/configs"
+	"sync"
+	"time"
+)
+
+// ErrWaitTimeout - не дождались результата параллельной обработки того же ключа
+var ErrWaitTimeout = errors.New("idempotency: timed out waiting for in-flight result")
+
+// Func - функция обработки запроса.
+// Возвращает сериализованный результат и признак keep: сохранять ли результат для повторных запросов
+// (неуспешную обработку обычно не сохраняем, чтобы повторная доставка могла её исправить)
+type Func = func(ctx context.Context) (data []byte, keep bool, err error)
+
+// call - обработка ключа, выполняющаяся прямо сейчас в этом процессе
+type call struct {
+	done   chan struct{}
+	result []byte
+	err    error
+}
+
+// Guard - слой идемпотентности на базе глобального интерфейса кэша
+type Guard struct {
+	cache    global_cache.Cache
+	config   *configs.IdempotencyConfig
+	mu       sync.Mutex
+	inflight map[string]*call
+}
+
+// конструктор для слоя идемпотентности
+func NewGuard(cache global_cache.Cache, config *configs.IdempotencyConfig) (*Guard, error) {
+	if cache == nil {
+		return nil, fmt.Errorf("cache cannot be nil")
+	}
+	if config == nil {
+		return nil, fmt.Errorf("idempotency config cannot be nil")
+	}
+
+	return &Guard{
+		cache:    cache,
+		config:   config,
+		inflight: make(map[string]*call),
+	}, nil
+}
+
+// Do выполняет fn не более одного раза для ключа key и возвращает её результат.
+// Для дубликатов возвращается сохранённый результат первой обработки
+func (g *Guard) Do(ctx context.Context, key string, fn Func) ([]byte, error) {
+	if !g.config.Enabled {
+		data, _, err := fn(ctx)
+		return data, err
+	}
+
+	// если этот ключ уже обрабатывается в нашем процессе - ждём его результата
+	g.mu.Lock()
+	if c, ok := g.inflight[key]; ok {
+		g.mu.Unlock()
+		select {
+		case <-c.done:
+			return c.result, c.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	c := &call{done: make(chan struct{})}
+	g.inflight[key] = c
+	g.mu.Unlock()
+
+	c.result, c.err = g.do(ctx, key, fn)
+
+	g.mu.Lock()
+	delete(g.inflight, key)
+	g.mu.Unlock()
+	close(c.done)
+
+	return c.result, c.err
+}
+
+// метод для обработки ключа с учётом других реплик
+func (g *Guard) do(ctx context.Context, key string, fn Func) ([]byte, error) {
+	resultKey := g.resultKey(key)
+	lockKey := g.lockKey(key)
+	deadline := time.Now().Add(g.config.WaitTimeout)
+
+	for {
+		// 1. ключ уже обработан - возвращаем сохранённый результат
+		data, err := g.cache.GetBytes(ctx, resultKey)
+		if err == nil {
+			return data, nil
+		}
+		if !errors.Is(err, global_cache.ErrNotFound) {
+			// кэш недоступен - лучше обработать запрос, чем потерять его
+			slog.WarnContext(ctx, "idempotency: cache unavailable, processing without deduplication", "key", key, "error", err)
+			data, _, err := fn(ctx)
+			return data, err
+		}
+
+		// 2. пробуем стать единственным обработчиком ключа
+		token, err := newLockToken()
+		if err != nil {
+			return nil, err
+		}
+		acquired, err := g.cache.SetNX(ctx, lockKey, token, g.config.LockTTL)
+		if err != nil {
+			slog.WarnContext(ctx, "idempotency: failed to acquire lock, processing without deduplication", "key", key, "error", err)
+			data, _, err := fn(ctx)
+			return data, err
+		}
+		if acquired {
+			return g.run(ctx, key, token, fn)
+		}
+
+		// 3. ключ обрабатывает другая реплика - ждём результата
+		if time.Now().After(deadline) {
+			return nil, ErrWaitTimeout
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(g.config.PollInterval):
+		}
+	}
+}
+
+// метод для выполнения обработки после захвата метки
+func (g *Guard) run(ctx context.Context, key string, token []byte, fn Func) ([]byte, error) {
+	// метку снимаем даже при отменённом контексте запроса; если обработка пережила LockTTL
+	// и метку уже поставила другая реплика - её метку не трогаем
+	cleanupCtx := context.WithoutCancel(ctx)
+	defer func() {
+		released, err := g.cache.CompareAndDelete(cleanupCtx, g.lockKey(key), token)
+		if err != nil {
+			slog.WarnContext(ctx, "idempotency: failed to release lock", "key", key, "error", err)
+		} else if !released {
+			slog.WarnContext(ctx, "idempotency: lock expired before processing finished", "key", key, "lock_ttl", g.config.LockTTL)
+		}
+	}()
+
+	data, keep, err := fn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if keep {
+		if err := g.cache.Set(cleanupCtx, g.resultKey(key), data, g.config.ResultTTL); err != nil {
+			// результат уже получен, поэтому не считаем это ошибкой обработки
+			slog.WarnContext(ctx, "idempotency: failed to store result", "key", key, "error", err)
+		}
+	}
+
+	return data, nil
+}
+
+// функция случайного токена владельца метки
+func newLockToken() ([]byte, error) {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return nil, fmt.Errorf("idempotency: random lock token: %w", err)
+	}
+	return []byte(hex.EncodeToString(random)), nil
+}
+
+// метод формирования ключа для результата
+func (g *Guard) resultKey(key string) string {
+	return fmt.Sprintf("%s:result:%s", g.config.Prefix, key)
+}
+
+// метод формирования ключа для метки "в обработке"
+func (g *Guard) lockKey(key string) string {
+	return fmt.Sprintf("%s:lock:%s", g.config.Prefix, key)
+}
@@ -0,0 +1,175 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"global_models/global_cache"
+	"pkg/configs"
+	memorycache "pkg/memory_cache"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// кэш для тестов - реализация в памяти
+func newFakeCache() *memorycache.MemoryCache {
+	return memorycache.NewMemoryCache(0)
+}
+
+func newTestGuard(t *testing.T, cache global_cache.Cache) *Guard {
+	t.Helper()
+	cfg := configs.UseDefaultIdempotencyConfig()
+	cfg.PollInterval = time.Millisecond
+	cfg.WaitTimeout = time.Second
+	g, err := NewGuard(cache, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
+
+func TestGuardDo(t *testing.T) {
+	t.Run("повторный запрос получает сохранённый результат", func(t *testing.T) {
+		g := newTestGuard(t, newFakeCache())
+		var calls int32
+		fn := func(ctx context.Context) ([]byte, bool, error) {
+			atomic.AddInt32(&calls, 1)
+			return []byte("ok"), true, nil
+		}
+
+		for i := 0; i < 3; i++ {
+			data, err := g.Do(context.Background(), "42", fn)
+			if err != nil {
+				t.Fatalf("не ожидалась ошибка: %v", err)
+			}
+			if string(data) != "ok" {
+				t.Errorf("ожидался результат 'ok', получено %q", data)
+			}
+		}
+		if calls != 1 {
+			t.Errorf("ожидался 1 вызов обработчика, получено %d", calls)
+		}
+	})
+
+	t.Run("параллельные дубликаты ждут первый результат", func(t *testing.T) {
+		g := newTestGuard(t, newFakeCache())
+		var calls int32
+		release := make(chan struct{})
+		fn := func(ctx context.Context) ([]byte, bool, error) {
+			atomic.AddInt32(&calls, 1)
+			<-release
+			return []byte("first"), true, nil
+		}
+
+		var wg sync.WaitGroup
+		results := make([]string, 10)
+		for i := range results {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				data, err := g.Do(context.Background(), "7", fn)
+				if err != nil {
+					t.Errorf("не ожидалась ошибка: %v", err)
+				}
+				results[i] = string(data)
+			}(i)
+		}
+
+		time.Sleep(20 * time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		if calls != 1 {
+			t.Errorf("ожидался 1 вызов обработчика, получено %d", calls)
+		}
+		for i, r := range results {
+			if r != "first" {
+				t.Errorf("результат %d: ожидалось 'first', получено %q", i, r)
+			}
+		}
+	})
+
+	t.Run("дубликат с другой реплики дожидается результата", func(t *testing.T) {
+		cache := newFakeCache()
+		first := newTestGuard(t, cache)
+		second := newTestGuard(t, cache)
+
+		started := make(chan struct{})
+		release := make(chan struct{})
+		go first.Do(context.Background(), "9", func(ctx context.Context) ([]byte, bool, error) {
+			close(started)
+			<-release
+			return []byte("from-first"), true, nil
+		})
+		<-started
+
+		go func() {
+			time.Sleep(20 * time.Millisecond)
+			close(release)
+		}()
+
+		data, err := second.Do(context.Background(), "9", func(ctx context.Context) ([]byte, bool, error) {
+			t.Error("вторая реплика не должна обрабатывать дубликат")
+			return nil, false, nil
+		})
+		if err != nil {
+			t.Fatalf("не ожидалась ошибка: %v", err)
+		}
+		if string(data) != "from-first" {
+			t.Errorf("ожидался результат первой реплики, получено %q", data)
+		}
+	})
+
+	t.Run("неуспешная обработка не сохраняется", func(t *testing.T) {
+		g := newTestGuard(t, newFakeCache())
+		var calls int32
+
+		_, err := g.Do(context.Background(), "1", func(ctx context.Context) ([]byte, bool, error) {
+			atomic.AddInt32(&calls, 1)
+			return nil, false, errors.New("db down")
+		})
+		if err == nil {
+			t.Fatal("ожидалась ошибка обработчика")
+		}
+
+		_, err = g.Do(context.Background(), "1", func(ctx context.Context) ([]byte, bool, error) {
+			atomic.AddInt32(&calls, 1)
+			return []byte("retry"), false, nil
+		})
+		if err != nil {
+			t.Fatalf("не ожидалась ошибка: %v", err)
+		}
+		if calls != 2 {
+			t.Errorf("ожидалось 2 вызова обработчика, получено %d", calls)
+		}
+	})
+
+	t.Run("метка другой реплики не снимается после истечения своей", func(t *testing.T) {
+		cache := newFakeCache()
+		cfg := configs.UseDefaultIdempotencyConfig()
+		cfg.LockTTL = 20 * time.Millisecond
+		g, err := NewGuard(cache, cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lockKey := g.lockKey("7")
+
+		_, err = g.Do(context.Background(), "7", func(ctx context.Context) ([]byte, bool, error) {
+			// обработка дольше LockTTL: метка истекла, её поставила другая реплика
+			time.Sleep(2 * cfg.LockTTL)
+			if ok, err := cache.SetNX(ctx, lockKey, []byte("other"), time.Minute); err != nil || !ok {
+				t.Fatalf("метка другой реплики: %v %v", ok, err)
+			}
+			return []byte("slow"), false, nil
+		})
+		if err != nil {
+			t.Fatalf("не ожидалась ошибка: %v", err)
+		}
+
+		data, err := cache.GetBytes(context.Background(), lockKey)
+		if err != nil || string(data) != "other" {
+			t.Errorf("метка другой реплики снята: %q %v", data, err)
+		}
+	})
+}
@@ -0,0 +1,51 @@
+// Пакет interceptors - переиспользуемая цепочка серверных gRPC interceptor-ов:
+// перехват паники, access-лог, request-id, дедлайны по умолчанию и prometheus метрики.
+package interceptors
+
+import (
+	"log/slog"
+	"pkg/configs"
+
+	"google.golang.org/grpc"
+)
+
+// ServerOptions возвращает опции grpc.NewServer с цепочкой interceptor-ов, включённых в конфиге.
+// Порядок важен: request-id нужен логу, лог и метрики должны видеть код ответа после recovery,
+// а дедлайн ставится непосредственно перед хэндлером
+func ServerOptions(conf *configs.InterceptorsConfig, logger *slog.Logger) []grpc.ServerOption {
+	chain := UnaryServerChain(conf, logger)
+	if len(chain) == 0 {
+		return nil
+	}
+	return []grpc.ServerOption{grpc.ChainUnaryInterceptor(chain...)}
+}
+
+// UnaryServerChain собирает список interceptor-ов по конфигу
+func UnaryServerChain(conf *configs.InterceptorsConfig, logger *slog.Logger) []grpc.UnaryServerInterceptor {
+	if conf == nil {
+		conf = configs.UseDefaultInterceptorsConfig()
+	}
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	var chain []grpc.UnaryServerInterceptor
+
+	if conf.RequestID {
+		chain = append(chain, UnaryServerRequestID())
+	}
+	if conf.AccessLog {
+		chain = append(chain, UnaryServerAccessLog(logger))
+	}
+	if conf.Metrics {
+		chain = append(chain, UnaryServerMetrics(DefaultMetrics()))
+	}
+	if conf.Recovery {
+		chain = append(chain, UnaryServerRecovery(logger))
+	}
+	if conf.DefaultTimeout > 0 || len(conf.MethodTimeouts) > 0 {
+		chain = append(chain, UnaryServerDeadline(conf.TimeoutFor))
+	}
+
+	return chain
+}
@@ -0,0 +1,28 @@
+package interceptors
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+// UnaryServerDeadline ставит дедлайн вызовам, которые пришли без него.
+// timeoutFor возвращает таймаут для метода (0 - дедлайн не ставим).
+// Если клиент передал свой дедлайн, он не меняется
+func UnaryServerDeadline(timeoutFor func(fullMethod string) time.Duration) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if _, ok := ctx.Deadline(); ok {
+			return handler(ctx, req)
+		}
+
+		timeout := timeoutFor(info.FullMethod)
+		if timeout <= 0 {
+			return handler(ctx, req)
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		return handler(ctx, req)
+	}
+}
@@ -0,0 +1,101 @@
+package interceptors
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"pkg/logger"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+var testInfo = &grpc.UnaryServerInfo{FullMethod: "/bot.BotService/ProcessUpdate"}
+
+func TestInterceptors(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	t.Run("паника превращается в codes.Internal", func(t *testing.T) {
+		interceptor := UnaryServerRecovery(log)
+
+		_, err := interceptor(context.Background(), nil, testInfo, func(ctx context.Context, req any) (any, error) {
+			panic("boom")
+		})
+		if status.Code(err) != codes.Internal {
+			t.Fatalf("ожидался codes.Internal, получено: %v", err)
+		}
+	})
+
+	t.Run("request-id берётся из метаданных", func(t *testing.T) {
+		interceptor := UnaryServerRequestID()
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(RequestIDKey, "abc"))
+
+		var got string
+		_, _ = interceptor(ctx, nil, testInfo, func(ctx context.Context, req any) (any, error) {
+			got = logger.CorrelationID(ctx)
+			return nil, nil
+		})
+		if got != "abc" {
+			t.Fatalf("ожидался request-id 'abc', получено: %q", got)
+		}
+	})
+
+	t.Run("request-id генерируется, если его нет", func(t *testing.T) {
+		interceptor := UnaryServerRequestID()
+
+		var got string
+		_, _ = interceptor(context.Background(), nil, testInfo, func(ctx context.Context, req any) (any, error) {
+			got = logger.CorrelationID(ctx)
+			return nil, nil
+		})
+		if got == "" {
+			t.Fatal("ожидался сгенерированный request-id")
+		}
+	})
+
+	t.Run("дедлайн ставится только если его нет", func(t *testing.T) {
+		interceptor := UnaryServerDeadline(func(string) time.Duration { return time.Minute })
+
+		var deadline time.Time
+		var ok bool
+		handler := func(ctx context.Context, req any) (any, error) {
+			deadline, ok = ctx.Deadline()
+			return nil, nil
+		}
+
+		_, _ = interceptor(context.Background(), nil, testInfo, handler)
+		if !ok || time.Until(deadline) > time.Minute {
+			t.Fatalf("ожидался дедлайн не позже минуты, получено: %v %v", deadline, ok)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+		defer cancel()
+		_, _ = interceptor(ctx, nil, testInfo, handler)
+		if time.Until(deadline) < 59*time.Minute {
+			t.Fatalf("дедлайн клиента не должен меняться, получено: %v", deadline)
+		}
+	})
+
+	t.Run("метрики считают вызовы по коду", func(t *testing.T) {
+		metrics, err := NewMetrics(prometheus.NewRegistry())
+		if err != nil {
+			t.Fatal(err)
+		}
+		interceptor := UnaryServerMetrics(metrics)
+
+		_, _ = interceptor(context.Background(), nil, testInfo, func(ctx context.Context, req any) (any, error) {
+			return nil, status.Error(codes.InvalidArgument, "bad")
+		})
+
+		got := testutil.ToFloat64(metrics.requests.WithLabelValues(testInfo.FullMethod, codes.InvalidArgument.String()))
+		if got != 1 {
+			t.Fatalf("ожидался 1 вызов, получено: %v", got)
+		}
+	})
+}
@@ -0,0 +1,44 @@
+package interceptors
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryServerAccessLog пишет структурированную запись о каждом вызове: метод, длительность, код ответа
+func UnaryServerAccessLog(logger *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		code := status.Code(err)
+
+		attrs := []slog.Attr{
+			slog.String("method", info.FullMethod),
+			slog.Duration("duration", time.Since(start)),
+			slog.String("code", code.String()),
+		}
+		if err != nil {
+			attrs = append(attrs, slog.String("error", err.Error()))
+		}
+
+		logger.LogAttrs(ctx, levelForCode(code), "grpc call", attrs...)
+		return resp, err
+	}
+}
+
+// levelForCode - ошибки сервера логируем как error, ошибки клиента как warn
+func levelForCode(code codes.Code) slog.Level {
+	switch code {
+	case codes.OK:
+		return slog.LevelInfo
+	case codes.Internal, codes.Unknown, codes.DataLoss, codes.Unimplemented, codes.Unavailable, codes.DeadlineExceeded:
+		return slog.LevelError
+	default:
+		return slog.LevelWarn
+	}
+}
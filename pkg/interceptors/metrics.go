@@ -0,0 +1,146 @@
+package interceptors
+
+import (
+	"context"
+	"pkg/metrics"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// Metrics - prometheus метрики серверных gRPC вызовов
+type Metrics struct {
+	requests *prometheus.CounterVec   // количество вызовов по методу и коду ответа
+	duration *prometheus.HistogramVec // длительность вызовов по методу
+	inFlight *prometheus.GaugeVec     // вызовы в работе по методу
+}
+
+var (
+	defaultMetrics     *Metrics
+	defaultMetricsOnce sync.Once
+)
+
+// DefaultMetrics возвращает метрики, зарегистрированные в prometheus.DefaultRegisterer (создаются один раз на процесс)
+func DefaultMetrics() *Metrics {
+	defaultMetricsOnce.Do(func() {
+		m, err := NewMetrics(prometheus.DefaultRegisterer)
+		if err != nil {
+			panic(err)
+		}
+		defaultMetrics = m
+	})
+	return defaultMetrics
+}
+
+// конструктор для метрик, регистрирует их в переданном registerer
+func NewMetrics(reg prometheus.Registerer) (*Metrics, error) {
+	m := &Metrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "grpc_server_handled_total",
+			Help: "Total number of gRPC calls completed on the server, by method and status code.",
+		}, []string{"method", "code"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "grpc_server_handling_seconds",
+			Help:    "Duration of gRPC calls handled by the server.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method"}),
+		inFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "grpc_server_in_flight",
+			Help: "Number of gRPC calls currently being handled.",
+		}, []string{"method"}),
+	}
+
+	var err error
+	if m.requests, err = metrics.Register(reg, m.requests); err != nil {
+		return nil, err
+	}
+	if m.duration, err = metrics.Register(reg, m.duration); err != nil {
+		return nil, err
+	}
+	if m.inFlight, err = metrics.Register(reg, m.inFlight); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// UnaryServerMetrics считает вызовы, коды ответов и длительность
+func UnaryServerMetrics(m *Metrics) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		inFlight := m.inFlight.WithLabelValues(info.FullMethod)
+		inFlight.Inc()
+		defer inFlight.Dec()
+
+		start := time.Now()
+		resp, err := handler(ctx, req)
+
+		m.duration.WithLabelValues(info.FullMethod).Observe(time.Since(start).Seconds())
+		m.requests.WithLabelValues(info.FullMethod, status.Code(err).String()).Inc()
+
+		return resp, err
+	}
+}
+
+// ClientMetrics - prometheus метрики клиентских gRPC вызовов
+type ClientMetrics struct {
+	requests *prometheus.CounterVec   // количество вызовов по методу и коду ответа
+	duration *prometheus.HistogramVec // длительность вызовов (вместе с повторами) по методу
+}
+
+var (
+	defaultClientMetrics     *ClientMetrics
+	defaultClientMetricsOnce sync.Once
+)
+
+// DefaultClientMetrics возвращает клиентские метрики, зарегистрированные в prometheus.DefaultRegisterer
+func DefaultClientMetrics() *ClientMetrics {
+	defaultClientMetricsOnce.Do(func() {
+		m, err := NewClientMetrics(prometheus.DefaultRegisterer)
+		if err != nil {
+			panic(err)
+		}
+		defaultClientMetrics = m
+	})
+	return defaultClientMetrics
+}
+
+// конструктор для клиентских метрик, регистрирует их в переданном registerer
+func NewClientMetrics(reg prometheus.Registerer) (*ClientMetrics, error) {
+	m := &ClientMetrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "grpc_client_handled_total",
+			Help: "Total number of gRPC calls completed by the client, by method and status code.",
+		}, []string{"method", "code"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "grpc_client_handling_seconds",
+			Help:    "Duration of gRPC calls made by the client, including retries.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method"}),
+	}
+
+	var err error
+	if m.requests, err = metrics.Register(reg, m.requests); err != nil {
+		return nil, err
+	}
+	if m.duration, err = metrics.Register(reg, m.duration); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// UnaryClientMetrics считает исходящие вызовы, коды ответов и длительность
+func UnaryClientMetrics(m *ClientMetrics) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		start := time.Now()
+		err := invoker(ctx, method, req, reply, cc, opts...)
+
+		m.duration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+		m.requests.WithLabelValues(method, status.Code(err).String()).Inc()
+
+		return err
+	}
+}
@@ -0,0 +1,44 @@
+package interceptors
+
+import (
+	"context"
+	"pkg/logger"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// RequestIDKey - ключ метаданных, в котором передаётся request-id (он же correlation ID в логах)
+const RequestIDKey = "x-request-id"
+
+// UnaryServerRequestID берёт request-id из входящих метаданных (или генерирует новый),
+// кладёт его в контекст как correlation ID и возвращает клиенту в заголовке ответа
+func UnaryServerRequestID() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		requestID := ""
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get(RequestIDKey); len(values) > 0 {
+				requestID = values[0]
+			}
+		}
+		if requestID == "" {
+			requestID = logger.NewCorrelationID()
+		}
+
+		// заголовок ответа - не критично, если не удалось (например, в тестах без транспорта)
+		_ = grpc.SetHeader(ctx, metadata.Pairs(RequestIDKey, requestID))
+
+		return handler(logger.WithCorrelationID(ctx, requestID), req)
+	}
+}
+
+// UnaryClientRequestID передаёт correlation ID из контекста в исходящие метаданные,
+// чтобы вызов можно было связать с исходным запросом на другой стороне
+func UnaryClientRequestID() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if requestID := logger.CorrelationID(ctx); requestID != "" {
+			ctx = metadata.AppendToOutgoingContext(ctx, RequestIDKey, requestID)
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
@@ -0,0 +1,311 @@
+// Пакет jobqueue - очередь фоновых задач на redis streams (consumer group).
+//
+// Задача попадает в поток <prefix>:stream и выполняется одним из обработчиков группы на любом экземпляре.
+// Отложенные задачи и повторы ждут своего времени в упорядоченном множестве <prefix>:delayed.
+// Задача, не подтверждённая дольше VisibilityTimeout (экземпляр упал посреди выполнения), забирается
+// другим обработчиком, поэтому обработчики должны быть идемпотентны. Задачи, исчерпавшие попытки,
+// уходят в поток <prefix>:dead
+package jobqueue
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"pkg/configs"
+	"pkg/metrics"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ErrClosed - очередь остановлена
+var ErrClosed = errors.New("jobqueue: closed")
+
+// метрики очереди (queue - префикс очереди, type - тип задачи)
+var (
+	jobsTotal = metrics.MustRegister(prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "jobqueue_jobs_total",
+		Help: "Jobs by result: enqueued, ok, retry (failed attempt, will be retried), dead (moved to dead-letter).",
+	}, []string{"queue", "type", "result"}))
+
+	jobDuration = metrics.MustRegister(prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "jobqueue_job_duration_seconds",
+		Help:    "Duration of a single job attempt.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"queue", "type"}))
+)
+
+// Job - задача очереди
+type Job struct {
+	ID         string          `json:"id"`
+	Type       string          `json:"type"`
+	Payload    json.RawMessage `json:"payload"`
+	Attempt    int             `json:"attempt"` // номер текущей попытки (с 1)
+	EnqueuedAt time.Time       `json:"enqueued_at"`
+	LastError  string          `json:"last_error,omitempty"` // ошибка предыдущей попытки
+}
+
+// Handler выполняет задачу. Ошибка - попытка неудачна (задача повторится), Permanent(err) - без повторов
+type Handler func(ctx context.Context, job *Job) error
+
+// ошибка, после которой задача не повторяется
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent помечает ошибку как окончательную: задача сразу уходит в dead-letter
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// функция проверки, окончательная ли ошибка
+func isPermanent(err error) bool {
+	var permanent *permanentError
+	return errors.As(err, &permanent)
+}
+
+// Handle регистрирует обработчик задачи с типизированными данными (данные приходят в JSON).
+// Данные, которые не разбираются в T, не повторяются - задача сразу уходит в dead-letter
+func Handle[T any](q *Queue, jobType string, fn func(ctx context.Context, payload T) error) {
+	q.Register(jobType, func(ctx context.Context, job *Job) error {
+		var payload T
+		if err := json.Unmarshal(job.Payload, &payload); err != nil {
+			return Permanent(fmt.Errorf("decode %s payload: %w", jobType, err))
+		}
+		return fn(ctx, payload)
+	})
+}
+
+// Queue - очередь задач. Enqueue можно вызывать на любом экземпляре, Run выполняет задачи
+type Queue struct {
+	client   redis.UniversalClient // nil - задачи выполняются сразу, без очереди
+	conf     *configs.JobQueueConfig
+	consumer string
+
+	stream, group, delayed, dead string
+
+	mu       sync.RWMutex
+	handlers map[string]Handler
+
+	stop     chan struct{} // закрывается в Close: обработчики не берут новые задачи
+	stopOnce sync.Once
+	running  sync.WaitGroup // циклы Run и задачи в работе
+
+	jobCtx    context.Context // контекст задач: отменяется, если Close не дождался их завершения
+	jobCancel context.CancelFunc
+}
+
+// конструктор для Queue. client = nil - задачи выполняются сразу в фоне, без повторов
+// (локальный запуск без redis)
+func New(client redis.UniversalClient, conf *configs.JobQueueConfig) (*Queue, error) {
+	if conf == nil {
+		conf = configs.UseDefaultJobQueueConfig()
+	}
+	if conf.Prefix == "" {
+		return nil, errors.New("jobqueue: prefix is empty")
+	}
+	if conf.Workers <= 0 || conf.MaxAttempts <= 0 {
+		return nil, errors.New("jobqueue: workers and max_attempts must be positive")
+	}
+	if conf.JobTimeout <= 0 || conf.VisibilityTimeout <= conf.JobTimeout {
+		return nil, errors.New("jobqueue: visibility_timeout must be greater than job_timeout")
+	}
+	if conf.RetryBackoff <= 0 || conf.ClaimInterval <= 0 || conf.PollInterval <= 0 || conf.BlockTimeout <= 0 {
+		return nil, errors.New("jobqueue: intervals must be positive")
+	}
+
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return nil, fmt.Errorf("jobqueue: %w", err)
+	}
+	hostname, _ := os.Hostname()
+
+	jobCtx, jobCancel := context.WithCancel(context.Background())
+	return &Queue{
+		client:    client,
+		conf:      conf,
+		consumer:  hostname + "-" + hex.EncodeToString(suffix),
+		stream:    conf.Prefix + ":stream",
+		group:     conf.Prefix + ":workers",
+		delayed:   conf.Prefix + ":delayed",
+		dead:      conf.Prefix + ":dead",
+		handlers:  make(map[string]Handler),
+		stop:      make(chan struct{}),
+		jobCtx:    jobCtx,
+		jobCancel: jobCancel,
+	}, nil
+}
+
+// Register регистрирует обработчик типа задачи (до Run)
+func (q *Queue) Register(jobType string, handler Handler) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.handlers[jobType] = handler
+}
+
+// метод возвращает обработчик типа задачи
+func (q *Queue) handler(jobType string) (Handler, bool) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	handler, ok := q.handlers[jobType]
+	return handler, ok
+}
+
+// Enqueue ставит задачу в очередь (payload кодируется в JSON) и возвращает её ID
+func (q *Queue) Enqueue(ctx context.Context, jobType string, payload any) (string, error) {
+	return q.EnqueueIn(ctx, jobType, payload, 0)
+}
+
+// EnqueueIn ставит задачу, которая выполнится не раньше чем через delay
+func (q *Queue) EnqueueIn(ctx context.Context, jobType string, payload any, delay time.Duration) (string, error) {
+	select {
+	case <-q.stop:
+		return "", ErrClosed
+	default:
+	}
+
+	job, err := newJob(jobType, payload)
+	if err != nil {
+		return "", err
+	}
+
+	if q.client == nil {
+		q.runInline(job, delay)
+	} else if delay > 0 {
+		err = q.schedule(ctx, q.client, job, time.Now().Add(delay))
+	} else {
+		err = q.add(ctx, q.client, job)
+	}
+	if err != nil {
+		return "", fmt.Errorf("enqueue %s: %w", jobType, err)
+	}
+
+	jobsTotal.WithLabelValues(q.conf.Prefix, jobType, "enqueued").Inc()
+	return job.ID, nil
+}
+
+// функция создания задачи
+func newJob(jobType string, payload any) (*Job, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("encode %s payload: %w", jobType, err)
+	}
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	return &Job{
+		ID:         hex.EncodeToString(id),
+		Type:       jobType,
+		Payload:    data,
+		Attempt:    1,
+		EnqueuedAt: time.Now().UTC(),
+	}, nil
+}
+
+// метод добавления задачи в поток
+func (q *Queue) add(ctx context.Context, cmd redis.Cmdable, job *Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	return cmd.XAdd(ctx, &redis.XAddArgs{
+		Stream: q.stream,
+		MaxLen: q.conf.MaxLen,
+		Approx: true,
+		Values: map[string]any{"job": data},
+	}).Err()
+}
+
+// метод откладывания задачи до runAt (cmd - клиент или транзакция)
+func (q *Queue) schedule(ctx context.Context, cmd redis.Cmdable, job *Job, runAt time.Time) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	return cmd.ZAdd(ctx, q.delayed, &redis.Z{Score: float64(runAt.UnixMilli()), Member: data}).Err()
+}
+
+// метод выполнения задачи без очереди (client = nil): одна попытка в фоне
+func (q *Queue) runInline(job *Job, delay time.Duration) {
+	q.running.Add(1)
+	go func() {
+		defer q.running.Done()
+		if delay > 0 {
+			select {
+			case <-q.jobCtx.Done():
+				return
+			case <-time.After(delay):
+			}
+		}
+		if err := q.execute(job); err != nil {
+			slog.Error("job failed", "queue", q.conf.Prefix, "type", job.Type, "id", job.ID, "error", err)
+			jobsTotal.WithLabelValues(q.conf.Prefix, job.Type, "dead").Inc()
+			return
+		}
+		jobsTotal.WithLabelValues(q.conf.Prefix, job.Type, "ok").Inc()
+	}()
+}
+
+// метод выполнения одной попытки задачи (с таймаутом и защитой от паники)
+func (q *Queue) execute(job *Job) (err error) {
+	handler, ok := q.handler(job.Type)
+	if !ok {
+		return Permanent(fmt.Errorf("no handler for job type %q", job.Type))
+	}
+
+	ctx, cancel := context.WithTimeout(q.jobCtx, q.conf.JobTimeout)
+	defer cancel()
+
+	started := time.Now()
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+		jobDuration.WithLabelValues(q.conf.Prefix, job.Type).Observe(time.Since(started).Seconds())
+	}()
+	return handler(ctx, job)
+}
+
+// Stats - состояние очереди
+type Stats struct {
+	Queued  int64 `json:"queued"`  // задачи в потоке (ещё не взятые и в работе)
+	Pending int64 `json:"pending"` // взяты обработчиками и не подтверждены
+	Delayed int64 `json:"delayed"` // отложенные задачи и ожидающие повтора
+	Dead    int64 `json:"dead"`    // задачи в dead-letter
+}
+
+// метод получения состояния очереди
+func (q *Queue) Stats(ctx context.Context) (Stats, error) {
+	if q.client == nil {
+		return Stats{}, nil
+	}
+
+	pipe := q.client.Pipeline()
+	queued := pipe.XLen(ctx, q.stream)
+	pending := pipe.XPending(ctx, q.stream, q.group)
+	delayed := pipe.ZCard(ctx, q.delayed)
+	dead := pipe.XLen(ctx, q.dead)
+	if _, err := pipe.Exec(ctx); err != nil && !isNoGroup(err) {
+		return Stats{}, err
+	}
+
+	stats := Stats{Queued: queued.Val(), Delayed: delayed.Val(), Dead: dead.Val()}
+	if pending.Err() == nil {
+		stats.Pending = pending.Val().Count
+	}
+	return stats, nil
+}
@@ -0,0 +1,257 @@
+package jobqueue
+
+import (
+	"context"
+	"errors"
+	"pkg/configs"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+)
+
+type notifyPayload struct {
+	ChatID int64  `json:"chat_id"`
+	Text   string `json:"text"`
+}
+
+func testConfig() *configs.JobQueueConfig {
+	return &configs.JobQueueConfig{
+		Enabled:           true,
+		Prefix:            "test_jobs",
+		Workers:           2,
+		MaxAttempts:       3,
+		RetryBackoff:      10 * time.Millisecond,
+		MaxBackoff:        20 * time.Millisecond,
+		JobTimeout:        time.Second,
+		VisibilityTimeout: 2 * time.Second,
+		ClaimInterval:     20 * time.Millisecond,
+		PollInterval:      10 * time.Millisecond,
+		BlockTimeout:      50 * time.Millisecond,
+		MaxLen:            1000,
+	}
+}
+
+func newTestClient(t *testing.T) *redis.Client {
+	t.Helper()
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+// функция запуска очереди в фоне (останавливается в конце теста)
+func startQueue(t *testing.T, q *Queue) {
+	t.Helper()
+	go q.Run(context.Background())
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		q.Close(ctx)
+	})
+}
+
+func newTestQueue(t *testing.T, client redis.UniversalClient, conf *configs.JobQueueConfig) *Queue {
+	t.Helper()
+	q, err := New(client, conf)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return q
+}
+
+// функция ожидания условия (задачи выполняются в фоне)
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("не дождались: %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestQueue(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("задача выполняется с типизированными данными", func(t *testing.T) {
+		q := newTestQueue(t, newTestClient(t), testConfig())
+		got := make(chan notifyPayload, 1)
+		Handle(q, "notify", func(ctx context.Context, p notifyPayload) error {
+			got <- p
+			return nil
+		})
+		startQueue(t, q)
+
+		if _, err := q.Enqueue(ctx, "notify", notifyPayload{ChatID: 42, Text: "заявка"}); err != nil {
+			t.Fatalf("Enqueue: %v", err)
+		}
+		select {
+		case p := <-got:
+			if p.ChatID != 42 || p.Text != "заявка" {
+				t.Errorf("данные задачи: %+v", p)
+			}
+		case <-time.After(3 * time.Second):
+			t.Fatal("задача не выполнена")
+		}
+		waitFor(t, "поток очищен", func() bool {
+			stats, _ := q.Stats(ctx)
+			return stats.Queued == 0 && stats.Pending == 0
+		})
+	})
+
+	t.Run("неудачная попытка повторяется", func(t *testing.T) {
+		q := newTestQueue(t, newTestClient(t), testConfig())
+		var attempts atomic.Int64
+		q.Register("flaky", func(ctx context.Context, job *Job) error {
+			if attempts.Add(1) < 3 {
+				return errors.New("temporary")
+			}
+			if job.Attempt != 3 || job.LastError != "temporary" {
+				t.Errorf("попытка %d, прошлая ошибка %q", job.Attempt, job.LastError)
+			}
+			return nil
+		})
+		startQueue(t, q)
+
+		q.Enqueue(ctx, "flaky", nil)
+		waitFor(t, "третья попытка", func() bool { return attempts.Load() == 3 })
+
+		time.Sleep(50 * time.Millisecond)
+		if stats, _ := q.Stats(ctx); stats.Dead != 0 || stats.Delayed != 0 {
+			t.Errorf("состояние после успеха: %+v", stats)
+		}
+	})
+
+	t.Run("исчерпавшая попытки задача уходит в dead-letter", func(t *testing.T) {
+		q := newTestQueue(t, newTestClient(t), testConfig())
+		var attempts atomic.Int64
+		q.Register("broken", func(context.Context, *Job) error {
+			attempts.Add(1)
+			return errors.New("always fails")
+		})
+		q.Register("invalid", func(context.Context, *Job) error {
+			return Permanent(errors.New("bad input"))
+		})
+		startQueue(t, q)
+
+		q.Enqueue(ctx, "broken", nil)
+		q.Enqueue(ctx, "invalid", nil)
+		q.Enqueue(ctx, "unknown_type", nil)
+		waitFor(t, "три задачи в dead-letter", func() bool {
+			stats, _ := q.Stats(ctx)
+			return stats.Dead == 3
+		})
+		if got := attempts.Load(); got != 3 {
+			t.Errorf("попыток: %d, ожидали max_attempts = 3", got)
+		}
+	})
+
+	t.Run("отложенная задача ждёт своего времени", func(t *testing.T) {
+		q := newTestQueue(t, newTestClient(t), testConfig())
+		done := make(chan time.Time, 1)
+		q.Register("later", func(context.Context, *Job) error {
+			done <- time.Now()
+			return nil
+		})
+		startQueue(t, q)
+
+		enqueued := time.Now()
+		q.EnqueueIn(ctx, "later", nil, 200*time.Millisecond)
+		if stats, _ := q.Stats(ctx); stats.Delayed != 1 {
+			t.Errorf("отложенных: %d", stats.Delayed)
+		}
+		select {
+		case at := <-done:
+			if at.Sub(enqueued) < 200*time.Millisecond {
+				t.Errorf("выполнена через %v, раньше срока", at.Sub(enqueued))
+			}
+		case <-time.After(3 * time.Second):
+			t.Fatal("задача не выполнена")
+		}
+	})
+
+	t.Run("зависшую задачу забирает другой обработчик", func(t *testing.T) {
+		client := newTestClient(t)
+		conf := testConfig()
+		conf.JobTimeout = 50 * time.Millisecond
+		conf.VisibilityTimeout = 100 * time.Millisecond
+
+		// первый экземпляр взял задачу и упал, не подтвердив её
+		crashed := newTestQueue(t, client, conf)
+		crashed.Enqueue(ctx, "notify", notifyPayload{ChatID: 1})
+		client.XGroupCreateMkStream(ctx, crashed.stream, crashed.group, "0")
+		if err := client.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group: crashed.group, Consumer: "crashed", Streams: []string{crashed.stream, ">"}, Count: 1,
+		}).Err(); err != nil {
+			t.Fatalf("XReadGroup: %v", err)
+		}
+
+		q := newTestQueue(t, client, conf)
+		done := make(chan struct{}, 1)
+		Handle(q, "notify", func(context.Context, notifyPayload) error {
+			done <- struct{}{}
+			return nil
+		})
+		startQueue(t, q)
+
+		select {
+		case <-done:
+		case <-time.After(3 * time.Second):
+			t.Fatal("зависшая задача не выполнена")
+		}
+	})
+
+	t.Run("Close дожидается выполняемой задачи", func(t *testing.T) {
+		q := newTestQueue(t, newTestClient(t), testConfig())
+		started := make(chan struct{})
+		var finished atomic.Bool
+		q.Register("slow", func(context.Context, *Job) error {
+			close(started)
+			time.Sleep(100 * time.Millisecond)
+			finished.Store(true)
+			return nil
+		})
+		go q.Run(ctx)
+
+		q.Enqueue(ctx, "slow", nil)
+		<-started
+
+		closeCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+		defer cancel()
+		if err := q.Close(closeCtx); err != nil {
+			t.Fatalf("Close: %v", err)
+		}
+		if !finished.Load() {
+			t.Error("Close вернулся до завершения задачи")
+		}
+		if _, err := q.Enqueue(ctx, "slow", nil); !errors.Is(err, ErrClosed) {
+			t.Errorf("Enqueue после Close: %v", err)
+		}
+		if stats, _ := q.Stats(ctx); stats.Queued != 0 {
+			t.Errorf("задача осталась в потоке: %+v", stats)
+		}
+	})
+
+	t.Run("без redis задача выполняется сразу", func(t *testing.T) {
+		q := newTestQueue(t, nil, testConfig())
+		done := make(chan struct{})
+		Handle(q, "notify", func(context.Context, notifyPayload) error {
+			close(done)
+			return nil
+		})
+
+		if _, err := q.Enqueue(ctx, "notify", notifyPayload{ChatID: 1}); err != nil {
+			t.Fatalf("Enqueue: %v", err)
+		}
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("задача не выполнена")
+		}
+		q.Close(ctx)
+	})
+}
@@ -0,0 +1,317 @@
+package jobqueue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// сколько отложенных задач переносится в поток за один проход
+const promoteBatch = 100
+
+// перенос отложенных задач, время которых пришло, в поток (атомарно: задача не теряется и не дублируется)
+var promoteScript = redis.NewScript(`
+local due = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, ARGV[2])
+for _, job in ipairs(due) do
+	redis.call('XADD', KEYS[2], 'MAXLEN', '~', ARGV[3], '*', 'job', job)
+	redis.call('ZREM', KEYS[1], job)
+end
+return #due
+`)
+
+// Run выполняет задачи до отмены ctx или Close: Workers обработчиков, перенос отложенных
+// задач и поиск зависших. Без redis (client = nil) только ждёт остановки
+func (q *Queue) Run(ctx context.Context) error {
+	if q.client == nil {
+		return nil
+	}
+
+	// группа создаётся вместе с потоком; BUSYGROUP - группа уже есть
+	err := q.client.XGroupCreateMkStream(ctx, q.stream, q.group, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-q.stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	slog.Info("job queue started", "queue", q.conf.Prefix, "consumer", q.consumer, "workers", q.conf.Workers)
+
+	for range q.conf.Workers {
+		q.running.Add(1)
+		go func() {
+			defer q.running.Done()
+			q.work(ctx)
+		}()
+	}
+	q.running.Add(2)
+	go func() {
+		defer q.running.Done()
+		q.every(ctx, q.conf.PollInterval, q.promote)
+	}()
+	go func() {
+		defer q.running.Done()
+		q.every(ctx, q.conf.ClaimInterval, q.claim)
+	}()
+
+	<-ctx.Done()
+	return nil
+}
+
+// метод периодического вызова fn до отмены ctx
+func (q *Queue) every(ctx context.Context, interval time.Duration, fn func(ctx context.Context)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			fn(ctx)
+		}
+	}
+}
+
+// цикл обработчика: берёт новые задачи группы по одной
+func (q *Queue) work(ctx context.Context) {
+	for ctx.Err() == nil {
+		streams, err := q.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    q.group,
+			Consumer: q.consumer,
+			Streams:  []string{q.stream, ">"},
+			Count:    1,
+			Block:    q.conf.BlockTimeout,
+		}).Result()
+		if errors.Is(err, redis.Nil) || ctx.Err() != nil {
+			continue
+		}
+		if err != nil {
+			slog.Warn("job queue: read failed", "queue", q.conf.Prefix, "error", err)
+			select {
+			case <-ctx.Done():
+			case <-time.After(time.Second):
+			}
+			continue
+		}
+
+		for _, stream := range streams {
+			for _, msg := range stream.Messages {
+				q.process(msg)
+			}
+		}
+	}
+}
+
+// метод переноса отложенных задач, время которых пришло
+func (q *Queue) promote(ctx context.Context) {
+	now := time.Now().UnixMilli()
+	err := promoteScript.Run(ctx, q.client, []string{q.delayed, q.stream}, now, promoteBatch, q.conf.MaxLen).Err()
+	if err != nil && ctx.Err() == nil {
+		slog.Warn("job queue: promote failed", "queue", q.conf.Prefix, "error", err)
+	}
+}
+
+// метод поиска задач, которые взяты и не подтверждены дольше VisibilityTimeout (их обработчик упал).
+// Задача, которую забирали уже больше MaxAttempts раз, сама роняет обработчик - она уходит в dead-letter
+func (q *Queue) claim(ctx context.Context) {
+	pending, err := q.client.XPendingExt(ctx, &redis.XPendingExtArgs{
+		Stream: q.stream,
+		Group:  q.group,
+		Idle:   q.conf.VisibilityTimeout,
+		Start:  "-",
+		End:    "+",
+		Count:  int64(q.conf.Workers),
+	}).Result()
+	if err != nil || len(pending) == 0 {
+		if err != nil && ctx.Err() == nil {
+			slog.Warn("job queue: claim failed", "queue", q.conf.Prefix, "error", err)
+		}
+		return
+	}
+
+	ids := make([]string, 0, len(pending))
+	deliveries := make(map[string]int64, len(pending))
+	for _, p := range pending {
+		ids = append(ids, p.ID)
+		deliveries[p.ID] = p.RetryCount
+	}
+
+	// XCLAIM повторно проверяет простой: задачу, которую уже забрал другой экземпляр, не получим
+	messages, err := q.client.XClaim(ctx, &redis.XClaimArgs{
+		Stream:   q.stream,
+		Group:    q.group,
+		Consumer: q.consumer,
+		MinIdle:  q.conf.VisibilityTimeout,
+		Messages: ids,
+	}).Result()
+	if err != nil {
+		if ctx.Err() == nil {
+			slog.Warn("job queue: claim failed", "queue", q.conf.Prefix, "error", err)
+		}
+		return
+	}
+
+	for _, msg := range messages {
+		slog.Warn("job queue: claimed stuck job", "queue", q.conf.Prefix, "message_id", msg.ID, "deliveries", deliveries[msg.ID])
+		if deliveries[msg.ID] > int64(q.conf.MaxAttempts) {
+			buryCtx, cancel := context.WithTimeout(context.Background(), q.conf.BlockTimeout)
+			job, decodeErr := decodeJob(msg)
+			if decodeErr != nil {
+				job = &Job{ID: msg.ID, Type: "unknown"}
+			}
+			q.bury(buryCtx, msg, job, errors.New("job was abandoned by its worker too many times"))
+			cancel()
+			continue
+		}
+		q.process(msg)
+	}
+}
+
+// метод выполнения задачи из потока и фиксации результата (подтверждение, повтор или dead-letter)
+func (q *Queue) process(msg redis.XMessage) {
+	job, err := decodeJob(msg)
+	if err != nil {
+		slog.Error("job queue: bad message", "queue", q.conf.Prefix, "message_id", msg.ID, "error", err)
+		job, err = &Job{ID: msg.ID, Type: "unknown"}, Permanent(err)
+	} else {
+		err = q.execute(job)
+	}
+	runErr := err
+
+	// результат фиксируется и при остановке очереди, иначе задача будет выполнена повторно
+	ctx, cancel := context.WithTimeout(context.Background(), q.conf.BlockTimeout)
+	defer cancel()
+
+	switch {
+	case runErr == nil:
+		q.ack(ctx, msg)
+		jobsTotal.WithLabelValues(q.conf.Prefix, job.Type, "ok").Inc()
+	case isPermanent(runErr) || job.Attempt >= q.conf.MaxAttempts:
+		slog.Error("job failed permanently", "queue", q.conf.Prefix, "type", job.Type, "id", job.ID,
+			"attempt", job.Attempt, "error", runErr)
+		q.bury(ctx, msg, job, runErr)
+	default:
+		delay := q.backoff(job.Attempt)
+		slog.Warn("job failed, will retry", "queue", q.conf.Prefix, "type", job.Type, "id", job.ID,
+			"attempt", job.Attempt, "retry_in", delay, "error", runErr)
+		q.retry(ctx, msg, job, runErr, delay)
+	}
+}
+
+// функция разбора сообщения потока
+func decodeJob(msg redis.XMessage) (*Job, error) {
+	raw, ok := msg.Values["job"].(string)
+	if !ok {
+		return nil, errors.New("message has no job field")
+	}
+	job := &Job{}
+	if err := json.Unmarshal([]byte(raw), job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// метод расчёта паузы перед следующей попыткой (удваивается, со случайным разбросом)
+func (q *Queue) backoff(attempt int) time.Duration {
+	delay := q.conf.RetryBackoff << min(attempt-1, 30)
+	if q.conf.MaxBackoff > 0 && (delay > q.conf.MaxBackoff || delay <= 0) {
+		delay = q.conf.MaxBackoff
+	}
+	return delay/2 + rand.N(delay/2+1)
+}
+
+// метод подтверждения задачи (удаляется из потока)
+func (q *Queue) ack(ctx context.Context, msg redis.XMessage) {
+	pipe := q.client.TxPipeline()
+	pipe.XAck(ctx, q.stream, q.group, msg.ID)
+	pipe.XDel(ctx, q.stream, msg.ID)
+	if _, err := pipe.Exec(ctx); err != nil {
+		// задача будет выполнена повторно после VisibilityTimeout
+		slog.Warn("job queue: ack failed", "queue", q.conf.Prefix, "message_id", msg.ID, "error", err)
+	}
+}
+
+// метод откладывания следующей попытки (в одной транзакции с подтверждением текущей)
+func (q *Queue) retry(ctx context.Context, msg redis.XMessage, job *Job, runErr error, delay time.Duration) {
+	next := *job
+	next.Attempt++
+	next.LastError = runErr.Error()
+
+	pipe := q.client.TxPipeline()
+	pipe.XAck(ctx, q.stream, q.group, msg.ID)
+	pipe.XDel(ctx, q.stream, msg.ID)
+	if err := q.schedule(ctx, pipe, &next, time.Now().Add(delay)); err != nil {
+		slog.Error("job queue: encode retry failed", "queue", q.conf.Prefix, "id", job.ID, "error", err)
+		return
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		slog.Warn("job queue: retry failed", "queue", q.conf.Prefix, "id", job.ID, "error", err)
+		return
+	}
+	jobsTotal.WithLabelValues(q.conf.Prefix, job.Type, "retry").Inc()
+}
+
+// метод переноса задачи в dead-letter (в одной транзакции с подтверждением)
+func (q *Queue) bury(ctx context.Context, msg redis.XMessage, job *Job, runErr error) {
+	data, _ := json.Marshal(job)
+
+	pipe := q.client.TxPipeline()
+	pipe.XAck(ctx, q.stream, q.group, msg.ID)
+	pipe.XDel(ctx, q.stream, msg.ID)
+	pipe.XAdd(ctx, &redis.XAddArgs{
+		Stream: q.dead,
+		MaxLen: q.conf.MaxLen,
+		Approx: true,
+		Values: map[string]any{
+			"job":       data,
+			"error":     runErr.Error(),
+			"failed_at": time.Now().UTC().Format(time.RFC3339),
+		},
+	})
+	if _, err := pipe.Exec(ctx); err != nil {
+		slog.Warn("job queue: dead-letter failed", "queue", q.conf.Prefix, "id", job.ID, "error", err)
+		return
+	}
+	jobsTotal.WithLabelValues(q.conf.Prefix, job.Type, "dead").Inc()
+}
+
+// Close прекращает приём новых задач и ждёт выполняемые, пока не истечёт ctx
+// (после этого их контекст отменяется). Клиент redis не закрывается - им владеет вызывающий код
+func (q *Queue) Close(ctx context.Context) error {
+	q.stopOnce.Do(func() { close(q.stop) })
+
+	done := make(chan struct{})
+	go func() {
+		q.running.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		q.jobCancel()
+		return nil
+	case <-ctx.Done():
+		q.jobCancel()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// функция проверки ошибки "группы ещё нет" (очередь не запускалась)
+func isNoGroup(err error) bool {
+	return err != nil && strings.HasPrefix(err.Error(), "NOGROUP")
+}
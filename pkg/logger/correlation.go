@@ -0,0 +1,46 @@
+package logger
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strconv"
+)
+
+// CorrelationKey - имя поля в логах
+const CorrelationKey = "correlation_id"
+
+type correlationCtxKey struct{}
+
+// WithCorrelationID кладёт correlation ID в контекст
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	if id == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, correlationCtxKey{}, id)
+}
+
+// CorrelationID достаёт correlation ID из контекста (пустая строка, если его нет)
+func CorrelationID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(correlationCtxKey{}).(string)
+	return id
+}
+
+// UpdateCorrelationID строит correlation ID из update_id Telegram
+// (одинаковый для повторной доставки одного и того же update)
+func UpdateCorrelationID(updateID int64) string {
+	if updateID == 0 {
+		return NewCorrelationID()
+	}
+	return "upd-" + strconv.FormatInt(updateID, 10)
+}
+
+// NewCorrelationID генерирует случайный correlation ID
+func NewCorrelationID() string {
+	buf := make([]byte, 8)
+	_, _ = rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
@@ -0,0 +1,101 @@
+// Пакет logger - структурированное логирование на базе log/slog.
+//
+// Формат (json/text) выбирается конфигом или переменной окружения ENV:
+// в development - читаемый text, в остальных окружениях - json.
+// Каждая запись, сделанная через *Context методы, получает correlation_id из контекста,
+// поэтому update от Telegram можно проследить через шлюз, gRPC вызов, запись в БД и ответ.
+package logger
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"pkg/configs"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// New создаёт логгер по конфигу
+func New(conf *configs.LoggerConfig, w io.Writer) *slog.Logger {
+	if conf == nil {
+		conf = configs.UseDefaultLoggerConfig()
+	}
+
+	opts := &slog.HandlerOptions{
+		Level:       ParseLevel(conf.Level),
+		AddSource:   conf.AddSource,
+		ReplaceAttr: redactAttr,
+	}
+
+	var handler slog.Handler
+	if useJSON(conf.Format) {
+		handler = slog.NewJSONHandler(w, opts)
+	} else {
+		handler = slog.NewTextHandler(w, opts)
+	}
+
+	return slog.New(&contextHandler{Handler: handler})
+}
+
+// Init создаёт логгер и делает его логгером по умолчанию (slog и стандартный log)
+func Init(conf *configs.LoggerConfig) *slog.Logger {
+	logger := New(conf, os.Stdout)
+	slog.SetDefault(logger)
+	return logger
+}
+
+// ParseLevel переводит строку из конфига в уровень slog (по умолчанию info)
+func ParseLevel(level string) slog.Level {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// useJSON - json везде, кроме development (если формат явно не задан)
+func useJSON(format string) bool {
+	switch strings.ToLower(format) {
+	case "json":
+		return true
+	case "text":
+		return false
+	}
+
+	env := strings.ToLower(os.Getenv("ENV"))
+	return env != "development" && env != "dev" && env != "local"
+}
+
+// contextHandler добавляет в запись correlation_id и trace_id из контекста
+type contextHandler struct {
+	slog.Handler
+}
+
+// Handle реализует slog.Handler
+func (h *contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if id := CorrelationID(ctx); id != "" {
+		record.AddAttrs(slog.String(CorrelationKey, id))
+	}
+	// по trace_id запись лога можно найти в трейсе (и наоборот)
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		record.AddAttrs(slog.String("trace_id", sc.TraceID().String()))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+// WithAttrs реализует slog.Handler
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+// WithGroup реализует slog.Handler
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,62 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"pkg/configs"
+	"testing"
+)
+
+func TestLogger(t *testing.T) {
+	t.Run("correlation_id из контекста попадает в запись", func(t *testing.T) {
+		var buf bytes.Buffer
+		log := New(&configs.LoggerConfig{Level: "info", Format: "json"}, &buf)
+
+		ctx := WithCorrelationID(context.Background(), UpdateCorrelationID(42))
+		log.InfoContext(ctx, "update received")
+
+		var record map[string]any
+		if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+			t.Fatal(err)
+		}
+		if record[CorrelationKey] != "upd-42" {
+			t.Fatalf("ожидался correlation_id upd-42, получено: %v", record[CorrelationKey])
+		}
+	})
+
+	t.Run("уровень из конфига отсекает debug", func(t *testing.T) {
+		var buf bytes.Buffer
+		log := New(&configs.LoggerConfig{Level: "info", Format: "json"}, &buf)
+
+		log.Debug("hidden")
+		if buf.Len() != 0 {
+			t.Fatalf("debug запись не должна попасть в лог: %s", buf.String())
+		}
+	})
+
+	t.Run("чувствительные ключи скрываются", func(t *testing.T) {
+		var buf bytes.Buffer
+		log := New(&configs.LoggerConfig{Format: "json"}, &buf)
+
+		log.Info("config", "token", "123456:ABC")
+		if bytes.Contains(buf.Bytes(), []byte("123456:ABC")) {
+			t.Fatalf("токен не должен попасть в лог: %s", buf.String())
+		}
+	})
+
+	t.Run("маскирование персональных данных", func(t *testing.T) {
+		cases := []struct{ got, want string }{
+			{MaskID(123456789), "12*****89"},
+			{MaskName("alexander"), "a*******r"},
+			{MaskName("ab"), "**"},
+			{RedactText("привет"), "[text:6 chars]"},
+			{RedactText(""), ""},
+		}
+		for _, c := range cases {
+			if c.got != c.want {
+				t.Fatalf("ожидалось %q, получено %q", c.want, c.got)
+			}
+		}
+	})
+}
@@ -0,0 +1,59 @@
+package logger
+
+import (
+	"log/slog"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// значение вместо скрытых данных
+const redacted = "[REDACTED]"
+
+// ключи, значения которых никогда не попадают в лог
+var sensitiveKeys = map[string]struct{}{
+	"token":         {},
+	"bot_token":     {},
+	"password":      {},
+	"authorization": {},
+	"secret":        {},
+	"phone":         {},
+}
+
+// redactAttr скрывает значения чувствительных ключей (ReplaceAttr для slog.HandlerOptions)
+func redactAttr(groups []string, attr slog.Attr) slog.Attr {
+	if _, ok := sensitiveKeys[strings.ToLower(attr.Key)]; ok {
+		return slog.String(attr.Key, redacted)
+	}
+	return attr
+}
+
+// MaskID частично скрывает числовой идентификатор пользователя/чата: 123456789 -> 12*****89
+func MaskID(id int64) string {
+	return maskMiddle(strconv.FormatInt(id, 10), 2)
+}
+
+// MaskName частично скрывает имя или username: "alexander" -> "a*******r"
+func MaskName(name string) string {
+	if name == "" {
+		return ""
+	}
+	return maskMiddle(name, 1)
+}
+
+// RedactText заменяет текст сообщения пользователя на его длину (содержимое переписки в лог не пишем)
+func RedactText(text string) string {
+	if text == "" {
+		return ""
+	}
+	return "[text:" + strconv.Itoa(utf8.RuneCountInString(text)) + " chars]"
+}
+
+// maskMiddle оставляет keep символов в начале и в конце, остальное заменяет '*'
+func maskMiddle(value string, keep int) string {
+	runes := []rune(value)
+	if len(runes) <= keep*2 {
+		return strings.Repeat("*", len(runes))
+	}
+	return string(runes[:keep]) + strings.Repeat("*", len(runes)-keep*2) + string(runes[len(runes)-keep:])
+}
@@ -0,0 +1,670 @@
+// Пакет memorycache - реализация global_cache.Cache в памяти процесса:
+// для локального запуска без Redis и для тестов. Семантика операций повторяет Redis
+package memorycache
+
+import (
+	"context"
+	"global_models/global_cache"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Проверка реализации интерфейса
+var (
+	_ global_cache.Cache  = (*MemoryCache)(nil)
+	_ global_cache.PubSub = (*MemoryCache)(nil)
+)
+
+// тип значения ключа
+type entryKind int
+
+const (
+	kindString entryKind = iota
+	kindHash
+	kindList
+	kindZSet
+)
+
+// значение ключа (заполнено одно поле - по kind)
+type entry struct {
+	kind      entryKind
+	value     []byte
+	hash      map[string][]byte
+	list      [][]byte
+	zset      map[string]float64
+	expiresAt time.Time // нулевое - без срока
+}
+
+// метод проверяет, истекло ли время жизни ключа
+func (e *entry) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && !now.Before(e.expiresAt)
+}
+
+// MemoryCache - потокобезопасный кэш в памяти. Истёкшие ключи не видны сразу,
+// а память освобождается фоновой очисткой (cleanupInterval) или при обращении к ключу
+type MemoryCache struct {
+	mu   sync.Mutex
+	data map[string]*entry
+
+	subsMu sync.Mutex
+	subs   map[string]map[*subscriber]struct{} // подписчики по каналам (PubSub внутри процесса)
+
+	stop      chan struct{}
+	closeOnce sync.Once
+}
+
+// конструктор: cleanupInterval > 0 запускает фоновую очистку истёкших ключей (до Close)
+func NewMemoryCache(cleanupInterval time.Duration) *MemoryCache {
+	c := &MemoryCache{
+		data: make(map[string]*entry),
+		stop: make(chan struct{}),
+	}
+	if cleanupInterval > 0 {
+		go c.janitor(cleanupInterval)
+	}
+	return c
+}
+
+// фоновая очистка истёкших ключей
+func (c *MemoryCache) janitor(every time.Duration) {
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+			c.deleteExpired()
+		}
+	}
+}
+
+// метод удаляет все истёкшие ключи
+func (c *MemoryCache) deleteExpired() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	for key, e := range c.data {
+		if e.expired(now) {
+			delete(c.data, key)
+		}
+	}
+}
+
+// метод возвращает количество ключей, включая ещё не удалённые истёкшие (для тестов очистки)
+func (c *MemoryCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.data)
+}
+
+// метод возвращает живое значение ключа (истёкший ключ удаляется). Вызывается под mu
+func (c *MemoryCache) lookup(key string) *entry {
+	e, ok := c.data[key]
+	if !ok {
+		return nil
+	}
+	if e.expired(time.Now()) {
+		delete(c.data, key)
+		return nil
+	}
+	return e
+}
+
+// метод возвращает значение ключа нужного типа: nil - ключа нет, ErrWrongType - ключ другого типа
+func (c *MemoryCache) lookupKind(key string, kind entryKind) (*entry, error) {
+	e := c.lookup(key)
+	if e == nil {
+		return nil, nil
+	}
+	if e.kind != kind {
+		return nil, global_cache.ErrWrongType
+	}
+	return e, nil
+}
+
+// метод возвращает значение ключа нужного типа, создавая пустое при отсутствии
+func (c *MemoryCache) lookupOrCreate(key string, kind entryKind) (*entry, error) {
+	e, err := c.lookupKind(key, kind)
+	if err != nil || e != nil {
+		return e, err
+	}
+
+	e = &entry{kind: kind}
+	switch kind {
+	case kindHash:
+		e.hash = make(map[string][]byte)
+	case kindZSet:
+		e.zset = make(map[string]float64)
+	}
+	c.data[key] = e
+	return e, nil
+}
+
+// метод удаляет ключ с опустевшей коллекцией (как Redis)
+func (c *MemoryCache) dropIfEmpty(key string, e *entry) {
+	if len(e.hash) == 0 && len(e.list) == 0 && len(e.zset) == 0 {
+		delete(c.data, key)
+	}
+}
+
+// функция для расчёта момента истечения (expiration <= 0 - без срока)
+func expiresAt(expiration time.Duration) time.Time {
+	if expiration <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(expiration)
+}
+
+// функция для копирования значения (вызывающий код не должен менять данные кэша)
+func clone(value []byte) []byte {
+	return append([]byte{}, value...)
+}
+
+// метод для освобождения ресурсов (останавливает фоновую очистку)
+func (c *MemoryCache) Close() error {
+	c.closeOnce.Do(func() { close(c.stop) })
+	return nil
+}
+
+// кэш в памяти доступен всегда
+func (c *MemoryCache) Ping(ctx context.Context) error {
+	return nil
+}
+
+// метод для добавления значения с TTL
+func (c *MemoryCache) Set(ctx context.Context, key string, value []byte, expiration time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.data[key] = &entry{kind: kindString, value: clone(value), expiresAt: expiresAt(expiration)}
+	return nil
+}
+
+// метод получения значения по ключу
+func (c *MemoryCache) Get(ctx context.Context, key string) (string, error) {
+	value, err := c.GetBytes(ctx, key)
+	return string(value), err
+}
+
+// метод получения значения по ключу (результат в виде байтового среза)
+func (c *MemoryCache) GetBytes(ctx context.Context, key string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, err := c.lookupKind(key, kindString)
+	if err != nil {
+		return nil, err
+	}
+	if e == nil {
+		return nil, global_cache.ErrNotFound
+	}
+	return clone(e.value), nil
+}
+
+// метод удаления ключа
+func (c *MemoryCache) Delete(ctx context.Context, key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.data, key)
+	return nil
+}
+
+// метод проверки существования ключа
+func (c *MemoryCache) Exists(ctx context.Context, key string) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.lookup(key) != nil, nil
+}
+
+// метод атомарной записи значения, только если ключа ещё нет
+func (c *MemoryCache) SetNX(ctx context.Context, key string, value []byte, expiration time.Duration) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.lookup(key) != nil {
+		return false, nil
+	}
+	c.data[key] = &entry{kind: kindString, value: clone(value), expiresAt: expiresAt(expiration)}
+	return true, nil
+}
+
+// метод атомарной записи значения с возвратом предыдущего (время жизни сбрасывается)
+func (c *MemoryCache) GetSet(ctx context.Context, key string, value []byte) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, err := c.lookupKind(key, kindString)
+	if err != nil {
+		return nil, err
+	}
+	c.data[key] = &entry{kind: kindString, value: clone(value)}
+	if e == nil {
+		return nil, global_cache.ErrNotFound
+	}
+	return e.value, nil
+}
+
+// метод атомарного увеличения счётчика на 1
+func (c *MemoryCache) Incr(ctx context.Context, key string, expiration time.Duration) (int64, error) {
+	return c.IncrBy(ctx, key, 1, expiration)
+}
+
+// метод атомарного увеличения счётчика на delta (время жизни задаётся только ключу без срока)
+func (c *MemoryCache) IncrBy(ctx context.Context, key string, delta int64, expiration time.Duration) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, err := c.lookupOrCreate(key, kindString)
+	if err != nil {
+		return 0, err
+	}
+
+	var current int64
+	if len(e.value) > 0 {
+		current, err = strconv.ParseInt(string(e.value), 10, 64)
+		if err != nil {
+			return 0, global_cache.ErrNotInteger
+		}
+	}
+
+	current += delta
+	e.value = strconv.AppendInt(e.value[:0], current, 10)
+	if expiration > 0 && e.expiresAt.IsZero() {
+		e.expiresAt = expiresAt(expiration)
+	}
+	return current, nil
+}
+
+// метод записи нового ключа с номером из счётчика fenceKey (0 - ключ уже есть)
+func (c *MemoryCache) SetNXFenced(ctx context.Context, key, fenceKey string, suffix []byte, expiration time.Duration) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.lookup(key) != nil {
+		return 0, nil
+	}
+
+	counter, err := c.lookupOrCreate(fenceKey, kindString)
+	if err != nil {
+		return 0, err
+	}
+	var fence int64
+	if len(counter.value) > 0 {
+		fence, err = strconv.ParseInt(string(counter.value), 10, 64)
+		if err != nil {
+			return 0, global_cache.ErrNotInteger
+		}
+	}
+	fence++
+	counter.value = strconv.AppendInt(counter.value[:0], fence, 10)
+
+	value := append(strconv.AppendInt(nil, fence, 10), '.')
+	c.data[key] = &entry{kind: kindString, value: append(value, suffix...), expiresAt: expiresAt(expiration)}
+	return fence, nil
+}
+
+// метод удаления ключа, только если его значение равно expected
+func (c *MemoryCache) CompareAndDelete(ctx context.Context, key string, expected []byte) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e := c.lookup(key)
+	if e == nil || e.kind != kindString || string(e.value) != string(expected) {
+		return false, nil
+	}
+	delete(c.data, key)
+	return true, nil
+}
+
+// метод продления ключа, только если его значение равно expected
+func (c *MemoryCache) CompareAndExpire(ctx context.Context, key string, expected []byte, expiration time.Duration) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e := c.lookup(key)
+	if e == nil || e.kind != kindString || string(e.value) != string(expected) {
+		return false, nil
+	}
+	if expiration <= 0 {
+		delete(c.data, key)
+		return true, nil
+	}
+	e.expiresAt = expiresAt(expiration)
+	return true, nil
+}
+
+// метод записи полей хэша
+func (c *MemoryCache) HSet(ctx context.Context, key string, values map[string][]byte) error {
+	if len(values) == 0 {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, err := c.lookupOrCreate(key, kindHash)
+	if err != nil {
+		return err
+	}
+	for field, value := range values {
+		e.hash[field] = clone(value)
+	}
+	return nil
+}
+
+// метод чтения поля хэша
+func (c *MemoryCache) HGet(ctx context.Context, key, field string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, err := c.lookupKind(key, kindHash)
+	if err != nil {
+		return nil, err
+	}
+	if e == nil {
+		return nil, global_cache.ErrNotFound
+	}
+	value, ok := e.hash[field]
+	if !ok {
+		return nil, global_cache.ErrNotFound
+	}
+	return clone(value), nil
+}
+
+// метод чтения всех полей хэша (нет ключа - пустой map)
+func (c *MemoryCache) HGetAll(ctx context.Context, key string) (map[string][]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, err := c.lookupKind(key, kindHash)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string][]byte)
+	if e != nil {
+		for field, value := range e.hash {
+			values[field] = clone(value)
+		}
+	}
+	return values, nil
+}
+
+// метод удаления полей хэша
+func (c *MemoryCache) HDel(ctx context.Context, key string, fields ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, err := c.lookupKind(key, kindHash)
+	if err != nil || e == nil {
+		return err
+	}
+	for _, field := range fields {
+		delete(e.hash, field)
+	}
+	c.dropIfEmpty(key, e)
+	return nil
+}
+
+// метод атомарного увеличения числового поля хэша
+func (c *MemoryCache) HIncrBy(ctx context.Context, key, field string, delta int64) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, err := c.lookupOrCreate(key, kindHash)
+	if err != nil {
+		return 0, err
+	}
+
+	var current int64
+	if value, ok := e.hash[field]; ok {
+		current, err = strconv.ParseInt(string(value), 10, 64)
+		if err != nil {
+			return 0, global_cache.ErrNotInteger
+		}
+	}
+
+	current += delta
+	e.hash[field] = []byte(strconv.FormatInt(current, 10))
+	return current, nil
+}
+
+// метод добавления значений в начало списка (как LPUSH: последнее значение окажется первым)
+func (c *MemoryCache) LPush(ctx context.Context, key string, values ...[]byte) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(values) == 0 {
+		return c.llen(key)
+	}
+	e, err := c.lookupOrCreate(key, kindList)
+	if err != nil {
+		return 0, err
+	}
+	pushed := make([][]byte, 0, len(values)+len(e.list))
+	for i := len(values) - 1; i >= 0; i-- {
+		pushed = append(pushed, clone(values[i]))
+	}
+	e.list = append(pushed, e.list...)
+	return int64(len(e.list)), nil
+}
+
+// метод добавления значений в конец списка
+func (c *MemoryCache) RPush(ctx context.Context, key string, values ...[]byte) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(values) == 0 {
+		return c.llen(key)
+	}
+	e, err := c.lookupOrCreate(key, kindList)
+	if err != nil {
+		return 0, err
+	}
+	for _, value := range values {
+		e.list = append(e.list, clone(value))
+	}
+	return int64(len(e.list)), nil
+}
+
+// метод извлечения первого элемента списка
+func (c *MemoryCache) LPop(ctx context.Context, key string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, err := c.lookupKind(key, kindList)
+	if err != nil {
+		return nil, err
+	}
+	if e == nil {
+		return nil, global_cache.ErrNotFound
+	}
+	value := e.list[0]
+	e.list[0] = nil
+	e.list = e.list[1:]
+	c.dropIfEmpty(key, e)
+	return value, nil
+}
+
+// метод чтения диапазона списка (индексы как у LRANGE)
+func (c *MemoryCache) LRange(ctx context.Context, key string, start, stop int64) ([][]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, err := c.lookupKind(key, kindList)
+	if err != nil {
+		return nil, err
+	}
+	values := [][]byte{}
+	if e == nil {
+		return values, nil
+	}
+
+	n := int64(len(e.list))
+	if start < 0 {
+		start = max(n+start, 0)
+	}
+	if stop < 0 {
+		stop = n + stop
+	}
+	stop = min(stop, n-1)
+	for i := start; i <= stop; i++ {
+		values = append(values, clone(e.list[i]))
+	}
+	return values, nil
+}
+
+// метод получения длины списка
+func (c *MemoryCache) LLen(ctx context.Context, key string) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.llen(key)
+}
+
+// длина списка (вызывается под mu)
+func (c *MemoryCache) llen(key string) (int64, error) {
+	e, err := c.lookupKind(key, kindList)
+	if err != nil || e == nil {
+		return 0, err
+	}
+	return int64(len(e.list)), nil
+}
+
+// метод добавления элементов в упорядоченное множество (у существующих меняется score)
+func (c *MemoryCache) ZAdd(ctx context.Context, key string, members ...global_cache.ZMember) error {
+	if len(members) == 0 {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, err := c.lookupOrCreate(key, kindZSet)
+	if err != nil {
+		return err
+	}
+	for _, member := range members {
+		e.zset[member.Member] = member.Score
+	}
+	return nil
+}
+
+// метод удаления элементов упорядоченного множества
+func (c *MemoryCache) ZRem(ctx context.Context, key string, members ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, err := c.lookupKind(key, kindZSet)
+	if err != nil || e == nil {
+		return err
+	}
+	for _, member := range members {
+		delete(e.zset, member)
+	}
+	c.dropIfEmpty(key, e)
+	return nil
+}
+
+// метод чтения score элемента
+func (c *MemoryCache) ZScore(ctx context.Context, key, member string) (float64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, err := c.lookupKind(key, kindZSet)
+	if err != nil {
+		return 0, err
+	}
+	if e == nil {
+		return 0, global_cache.ErrNotFound
+	}
+	score, ok := e.zset[member]
+	if !ok {
+		return 0, global_cache.ErrNotFound
+	}
+	return score, nil
+}
+
+// метод чтения элементов по диапазону score (при равном score - по имени, как в Redis)
+func (c *MemoryCache) ZRangeByScore(ctx context.Context, key string, min, max float64, limit int64) ([]global_cache.ZMember, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, err := c.lookupKind(key, kindZSet)
+	if err != nil {
+		return nil, err
+	}
+	members := []global_cache.ZMember{}
+	if e == nil {
+		return members, nil
+	}
+
+	for member, score := range e.zset {
+		if score >= min && score <= max {
+			members = append(members, global_cache.ZMember{Member: member, Score: score})
+		}
+	}
+	slices.SortFunc(members, func(a, b global_cache.ZMember) int {
+		if a.Score != b.Score {
+			if a.Score < b.Score {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(a.Member, b.Member)
+	})
+	if limit > 0 && int64(len(members)) > limit {
+		members = members[:limit]
+	}
+	return members, nil
+}
+
+// метод получения размера упорядоченного множества
+func (c *MemoryCache) ZCard(ctx context.Context, key string) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, err := c.lookupKind(key, kindZSet)
+	if err != nil || e == nil {
+		return 0, err
+	}
+	return int64(len(e.zset)), nil
+}
+
+// метод устанавливает время жизни ключа (expiration <= 0 удаляет ключ, как EXPIRE с неположительным временем)
+func (c *MemoryCache) Expire(ctx context.Context, key string, expiration time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e := c.lookup(key)
+	if e == nil {
+		return nil
+	}
+	if expiration <= 0 {
+		delete(c.data, key)
+		return nil
+	}
+	e.expiresAt = expiresAt(expiration)
+	return nil
+}
+
+// метод возвращает оставшееся время жизни ключа: -1 - без срока, -2 - ключа нет
+func (c *MemoryCache) TTL(ctx context.Context, key string) (time.Duration, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e := c.lookup(key)
+	if e == nil {
+		return -2, nil
+	}
+	if e.expiresAt.IsZero() {
+		return -1, nil
+	}
+	return time.Until(e.expiresAt), nil
+}
@@ -0,0 +1,61 @@
+package memorycache
+
+import (
+	"context"
+	"global_models/global_cache"
+	"pkg/cachetest"
+	"testing"
+	"time"
+)
+
+func TestMemoryCacheContract(t *testing.T) {
+	cachetest.RunContract(t, func(t *testing.T) global_cache.Cache {
+		cache := NewMemoryCache(time.Minute)
+		t.Cleanup(func() { cache.Close() })
+		return cache
+	}, time.Sleep)
+}
+
+func TestMemoryCachePubSubContract(t *testing.T) {
+	cachetest.RunPubSubContract(t, func(t *testing.T) global_cache.PubSub {
+		cache := NewMemoryCache(0)
+		t.Cleanup(func() { cache.Close() })
+		return cache
+	})
+}
+
+func TestMemoryCacheCleanup(t *testing.T) {
+	t.Run("фоновая очистка освобождает истёкшие ключи", func(t *testing.T) {
+		cache := NewMemoryCache(10 * time.Millisecond)
+		defer cache.Close()
+
+		ctx := context.Background()
+		for _, key := range []string{"a", "b", "c"} {
+			cache.Set(ctx, key, []byte("v"), 20*time.Millisecond)
+		}
+		cache.Set(ctx, "kept", []byte("v"), 0)
+
+		deadline := time.Now().Add(time.Second)
+		for cache.Len() > 1 && time.Now().Before(deadline) {
+			time.Sleep(5 * time.Millisecond)
+		}
+		if n := cache.Len(); n != 1 {
+			t.Errorf("ключей после очистки: %d, ожидали 1", n)
+		}
+	})
+
+	t.Run("значение не меняется через возвращённый срез", func(t *testing.T) {
+		cache := NewMemoryCache(0)
+		ctx := context.Background()
+
+		value := []byte("value")
+		cache.Set(ctx, "key", value, 0)
+		value[0] = 'X'
+
+		got, _ := cache.GetBytes(ctx, "key")
+		got[1] = 'Y'
+		if again, _ := cache.Get(ctx, "key"); again != "value" {
+			t.Errorf("данные кэша изменены снаружи: %q", again)
+		}
+	})
+}
@@ -0,0 +1,62 @@
+package memorycache
+
+import "context"
+
+// сколько сообщений может ждать обработки у подписчика (остальные отбрасываются, как у Redis при переполнении)
+const subscriberBuffer = 1024
+
+// подписчик канала
+type subscriber struct {
+	messages chan []byte
+}
+
+// метод публикации сообщения всем подписчикам канала внутри процесса
+func (c *MemoryCache) Publish(ctx context.Context, channel string, payload []byte) error {
+	c.subsMu.Lock()
+	defer c.subsMu.Unlock()
+
+	for sub := range c.subs[channel] {
+		select {
+		case sub.messages <- clone(payload):
+		default:
+			// подписчик не успевает - сообщение теряется (доставка не гарантируется)
+		}
+	}
+	return nil
+}
+
+// метод подписки на канал: блокируется до отмены ctx или Close (handler(nil) - подписка установлена)
+func (c *MemoryCache) Subscribe(ctx context.Context, channel string, handler func(payload []byte)) error {
+	sub := &subscriber{messages: make(chan []byte, subscriberBuffer)}
+
+	c.subsMu.Lock()
+	if c.subs == nil {
+		c.subs = make(map[string]map[*subscriber]struct{})
+	}
+	if c.subs[channel] == nil {
+		c.subs[channel] = make(map[*subscriber]struct{})
+	}
+	c.subs[channel][sub] = struct{}{}
+	c.subsMu.Unlock()
+
+	defer func() {
+		c.subsMu.Lock()
+		delete(c.subs[channel], sub)
+		if len(c.subs[channel]) == 0 {
+			delete(c.subs, channel)
+		}
+		c.subsMu.Unlock()
+	}()
+	handler(nil)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-c.stop:
+			return nil
+		case payload := <-sub.messages:
+			handler(payload)
+		}
+	}
+}
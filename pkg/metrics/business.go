@@ -0,0 +1,25 @@
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// бизнес-события воронки (значения метки event)
+const (
+	EventNewUser      = "new_user"      // пользователь написал боту впервые
+	EventStart        = "start"         // команда /start
+	EventLookup       = "lookup"        // пользователь открыл работы мастера
+	EventContactedYes = "contacted_yes" // пользователь готов связаться с мастером
+	EventContactedNo  = "contacted_no"  // пользователь пока не готов
+	EventLeadCreated  = "lead_created"  // создана заявка для мастера
+	EventHandoff      = "handoff"       // клиент позвал мастера, бот замолчал
+)
+
+// счётчик бизнес-событий, чтобы дашборды не строились SQL запросами по callback_logs
+var businessEvents = MustRegister(prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "biz_events_total",
+	Help: "Business funnel events, by event name.",
+}, []string{"event"}))
+
+// BusinessEvent увеличивает счётчик бизнес-события
+func BusinessEvent(event string) {
+	businessEvents.WithLabelValues(event).Inc()
+}
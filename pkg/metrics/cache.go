@@ -0,0 +1,256 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"global_models/global_cache"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Проверка реализации интерфейса
+var _ global_cache.Cache = (*measuredCache)(nil)
+
+// длительность команд кэша по команде и результату (ok, miss, error)
+var cacheCommandDuration = MustRegister(prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "cache_command_duration_seconds",
+	Help:    "Duration of cache (Redis) commands, by command and result.",
+	Buckets: []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5},
+}, []string{"command", "result"}))
+
+// WrapCache оборачивает кэш: длительность каждой команды попадает в cache_command_duration_seconds
+func WrapCache(cache global_cache.Cache) global_cache.Cache {
+	return &measuredCache{cache: cache}
+}
+
+// кэш с метриками
+type measuredCache struct {
+	cache global_cache.Cache
+}
+
+func (c *measuredCache) Set(ctx context.Context, key string, value []byte, expiration time.Duration) error {
+	start := time.Now()
+	err := c.cache.Set(ctx, key, value, expiration)
+	observeCommand("SET", start, err)
+	return err
+}
+
+func (c *measuredCache) Get(ctx context.Context, key string) (string, error) {
+	start := time.Now()
+	value, err := c.cache.Get(ctx, key)
+	observeCommand("GET", start, err)
+	return value, err
+}
+
+func (c *measuredCache) GetBytes(ctx context.Context, key string) ([]byte, error) {
+	start := time.Now()
+	value, err := c.cache.GetBytes(ctx, key)
+	observeCommand("GET", start, err)
+	return value, err
+}
+
+func (c *measuredCache) Delete(ctx context.Context, key string) error {
+	start := time.Now()
+	err := c.cache.Delete(ctx, key)
+	observeCommand("DEL", start, err)
+	return err
+}
+
+func (c *measuredCache) Exists(ctx context.Context, key string) (bool, error) {
+	start := time.Now()
+	ok, err := c.cache.Exists(ctx, key)
+	observeCommand("EXISTS", start, err)
+	return ok, err
+}
+
+func (c *measuredCache) SetNX(ctx context.Context, key string, value []byte, expiration time.Duration) (bool, error) {
+	start := time.Now()
+	ok, err := c.cache.SetNX(ctx, key, value, expiration)
+	observeCommand("SETNX", start, err)
+	return ok, err
+}
+
+func (c *measuredCache) GetSet(ctx context.Context, key string, value []byte) ([]byte, error) {
+	start := time.Now()
+	result, err := c.cache.GetSet(ctx, key, value)
+	observeCommand("GETSET", start, err)
+	return result, err
+}
+
+func (c *measuredCache) Incr(ctx context.Context, key string, expiration time.Duration) (int64, error) {
+	start := time.Now()
+	result, err := c.cache.Incr(ctx, key, expiration)
+	observeCommand("INCR", start, err)
+	return result, err
+}
+
+func (c *measuredCache) IncrBy(ctx context.Context, key string, delta int64, expiration time.Duration) (int64, error) {
+	start := time.Now()
+	result, err := c.cache.IncrBy(ctx, key, delta, expiration)
+	observeCommand("INCRBY", start, err)
+	return result, err
+}
+
+func (c *measuredCache) SetNXFenced(ctx context.Context, key, fenceKey string, suffix []byte, expiration time.Duration) (int64, error) {
+	start := time.Now()
+	result, err := c.cache.SetNXFenced(ctx, key, fenceKey, suffix, expiration)
+	observeCommand("SET_NX_FENCED", start, err)
+	return result, err
+}
+
+func (c *measuredCache) CompareAndDelete(ctx context.Context, key string, expected []byte) (bool, error) {
+	start := time.Now()
+	result, err := c.cache.CompareAndDelete(ctx, key, expected)
+	observeCommand("COMPARE_AND_DELETE", start, err)
+	return result, err
+}
+
+func (c *measuredCache) CompareAndExpire(ctx context.Context, key string, expected []byte, expiration time.Duration) (bool, error) {
+	start := time.Now()
+	result, err := c.cache.CompareAndExpire(ctx, key, expected, expiration)
+	observeCommand("COMPARE_AND_EXPIRE", start, err)
+	return result, err
+}
+
+func (c *measuredCache) HSet(ctx context.Context, key string, values map[string][]byte) error {
+	start := time.Now()
+	err := c.cache.HSet(ctx, key, values)
+	observeCommand("HSET", start, err)
+	return err
+}
+
+func (c *measuredCache) HGet(ctx context.Context, key, field string) ([]byte, error) {
+	start := time.Now()
+	result, err := c.cache.HGet(ctx, key, field)
+	observeCommand("HGET", start, err)
+	return result, err
+}
+
+func (c *measuredCache) HGetAll(ctx context.Context, key string) (map[string][]byte, error) {
+	start := time.Now()
+	result, err := c.cache.HGetAll(ctx, key)
+	observeCommand("HGETALL", start, err)
+	return result, err
+}
+
+func (c *measuredCache) HDel(ctx context.Context, key string, fields ...string) error {
+	start := time.Now()
+	err := c.cache.HDel(ctx, key, fields...)
+	observeCommand("HDEL", start, err)
+	return err
+}
+
+func (c *measuredCache) HIncrBy(ctx context.Context, key, field string, delta int64) (int64, error) {
+	start := time.Now()
+	result, err := c.cache.HIncrBy(ctx, key, field, delta)
+	observeCommand("HINCRBY", start, err)
+	return result, err
+}
+
+func (c *measuredCache) LPush(ctx context.Context, key string, values ...[]byte) (int64, error) {
+	start := time.Now()
+	result, err := c.cache.LPush(ctx, key, values...)
+	observeCommand("LPUSH", start, err)
+	return result, err
+}
+
+func (c *measuredCache) RPush(ctx context.Context, key string, values ...[]byte) (int64, error) {
+	start := time.Now()
+	result, err := c.cache.RPush(ctx, key, values...)
+	observeCommand("RPUSH", start, err)
+	return result, err
+}
+
+func (c *measuredCache) LPop(ctx context.Context, key string) ([]byte, error) {
+	start := time.Now()
+	result, err := c.cache.LPop(ctx, key)
+	observeCommand("LPOP", start, err)
+	return result, err
+}
+
+func (c *measuredCache) LRange(ctx context.Context, key string, start, stop int64) ([][]byte, error) {
+	began := time.Now()
+	result, err := c.cache.LRange(ctx, key, start, stop)
+	observeCommand("LRANGE", began, err)
+	return result, err
+}
+
+func (c *measuredCache) LLen(ctx context.Context, key string) (int64, error) {
+	start := time.Now()
+	result, err := c.cache.LLen(ctx, key)
+	observeCommand("LLEN", start, err)
+	return result, err
+}
+
+func (c *measuredCache) ZAdd(ctx context.Context, key string, members ...global_cache.ZMember) error {
+	start := time.Now()
+	err := c.cache.ZAdd(ctx, key, members...)
+	observeCommand("ZADD", start, err)
+	return err
+}
+
+func (c *measuredCache) ZRem(ctx context.Context, key string, members ...string) error {
+	start := time.Now()
+	err := c.cache.ZRem(ctx, key, members...)
+	observeCommand("ZREM", start, err)
+	return err
+}
+
+func (c *measuredCache) ZScore(ctx context.Context, key, member string) (float64, error) {
+	start := time.Now()
+	result, err := c.cache.ZScore(ctx, key, member)
+	observeCommand("ZSCORE", start, err)
+	return result, err
+}
+
+func (c *measuredCache) ZRangeByScore(ctx context.Context, key string, min, max float64, limit int64) ([]global_cache.ZMember, error) {
+	start := time.Now()
+	result, err := c.cache.ZRangeByScore(ctx, key, min, max, limit)
+	observeCommand("ZRANGEBYSCORE", start, err)
+	return result, err
+}
+
+func (c *measuredCache) ZCard(ctx context.Context, key string) (int64, error) {
+	start := time.Now()
+	result, err := c.cache.ZCard(ctx, key)
+	observeCommand("ZCARD", start, err)
+	return result, err
+}
+
+func (c *measuredCache) Expire(ctx context.Context, key string, expiration time.Duration) error {
+	start := time.Now()
+	err := c.cache.Expire(ctx, key, expiration)
+	observeCommand("EXPIRE", start, err)
+	return err
+}
+
+func (c *measuredCache) TTL(ctx context.Context, key string) (time.Duration, error) {
+	start := time.Now()
+	ttl, err := c.cache.TTL(ctx, key)
+	observeCommand("TTL", start, err)
+	return ttl, err
+}
+
+func (c *measuredCache) Ping(ctx context.Context) error {
+	start := time.Now()
+	err := c.cache.Ping(ctx)
+	observeCommand("PING", start, err)
+	return err
+}
+
+func (c *measuredCache) Close() error {
+	return c.cache.Close()
+}
+
+// функция для записи длительности команды
+func observeCommand(command string, start time.Time, err error) {
+	result := "ok"
+	switch {
+	case errors.Is(err, global_cache.ErrNotFound):
+		result = "miss"
+	case err != nil:
+		result = "error"
+	}
+	cacheCommandDuration.WithLabelValues(command, result).Observe(time.Since(start).Seconds())
+}
@@ -0,0 +1,245 @@
+package metrics
+
+import (
+	"context"
+	"global_models/global_db"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Проверки реализации интерфейсов
+var _ global_db.Pool = (*measuredPool)(nil)
+var _ global_db.Tx = (*measuredTx)(nil)
+
+// длительность запросов к БД по типу запроса
+var dbQueryDuration = MustRegister(prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "db_query_duration_seconds",
+	Help:    "Duration of database queries, by operation and result.",
+	Buckets: []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+}, []string{"operation", "result"}))
+
+// WrapPool оборачивает пул соединений: длительность каждого запроса попадает в db_query_duration_seconds.
+// Для QueryRow и Query учитывается время до чтения результата (Scan / Close)
+func WrapPool(pool global_db.Pool) global_db.Pool {
+	return &measuredPool{pool: pool}
+}
+
+// пул соединений с метриками
+type measuredPool struct {
+	pool global_db.Pool
+}
+
+func (p *measuredPool) Exec(ctx context.Context, sql string, args ...any) (int64, error) {
+	start := time.Now()
+	n, err := p.pool.Exec(ctx, sql, args...)
+	observeQuery(sql, start, err)
+	return n, err
+}
+
+func (p *measuredPool) QueryRow(ctx context.Context, sql string, args ...any) global_db.Row {
+	return &measuredRow{row: p.pool.QueryRow(ctx, sql, args...), sql: sql, start: time.Now()}
+}
+
+func (p *measuredPool) Query(ctx context.Context, sql string, args ...any) (global_db.Rows, error) {
+	start := time.Now()
+	rows, err := p.pool.Query(ctx, sql, args...)
+	if err != nil {
+		observeQuery(sql, start, err)
+		return nil, err
+	}
+	return &measuredRows{Rows: rows, sql: sql, start: start}, nil
+}
+
+func (p *measuredPool) CopyFrom(ctx context.Context, table string, columns []string, rows [][]any) (int64, error) {
+	start := time.Now()
+	n, err := p.pool.CopyFrom(ctx, table, columns, rows)
+	observeQuery("COPY", start, err)
+	return n, err
+}
+
+func (p *measuredPool) SendBatch(ctx context.Context, batch *global_db.Batch) global_db.BatchResults {
+	return &measuredBatch{BatchResults: p.pool.SendBatch(ctx, batch), start: time.Now()}
+}
+
+func (p *measuredPool) Begin(ctx context.Context) (global_db.Tx, error) {
+	tx, err := p.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &measuredTx{tx: tx}, nil
+}
+
+func (p *measuredPool) BeginTx(ctx context.Context, opts global_db.TxOptions) (global_db.Tx, error) {
+	tx, err := p.pool.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &measuredTx{tx: tx}, nil
+}
+
+func (p *measuredPool) Stat() global_db.PoolStat {
+	return p.pool.Stat()
+}
+
+func (p *measuredPool) Ping(ctx context.Context) error {
+	return p.pool.Ping(ctx)
+}
+
+func (p *measuredPool) Close() error {
+	return p.pool.Close()
+}
+
+// транзакция с метриками
+type measuredTx struct {
+	tx global_db.Tx
+}
+
+func (t *measuredTx) Commit(ctx context.Context) error {
+	start := time.Now()
+	err := t.tx.Commit(ctx)
+	observeQuery("COMMIT", start, err)
+	return err
+}
+
+func (t *measuredTx) Rollback(ctx context.Context) error {
+	start := time.Now()
+	err := t.tx.Rollback(ctx)
+	observeQuery("ROLLBACK", start, err)
+	return err
+}
+
+func (t *measuredTx) Exec(ctx context.Context, sql string, args ...any) (int64, error) {
+	start := time.Now()
+	n, err := t.tx.Exec(ctx, sql, args...)
+	observeQuery(sql, start, err)
+	return n, err
+}
+
+func (t *measuredTx) QueryRow(ctx context.Context, sql string, args ...any) global_db.Row {
+	return &measuredRow{row: t.tx.QueryRow(ctx, sql, args...), sql: sql, start: time.Now()}
+}
+
+func (t *measuredTx) Query(ctx context.Context, sql string, args ...any) (global_db.Rows, error) {
+	start := time.Now()
+	rows, err := t.tx.Query(ctx, sql, args...)
+	if err != nil {
+		observeQuery(sql, start, err)
+		return nil, err
+	}
+	return &measuredRows{Rows: rows, sql: sql, start: start}, nil
+}
+
+func (t *measuredTx) CopyFrom(ctx context.Context, table string, columns []string, rows [][]any) (int64, error) {
+	start := time.Now()
+	n, err := t.tx.CopyFrom(ctx, table, columns, rows)
+	observeQuery("COPY", start, err)
+	return n, err
+}
+
+func (t *measuredTx) SendBatch(ctx context.Context, batch *global_db.Batch) global_db.BatchResults {
+	return &measuredBatch{BatchResults: t.tx.SendBatch(ctx, batch), start: time.Now()}
+}
+
+// строка результата: запрос учитывается после Scan
+type measuredRow struct {
+	row   global_db.Row
+	sql   string
+	start time.Time
+}
+
+func (r *measuredRow) Scan(dest ...any) error {
+	err := r.row.Scan(dest...)
+	observeQuery(r.sql, r.start, err)
+	return err
+}
+
+// набор строк результата: запрос учитывается после Close
+type measuredRows struct {
+	global_db.Rows
+	sql   string
+	start time.Time
+}
+
+func (r *measuredRows) Close() {
+	r.Rows.Close()
+	observeQuery(r.sql, r.start, r.Rows.Err())
+}
+
+// результаты пакета: пакет учитывается целиком после Close
+type measuredBatch struct {
+	global_db.BatchResults
+	start time.Time
+}
+
+func (b *measuredBatch) Close() error {
+	err := b.BatchResults.Close()
+	observeQuery("BATCH", b.start, err)
+	return err
+}
+
+// функция для записи длительности запроса
+func observeQuery(sql string, start time.Time, err error) {
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+	dbQueryDuration.WithLabelValues(sqlOperation(sql), result).Observe(time.Since(start).Seconds())
+}
+
+// функция для получения типа запроса (SELECT, INSERT, ...) по его тексту
+func sqlOperation(sql string) string {
+	fields := strings.Fields(sql)
+	if len(fields) == 0 {
+		return "QUERY"
+	}
+	return strings.ToUpper(fields[0])
+}
+
+// RegisterPoolStats публикует статистику пула соединений (соединения, ожидание соединения)
+func RegisterPoolStats(pool global_db.Pool) {
+	MustRegister(&poolStatsCollector{stat: pool.Stat})
+}
+
+// описания метрик пула соединений
+var (
+	poolAcquiredConns = prometheus.NewDesc("db_pool_acquired_conns", "Connections currently in use.", nil, nil)
+	poolIdleConns     = prometheus.NewDesc("db_pool_idle_conns", "Idle connections in the pool.", nil, nil)
+	poolTotalConns    = prometheus.NewDesc("db_pool_total_conns", "Total connections in the pool.", nil, nil)
+	poolMaxConns      = prometheus.NewDesc("db_pool_max_conns", "Maximum size of the pool.", nil, nil)
+	poolAcquires      = prometheus.NewDesc("db_pool_acquires_total", "Successful connection acquires.", nil, nil)
+	poolEmptyAcquires = prometheus.NewDesc("db_pool_empty_acquires_total", "Acquires that had to wait for a connection.", nil, nil)
+	poolCanceled      = prometheus.NewDesc("db_pool_canceled_acquires_total", "Acquires canceled by context.", nil, nil)
+	poolAcquireWait   = prometheus.NewDesc("db_pool_acquire_duration_seconds_total", "Total time spent acquiring connections.", nil, nil)
+)
+
+// коллектор статистики пула: значения читаются в момент сбора метрик
+type poolStatsCollector struct {
+	stat func() global_db.PoolStat
+}
+
+// Describe реализует prometheus.Collector
+func (c *poolStatsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- poolAcquiredConns
+	ch <- poolIdleConns
+	ch <- poolTotalConns
+	ch <- poolMaxConns
+	ch <- poolAcquires
+	ch <- poolEmptyAcquires
+	ch <- poolCanceled
+	ch <- poolAcquireWait
+}
+
+// Collect реализует prometheus.Collector
+func (c *poolStatsCollector) Collect(ch chan<- prometheus.Metric) {
+	s := c.stat()
+	ch <- prometheus.MustNewConstMetric(poolAcquiredConns, prometheus.GaugeValue, float64(s.AcquiredConns))
+	ch <- prometheus.MustNewConstMetric(poolIdleConns, prometheus.GaugeValue, float64(s.IdleConns))
+	ch <- prometheus.MustNewConstMetric(poolTotalConns, prometheus.GaugeValue, float64(s.TotalConns))
+	ch <- prometheus.MustNewConstMetric(poolMaxConns, prometheus.GaugeValue, float64(s.MaxConns))
+	ch <- prometheus.MustNewConstMetric(poolAcquires, prometheus.CounterValue, float64(s.AcquireCount))
+	ch <- prometheus.MustNewConstMetric(poolEmptyAcquires, prometheus.CounterValue, float64(s.EmptyAcquireCount))
+	ch <- prometheus.MustNewConstMetric(poolCanceled, prometheus.CounterValue, float64(s.CanceledAcquireCount))
+	ch <- prometheus.MustNewConstMetric(poolAcquireWait, prometheus.CounterValue, s.AcquireDuration.Seconds())
+}
@@ -0,0 +1,39 @@
+// Пакет metrics - общие prometheus метрики сервисов: endpoint /metrics,
+// задержки запросов к БД и кэшу, статистика пула соединений и бизнес-события
+package metrics
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// RegisterRoutes регистрирует GET /metrics (prometheus.DefaultGatherer)
+func RegisterRoutes(router gin.IRoutes) {
+	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
+}
+
+// Register регистрирует коллектор, а если такой уже зарегистрирован - возвращает существующий
+func Register[T prometheus.Collector](reg prometheus.Registerer, collector T) (T, error) {
+	if err := reg.Register(collector); err != nil {
+		var already prometheus.AlreadyRegisteredError
+		if errors.As(err, &already) {
+			if existing, ok := already.ExistingCollector.(T); ok {
+				return existing, nil
+			}
+		}
+		return collector, err
+	}
+	return collector, nil
+}
+
+// MustRegister - Register для коллекторов пакетного уровня (ошибка регистрации - ошибка программиста)
+func MustRegister[T prometheus.Collector](collector T) T {
+	registered, err := Register(prometheus.DefaultRegisterer, collector)
+	if err != nil {
+		panic(err)
+	}
+	return registered
+}
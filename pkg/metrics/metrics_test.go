@@ -0,0 +1,124 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"global_models/global_cache"
+	"global_models/global_db"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// фейковая БД: все запросы завершаются заданной ошибкой
+type fakePool struct {
+	global_db.Pool
+	err error
+}
+
+func (p *fakePool) Exec(ctx context.Context, sql string, args ...any) (int64, error) {
+	return 0, p.err
+}
+
+// фейковый кэш: на все чтения отвечает промахом
+type fakeCache struct {
+	global_cache.Cache
+}
+
+func (c fakeCache) Get(ctx context.Context, key string) (string, error) {
+	return "", global_cache.ErrNotFound
+}
+
+func TestRegister(t *testing.T) {
+	t.Run("повторная регистрация возвращает существующий коллектор", func(t *testing.T) {
+		reg := prometheus.NewRegistry()
+		opts := prometheus.CounterOpts{Name: "test_total", Help: "test"}
+
+		first, err := Register(reg, prometheus.NewCounter(opts))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		second, err := Register(reg, prometheus.NewCounter(opts))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if first != second {
+			t.Error("want the already registered collector")
+		}
+	})
+}
+
+func TestWrapPool(t *testing.T) {
+	t.Run("запрос учитывается по типу и результату", func(t *testing.T) {
+		before := histogramCount(t, dbQueryDuration.WithLabelValues("UPDATE", "error"))
+
+		pool := WrapPool(&fakePool{err: errors.New("boom")})
+		_, _ = pool.Exec(context.Background(), "  update users set is_active = false")
+
+		if got := histogramCount(t, dbQueryDuration.WithLabelValues("UPDATE", "error")); got != before+1 {
+			t.Errorf("want %d observations for UPDATE/error, got %d", before+1, got)
+		}
+	})
+}
+
+func TestWrapCache(t *testing.T) {
+	t.Run("промах кэша - отдельный результат", func(t *testing.T) {
+		before := histogramCount(t, cacheCommandDuration.WithLabelValues("GET", "miss"))
+
+		cache := WrapCache(fakeCache{})
+		_, _ = cache.Get(context.Background(), "user:1")
+
+		if got := histogramCount(t, cacheCommandDuration.WithLabelValues("GET", "miss")); got != before+1 {
+			t.Errorf("want %d observations for GET/miss, got %d", before+1, got)
+		}
+	})
+}
+
+func TestBusinessEvent(t *testing.T) {
+	t.Run("событие увеличивает счётчик", func(t *testing.T) {
+		before := testutil.ToFloat64(businessEvents.WithLabelValues(EventLeadCreated))
+		BusinessEvent(EventLeadCreated)
+		if got := testutil.ToFloat64(businessEvents.WithLabelValues(EventLeadCreated)); got != before+1 {
+			t.Errorf("want %v, got %v", before+1, got)
+		}
+	})
+}
+
+func TestRegisterRoutes(t *testing.T) {
+	t.Run("/metrics отдаёт метрики в формате prometheus", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		router := gin.New()
+		RegisterRoutes(router)
+		BusinessEvent(EventStart)
+
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("want 200, got %d", rec.Code)
+		}
+		if !strings.Contains(rec.Body.String(), `biz_events_total{event="start"}`) {
+			t.Error("want biz_events_total in response")
+		}
+	})
+}
+
+// функция для получения числа наблюдений гистограммы
+func histogramCount(t *testing.T, observer prometheus.Observer) uint64 {
+	t.Helper()
+	metric, ok := observer.(prometheus.Metric)
+	if !ok {
+		t.Fatal("observer is not a metric")
+	}
+	var m dto.Metric
+	if err := metric.Write(&m); err != nil {
+		t.Fatalf("failed to read metric: %v", err)
+	}
+	return m.GetHistogram().GetSampleCount()
+}
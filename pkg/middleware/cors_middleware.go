@@ -1,6 +1,8 @@
 package middleware
 
 import (
+	"net/url"
+
 	"github.com/gin-gonic/gin"
 )
 
@@ -15,6 +17,12 @@ func CORSMiddleware() gin.HandlerFunc {
 
 		origin := c.Request.Header.Get("Origin")
 
+		// Браузер присылает Origin и в запросах со своей же страницы (формы и fetch админки) - это не CORS
+		if isSameOrigin(origin, c.Request.Host) {
+			c.Next()
+			return
+		}
+
 		// Если Origin не указан (например, запрос из curl или postman)
 		if origin == "" {
 			// Разрешаем любые источники (или задайте конкретные)
@@ -66,3 +74,15 @@ func CORSMiddleware() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// функция для проверки, что запрос пришёл со страницы того же хоста, что и сам сервер
+func isSameOrigin(origin, host string) bool {
+	if origin == "" || host == "" {
+		return false
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return u.Host == host
+}
@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// заголовок с идентификатором запроса
+const requestIDHeader = "X-Request-ID"
+
+// middleware для correlation ID: берём X-Request-ID из запроса (или генерируем новый),
+// кладём в контекст запроса для логов и возвращаем в ответе
+func RequestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(requestIDHeader)
+		if requestID == "" {
+			requestID = logger.NewCorrelationID()
+		}
+
+		c.Request = c.Request.WithContext(logger.WithCorrelationID(c.Request.Context(), requestID))
+		c.Header(requestIDHeader, requestID)
+		c.Next()
+	}
+}
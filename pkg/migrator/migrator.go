@@ -0,0 +1,131 @@
+// Пакет migrator - применение версионированных SQL миграций (goose) из встроенной файловой системы
+package migrator
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"pkg/configs"
+
+	_ "github.com/jackc/pgx/v4/stdlib" // драйвер "pgx" для database/sql
+	"github.com/pressly/goose/v3"
+	"github.com/pressly/goose/v3/lock"
+)
+
+// ErrPendingMigrations - в БД применены не все миграции (режим check)
+var ErrPendingMigrations = errors.New("database has pending migrations")
+
+// Migrator применяет миграции к БД
+type Migrator struct {
+	db       *sql.DB
+	provider *goose.Provider
+}
+
+// New создаёт мигратор для БД по DSN. fsys - файлы миграций (обычно embed.FS)
+func New(dsn string, fsys fs.FS, conf *configs.MigrationsConfig) (*Migrator, error) {
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	// несколько реплик могут стартовать одновременно - миграции применяет только одна (advisory lock)
+	locker, err := lock.NewPostgresSessionLocker()
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create migrations lock: %w", err)
+	}
+
+	provider, err := goose.NewProvider(goose.DialectPostgres, db, fsys,
+		goose.WithTableName(conf.Table),
+		goose.WithSessionLocker(locker),
+		goose.WithDisableGlobalRegistry(true),
+	)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create migrations provider: %w", err)
+	}
+
+	return &Migrator{db: db, provider: provider}, nil
+}
+
+// Close закрывает соединение с БД
+func (m *Migrator) Close() error {
+	return m.db.Close()
+}
+
+// Up применяет все непримененные миграции
+func (m *Migrator) Up(ctx context.Context) error {
+	results, err := m.provider.Up(ctx)
+	for _, r := range results {
+		slog.InfoContext(ctx, "migration applied", "version", r.Source.Version, "file", r.Source.Path, "duration", r.Duration)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to apply migrations: %w", err)
+	}
+	return nil
+}
+
+// Down откатывает последнюю примененную миграцию
+func (m *Migrator) Down(ctx context.Context) error {
+	r, err := m.provider.Down(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to roll back migration: %w", err)
+	}
+	slog.InfoContext(ctx, "migration rolled back", "version", r.Source.Version, "file", r.Source.Path, "duration", r.Duration)
+	return nil
+}
+
+// Status возвращает состояние всех миграций
+func (m *Migrator) Status(ctx context.Context) ([]*goose.MigrationStatus, error) {
+	statuses, err := m.provider.Status(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get migrations status: %w", err)
+	}
+	return statuses, nil
+}
+
+// Pending возвращает количество непримененных миграций
+func (m *Migrator) Pending(ctx context.Context) (int, error) {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	pending := 0
+	for _, s := range statuses {
+		if s.State == goose.StatePending {
+			pending++
+		}
+	}
+	return pending, nil
+}
+
+// OnStartup выполняет проверку миграций при старте сервиса согласно режиму из конфига
+func (m *Migrator) OnStartup(ctx context.Context, mode string) error {
+	switch mode {
+	case configs.MigrationsModeOff, "":
+		return nil
+	case configs.MigrationsModeAuto:
+		return m.Up(ctx)
+	case configs.MigrationsModeCheck:
+		pending, err := m.Pending(ctx)
+		if err != nil {
+			return err
+		}
+		if pending > 0 {
+			return fmt.Errorf("%w: %d (run `migrate up`)", ErrPendingMigrations, pending)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown migrations mode %q", mode)
+	}
+}
+
+// Create создаёт в каталоге dir пустой файл SQL миграции со следующим порядковым номером
+func Create(dir, name string) error {
+	goose.SetSequential(true)
+	return goose.Create(nil, dir, name, "sql")
+}
@@ -0,0 +1,54 @@
+package postgresdb
+
+import (
+	"errors"
+	"global_models/global_db"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+)
+
+// коды SQLSTATE, которые переводятся в типизированные ошибки
+const (
+	codeUniqueViolation      = "23505"
+	codeForeignKeyViolation  = "23503"
+	codeSerializationFailure = "40001"
+	codeDeadlockDetected     = "40P01"
+)
+
+// функция оборачивает ошибку драйвера в *global_db.Error, если у неё есть тип;
+// остальные ошибки возвращаются как есть
+func mapError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var typed *global_db.Error
+	if errors.As(err, &typed) {
+		return err
+	}
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return &global_db.Error{Kind: global_db.ErrNoRows, Err: err}
+	}
+
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return err
+	}
+
+	var kind error
+	switch pgErr.Code {
+	case codeUniqueViolation:
+		kind = global_db.ErrUniqueViolation
+	case codeForeignKeyViolation:
+		kind = global_db.ErrForeignKeyViolation
+	case codeSerializationFailure:
+		kind = global_db.ErrSerializationFailure
+	case codeDeadlockDetected:
+		kind = global_db.ErrDeadlock
+	default:
+		return err
+	}
+	return &global_db.Error{Kind: kind, Code: pgErr.Code, Constraint: pgErr.ConstraintName, Err: err}
+}
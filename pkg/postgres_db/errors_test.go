@@ -0,0 +1,63 @@
+package postgresdb
+
+import (
+	"errors"
+	"fmt"
+	"global_models/global_db"
+	"testing"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+)
+
+func TestMapError(t *testing.T) {
+	t.Run("нет строк - ErrNoRows с исходной ошибкой", func(t *testing.T) {
+		err := mapError(pgx.ErrNoRows)
+		if !errors.Is(err, global_db.ErrNoRows) {
+			t.Errorf("ожидали ErrNoRows, получили %v", err)
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			t.Error("исходная ошибка драйвера потеряна")
+		}
+	})
+
+	t.Run("коды Postgres переводятся в типы", func(t *testing.T) {
+		cases := map[string]error{
+			"23505": global_db.ErrUniqueViolation,
+			"23503": global_db.ErrForeignKeyViolation,
+			"40001": global_db.ErrSerializationFailure,
+			"40P01": global_db.ErrDeadlock,
+		}
+		for code, want := range cases {
+			pgErr := &pgconn.PgError{Code: code, ConstraintName: "users_telegram_id_key"}
+			err := mapError(fmt.Errorf("failed to save: %w", pgErr))
+			if !errors.Is(err, want) {
+				t.Errorf("%s: ожидали %v, получили %v", code, want, err)
+			}
+
+			var typed *global_db.Error
+			if !errors.As(err, &typed) || typed.Code != code || typed.Constraint != "users_telegram_id_key" {
+				t.Errorf("%s: нет деталей ошибки: %+v", code, typed)
+			}
+		}
+	})
+
+	t.Run("прочие ошибки не меняются", func(t *testing.T) {
+		original := &pgconn.PgError{Code: "42P01"}
+		if err := mapError(original); err != error(original) {
+			t.Errorf("ошибка изменена: %v", err)
+		}
+		if mapError(nil) != nil {
+			t.Error("nil должен остаться nil")
+		}
+	})
+
+	t.Run("ошибка сериализации - повторяемая", func(t *testing.T) {
+		if !global_db.IsRetryable(mapError(&pgconn.PgError{Code: "40001"})) {
+			t.Error("40001 должна повторяться")
+		}
+		if global_db.IsRetryable(mapError(&pgconn.PgError{Code: "23505"})) {
+			t.Error("нарушение уникальности не повторяется")
+		}
+	})
+}
@@ -14,8 +14,10 @@ var _ global_db.Pool = (*PoolAdapter)(nil)
 var _ global_db.Rows = (*RowsAdapter)(nil)
 var _ global_db.Row = (*RowAdapter)(nil)
 var _ global_db.Tx = (*TxAdapter)(nil)
+var _ global_db.BatchResults = (*BatchResultsAdapter)(nil)
 
-// PoolAdapter адаптирует *pgxpool.Pool к интерфейсу db.Pool
+// PoolAdapter адаптирует *pgxpool.Pool к интерфейсу db.Pool.
+// Ошибки драйвера оборачиваются в *global_db.Error (см. mapError)
 type PoolAdapter struct {
 	pool *pgxpool.Pool
 }
@@ -29,27 +31,71 @@ func (a *PoolAdapter) Close() error {
 	return nil
 }
 
+func (a *PoolAdapter) Ping(ctx context.Context) error {
+	return a.pool.Ping(ctx)
+}
+
+// Stat возвращает статистику пула соединений (для метрик)
+func (a *PoolAdapter) Stat() global_db.PoolStat {
+	s := a.pool.Stat()
+	return global_db.PoolStat{
+		AcquiredConns:        s.AcquiredConns(),
+		IdleConns:            s.IdleConns(),
+		TotalConns:           s.TotalConns(),
+		MaxConns:             s.MaxConns(),
+		AcquireCount:         s.AcquireCount(),
+		EmptyAcquireCount:    s.EmptyAcquireCount(),
+		CanceledAcquireCount: s.CanceledAcquireCount(),
+		AcquireDuration:      s.AcquireDuration(),
+	}
+}
+
 func (a *PoolAdapter) Exec(ctx context.Context, sql string, args ...any) (int64, error) {
 	tag, err := a.pool.Exec(ctx, sql, args...)
-	return tag.RowsAffected(), err
+	return tag.RowsAffected(), mapError(err)
 }
 
 func (a *PoolAdapter) QueryRow(ctx context.Context, sql string, args ...any) global_db.Row {
-	return a.pool.QueryRow(ctx, sql, args...)
+	return &RowAdapter{row: a.pool.QueryRow(ctx, sql, args...)}
 }
 
 func (a *PoolAdapter) Query(ctx context.Context, sql string, args ...any) (global_db.Rows, error) {
 	rows, err := a.pool.Query(ctx, sql, args...)
 	if err != nil {
-		return nil, fmt.Errorf("query failed: %w", err)
+		return nil, fmt.Errorf("query failed: %w", mapError(err))
 	}
 	return &RowsAdapter{rows: rows}, nil
 }
 
+// CopyFrom загружает строки через протокол COPY
+func (a *PoolAdapter) CopyFrom(ctx context.Context, table string, columns []string, rows [][]any) (int64, error) {
+	n, err := a.pool.CopyFrom(ctx, pgx.Identifier{table}, columns, pgx.CopyFromRows(rows))
+	return n, mapError(err)
+}
+
+// SendBatch отправляет пакет запросов одним обращением к БД
+func (a *PoolAdapter) SendBatch(ctx context.Context, batch *global_db.Batch) global_db.BatchResults {
+	return &BatchResultsAdapter{results: a.pool.SendBatch(ctx, toPgxBatch(batch))}
+}
+
 func (a *PoolAdapter) Begin(ctx context.Context) (global_db.Tx, error) {
 	tx, err := a.pool.Begin(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+		return nil, fmt.Errorf("failed to begin transaction: %w", mapError(err))
+	}
+	return &TxAdapter{tx: tx}, nil
+}
+
+// BeginTx открывает транзакцию с уровнем изоляции и режимом доступа из opts
+func (a *PoolAdapter) BeginTx(ctx context.Context, opts global_db.TxOptions) (global_db.Tx, error) {
+	txOptions := pgx.TxOptions{IsoLevel: pgx.TxIsoLevel(opts.IsoLevel)}
+	if opts.ReadOnly {
+		txOptions.AccessMode = pgx.ReadOnly
+	}
+
+	tx, err := a.pool.BeginTx(ctx, txOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", mapError(err))
 	}
 	return &TxAdapter{tx: tx}, nil
 }
@@ -60,7 +106,7 @@ type RowAdapter struct {
 }
 
 func (r *RowAdapter) Scan(dest ...any) error {
-	return r.row.Scan(dest...)
+	return mapError(r.row.Scan(dest...))
 }
 
 // RowsAdapter адаптирует pgx.Rows к интерфейсу db.Rows
@@ -73,7 +119,7 @@ func (r *RowsAdapter) Next() bool {
 }
 
 func (r *RowsAdapter) Scan(dest ...any) error {
-	return r.rows.Scan(dest...)
+	return mapError(r.rows.Scan(dest...))
 }
 
 func (r *RowsAdapter) Close() {
@@ -81,7 +127,7 @@ func (r *RowsAdapter) Close() {
 }
 
 func (r *RowsAdapter) Err() error {
-	return r.rows.Err()
+	return mapError(r.rows.Err())
 }
 
 // TxAdapter адаптирует pgx.Tx к интерфейсу db.Tx
@@ -90,26 +136,70 @@ type TxAdapter struct {
 }
 
 func (t *TxAdapter) Commit(ctx context.Context) error {
-	return t.tx.Commit(ctx)
+	return mapError(t.tx.Commit(ctx))
 }
 
 func (t *TxAdapter) Rollback(ctx context.Context) error {
-	return t.tx.Rollback(ctx)
+	return mapError(t.tx.Rollback(ctx))
 }
 
 func (t *TxAdapter) Exec(ctx context.Context, sql string, args ...any) (int64, error) {
 	tag, err := t.tx.Exec(ctx, sql, args...)
-	return tag.RowsAffected(), err
+	return tag.RowsAffected(), mapError(err)
 }
 
 func (t *TxAdapter) QueryRow(ctx context.Context, sql string, args ...any) global_db.Row {
-	return t.tx.QueryRow(ctx, sql, args...)
+	return &RowAdapter{row: t.tx.QueryRow(ctx, sql, args...)}
 }
 
 func (t *TxAdapter) Query(ctx context.Context, sql string, args ...any) (global_db.Rows, error) {
 	rows, err := t.tx.Query(ctx, sql, args...)
 	if err != nil {
-		return nil, fmt.Errorf("query failed: %w", err)
+		return nil, fmt.Errorf("query failed: %w", mapError(err))
+	}
+	return &RowsAdapter{rows: rows}, nil
+}
+
+func (t *TxAdapter) CopyFrom(ctx context.Context, table string, columns []string, rows [][]any) (int64, error) {
+	n, err := t.tx.CopyFrom(ctx, pgx.Identifier{table}, columns, pgx.CopyFromRows(rows))
+	return n, mapError(err)
+}
+
+func (t *TxAdapter) SendBatch(ctx context.Context, batch *global_db.Batch) global_db.BatchResults {
+	return &BatchResultsAdapter{results: t.tx.SendBatch(ctx, toPgxBatch(batch))}
+}
+
+// BatchResultsAdapter адаптирует pgx.BatchResults к интерфейсу db.BatchResults
+type BatchResultsAdapter struct {
+	results pgx.BatchResults
+}
+
+func (b *BatchResultsAdapter) Exec() (int64, error) {
+	tag, err := b.results.Exec()
+	return tag.RowsAffected(), mapError(err)
+}
+
+func (b *BatchResultsAdapter) QueryRow() global_db.Row {
+	return &RowAdapter{row: b.results.QueryRow()}
+}
+
+func (b *BatchResultsAdapter) Query() (global_db.Rows, error) {
+	rows, err := b.results.Query()
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", mapError(err))
 	}
 	return &RowsAdapter{rows: rows}, nil
 }
+
+func (b *BatchResultsAdapter) Close() error {
+	return mapError(b.results.Close())
+}
+
+// функция для перевода пакета запросов в pgx.Batch
+func toPgxBatch(batch *global_db.Batch) *pgx.Batch {
+	pgxBatch := &pgx.Batch{}
+	for _, query := range batch.Queries() {
+		pgxBatch.Queue(query.SQL, query.Args...)
+	}
+	return pgxBatch
+}
@@ -2,11 +2,23 @@ package redis
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"global_models/global_cache"
+	"math"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-redis/redis/v8"
 )
 
+// Проверка реализации интерфейса
+var (
+	_ global_cache.Cache  = (*CacheRedisAdapter)(nil)
+	_ global_cache.PubSub = (*CacheRedisAdapter)(nil)
+)
+
 type CacheRedisAdapter struct {
 	client *redis.Client
 }
@@ -24,6 +36,11 @@ func (r *CacheRedisAdapter) Close() error {
 	return nil
 }
 
+// метод для проверки доступности redis
+func (r *CacheRedisAdapter) Ping(ctx context.Context) error {
+	return r.client.Ping(ctx).Err()
+}
+
 // метод для добавления значения с TTL в redis
 func (r *CacheRedisAdapter) Set(ctx context.Context, key string, value []byte, expiration time.Duration) error {
 	return r.client.Set(ctx, key, value, expiration).Err()
@@ -31,12 +48,241 @@ func (r *CacheRedisAdapter) Set(ctx context.Context, key string, value []byte, e
 
 // метод получения значения из redis по ключу
 func (r *CacheRedisAdapter) Get(ctx context.Context, key string) (string, error) {
-	return r.client.Get(ctx, key).Result()
+	result, err := r.client.Get(ctx, key).Result()
+	return result, mapError(err)
 }
 
 // метод получения значения из redis по ключу (результат в виде байтового среза)
 func (r *CacheRedisAdapter) GetBytes(ctx context.Context, key string) ([]byte, error) {
-	return r.client.Get(ctx, key).Bytes()
+	result, err := r.client.Get(ctx, key).Bytes()
+	return result, mapError(err)
+}
+
+// метод атомарной записи значения, только если ключа ещё нет (SET NX)
+func (r *CacheRedisAdapter) SetNX(ctx context.Context, key string, value []byte, expiration time.Duration) (bool, error) {
+	return r.client.SetNX(ctx, key, value, expiration).Result()
+}
+
+// метод атомарной записи значения с возвратом предыдущего (GETSET)
+func (r *CacheRedisAdapter) GetSet(ctx context.Context, key string, value []byte) ([]byte, error) {
+	result, err := r.client.GetSet(ctx, key, value).Bytes()
+	return result, mapError(err)
+}
+
+// метод атомарного увеличения счётчика на 1
+func (r *CacheRedisAdapter) Incr(ctx context.Context, key string, expiration time.Duration) (int64, error) {
+	return r.IncrBy(ctx, key, 1, expiration)
+}
+
+// увеличение и установка времени жизни одним скриптом: счётчик не может остаться без TTL
+var incrByScript = redis.NewScript(`
+local value = redis.call('INCRBY', KEYS[1], ARGV[1])
+if tonumber(ARGV[2]) > 0 and redis.call('PTTL', KEYS[1]) == -1 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return value
+`)
+
+// метод атомарного увеличения счётчика на delta (время жизни задаётся только новому ключу)
+func (r *CacheRedisAdapter) IncrBy(ctx context.Context, key string, delta int64, expiration time.Duration) (int64, error) {
+	if expiration <= 0 {
+		result, err := r.client.IncrBy(ctx, key, delta).Result()
+		return result, mapError(err)
+	}
+	result, err := incrByScript.Run(ctx, r.client, []string{key}, delta, expiration.Milliseconds()).Int64()
+	return result, mapError(err)
+}
+
+// захват ключа и выдача номера одним скриптом: номер тратится только при записи,
+// поэтому порядок номеров совпадает с порядком захватов
+var setNXFencedScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 1 then
+	return 0
+end
+local fence = redis.call('INCR', KEYS[2])
+local value = fence .. '.' .. ARGV[1]
+if tonumber(ARGV[2]) > 0 then
+	redis.call('SET', KEYS[1], value, 'PX', ARGV[2])
+else
+	redis.call('SET', KEYS[1], value)
+end
+return fence
+`)
+
+// метод записи нового ключа с номером из счётчика fenceKey (0 - ключ уже есть)
+func (r *CacheRedisAdapter) SetNXFenced(ctx context.Context, key, fenceKey string, suffix []byte, expiration time.Duration) (int64, error) {
+	result, err := setNXFencedScript.Run(ctx, r.client, []string{key, fenceKey}, suffix, expiration.Milliseconds()).Int64()
+	return result, mapError(err)
+}
+
+// удаление ключа только при совпадении значения
+var compareAndDeleteScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// метод удаления ключа, только если его значение равно expected
+func (r *CacheRedisAdapter) CompareAndDelete(ctx context.Context, key string, expected []byte) (bool, error) {
+	result, err := compareAndDeleteScript.Run(ctx, r.client, []string{key}, expected).Int64()
+	return result == 1, mapError(err)
+}
+
+// продление ключа только при совпадении значения
+var compareAndExpireScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// метод продления ключа, только если его значение равно expected
+func (r *CacheRedisAdapter) CompareAndExpire(ctx context.Context, key string, expected []byte, expiration time.Duration) (bool, error) {
+	result, err := compareAndExpireScript.Run(ctx, r.client, []string{key}, expected, expiration.Milliseconds()).Int64()
+	return result == 1, mapError(err)
+}
+
+// метод записи полей хэша
+func (r *CacheRedisAdapter) HSet(ctx context.Context, key string, values map[string][]byte) error {
+	if len(values) == 0 {
+		return nil
+	}
+	args := make([]any, 0, len(values)*2)
+	for field, value := range values {
+		args = append(args, field, value)
+	}
+	return mapError(r.client.HSet(ctx, key, args...).Err())
+}
+
+// метод чтения поля хэша
+func (r *CacheRedisAdapter) HGet(ctx context.Context, key, field string) ([]byte, error) {
+	result, err := r.client.HGet(ctx, key, field).Bytes()
+	return result, mapError(err)
+}
+
+// метод чтения всех полей хэша (нет ключа - пустой map)
+func (r *CacheRedisAdapter) HGetAll(ctx context.Context, key string) (map[string][]byte, error) {
+	result, err := r.client.HGetAll(ctx, key).Result()
+	if err != nil {
+		return nil, mapError(err)
+	}
+	values := make(map[string][]byte, len(result))
+	for field, value := range result {
+		values[field] = []byte(value)
+	}
+	return values, nil
+}
+
+// метод удаления полей хэша
+func (r *CacheRedisAdapter) HDel(ctx context.Context, key string, fields ...string) error {
+	if len(fields) == 0 {
+		return nil
+	}
+	return mapError(r.client.HDel(ctx, key, fields...).Err())
+}
+
+// метод атомарного увеличения числового поля хэша
+func (r *CacheRedisAdapter) HIncrBy(ctx context.Context, key, field string, delta int64) (int64, error) {
+	result, err := r.client.HIncrBy(ctx, key, field, delta).Result()
+	return result, mapError(err)
+}
+
+// метод добавления значений в начало списка
+func (r *CacheRedisAdapter) LPush(ctx context.Context, key string, values ...[]byte) (int64, error) {
+	if len(values) == 0 {
+		return r.LLen(ctx, key)
+	}
+	result, err := r.client.LPush(ctx, key, bytesArgs(values)...).Result()
+	return result, mapError(err)
+}
+
+// метод добавления значений в конец списка
+func (r *CacheRedisAdapter) RPush(ctx context.Context, key string, values ...[]byte) (int64, error) {
+	if len(values) == 0 {
+		return r.LLen(ctx, key)
+	}
+	result, err := r.client.RPush(ctx, key, bytesArgs(values)...).Result()
+	return result, mapError(err)
+}
+
+// метод извлечения первого элемента списка
+func (r *CacheRedisAdapter) LPop(ctx context.Context, key string) ([]byte, error) {
+	result, err := r.client.LPop(ctx, key).Bytes()
+	return result, mapError(err)
+}
+
+// метод чтения диапазона списка
+func (r *CacheRedisAdapter) LRange(ctx context.Context, key string, start, stop int64) ([][]byte, error) {
+	result, err := r.client.LRange(ctx, key, start, stop).Result()
+	if err != nil {
+		return nil, mapError(err)
+	}
+	values := make([][]byte, len(result))
+	for i, value := range result {
+		values[i] = []byte(value)
+	}
+	return values, nil
+}
+
+// метод получения длины списка
+func (r *CacheRedisAdapter) LLen(ctx context.Context, key string) (int64, error) {
+	result, err := r.client.LLen(ctx, key).Result()
+	return result, mapError(err)
+}
+
+// метод добавления элементов в упорядоченное множество (у существующих меняется score)
+func (r *CacheRedisAdapter) ZAdd(ctx context.Context, key string, members ...global_cache.ZMember) error {
+	if len(members) == 0 {
+		return nil
+	}
+	zs := make([]*redis.Z, len(members))
+	for i, member := range members {
+		zs[i] = &redis.Z{Score: member.Score, Member: member.Member}
+	}
+	return mapError(r.client.ZAdd(ctx, key, zs...).Err())
+}
+
+// метод удаления элементов упорядоченного множества
+func (r *CacheRedisAdapter) ZRem(ctx context.Context, key string, members ...string) error {
+	if len(members) == 0 {
+		return nil
+	}
+	args := make([]any, len(members))
+	for i, member := range members {
+		args[i] = member
+	}
+	return mapError(r.client.ZRem(ctx, key, args...).Err())
+}
+
+// метод чтения score элемента
+func (r *CacheRedisAdapter) ZScore(ctx context.Context, key, member string) (float64, error) {
+	result, err := r.client.ZScore(ctx, key, member).Result()
+	return result, mapError(err)
+}
+
+// метод чтения элементов по диапазону score
+func (r *CacheRedisAdapter) ZRangeByScore(ctx context.Context, key string, min, max float64, limit int64) ([]global_cache.ZMember, error) {
+	opt := &redis.ZRangeBy{Min: formatScore(min), Max: formatScore(max)}
+	if limit > 0 {
+		opt.Count = limit
+	}
+	result, err := r.client.ZRangeByScoreWithScores(ctx, key, opt).Result()
+	if err != nil {
+		return nil, mapError(err)
+	}
+	members := make([]global_cache.ZMember, len(result))
+	for i, z := range result {
+		member, _ := z.Member.(string)
+		members[i] = global_cache.ZMember{Member: member, Score: z.Score}
+	}
+	return members, nil
+}
+
+// метод получения размера упорядоченного множества
+func (r *CacheRedisAdapter) ZCard(ctx context.Context, key string) (int64, error) {
+	result, err := r.client.ZCard(ctx, key).Result()
+	return result, mapError(err)
 }
 
 // метод удаления элемента по ключу из redis
@@ -50,13 +296,89 @@ func (r *CacheRedisAdapter) Exists(ctx context.Context, key string) (bool, error
 	return result > 0, err
 }
 
-// метод устанавливает время жизни ключа в Redis.
+// метод устанавливает время жизни ключа в Redis (с точностью до миллисекунд - EXPIRE округлил бы до секунд).
 func (r *CacheRedisAdapter) Expire(ctx context.Context, key string, expiration time.Duration) error {
-	return r.client.Expire(ctx, key, expiration).Err()
+	return r.client.PExpire(ctx, key, expiration).Err()
 }
 
 // метод возвращает оставшееся время жизни ключа в Redis.
 // Возвращает -1 если время жизни не установлено, -2 если ключ не существует.
 func (r *CacheRedisAdapter) TTL(ctx context.Context, key string) (time.Duration, error) {
-	return r.client.TTL(ctx, key).Result()
+	return r.client.PTTL(ctx, key).Result()
+}
+
+// метод публикации сообщения в канал (PUBLISH)
+func (r *CacheRedisAdapter) Publish(ctx context.Context, channel string, payload []byte) error {
+	return r.client.Publish(ctx, channel, payload).Err()
+}
+
+// метод подписки на канал (SUBSCRIBE): блокируется до отмены ctx.
+// go-redis сам восстанавливает соединение - о каждой повторной подписке handler узнаёт по nil
+func (r *CacheRedisAdapter) Subscribe(ctx context.Context, channel string, handler func(payload []byte)) error {
+	pubsub := r.client.Subscribe(ctx, channel)
+	defer pubsub.Close()
+
+	// дожидаемся подтверждения подписки, чтобы ошибка подключения вернулась сразу
+	if _, err := pubsub.Receive(ctx); err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return err
+	}
+	handler(nil)
+
+	messages := pubsub.ChannelWithSubscriptions(ctx, 100)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case msg, ok := <-messages:
+			if !ok {
+				return nil
+			}
+			switch msg := msg.(type) {
+			case *redis.Message:
+				handler([]byte(msg.Payload))
+			case *redis.Subscription:
+				if msg.Kind == "subscribe" {
+					handler(nil)
+				}
+			}
+		}
+	}
+}
+
+// mapError приводит ошибки redis ("ключ не найден", WRONGTYPE, не число) к ошибкам глобального интерфейса
+func mapError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, redis.Nil):
+		return global_cache.ErrNotFound
+	case strings.HasPrefix(err.Error(), "WRONGTYPE"):
+		return fmt.Errorf("%w: %v", global_cache.ErrWrongType, err)
+	case strings.Contains(err.Error(), "not an integer"):
+		return fmt.Errorf("%w: %v", global_cache.ErrNotInteger, err)
+	}
+	return err
+}
+
+// функция для передачи значений списка аргументами команды
+func bytesArgs(values [][]byte) []any {
+	args := make([]any, len(values))
+	for i, value := range values {
+		args[i] = value
+	}
+	return args
+}
+
+// функция для записи границы score в формате Redis (бесконечности - +inf / -inf)
+func formatScore(score float64) string {
+	switch {
+	case math.IsInf(score, 1):
+		return "+inf"
+	case math.IsInf(score, -1):
+		return "-inf"
+	}
+	return strconv.FormatFloat(score, 'g', -1, 64)
 }
@@ -0,0 +1,53 @@
+package redis
+
+import (
+	"context"
+	"global_models/global_cache"
+	"os"
+	"pkg/cachetest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+)
+
+// адрес настоящего Redis для тестов адаптера (например, localhost:6379).
+// ВНИМАНИЕ: база очищается перед каждым тестом (FLUSHDB) - используйте отдельную
+const testRedisAddrEnv = "TEST_REDIS_ADDR"
+
+func TestCacheRedisAdapterContract(t *testing.T) {
+	t.Run("miniredis", func(t *testing.T) {
+		var server *miniredis.Miniredis
+		cachetest.RunContract(t, func(t *testing.T) global_cache.Cache {
+			server = miniredis.RunT(t)
+			client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+			t.Cleanup(func() { client.Close() })
+			return NewCacheAdapter(client)
+		}, func(d time.Duration) { server.FastForward(d) })
+	})
+
+	t.Run("redis", func(t *testing.T) {
+		addr := os.Getenv(testRedisAddrEnv)
+		if addr == "" {
+			t.Skipf("%s не задан", testRedisAddrEnv)
+		}
+		cachetest.RunContract(t, func(t *testing.T) global_cache.Cache {
+			client := redis.NewClient(&redis.Options{Addr: addr})
+			if err := client.FlushDB(context.Background()).Err(); err != nil {
+				t.Fatalf("FLUSHDB: %v", err)
+			}
+			t.Cleanup(func() { client.Close() })
+			return NewCacheAdapter(client)
+		}, time.Sleep)
+	})
+}
+
+func TestCacheRedisAdapterPubSubContract(t *testing.T) {
+	cachetest.RunPubSubContract(t, func(t *testing.T) global_cache.PubSub {
+		server := miniredis.RunT(t)
+		client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+		t.Cleanup(func() { client.Close() })
+		return NewCacheAdapter(client)
+	})
+}
@@ -0,0 +1,272 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"pkg/configs"
+	"pkg/metrics"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ErrNotLeader - экземпляр не является лидером (задача лидера не выполняется)
+var ErrNotLeader = errors.New("lock: not a leader")
+
+// метрики выбора лидера
+var (
+	leaderGauge = metrics.MustRegister(prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "leader_election_is_leader",
+		Help: "1 if this instance currently holds the leadership lock.",
+	}, []string{"name"}))
+
+	leaderChanges = metrics.MustRegister(prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "leader_election_transitions_total",
+		Help: "Leadership transitions of this instance (elected / lost / resigned).",
+	}, []string{"name", "transition"}))
+)
+
+// Elector держит блокировку лидера среди экземпляров сервиса: захватывает её, продлевает,
+// а при потере прекращает задачи лидера (контекст Do отменяется)
+type Elector struct {
+	locker *Locker
+	conf   *configs.LeaderElectionConfig
+
+	mu        sync.RWMutex
+	lock      *Lock              // nil - не лидер
+	renewedAt time.Time          // последнее успешное продление (блокировка живёт до renewedAt + TTL)
+	leaderCtx context.Context    // отменяется при потере лидерства
+	cancel    context.CancelFunc // отменяет leaderCtx
+	closed    bool               // после Close лидерство не захватывается
+}
+
+// конструктор для Elector. При выключенном выборе (conf.Enabled = false) экземпляр всегда лидер
+func NewElector(locker *Locker, conf *configs.LeaderElectionConfig) (*Elector, error) {
+	if conf == nil {
+		conf = configs.UseDefaultLeaderElectionConfig()
+	}
+	if !conf.Enabled {
+		e := &Elector{conf: conf}
+		e.leaderCtx, e.cancel = context.WithCancel(context.Background())
+		return e, nil
+	}
+
+	if locker == nil {
+		return nil, errors.New("locker is nil")
+	}
+	if conf.Name == "" {
+		return nil, errors.New("leader election: name is empty")
+	}
+	if conf.TTL <= 0 || conf.RenewInterval <= 0 || conf.RetryInterval <= 0 {
+		return nil, errors.New("leader election: ttl and intervals must be positive")
+	}
+	if conf.RenewInterval >= conf.TTL {
+		return nil, errors.New("leader election: renew_interval must be less than ttl")
+	}
+	return &Elector{locker: locker, conf: conf}, nil
+}
+
+// Run захватывает и продлевает лидерство до отмены ctx (или Close), затем освобождает блокировку
+func (e *Elector) Run(ctx context.Context) {
+	if !e.conf.Enabled {
+		return
+	}
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			releaseCtx, cancel := context.WithTimeout(context.Background(), e.conf.RenewInterval)
+			e.Resign(releaseCtx)
+			cancel()
+			return
+		case <-timer.C:
+		}
+
+		if e.IsLeader() {
+			e.renew(ctx)
+		} else {
+			e.tryAcquire(ctx)
+		}
+
+		if e.isClosed() {
+			return
+		}
+		if e.IsLeader() {
+			timer.Reset(e.conf.RenewInterval)
+		} else {
+			timer.Reset(e.conf.RetryInterval)
+		}
+	}
+}
+
+// метод одной попытки стать лидером
+func (e *Elector) tryAcquire(ctx context.Context) {
+	attemptCtx, cancel := context.WithTimeout(ctx, e.conf.RetryInterval)
+	defer cancel()
+
+	lock, err := e.locker.TryAcquire(attemptCtx, e.conf.Name, e.conf.TTL)
+	if errors.Is(err, ErrNotAcquired) {
+		return
+	}
+	if err != nil {
+		slog.Warn("leader election: acquire failed", "name", e.conf.Name, "error", err)
+		return
+	}
+
+	e.mu.Lock()
+	if e.closed {
+		e.mu.Unlock()
+		// Close пришёл во время захвата - сразу отдаём блокировку
+		lock.Release(attemptCtx)
+		return
+	}
+	e.lock = lock
+	e.renewedAt = time.Now()
+	e.leaderCtx, e.cancel = context.WithCancel(context.Background())
+	e.mu.Unlock()
+
+	leaderGauge.WithLabelValues(e.conf.Name).Set(1)
+	leaderChanges.WithLabelValues(e.conf.Name, "elected").Inc()
+	slog.Info("leader election: elected", "name", e.conf.Name, "fence", lock.Fence())
+}
+
+// метод продления лидерства. Если хранилище недоступно, лидерство сохраняется,
+// пока блокировка гарантированно жива, и снимается раньше, чем её сможет захватить другой
+func (e *Elector) renew(ctx context.Context) {
+	e.mu.RLock()
+	lock, renewedAt := e.lock, e.renewedAt
+	e.mu.RUnlock()
+	if lock == nil {
+		return
+	}
+
+	renewCtx, cancel := context.WithTimeout(ctx, e.conf.RenewInterval)
+	defer cancel()
+
+	err := lock.Refresh(renewCtx, e.conf.TTL)
+	switch {
+	case err == nil:
+		e.mu.Lock()
+		if e.lock == lock {
+			e.renewedAt = time.Now()
+		}
+		e.mu.Unlock()
+	case errors.Is(err, ErrLockLost):
+		e.stepDown(lock, "lost")
+		slog.Warn("leader election: leadership lost", "name", e.conf.Name, "fence", lock.Fence())
+	default:
+		// до следующего продления блокировка может истечь - не рискуем двумя лидерами
+		if time.Until(renewedAt.Add(e.conf.TTL)) <= e.conf.RenewInterval {
+			e.stepDown(lock, "lost")
+			slog.Warn("leader election: leadership lost, renew failed", "name", e.conf.Name, "error", err)
+			return
+		}
+		slog.Warn("leader election: renew failed", "name", e.conf.Name, "error", err)
+	}
+}
+
+// метод снятия лидерства (блокировка в хранилище не трогается)
+func (e *Elector) stepDown(lock *Lock, transition string) bool {
+	e.mu.Lock()
+	if e.lock != lock || lock == nil {
+		e.mu.Unlock()
+		return false
+	}
+	e.lock = nil
+	e.cancel()
+	e.mu.Unlock()
+
+	leaderGauge.WithLabelValues(e.conf.Name).Set(0)
+	leaderChanges.WithLabelValues(e.conf.Name, transition).Inc()
+	return true
+}
+
+// Resign отказывается от лидерства и освобождает блокировку (другой экземпляр захватит её, не дожидаясь TTL)
+func (e *Elector) Resign(ctx context.Context) error {
+	e.mu.RLock()
+	lock := e.lock
+	e.mu.RUnlock()
+
+	if !e.stepDown(lock, "resigned") {
+		return nil
+	}
+	slog.Info("leader election: resigned", "name", e.conf.Name, "fence", lock.Fence())
+
+	if err := lock.Release(ctx); err != nil && !errors.Is(err, ErrLockLost) {
+		return err
+	}
+	return nil
+}
+
+// Close прекращает участие в выборах и освобождает блокировку
+func (e *Elector) Close(ctx context.Context) error {
+	e.mu.Lock()
+	e.closed = true
+	e.mu.Unlock()
+
+	if !e.conf.Enabled {
+		e.cancel()
+		return nil
+	}
+	return e.Resign(ctx)
+}
+
+// метод проверки, закрыт ли Elector
+func (e *Elector) isClosed() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.closed
+}
+
+// IsLeader - является ли экземпляр лидером сейчас
+func (e *Elector) IsLeader() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if !e.conf.Enabled {
+		return !e.closed
+	}
+	return e.lock != nil
+}
+
+// Fence возвращает fencing token текущего лидерства (false - не лидер).
+// При выключенном выборе токен всегда 0
+func (e *Elector) Fence() (int64, bool) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if !e.conf.Enabled {
+		return 0, !e.closed
+	}
+	if e.lock == nil {
+		return 0, false
+	}
+	return e.lock.Fence(), true
+}
+
+// Do выполняет fn, только если экземпляр - лидер (иначе ErrNotLeader).
+// Контекст fn отменяется при потере лидерства, fence - токен, которым fn помечает свои записи
+func (e *Elector) Do(ctx context.Context, fn func(ctx context.Context, fence int64) error) error {
+	e.mu.RLock()
+	leader := e.lock != nil || (!e.conf.Enabled && !e.closed)
+	var fence int64
+	if e.lock != nil {
+		fence = e.lock.Fence()
+	}
+	leaderCtx := e.leaderCtx
+	e.mu.RUnlock()
+
+	if !leader {
+		return ErrNotLeader
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stop := context.AfterFunc(leaderCtx, cancel)
+	defer stop()
+
+	return fn(ctx, fence)
+}
@@ -0,0 +1,127 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"pkg/configs"
+	"testing"
+	"time"
+)
+
+func testElectionConfig() *configs.LeaderElectionConfig {
+	return &configs.LeaderElectionConfig{
+		Enabled:       true,
+		Name:          "scheduler",
+		TTL:           200 * time.Millisecond,
+		RenewInterval: 20 * time.Millisecond,
+		RetryInterval: 20 * time.Millisecond,
+	}
+}
+
+// функция ожидания условия (выборы идут в фоне)
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("не дождались: %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestElector(t *testing.T) {
+	t.Run("лидер только один, после ухода лидерство переходит с большим fence", func(t *testing.T) {
+		locker, _ := newTestLocker(t)
+		first, _ := NewElector(locker, testElectionConfig())
+		second, _ := NewElector(locker, testElectionConfig())
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go first.Run(ctx)
+		waitFor(t, "первый стал лидером", first.IsLeader)
+		go second.Run(ctx)
+
+		time.Sleep(60 * time.Millisecond)
+		if second.IsLeader() {
+			t.Fatal("два лидера одновременно")
+		}
+		firstFence, _ := first.Fence()
+
+		if err := first.Close(context.Background()); err != nil {
+			t.Fatalf("Close: %v", err)
+		}
+		waitFor(t, "второй стал лидером", second.IsLeader)
+
+		secondFence, ok := second.Fence()
+		if !ok || secondFence <= firstFence {
+			t.Errorf("fence нового лидера %d, предыдущего %d", secondFence, firstFence)
+		}
+		if first.IsLeader() {
+			t.Error("закрытый экземпляр остался лидером")
+		}
+	})
+
+	t.Run("при потере блокировки контекст задачи лидера отменяется", func(t *testing.T) {
+		locker, cache := newTestLocker(t)
+		elector, _ := NewElector(locker, testElectionConfig())
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go elector.Run(ctx)
+		waitFor(t, "стал лидером", elector.IsLeader)
+
+		err := elector.Do(ctx, func(jobCtx context.Context, fence int64) error {
+			if fence <= 0 {
+				t.Errorf("fence %d", fence)
+			}
+			// блокировку забрали (например, истекла во время паузы процесса)
+			cache.Delete(context.Background(), "test:lock:scheduler")
+			<-jobCtx.Done()
+			return jobCtx.Err()
+		})
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("Do: ожидали отмену, получили %v", err)
+		}
+		if elector.IsLeader() {
+			// лидерство может быть сразу захвачено снова - важно лишь, что с новым fence
+			if fence, _ := elector.Fence(); fence <= 1 {
+				t.Errorf("повторное лидерство со старым fence %d", fence)
+			}
+		}
+	})
+
+	t.Run("не лидер не выполняет задачу", func(t *testing.T) {
+		locker, _ := newTestLocker(t)
+		locker.TryAcquire(context.Background(), "scheduler", time.Minute)
+		elector, _ := NewElector(locker, testElectionConfig())
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go elector.Run(ctx)
+		time.Sleep(40 * time.Millisecond)
+
+		called := false
+		err := elector.Do(ctx, func(context.Context, int64) error { called = true; return nil })
+		if !errors.Is(err, ErrNotLeader) || called {
+			t.Errorf("Do: %v, вызвана: %v", err, called)
+		}
+	})
+
+	t.Run("выключенный выбор - всегда лидер", func(t *testing.T) {
+		elector, err := NewElector(nil, &configs.LeaderElectionConfig{Enabled: false})
+		if err != nil {
+			t.Fatalf("NewElector: %v", err)
+		}
+		if !elector.IsLeader() {
+			t.Fatal("не лидер")
+		}
+		if err := elector.Do(context.Background(), func(context.Context, int64) error { return nil }); err != nil {
+			t.Errorf("Do: %v", err)
+		}
+		elector.Close(context.Background())
+		if elector.IsLeader() {
+			t.Error("лидер после Close")
+		}
+	})
+}
@@ -0,0 +1,166 @@
+// Пакет lock - распределённые блокировки поверх global_cache.Cache (SET NX PX в Redis)
+// и выбор лидера среди экземпляров сервиса для фоновых задач, которые должны выполняться в одном экземпляре.
+//
+// Блокировка не гарантирует взаимоисключение при паузах процесса (GC, сеть): её время жизни может
+// истечь, пока владелец ещё работает. Поэтому каждая блокировка несёт fencing token - число, которое
+// растёт с каждым захватом. Хранилище, в которое пишет владелец, должно отвергать записи
+// с токеном меньше уже виденного
+package lock
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"global_models/global_cache"
+	mrand "math/rand/v2"
+	"strconv"
+	"time"
+)
+
+// ErrNotAcquired - блокировка занята другим владельцем (или не освободилась за время ожидания)
+var ErrNotAcquired = errors.New("lock: not acquired")
+
+// ErrLockLost - блокировка больше не принадлежит владельцу (истекло время жизни или её захватил другой)
+var ErrLockLost = errors.New("lock: lost")
+
+// интервалы повторных попыток захвата при ожидании
+const (
+	minRetryBackoff = 10 * time.Millisecond
+	maxRetryBackoff = 250 * time.Millisecond
+)
+
+// Locker выдаёт блокировки с общим префиксом ключей
+type Locker struct {
+	cache  global_cache.Cache
+	prefix string
+}
+
+// конструктор для Locker (prefix отделяет ключи блокировок от остальных данных кэша)
+func NewLocker(cache global_cache.Cache, prefix string) (*Locker, error) {
+	if cache == nil {
+		return nil, errors.New("cache is nil")
+	}
+	return &Locker{cache: cache, prefix: prefix}, nil
+}
+
+// метод формирования ключа блокировки
+func (l *Locker) key(name string) string {
+	return l.prefix + ":lock:" + name
+}
+
+// TryAcquire делает одну попытку захвата: ErrNotAcquired - блокировка занята
+func (l *Locker) TryAcquire(ctx context.Context, name string, ttl time.Duration) (*Lock, error) {
+	if ttl <= 0 {
+		return nil, fmt.Errorf("lock %s: ttl must be positive", name)
+	}
+	key := l.key(name)
+
+	suffix, err := newSuffix()
+	if err != nil {
+		return nil, fmt.Errorf("lock %s: %w", name, err)
+	}
+
+	// захват и выдача fencing token - одна атомарная операция: номер тратится только при захвате,
+	// поэтому следующий владелец всегда получает больший (счётчик хранится без срока)
+	fence, err := l.cache.SetNXFenced(ctx, key, key+":fence", suffix, ttl)
+	if err != nil {
+		return nil, fmt.Errorf("lock %s: %w", name, err)
+	}
+	if fence == 0 {
+		return nil, ErrNotAcquired
+	}
+	value := []byte(strconv.FormatInt(fence, 10) + "." + string(suffix))
+
+	return &Lock{cache: l.cache, name: name, key: key, value: value, fence: fence}, nil
+}
+
+// Acquire ждёт блокировку не дольше wait (wait <= 0 - одна попытка).
+// ErrNotAcquired - блокировка не освободилась за wait, ошибка контекста - ожидание прервано
+func (l *Locker) Acquire(ctx context.Context, name string, ttl, wait time.Duration) (*Lock, error) {
+	deadline := time.Now().Add(wait)
+	backoff := minRetryBackoff
+
+	for {
+		lock, err := l.TryAcquire(ctx, name, ttl)
+		if !errors.Is(err, ErrNotAcquired) {
+			return lock, err
+		}
+
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return nil, ErrNotAcquired
+		}
+
+		// ждём со случайным разбросом, чтобы ожидающие не приходили одновременно
+		sleep := min(backoff/2+mrand.N(backoff/2+1), remaining)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(sleep):
+		}
+		backoff = min(backoff*2, maxRetryBackoff)
+	}
+}
+
+// функция для формирования случайной части значения блокировки ("<fencing token>.<случайная часть>").
+// Случайная часть отличает владельцев, даже если счётчик токенов был сброшен
+func newSuffix() ([]byte, error) {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return nil, fmt.Errorf("random token: %w", err)
+	}
+	return []byte(hex.EncodeToString(random)), nil
+}
+
+// Lock - захваченная блокировка. Снять или продлить её может только владелец
+type Lock struct {
+	cache global_cache.Cache
+	name  string
+	key   string
+	value []byte
+	fence int64
+}
+
+// метод возвращает имя блокировки
+func (l *Lock) Name() string {
+	return l.name
+}
+
+// метод возвращает значение ключа блокировки (уникально для каждого захвата)
+func (l *Lock) Token() string {
+	return string(l.value)
+}
+
+// метод возвращает fencing token - номер захвата, который растёт с каждым новым владельцем
+func (l *Lock) Fence() int64 {
+	return l.fence
+}
+
+// Refresh продлевает блокировку на ttl. ErrLockLost - блокировка уже не наша
+func (l *Lock) Refresh(ctx context.Context, ttl time.Duration) error {
+	if ttl <= 0 {
+		return fmt.Errorf("lock %s: ttl must be positive", l.name)
+	}
+	ok, err := l.cache.CompareAndExpire(ctx, l.key, l.value, ttl)
+	if err != nil {
+		return fmt.Errorf("lock %s: %w", l.name, err)
+	}
+	if !ok {
+		return ErrLockLost
+	}
+	return nil
+}
+
+// Release снимает блокировку. ErrLockLost - блокировка уже не наша (чужую не снимаем)
+func (l *Lock) Release(ctx context.Context) error {
+	ok, err := l.cache.CompareAndDelete(ctx, l.key, l.value)
+	if err != nil {
+		return fmt.Errorf("lock %s: %w", l.name, err)
+	}
+	if !ok {
+		return ErrLockLost
+	}
+	return nil
+}
@@ -0,0 +1,194 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"global_models/global_cache"
+	memorycache "pkg/memory_cache"
+	"sync"
+	"testing"
+	"time"
+)
+
+// interleavedCache вызывает before перед первым захватом - так соперник успевает вклиниться в чужую попытку
+type interleavedCache struct {
+	global_cache.Cache
+	once   sync.Once
+	before func()
+}
+
+func (c *interleavedCache) SetNXFenced(ctx context.Context, key, fenceKey string, suffix []byte, expiration time.Duration) (int64, error) {
+	c.once.Do(c.before)
+	return c.Cache.SetNXFenced(ctx, key, fenceKey, suffix, expiration)
+}
+
+func newTestLocker(t *testing.T) (*Locker, *memorycache.MemoryCache) {
+	t.Helper()
+	cache := memorycache.NewMemoryCache(0)
+	locker, err := NewLocker(cache, "test")
+	if err != nil {
+		t.Fatalf("NewLocker: %v", err)
+	}
+	return locker, cache
+}
+
+func TestLocker(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("занятую блокировку не захватить", func(t *testing.T) {
+		locker, _ := newTestLocker(t)
+		first, err := locker.TryAcquire(ctx, "job", time.Minute)
+		if err != nil {
+			t.Fatalf("первый захват: %v", err)
+		}
+		if _, err := locker.TryAcquire(ctx, "job", time.Minute); !errors.Is(err, ErrNotAcquired) {
+			t.Fatalf("второй захват: ожидали ErrNotAcquired, получили %v", err)
+		}
+		if err := first.Release(ctx); err != nil {
+			t.Fatalf("Release: %v", err)
+		}
+		if _, err := locker.TryAcquire(ctx, "job", time.Minute); err != nil {
+			t.Errorf("захват после Release: %v", err)
+		}
+	})
+
+	t.Run("fencing token растёт с каждым владельцем", func(t *testing.T) {
+		locker, _ := newTestLocker(t)
+		first, _ := locker.TryAcquire(ctx, "job", time.Minute)
+		first.Release(ctx)
+		second, err := locker.TryAcquire(ctx, "job", time.Minute)
+		if err != nil {
+			t.Fatalf("TryAcquire: %v", err)
+		}
+		if second.Fence() <= first.Fence() {
+			t.Errorf("fence %d не больше предыдущего %d", second.Fence(), first.Fence())
+		}
+		if second.Token() == first.Token() {
+			t.Error("токены владельцев совпали")
+		}
+	})
+
+	t.Run("соперник, вклинившийся в попытку захвата, получает меньший fence", func(t *testing.T) {
+		_, cache := newTestLocker(t)
+		var rival *Lock
+		interleaved := &interleavedCache{Cache: cache}
+		interleaved.before = func() {
+			locker, _ := NewLocker(cache, "test")
+			rival, _ = locker.TryAcquire(ctx, "job", time.Minute)
+			rival.Release(ctx)
+		}
+		locker, _ := NewLocker(interleaved, "test")
+
+		lock, err := locker.TryAcquire(ctx, "job", time.Minute)
+		if err != nil {
+			t.Fatalf("TryAcquire: %v", err)
+		}
+		if rival == nil || lock.Fence() <= rival.Fence() {
+			t.Errorf("fence %d не больше fence соперника, захватившего раньше", lock.Fence())
+		}
+	})
+
+	t.Run("fence растёт в порядке захватов при одновременных попытках", func(t *testing.T) {
+		locker, _ := newTestLocker(t)
+		var mu sync.Mutex
+		var fences []int64
+
+		var wg sync.WaitGroup
+		for range 8 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for range 50 {
+					lock, err := locker.TryAcquire(ctx, "job", time.Minute)
+					if err != nil {
+						continue
+					}
+					// пока блокировка наша, порядок записи совпадает с порядком захватов
+					mu.Lock()
+					fences = append(fences, lock.Fence())
+					mu.Unlock()
+					lock.Release(ctx)
+				}
+			}()
+		}
+		wg.Wait()
+
+		for i := 1; i < len(fences); i++ {
+			if fences[i] <= fences[i-1] {
+				t.Fatalf("fence %d после %d: порядок захватов нарушен", fences[i], fences[i-1])
+			}
+		}
+		if len(fences) > 0 && fences[len(fences)-1] != int64(len(fences)) {
+			t.Errorf("неудачные попытки потратили номера: %d захватов, последний fence %d", len(fences), fences[len(fences)-1])
+		}
+	})
+
+	t.Run("истёкшую блокировку не снять и не продлить", func(t *testing.T) {
+		locker, _ := newTestLocker(t)
+		stale, _ := locker.TryAcquire(ctx, "job", 20*time.Millisecond)
+		time.Sleep(40 * time.Millisecond)
+
+		fresh, err := locker.TryAcquire(ctx, "job", time.Minute)
+		if err != nil {
+			t.Fatalf("захват истёкшей блокировки: %v", err)
+		}
+		if err := stale.Refresh(ctx, time.Minute); !errors.Is(err, ErrLockLost) {
+			t.Errorf("Refresh чужой блокировки: ожидали ErrLockLost, получили %v", err)
+		}
+		if err := stale.Release(ctx); !errors.Is(err, ErrLockLost) {
+			t.Errorf("Release чужой блокировки: ожидали ErrLockLost, получили %v", err)
+		}
+		if err := fresh.Refresh(ctx, time.Minute); err != nil {
+			t.Errorf("блокировка нового владельца снята: %v", err)
+		}
+	})
+
+	t.Run("Refresh продлевает блокировку", func(t *testing.T) {
+		locker, cache := newTestLocker(t)
+		lock, _ := locker.TryAcquire(ctx, "job", 50*time.Millisecond)
+		if err := lock.Refresh(ctx, time.Minute); err != nil {
+			t.Fatalf("Refresh: %v", err)
+		}
+		if ttl, _ := cache.TTL(ctx, "test:lock:job"); ttl <= 50*time.Millisecond {
+			t.Errorf("TTL после Refresh: %v", ttl)
+		}
+	})
+
+	t.Run("Acquire дожидается освобождения", func(t *testing.T) {
+		locker, _ := newTestLocker(t)
+		held, _ := locker.TryAcquire(ctx, "job", time.Minute)
+		time.AfterFunc(30*time.Millisecond, func() { held.Release(ctx) })
+
+		lock, err := locker.Acquire(ctx, "job", time.Minute, time.Second)
+		if err != nil {
+			t.Fatalf("Acquire: %v", err)
+		}
+		if lock.Fence() <= held.Fence() {
+			t.Errorf("fence %d не больше предыдущего %d", lock.Fence(), held.Fence())
+		}
+	})
+
+	t.Run("Acquire по истечении ожидания - ErrNotAcquired", func(t *testing.T) {
+		locker, _ := newTestLocker(t)
+		locker.TryAcquire(ctx, "job", time.Minute)
+
+		started := time.Now()
+		if _, err := locker.Acquire(ctx, "job", time.Minute, 50*time.Millisecond); !errors.Is(err, ErrNotAcquired) {
+			t.Fatalf("ожидали ErrNotAcquired, получили %v", err)
+		}
+		if waited := time.Since(started); waited < 50*time.Millisecond || waited > time.Second {
+			t.Errorf("ожидание %v, ожидали около 50ms", waited)
+		}
+	})
+
+	t.Run("Acquire прерывается отменой контекста", func(t *testing.T) {
+		locker, _ := newTestLocker(t)
+		locker.TryAcquire(ctx, "job", time.Minute)
+
+		cancelCtx, cancel := context.WithTimeout(ctx, 30*time.Millisecond)
+		defer cancel()
+		if _, err := locker.Acquire(cancelCtx, "job", time.Minute, time.Minute); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("ожидали DeadlineExceeded, получили %v", err)
+		}
+	})
+}
@@ -4,7 +4,7 @@ import (
 	"context"
 	"fmt"
 	"global_models/global_cache"
-	"log"
+	"log/slog"
 	"pkg/configs"
 
 	"github.com/go-redis/redis/v8"
@@ -15,6 +15,18 @@ type RedisRepository struct {
 }
 
 func NewRedisCacheRepository(cfg *configs.RedisConfig) (global_cache.Cache, error) {
+	client, err := NewClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// возвращаем результат работы конструктора адаптера
+	return NewCacheAdapter(client), nil
+}
+
+// NewClient создаёт клиента redis по конфигу и проверяет подключение.
+// Отдельный клиент нужен тем, кто держит соединение в блокирующих командах (очередь задач на streams)
+func NewClient(cfg *configs.RedisConfig) (*redis.Client, error) {
 	// проверяем, что конфиг редиса не nil
 	if cfg == nil {
 		return nil, fmt.Errorf("Error in redis config")
@@ -35,8 +47,6 @@ func NewRedisCacheRepository(cfg *configs.RedisConfig) (global_cache.Cache, erro
 		return nil, fmt.Errorf("redis connection failed: %w", err)
 	}
 
-	log.Printf("Connected to Redis at %s (DB: %d)", redisOptions.Addr, redisOptions.DB)
-
-	// возвращаем результат работы конструктора адаптера
-	return NewCacheAdapter(client), nil
+	slog.Info("connected to redis", "addr", redisOptions.Addr, "db", redisOptions.DB)
+	return client, nil
 }
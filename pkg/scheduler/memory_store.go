@@ -0,0 +1,244 @@
+package scheduler
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// проверка реализации интерфейса
+var _ Store = (*MemoryStore)(nil)
+
+// MemoryStore - хранилище задач в памяти (для тестов без Postgres)
+type MemoryStore struct {
+	mu        sync.Mutex
+	jobs      map[string]*Job
+	runs      []*Run
+	nextRunID int64
+}
+
+// конструктор для хранилища в памяти
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{jobs: make(map[string]*Job)}
+}
+
+// функция копирования задачи (наружу не отдаются внутренние указатели)
+func copyJob(job *Job) *Job {
+	c := *job
+	c.Payload = slices.Clone(job.Payload)
+	if job.NextRunAt != nil {
+		next := *job.NextRunAt
+		c.NextRunAt = &next
+	}
+	if job.LastRunAt != nil {
+		last := *job.LastRunAt
+		c.LastRunAt = &last
+	}
+	return &c
+}
+
+// функция копирования записи истории
+func copyRun(run *Run) *Run {
+	c := *run
+	if run.FinishedAt != nil {
+		finished := *run.FinishedAt
+		c.FinishedAt = &finished
+	}
+	return &c
+}
+
+// метод создания или обновления задачи
+func (s *MemoryStore) UpsertJob(ctx context.Context, job *Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	existing, ok := s.jobs[job.Name]
+	if !ok {
+		stored := copyJob(job)
+		stored.Paused, stored.Triggered, stored.LastRunAt, stored.LastStatus, stored.LastFence = false, false, nil, "", 0
+		stored.Version = 1
+		stored.CreatedAt, stored.UpdatedAt = now, now
+		s.jobs[job.Name] = stored
+		*job = *copyJob(stored)
+		return nil
+	}
+
+	reschedule := job.Kind == KindOnce || existing.Kind != job.Kind || existing.NextRunAt == nil ||
+		existing.Spec != job.Spec || existing.Timezone != job.Timezone
+	existing.Kind, existing.Handler, existing.Spec, existing.Timezone = job.Kind, job.Handler, job.Spec, job.Timezone
+	existing.Payload = slices.Clone(job.Payload)
+	if reschedule {
+		existing.NextRunAt = copyJob(job).NextRunAt
+	}
+	existing.Version++
+	existing.UpdatedAt = now
+	*job = *copyJob(existing)
+	return nil
+}
+
+// метод получения задачи
+func (s *MemoryStore) GetJob(ctx context.Context, name string) (*Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[name]
+	if !ok {
+		return nil, ErrJobNotFound
+	}
+	return copyJob(job), nil
+}
+
+// метод получения всех задач
+func (s *MemoryStore) ListJobs(ctx context.Context) ([]*Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	jobs := make([]*Job, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		jobs = append(jobs, copyJob(job))
+	}
+	slices.SortFunc(jobs, func(a, b *Job) int { return strings.Compare(a.Name, b.Name) })
+	return jobs, nil
+}
+
+// метод получения задач, которые пора запустить (сначала самые просроченные)
+func (s *MemoryStore) DueJobs(ctx context.Context, now time.Time, limit int) ([]*Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var due []*Job
+	for _, job := range s.jobs {
+		if job.Triggered || (!job.Paused && job.NextRunAt != nil && !job.NextRunAt.After(now)) {
+			due = append(due, copyJob(job))
+		}
+	}
+	slices.SortFunc(due, func(a, b *Job) int { return dueAt(a, now).Compare(dueAt(b, now)) })
+	if limit > 0 && len(due) > limit {
+		due = due[:limit]
+	}
+	return due, nil
+}
+
+// функция времени, с которого задача ждёт запуска (запрошенный запуск - без ожидания)
+func dueAt(job *Job, now time.Time) time.Time {
+	if job.NextRunAt != nil && job.NextRunAt.Before(now) {
+		return *job.NextRunAt
+	}
+	return now
+}
+
+// метод отметки запуска
+func (s *MemoryStore) ClaimRun(ctx context.Context, job *Job, next *time.Time, run *Run) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.jobs[job.Name]
+	if !ok || stored.Version != job.Version {
+		return false, nil
+	}
+
+	if next != nil {
+		n := *next
+		stored.NextRunAt = &n
+	} else {
+		stored.NextRunAt = nil
+	}
+	started := run.StartedAt
+	stored.Triggered = false
+	stored.LastRunAt = &started
+	stored.LastFence = run.Fence
+	stored.Version++
+	stored.UpdatedAt = time.Now()
+
+	s.nextRunID++
+	run.ID = s.nextRunID
+	s.runs = append(s.runs, copyRun(run))
+	return true, nil
+}
+
+// метод сохранения результата запуска
+func (s *MemoryStore) FinishRun(ctx context.Context, run *Run) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, stored := range s.runs {
+		if stored.ID == run.ID {
+			s.runs[i] = copyRun(run)
+			break
+		}
+	}
+	if job, ok := s.jobs[run.JobName]; ok {
+		job.LastStatus = run.Status
+	}
+	return nil
+}
+
+// метод постановки задачи на паузу
+func (s *MemoryStore) SetPaused(ctx context.Context, name string, paused bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[name]
+	if !ok {
+		return ErrJobNotFound
+	}
+	job.Paused = paused
+	job.Version++
+	job.UpdatedAt = time.Now()
+	return nil
+}
+
+// метод запроса запуска вне расписания
+func (s *MemoryStore) Trigger(ctx context.Context, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[name]
+	if !ok {
+		return ErrJobNotFound
+	}
+	job.Triggered = true
+	job.Version++
+	job.UpdatedAt = time.Now()
+	return nil
+}
+
+// метод удаления задачи вместе с историей
+func (s *MemoryStore) DeleteJob(ctx context.Context, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.jobs[name]; !ok {
+		return ErrJobNotFound
+	}
+	delete(s.jobs, name)
+	s.runs = slices.DeleteFunc(s.runs, func(run *Run) bool { return run.JobName == name })
+	return nil
+}
+
+// метод получения последних запусков задачи
+func (s *MemoryStore) Runs(ctx context.Context, name string, limit int) ([]*Run, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var runs []*Run
+	for i := len(s.runs) - 1; i >= 0 && (limit <= 0 || len(runs) < limit); i-- {
+		if s.runs[i].JobName == name {
+			runs = append(runs, copyRun(s.runs[i]))
+		}
+	}
+	return runs, nil
+}
+
+// метод удаления старой истории запусков
+func (s *MemoryStore) DeleteRunsBefore(ctx context.Context, before time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := len(s.runs)
+	s.runs = slices.DeleteFunc(s.runs, func(run *Run) bool { return run.StartedAt.Before(before) })
+	return int64(n - len(s.runs)), nil
+}
@@ -0,0 +1,436 @@
+// Пакет scheduler - планировщик периодических задач (cron выражения) и таймеров (один запуск в заданное время).
+//
+// Задачи и история запусков хранятся в Store (в сервере - Postgres), поэтому переживают перезапуск.
+// Запускает задачи только лидер (Leader - выбор лидера через redis): каждый запуск отмечается в хранилище
+// с проверкой версии задачи, поэтому задача не выполняется дважды, даже если бывший лидер ещё не заметил
+// потерю лидерства (fencing token лидера сохраняется в истории запусков).
+// Пропущенные за время простоя запуски не догоняются: просроченная задача выполняется один раз,
+// следующий запуск считается от текущего времени
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"pkg/configs"
+	"pkg/metrics"
+	"pkg/redis/lock"
+	"sync"
+	"time"
+	_ "time/tzdata" // часовые пояса задач не зависят от наличия tzdata в образе
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/robfig/cron/v3"
+)
+
+// CleanupHistoryHandler - встроенный обработчик удаления истории запусков старше HistoryRetention
+const CleanupHistoryHandler = "scheduler.cleanup_history"
+
+// метрики планировщика (job - имя задачи)
+var (
+	runsTotal = metrics.MustRegister(prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "scheduler_runs_total",
+		Help: "Scheduler job runs by status: ok, failed.",
+	}, []string{"job", "status"}))
+
+	runDuration = metrics.MustRegister(prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "scheduler_run_duration_seconds",
+		Help:    "Duration of a single scheduler job run.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"job"}))
+)
+
+// Handler выполняет задачу (job.Payload - данные, заданные при создании задачи)
+type Handler func(ctx context.Context, job *Job) error
+
+// Leader - выбор лидера (lock.Elector): fn выполняется, только пока экземпляр лидер,
+// ctx отменяется при потере лидерства. Если не лидер - lock.ErrNotLeader
+type Leader interface {
+	Do(ctx context.Context, fn func(ctx context.Context, fence int64) error) error
+}
+
+// Scheduler - планировщик задач
+type Scheduler struct {
+	store  Store
+	leader Leader
+	conf   *configs.SchedulerConfig
+
+	mu       sync.RWMutex
+	handlers map[string]Handler
+
+	runningMu sync.Mutex
+	running   map[string]bool // задачи, выполняемые сейчас этим экземпляром
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{} // закрывается, когда Run завершился (вместе с запусками)
+	started  sync.Once
+}
+
+// конструктор для Scheduler
+func New(store Store, leader Leader, conf *configs.SchedulerConfig) (*Scheduler, error) {
+	if store == nil || leader == nil {
+		return nil, errors.New("scheduler: store and leader are required")
+	}
+	if conf == nil {
+		conf = configs.UseDefaultSchedulerConfig()
+	}
+	if conf.TickInterval <= 0 || conf.JobTimeout <= 0 || conf.BatchSize <= 0 {
+		return nil, errors.New("scheduler: tick_interval, job_timeout and batch_size must be positive")
+	}
+	if _, err := time.LoadLocation(conf.Timezone); err != nil {
+		return nil, fmt.Errorf("scheduler: timezone: %w", err)
+	}
+
+	s := &Scheduler{
+		store:    store,
+		leader:   leader,
+		conf:     conf,
+		handlers: make(map[string]Handler),
+		running:  make(map[string]bool),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	s.Register(CleanupHistoryHandler, s.cleanupHistory)
+	return s, nil
+}
+
+// Register регистрирует обработчик (до Run). Задачи ссылаются на обработчик по имени
+func (s *Scheduler) Register(name string, handler Handler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[name] = handler
+}
+
+// метод возвращает обработчик по имени
+func (s *Scheduler) handler(name string) (Handler, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	handler, ok := s.handlers[name]
+	return handler, ok
+}
+
+// AddCron создаёт или обновляет периодическую задачу. spec - cron выражение из 5 полей
+// или @daily/@hourly/@every 10m, timezone - часовой пояс расписания (пустой - из конфига).
+// Вызывается при старте каждого экземпляра: пауза и история задачи сохраняются
+func (s *Scheduler) AddCron(ctx context.Context, name, handler, spec, timezone string, payload any) error {
+	if timezone == "" {
+		timezone = s.conf.Timezone
+	}
+	next, err := nextRun(spec, timezone, time.Now())
+	if err != nil {
+		return fmt.Errorf("scheduler: job %s: %w", name, err)
+	}
+	job, err := s.newJob(name, handler, payload)
+	if err != nil {
+		return err
+	}
+	job.Kind, job.Spec, job.Timezone, job.NextRunAt = KindCron, spec, timezone, &next
+	return s.store.UpsertJob(ctx, job)
+}
+
+// AddTimer создаёт таймер: задача выполнится один раз в runAt (повторный вызов с тем же именем переносит таймер)
+func (s *Scheduler) AddTimer(ctx context.Context, name, handler string, runAt time.Time, payload any) error {
+	job, err := s.newJob(name, handler, payload)
+	if err != nil {
+		return err
+	}
+	runAt = runAt.UTC()
+	job.Kind, job.Timezone, job.NextRunAt = KindOnce, s.conf.Timezone, &runAt
+	return s.store.UpsertJob(ctx, job)
+}
+
+// метод создания определения задачи
+func (s *Scheduler) newJob(name, handler string, payload any) (*Job, error) {
+	if name == "" {
+		return nil, errors.New("scheduler: job name is empty")
+	}
+	if _, ok := s.handler(handler); !ok {
+		return nil, fmt.Errorf("scheduler: job %s: handler %q is not registered", name, handler)
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("scheduler: job %s payload: %w", name, err)
+	}
+	return &Job{Name: name, Handler: handler, Payload: data}, nil
+}
+
+// функция расчёта следующего запуска по cron выражению в часовом поясе задачи
+func nextRun(spec, timezone string, after time.Time) (time.Time, error) {
+	schedule, err := cron.ParseStandard(spec)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("bad cron spec %q: %w", spec, err)
+	}
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("bad timezone %q: %w", timezone, err)
+	}
+	next := schedule.Next(after.In(location))
+	if next.IsZero() {
+		return time.Time{}, fmt.Errorf("cron spec %q never fires", spec)
+	}
+	return next.UTC(), nil
+}
+
+// List возвращает все задачи
+func (s *Scheduler) List(ctx context.Context) ([]*Job, error) {
+	return s.store.ListJobs(ctx)
+}
+
+// Get возвращает задачу или ErrJobNotFound
+func (s *Scheduler) Get(ctx context.Context, name string) (*Job, error) {
+	return s.store.GetJob(ctx, name)
+}
+
+// Pause ставит задачу на паузу: по расписанию она не запускается, ручной запуск работает
+func (s *Scheduler) Pause(ctx context.Context, name string) error {
+	return s.store.SetPaused(ctx, name, true)
+}
+
+// Resume снимает задачу с паузы (просроченный за время паузы запуск выполнится сразу)
+func (s *Scheduler) Resume(ctx context.Context, name string) error {
+	return s.store.SetPaused(ctx, name, false)
+}
+
+// Trigger запрашивает запуск задачи вне расписания: выполнит лидер при следующей проверке,
+// поэтому вызывать можно на любом экземпляре
+func (s *Scheduler) Trigger(ctx context.Context, name string) error {
+	return s.store.Trigger(ctx, name)
+}
+
+// Remove удаляет задачу вместе с историей
+func (s *Scheduler) Remove(ctx context.Context, name string) error {
+	return s.store.DeleteJob(ctx, name)
+}
+
+// History возвращает последние запуски задачи (ErrJobNotFound - задачи нет)
+func (s *Scheduler) History(ctx context.Context, name string, limit int) ([]*Run, error) {
+	if _, err := s.store.GetJob(ctx, name); err != nil {
+		return nil, err
+	}
+	return s.store.Runs(ctx, name, limit)
+}
+
+// Run запускает задачи, пока экземпляр лидер, до отмены ctx или Close.
+// Остальные экземпляры раз в TickInterval пробуют стать лидером
+func (s *Scheduler) Run(ctx context.Context) {
+	s.started.Do(func() { s.run(ctx) })
+}
+
+// метод цикла планировщика
+func (s *Scheduler) run(ctx context.Context) {
+	defer close(s.done)
+	if !s.conf.Enabled {
+		slog.Warn("scheduler is disabled, jobs are not run")
+		return
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-s.stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	ticker := time.NewTicker(s.conf.TickInterval)
+	defer ticker.Stop()
+
+	for {
+		// пока экземпляр лидер, lead не возвращается
+		err := s.leader.Do(ctx, s.lead)
+		if err != nil && !errors.Is(err, lock.ErrNotLeader) && ctx.Err() == nil {
+			slog.Warn("scheduler: leadership lost", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// метод работы лидера: проверяет задачи раз в TickInterval, пока не отменён ctx (потеря лидерства или остановка).
+// Перед возвратом дожидается запущенных задач (их ctx отменён вместе с лидерством)
+func (s *Scheduler) lead(ctx context.Context, fence int64) error {
+	slog.Info("scheduler: running jobs as leader", "fence", fence)
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	ticker := time.NewTicker(s.conf.TickInterval)
+	defer ticker.Stop()
+
+	for {
+		s.dispatch(ctx, fence, &wg)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// метод запуска задач, время которых пришло (каждая - в своей горутине)
+func (s *Scheduler) dispatch(ctx context.Context, fence int64, wg *sync.WaitGroup) {
+	now := time.Now()
+	jobs, err := s.store.DueJobs(ctx, now, s.conf.BatchSize)
+	if err != nil {
+		if ctx.Err() == nil {
+			slog.Warn("scheduler: failed to load due jobs", "error", err)
+		}
+		return
+	}
+
+	for _, job := range jobs {
+		// предыдущий запуск ещё выполняется - задача остаётся просроченной до его завершения
+		if !s.markRunning(job.Name) {
+			continue
+		}
+
+		run, ok := s.claim(ctx, job, fence, now)
+		if !ok {
+			s.unmarkRunning(job.Name)
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer s.unmarkRunning(job.Name)
+			s.execute(ctx, job, run)
+		}()
+	}
+}
+
+// метод отметки запуска в хранилище. false - задачу изменили или уже запустил другой экземпляр
+func (s *Scheduler) claim(ctx context.Context, job *Job, fence int64, now time.Time) (*Run, bool) {
+	run := &Run{
+		JobName:     job.Name,
+		Trigger:     TriggerSchedule,
+		Fence:       fence,
+		ScheduledAt: now,
+		StartedAt:   now,
+		Status:      RunRunning,
+	}
+
+	// запуск по расписанию сдвигает расписание, ручной запуск (до времени по расписанию) - нет
+	next := job.NextRunAt
+	scheduled := !job.Paused && job.NextRunAt != nil && !job.NextRunAt.After(now)
+	if scheduled {
+		run.ScheduledAt = *job.NextRunAt
+		next = nil
+		if job.Kind == KindCron {
+			n, err := nextRun(job.Spec, job.Timezone, now)
+			if err != nil {
+				slog.Error("scheduler: bad job schedule", "job", job.Name, "error", err)
+				return nil, false
+			}
+			next = &n
+		}
+	} else {
+		run.Trigger = TriggerManual
+	}
+
+	ok, err := s.store.ClaimRun(ctx, job, next, run)
+	if err != nil {
+		if ctx.Err() == nil {
+			slog.Warn("scheduler: failed to claim job", "job", job.Name, "error", err)
+		}
+		return nil, false
+	}
+	return run, ok
+}
+
+// метод выполнения задачи и записи результата в историю
+func (s *Scheduler) execute(ctx context.Context, job *Job, run *Run) {
+	err := s.call(ctx, job)
+
+	finished := time.Now()
+	run.FinishedAt = &finished
+	run.Status = RunOK
+	if err != nil {
+		run.Status = RunFailed
+		run.Error = err.Error()
+		slog.Error("scheduler job failed", "job", job.Name, "trigger", run.Trigger, "error", err)
+	} else {
+		slog.Info("scheduler job done", "job", job.Name, "trigger", run.Trigger, "duration", finished.Sub(run.StartedAt))
+	}
+	runsTotal.WithLabelValues(job.Name, string(run.Status)).Inc()
+	runDuration.WithLabelValues(job.Name).Observe(finished.Sub(run.StartedAt).Seconds())
+
+	// результат записывается и после потери лидерства (иначе запуск навсегда останется running)
+	saveCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+	if err := s.store.FinishRun(saveCtx, run); err != nil {
+		slog.Warn("scheduler: failed to save run result", "job", job.Name, "error", err)
+	}
+}
+
+// метод вызова обработчика задачи (с таймаутом и защитой от паники)
+func (s *Scheduler) call(ctx context.Context, job *Job) (err error) {
+	handler, ok := s.handler(job.Handler)
+	if !ok {
+		return fmt.Errorf("handler %q is not registered", job.Handler)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.conf.JobTimeout)
+	defer cancel()
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+	return handler(ctx, job)
+}
+
+// метод отметки, что задача выполняется этим экземпляром. false - уже выполняется
+func (s *Scheduler) markRunning(name string) bool {
+	s.runningMu.Lock()
+	defer s.runningMu.Unlock()
+	if s.running[name] {
+		return false
+	}
+	s.running[name] = true
+	return true
+}
+
+// метод снятия отметки о выполнении
+func (s *Scheduler) unmarkRunning(name string) {
+	s.runningMu.Lock()
+	defer s.runningMu.Unlock()
+	delete(s.running, name)
+}
+
+// встроенный обработчик: удаление истории запусков старше HistoryRetention
+func (s *Scheduler) cleanupHistory(ctx context.Context, job *Job) error {
+	deleted, err := s.store.DeleteRunsBefore(ctx, time.Now().Add(-s.conf.HistoryRetention))
+	if err != nil {
+		return err
+	}
+	slog.Info("scheduler: run history cleaned up", "deleted", deleted)
+	return nil
+}
+
+// Close останавливает планировщик и ждёт выполняемые задачи, пока не истечёт ctx
+// (задачи получают отмену контекста сразу)
+func (s *Scheduler) Close(ctx context.Context) error {
+	s.stopOnce.Do(func() { close(s.stop) })
+
+	// Run не запускался - ждать нечего
+	s.started.Do(func() { close(s.done) })
+
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,314 @@
+package scheduler_test
+
+import (
+	"context"
+	"errors"
+	"pkg/configs"
+	"pkg/redis/lock"
+	"pkg/scheduler"
+	"pkg/scheduler/schedulertest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreContract(t *testing.T) {
+	schedulertest.RunStoreContract(t, func(t *testing.T) scheduler.Store {
+		return scheduler.NewMemoryStore()
+	})
+}
+
+// fakeLeader - выбор лидера для тестов: лидер, пока leader = true
+type fakeLeader struct {
+	leader atomic.Bool
+	fence  int64
+}
+
+func newLeader(fence int64) *fakeLeader {
+	l := &fakeLeader{fence: fence}
+	l.leader.Store(true)
+	return l
+}
+
+func (l *fakeLeader) Do(ctx context.Context, fn func(ctx context.Context, fence int64) error) error {
+	if !l.leader.Load() {
+		return lock.ErrNotLeader
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		for ctx.Err() == nil && l.leader.Load() {
+			time.Sleep(time.Millisecond)
+		}
+		cancel()
+	}()
+	return fn(ctx, l.fence)
+}
+
+func testConfig() *configs.SchedulerConfig {
+	return &configs.SchedulerConfig{
+		Enabled:          true,
+		TickInterval:     10 * time.Millisecond,
+		JobTimeout:       time.Second,
+		Timezone:         "UTC",
+		BatchSize:        10,
+		HistoryRetention: time.Hour,
+	}
+}
+
+func newTestScheduler(t *testing.T, store scheduler.Store, leader scheduler.Leader) *scheduler.Scheduler {
+	t.Helper()
+	s, err := scheduler.New(store, leader, testConfig())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		s.Close(ctx)
+	})
+	return s
+}
+
+// функция ожидания условия (задачи выполняются в фоне)
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("не дождались: %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// функция ожидания завершённого запуска задачи
+func waitRun(t *testing.T, s *scheduler.Scheduler, name string, count int) []*scheduler.Run {
+	t.Helper()
+	var runs []*scheduler.Run
+	waitFor(t, "запуски "+name, func() bool {
+		runs, _ = s.History(context.Background(), name, 0)
+		return len(runs) >= count && runs[0].Status != scheduler.RunRunning
+	})
+	return runs
+}
+
+func TestScheduler(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("таймер срабатывает один раз в своё время", func(t *testing.T) {
+		s := newTestScheduler(t, scheduler.NewMemoryStore(), newLeader(3))
+		var calls atomic.Int64
+		var payload string
+		s.Register("remind", func(ctx context.Context, job *scheduler.Job) error {
+			payload = string(job.Payload)
+			calls.Add(1)
+			return nil
+		})
+		runAt := time.Now().Add(100 * time.Millisecond)
+		if err := s.AddTimer(ctx, "remind:42", "remind", runAt, map[string]int64{"chat_id": 42}); err != nil {
+			t.Fatalf("AddTimer: %v", err)
+		}
+		go s.Run(ctx)
+
+		runs := waitRun(t, s, "remind:42", 1)
+		if runs[0].StartedAt.Before(runAt) {
+			t.Errorf("таймер сработал раньше срока: %v < %v", runs[0].StartedAt, runAt)
+		}
+		if runs[0].Status != scheduler.RunOK || runs[0].Trigger != scheduler.TriggerSchedule || runs[0].Fence != 3 {
+			t.Errorf("запуск: %+v", runs[0])
+		}
+		if payload != `{"chat_id":42}` {
+			t.Errorf("payload: %s", payload)
+		}
+
+		time.Sleep(50 * time.Millisecond)
+		if calls.Load() != 1 {
+			t.Errorf("таймер сработал %d раз", calls.Load())
+		}
+		if job, _ := s.Get(ctx, "remind:42"); job.NextRunAt != nil || job.LastStatus != scheduler.RunOK {
+			t.Errorf("таймер после запуска: %+v", job)
+		}
+	})
+
+	t.Run("ручной запуск не сдвигает расписание и работает на паузе", func(t *testing.T) {
+		s := newTestScheduler(t, scheduler.NewMemoryStore(), newLeader(1))
+		s.Register("digest", func(context.Context, *scheduler.Job) error { return nil })
+		if err := s.AddCron(ctx, "digest", "digest", "0 9 * * *", "", nil); err != nil {
+			t.Fatalf("AddCron: %v", err)
+		}
+		before, _ := s.Get(ctx, "digest")
+		s.Pause(ctx, "digest")
+		go s.Run(ctx)
+
+		if err := s.Trigger(ctx, "digest"); err != nil {
+			t.Fatalf("Trigger: %v", err)
+		}
+		runs := waitRun(t, s, "digest", 1)
+		if runs[0].Trigger != scheduler.TriggerManual {
+			t.Errorf("причина запуска: %s", runs[0].Trigger)
+		}
+		after, _ := s.Get(ctx, "digest")
+		if !after.NextRunAt.Equal(*before.NextRunAt) || !after.Paused {
+			t.Errorf("после ручного запуска: next %v (был %v), пауза %v", after.NextRunAt, before.NextRunAt, after.Paused)
+		}
+
+		if err := s.Trigger(ctx, "missing"); !errors.Is(err, scheduler.ErrJobNotFound) {
+			t.Errorf("Trigger неизвестной задачи: %v", err)
+		}
+	})
+
+	t.Run("задача на паузе не запускается по расписанию", func(t *testing.T) {
+		store := scheduler.NewMemoryStore()
+		s := newTestScheduler(t, store, newLeader(1))
+		var calls atomic.Int64
+		s.Register("cleanup", func(context.Context, *scheduler.Job) error {
+			calls.Add(1)
+			return nil
+		})
+		s.AddTimer(ctx, "cleanup", "cleanup", time.Now(), nil)
+		s.Pause(ctx, "cleanup")
+		go s.Run(ctx)
+
+		time.Sleep(100 * time.Millisecond)
+		if calls.Load() != 0 {
+			t.Fatal("задача на паузе запустилась")
+		}
+		s.Resume(ctx, "cleanup")
+		waitFor(t, "запуск после снятия паузы", func() bool { return calls.Load() == 1 })
+	})
+
+	t.Run("не лидер задачи не запускает", func(t *testing.T) {
+		leader := newLeader(1)
+		leader.leader.Store(false)
+		s := newTestScheduler(t, scheduler.NewMemoryStore(), leader)
+		var calls atomic.Int64
+		s.Register("digest", func(context.Context, *scheduler.Job) error {
+			calls.Add(1)
+			return nil
+		})
+		s.AddTimer(ctx, "digest", "digest", time.Now(), nil)
+		go s.Run(ctx)
+
+		time.Sleep(100 * time.Millisecond)
+		if calls.Load() != 0 {
+			t.Fatal("задачу запустил не лидер")
+		}
+		leader.leader.Store(true)
+		waitFor(t, "запуск после получения лидерства", func() bool { return calls.Load() == 1 })
+	})
+
+	t.Run("потеря лидерства отменяет выполняемую задачу", func(t *testing.T) {
+		leader := newLeader(1)
+		s := newTestScheduler(t, scheduler.NewMemoryStore(), leader)
+		started := make(chan struct{})
+		s.Register("export", func(ctx context.Context, job *scheduler.Job) error {
+			close(started)
+			<-ctx.Done()
+			return ctx.Err()
+		})
+		s.AddTimer(ctx, "export", "export", time.Now(), nil)
+		go s.Run(ctx)
+
+		<-started
+		leader.leader.Store(false)
+		runs := waitRun(t, s, "export", 1)
+		if runs[0].Status != scheduler.RunFailed || runs[0].FinishedAt == nil {
+			t.Errorf("прерванный запуск: %+v", runs[0])
+		}
+	})
+
+	t.Run("два лидера не запускают задачу дважды", func(t *testing.T) {
+		// бывший лидер ещё не заметил потерю лидерства
+		store := scheduler.NewMemoryStore()
+		var calls atomic.Int64
+		handler := func(context.Context, *scheduler.Job) error {
+			calls.Add(1)
+			return nil
+		}
+		first := newTestScheduler(t, store, newLeader(1))
+		second := newTestScheduler(t, store, newLeader(2))
+		first.Register("digest", handler)
+		second.Register("digest", handler)
+		for i := range 20 {
+			first.AddTimer(ctx, "digest:"+string(rune('a'+i)), "digest", time.Now().Add(50*time.Millisecond), nil)
+		}
+		go first.Run(ctx)
+		go second.Run(ctx)
+
+		waitFor(t, "все таймеры", func() bool { return calls.Load() >= 20 })
+		time.Sleep(50 * time.Millisecond)
+		if got := calls.Load(); got != 20 {
+			t.Errorf("запусков: %d, ожидали 20", got)
+		}
+	})
+
+	t.Run("ошибка и паника задачи попадают в историю", func(t *testing.T) {
+		s := newTestScheduler(t, scheduler.NewMemoryStore(), newLeader(1))
+		s.Register("fail", func(context.Context, *scheduler.Job) error { return errors.New("smtp is down") })
+		s.Register("panic", func(context.Context, *scheduler.Job) error { panic("nil map") })
+		s.AddTimer(ctx, "fail", "fail", time.Now(), nil)
+		s.AddTimer(ctx, "panic", "panic", time.Now(), nil)
+		go s.Run(ctx)
+
+		if runs := waitRun(t, s, "fail", 1); runs[0].Status != scheduler.RunFailed || runs[0].Error != "smtp is down" {
+			t.Errorf("запуск с ошибкой: %+v", runs[0])
+		}
+		if runs := waitRun(t, s, "panic", 1); runs[0].Status != scheduler.RunFailed || runs[0].Error != "job panicked: nil map" {
+			t.Errorf("запуск с паникой: %+v", runs[0])
+		}
+	})
+
+	t.Run("неверное расписание и неизвестный обработчик отклоняются", func(t *testing.T) {
+		s := newTestScheduler(t, scheduler.NewMemoryStore(), newLeader(1))
+		s.Register("digest", func(context.Context, *scheduler.Job) error { return nil })
+
+		if err := s.AddCron(ctx, "digest", "digest", "61 * * * *", "", nil); err == nil {
+			t.Error("AddCron с неверным выражением: нет ошибки")
+		}
+		if err := s.AddCron(ctx, "digest", "digest", "@daily", "Mars/Olympus", nil); err == nil {
+			t.Error("AddCron с неверным часовым поясом: нет ошибки")
+		}
+		if err := s.AddCron(ctx, "digest", "missing", "@daily", "", nil); err == nil {
+			t.Error("AddCron с незарегистрированным обработчиком: нет ошибки")
+		}
+	})
+
+	t.Run("расписание считается в часовом поясе задачи", func(t *testing.T) {
+		s := newTestScheduler(t, scheduler.NewMemoryStore(), newLeader(1))
+		s.Register("digest", func(context.Context, *scheduler.Job) error { return nil })
+		if err := s.AddCron(ctx, "digest", "digest", "0 9 * * *", "Asia/Tokyo", nil); err != nil {
+			t.Fatalf("AddCron: %v", err)
+		}
+		job, _ := s.Get(ctx, "digest")
+		// 09:00 в Токио - 00:00 UTC (без перехода на летнее время)
+		if next := job.NextRunAt.UTC(); next.Hour() != 0 || next.Minute() != 0 || job.Timezone != "Asia/Tokyo" {
+			t.Errorf("следующий запуск: %v (%s)", next, job.Timezone)
+		}
+	})
+
+	t.Run("Close дожидается выполняемой задачи", func(t *testing.T) {
+		s, _ := scheduler.New(scheduler.NewMemoryStore(), newLeader(1), testConfig())
+		started := make(chan struct{})
+		var finished atomic.Bool
+		s.Register("export", func(ctx context.Context, job *scheduler.Job) error {
+			close(started)
+			<-ctx.Done()
+			time.Sleep(20 * time.Millisecond)
+			finished.Store(true)
+			return nil
+		})
+		s.AddTimer(ctx, "export", "export", time.Now(), nil)
+		go s.Run(ctx)
+		<-started
+
+		closeCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+		defer cancel()
+		if err := s.Close(closeCtx); err != nil {
+			t.Fatalf("Close: %v", err)
+		}
+		if !finished.Load() {
+			t.Error("Close вернулся до завершения задачи")
+		}
+	})
+}
@@ -0,0 +1,348 @@
+// Пакет schedulertest - общий набор тестов поведения scheduler.Store.
+// Его прогоняют все реализации (Postgres, память), чтобы они были взаимозаменяемы
+package schedulertest
+
+import (
+	"context"
+	"errors"
+	"pkg/scheduler"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Factory возвращает пустое хранилище для одного теста
+type Factory func(t *testing.T) scheduler.Store
+
+// RunStoreContract прогоняет набор тестов на реализации хранилища
+func RunStoreContract(t *testing.T, newStore Factory) {
+	t.Run("создание и обновление задачи", func(t *testing.T) { testUpsert(t, newStore) })
+	t.Run("задачи к запуску", func(t *testing.T) { testDue(t, newStore) })
+	t.Run("отметка запуска", func(t *testing.T) { testClaim(t, newStore) })
+	t.Run("пауза, ручной запуск и удаление", func(t *testing.T) { testControl(t, newStore) })
+	t.Run("история запусков", func(t *testing.T) { testRuns(t, newStore) })
+}
+
+// функция времени с точностью до микросекунд (как в Postgres)
+func at(d time.Duration) time.Time {
+	return time.Now().Add(d).UTC().Truncate(time.Microsecond)
+}
+
+func cronJob(name, spec string, next time.Time) *scheduler.Job {
+	return &scheduler.Job{
+		Name:      name,
+		Kind:      scheduler.KindCron,
+		Handler:   "handler",
+		Spec:      spec,
+		Timezone:  "UTC",
+		Payload:   []byte(`{"chat_id":1}`),
+		NextRunAt: &next,
+	}
+}
+
+func mustUpsert(t *testing.T, store scheduler.Store, job *scheduler.Job) *scheduler.Job {
+	t.Helper()
+	if err := store.UpsertJob(context.Background(), job); err != nil {
+		t.Fatalf("UpsertJob(%s): %v", job.Name, err)
+	}
+	got, err := store.GetJob(context.Background(), job.Name)
+	if err != nil {
+		t.Fatalf("GetJob(%s): %v", job.Name, err)
+	}
+	return got
+}
+
+func testUpsert(t *testing.T, newStore Factory) {
+	ctx := context.Background()
+
+	t.Run("новая задача сохраняется целиком", func(t *testing.T) {
+		store := newStore(t)
+		next := at(time.Hour)
+		got := mustUpsert(t, store, cronJob("digest", "0 9 * * *", next))
+
+		if got.Kind != scheduler.KindCron || got.Handler != "handler" || got.Spec != "0 9 * * *" || got.Timezone != "UTC" {
+			t.Errorf("определение задачи: %+v", got)
+		}
+		// jsonb в Postgres нормализует пробелы
+		if p := strings.ReplaceAll(string(got.Payload), " ", ""); p != `{"chat_id":1}` {
+			t.Errorf("payload: %s", got.Payload)
+		}
+		if got.NextRunAt == nil || !got.NextRunAt.Equal(next) {
+			t.Errorf("next_run_at: %v, ожидали %v", got.NextRunAt, next)
+		}
+		if got.Paused || got.Triggered || got.LastRunAt != nil || got.CreatedAt.IsZero() {
+			t.Errorf("состояние новой задачи: %+v", got)
+		}
+	})
+
+	t.Run("неизвестная задача - ErrJobNotFound", func(t *testing.T) {
+		if _, err := newStore(t).GetJob(ctx, "missing"); !errors.Is(err, scheduler.ErrJobNotFound) {
+			t.Errorf("GetJob: ожидали ErrJobNotFound, получили %v", err)
+		}
+	})
+
+	t.Run("то же расписание не сдвигает следующий запуск", func(t *testing.T) {
+		store := newStore(t)
+		first := at(time.Hour)
+		mustUpsert(t, store, cronJob("digest", "0 9 * * *", first))
+		store.SetPaused(ctx, "digest", true)
+
+		update := cronJob("digest", "0 9 * * *", at(2*time.Hour))
+		update.Handler = "other_handler"
+		got := mustUpsert(t, store, update)
+
+		if !got.NextRunAt.Equal(first) {
+			t.Errorf("next_run_at: %v, ожидали прежний %v", got.NextRunAt, first)
+		}
+		if got.Handler != "other_handler" || !got.Paused {
+			t.Errorf("handler обновляется, пауза сохраняется: %+v", got)
+		}
+	})
+
+	t.Run("новое расписание пересчитывает следующий запуск", func(t *testing.T) {
+		store := newStore(t)
+		mustUpsert(t, store, cronJob("digest", "0 9 * * *", at(time.Hour)))
+
+		next := at(3 * time.Hour)
+		got := mustUpsert(t, store, cronJob("digest", "0 12 * * *", next))
+		if !got.NextRunAt.Equal(next) {
+			t.Errorf("next_run_at: %v, ожидали %v", got.NextRunAt, next)
+		}
+
+		moved := cronJob("digest", "0 12 * * *", at(4*time.Hour))
+		moved.Timezone = "Europe/Moscow"
+		if got := mustUpsert(t, store, moved); !got.NextRunAt.Equal(*moved.NextRunAt) || got.Timezone != "Europe/Moscow" {
+			t.Errorf("смена часового пояса: %+v", got)
+		}
+	})
+
+	t.Run("повторный таймер переносится", func(t *testing.T) {
+		store := newStore(t)
+		timer := &scheduler.Job{Name: "remind", Kind: scheduler.KindOnce, Handler: "handler", Timezone: "UTC"}
+		first := at(time.Hour)
+		timer.NextRunAt = &first
+		mustUpsert(t, store, timer)
+
+		moved := at(2 * time.Hour)
+		timer.NextRunAt = &moved
+		if got := mustUpsert(t, store, timer); !got.NextRunAt.Equal(moved) {
+			t.Errorf("next_run_at: %v, ожидали %v", got.NextRunAt, moved)
+		}
+	})
+
+	t.Run("список упорядочен по имени", func(t *testing.T) {
+		store := newStore(t)
+		for _, name := range []string{"b", "c", "a"} {
+			mustUpsert(t, store, cronJob(name, "@daily", at(time.Hour)))
+		}
+		jobs, err := store.ListJobs(ctx)
+		if err != nil {
+			t.Fatalf("ListJobs: %v", err)
+		}
+		if len(jobs) != 3 || jobs[0].Name != "a" || jobs[2].Name != "c" {
+			t.Errorf("ListJobs: %d задач, первая %v", len(jobs), jobs)
+		}
+	})
+}
+
+func testDue(t *testing.T, newStore Factory) {
+	ctx := context.Background()
+	store := newStore(t)
+
+	mustUpsert(t, store, cronJob("late", "@daily", at(-2*time.Minute)))
+	mustUpsert(t, store, cronJob("due", "@daily", at(-time.Minute)))
+	mustUpsert(t, store, cronJob("future", "@daily", at(time.Hour)))
+	mustUpsert(t, store, cronJob("paused", "@daily", at(-time.Hour)))
+	mustUpsert(t, store, cronJob("triggered", "@daily", at(time.Hour)))
+	store.SetPaused(ctx, "paused", true)
+	store.Trigger(ctx, "triggered")
+
+	jobs, err := store.DueJobs(ctx, time.Now(), 10)
+	if err != nil {
+		t.Fatalf("DueJobs: %v", err)
+	}
+	var names []string
+	for _, job := range jobs {
+		names = append(names, job.Name)
+	}
+	if len(names) != 3 || names[0] != "late" || names[1] != "due" || names[2] != "triggered" {
+		t.Errorf("DueJobs: %v, ожидали [late due triggered]", names)
+	}
+
+	if jobs, _ := store.DueJobs(ctx, time.Now(), 1); len(jobs) != 1 || jobs[0].Name != "late" {
+		t.Errorf("DueJobs с лимитом: %v", jobs)
+	}
+}
+
+func testClaim(t *testing.T, newStore Factory) {
+	ctx := context.Background()
+
+	t.Run("запуск сдвигает расписание и пишется в историю", func(t *testing.T) {
+		store := newStore(t)
+		mustUpsert(t, store, cronJob("digest", "@daily", at(-time.Minute)))
+		store.Trigger(ctx, "digest")
+		job, _ := store.GetJob(ctx, "digest")
+
+		next := at(24 * time.Hour)
+		run := &scheduler.Run{
+			JobName: "digest", Trigger: scheduler.TriggerSchedule, Fence: 7,
+			ScheduledAt: *job.NextRunAt, StartedAt: at(0), Status: scheduler.RunRunning,
+		}
+		ok, err := store.ClaimRun(ctx, job, &next, run)
+		if err != nil || !ok {
+			t.Fatalf("ClaimRun: %v, %v", ok, err)
+		}
+		if run.ID == 0 {
+			t.Error("ClaimRun не заполнил run.ID")
+		}
+
+		got, _ := store.GetJob(ctx, "digest")
+		if !got.NextRunAt.Equal(next) || got.Triggered || got.LastRunAt == nil || !got.LastRunAt.Equal(run.StartedAt) {
+			t.Errorf("задача после запуска: %+v", got)
+		}
+		if got.LastFence != 7 {
+			t.Errorf("last_fence: %d", got.LastFence)
+		}
+		if due, _ := store.DueJobs(ctx, time.Now(), 10); len(due) != 0 {
+			t.Errorf("после запуска задача осталась к запуску: %v", due)
+		}
+	})
+
+	t.Run("повторная отметка по той же версии отклоняется", func(t *testing.T) {
+		store := newStore(t)
+		job := mustUpsert(t, store, cronJob("digest", "@daily", at(-time.Minute)))
+		next := at(time.Hour)
+
+		first := &scheduler.Run{JobName: "digest", Trigger: scheduler.TriggerSchedule, StartedAt: at(0), ScheduledAt: at(0), Status: scheduler.RunRunning}
+		second := *first
+		if ok, err := store.ClaimRun(ctx, job, &next, first); !ok || err != nil {
+			t.Fatalf("первая отметка: %v, %v", ok, err)
+		}
+		if ok, err := store.ClaimRun(ctx, job, &next, &second); ok || err != nil {
+			t.Errorf("вторая отметка: %v, %v - задача запустилась бы дважды", ok, err)
+		}
+		if runs, _ := store.Runs(ctx, "digest", 10); len(runs) != 1 {
+			t.Errorf("в истории %d запусков", len(runs))
+		}
+	})
+
+	t.Run("изменённая после чтения задача не отмечается", func(t *testing.T) {
+		store := newStore(t)
+		job := mustUpsert(t, store, cronJob("digest", "@daily", at(-time.Minute)))
+		store.SetPaused(ctx, "digest", true)
+
+		next := at(time.Hour)
+		run := &scheduler.Run{JobName: "digest", Trigger: scheduler.TriggerSchedule, StartedAt: at(0), ScheduledAt: at(0), Status: scheduler.RunRunning}
+		if ok, err := store.ClaimRun(ctx, job, &next, run); ok || err != nil {
+			t.Errorf("ClaimRun: %v, %v", ok, err)
+		}
+	})
+
+	t.Run("сработавший таймер больше не запускается", func(t *testing.T) {
+		store := newStore(t)
+		due := at(-time.Minute)
+		job := mustUpsert(t, store, &scheduler.Job{Name: "remind", Kind: scheduler.KindOnce, Handler: "handler", Timezone: "UTC", NextRunAt: &due})
+
+		run := &scheduler.Run{JobName: "remind", Trigger: scheduler.TriggerSchedule, StartedAt: at(0), ScheduledAt: due, Status: scheduler.RunRunning}
+		if ok, err := store.ClaimRun(ctx, job, nil, run); !ok || err != nil {
+			t.Fatalf("ClaimRun: %v, %v", ok, err)
+		}
+		got, _ := store.GetJob(ctx, "remind")
+		if got.NextRunAt != nil {
+			t.Errorf("next_run_at таймера: %v", got.NextRunAt)
+		}
+		if jobs, _ := store.DueJobs(ctx, time.Now().Add(time.Hour), 10); len(jobs) != 0 {
+			t.Errorf("таймер снова к запуску: %v", jobs)
+		}
+	})
+}
+
+func testControl(t *testing.T, newStore Factory) {
+	ctx := context.Background()
+	store := newStore(t)
+	mustUpsert(t, store, cronJob("digest", "@daily", at(time.Hour)))
+
+	if err := store.SetPaused(ctx, "digest", true); err != nil {
+		t.Fatalf("SetPaused: %v", err)
+	}
+	if err := store.Trigger(ctx, "digest"); err != nil {
+		t.Fatalf("Trigger: %v", err)
+	}
+	got, _ := store.GetJob(ctx, "digest")
+	if !got.Paused || !got.Triggered {
+		t.Errorf("после паузы и ручного запуска: %+v", got)
+	}
+	if err := store.SetPaused(ctx, "digest", false); err != nil {
+		t.Fatalf("SetPaused: %v", err)
+	}
+	if got, _ := store.GetJob(ctx, "digest"); got.Paused {
+		t.Error("пауза не снята")
+	}
+
+	for name, err := range map[string]error{
+		"SetPaused": store.SetPaused(ctx, "missing", true),
+		"Trigger":   store.Trigger(ctx, "missing"),
+		"DeleteJob": store.DeleteJob(ctx, "missing"),
+	} {
+		if !errors.Is(err, scheduler.ErrJobNotFound) {
+			t.Errorf("%s неизвестной задачи: ожидали ErrJobNotFound, получили %v", name, err)
+		}
+	}
+
+	if err := store.DeleteJob(ctx, "digest"); err != nil {
+		t.Fatalf("DeleteJob: %v", err)
+	}
+	if _, err := store.GetJob(ctx, "digest"); !errors.Is(err, scheduler.ErrJobNotFound) {
+		t.Errorf("задача не удалена: %v", err)
+	}
+}
+
+func testRuns(t *testing.T, newStore Factory) {
+	ctx := context.Background()
+	store := newStore(t)
+	mustUpsert(t, store, cronJob("digest", "@daily", at(-time.Minute)))
+
+	var last *scheduler.Run
+	for i, started := range []time.Time{at(-48 * time.Hour), at(-time.Hour), at(0)} {
+		job, _ := store.GetJob(ctx, "digest")
+		next := at(time.Hour)
+		last = &scheduler.Run{JobName: "digest", Trigger: scheduler.TriggerManual, StartedAt: started, ScheduledAt: started, Status: scheduler.RunRunning}
+		if ok, err := store.ClaimRun(ctx, job, &next, last); !ok || err != nil {
+			t.Fatalf("ClaimRun %d: %v, %v", i, ok, err)
+		}
+	}
+
+	finished := at(time.Second)
+	last.FinishedAt, last.Status, last.Error = &finished, scheduler.RunFailed, "boom"
+	if err := store.FinishRun(ctx, last); err != nil {
+		t.Fatalf("FinishRun: %v", err)
+	}
+
+	runs, err := store.Runs(ctx, "digest", 2)
+	if err != nil {
+		t.Fatalf("Runs: %v", err)
+	}
+	if len(runs) != 2 || runs[0].ID != last.ID {
+		t.Fatalf("Runs: %d записей, первая %+v", len(runs), runs)
+	}
+	if runs[0].Status != scheduler.RunFailed || runs[0].Error != "boom" || runs[0].FinishedAt == nil || runs[0].Trigger != scheduler.TriggerManual {
+		t.Errorf("результат запуска: %+v", runs[0])
+	}
+	if job, _ := store.GetJob(ctx, "digest"); job.LastStatus != scheduler.RunFailed {
+		t.Errorf("last_status: %q", job.LastStatus)
+	}
+
+	deleted, err := store.DeleteRunsBefore(ctx, at(-24*time.Hour))
+	if err != nil || deleted != 1 {
+		t.Errorf("DeleteRunsBefore: %d, %v", deleted, err)
+	}
+	if runs, _ := store.Runs(ctx, "digest", 0); len(runs) != 2 {
+		t.Errorf("после очистки %d запусков", len(runs))
+	}
+
+	// удаление задачи удаляет и её историю
+	store.DeleteJob(ctx, "digest")
+	if runs, _ := store.Runs(ctx, "digest", 0); len(runs) != 0 {
+		t.Errorf("история удалённой задачи: %d", len(runs))
+	}
+}
@@ -0,0 +1,109 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// ErrJobNotFound - задачи с таким именем нет
+var ErrJobNotFound = errors.New("scheduler: job not found")
+
+// Kind - вид задачи
+type Kind string
+
+const (
+	KindCron Kind = "cron" // периодическая задача по cron выражению
+	KindOnce Kind = "once" // таймер: один запуск в заданное время
+)
+
+// Trigger - причина запуска
+type Trigger string
+
+const (
+	TriggerSchedule Trigger = "schedule" // пришло время по расписанию
+	TriggerManual   Trigger = "manual"   // запуск вне расписания (из админки)
+)
+
+// RunStatus - состояние запуска
+type RunStatus string
+
+const (
+	RunRunning RunStatus = "running"
+	RunOK      RunStatus = "ok"
+	RunFailed  RunStatus = "failed"
+)
+
+// Job - задача планировщика
+type Job struct {
+	Name       string          `json:"name"`
+	Kind       Kind            `json:"kind"`
+	Handler    string          `json:"handler"`        // имя зарегистрированного обработчика
+	Spec       string          `json:"spec,omitempty"` // cron выражение (для KindCron)
+	Timezone   string          `json:"timezone"`       // часовой пояс, в котором считается расписание
+	Payload    json.RawMessage `json:"payload,omitempty"`
+	Paused     bool            `json:"paused"`
+	Triggered  bool            `json:"triggered"`             // запрошен запуск вне расписания
+	NextRunAt  *time.Time      `json:"next_run_at,omitempty"` // nil - таймер уже сработал
+	LastRunAt  *time.Time      `json:"last_run_at,omitempty"`
+	LastStatus RunStatus       `json:"last_status,omitempty"`
+	LastFence  int64           `json:"-"` // fencing token лидера, запустившего задачу последним
+	Version    int64           `json:"-"` // меняется при каждом изменении (защита от двойного запуска)
+	CreatedAt  time.Time       `json:"created_at"`
+	UpdatedAt  time.Time       `json:"updated_at"`
+}
+
+// Run - запись истории запусков
+type Run struct {
+	ID          int64      `json:"id"`
+	JobName     string     `json:"job_name"`
+	Trigger     Trigger    `json:"trigger"`
+	Fence       int64      `json:"fence"`        // fencing token лидера, выполнившего запуск
+	ScheduledAt time.Time  `json:"scheduled_at"` // время по расписанию (для ручного запуска - время запроса)
+	StartedAt   time.Time  `json:"started_at"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+	Status      RunStatus  `json:"status"`
+	Error       string     `json:"error,omitempty"`
+}
+
+// Store - хранилище задач и истории запусков (Postgres в сервере, MemoryStore в тестах).
+// Поведение реализаций проверяет schedulertest.RunStoreContract
+type Store interface {
+	// UpsertJob создаёт задачу или обновляет её определение (handler, spec, timezone, payload).
+	// Пауза, запрошенный запуск и история сохраняются. NextRunAt таймера заменяется всегда,
+	// у периодической задачи - только если изменились spec или timezone (или расписания ещё нет)
+	UpsertJob(ctx context.Context, job *Job) error
+
+	// GetJob возвращает задачу или ErrJobNotFound
+	GetJob(ctx context.Context, name string) (*Job, error)
+
+	// ListJobs возвращает все задачи по имени
+	ListJobs(ctx context.Context) ([]*Job, error)
+
+	// DueJobs возвращает задачи, которые пора запустить: не на паузе и NextRunAt <= now, или с запрошенным запуском
+	DueJobs(ctx context.Context, now time.Time, limit int) ([]*Job, error)
+
+	// ClaimRun атомарно отмечает запуск: задача получает NextRunAt = next, сбрасывает Triggered,
+	// LastRunAt = run.StartedAt, LastFence = run.Fence, а run записывается в историю (заполняется run.ID).
+	// false - задача изменилась после чтения (job.Version): её изменили или уже запустил другой экземпляр
+	ClaimRun(ctx context.Context, job *Job, next *time.Time, run *Run) (bool, error)
+
+	// FinishRun сохраняет результат запуска и LastStatus задачи
+	FinishRun(ctx context.Context, run *Run) error
+
+	// SetPaused ставит задачу на паузу или снимает с неё (ErrJobNotFound - задачи нет)
+	SetPaused(ctx context.Context, name string, paused bool) error
+
+	// Trigger запрашивает запуск вне расписания (выполнится и на паузе). ErrJobNotFound - задачи нет
+	Trigger(ctx context.Context, name string) error
+
+	// DeleteJob удаляет задачу вместе с историей (ErrJobNotFound - задачи нет)
+	DeleteJob(ctx context.Context, name string) error
+
+	// Runs возвращает последние запуски задачи (сначала новые)
+	Runs(ctx context.Context, name string, limit int) ([]*Run, error)
+
+	// DeleteRunsBefore удаляет историю запусков, начатых раньше before, возвращает число удалённых
+	DeleteRunsBefore(ctx context.Context, before time.Time) (int64, error)
+}
@@ -0,0 +1,79 @@
+package tieredcache
+
+import (
+	"container/list"
+	"time"
+)
+
+// запись локального уровня
+type lruEntry struct {
+	key       string
+	value     []byte
+	negative  bool // данных нет в источнике
+	expiresAt time.Time
+}
+
+// lru - ограниченный по числу записей список с вытеснением давно не читанных. Не потокобезопасен
+type lru struct {
+	size  int
+	ll    *list.List // в начале - недавно использованные
+	items map[string]*list.Element
+}
+
+// конструктор для lru
+func newLRU(size int) *lru {
+	return &lru{size: size, ll: list.New(), items: make(map[string]*list.Element)}
+}
+
+// метод чтения записи (истёкшая удаляется)
+func (l *lru) get(key string, now time.Time) (*lruEntry, bool) {
+	el, ok := l.items[key]
+	if !ok {
+		return nil, false
+	}
+	entry := el.Value.(*lruEntry)
+	if !now.Before(entry.expiresAt) {
+		l.removeElement(el)
+		return nil, false
+	}
+	l.ll.MoveToFront(el)
+	return entry, true
+}
+
+// метод записи (при переполнении вытесняются давно не читанные записи)
+func (l *lru) add(entry *lruEntry) {
+	if el, ok := l.items[entry.key]; ok {
+		el.Value = entry
+		l.ll.MoveToFront(el)
+		return
+	}
+	l.items[entry.key] = l.ll.PushFront(entry)
+
+	for l.ll.Len() > l.size {
+		l.removeElement(l.ll.Back())
+	}
+}
+
+// метод удаления записи
+func (l *lru) remove(key string) {
+	if el, ok := l.items[key]; ok {
+		l.removeElement(el)
+	}
+}
+
+// метод удаления всех записей
+func (l *lru) purge() {
+	l.ll.Init()
+	clear(l.items)
+}
+
+// метод возвращает число записей
+func (l *lru) len() int {
+	return l.ll.Len()
+}
+
+// метод удаления элемента списка вместе с индексом
+func (l *lru) removeElement(el *list.Element) {
+	l.ll.Remove(el)
+	delete(l.items, el.Value.(*lruEntry).key)
+}
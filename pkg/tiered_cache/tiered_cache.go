@@ -0,0 +1,434 @@
+// Пакет tieredcache - двухуровневый кэш: LRU в памяти процесса перед общим хранилищем (redis).
+// Данные, которые читаются на каждом update, но меняются редко (пользователи, тексты сценариев,
+// портфолио), отдаются из памяти без обращения к redis.
+//
+// Запись через кэш (Set, Delete, Incr, ...) сбрасывает ключ в памяти всех экземпляров сервиса:
+// сообщение со списком ключей рассылается через pub/sub. Pub/sub не гарантирует доставку,
+// поэтому записи в памяти живут не дольше LocalTTL, а при переподключении память очищается целиком
+package tieredcache
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"global_models/global_cache"
+	"log/slog"
+	"pkg/configs"
+	"pkg/metrics"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/sync/singleflight"
+)
+
+// Проверка реализации интерфейсов
+var (
+	_ global_cache.Cache  = (*Cache)(nil)
+	_ global_cache.Loader = (*Cache)(nil)
+)
+
+// значение в хранилище, означающее "данных нет в источнике" (отрицательное кэширование)
+var negativeMarker = []byte("\x00tiered_cache:not_found")
+
+// пауза перед повторной подпиской после ошибки
+const resubscribeDelay = time.Second
+
+// метрики двухуровневого кэша (cache - имя экземпляра кэша)
+var (
+	cacheRequests = metrics.MustRegister(prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "tiered_cache_requests_total",
+		Help: "Tiered cache reads by tier (local / remote) and result (hit / negative / miss / error).",
+	}, []string{"cache", "tier", "result"}))
+
+	cacheLoads = metrics.MustRegister(prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "tiered_cache_loads_total",
+		Help: "Source loads after a cache miss by result (ok / not_found / error); shared - readers that reused a concurrent load.",
+	}, []string{"cache", "result"}))
+
+	cacheInvalidations = metrics.MustRegister(prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "tiered_cache_invalidations_total",
+		Help: "Local tier invalidations by source (local write / remote message / resync after reconnect).",
+	}, []string{"cache", "source"}))
+
+	cacheLocalEntries = metrics.MustRegister(prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "tiered_cache_local_entries",
+		Help: "Entries held in the in-process tier.",
+	}, []string{"cache"}))
+)
+
+// сообщение о сбросе ключей (origin - экземпляр-отправитель, свои сообщения не обрабатываются)
+type invalidation struct {
+	Origin string   `json:"origin"`
+	Keys   []string `json:"keys"`
+}
+
+// Cache - двухуровневый кэш. Методы, которые не переопределены (хэши, списки, множества),
+// идут напрямую в хранилище: в памяти кэшируются только строковые значения
+type Cache struct {
+	global_cache.Cache // общее хранилище (redis)
+
+	name   string
+	conf   *configs.TieredCacheConfig
+	bus    global_cache.PubSub // nil - без рассылки (один экземпляр)
+	origin string
+
+	mu         sync.Mutex
+	local      *lru
+	generation uint64 // растёт при каждом сбросе: запись, прочитанная до сброса, в память не попадает
+
+	group singleflight.Group
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// конструктор для Cache: name - имя для метрик, bus - рассылка сбросов между экземплярами.
+// Подписка на сбросы работает в фоне до Close
+func New(remote global_cache.Cache, bus global_cache.PubSub, conf *configs.TieredCacheConfig, name string) (*Cache, error) {
+	if remote == nil {
+		return nil, errors.New("remote cache is nil")
+	}
+	if conf == nil {
+		conf = configs.UseDefaultTieredCacheConfig()
+	}
+	if conf.LocalSize <= 0 || conf.LocalTTL <= 0 {
+		return nil, errors.New("tiered cache: local_size and local_ttl must be positive")
+	}
+	if bus != nil && conf.Channel == "" {
+		return nil, errors.New("tiered cache: channel is empty")
+	}
+
+	origin := make([]byte, 8)
+	if _, err := rand.Read(origin); err != nil {
+		return nil, fmt.Errorf("tiered cache: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &Cache{
+		Cache:  remote,
+		name:   name,
+		conf:   conf,
+		bus:    bus,
+		origin: hex.EncodeToString(origin),
+		local:  newLRU(conf.LocalSize),
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+
+	if bus == nil {
+		close(c.done)
+		return c, nil
+	}
+	go c.listen(ctx)
+	return c, nil
+}
+
+// метод освобождения ресурсов: останавливает подписку. Хранилище не закрывается - им владеет вызывающий код
+func (c *Cache) Close() error {
+	c.cancel()
+	<-c.done
+
+	c.mu.Lock()
+	c.local.purge()
+	c.mu.Unlock()
+	cacheLocalEntries.WithLabelValues(c.name).Set(0)
+	return nil
+}
+
+// фоновая подписка на сбросы (переподписывается после ошибок до отмены ctx)
+func (c *Cache) listen(ctx context.Context) {
+	defer close(c.done)
+
+	for {
+		err := c.bus.Subscribe(ctx, c.conf.Channel, c.onMessage)
+		if ctx.Err() != nil {
+			return
+		}
+		slog.Warn("tiered cache: subscription failed", "cache", c.name, "error", err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(resubscribeDelay):
+		}
+	}
+}
+
+// обработчик сообщения подписки
+func (c *Cache) onMessage(payload []byte) {
+	// подписка (пере)установлена: сбросы за время разрыва потеряны - очищаем память целиком
+	if payload == nil {
+		c.mu.Lock()
+		c.local.purge()
+		c.generation++
+		c.mu.Unlock()
+		cacheLocalEntries.WithLabelValues(c.name).Set(0)
+		cacheInvalidations.WithLabelValues(c.name, "resync").Inc()
+		return
+	}
+
+	var msg invalidation
+	if err := json.Unmarshal(payload, &msg); err != nil {
+		slog.Warn("tiered cache: bad invalidation message", "cache", c.name, "error", err)
+		return
+	}
+	if msg.Origin == c.origin {
+		return
+	}
+	c.dropLocal(msg.Keys...)
+	cacheInvalidations.WithLabelValues(c.name, "remote").Add(float64(len(msg.Keys)))
+}
+
+// метод удаления ключей из памяти
+func (c *Cache) dropLocal(keys ...string) {
+	c.mu.Lock()
+	for _, key := range keys {
+		c.local.remove(key)
+	}
+	c.generation++
+	entries := c.local.len()
+	c.mu.Unlock()
+	cacheLocalEntries.WithLabelValues(c.name).Set(float64(entries))
+}
+
+// метод сброса ключей после записи: в памяти этого экземпляра и (через pub/sub) остальных
+func (c *Cache) invalidate(ctx context.Context, keys ...string) {
+	c.dropLocal(keys...)
+	cacheInvalidations.WithLabelValues(c.name, "local").Add(float64(len(keys)))
+
+	if c.bus == nil {
+		return
+	}
+	payload, err := json.Marshal(invalidation{Origin: c.origin, Keys: keys})
+	if err != nil {
+		return
+	}
+	if err := c.bus.Publish(ctx, c.conf.Channel, payload); err != nil {
+		// на других экземплярах запись устареет по LocalTTL
+		slog.WarnContext(ctx, "tiered cache: failed to publish invalidation", "cache", c.name, "error", err)
+	}
+}
+
+// метод чтения из памяти: found - запись есть (negative - данных нет в источнике)
+func (c *Cache) getLocal(key string) (value []byte, negative, found bool, generation uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.local.get(key, time.Now())
+	if !ok {
+		return nil, false, false, c.generation
+	}
+	return entry.value, entry.negative, true, c.generation
+}
+
+// метод записи в память, если с момента чтения (generation) ключи не сбрасывались
+func (c *Cache) setLocal(key string, value []byte, negative bool, ttl time.Duration, generation uint64) {
+	c.mu.Lock()
+	if c.generation != generation {
+		c.mu.Unlock()
+		return
+	}
+	c.local.add(&lruEntry{key: key, value: value, negative: negative, expiresAt: time.Now().Add(ttl)})
+	entries := c.local.len()
+	c.mu.Unlock()
+	cacheLocalEntries.WithLabelValues(c.name).Set(float64(entries))
+}
+
+// метод возвращает время жизни записи в памяти (не дольше, чем в хранилище)
+func (c *Cache) localTTL(ttl time.Duration) time.Duration {
+	if ttl > 0 && ttl < c.conf.LocalTTL {
+		return ttl
+	}
+	return c.conf.LocalTTL
+}
+
+// метод чтения: сначала память, затем хранилище. negative - данных нет в источнике,
+// generation - поколение сбросов до чтения (для записи загруженного значения в память)
+func (c *Cache) read(ctx context.Context, key string) (value []byte, negative bool, generation uint64, err error) {
+	value, negative, found, generation := c.getLocal(key)
+	if found {
+		if negative {
+			cacheRequests.WithLabelValues(c.name, "local", "negative").Inc()
+			return nil, true, generation, nil
+		}
+		cacheRequests.WithLabelValues(c.name, "local", "hit").Inc()
+		return bytes.Clone(value), false, generation, nil
+	}
+	cacheRequests.WithLabelValues(c.name, "local", "miss").Inc()
+
+	value, err = c.Cache.GetBytes(ctx, key)
+	switch {
+	case errors.Is(err, global_cache.ErrNotFound):
+		cacheRequests.WithLabelValues(c.name, "remote", "miss").Inc()
+		return nil, false, generation, err
+	case err != nil:
+		cacheRequests.WithLabelValues(c.name, "remote", "error").Inc()
+		return nil, false, generation, err
+	}
+
+	// время жизни в хранилище не читаем (лишний запрос): запись в памяти живёт LocalTTL / NegativeTTL
+	if bytes.Equal(value, negativeMarker) {
+		cacheRequests.WithLabelValues(c.name, "remote", "negative").Inc()
+		c.setLocal(key, nil, true, c.localTTL(c.conf.NegativeTTL), generation)
+		return nil, true, generation, nil
+	}
+	cacheRequests.WithLabelValues(c.name, "remote", "hit").Inc()
+	c.setLocal(key, value, false, c.conf.LocalTTL, generation)
+	return bytes.Clone(value), false, generation, nil
+}
+
+// метод чтения значения (ErrNotFound - нет в кэше или данных нет в источнике)
+func (c *Cache) GetBytes(ctx context.Context, key string) ([]byte, error) {
+	value, negative, _, err := c.read(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if negative {
+		return nil, global_cache.ErrNotFound
+	}
+	return value, nil
+}
+
+// метод чтения значения строкой
+func (c *Cache) Get(ctx context.Context, key string) (string, error) {
+	value, err := c.GetBytes(ctx, key)
+	return string(value), err
+}
+
+// метод проверки существования значения (отрицательная запись - значения нет)
+func (c *Cache) Exists(ctx context.Context, key string) (bool, error) {
+	_, err := c.GetBytes(ctx, key)
+	if errors.Is(err, global_cache.ErrNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// GetOrLoad читает значение, а при промахе загружает его из источника (одна загрузка на ключ
+// внутри экземпляра, остальные читатели ждут её результат). Ошибки хранилища не мешают чтению:
+// значение загружается из источника. ErrNotFound из load запоминается на NegativeTTL.
+// load получает контекст первого читателя
+func (c *Cache) GetOrLoad(ctx context.Context, key string, ttl time.Duration, load func(ctx context.Context) ([]byte, error)) ([]byte, error) {
+	value, negative, generation, err := c.read(ctx, key)
+	switch {
+	case err == nil && negative:
+		return nil, global_cache.ErrNotFound
+	case err == nil:
+		return value, nil
+	case !errors.Is(err, global_cache.ErrNotFound):
+		slog.WarnContext(ctx, "tiered cache: remote read failed", "cache", c.name, "error", err)
+	}
+
+	result, err, shared := c.group.Do(key, func() (any, error) {
+		value, err := load(ctx)
+		switch {
+		case errors.Is(err, global_cache.ErrNotFound):
+			cacheLoads.WithLabelValues(c.name, "not_found").Inc()
+			c.fill(ctx, key, nil, true, ttl, generation)
+			return nil, global_cache.ErrNotFound
+		case err != nil:
+			cacheLoads.WithLabelValues(c.name, "error").Inc()
+			return nil, err
+		}
+		cacheLoads.WithLabelValues(c.name, "ok").Inc()
+		c.fill(ctx, key, value, false, ttl, generation)
+		return value, nil
+	})
+	if shared {
+		cacheLoads.WithLabelValues(c.name, "shared").Inc()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return bytes.Clone(result.([]byte)), nil
+}
+
+// метод записи загруженного значения в хранилище и память (ошибка хранилища не мешает чтению).
+// Остальным экземплярам не рассылается: у них этого ключа в памяти не было или он был тем же
+func (c *Cache) fill(ctx context.Context, key string, value []byte, negative bool, ttl time.Duration, generation uint64) {
+	remoteValue, localTTL := value, c.localTTL(ttl)
+	if negative {
+		if c.conf.NegativeTTL <= 0 {
+			return
+		}
+		remoteValue, ttl, localTTL = negativeMarker, c.conf.NegativeTTL, c.localTTL(c.conf.NegativeTTL)
+	}
+
+	if err := c.Cache.Set(ctx, key, remoteValue, ttl); err != nil {
+		slog.WarnContext(ctx, "tiered cache: remote write failed", "cache", c.name, "error", err)
+	}
+	c.setLocal(key, value, negative, localTTL, generation)
+}
+
+// метод записи значения (запись на всех экземплярах сбрасывается)
+func (c *Cache) Set(ctx context.Context, key string, value []byte, expiration time.Duration) error {
+	if err := c.Cache.Set(ctx, key, value, expiration); err != nil {
+		return err
+	}
+	c.invalidate(ctx, key)
+	return nil
+}
+
+// метод удаления ключа
+func (c *Cache) Delete(ctx context.Context, key string) error {
+	if err := c.Cache.Delete(ctx, key); err != nil {
+		return err
+	}
+	c.invalidate(ctx, key)
+	return nil
+}
+
+// метод атомарной записи значения, только если ключа ещё нет (сбрасывает отрицательные записи)
+func (c *Cache) SetNX(ctx context.Context, key string, value []byte, expiration time.Duration) (bool, error) {
+	ok, err := c.Cache.SetNX(ctx, key, value, expiration)
+	if ok {
+		c.invalidate(ctx, key)
+	}
+	return ok, err
+}
+
+// метод атомарной записи значения с возвратом предыдущего
+func (c *Cache) GetSet(ctx context.Context, key string, value []byte) ([]byte, error) {
+	prev, err := c.Cache.GetSet(ctx, key, value)
+	if err == nil || errors.Is(err, global_cache.ErrNotFound) {
+		c.invalidate(ctx, key)
+	}
+	return prev, err
+}
+
+// метод атомарного увеличения счётчика на 1
+func (c *Cache) Incr(ctx context.Context, key string, expiration time.Duration) (int64, error) {
+	return c.IncrBy(ctx, key, 1, expiration)
+}
+
+// метод атомарного увеличения счётчика на delta
+func (c *Cache) IncrBy(ctx context.Context, key string, delta int64, expiration time.Duration) (int64, error) {
+	n, err := c.Cache.IncrBy(ctx, key, delta, expiration)
+	if err == nil {
+		c.invalidate(ctx, key)
+	}
+	return n, err
+}
+
+// метод записи нового ключа с номером из счётчика fenceKey
+func (c *Cache) SetNXFenced(ctx context.Context, key, fenceKey string, suffix []byte, expiration time.Duration) (int64, error) {
+	fence, err := c.Cache.SetNXFenced(ctx, key, fenceKey, suffix, expiration)
+	if fence > 0 {
+		c.invalidate(ctx, key, fenceKey)
+	}
+	return fence, err
+}
+
+// метод удаления ключа, только если его значение равно expected
+func (c *Cache) CompareAndDelete(ctx context.Context, key string, expected []byte) (bool, error) {
+	ok, err := c.Cache.CompareAndDelete(ctx, key, expected)
+	if ok {
+		c.invalidate(ctx, key)
+	}
+	return ok, err
+}
@@ -0,0 +1,227 @@
+package tieredcache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"global_models/global_cache"
+	"pkg/configs"
+	memorycache "pkg/memory_cache"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingCache считает чтения из хранилища (каждое - запрос к redis)
+type countingCache struct {
+	*memorycache.MemoryCache
+	reads atomic.Int64
+	err   error // если задана - чтение и запись возвращают её (хранилище недоступно)
+}
+
+func (c *countingCache) GetBytes(ctx context.Context, key string) ([]byte, error) {
+	c.reads.Add(1)
+	if c.err != nil {
+		return nil, c.err
+	}
+	return c.MemoryCache.GetBytes(ctx, key)
+}
+
+func (c *countingCache) Set(ctx context.Context, key string, value []byte, expiration time.Duration) error {
+	if c.err != nil {
+		return c.err
+	}
+	return c.MemoryCache.Set(ctx, key, value, expiration)
+}
+
+func testConfig() *configs.TieredCacheConfig {
+	return &configs.TieredCacheConfig{
+		Enabled:     true,
+		LocalSize:   100,
+		LocalTTL:    time.Minute,
+		NegativeTTL: time.Minute,
+		Channel:     "invalidate",
+	}
+}
+
+// функция создания кэша над общим хранилищем (store одновременно и рассылка сбросов)
+func newTestCache(t *testing.T, store *countingCache, conf *configs.TieredCacheConfig) *Cache {
+	t.Helper()
+	cache, err := New(store, store.MemoryCache, conf, "test")
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	t.Cleanup(func() { cache.Close() })
+	return cache
+}
+
+func newStore(t *testing.T) *countingCache {
+	store := &countingCache{MemoryCache: memorycache.NewMemoryCache(0)}
+	t.Cleanup(func() { store.MemoryCache.Close() })
+	return store
+}
+
+// функция ожидания условия (сбросы приходят в фоне)
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("не дождались: %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestTieredCache(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("повторное чтение идёт из памяти", func(t *testing.T) {
+		store := newStore(t)
+		cache := newTestCache(t, store, testConfig())
+		store.MemoryCache.Set(ctx, "key", []byte("value"), 0)
+
+		for range 3 {
+			if got, err := cache.Get(ctx, "key"); err != nil || got != "value" {
+				t.Fatalf("Get: %q, %v", got, err)
+			}
+		}
+		if got := store.reads.Load(); got != 1 {
+			t.Errorf("чтений из хранилища: %d, ожидали 1", got)
+		}
+	})
+
+	t.Run("память ограничена local_size", func(t *testing.T) {
+		store := newStore(t)
+		conf := testConfig()
+		conf.LocalSize = 2
+		cache := newTestCache(t, store, conf)
+
+		for _, key := range []string{"a", "b", "c"} {
+			store.MemoryCache.Set(ctx, key, []byte(key), 0)
+			cache.Get(ctx, key)
+		}
+		store.reads.Store(0)
+
+		cache.Get(ctx, "a") // вытеснен как давно не читанный
+		cache.Get(ctx, "c")
+		if got := store.reads.Load(); got != 1 {
+			t.Errorf("чтений из хранилища: %d, ожидали 1", got)
+		}
+	})
+
+	t.Run("запись на одном экземпляре сбрасывает память остальных", func(t *testing.T) {
+		store := newStore(t)
+		first := newTestCache(t, store, testConfig())
+		second := newTestCache(t, store, testConfig())
+
+		first.Set(ctx, "key", []byte("old"), 0)
+		if got, _ := second.Get(ctx, "key"); got != "old" {
+			t.Fatalf("Get: %q", got)
+		}
+
+		if err := first.Set(ctx, "key", []byte("new"), 0); err != nil {
+			t.Fatalf("Set: %v", err)
+		}
+		waitFor(t, "второй экземпляр прочитал новое значение", func() bool {
+			got, _ := second.Get(ctx, "key")
+			return got == "new"
+		})
+
+		first.Delete(ctx, "key")
+		waitFor(t, "второй экземпляр увидел удаление", func() bool {
+			_, err := second.Get(ctx, "key")
+			return errors.Is(err, global_cache.ErrNotFound)
+		})
+	})
+
+	t.Run("одновременные промахи загружают данные один раз", func(t *testing.T) {
+		cache := newTestCache(t, newStore(t), testConfig())
+		var loads atomic.Int64
+		release := make(chan struct{})
+
+		var wg sync.WaitGroup
+		for range 10 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				got, err := cache.GetOrLoad(ctx, "key", time.Minute, func(context.Context) ([]byte, error) {
+					loads.Add(1)
+					<-release
+					return []byte("loaded"), nil
+				})
+				if err != nil || string(got) != "loaded" {
+					t.Errorf("GetOrLoad: %q, %v", got, err)
+				}
+			}()
+		}
+		time.Sleep(20 * time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		if got := loads.Load(); got != 1 {
+			t.Errorf("загрузок: %d, ожидали 1", got)
+		}
+	})
+
+	t.Run("отсутствие данных запоминается до записи", func(t *testing.T) {
+		store := newStore(t)
+		cache := newTestCache(t, store, testConfig())
+		other := newTestCache(t, store, testConfig())
+		var loads atomic.Int64
+		load := func(context.Context) ([]byte, error) {
+			loads.Add(1)
+			return nil, global_cache.ErrNotFound
+		}
+
+		for _, c := range []*Cache{cache, cache, other} {
+			if _, err := c.GetOrLoad(ctx, "key", time.Minute, load); !errors.Is(err, global_cache.ErrNotFound) {
+				t.Fatalf("GetOrLoad: ожидали ErrNotFound, получили %v", err)
+			}
+		}
+		if got := loads.Load(); got != 1 {
+			t.Errorf("загрузок: %d, ожидали 1 (второй экземпляр читает отметку из хранилища)", got)
+		}
+
+		cache.Set(ctx, "key", []byte("created"), time.Minute)
+		waitFor(t, "второй экземпляр увидел запись", func() bool {
+			got, _ := other.Get(ctx, "key")
+			return got == "created"
+		})
+	})
+
+	t.Run("недоступное хранилище не мешает загрузке", func(t *testing.T) {
+		store := newStore(t)
+		store.err = errors.New("connection refused")
+		cache := newTestCache(t, store, testConfig())
+
+		got, err := cache.GetOrLoad(ctx, "key", time.Minute, func(context.Context) ([]byte, error) {
+			return []byte("loaded"), nil
+		})
+		if err != nil || string(got) != "loaded" {
+			t.Fatalf("GetOrLoad: %q, %v", got, err)
+		}
+		// загруженное значение всё равно попадает в память
+		if got, err := cache.Get(ctx, "key"); err != nil || got != "loaded" {
+			t.Errorf("Get: %q, %v", got, err)
+		}
+	})
+
+	t.Run("ошибка загрузки не кэшируется", func(t *testing.T) {
+		cache := newTestCache(t, newStore(t), testConfig())
+		failure := fmt.Errorf("db is down")
+
+		if _, err := cache.GetOrLoad(ctx, "key", time.Minute, func(context.Context) ([]byte, error) {
+			return nil, failure
+		}); !errors.Is(err, failure) {
+			t.Fatalf("GetOrLoad: %v", err)
+		}
+		got, err := cache.GetOrLoad(ctx, "key", time.Minute, func(context.Context) ([]byte, error) {
+			return []byte("loaded"), nil
+		})
+		if err != nil || string(got) != "loaded" {
+			t.Errorf("повторная загрузка: %q, %v", got, err)
+		}
+	})
+}
@@ -0,0 +1,258 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"global_models/global_cache"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Проверка реализации интерфейса
+var _ global_cache.Cache = (*tracedCache)(nil)
+
+// WrapCache оборачивает кэш: каждая операция записывается спаном.
+// Ключи в спан не пишутся - в них бывают идентификаторы пользователей
+func WrapCache(cache global_cache.Cache) global_cache.Cache {
+	return &tracedCache{cache: cache}
+}
+
+// кэш с трассировкой
+type tracedCache struct {
+	cache global_cache.Cache
+}
+
+func (c *tracedCache) Set(ctx context.Context, key string, value []byte, expiration time.Duration) error {
+	ctx, span := startCacheSpan(ctx, "SET")
+	err := c.cache.Set(ctx, key, value, expiration)
+	endCacheSpan(span, err)
+	return err
+}
+
+func (c *tracedCache) Get(ctx context.Context, key string) (string, error) {
+	ctx, span := startCacheSpan(ctx, "GET")
+	value, err := c.cache.Get(ctx, key)
+	endCacheSpan(span, err)
+	return value, err
+}
+
+func (c *tracedCache) GetBytes(ctx context.Context, key string) ([]byte, error) {
+	ctx, span := startCacheSpan(ctx, "GET")
+	value, err := c.cache.GetBytes(ctx, key)
+	endCacheSpan(span, err)
+	return value, err
+}
+
+func (c *tracedCache) Delete(ctx context.Context, key string) error {
+	ctx, span := startCacheSpan(ctx, "DEL")
+	err := c.cache.Delete(ctx, key)
+	endCacheSpan(span, err)
+	return err
+}
+
+func (c *tracedCache) Exists(ctx context.Context, key string) (bool, error) {
+	ctx, span := startCacheSpan(ctx, "EXISTS")
+	ok, err := c.cache.Exists(ctx, key)
+	endCacheSpan(span, err)
+	return ok, err
+}
+
+func (c *tracedCache) SetNX(ctx context.Context, key string, value []byte, expiration time.Duration) (bool, error) {
+	ctx, span := startCacheSpan(ctx, "SETNX")
+	ok, err := c.cache.SetNX(ctx, key, value, expiration)
+	span.SetAttributes(attribute.Bool("cache.acquired", ok))
+	endCacheSpan(span, err)
+	return ok, err
+}
+
+func (c *tracedCache) GetSet(ctx context.Context, key string, value []byte) ([]byte, error) {
+	ctx, span := startCacheSpan(ctx, "GETSET")
+	result, err := c.cache.GetSet(ctx, key, value)
+	endCacheSpan(span, err)
+	return result, err
+}
+
+func (c *tracedCache) Incr(ctx context.Context, key string, expiration time.Duration) (int64, error) {
+	ctx, span := startCacheSpan(ctx, "INCR")
+	result, err := c.cache.Incr(ctx, key, expiration)
+	endCacheSpan(span, err)
+	return result, err
+}
+
+func (c *tracedCache) IncrBy(ctx context.Context, key string, delta int64, expiration time.Duration) (int64, error) {
+	ctx, span := startCacheSpan(ctx, "INCRBY")
+	result, err := c.cache.IncrBy(ctx, key, delta, expiration)
+	endCacheSpan(span, err)
+	return result, err
+}
+
+func (c *tracedCache) SetNXFenced(ctx context.Context, key, fenceKey string, suffix []byte, expiration time.Duration) (int64, error) {
+	ctx, span := startCacheSpan(ctx, "SET_NX_FENCED")
+	result, err := c.cache.SetNXFenced(ctx, key, fenceKey, suffix, expiration)
+	endCacheSpan(span, err)
+	return result, err
+}
+
+func (c *tracedCache) CompareAndDelete(ctx context.Context, key string, expected []byte) (bool, error) {
+	ctx, span := startCacheSpan(ctx, "COMPARE_AND_DELETE")
+	result, err := c.cache.CompareAndDelete(ctx, key, expected)
+	endCacheSpan(span, err)
+	return result, err
+}
+
+func (c *tracedCache) CompareAndExpire(ctx context.Context, key string, expected []byte, expiration time.Duration) (bool, error) {
+	ctx, span := startCacheSpan(ctx, "COMPARE_AND_EXPIRE")
+	result, err := c.cache.CompareAndExpire(ctx, key, expected, expiration)
+	endCacheSpan(span, err)
+	return result, err
+}
+
+func (c *tracedCache) HSet(ctx context.Context, key string, values map[string][]byte) error {
+	ctx, span := startCacheSpan(ctx, "HSET")
+	err := c.cache.HSet(ctx, key, values)
+	endCacheSpan(span, err)
+	return err
+}
+
+func (c *tracedCache) HGet(ctx context.Context, key, field string) ([]byte, error) {
+	ctx, span := startCacheSpan(ctx, "HGET")
+	result, err := c.cache.HGet(ctx, key, field)
+	endCacheSpan(span, err)
+	return result, err
+}
+
+func (c *tracedCache) HGetAll(ctx context.Context, key string) (map[string][]byte, error) {
+	ctx, span := startCacheSpan(ctx, "HGETALL")
+	result, err := c.cache.HGetAll(ctx, key)
+	endCacheSpan(span, err)
+	return result, err
+}
+
+func (c *tracedCache) HDel(ctx context.Context, key string, fields ...string) error {
+	ctx, span := startCacheSpan(ctx, "HDEL")
+	err := c.cache.HDel(ctx, key, fields...)
+	endCacheSpan(span, err)
+	return err
+}
+
+func (c *tracedCache) HIncrBy(ctx context.Context, key, field string, delta int64) (int64, error) {
+	ctx, span := startCacheSpan(ctx, "HINCRBY")
+	result, err := c.cache.HIncrBy(ctx, key, field, delta)
+	endCacheSpan(span, err)
+	return result, err
+}
+
+func (c *tracedCache) LPush(ctx context.Context, key string, values ...[]byte) (int64, error) {
+	ctx, span := startCacheSpan(ctx, "LPUSH")
+	result, err := c.cache.LPush(ctx, key, values...)
+	endCacheSpan(span, err)
+	return result, err
+}
+
+func (c *tracedCache) RPush(ctx context.Context, key string, values ...[]byte) (int64, error) {
+	ctx, span := startCacheSpan(ctx, "RPUSH")
+	result, err := c.cache.RPush(ctx, key, values...)
+	endCacheSpan(span, err)
+	return result, err
+}
+
+func (c *tracedCache) LPop(ctx context.Context, key string) ([]byte, error) {
+	ctx, span := startCacheSpan(ctx, "LPOP")
+	result, err := c.cache.LPop(ctx, key)
+	endCacheSpan(span, err)
+	return result, err
+}
+
+func (c *tracedCache) LRange(ctx context.Context, key string, start, stop int64) ([][]byte, error) {
+	ctx, span := startCacheSpan(ctx, "LRANGE")
+	result, err := c.cache.LRange(ctx, key, start, stop)
+	endCacheSpan(span, err)
+	return result, err
+}
+
+func (c *tracedCache) LLen(ctx context.Context, key string) (int64, error) {
+	ctx, span := startCacheSpan(ctx, "LLEN")
+	result, err := c.cache.LLen(ctx, key)
+	endCacheSpan(span, err)
+	return result, err
+}
+
+func (c *tracedCache) ZAdd(ctx context.Context, key string, members ...global_cache.ZMember) error {
+	ctx, span := startCacheSpan(ctx, "ZADD")
+	err := c.cache.ZAdd(ctx, key, members...)
+	endCacheSpan(span, err)
+	return err
+}
+
+func (c *tracedCache) ZRem(ctx context.Context, key string, members ...string) error {
+	ctx, span := startCacheSpan(ctx, "ZREM")
+	err := c.cache.ZRem(ctx, key, members...)
+	endCacheSpan(span, err)
+	return err
+}
+
+func (c *tracedCache) ZScore(ctx context.Context, key, member string) (float64, error) {
+	ctx, span := startCacheSpan(ctx, "ZSCORE")
+	result, err := c.cache.ZScore(ctx, key, member)
+	endCacheSpan(span, err)
+	return result, err
+}
+
+func (c *tracedCache) ZRangeByScore(ctx context.Context, key string, min, max float64, limit int64) ([]global_cache.ZMember, error) {
+	ctx, span := startCacheSpan(ctx, "ZRANGEBYSCORE")
+	result, err := c.cache.ZRangeByScore(ctx, key, min, max, limit)
+	endCacheSpan(span, err)
+	return result, err
+}
+
+func (c *tracedCache) ZCard(ctx context.Context, key string) (int64, error) {
+	ctx, span := startCacheSpan(ctx, "ZCARD")
+	result, err := c.cache.ZCard(ctx, key)
+	endCacheSpan(span, err)
+	return result, err
+}
+
+func (c *tracedCache) Expire(ctx context.Context, key string, expiration time.Duration) error {
+	ctx, span := startCacheSpan(ctx, "EXPIRE")
+	err := c.cache.Expire(ctx, key, expiration)
+	endCacheSpan(span, err)
+	return err
+}
+
+func (c *tracedCache) TTL(ctx context.Context, key string) (time.Duration, error) {
+	ctx, span := startCacheSpan(ctx, "TTL")
+	ttl, err := c.cache.TTL(ctx, key)
+	endCacheSpan(span, err)
+	return ttl, err
+}
+
+func (c *tracedCache) Ping(ctx context.Context) error {
+	// проверки здоровья не трассируем
+	return c.cache.Ping(ctx)
+}
+
+func (c *tracedCache) Close() error {
+	return c.cache.Close()
+}
+
+// функция для открытия спана операции с кэшем
+func startCacheSpan(ctx context.Context, operation string) (context.Context, trace.Span) {
+	return Start(ctx, "cache."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system.name", "redis"),
+			attribute.String("db.operation.name", operation),
+		),
+	)
+}
+
+// функция для закрытия спана: отсутствие ключа - обычный промах кэша, а не ошибка
+func endCacheSpan(span trace.Span, err error) {
+	if errors.Is(err, global_cache.ErrNotFound) {
+		span.SetAttributes(attribute.Bool("cache.hit", false))
+		err = nil
+	}
+	End(span, err)
+}
@@ -0,0 +1,249 @@
+package tracing
+
+import (
+	"context"
+	"global_models/global_db"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Проверки реализации интерфейсов
+var _ global_db.Pool = (*tracedPool)(nil)
+var _ global_db.Tx = (*tracedTx)(nil)
+
+// WrapPool оборачивает пул соединений: каждый запрос и каждая транзакция записываются спаном.
+// В спан попадает текст запроса с плейсхолдерами, значения параметров не записываются
+func WrapPool(pool global_db.Pool) global_db.Pool {
+	return &tracedPool{pool: pool}
+}
+
+// пул соединений с трассировкой
+type tracedPool struct {
+	pool global_db.Pool
+}
+
+func (p *tracedPool) Exec(ctx context.Context, sql string, args ...any) (int64, error) {
+	ctx, span := startDBSpan(ctx, sql)
+	n, err := p.pool.Exec(ctx, sql, args...)
+	span.SetAttributes(attribute.Int64("db.response.affected_rows", n))
+	End(span, err)
+	return n, err
+}
+
+func (p *tracedPool) QueryRow(ctx context.Context, sql string, args ...any) global_db.Row {
+	ctx, span := startDBSpan(ctx, sql)
+	// запрос выполняется до Scan, поэтому спан закрываем после чтения строки
+	return &tracedRow{row: p.pool.QueryRow(ctx, sql, args...), span: span}
+}
+
+func (p *tracedPool) Query(ctx context.Context, sql string, args ...any) (global_db.Rows, error) {
+	ctx, span := startDBSpan(ctx, sql)
+	rows, err := p.pool.Query(ctx, sql, args...)
+	if err != nil {
+		End(span, err)
+		return nil, err
+	}
+	return &tracedRows{Rows: rows, span: span}, nil
+}
+
+func (p *tracedPool) CopyFrom(ctx context.Context, table string, columns []string, rows [][]any) (int64, error) {
+	ctx, span := startCopySpan(ctx, table, len(rows))
+	n, err := p.pool.CopyFrom(ctx, table, columns, rows)
+	End(span, err)
+	return n, err
+}
+
+func (p *tracedPool) SendBatch(ctx context.Context, batch *global_db.Batch) global_db.BatchResults {
+	ctx, span := startBatchSpan(ctx, batch)
+	return &tracedBatch{BatchResults: p.pool.SendBatch(ctx, batch), span: span}
+}
+
+func (p *tracedPool) Begin(ctx context.Context) (global_db.Tx, error) {
+	ctx, span := startTxSpan(ctx)
+	tx, err := p.pool.Begin(ctx)
+	if err != nil {
+		End(span, err)
+		return nil, err
+	}
+	return &tracedTx{tx: tx, span: span}, nil
+}
+
+func (p *tracedPool) BeginTx(ctx context.Context, opts global_db.TxOptions) (global_db.Tx, error) {
+	ctx, span := startTxSpan(ctx,
+		attribute.String("db.transaction.isolation_level", string(opts.IsoLevel)),
+		attribute.Bool("db.transaction.read_only", opts.ReadOnly),
+	)
+	tx, err := p.pool.BeginTx(ctx, opts)
+	if err != nil {
+		End(span, err)
+		return nil, err
+	}
+	return &tracedTx{tx: tx, span: span}, nil
+}
+
+func (p *tracedPool) Stat() global_db.PoolStat {
+	return p.pool.Stat()
+}
+
+func (p *tracedPool) Ping(ctx context.Context) error {
+	// проверки здоровья не трассируем - они идут по таймеру и только засоряют трейсы
+	return p.pool.Ping(ctx)
+}
+
+func (p *tracedPool) Close() error {
+	return p.pool.Close()
+}
+
+// транзакция с трассировкой: спан транзакции живёт от Begin до Commit/Rollback,
+// запросы внутри транзакции - его дочерние спаны
+type tracedTx struct {
+	tx   global_db.Tx
+	span trace.Span
+}
+
+func (t *tracedTx) Commit(ctx context.Context) error {
+	err := t.tx.Commit(ctx)
+	t.span.SetAttributes(attribute.String("db.transaction.result", "commit"))
+	End(t.span, err)
+	return err
+}
+
+func (t *tracedTx) Rollback(ctx context.Context) error {
+	err := t.tx.Rollback(ctx)
+	t.span.SetAttributes(attribute.String("db.transaction.result", "rollback"))
+	End(t.span, err)
+	return err
+}
+
+func (t *tracedTx) Exec(ctx context.Context, sql string, args ...any) (int64, error) {
+	ctx, span := startDBSpan(t.childContext(ctx), sql)
+	n, err := t.tx.Exec(ctx, sql, args...)
+	span.SetAttributes(attribute.Int64("db.response.affected_rows", n))
+	End(span, err)
+	return n, err
+}
+
+func (t *tracedTx) QueryRow(ctx context.Context, sql string, args ...any) global_db.Row {
+	ctx, span := startDBSpan(t.childContext(ctx), sql)
+	return &tracedRow{row: t.tx.QueryRow(ctx, sql, args...), span: span}
+}
+
+func (t *tracedTx) Query(ctx context.Context, sql string, args ...any) (global_db.Rows, error) {
+	ctx, span := startDBSpan(t.childContext(ctx), sql)
+	rows, err := t.tx.Query(ctx, sql, args...)
+	if err != nil {
+		End(span, err)
+		return nil, err
+	}
+	return &tracedRows{Rows: rows, span: span}, nil
+}
+
+func (t *tracedTx) CopyFrom(ctx context.Context, table string, columns []string, rows [][]any) (int64, error) {
+	ctx, span := startCopySpan(t.childContext(ctx), table, len(rows))
+	n, err := t.tx.CopyFrom(ctx, table, columns, rows)
+	End(span, err)
+	return n, err
+}
+
+func (t *tracedTx) SendBatch(ctx context.Context, batch *global_db.Batch) global_db.BatchResults {
+	ctx, span := startBatchSpan(t.childContext(ctx), batch)
+	return &tracedBatch{BatchResults: t.tx.SendBatch(ctx, batch), span: span}
+}
+
+// метод для привязки запроса к спану транзакции
+func (t *tracedTx) childContext(ctx context.Context) context.Context {
+	return trace.ContextWithSpan(ctx, t.span)
+}
+
+// строка результата: спан закрывается после Scan
+type tracedRow struct {
+	row  global_db.Row
+	span trace.Span
+}
+
+func (r *tracedRow) Scan(dest ...any) error {
+	err := r.row.Scan(dest...)
+	End(r.span, err)
+	return err
+}
+
+// набор строк результата: спан закрывается вместе с Rows
+type tracedRows struct {
+	global_db.Rows
+	span trace.Span
+}
+
+func (r *tracedRows) Close() {
+	r.Rows.Close()
+	End(r.span, r.Rows.Err())
+}
+
+// результаты пакета: спан закрывается вместе с ними
+type tracedBatch struct {
+	global_db.BatchResults
+	span trace.Span
+}
+
+func (b *tracedBatch) Close() error {
+	err := b.BatchResults.Close()
+	End(b.span, err)
+	return err
+}
+
+// функция для открытия спана транзакции
+func startTxSpan(ctx context.Context, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return Start(ctx, "db.transaction",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("db.system.name", "postgresql")),
+		trace.WithAttributes(attrs...),
+	)
+}
+
+// функция для открытия спана пакета запросов (тексты запросов не пишем - их может быть много)
+func startBatchSpan(ctx context.Context, batch *global_db.Batch) (context.Context, trace.Span) {
+	return Start(ctx, "db.batch",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system.name", "postgresql"),
+			attribute.String("db.operation.name", "BATCH"),
+			attribute.Int("db.operation.batch.size", batch.Len()),
+		),
+	)
+}
+
+// функция для открытия спана запроса к БД
+func startDBSpan(ctx context.Context, sql string) (context.Context, trace.Span) {
+	operation := sqlOperation(sql)
+	return Start(ctx, "db."+strings.ToLower(operation),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system.name", "postgresql"),
+			attribute.String("db.operation.name", operation),
+			attribute.String("db.query.text", sql),
+		),
+	)
+}
+
+// функция для открытия спана массовой загрузки (COPY)
+func startCopySpan(ctx context.Context, table string, rows int) (context.Context, trace.Span) {
+	return Start(ctx, "db.copy",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system.name", "postgresql"),
+			attribute.String("db.operation.name", "COPY"),
+			attribute.String("db.collection.name", table),
+			attribute.Int("db.operation.batch.size", rows),
+		),
+	)
+}
+
+// функция для получения типа запроса (SELECT, INSERT, ...) по его тексту
+func sqlOperation(sql string) string {
+	fields := strings.Fields(sql)
+	if len(fields) == 0 {
+		return "QUERY"
+	}
+	return strings.ToUpper(fields[0])
+}
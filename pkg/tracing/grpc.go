@@ -0,0 +1,27 @@
+package tracing
+
+import (
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc/filters"
+	"google.golang.org/grpc"
+)
+
+// ServerOptions возвращает опции gRPC сервера: спан на каждый входящий вызов,
+// продолжающий трейс клиента (проверки grpc.health.v1 не трассируются)
+func ServerOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.StatsHandler(otelgrpc.NewServerHandler(
+			otelgrpc.WithFilter(filters.Not(filters.HealthCheck())),
+		)),
+	}
+}
+
+// DialOptions возвращает опции gRPC клиента: спан на каждый исходящий вызов
+// и передачу контекста трейса серверу в метаданных (traceparent)
+func DialOptions() []grpc.DialOption {
+	return []grpc.DialOption{
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler(
+			otelgrpc.WithFilter(filters.Not(filters.HealthCheck())),
+		)),
+	}
+}
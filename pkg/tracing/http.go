@@ -0,0 +1,61 @@
+package tracing
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Transport оборачивает http.RoundTripper спаном на каждый исходящий запрос.
+// В отличие от otelhttp в спан не пишется URL целиком - у Telegram в пути запроса лежит токен бота,
+// поэтому записываются только хост и последний сегмент пути (метод API, например sendMessage)
+func Transport(peer string, base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{peer: peer, base: base}
+}
+
+// RoundTripper с трассировкой запросов
+type transport struct {
+	peer string            // имя внешнего сервиса (префикс имени спана)
+	base http.RoundTripper // транспорт, который выполняет запрос
+}
+
+// метод для выполнения запроса внутри спана
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	operation := path.Base(req.URL.Path)
+
+	ctx, span := Start(req.Context(), fmt.Sprintf("%s %s", t.peer, operation),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("http.request.method", req.Method),
+			attribute.String("server.address", req.URL.Hostname()),
+			attribute.String("rpc.method", operation),
+		),
+	)
+	defer span.End()
+
+	// RoundTripper не должен менять исходный запрос - передаём копию с заголовками трейса
+	req = req.Clone(ctx)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+	if resp.StatusCode >= http.StatusBadRequest {
+		span.SetStatus(codes.Error, resp.Status)
+	}
+	return resp, nil
+}
@@ -0,0 +1,98 @@
+// Пакет tracing настраивает OpenTelemetry: провайдер трейсов, экспортеры,
+// пробрасывание контекста через gRPC/HTTP и обёртки над БД и кэшем
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"pkg/configs"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.39.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// имя инструментирующей библиотеки (атрибут otel.scope.name у спанов)
+const instrumentationName = "pkg/tracing"
+
+// ShutdownFunc дописывает накопленные спаны и останавливает провайдер
+type ShutdownFunc func(ctx context.Context) error
+
+// Init настраивает глобальный провайдер трейсов и пробрасывание контекста (W3C traceparent + baggage).
+// Если экспортер не задан, спаны не записываются, но контекст трассировки всё равно передаётся дальше
+func Init(ctx context.Context, conf *configs.TracingConfig, serviceName string) (ShutdownFunc, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	if !conf.Enabled() {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := newExporter(ctx, conf)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(serviceName),
+		semconv.DeploymentEnvironmentName(os.Getenv("ENV")),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build tracing resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		// решение о записи принимает тот, кто начал трейс (шлюз бота), остальные ему следуют
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(conf.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+// функция для создания экспортера из конфига
+func newExporter(ctx context.Context, conf *configs.TracingConfig) (sdktrace.SpanExporter, error) {
+	switch conf.Exporter {
+	case configs.TracingExporterStdout:
+		return stdouttrace.New(stdouttrace.WithPrettyPrint())
+	case configs.TracingExporterOTLP:
+		opts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(conf.Endpoint)}
+		if conf.Insecure {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+		return otlptracegrpc.New(ctx, opts...)
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter %q", conf.Exporter)
+	}
+}
+
+// Tracer возвращает трейсер с заданным именем (обычно - имя пакета)
+func Tracer(name string) trace.Tracer {
+	return otel.Tracer(name)
+}
+
+// Start открывает дочерний спан от спана в контексте
+func Start(ctx context.Context, spanName string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, spanName, opts...)
+}
+
+// End закрывает спан, отмечая в нём ошибку (если она есть)
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,258 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"global_models/global_cache"
+	"global_models/global_db"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// подменяем глобальный провайдер на провайдер, который складывает спаны в память
+func newRecorder(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	prevProvider, prevPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prevProvider)
+		otel.SetTextMapPropagator(prevPropagator)
+	})
+	return recorder
+}
+
+// фейковая БД: запоминает запросы и возвращает заданную ошибку
+type fakePool struct {
+	err error
+}
+
+func (p *fakePool) Exec(ctx context.Context, sql string, args ...any) (int64, error) {
+	return 1, p.err
+}
+func (p *fakePool) QueryRow(ctx context.Context, sql string, args ...any) global_db.Row {
+	return fakeRow{err: p.err}
+}
+func (p *fakePool) Query(ctx context.Context, sql string, args ...any) (global_db.Rows, error) {
+	return nil, p.err
+}
+func (p *fakePool) CopyFrom(ctx context.Context, table string, columns []string, rows [][]any) (int64, error) {
+	return int64(len(rows)), p.err
+}
+func (p *fakePool) SendBatch(ctx context.Context, batch *global_db.Batch) global_db.BatchResults {
+	return fakeBatch{err: p.err}
+}
+func (p *fakePool) Begin(ctx context.Context) (global_db.Tx, error) { return &fakeTx{}, p.err }
+func (p *fakePool) BeginTx(ctx context.Context, opts global_db.TxOptions) (global_db.Tx, error) {
+	return &fakeTx{}, p.err
+}
+func (p *fakePool) Ping(ctx context.Context) error { return nil }
+func (p *fakePool) Stat() global_db.PoolStat       { return global_db.PoolStat{} }
+func (p *fakePool) Close() error                   { return nil }
+
+type fakeBatch struct{ err error }
+
+func (b fakeBatch) Exec() (int64, error)           { return 1, b.err }
+func (b fakeBatch) QueryRow() global_db.Row        { return fakeRow{err: b.err} }
+func (b fakeBatch) Query() (global_db.Rows, error) { return nil, b.err }
+func (b fakeBatch) Close() error                   { return b.err }
+
+type fakeRow struct{ err error }
+
+func (r fakeRow) Scan(dest ...any) error { return r.err }
+
+type fakeTx struct{}
+
+func (t *fakeTx) Commit(ctx context.Context) error   { return nil }
+func (t *fakeTx) Rollback(ctx context.Context) error { return nil }
+func (t *fakeTx) Exec(ctx context.Context, sql string, args ...any) (int64, error) {
+	return 1, nil
+}
+func (t *fakeTx) QueryRow(ctx context.Context, sql string, args ...any) global_db.Row {
+	return fakeRow{}
+}
+func (t *fakeTx) Query(ctx context.Context, sql string, args ...any) (global_db.Rows, error) {
+	return nil, nil
+}
+func (t *fakeTx) CopyFrom(ctx context.Context, table string, columns []string, rows [][]any) (int64, error) {
+	return int64(len(rows)), nil
+}
+func (t *fakeTx) SendBatch(ctx context.Context, batch *global_db.Batch) global_db.BatchResults {
+	return fakeBatch{}
+}
+
+// фейковый кэш: на все чтения отвечает промахом
+type fakeCache struct {
+	global_cache.Cache
+}
+
+func (c fakeCache) Get(ctx context.Context, key string) (string, error) {
+	return "", global_cache.ErrNotFound
+}
+func (c fakeCache) Set(ctx context.Context, key string, value []byte, expiration time.Duration) error {
+	return errors.New("redis is down")
+}
+
+func TestWrapPool(t *testing.T) {
+	t.Run("запрос записывается дочерним спаном без значений параметров", func(t *testing.T) {
+		recorder := newRecorder(t)
+		ctx, parent := Start(context.Background(), "update")
+
+		pool := WrapPool(&fakePool{})
+		if _, err := pool.Exec(ctx, "INSERT INTO users (telegram_id) VALUES ($1)", int64(123456)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		parent.End()
+
+		spans := recorder.Ended()
+		if len(spans) != 2 {
+			t.Fatalf("want 2 spans, got %d", len(spans))
+		}
+		span := spans[0]
+		if span.Name() != "db.insert" {
+			t.Errorf("want span db.insert, got %s", span.Name())
+		}
+		if span.Parent().SpanID() != parent.SpanContext().SpanID() {
+			t.Error("db span must be a child of update span")
+		}
+		for _, attr := range span.Attributes() {
+			if strings.Contains(attr.Value.Emit(), "123456") {
+				t.Errorf("query args must not be recorded, got %s=%s", attr.Key, attr.Value.Emit())
+			}
+		}
+	})
+
+	t.Run("спан QueryRow закрывается после Scan и содержит ошибку", func(t *testing.T) {
+		recorder := newRecorder(t)
+		pool := WrapPool(&fakePool{err: errors.New("no rows")})
+
+		row := pool.QueryRow(context.Background(), "SELECT 1")
+		if len(recorder.Ended()) != 0 {
+			t.Fatal("span must stay open until Scan")
+		}
+		_ = row.Scan()
+
+		spans := recorder.Ended()
+		if len(spans) != 1 || spans[0].Status().Code != codes.Error {
+			t.Fatalf("want 1 errored span, got %d", len(spans))
+		}
+	})
+
+	t.Run("запросы транзакции - дочерние спаны транзакции", func(t *testing.T) {
+		recorder := newRecorder(t)
+		pool := WrapPool(&fakePool{})
+
+		tx, err := pool.Begin(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		_, _ = tx.Exec(context.Background(), "UPDATE users SET is_active = false")
+		_ = tx.Commit(context.Background())
+
+		spans := recorder.Ended()
+		if len(spans) != 2 {
+			t.Fatalf("want 2 spans, got %d", len(spans))
+		}
+		if spans[0].Parent().SpanID() != spans[1].SpanContext().SpanID() {
+			t.Error("query span must be a child of transaction span")
+		}
+	})
+
+	t.Run("спан пакета закрывается вместе с результатами", func(t *testing.T) {
+		recorder := newRecorder(t)
+		pool := WrapPool(&fakePool{})
+
+		batch := &global_db.Batch{}
+		batch.Queue("UPDATE users SET last_seen_at = now() WHERE telegram_id = $1", int64(1))
+		batch.Queue("UPDATE users SET last_seen_at = now() WHERE telegram_id = $1", int64(2))
+
+		results := pool.SendBatch(context.Background(), batch)
+		if len(recorder.Ended()) != 0 {
+			t.Fatal("span must stay open until Close")
+		}
+		_ = results.Close()
+
+		spans := recorder.Ended()
+		if len(spans) != 1 || spans[0].Name() != "db.batch" {
+			t.Fatalf("want 1 db.batch span, got %d", len(spans))
+		}
+		for _, attr := range spans[0].Attributes() {
+			if attr.Key == "db.operation.batch.size" && attr.Value.AsInt64() != 2 {
+				t.Errorf("want batch size 2, got %d", attr.Value.AsInt64())
+			}
+		}
+	})
+}
+
+func TestWrapCache(t *testing.T) {
+	t.Run("промах кэша - не ошибка", func(t *testing.T) {
+		recorder := newRecorder(t)
+		cache := WrapCache(fakeCache{})
+
+		if _, err := cache.Get(context.Background(), "user:1"); !errors.Is(err, global_cache.ErrNotFound) {
+			t.Fatalf("want ErrNotFound, got %v", err)
+		}
+
+		span := recorder.Ended()[0]
+		if span.Name() != "cache.GET" || span.Status().Code == codes.Error {
+			t.Errorf("want successful cache.GET span, got %s (%v)", span.Name(), span.Status().Code)
+		}
+	})
+
+	t.Run("ошибка кэша отмечается в спане", func(t *testing.T) {
+		recorder := newRecorder(t)
+		cache := WrapCache(fakeCache{})
+
+		if err := cache.Set(context.Background(), "user:1", nil, time.Minute); err == nil {
+			t.Fatal("want error")
+		}
+		if recorder.Ended()[0].Status().Code != codes.Error {
+			t.Error("want errored span")
+		}
+	})
+}
+
+func TestTransport(t *testing.T) {
+	t.Run("токен из пути не попадает в спан, traceparent передаётся", func(t *testing.T) {
+		recorder := newRecorder(t)
+
+		var traceparent string
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			traceparent = r.Header.Get("traceparent")
+		}))
+		defer srv.Close()
+
+		client := &http.Client{Transport: Transport("telegram", nil)}
+		resp, err := client.Get(srv.URL + "/bot123:SECRET/sendMessage")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		resp.Body.Close()
+
+		if traceparent == "" {
+			t.Error("traceparent header must be injected")
+		}
+
+		span := recorder.Ended()[0]
+		if span.Name() != "telegram sendMessage" {
+			t.Errorf("want span name 'telegram sendMessage', got %q", span.Name())
+		}
+		for _, attr := range span.Attributes() {
+			if strings.Contains(attr.Value.Emit(), "SECRET") {
+				t.Errorf("token leaked into attribute %s", attr.Key)
+			}
+		}
+	})
+}
@@ -2,8 +2,7 @@ package main
 
 import (
 	"context"
-	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
@@ -15,6 +14,11 @@ import (
 )
 
 func main() {
+	// server migrate <command> - управление схемой БД без запуска серверов
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		os.Exit(runMigrate(os.Args[2:]))
+	}
+
 	// Создаем корневой контекст
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -22,17 +26,34 @@ func main() {
 	// Инициализируем общие зависимости
 	deps, err := dependencies.InitDependencies(ctx)
 	if err != nil {
-		log.Fatalf("Failed to initialize dependencies: %v", err)
+		slog.Error("failed to initialize dependencies", "error", err)
+		os.Exit(1)
 	}
 
 	// Создаем HTTP-сервер
-	httpServer, err := httpserver.NewBizServer(ctx, deps.BizConfig.HTTPServerConf, deps.BizHTTPHandler)
+	httpServer, err := httpserver.NewBizServer(ctx, deps.BizConfig.HTTPServerConf, deps.BizHTTPHandler, deps.BizHealth, deps.BizAdminUI)
 	if err != nil {
 		panic("Failed to create server!")
 	}
 
 	// Создаём GRPC-сервер
-	grpcServer := grpcserver.NewGRPCServer(deps.BizGRPCHandler, deps.BizConfig.GRPCServerConf)
+	grpcServer := grpcserver.NewGRPCServer(deps.BizGRPCHandler, deps.BizDedup, deps.BizHealth.GRPCServer(), deps.BizConfig.GRPCServerConf)
+
+	// запускаем периодические проверки зависимостей (статус для gRPC health и /readyz)
+	go deps.BizHealth.Run(ctx)
+
+	// участвуем в выборе лидера (периодические задачи выполняет только лидер)
+	go deps.BizLeader.Run(ctx)
+
+	// запускаем задачи по расписанию (выполняет только лидер)
+	go deps.BizScheduler.Run(ctx)
+
+	// выполняем фоновые задачи из очереди (останавливается в deps.Close, дождавшись текущих задач)
+	go func() {
+		if err := deps.BizJobs.Run(ctx); err != nil {
+			slog.Error("job queue failed", "error", err)
+		}
+	}()
 
 	// создаём канал, который бдут реагировать на системные сигналы
 	sigChan := make(chan os.Signal, 1)
@@ -40,46 +61,49 @@ func main() {
 
 	// Запуск HTTP сервера
 	go func() {
-		fmt.Printf("🚀 HTTP сервер основной логики запускается на %s\n", deps.BizConfig.HTTPServerConf.Addr())
 		if err := httpServer.Run(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("HTTP Server --- failed: %v", err)
+			slog.Error("http server failed", "error", err)
+			os.Exit(1)
 		}
 	}()
 
 	// запуск GRPC сервера
 	go func() {
-		fmt.Printf("🚀 GRPC сервер основной логики запускается на %s\n", deps.BizConfig.GRPCServerConf.Addr())
 		if err := grpcServer.Run(); err != nil {
-			log.Fatalf("GRPC Server --- failed: %v", err)
+			slog.Error("grpc server failed", "error", err)
+			os.Exit(1)
 		}
 	}()
 
 	// Ожидание сигнала
 	<-sigChan
-	fmt.Println("\n🛑 Остановка сервера biz...")
+	slog.Info("stopping biz service")
+
+	// сообщаем оркестратору и клиентам, что сервер больше не принимает трафик
+	deps.BizHealth.Shutdown()
 
 	// Graceful shutdown - контекст с отменой, чтобы дать 30 сек на завершение 2х серверов
 	shutdownCtx, shutdownCancel := context.WithTimeout(ctx, 30*time.Second)
 	defer shutdownCancel()
 
 	// Останавливаем HTTP сервер (ждем текущие запросы)
-	fmt.Println("Останавливаем HTTP biz сервер...")
+	slog.Info("stopping HTTP server")
 	if err := httpServer.Shutdown(shutdownCtx); err != nil {
-		log.Printf("Error during server shutdown: %v", err)
+		slog.Error("error during server shutdown", "error", err)
 	}
 
 	// Останавливаем GRPC сервер (ждем текущие запросы)
-	fmt.Println("Останавливаем GRPC biz сервер...")
+	slog.Info("stopping gRPC server")
 	if err := grpcServer.Shutdown(shutdownCtx); err != nil {
-		log.Printf("Error during server shutdown: %v", err)
+		slog.Error("error during server shutdown", "error", err)
 	}
 
 	// Закрываем зависимости при выходе
 	err = deps.Close()
 	if err != nil {
-		log.Printf("Error during resourses closing: %v", err)
+		slog.Error("error during resources closing", "error", err)
 	}
 
-	fmt.Println("👋 Серверы остановлены")
+	slog.Info("biz servers stopped")
 
 }
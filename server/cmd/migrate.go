@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"pkg/logger"
+	"pkg/migrator"
+	"server/configs"
+	"server/migrations"
+	"text/tabwriter"
+	"time"
+)
+
+// описание команды migrate
+const migrateUsage = `usage: server migrate <command> [args]
+
+commands:
+  up              apply all pending migrations
+  down            roll back the last applied migration
+  status          show applied and pending migrations
+  create <name>   create a new SQL migration file (-dir, default from config)
+`
+
+// runMigrate выполняет команду migrate и возвращает код выхода процесса
+func runMigrate(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, migrateUsage)
+		return 2
+	}
+
+	conf, err := configs.LoadConfig()
+	if err != nil {
+		slog.Error("failed to load config", "error", err)
+		return 1
+	}
+	logger.Init(conf.LoggerConf)
+
+	command, args := args[0], args[1:]
+
+	// create работает с исходниками миграций, а не с БД
+	if command == "create" {
+		fs := flag.NewFlagSet("create", flag.ContinueOnError)
+		dir := fs.String("dir", conf.MigrationsConf.Dir, "directory with migration sources")
+		if err := fs.Parse(args); err != nil || fs.NArg() != 1 {
+			fmt.Fprint(os.Stderr, migrateUsage)
+			return 2
+		}
+		if err := migrator.Create(*dir, fs.Arg(0)); err != nil {
+			slog.Error("failed to create migration", "error", err)
+			return 1
+		}
+		return 0
+	}
+
+	m, err := migrator.New(conf.PostgresDBConf.DSN, migrations.FS, conf.MigrationsConf)
+	if err != nil {
+		slog.Error("failed to create migrator", "error", err)
+		return 1
+	}
+	defer m.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	switch command {
+	case "up":
+		err = m.Up(ctx)
+	case "down":
+		err = m.Down(ctx)
+	case "status":
+		err = printStatus(ctx, m)
+	default:
+		fmt.Fprint(os.Stderr, migrateUsage)
+		return 2
+	}
+
+	if err != nil {
+		slog.Error("migrate command failed", "command", command, "error", err)
+		return 1
+	}
+	return 0
+}
+
+// функция для вывода состояния миграций таблицей
+func printStatus(ctx context.Context, m *migrator.Migrator) error {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tSTATE\tAPPLIED AT\tFILE")
+	for _, s := range statuses {
+		appliedAt := "-"
+		if !s.AppliedAt.IsZero() {
+			appliedAt = s.AppliedAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", s.Source.Version, s.State, appliedAt, s.Source.Path)
+	}
+	return w.Flush()
+}
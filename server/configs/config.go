@@ -10,11 +10,25 @@ import (
 
 // структрура конфига для всего сервиса работы с ботами
 type BizServiceConfig struct {
-	HTTPServerConf   *configs.HttpServerConfig // конфиг для HTTP сервера
-	GRPCServerConf   *configs.GRPCServerConfig // конфиг для GRPC сервера
-	GRPCClientConfig *configs.GRPCClientConfig // конфиг для GRPC клиента
-	PostgresDBConf   *configs.PostgresDBConfig // конфиг для базы данных POSTGRES
-	RedisConf        *configs.RedisConfig      // конфиг для кэша REDIS
+	HTTPServerConf   *configs.HttpServerConfig     // конфиг для HTTP сервера
+	GRPCServerConf   *configs.GRPCServerConfig     // конфиг для GRPC сервера
+	GRPCClientConfig *configs.GRPCClientConfig     // конфиг для GRPC клиента
+	PostgresDBConf   *configs.PostgresDBConfig     // конфиг для базы данных POSTGRES
+	RedisConf        *configs.RedisConfig          // конфиг для кэша REDIS
+	IdempotencyConf  *configs.IdempotencyConfig    // конфиг для дедупликации update
+	HealthConf       *configs.HealthConfig         // конфиг проверок здоровья сервиса
+	LoggerConf       *configs.LoggerConfig         // конфиг логгера
+	TracingConf      *configs.TracingConfig        // конфиг трассировки (OpenTelemetry)
+	MigrationsConf   *configs.MigrationsConfig     // конфиг миграций схемы БД
+	UserCacheConf    *configs.UserCacheConfig      // конфиг кэша пользователей
+	MessageLogConf   *configs.MessageLogConfig     // конфиг записи журнала сообщений
+	LeaderConf       *configs.LeaderElectionConfig // конфиг выбора лидера среди экземпляров сервера
+	TieredCacheConf  *configs.TieredCacheConfig    // конфиг локального уровня кэша перед redis
+	JobQueueConf     *configs.JobQueueConfig       // конфиг очереди фоновых задач
+	SchedulerConf    *configs.SchedulerConfig      // конфиг планировщика периодических задач и таймеров
+	AdminAuthConf    *configs.AdminAuthConfig      // конфиг входа в админку и сессий администраторов
+	HandoffConf      *configs.HandoffConfig        // конфиг диалога с оператором
+	AnalyticsConf    *configs.AnalyticsConfig      // конфиг аналитики
 }
 
 // путь к .env файлу
@@ -56,11 +70,109 @@ func LoadConfig() (*BizServiceConfig, error) {
 		return nil, fmt.Errorf("Error during loading config: %s\n", err.Error())
 	}
 
+	// загружаем конфиг для дедупликации update
+	idempotencyConfig, err := configs.LoadYAMLConfig[configs.IdempotencyConfig](os.Getenv("IDEMPOTENCY_CONFIG_ADDRESS_STRING"), configs.UseDefaultIdempotencyConfig)
+	if err != nil {
+		return nil, fmt.Errorf("Error during loading config: %s\n", err.Error())
+	}
+
+	// загружаем конфиг проверок здоровья
+	healthConfig, err := configs.LoadYAMLConfig[configs.HealthConfig](os.Getenv("HEALTH_CONFIG_ADDRESS_STRING"), configs.UseDefaultHealthConfig)
+	if err != nil {
+		return nil, fmt.Errorf("Error during loading config: %s\n", err.Error())
+	}
+
+	// загружаем конфиг логгера
+	loggerConfig, err := configs.LoadYAMLConfig[configs.LoggerConfig](os.Getenv("LOGGER_CONFIG_ADDRESS_STRING"), configs.UseDefaultLoggerConfig)
+	if err != nil {
+		return nil, fmt.Errorf("Error during loading config: %s\n", err.Error())
+	}
+
+	// загружаем конфиг трассировки
+	tracingConfig, err := configs.LoadYAMLConfig[configs.TracingConfig](os.Getenv("TRACING_CONFIG_ADDRESS_STRING"), configs.UseDefaultTracingConfig)
+	if err != nil {
+		return nil, fmt.Errorf("Error during loading config: %s\n", err.Error())
+	}
+
+	// загружаем конфиг миграций
+	migrationsConfig, err := configs.LoadYAMLConfig[configs.MigrationsConfig](os.Getenv("MIGRATIONS_CONFIG_ADDRESS_STRING"), configs.UseDefaultMigrationsConfig)
+	if err != nil {
+		return nil, fmt.Errorf("Error during loading config: %s\n", err.Error())
+	}
+
+	// загружаем конфиг кэша пользователей
+	userCacheConfig, err := configs.LoadYAMLConfig[configs.UserCacheConfig](os.Getenv("USER_CACHE_CONFIG_ADDRESS_STRING"), configs.UseDefaultUserCacheConfig)
+	if err != nil {
+		return nil, fmt.Errorf("Error during loading config: %s\n", err.Error())
+	}
+
+	// загружаем конфиг журнала сообщений
+	messageLogConfig, err := configs.LoadYAMLConfig[configs.MessageLogConfig](os.Getenv("MESSAGE_LOG_CONFIG_ADDRESS_STRING"), configs.UseDefaultMessageLogConfig)
+	if err != nil {
+		return nil, fmt.Errorf("Error during loading config: %s\n", err.Error())
+	}
+
+	// загружаем конфиг выбора лидера
+	leaderConfig, err := configs.LoadYAMLConfig[configs.LeaderElectionConfig](os.Getenv("LEADER_ELECTION_CONFIG_ADDRESS_STRING"), configs.UseDefaultLeaderElectionConfig)
+	if err != nil {
+		return nil, fmt.Errorf("Error during loading config: %s\n", err.Error())
+	}
+
+	// загружаем конфиг двухуровневого кэша
+	tieredCacheConfig, err := configs.LoadYAMLConfig[configs.TieredCacheConfig](os.Getenv("TIERED_CACHE_CONFIG_ADDRESS_STRING"), configs.UseDefaultTieredCacheConfig)
+	if err != nil {
+		return nil, fmt.Errorf("Error during loading config: %s\n", err.Error())
+	}
+
+	// загружаем конфиг очереди фоновых задач
+	jobQueueConfig, err := configs.LoadYAMLConfig[configs.JobQueueConfig](os.Getenv("JOB_QUEUE_CONFIG_ADDRESS_STRING"), configs.UseDefaultJobQueueConfig)
+	if err != nil {
+		return nil, fmt.Errorf("Error during loading config: %s\n", err.Error())
+	}
+
+	// загружаем конфиг планировщика
+	schedulerConfig, err := configs.LoadYAMLConfig[configs.SchedulerConfig](os.Getenv("SCHEDULER_CONFIG_ADDRESS_STRING"), configs.UseDefaultSchedulerConfig)
+	if err != nil {
+		return nil, fmt.Errorf("Error during loading config: %s\n", err.Error())
+	}
+
+	// загружаем конфиг входа в админку
+	adminAuthConfig, err := configs.LoadYAMLConfig[configs.AdminAuthConfig](os.Getenv("ADMIN_AUTH_CONFIG_ADDRESS_STRING"), configs.UseDefaultAdminAuthConfig)
+	if err != nil {
+		return nil, fmt.Errorf("Error during loading config: %s\n", err.Error())
+	}
+
+	// загружаем конфиг диалога с оператором
+	handoffConfig, err := configs.LoadYAMLConfig[configs.HandoffConfig](os.Getenv("HANDOFF_CONFIG_ADDRESS_STRING"), configs.UseDefaultHandoffConfig)
+	if err != nil {
+		return nil, fmt.Errorf("Error during loading config: %s\n", err.Error())
+	}
+
+	// загружаем конфиг аналитики
+	analyticsConfig, err := configs.LoadYAMLConfig[configs.AnalyticsConfig](os.Getenv("ANALYTICS_CONFIG_ADDRESS_STRING"), configs.UseDefaultAnalyticsConfig)
+	if err != nil {
+		return nil, fmt.Errorf("Error during loading config: %s\n", err.Error())
+	}
+
 	return &BizServiceConfig{
 		HTTPServerConf:   serverConfig,
 		GRPCServerConf:   grpcServerConfig,
 		GRPCClientConfig: grpcClientCofig,
 		PostgresDBConf:   postgresDBConfig,
 		RedisConf:        redisConfig,
+		IdempotencyConf:  idempotencyConfig,
+		HealthConf:       healthConfig,
+		LoggerConf:       loggerConfig,
+		TracingConf:      tracingConfig,
+		MigrationsConf:   migrationsConfig,
+		UserCacheConf:    userCacheConfig,
+		MessageLogConf:   messageLogConfig,
+		LeaderConf:       leaderConfig,
+		TieredCacheConf:  tieredCacheConfig,
+		JobQueueConf:     jobQueueConfig,
+		SchedulerConf:    schedulerConfig,
+		AdminAuthConf:    adminAuthConfig,
+		HandoffConf:      handoffConfig,
+		AnalyticsConf:    analyticsConfig,
 	}, nil
 }
@@ -0,0 +1,264 @@
+// Пакет analytics - пересчёт агрегатов аналитики из сохранённых событий: дневные и недельные воронки
+// (/start -> знакомство с мастером -> согласие на связь), конверсия новых пользователей по источнику,
+// время до связи и удержание недельных когорт. Периоды считаются по часовому поясу мастера
+package analytics
+
+import (
+	"cmp"
+	"server/internal/domain"
+	"slices"
+	"time"
+)
+
+// Window - пересчитываемые периоды: дневные с DayFrom, недельные и когорты с WeekFrom (начало дня в часовом поясе мастера)
+type Window struct {
+	Loc      *time.Location
+	Now      time.Time
+	DayFrom  time.Time
+	WeekFrom time.Time
+}
+
+// NewWindow возвращает окно пересчёта: days последних дней и weeks последних недель, включая текущие
+func NewWindow(now time.Time, loc *time.Location, days, weeks int) Window {
+	today := DayStart(now, loc)
+	return Window{
+		Loc:      loc,
+		Now:      now,
+		DayFrom:  today.AddDate(0, 0, -(max(days, 1) - 1)),
+		WeekFrom: WeekStart(now, loc).AddDate(0, 0, -7*(max(weeks, 1)-1)),
+	}
+}
+
+// Since возвращает, с какого момента нужны события для пересчёта окна
+func (w Window) Since() time.Time {
+	if w.DayFrom.Before(w.WeekFrom) {
+		return w.DayFrom
+	}
+	return w.WeekFrom
+}
+
+// DayStart возвращает начало дня t в часовом поясе loc
+func DayStart(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+}
+
+// WeekStart возвращает начало недели (понедельник) t в часовом поясе loc
+func WeekStart(t time.Time, loc *time.Location) time.Time {
+	day := DayStart(t, loc)
+	return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+}
+
+// PeriodStart возвращает начало периода t в часовом поясе loc
+func PeriodStart(t time.Time, period domain.AnalyticsPeriod, loc *time.Location) time.Time {
+	if period == domain.PeriodWeek {
+		return WeekStart(t, loc)
+	}
+	return DayStart(t, loc)
+}
+
+// PeriodDate возвращает дату периода t в том виде, в каком она хранится в агрегатах (FunnelStats.Start и др.)
+func PeriodDate(t time.Time, period domain.AnalyticsPeriod, loc *time.Location) time.Time {
+	return dateOf(PeriodStart(t, period, loc))
+}
+
+// функция даты периода для хранения: та же дата, 00:00 UTC (в БД - тип DATE)
+func dateOf(start time.Time) time.Time {
+	return time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// множество пользователей
+type userSet map[int64]struct{}
+
+// метод добавления пользователя
+func (s userSet) add(telegramID int64) {
+	s[telegramID] = struct{}{}
+}
+
+// накопитель воронки одного периода
+type funnelAcc struct {
+	stats        *domain.FunnelStats
+	active       userSet
+	steps        map[string]userSet
+	contactTimes []time.Duration
+}
+
+// Compute пересчитывает агрегаты окна по событиям (facts должны покрывать window.Since())
+func Compute(facts *domain.AnalyticsFacts, window Window) *domain.AnalyticsSnapshot {
+	snapshot := &domain.AnalyticsSnapshot{
+		DayFrom:    dateOf(window.DayFrom),
+		WeekFrom:   dateOf(window.WeekFrom),
+		ComputedAt: window.Now,
+	}
+
+	// все периоды окна - в том числе пустые, чтобы в графиках не было дыр
+	funnels := make(map[domain.AnalyticsPeriod]map[time.Time]*funnelAcc)
+	periods := []struct {
+		period domain.AnalyticsPeriod
+		from   time.Time
+	}{{domain.PeriodDay, window.DayFrom}, {domain.PeriodWeek, window.WeekFrom}}
+	for _, p := range periods {
+		funnels[p.period] = make(map[time.Time]*funnelAcc)
+		for start := p.from; !start.After(window.Now); start = nextPeriod(start, p.period) {
+			stats := &domain.FunnelStats{Period: p.period, Start: dateOf(start)}
+			funnels[p.period][stats.Start] = &funnelAcc{stats: stats, active: userSet{}, steps: map[string]userSet{}}
+			snapshot.Funnels = append(snapshot.Funnels, stats)
+		}
+	}
+	// накопитель периода, в который попадает t (nil - вне окна)
+	accOf := func(t time.Time, period domain.AnalyticsPeriod) *funnelAcc {
+		return funnels[period][dateOf(PeriodStart(t, period, window.Loc))]
+	}
+	forPeriods := func(t time.Time, fn func(acc *funnelAcc)) {
+		for _, p := range periods {
+			if acc := accOf(t, p.period); acc != nil {
+				fn(acc)
+			}
+		}
+	}
+
+	for _, a := range facts.Activity {
+		forPeriods(a.Hour, func(acc *funnelAcc) { acc.active.add(a.TelegramID) })
+	}
+	stepsByUser := make(map[int64]map[string]bool)
+	for _, e := range facts.Events {
+		forPeriods(e.At, func(acc *funnelAcc) {
+			if acc.steps[e.Step] == nil {
+				acc.steps[e.Step] = userSet{}
+			}
+			acc.steps[e.Step].add(e.TelegramID)
+		})
+		if stepsByUser[e.TelegramID] == nil {
+			stepsByUser[e.TelegramID] = make(map[string]bool)
+		}
+		stepsByUser[e.TelegramID][e.Step] = true
+	}
+
+	type sourceKey struct {
+		period domain.AnalyticsPeriod
+		start  time.Time
+		source string
+	}
+	sources := make(map[sourceKey]*domain.SourceStats)
+	for _, u := range facts.Users {
+		if !u.CreatedAt.Before(facts.Since) {
+			forPeriods(u.CreatedAt, func(acc *funnelAcc) {
+				acc.stats.NewUsers++
+
+				key := sourceKey{acc.stats.Period, acc.stats.Start, u.Source}
+				source, ok := sources[key]
+				if !ok {
+					source = &domain.SourceStats{Period: key.period, Start: key.start, Source: key.source}
+					sources[key] = source
+				}
+				source.NewUsers++
+				if stepsByUser[u.TelegramID][domain.FunnelLookup] {
+					source.Lookups++
+				}
+				if u.FirstContactAt != nil {
+					source.ContactedYes++
+				}
+			})
+		}
+		if u.FirstContactAt != nil {
+			// время до связи - от появления в боте (created_at): /start есть не у всех (писали сразу текстом
+			// или сервер был недоступен, а /start в очередь не ставится)
+			contactTime := max(u.FirstContactAt.Sub(u.CreatedAt), 0)
+			forPeriods(*u.FirstContactAt, func(acc *funnelAcc) { acc.contactTimes = append(acc.contactTimes, contactTime) })
+		}
+	}
+
+	for _, byStart := range funnels {
+		for _, acc := range byStart {
+			acc.stats.ActiveUsers = int64(len(acc.active))
+			acc.stats.Started = int64(len(acc.steps[domain.FunnelStart]))
+			acc.stats.Lookups = int64(len(acc.steps[domain.FunnelLookup]))
+			acc.stats.ContactedYes = int64(len(acc.steps[domain.FunnelContactedYes]))
+			acc.stats.Contacts = int64(len(acc.contactTimes))
+			acc.stats.ContactTimeAvg, acc.stats.ContactTimeMedian = avgMedian(acc.contactTimes)
+		}
+	}
+
+	for _, source := range sources {
+		snapshot.Sources = append(snapshot.Sources, source)
+	}
+	slices.SortFunc(snapshot.Sources, func(a, b *domain.SourceStats) int {
+		return cmp.Or(cmp.Compare(a.Period, b.Period), a.Start.Compare(b.Start), cmp.Compare(a.Source, b.Source))
+	})
+
+	snapshot.Cohorts = cohorts(facts, window)
+	return snapshot
+}
+
+// функция начала следующего периода
+func nextPeriod(start time.Time, period domain.AnalyticsPeriod) time.Time {
+	if period == domain.PeriodWeek {
+		return start.AddDate(0, 0, 7)
+	}
+	return start.AddDate(0, 0, 1)
+}
+
+// функция среднего и медианы (0 - выборка пуста)
+func avgMedian(values []time.Duration) (time.Duration, time.Duration) {
+	if len(values) == 0 {
+		return 0, 0
+	}
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+
+	var sum time.Duration
+	for _, v := range sorted {
+		sum += v
+	}
+	median := sorted[len(sorted)/2]
+	if len(sorted)%2 == 0 {
+		median = (sorted[len(sorted)/2-1] + sorted[len(sorted)/2]) / 2
+	}
+	return sum / time.Duration(len(sorted)), median
+}
+
+// функция удержания недельных когорт окна: для когорты - сколько её пользователей были активны
+// в неделю прихода и в каждую следующую неделю до текущей
+func cohorts(facts *domain.AnalyticsFacts, window Window) []*domain.CohortStats {
+	cohortOf := make(map[int64]time.Time) // пользователь -> начало недели прихода
+	sizes := make(map[time.Time]int64)
+	for _, u := range facts.Users {
+		if u.CreatedAt.Before(window.WeekFrom) {
+			continue
+		}
+		start := WeekStart(u.CreatedAt, window.Loc)
+		cohortOf[u.TelegramID] = start
+		sizes[start]++
+	}
+
+	type weekUser struct {
+		week       time.Time
+		telegramID int64
+	}
+	activeWeeks := make(map[weekUser]struct{})
+	for _, a := range facts.Activity {
+		if _, ok := cohortOf[a.TelegramID]; ok {
+			activeWeeks[weekUser{WeekStart(a.Hour, window.Loc), a.TelegramID}] = struct{}{}
+		}
+	}
+
+	current := WeekStart(window.Now, window.Loc)
+	var result []*domain.CohortStats
+	for start := window.WeekFrom; !start.After(current); start = start.AddDate(0, 0, 7) {
+		if sizes[start] == 0 {
+			continue
+		}
+		weeks := int(current.Sub(start).Hours()/24+0.5)/7 + 1
+		cohort := &domain.CohortStats{Start: dateOf(start), Size: sizes[start], Retained: make([]int64, weeks)}
+		for key := range activeWeeks {
+			if cohortOf[key.telegramID].Equal(start) {
+				offset := int(key.week.Sub(start).Hours()/24+0.5) / 7
+				if offset >= 0 && offset < weeks {
+					cohort.Retained[offset]++
+				}
+			}
+		}
+		result = append(result, cohort)
+	}
+	return result
+}
@@ -0,0 +1,165 @@
+package analytics
+
+import (
+	"server/internal/domain"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestCompute(t *testing.T) {
+	loc := time.FixedZone("UTC+3", 3*60*60)
+	// среда, 15 октября 2025, 12:00 по мастеру
+	now := time.Date(2025, 10, 15, 12, 0, 0, 0, loc)
+	at := func(day, hour int) time.Time { return time.Date(2025, 10, day, hour, 0, 0, 0, loc) }
+	date := func(day int) time.Time { return time.Date(2025, 10, day, 0, 0, 0, 0, time.UTC) }
+
+	t.Run("окно пересчёта по часовому поясу мастера", func(t *testing.T) {
+		window := NewWindow(now, loc, 3, 2)
+		if !window.DayFrom.Equal(at(13, 0)) {
+			t.Errorf("DayFrom = %v", window.DayFrom)
+		}
+		// неделя с понедельника 13 октября, предыдущая - с 6 октября
+		if !window.WeekFrom.Equal(at(6, 0)) {
+			t.Errorf("WeekFrom = %v", window.WeekFrom)
+		}
+		if !window.Since().Equal(at(6, 0)) {
+			t.Errorf("Since = %v", window.Since())
+		}
+		// 23:30 UTC 14-го - уже 15-е по мастеру
+		if got := DayStart(time.Date(2025, 10, 14, 23, 30, 0, 0, time.UTC), loc); !got.Equal(at(15, 0)) {
+			t.Errorf("DayStart = %v", got)
+		}
+	})
+
+	t.Run("воронка за день и неделю", func(t *testing.T) {
+		contact := at(14, 13)
+		facts := &domain.AnalyticsFacts{
+			Since: at(6, 0),
+			Users: []domain.AnalyticsUser{
+				{TelegramID: 1, CreatedAt: at(14, 10), Source: "instagram", FirstContactAt: &contact},
+				{TelegramID: 2, CreatedAt: at(14, 11), Source: domain.DirectSource},
+				{TelegramID: 3, CreatedAt: at(15, 9), Source: "instagram"},
+			},
+			Events: []domain.FunnelEvent{
+				{TelegramID: 1, Step: domain.FunnelStart, At: at(14, 10)},
+				{TelegramID: 1, Step: domain.FunnelLookup, At: at(14, 11)},
+				{TelegramID: 1, Step: domain.FunnelLookup, At: at(14, 12)},
+				{TelegramID: 1, Step: domain.FunnelContactedYes, At: contact},
+				{TelegramID: 2, Step: domain.FunnelStart, At: at(14, 11)},
+				{TelegramID: 3, Step: domain.FunnelStart, At: at(15, 9)},
+			},
+			Activity: []domain.UserActivity{
+				{TelegramID: 1, Hour: at(14, 10)},
+				{TelegramID: 1, Hour: at(14, 11)},
+				{TelegramID: 2, Hour: at(14, 11)},
+				{TelegramID: 3, Hour: at(15, 9)},
+				{TelegramID: 1, Hour: at(15, 10)},
+			},
+		}
+		snapshot := Compute(facts, NewWindow(now, loc, 3, 2))
+
+		funnel := func(period domain.AnalyticsPeriod, start time.Time) domain.FunnelStats {
+			t.Helper()
+			for _, f := range snapshot.Funnels {
+				if f.Period == period && f.Start.Equal(start) {
+					return *f
+				}
+			}
+			t.Fatalf("нет воронки %s %v", period, start)
+			return domain.FunnelStats{}
+		}
+
+		day := funnel(domain.PeriodDay, date(14))
+		want := domain.FunnelStats{
+			Period: domain.PeriodDay, Start: date(14), NewUsers: 2, ActiveUsers: 2,
+			Started: 2, Lookups: 1, ContactedYes: 1,
+			Contacts: 1, ContactTimeAvg: 3 * time.Hour, ContactTimeMedian: 3 * time.Hour,
+		}
+		if day != want {
+			t.Errorf("день 14:\n got %+v\nwant %+v", day, want)
+		}
+		if empty := funnel(domain.PeriodDay, date(13)); empty.NewUsers != 0 || empty.ActiveUsers != 0 {
+			t.Errorf("пустой день тоже сохраняется с нулями: %+v", empty)
+		}
+		if today := funnel(domain.PeriodDay, date(15)); today.NewUsers != 1 || today.ActiveUsers != 2 || today.Started != 1 {
+			t.Errorf("день 15: %+v", today)
+		}
+
+		week := funnel(domain.PeriodWeek, date(13))
+		if week.NewUsers != 3 || week.ActiveUsers != 3 || week.Started != 3 || week.Lookups != 1 || week.ContactedYes != 1 {
+			t.Errorf("неделя: %+v", week)
+		}
+		if len(snapshot.Funnels) != 3+2 {
+			t.Errorf("периодов: %d, ждём 3 дня и 2 недели", len(snapshot.Funnels))
+		}
+	})
+
+	t.Run("конверсия по источникам", func(t *testing.T) {
+		contact := at(14, 13)
+		facts := &domain.AnalyticsFacts{
+			Since: at(6, 0),
+			Users: []domain.AnalyticsUser{
+				{TelegramID: 1, CreatedAt: at(14, 10), Source: "instagram", FirstContactAt: &contact},
+				{TelegramID: 2, CreatedAt: at(14, 11), Source: "instagram"},
+				{TelegramID: 3, CreatedAt: at(14, 12), Source: domain.DirectSource},
+				// пришёл до окна, согласился в окне: в воронке источников его нет
+				{TelegramID: 4, CreatedAt: at(1, 12), Source: "vk", FirstContactAt: &contact},
+			},
+			Events: []domain.FunnelEvent{
+				{TelegramID: 1, Step: domain.FunnelLookup, At: at(14, 11)},
+				{TelegramID: 3, Step: domain.FunnelLookup, At: at(14, 12)},
+			},
+		}
+		snapshot := Compute(facts, NewWindow(now, loc, 3, 2))
+
+		var got []domain.SourceStats
+		for _, s := range snapshot.Sources {
+			if s.Period == domain.PeriodDay {
+				got = append(got, *s)
+			}
+		}
+		want := []domain.SourceStats{
+			{Period: domain.PeriodDay, Start: date(14), Source: domain.DirectSource, NewUsers: 1, Lookups: 1},
+			{Period: domain.PeriodDay, Start: date(14), Source: "instagram", NewUsers: 2, Lookups: 1, ContactedYes: 1},
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("источники:\n got %+v\nwant %+v", got, want)
+		}
+
+		day := snapshot.Funnels[1]
+		if day.Contacts != 2 || day.ContactTimeAvg != (3*time.Hour+13*24*time.Hour+time.Hour)/2 || day.ContactTimeMedian != day.ContactTimeAvg {
+			t.Errorf("время до связи: %+v", day)
+		}
+	})
+
+	t.Run("удержание недельных когорт", func(t *testing.T) {
+		facts := &domain.AnalyticsFacts{
+			Since: at(6, 0),
+			Users: []domain.AnalyticsUser{
+				{TelegramID: 1, CreatedAt: at(7, 10), Source: domain.DirectSource},
+				{TelegramID: 2, CreatedAt: at(8, 10), Source: domain.DirectSource},
+				{TelegramID: 3, CreatedAt: at(14, 10), Source: domain.DirectSource},
+			},
+			Activity: []domain.UserActivity{
+				{TelegramID: 1, Hour: at(7, 10)},
+				{TelegramID: 2, Hour: at(8, 10)},
+				{TelegramID: 1, Hour: at(13, 9)},
+				{TelegramID: 1, Hour: at(15, 9)},
+				{TelegramID: 3, Hour: at(14, 10)},
+			},
+		}
+		snapshot := Compute(facts, NewWindow(now, loc, 3, 2))
+
+		if len(snapshot.Cohorts) != 2 {
+			t.Fatalf("когорт: %d", len(snapshot.Cohorts))
+		}
+		first, second := snapshot.Cohorts[0], snapshot.Cohorts[1]
+		if !first.Start.Equal(date(6)) || first.Size != 2 || !slices.Equal(first.Retained, []int64{2, 1}) {
+			t.Errorf("когорта 6 октября: %+v", first)
+		}
+		if !second.Start.Equal(date(13)) || second.Size != 1 || !slices.Equal(second.Retained, []int64{1}) {
+			t.Errorf("когорта 13 октября: %+v", second)
+		}
+	})
+}
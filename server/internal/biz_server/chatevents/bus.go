@@ -0,0 +1,142 @@
+// Пакет chatevents - события чатов для консоли оператора: сообщения клиента, бота и оператора,
+// открытие и закрытие диалога с оператором. Update может обработать один экземпляр сервера,
+// а консоль открыта на другом, поэтому события рассылаются через pub/sub (redis) и раздаются
+// подписчикам каждого экземпляра. Pub/sub не гарантирует доставку: консоль при переподключении
+// перечитывает переписку из БД
+package chatevents
+
+import (
+	"context"
+	"encoding/json"
+	"global_models/global_cache"
+	"log/slog"
+	"pkg/logger"
+	"server/internal/domain"
+	"sync"
+	"time"
+)
+
+// пауза перед повторной подпиской после ошибки
+const resubscribeDelay = time.Second
+
+// сколько событий может ждать отправки в консоль (остальные отбрасываются)
+const subscriberBuffer = 64
+
+// подписчик событий одного чата (telegramID == 0 - всех чатов)
+type subscription struct {
+	telegramID int64
+	events     chan domain.ChatEvent
+}
+
+// Bus - рассылка событий чатов между экземплярами сервера
+type Bus struct {
+	pubsub  global_cache.PubSub
+	channel string
+
+	mu   sync.Mutex
+	subs map[*subscription]struct{}
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// конструктор для шины событий (подписка на канал работает до Close)
+func New(pubsub global_cache.PubSub, channel string) *Bus {
+	ctx, cancel := context.WithCancel(context.Background())
+	b := &Bus{
+		pubsub:  pubsub,
+		channel: channel,
+		subs:    make(map[*subscription]struct{}),
+		cancel:  cancel,
+		done:    make(chan struct{}),
+	}
+	go b.listen(ctx)
+	return b
+}
+
+// Publish рассылает событие консолям всех экземпляров (на nil шине ничего не делает)
+func (b *Bus) Publish(ctx context.Context, event domain.ChatEvent) error {
+	if b == nil {
+		return nil
+	}
+	if event.At.IsZero() {
+		event.At = time.Now()
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return b.pubsub.Publish(ctx, b.channel, payload)
+}
+
+// Subscribe подписывает на события чата (telegramID == 0 - всех чатов) и возвращает функцию отписки.
+// Подписчик, который не успевает читать, теряет события
+func (b *Bus) Subscribe(telegramID int64) (<-chan domain.ChatEvent, func()) {
+	sub := &subscription{telegramID: telegramID, events: make(chan domain.ChatEvent, subscriberBuffer)}
+
+	b.mu.Lock()
+	b.subs[sub] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	return sub.events, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subs, sub)
+			b.mu.Unlock()
+		})
+	}
+}
+
+// метод освобождения ресурсов: останавливает подписку на канал
+func (b *Bus) Close() error {
+	b.cancel()
+	<-b.done
+	return nil
+}
+
+// фоновая подписка на канал (переподписывается после ошибок до отмены ctx)
+func (b *Bus) listen(ctx context.Context) {
+	defer close(b.done)
+
+	for {
+		err := b.pubsub.Subscribe(ctx, b.channel, b.onMessage)
+		if ctx.Err() != nil {
+			return
+		}
+		slog.Warn("chat events: subscription failed", "channel", b.channel, "error", err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(resubscribeDelay):
+		}
+	}
+}
+
+// обработчик сообщения канала: раздаёт событие подписчикам этого экземпляра
+func (b *Bus) onMessage(payload []byte) {
+	// подписка (пере)установлена - событий для раздачи нет
+	if payload == nil {
+		return
+	}
+
+	var event domain.ChatEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		slog.Warn("chat events: malformed event", "error", err)
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for sub := range b.subs {
+		if sub.telegramID != 0 && sub.telegramID != event.TelegramID {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+			slog.Debug("chat events: subscriber is too slow, event dropped", "chat_id", logger.MaskID(event.TelegramID))
+		}
+	}
+}
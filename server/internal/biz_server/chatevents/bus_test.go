@@ -0,0 +1,100 @@
+package chatevents
+
+import (
+	"context"
+	memorycache "pkg/memory_cache"
+	"server/internal/domain"
+	"testing"
+	"time"
+)
+
+// функция ожидания события (nil - событие не пришло)
+func receive(events <-chan domain.ChatEvent, wait time.Duration) *domain.ChatEvent {
+	select {
+	case event := <-events:
+		return &event
+	case <-time.After(wait):
+		return nil
+	}
+}
+
+// функция ожидания подписки шины на канал: пробные события публикуются, пока одно не придёт
+func waitReady(t *testing.T, bus *Bus) {
+	t.Helper()
+	events, unsubscribe := bus.Subscribe(-1)
+	defer unsubscribe()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if err := bus.Publish(context.Background(), domain.ChatEvent{Type: domain.ChatEventMessage, TelegramID: -1}); err != nil {
+			t.Fatalf("Publish: %v", err)
+		}
+		if receive(events, 10*time.Millisecond) != nil {
+			return
+		}
+	}
+	t.Fatal("шина не подписалась на канал")
+}
+
+func TestBus(t *testing.T) {
+	ps := memorycache.NewMemoryCache(time.Minute)
+	defer ps.Close()
+
+	// два экземпляра сервера с общим pub/sub
+	first, second := New(ps, "chat_events"), New(ps, "chat_events")
+	defer first.Close()
+	defer second.Close()
+	waitReady(t, first)
+	waitReady(t, second)
+	ctx := context.Background()
+
+	t.Run("событие чата приходит подписчикам на другом экземпляре", func(t *testing.T) {
+		chat, unsubscribeChat := second.Subscribe(42)
+		defer unsubscribeChat()
+		all, unsubscribeAll := second.Subscribe(0)
+		defer unsubscribeAll()
+
+		if err := first.Publish(ctx, domain.ChatEvent{Type: domain.ChatEventMessage, TelegramID: 42, Direction: "incoming", Text: "Здравствуйте"}); err != nil {
+			t.Fatalf("Publish: %v", err)
+		}
+		event := receive(chat, time.Second)
+		if event == nil || event.Text != "Здравствуйте" || event.At.IsZero() {
+			t.Fatalf("подписчик чата: %+v", event)
+		}
+		if event := receive(all, time.Second); event == nil || event.TelegramID != 42 {
+			t.Fatalf("подписчик всех чатов: %+v", event)
+		}
+	})
+
+	t.Run("события других чатов не приходят", func(t *testing.T) {
+		chat, unsubscribe := second.Subscribe(42)
+		defer unsubscribe()
+
+		if err := first.Publish(ctx, domain.ChatEvent{Type: domain.ChatEventHandoffOpened, TelegramID: 7}); err != nil {
+			t.Fatalf("Publish: %v", err)
+		}
+		if event := receive(chat, 50*time.Millisecond); event != nil {
+			t.Fatalf("пришло чужое событие: %+v", event)
+		}
+	})
+
+	t.Run("после отписки события не приходят", func(t *testing.T) {
+		chat, unsubscribe := second.Subscribe(42)
+		unsubscribe()
+		unsubscribe()
+
+		if err := first.Publish(ctx, domain.ChatEvent{Type: domain.ChatEventMessage, TelegramID: 42}); err != nil {
+			t.Fatalf("Publish: %v", err)
+		}
+		if event := receive(chat, 50*time.Millisecond); event != nil {
+			t.Fatalf("событие после отписки: %+v", event)
+		}
+	})
+
+	t.Run("nil шина не публикует", func(t *testing.T) {
+		var bus *Bus
+		if err := bus.Publish(ctx, domain.ChatEvent{TelegramID: 42}); err != nil {
+			t.Fatalf("Publish: %v", err)
+		}
+	})
+}
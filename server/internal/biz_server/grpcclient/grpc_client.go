@@ -1,11 +1,12 @@
 package grpcclient
 
 import (
-	"fmt"
+	"context"
 	"pkg/configs"
+	"pkg/grpcconn"
+	"pkg/health"
 
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
 
 	// Импортируем сгенерированный из proto файла пакет
 	// pb - это псевдоним (alias) для удобства использования
@@ -15,31 +16,27 @@ import (
 // BotGrpcClient представляет gRPC клиент для сервиса бота
 // Инкапсулирует соединение и сгенерированный клиент
 type BotGrpcClient struct {
-	conn   *grpc.ClientConn    // Физическое соединение с сервером
-	client pb.BotServiceClient // Сгенерированный клиент для вызова методов
+	conn   *grpc.ClientConn                // Физическое соединение с сервером
+	client pb.BotServiceClient             // Сгенерированный клиент для вызова методов
+	health func(ctx context.Context) error // проверка доступности сервера бота (grpc.health.v1)
 }
 
 // NewBotGrpcClient создает новый gRPC клиент и устанавливает соединение с сервером
 // serverAddr - адрес сервера в формате "host:port" (например "localhost:50052")
 func NewBotGrpcClient(config *configs.GRPCClientConfig) (*BotGrpcClient, error) {
-	// Создаем клиент
-	conn, err := grpc.NewClient(
-		config.Addr(),
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-	)
+	// Создаем соединение (повторы, дедлайн по умолчанию, keepalive, TLS и токен - из конфига)
+	conn, err := grpcconn.NewClient(config, pb.BotService_ServiceDesc.ServiceName)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create gRPC client: %w", err)
+		return nil, err
 	}
 
-	// Инициируем соединение
-	conn.Connect()
-
 	// Проверяем состояние сразу
 	client := pb.NewBotServiceClient(conn)
 
 	return &BotGrpcClient{
 		conn:   conn,
 		client: client,
+		health: health.GRPCPeerCheck(conn, ""),
 	}, nil
 }
 
@@ -49,6 +46,16 @@ func (c *BotGrpcClient) ShutDown() error {
 	return c.conn.Close()
 }
 
+// CheckHealth проверяет, что gRPC сервер бота доступен и готов принимать вызовы
+func (c *BotGrpcClient) CheckHealth(ctx context.Context) error {
+	return c.health(ctx)
+}
+
+// SendMessage просит бота отправить сообщение клиенту (сообщения из админки)
+func (c *BotGrpcClient) SendMessage(ctx context.Context, req *pb.SendMessageRequest) (*pb.SendMessageResponse, error) {
+	return c.client.SendMessage(ctx, req)
+}
+
 // Необходимо будет использовать только нужные методы grpc сервера
 
 /*
@@ -58,9 +65,4 @@ func (c *BotGrpcClient) ProcessUpdate(ctx context.Context, req *pb.UpdateRequest
 	// Запрос автоматически сериализуется в protobuf и отправляется по gRPC
 	return c.client.ProcessUpdate(ctx, req)
 }
-
-// SendMessage отправляет запрос на отправку сообщения от бота
-func (c *BotGrpcClient) SendMessage(ctx context.Context, req *pb.SendMessageRequest) (*pb.SendMessageResponse, error) {
-	return c.client.SendMessage(ctx, req)
-}
 */
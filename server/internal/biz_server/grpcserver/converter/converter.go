@@ -3,7 +3,7 @@ package converter
 
 import (
 	pb "global_models/grpc/bot"
-	"log"
+	"log/slog"
 	"server/internal/domain"
 
 	"time"
@@ -65,11 +65,10 @@ func ToProtoResponse(resp *domain.MessageResponse) *pb.SendMessageResponse {
 //
 // Поле ID оставляем пустым - его потом база данных сама присвоит
 func ToCallbackLog(callback *pb.CallbackQuery) *domain.CallbackLog {
-	defer func() {
-		if recover() != nil {
-			log.Println("Panic")
-		}
-	}()
+	// Если колбэка нет - возвращаем nil
+	if callback == nil {
+		return nil
+	}
 
 	// Защита от nil полей
 	var userNickName, userFirstName, userLastName string
@@ -79,7 +78,7 @@ func ToCallbackLog(callback *pb.CallbackQuery) *domain.CallbackLog {
 		userFirstName = callback.From.FirstName
 		userLastName = callback.From.LastName
 	} else {
-		log.Printf("⚠️ Warning: callback.From is nil for callback ID: %s", callback.Id)
+		slog.Warn("callback.From is nil", "callback_id", callback.Id)
 		// Можно попробовать получить данные из других полей, если они есть
 		// Или оставить пустыми строками
 	}
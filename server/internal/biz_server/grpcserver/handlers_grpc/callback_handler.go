@@ -4,6 +4,10 @@ import (
 	"context"
 	"fmt"
 	pb "global_models/grpc/bot"
+	"log/slog"
+	"pkg/logger"
+	"pkg/metrics"
+	"pkg/tracing"
 	"server/internal/biz_server/grpcserver/converter"
 	"server/internal/domain"
 )
@@ -20,6 +24,9 @@ type callbackContext struct {
 
 // ProcessCallback - обработка callback от inline клавиатуры
 func (b *BizGRPCHandler) ProcessCallback(ctx context.Context, callback *pb.CallbackQuery) (*pb.UpdateResponse, error) {
+	ctx, span := tracing.Start(ctx, "handler.ProcessCallback")
+	defer span.End()
+
 	// 1. Валидация
 	if err := b.validateCallback(callback); err != nil {
 		return nil, err
@@ -55,14 +62,10 @@ func (b *BizGRPCHandler) buildCallbackContext(ctx context.Context, callback *pb.
 	// конвертируем grpc колбэк в доменную структуру
 	callbackLog := converter.ToCallbackLog(callback)
 
-	// сохраняем/обновляем пользователя
-	user, err := b.Service.Users.RegisterOrUpdate(ctx,
-		callbackLog.MessageID,
-		callbackLog.UserFirstName,
-		callbackLog.UserLastName,
-		callbackLog.UserNickName)
+	// сохраняем/обновляем пользователя (профиль - из отправителя колбэка, pb.User)
+	user, _, err := b.Service.Users.RegisterOrUpdate(ctx, converter.ToDomainUser(callback.From))
 	if err != nil {
-		fmt.Printf("⚠️ Failed to save user: %v", err)
+		slog.WarnContext(ctx, "failed to save user", "error", err)
 	}
 
 	// заполняем структуру контекста колбэка
@@ -81,27 +84,27 @@ func (b *BizGRPCHandler) saveCallback(cbCtx *callbackContext) {
 
 	// Сохраняем callback в БД
 	if err := b.Service.Messages.CheckAndSaveCallBack(cbCtx.ctx, cbCtx.callback); err != nil {
-		fmt.Printf("⚠️ Failed to save callback: %v", err)
+		slog.WarnContext(cbCtx.ctx, "failed to save callback", "error", err)
 	}
 }
 
-// метод для логирования колбэка
+// метод для логирования колбэка (персональные данные маскируем)
 func (b *BizGRPCHandler) logCallback(cbCtx *callbackContext) {
 	// Используем сохраненные данные для логирования
 	userName := "unknown"
 	if cbCtx.user != nil {
 		userName = cbCtx.user.Username
 		if userName == "" {
-			userName = fmt.Sprintf("%s %s", cbCtx.user.FirstName, cbCtx.user.LastName)
+			userName = cbCtx.user.FirstName
 		}
 	}
 
-	fmt.Printf("🔘 Callback processed: ID=%s, User=%s (ID=%d), ChatID=%d, Data=%s",
-		cbCtx.callback.ID,
-		userName,
-		cbCtx.userID,
-		cbCtx.chatID,
-		cbCtx.callbackData)
+	slog.InfoContext(cbCtx.ctx, "callback processed",
+		"callback_id", cbCtx.callback.CallbackID,
+		"user", logger.MaskName(userName),
+		"user_id", logger.MaskID(cbCtx.userID),
+		"chat_id", logger.MaskID(cbCtx.chatID),
+		"data", cbCtx.callbackData)
 }
 
 // метод возвращения ответа в grpc формате
@@ -109,6 +112,12 @@ func (b *BizGRPCHandler) handleCallbackAction(cbCtx *callbackContext) (*pb.Updat
 	// формируем успешный вариант (оптимистичный прогноз)
 	response := &pb.UpdateResponse{Success: true}
 
+	// пока чат ведёт оператор, бот не отвечает и на кнопки (кроме повторного "Позвать мастера")
+	if cbCtx.callbackData != domain.HandoffCallback && b.Service.Handoffs.Intercept(cbCtx.ctx, cbCtx.userID) {
+		slog.DebugContext(cbCtx.ctx, "callback ignored during handoff", "data", cbCtx.callbackData)
+		return response, nil
+	}
+
 	// объявляем кастомный тип для работы с хэндлерами
 	type handler func(*callbackContext) *pb.UpdateResponse
 
@@ -119,13 +128,14 @@ func (b *BizGRPCHandler) handleCallbackAction(cbCtx *callbackContext) (*pb.Updat
 		"menu":          b.handleMenuCallback,
 		"contacted_yes": b.handleContactedYes,
 		"contacted_no":  b.handleContactedNo,
+
+		domain.HandoffCallback: b.handleHandoffCallback,
 	}
 
 	// если в мапе есть такой обработчик - то вызываем его и возвращаем результат
 	if handler, exists := handlers[cbCtx.callbackData]; exists {
 		result := handler(cbCtx)
-		fmt.Printf("✅ Callback '%s' handled successfully for user %d",
-			cbCtx.callbackData, cbCtx.userID)
+		slog.DebugContext(cbCtx.ctx, "callback handled", "data", cbCtx.callbackData)
 		return result, nil
 	}
 
@@ -136,8 +146,9 @@ func (b *BizGRPCHandler) handleCallbackAction(cbCtx *callbackContext) (*pb.Updat
 		Text:   fmt.Sprintf("❓ Неизвестная команда: %s", cbCtx.callbackData),
 	})
 
-	fmt.Printf("⚠️ Unknown callback command: %s from user %d",
-		cbCtx.callbackData, cbCtx.userID)
+	slog.WarnContext(cbCtx.ctx, "unknown callback command",
+		"data", cbCtx.callbackData,
+		"user_id", logger.MaskID(cbCtx.userID))
 
 	return response, nil
 }
@@ -149,7 +160,7 @@ func (b *BizGRPCHandler) handleHelpCallback(cbCtx *callbackContext) *pb.UpdateRe
 		Messages: []*pb.OutgoingMessage{
 			{
 				ChatId: cbCtx.chatID,
-				Text:   "🤖 Я бот-помощник. Доступные команды:\n/help - помощь\n/menu - главное меню",
+				Text:   b.Service.Content.Text(cbCtx.ctx, domain.ContentHelp),
 			},
 		},
 	}
@@ -157,6 +168,8 @@ func (b *BizGRPCHandler) handleHelpCallback(cbCtx *callbackContext) *pb.UpdateRe
 
 // обработчик для колбэка "lookup"
 func (b *BizGRPCHandler) handleLookupCallback(cbCtx *callbackContext) *pb.UpdateResponse {
+	metrics.BusinessEvent(metrics.EventLookup)
+
 	btns := [][]domain.InlineButton{
 		{
 			{Text: "🔗 Перейти в Instagram", URL: "https://www.instagram.com/..."},
@@ -175,7 +188,7 @@ func (b *BizGRPCHandler) handleLookupCallback(cbCtx *callbackContext) *pb.Update
 		Messages: []*pb.OutgoingMessage{
 			{
 				ChatId:      cbCtx.chatID,
-				Text:        "📸 Вот ссылка на Instagram аккаунт мастера:\nПосле просмотра, пожалуйста, выберите вариант:",
+				Text:        b.Service.Content.Text(cbCtx.ctx, domain.ContentLookup),
 				ReplyMarkup: converter.ToProtoReplyMarkup(replyMarkup),
 			},
 		},
@@ -189,6 +202,9 @@ func (b *BizGRPCHandler) handleMenuCallback(cbCtx *callbackContext) *pb.UpdateRe
 			{Text: "🆘 Помощь", CallbackData: "help"},
 			{Text: "🔍 Ознакомиться", CallbackData: "lookup"},
 		},
+		{
+			{Text: domain.HandoffButtonText, CallbackData: domain.HandoffCallback},
+		},
 	}
 
 	replyMarkup := &domain.ReplyMarkup{InlineKeyboard: btns}
@@ -198,7 +214,7 @@ func (b *BizGRPCHandler) handleMenuCallback(cbCtx *callbackContext) *pb.UpdateRe
 		Messages: []*pb.OutgoingMessage{
 			{
 				ChatId:      cbCtx.chatID,
-				Text:        "🏠 Главное меню",
+				Text:        b.Service.Content.Text(cbCtx.ctx, domain.ContentMainMenu),
 				ReplyMarkup: converter.ToProtoReplyMarkup(replyMarkup),
 			},
 		},
@@ -207,17 +223,24 @@ func (b *BizGRPCHandler) handleMenuCallback(cbCtx *callbackContext) *pb.UpdateRe
 
 // обработчик для колбэка "contacted_yes"
 func (b *BizGRPCHandler) handleContactedYes(cbCtx *callbackContext) *pb.UpdateResponse {
-	// Здесь можно добавить дополнительную логику, например:
-	// - Сохранить в БД факт согласия на связь
-	// - Отправить уведомление администратору
-	// - Запустить бизнес-процесс
+	// согласие на связь с мастером - это заявка (лид), повторное согласие новую заявку не создаёт
+	metrics.BusinessEvent(metrics.EventContactedYes)
+	lead, created, err := b.Service.Leads.Open(cbCtx.ctx, cbCtx.userID, cbCtx.callbackData)
+	switch {
+	case err != nil:
+		// клиенту всё равно отвечаем: заявку мастер увидит по журналу нажатий
+		slog.WarnContext(cbCtx.ctx, "failed to open lead", "user_id", logger.MaskID(cbCtx.userID), "error", err)
+	case created:
+		metrics.BusinessEvent(metrics.EventLeadCreated)
+		slog.InfoContext(cbCtx.ctx, "lead created", "lead_id", lead.ID, "user_id", logger.MaskID(cbCtx.userID))
+	}
 
 	return &pb.UpdateResponse{
 		Success: true,
 		Messages: []*pb.OutgoingMessage{
 			{
 				ChatId: cbCtx.chatID,
-				Text:   "✅ Отлично! Я передам ваши контакты мастеру. Ожидайте связи в ближайшее время.",
+				Text:   b.Service.Content.Text(cbCtx.ctx, domain.ContentContactedYes),
 			},
 		},
 	}
@@ -225,12 +248,14 @@ func (b *BizGRPCHandler) handleContactedYes(cbCtx *callbackContext) *pb.UpdateRe
 
 // обработчик для колбэка "contacted_no"
 func (b *BizGRPCHandler) handleContactedNo(cbCtx *callbackContext) *pb.UpdateResponse {
+	metrics.BusinessEvent(metrics.EventContactedNo)
+
 	return &pb.UpdateResponse{
 		Success: true,
 		Messages: []*pb.OutgoingMessage{
 			{
 				ChatId: cbCtx.chatID,
-				Text:   "💭 Жаль! Если передумаете, просто нажмите /start, чтобы вернуться в меню.",
+				Text:   b.Service.Content.Text(cbCtx.ctx, domain.ContentContactedNo),
 			},
 		},
 	}
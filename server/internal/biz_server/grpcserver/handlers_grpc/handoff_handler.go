@@ -0,0 +1,40 @@
+package handlersgrpc
+
+import (
+	"context"
+	pb "global_models/grpc/bot"
+	"log/slog"
+	"pkg/logger"
+	"pkg/metrics"
+	"server/internal/domain"
+)
+
+// ответ клиенту, если диалог с оператором открыть не удалось
+const handoffFailedText = "⚠️ Не получилось позвать мастера, попробуйте ещё раз через минуту."
+
+// метод открытия диалога с оператором по кнопке "Позвать мастера" (возвращает текст ответа клиенту)
+func (b *BizGRPCHandler) requestHandoff(ctx context.Context, telegramID int64) string {
+	created, err := b.Service.Handoffs.Request(ctx, telegramID)
+	if err != nil {
+		slog.WarnContext(ctx, "failed to request handoff", "user_id", logger.MaskID(telegramID), "error", err)
+		return handoffFailedText
+	}
+	if created {
+		metrics.BusinessEvent(metrics.EventHandoff)
+		slog.InfoContext(ctx, "handoff requested", "user_id", logger.MaskID(telegramID))
+	}
+	return b.Service.Content.Text(ctx, domain.ContentHandoffStart)
+}
+
+// обработчик для колбэка "operator" (inline кнопка "Позвать мастера" в меню)
+func (b *BizGRPCHandler) handleHandoffCallback(cbCtx *callbackContext) *pb.UpdateResponse {
+	return &pb.UpdateResponse{
+		Success: true,
+		Messages: []*pb.OutgoingMessage{
+			{
+				ChatId: cbCtx.chatID,
+				Text:   b.requestHandoff(cbCtx.ctx, cbCtx.userID),
+			},
+		},
+	}
+}
@@ -4,6 +4,9 @@ import (
 	"context"
 	"fmt"
 	pb "global_models/grpc/bot"
+	"log/slog"
+	"pkg/logger"
+	"pkg/tracing"
 	"server/internal/biz_server/grpcserver/converter"
 	"server/internal/domain"
 	"time"
@@ -20,6 +23,9 @@ type messageContext struct {
 
 // ProcessMessage - обработка входящего сообщения
 func (b *BizGRPCHandler) ProcessMessage(ctx context.Context, msg *pb.Message) (*pb.UpdateResponse, error) {
+	ctx, span := tracing.Start(ctx, "handler.ProcessMessage")
+	defer span.End()
+
 	// 1. Создание контекста сообщения
 	msgCtx, err := b.buildMessageContext(ctx, msg)
 	if err != nil {
@@ -27,22 +33,42 @@ func (b *BizGRPCHandler) ProcessMessage(ctx context.Context, msg *pb.Message) (*
 	}
 
 	// 2. Логирование входящего сообщения
-	b.logIncomingMessage(msg)
+	b.logIncomingMessage(ctx, msg)
 
 	// 3. Сохранение пользователя и сообщения
 	if err := b.saveUserAndMessage(msgCtx); err != nil {
-		fmt.Printf("⚠️ Warning: %v", err)
+		slog.WarnContext(ctx, "failed to save incoming message", "error", err)
 		// продолжаем выполнение, не блокируем ответ
 	}
 
-	// 4. Генерация ответа
+	// 4. Сообщение видно в консоли оператора; пока чат ведёт оператор, бот не отвечает
+	b.Service.Handoffs.Publish(ctx, msgCtx.msg)
+	if msg.Text == domain.HandoffButtonText {
+		replyText := b.requestHandoff(ctx, msgCtx.userID)
+		b.saveOutgoingMessage(msgCtx.ctx, msgCtx.chatID, msgCtx.userID, replyText)
+		return b.buildMessageResponse(msgCtx.chatID, replyText, nil), nil
+	}
+	if b.Service.Handoffs.Intercept(ctx, msgCtx.userID) {
+		slog.DebugContext(ctx, "message forwarded to operator", "user_id", logger.MaskID(msgCtx.userID))
+		return &pb.UpdateResponse{Success: true}, nil
+	}
+
+	// 5. Сводка аналитики для мастера (остальным /stats отвечает как на обычный текст)
+	if isStatsCommand(msg.Text) {
+		if replyText, ok := b.statsReply(ctx, msgCtx.userID, msgCtx.chatID); ok {
+			b.saveOutgoingMessage(msgCtx.ctx, msgCtx.chatID, msgCtx.userID, replyText)
+			return b.buildMessageResponse(msgCtx.chatID, replyText, nil), nil
+		}
+	}
+
+	// 6. Генерация ответа
 	replyText := b.Service.Responses.GenerateReply(msg.Text, msgCtx.user)
 	replyMarkup := b.Service.Responses.CreateTextRespKeyBoard(msg.Text)
 
-	// 5. Сохранение исходящего сообщения
+	// 7. Сохранение исходящего сообщения
 	b.saveOutgoingMessage(msgCtx.ctx, msgCtx.chatID, msgCtx.userID, replyText)
 
-	// 6. Формирование ответа
+	// 8. Формирование ответа
 	return b.buildMessageResponse(msgCtx.chatID, replyText, replyMarkup), nil
 }
 
@@ -60,22 +86,23 @@ func (b *BizGRPCHandler) buildMessageContext(ctx context.Context, msg *pb.Messag
 	}, nil
 }
 
-// логируем сообщение (пока в консоль)
-func (b *BizGRPCHandler) logIncomingMessage(msg *pb.Message) {
-	fmt.Printf("📨 Incoming message: UserID=%d, ChatID=%d, Text=%s",
-		msg.UserId, msg.ChatId, msg.Text)
+// логируем сообщение (персональные данные и текст маскируем)
+func (b *BizGRPCHandler) logIncomingMessage(ctx context.Context, msg *pb.Message) {
+	slog.InfoContext(ctx, "incoming message",
+		"user_id", logger.MaskID(msg.UserId),
+		"chat_id", logger.MaskID(msg.ChatId),
+		"text", logger.RedactText(msg.Text))
 }
 
 // метод для сохранения/обновления пользователя и его сообщения
 func (b *BizGRPCHandler) saveUserAndMessage(msgCtx *messageContext) error {
-	// Сохраняем/обновляем пользователя
-	_, err := b.Service.Users.RegisterOrUpdate(msgCtx.ctx,
-		msgCtx.msg.MessageID,
-		msgCtx.msg.UserFirstName,
-		msgCtx.msg.UserLastName,
-		msgCtx.msg.UserNickName)
+	// Сохраняем/обновляем пользователя (профиль - из отправителя сообщения, pb.User)
+	user, _, err := b.Service.Users.RegisterOrUpdate(msgCtx.ctx, msgCtx.user)
 	if err != nil {
-		return fmt.Errorf("failed to save user: %w", err)
+		// сообщение сохраняем и без пользователя
+		slog.WarnContext(msgCtx.ctx, "failed to save user", "error", err)
+	} else {
+		msgCtx.user = user
 	}
 
 	// Сохраняем сообщение
@@ -97,8 +124,9 @@ func (b *BizGRPCHandler) saveOutgoingMessage(ctx context.Context, chatID, userID
 	}
 
 	if err := b.Service.Messages.CheckAndSaveMsg(ctx, outgoingMsg); err != nil {
-		fmt.Printf("⚠️ Failed to save outgoing message: %v", err)
+		slog.WarnContext(ctx, "failed to save outgoing message", "error", err)
 	}
+	b.Service.Handoffs.Publish(ctx, outgoingMsg)
 }
 
 // метод для формирования ответа в grpc форме
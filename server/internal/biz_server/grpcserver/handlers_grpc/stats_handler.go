@@ -0,0 +1,35 @@
+package handlersgrpc
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"pkg/logger"
+	servicegrpc "server/internal/biz_server/service_grpc"
+	"strings"
+)
+
+// команда сводки аналитики для мастера
+const statsCommand = "/stats"
+
+// ответ, если сводку получить не удалось
+const statsFailedText = "⚠️ Не получилось получить статистику, попробуйте позже."
+
+// функция проверки команды /stats (в группах - /stats@<бот>)
+func isStatsCommand(text string) bool {
+	command, _, _ := strings.Cut(strings.TrimSpace(text), " ")
+	return command == statsCommand || strings.HasPrefix(command, statsCommand+"@")
+}
+
+// метод ответа на /stats (ok = false - прислал не администратор, команда обрабатывается как обычный текст)
+func (b *BizGRPCHandler) statsReply(ctx context.Context, telegramID, chatID int64) (text string, ok bool) {
+	text, err := b.Service.Stats.Report(ctx, telegramID, chatID)
+	switch {
+	case errors.Is(err, servicegrpc.ErrStatsForbidden):
+		return "", false
+	case err != nil:
+		slog.WarnContext(ctx, "failed to build stats report", "user_id", logger.MaskID(telegramID), "error", err)
+		return statsFailedText, true
+	}
+	return text, true
+}
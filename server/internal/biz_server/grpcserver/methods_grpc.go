@@ -3,10 +3,13 @@ package grpcserver
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
+	"pkg/logger"
+	"strconv"
 
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 
 	pb "global_models/grpc/bot"
 )
@@ -22,15 +25,49 @@ func (s *GRPCServer) ProcessUpdate(ctx context.Context, req *pb.UpdateRequest) (
 	// Проверяем, не отменён ли контекст
 	select {
 	case <-ctx.Done():
-		log.Printf("❌ Контекст отменён до начала обработки: %v", ctx.Err())
+		slog.WarnContext(ctx, "update context canceled before processing", "update_id", req.UpdateId, "error", ctx.Err())
 		return nil, ctx.Err()
 	default:
 	}
 
 	// Логируем факт получения обновления
 	// UpdateId - это как номер обращения в техподдержку
-	log.Printf("Processing update %d", req.UpdateId)
+	slog.InfoContext(ctx, "processing update", "update_id", req.UpdateId)
 
+	// Telegram повторяет доставку update при таймаутах, поэтому обрабатываем каждый update_id один раз.
+	// update_id == 0 Telegram не присылает - такие запросы обрабатываем без дедупликации
+	if s.Dedup == nil || req.UpdateId == 0 {
+		return s.processUpdate(ctx, req)
+	}
+
+	data, err := s.Dedup.Do(ctx, strconv.FormatInt(req.UpdateId, 10), func(ctx context.Context) ([]byte, bool, error) {
+		resp, err := s.processUpdate(ctx, req)
+		if err != nil {
+			return nil, false, err
+		}
+
+		data, err := proto.Marshal(resp)
+		if err != nil {
+			return nil, false, err
+		}
+
+		// неуспешный ответ не запоминаем, чтобы повторная доставка могла обработать update заново
+		return data, resp.Success, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &pb.UpdateResponse{}
+	if err := proto.Unmarshal(data, resp); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to decode stored response: %v", err)
+	}
+
+	return resp, nil
+}
+
+// processUpdate - непосредственная обработка обновления (сообщения и/или колбэка)
+func (s *GRPCServer) processUpdate(ctx context.Context, req *pb.UpdateRequest) (*pb.UpdateResponse, error) {
 	// Создаем временные хранилища для:
 	// - responses: сюда складываем успешные ответы от обработчиков
 	// - errors: сюда складываем ошибки, если что-то пошло не так
@@ -39,7 +76,6 @@ func (s *GRPCServer) ProcessUpdate(ctx context.Context, req *pb.UpdateRequest) (
 
 	// ШАГ 1: Обрабатываем сообщение от пользователя (если оно есть)
 	// Например, пользователь написал "Привет!" или прислал фото
-	log.Printf("-----Состояние сообщения: %v-----'\n", req.Message)
 	if req.Message != nil {
 		// Вызываем специалиста по сообщениям (ProcessMessage)
 		// Передаем ему само сообщение для анализа
@@ -48,16 +84,15 @@ func (s *GRPCServer) ProcessUpdate(ctx context.Context, req *pb.UpdateRequest) (
 		if err != nil {
 			// Если специалист вернул ошибку - записываем её
 			// Но продолжаем работу (может быть, еще есть callback)
-			fmt.Println("ошибка при обработке сообщения в методе grc сервера", err.Error())
+			slog.ErrorContext(ctx, "failed to process message", "update_id", req.UpdateId, "error", err)
 			errors = append(errors, err)
 		} else if resp != nil {
 			// Если специалист успешно обработал - сохраняем ответ
-			fmt.Printf("response status обработки сообщения:%v\n", resp.Success)
+			slog.DebugContext(ctx, "message processed", "update_id", req.UpdateId, "success", resp.Success)
 			responses = append(responses, resp)
 		}
 	}
 
-	log.Printf("-----Состояние колбэка: %v-----'\n", req.CallbackQuery)
 	// ШАГ 2: Обрабатываем нажатие на кнопку (если оно есть)
 	// Например, пользователь нажал кнопку "Узнать цену"
 	if req.CallbackQuery != nil {
@@ -65,10 +100,10 @@ func (s *GRPCServer) ProcessUpdate(ctx context.Context, req *pb.UpdateRequest) (
 		resp, err := s.Handler.ProcessCallback(ctx, req.CallbackQuery)
 
 		if err != nil {
-			fmt.Println("ошибка при обработке колбэка в методе grc сервера")
+			slog.ErrorContext(ctx, "failed to process callback", "update_id", req.UpdateId, "error", err)
 			errors = append(errors, err)
 		} else if resp != nil {
-			fmt.Printf("response status обработки колбэка:%v\n", resp.Success)
+			slog.DebugContext(ctx, "callback processed", "update_id", req.UpdateId, "success", resp.Success)
 			responses = append(responses, resp)
 		}
 	}
@@ -99,7 +134,6 @@ func (s *GRPCServer) ProcessUpdate(ctx context.Context, req *pb.UpdateRequest) (
 
 	// ШАГ 5: Если были ошибки - добавляем их в ответ
 	if len(errors) > 0 {
-		log.Println(len(errors))
 		finalResp.Success = false
 		// Объединяем все ошибки в одну строку
 		// Клиент увидит что-то вроде: "errors: [ошибка1 ошибка2]"
@@ -119,7 +153,7 @@ func (s *GRPCServer) ProcessUpdate(ctx context.Context, req *pb.UpdateRequest) (
 func (s *GRPCServer) SendMessage(ctx context.Context, req *pb.SendMessageRequest) (*pb.SendMessageResponse, error) {
 	// Логируем, что нужно отправить сообщение в конкретный чат
 	// ChatId - это как адрес получателя (уникальный ID чата с пользователем)
-	log.Printf("SendMessage request for chat %d", req.ChatId)
+	slog.InfoContext(ctx, "send message request", "chat_id", logger.MaskID(req.ChatId))
 
 	// Передаем запрос в бизнес-логику для обработки принятого сообщения от grpc клиента на стороне бота
 	// проведём проверки и передадим в сервисный слой, чтобы там решить куда дальше посылать ответ (если нужно будет)
@@ -3,13 +3,17 @@ package grpcserver
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"net"
 	"pkg/configs"
+	"pkg/grpcsecurity"
+	"pkg/interceptors"
+	"pkg/tracing"
 
 	"server/internal/interfaces"
 
 	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/keepalive"
 	"google.golang.org/grpc/reflection"
 
@@ -22,13 +26,17 @@ type GRPCServer struct {
 	pb.UnimplementedBotServiceServer                                 // Встраиваем для обратной совместимости
 	server                           *grpc.Server                    // Сам сервер, который слушает входящие подключения
 	Handler                          interfaces.GRPCHandlerInterface // Бизнес-логика для сообщений (интерфейс из сервисного слоя)
+	Dedup                            interfaces.UpdateDeduplicator   // слой идемпотентности (защита от повторной обработки update)
+	health                           healthpb.HealthServer           // стандартный grpc.health.v1 (статус от проверок зависимостей)
 	config                           *configs.GRPCServerConfig       // конфиг grpc сервера
 }
 
 // NewGRPCServer создает новый gRPC сервер (конструктор), возвращает глобальный интерфейс
-func NewGRPCServer(handler interfaces.GRPCHandlerInterface, conf *configs.GRPCServerConfig) interf.GRPCInterface {
+func NewGRPCServer(handler interfaces.GRPCHandlerInterface, dedup interfaces.UpdateDeduplicator, healthServer healthpb.HealthServer, conf *configs.GRPCServerConfig) interf.GRPCInterface {
 	return &GRPCServer{
 		Handler: handler,
+		Dedup:   dedup,
+		health:  healthServer,
 		config:  conf,
 	}
 }
@@ -52,19 +60,40 @@ func (s *GRPCServer) Run() error {
 	}
 
 	// Создаем gRPC сервер с опциями
-	s.server = grpc.NewServer(
+	opts := []grpc.ServerOption{
 		grpc.KeepaliveParams(keepaliveParams),        // Добавляем проверки соединения
 		grpc.MaxRecvMsgSize(s.config.MaxRecvMsgSize), // Максимальный размер принимаемого сообщения - 10 МБ
 		grpc.MaxSendMsgSize(s.config.MaxSendMsgSize), // Максимальный размер отправляемого сообщения - тоже 10 МБ
-	)
+	}
+
+	// Добавляем цепочку interceptor-ов (recovery, access-лог, request-id, метрики, дедлайны)
+	opts = append(opts, interceptors.ServerOptions(&s.config.Interceptors, nil)...)
+
+	// Добавляем трассировку входящих вызовов (продолжаем трейс клиента)
+	opts = append(opts, tracing.ServerOptions()...)
+
+	// Добавляем TLS / mTLS и проверку токена (если включены в конфиге)
+	securityOpts, err := grpcsecurity.ServerOptions(s.config)
+	if err != nil {
+		lis.Close()
+		return fmt.Errorf("failed to configure grpc security: %w", err)
+	}
+	opts = append(opts, securityOpts...)
+
+	s.server = grpc.NewServer(opts...)
 
 	// Регистрируем наш сервис - говорим: "Этот сервер умеет работать с ботом по таким-то правилам"
 	pb.RegisterBotServiceServer(s.server, s)
 
+	// Регистрируем grpc.health.v1 - по нему бот и оркестратор проверяют готовность сервера
+	if s.health != nil {
+		healthpb.RegisterHealthServer(s.server, s.health)
+	}
+
 	// Регистрируем reflection для инструментов отладки (grpcurl и т.д.)
 	reflection.Register(s.server)
 
-	log.Printf("gRPC server listening on :%s", s.config.Port)
+	slog.Info("gRPC server listening", "port", s.config.Port)
 
 	// Запускаем сервер в бесконечный цикл приема сообщений
 	// Serve - блокирующая операция, выполняется пока сервер не остановят
@@ -89,7 +118,7 @@ func (s *GRPCServer) Shutdown(ctx context.Context) error {
 		s.server.Stop() // Грубо останавливаем все соединения
 		return ctx.Err()
 	case <-stopped:
-		log.Println("gRPC server shutdown completed")
+		slog.Info("gRPC server shutdown completed")
 		return nil
 	}
 }
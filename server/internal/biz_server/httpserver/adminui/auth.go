@@ -0,0 +1,138 @@
+package adminui
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"server/internal/biz_server/httpserver/handlers"
+	"server/internal/domain"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// защита форм от CSRF: случайный токен в HttpOnly куке и в скрытом поле каждой формы (double submit).
+// Чужой сайт не может прочитать куку, а SameSite=Strict не отправляет её с чужих страниц
+const (
+	csrfCookie = "admin_csrf"
+	csrfField  = "csrf_token"
+	csrfKey    = "admin_csrf"
+	csrfBytes  = 32
+)
+
+// middleware заголовков безопасности: страницы не встраиваются во фреймы, скрипты и стили - только свои
+func securityHeaders(c *gin.Context) {
+	h := c.Writer.Header()
+	h.Set("Content-Security-Policy", "default-src 'self'; img-src 'self' data:; frame-ancestors 'none'; form-action 'self'; base-uri 'none'")
+	h.Set("X-Frame-Options", "DENY")
+	h.Set("X-Content-Type-Options", "nosniff")
+	h.Set("Referrer-Policy", "same-origin")
+	h.Set("Cache-Control", "no-store")
+	c.Next()
+}
+
+// функция нового CSRF токена
+func newCSRFToken() (string, error) {
+	buf := make([]byte, csrfBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// middleware CSRF: выдаёт токен (если куки ещё нет) и проверяет его во всех формах
+func (u *UI) csrf(c *gin.Context) {
+	token, err := c.Cookie(csrfCookie)
+	if err != nil || base64.RawURLEncoding.DecodedLen(len(token)) != csrfBytes {
+		if token, err = newCSRFToken(); err != nil {
+			slog.ErrorContext(c.Request.Context(), "failed to create csrf token", "error", err)
+			c.AbortWithStatus(http.StatusInternalServerError)
+			return
+		}
+		conf := u.service.Auth.Config()
+		c.SetSameSite(http.SameSiteStrictMode)
+		c.SetCookie(csrfCookie, token, 0, "/admin", conf.CookieDomain, conf.CookieSecure, true)
+	}
+	c.Set(csrfKey, token)
+
+	if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+		if subtle.ConstantTimeCompare([]byte(c.PostForm(csrfField)), []byte(token)) != 1 {
+			slog.WarnContext(c.Request.Context(), "admin ui csrf check failed", "path", c.Request.URL.Path)
+			u.renderError(c, http.StatusForbidden, "Форма устарела. Обновите страницу и отправьте её ещё раз.")
+			return
+		}
+	}
+	c.Next()
+}
+
+// middleware входа и роли для страниц: без входа - перенаправление на страницу входа
+func (u *UI) requireRole(required domain.AdminRole) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		auth := u.service.Auth
+		if !auth.Enabled() {
+			c.Next()
+			return
+		}
+
+		token, _ := c.Cookie(handlers.AccessCookie)
+		claims, err := auth.Authenticate(token)
+		if err != nil {
+			// после входа вернём на ту же страницу (данные формы при этом теряются)
+			next := "/admin"
+			if c.Request.Method == http.MethodGet {
+				next = c.Request.URL.RequestURI()
+			}
+			c.Redirect(http.StatusSeeOther, "/admin/login?next="+url.QueryEscape(next))
+			c.Abort()
+			return
+		}
+		if !domain.AdminRole(claims.Role).Allows(required) {
+			u.renderError(c, http.StatusForbidden, "Недостаточно прав для этого действия.")
+			return
+		}
+
+		c.Set(handlers.ClaimsKey, claims)
+		c.Next()
+	}
+}
+
+// функция безопасного адреса возврата после входа (только страницы админки, без перехода на чужой хост)
+func safeNext(next string) string {
+	if (next == "/admin" || strings.HasPrefix(next, "/admin/")) && !strings.HasPrefix(next, "/admin/login") && !strings.Contains(next, `\`) {
+		return next
+	}
+	return "/admin"
+}
+
+// loginData - данные страницы входа
+type loginData struct {
+	BotID string // id бота для oauth.telegram.org
+	Next  string
+}
+
+// метод страницы входа: скрипт сначала пробует обновить сессию, иначе ведёт на вход через Telegram
+func (u *UI) LoginPage(c *gin.Context) {
+	next := safeNext(c.Query("next"))
+	if !u.service.Auth.Enabled() {
+		c.Redirect(http.StatusSeeOther, next)
+		return
+	}
+	u.render(c, http.StatusOK, "login", u.page(c, "", "Вход", loginData{BotID: u.service.Auth.LoginBotID(), Next: next}))
+}
+
+// метод выхода: закрывает сессию текущего токена доступа и удаляет куки.
+// Если токен доступа уже истёк, сессия закроется сама по refresh_ttl - токена обновления у браузера больше нет
+func (u *UI) Logout(c *gin.Context) {
+	auth := u.service.Auth
+	token, _ := c.Cookie(handlers.AccessCookie)
+	if claims, err := auth.Authenticate(token); err == nil {
+		if err := auth.LogoutSession(c.Request.Context(), claims.SessionID); err != nil {
+			slog.WarnContext(c.Request.Context(), "failed to revoke admin session", "error", err)
+		}
+	}
+	handlers.SetSessionCookies(c, auth.Config(), nil)
+	c.Redirect(http.StatusSeeOther, "/admin/login")
+}
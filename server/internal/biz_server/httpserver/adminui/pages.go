@@ -0,0 +1,302 @@
+package adminui
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"pkg/adminauth"
+	"server/internal/biz_server/httpserver/handlers"
+	"server/internal/biz_server/repository"
+	servicehttp "server/internal/biz_server/service_http"
+	"server/internal/domain"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// размеры выборок страниц
+const (
+	usersPageSize   = 50
+	transcriptLimit = 200 // сообщений в переписке на странице пользователя
+	boardColumnSize = 50  // заявок в колонке доски
+)
+
+// функция текста ошибки сервиса для страницы (внутренние ошибки только логируются)
+func errorMessage(c *gin.Context, err error) string {
+	var verr *servicehttp.ValidationError
+	switch {
+	case errors.As(err, &verr):
+		return "Неверное значение поля " + verr.Field + ": " + verr.Message
+	case errors.Is(err, repository.ErrUserNotFound):
+		return "Пользователь не найден."
+	case errors.Is(err, repository.ErrLeadNotFound):
+		return "Заявка не найдена."
+	case errors.Is(err, repository.ErrLeadConflict):
+		return "У клиента уже есть открытая заявка."
+	case errors.Is(err, repository.ErrHandoffNotFound):
+		return "Чат уже ведёт бот."
+	case errors.Is(err, servicehttp.ErrDeliveryFailed):
+		return "Сообщение не доставлено: бот недоступен или клиент заблокировал бота. Оно сохранено в переписке со статусом failed."
+	}
+	slog.ErrorContext(c.Request.Context(), "admin ui request failed", "path", c.Request.URL.Path, "error", err)
+	return "Внутренняя ошибка, попробуйте ещё раз."
+}
+
+// метод страницы с ошибкой сервиса (404 - для ненайденных записей)
+func (u *UI) failPage(c *gin.Context, err error) {
+	status := http.StatusInternalServerError
+	if errors.Is(err, repository.ErrUserNotFound) || errors.Is(err, repository.ErrLeadNotFound) {
+		status = http.StatusNotFound
+	}
+	u.renderError(c, status, errorMessage(c, err))
+}
+
+// функция числового параметра пути (0 - не число)
+func paramID(c *gin.Context, name string) int64 {
+	id, err := strconv.ParseInt(c.Param(name), 10, 64)
+	if err != nil || id <= 0 {
+		return 0
+	}
+	return id
+}
+
+// метод главной страницы: сводка за сегодня
+func (u *UI) Dashboard(c *gin.Context) {
+	dashboard, err := u.service.Dashboard.Today(c.Request.Context())
+	if err != nil {
+		u.failPage(c, err)
+		return
+	}
+	u.render(c, http.StatusOK, "dashboard", u.page(c, "dashboard", "Сводка", dashboard))
+}
+
+// usersData - данные страницы пользователей
+type usersData struct {
+	Users    []*domain.User
+	Query    string
+	Total    int64
+	Page     int
+	PrevPage int // 0 - нет предыдущей страницы
+	NextPage int // 0 - нет следующей страницы
+}
+
+// метод страницы пользователей с поиском
+func (u *UI) Users(c *gin.Context) {
+	number, _ := strconv.Atoi(c.Query("page"))
+	page := servicehttp.NewPage(number, usersPageSize)
+	query := strings.TrimSpace(c.Query("query"))
+
+	users, total, err := u.service.Admin.ListUsers(c.Request.Context(), domain.UserFilter{Query: query, Page: page})
+	if err != nil {
+		u.failPage(c, err)
+		return
+	}
+	data := usersData{Users: users, Query: query, Total: total, Page: page.Number}
+	if page.Number > 1 {
+		data.PrevPage = page.Number - 1
+	}
+	if int64(page.Offset()+len(users)) < total {
+		data.NextPage = page.Number + 1
+	}
+	u.render(c, http.StatusOK, "users", u.page(c, "users", "Пользователи", data))
+}
+
+// userData - данные страницы пользователя
+type userData struct {
+	User     *domain.User
+	Messages []*domain.Message // переписка от старых к новым
+	Leads    []*domain.Lead
+	Handoff  *domain.Handoff // nil - чат ведёт бот
+}
+
+// метод страницы пользователя: профиль, заявки и переписка
+func (u *UI) User(c *gin.Context) {
+	ctx := c.Request.Context()
+	telegramID := paramID(c, "telegram_id")
+	if telegramID == 0 {
+		u.renderError(c, http.StatusNotFound, "Пользователь не найден.")
+		return
+	}
+
+	profile, err := u.service.Admin.GetProfile(ctx, telegramID)
+	if err != nil {
+		u.failPage(c, err)
+		return
+	}
+	messages, err := u.service.Admin.ListMessages(ctx, telegramID, 0, transcriptLimit)
+	if err != nil {
+		u.failPage(c, err)
+		return
+	}
+	slices.Reverse(messages)
+	handoff, err := u.service.Handoffs.Get(ctx, telegramID)
+	if err != nil {
+		u.failPage(c, err)
+		return
+	}
+
+	data := userData{User: profile.User, Messages: messages, Leads: profile.Leads, Handoff: handoff}
+	u.render(c, http.StatusOK, "user", u.page(c, "users", displayName(profile.User.FirstName, profile.User.LastName, profile.User.Username, telegramID), data))
+}
+
+// метод отправки сообщения клиенту через бота
+func (u *UI) SendMessage(c *gin.Context) {
+	telegramID := paramID(c, "telegram_id")
+	if telegramID == 0 {
+		u.renderError(c, http.StatusNotFound, "Пользователь не найден.")
+		return
+	}
+	back := "/admin/users/" + strconv.FormatInt(telegramID, 10)
+
+	if _, err := u.service.Messaging.Send(c.Request.Context(), telegramID, c.PostForm("text")); err != nil {
+		redirect(c, back, "", errorMessage(c, err))
+		return
+	}
+	redirect(c, back, "Сообщение отправлено.", "")
+}
+
+// метод консоли оператора: чаты, которые сейчас ведёт мастер, а не бот
+func (u *UI) Console(c *gin.Context) {
+	handoffs, err := u.service.Handoffs.List(c.Request.Context())
+	if err != nil {
+		u.failPage(c, err)
+		return
+	}
+	u.render(c, http.StatusOK, "console", u.page(c, "console", "Консоль оператора", handoffs))
+}
+
+// метод перехвата чата: бот перестаёт отвечать клиенту, пока мастер не вернёт чат
+func (u *UI) OpenHandoff(c *gin.Context) {
+	telegramID := paramID(c, "telegram_id")
+	if telegramID == 0 {
+		u.renderError(c, http.StatusNotFound, "Пользователь не найден.")
+		return
+	}
+	back := "/admin/users/" + strconv.FormatInt(telegramID, 10)
+
+	var adminID int64
+	if claims, ok := c.Get(handlers.ClaimsKey); ok {
+		adminID = claims.(*adminauth.Claims).Subject
+	}
+	if _, err := u.service.Handoffs.Open(c.Request.Context(), telegramID, adminID); err != nil {
+		redirect(c, back, "", errorMessage(c, err))
+		return
+	}
+	redirect(c, back, "Чат ведёте вы, бот не отвечает клиенту.", "")
+}
+
+// метод возврата чата боту
+func (u *UI) CloseHandoff(c *gin.Context) {
+	telegramID := paramID(c, "telegram_id")
+	if telegramID == 0 {
+		u.renderError(c, http.StatusNotFound, "Пользователь не найден.")
+		return
+	}
+	back := "/admin/users/" + strconv.FormatInt(telegramID, 10)
+
+	if err := u.service.Handoffs.Close(c.Request.Context(), telegramID); err != nil {
+		redirect(c, back, "", errorMessage(c, err))
+		return
+	}
+	redirect(c, back, "Чат снова ведёт бот.", "")
+}
+
+// boardColumn - колонка доски заявок
+type boardColumn struct {
+	Status domain.LeadStatus
+	Leads  []*domain.Lead
+	Total  int64
+}
+
+// метод доски заявок по статусам
+func (u *UI) Leads(c *gin.Context) {
+	columns := make([]boardColumn, 0, len(leadStatuses))
+	for _, status := range leadStatuses {
+		leads, total, err := u.service.Leads.ListLeads(c.Request.Context(), domain.LeadFilter{
+			Status: status,
+			Page:   servicehttp.NewPage(1, boardColumnSize),
+		})
+		if err != nil {
+			u.failPage(c, err)
+			return
+		}
+		columns = append(columns, boardColumn{Status: status, Leads: leads, Total: total})
+	}
+	u.render(c, http.StatusOK, "leads", u.page(c, "leads", "Заявки", columns))
+}
+
+// метод смены статуса и заметки заявки
+func (u *UI) UpdateLead(c *gin.Context) {
+	id := paramID(c, "id")
+	if id == 0 {
+		u.renderError(c, http.StatusNotFound, "Заявка не найдена.")
+		return
+	}
+	status := domain.LeadStatus(c.PostForm("status"))
+	note := c.PostForm("note")
+
+	if _, err := u.service.Leads.UpdateLead(c.Request.Context(), id, servicehttp.LeadPatch{Status: &status, Note: &note}); err != nil {
+		redirect(c, "/admin/leads", "", errorMessage(c, err))
+		return
+	}
+	redirect(c, "/admin/leads", "Заявка #"+strconv.FormatInt(id, 10)+" сохранена.", "")
+}
+
+// метод страницы текстов бота
+func (u *UI) Content(c *gin.Context) {
+	items, err := u.service.Content.List(c.Request.Context())
+	if err != nil {
+		u.failPage(c, err)
+		return
+	}
+	u.render(c, http.StatusOK, "content", u.page(c, "content", "Тексты бота", items))
+}
+
+// метод сохранения текста бота (кнопка "сбросить" возвращает текст по умолчанию)
+func (u *UI) SaveContent(c *gin.Context) {
+	text := c.PostForm("text")
+	if c.PostForm("reset") != "" {
+		text = ""
+	}
+	if err := u.service.Content.Save(c.Request.Context(), domain.ContentKey(c.PostForm("key")), text); err != nil {
+		redirect(c, "/admin/content", "", errorMessage(c, err))
+		return
+	}
+	redirect(c, "/admin/content", "Текст сохранён. Бот начнёт отвечать им в течение минуты.", "")
+}
+
+// метод страницы настроек бизнеса
+func (u *UI) Settings(c *gin.Context) {
+	settings, err := u.service.Settings.GetSettings(c.Request.Context())
+	if err != nil {
+		u.failPage(c, err)
+		return
+	}
+	u.render(c, http.StatusOK, "settings", u.page(c, "settings", "Настройки", settings))
+}
+
+// метод сохранения настроек бизнеса
+func (u *UI) SaveSettings(c *gin.Context) {
+	settings := &domain.BusinessSettings{
+		MasterName:   c.PostForm("master_name"),
+		InstagramURL: c.PostForm("instagram_url"),
+		WelcomeText:  c.PostForm("welcome_text"),
+		Timezone:     c.PostForm("timezone"),
+	}
+	if raw := strings.TrimSpace(c.PostForm("notify_chat_id")); raw != "" {
+		chatID, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			redirect(c, "/admin/settings", "", "Чат для уведомлений - это числовой id.")
+			return
+		}
+		settings.NotifyChatID = chatID
+	}
+
+	if err := u.service.Settings.SaveSettings(c.Request.Context(), settings); err != nil {
+		redirect(c, "/admin/settings", "", errorMessage(c, err))
+		return
+	}
+	redirect(c, "/admin/settings", "Настройки сохранены.", "")
+}
@@ -0,0 +1,92 @@
+// Консоль оператора: события чатов приходят с сервера (SSE, GET /api/v1/.../events).
+// Страница пользователя дописывает новые сообщения в переписку, список диалогов перезагружается
+// при открытии и закрытии диалога. Истёкший токен доступа продлевается через /api/v1/auth/refresh
+(function () {
+  "use strict";
+
+  var chat = document.getElementById("chat");
+  var root = document.getElementById("handoff") || document.getElementById("handoffs");
+  var status = document.getElementById("live-status");
+  if (!root || !window.EventSource) {
+    return;
+  }
+
+  var refreshed = false;
+
+  function show(text) {
+    status.textContent = text;
+  }
+
+  function time(value) {
+    var d = new Date(value);
+    function pad(n) {
+      return (n < 10 ? "0" : "") + n;
+    }
+    return pad(d.getDate()) + "." + pad(d.getMonth() + 1) + "." + d.getFullYear() + " " + pad(d.getHours()) + ":" + pad(d.getMinutes());
+  }
+
+  function appendMessage(event) {
+    var msg = document.createElement("div");
+    msg.className = "msg msg-" + event.direction + (event.status === "failed" ? " msg-failed" : "");
+    var text = document.createElement("div");
+    text.className = "text";
+    text.textContent = event.text;
+    var meta = document.createElement("div");
+    meta.className = "meta";
+    meta.textContent = time(event.at) + (event.status === "failed" ? " · не доставлено" : "");
+    msg.appendChild(text);
+    msg.appendChild(meta);
+
+    var empty = chat.querySelector("p.muted");
+    if (empty) {
+      empty.remove();
+    }
+    chat.appendChild(msg);
+    msg.scrollIntoView({ block: "end" });
+  }
+
+  function reload() {
+    // сообщения об успехе прошлой формы не показываем повторно
+    window.location.replace(window.location.pathname);
+  }
+
+  function connect() {
+    var source = new EventSource(root.dataset.events);
+
+    source.onopen = function () {
+      refreshed = false;
+      show("Обновления в реальном времени включены.");
+    };
+    source.addEventListener("message", function (e) {
+      if (chat) {
+        appendMessage(JSON.parse(e.data));
+      }
+    });
+    source.addEventListener("handoff_opened", reload);
+    source.addEventListener("handoff_closed", reload);
+
+    source.onerror = function () {
+      if (source.readyState !== EventSource.CLOSED) {
+        show("Связь с сервером потеряна, переподключаемся…");
+        return;
+      }
+      // сервер отказал (истёк токен доступа или события выключены): один раз пробуем продлить сессию
+      if (refreshed) {
+        show("Обновления в реальном времени недоступны, обновите страницу.");
+        return;
+      }
+      refreshed = true;
+      fetch("/api/v1/auth/refresh", { method: "POST", credentials: "same-origin" }).then(function (resp) {
+        if (resp.ok) {
+          connect();
+          return;
+        }
+        show("Сессия истекла, обновите страницу.");
+      }, function () {
+        show("Сервер недоступен, обновите страницу.");
+      });
+    };
+  }
+
+  connect();
+})();
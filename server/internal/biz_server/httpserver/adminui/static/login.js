@@ -0,0 +1,88 @@
+// Вход в админку через Telegram без внешних скриптов: страница oauth.telegram.org
+// возвращает подписанные данные в #tgAuthResult, сервер проверяет подпись в POST /api/v1/auth/telegram.
+(function () {
+  "use strict";
+
+  var root = document.getElementById("login");
+  var link = document.getElementById("login-link");
+  var status = document.getElementById("login-status");
+  var next = root.dataset.next || "/admin";
+
+  function show(text) {
+    status.textContent = text;
+  }
+
+  function showButton(text) {
+    show(text || "");
+    link.hidden = false;
+  }
+
+  // base64url из фрагмента адреса -> объект с полями входа (строки в UTF-8)
+  function decodeAuthResult(value) {
+    var b64 = value.replace(/-/g, "+").replace(/_/g, "/");
+    while (b64.length % 4) {
+      b64 += "=";
+    }
+    var binary = atob(b64);
+    var bytes = new Uint8Array(binary.length);
+    for (var i = 0; i < binary.length; i++) {
+      bytes[i] = binary.charCodeAt(i);
+    }
+    return JSON.parse(new TextDecoder().decode(bytes));
+  }
+
+  function post(url, body) {
+    return fetch(url, {
+      method: "POST",
+      credentials: "same-origin",
+      headers: { "Content-Type": "application/json" },
+      body: body === undefined ? "" : JSON.stringify(body),
+    });
+  }
+
+  function login(fields) {
+    show("Входим…");
+    post("/api/v1/auth/telegram", fields).then(function (resp) {
+      if (resp.ok) {
+        window.location.replace(next);
+        return;
+      }
+      if (resp.status === 403) {
+        showButton("Этот аккаунт Telegram не добавлен в администраторы.");
+        return;
+      }
+      showButton("Не удалось войти, попробуйте ещё раз.");
+    }, function () {
+      showButton("Сервер недоступен, попробуйте ещё раз.");
+    });
+  }
+
+  var authURL = new URL("https://oauth.telegram.org/auth");
+  authURL.searchParams.set("bot_id", root.dataset.botId);
+  authURL.searchParams.set("origin", window.location.origin);
+  authURL.searchParams.set("return_to", window.location.origin + window.location.pathname + window.location.search);
+  authURL.searchParams.set("request_access", "write");
+  link.href = authURL.toString();
+
+  var match = window.location.hash.match(/tgAuthResult=([A-Za-z0-9_\-+/=]+)/);
+  if (match) {
+    history.replaceState(null, "", window.location.pathname + window.location.search);
+    try {
+      login(decodeAuthResult(match[1]));
+    } catch (e) {
+      showButton("Telegram вернул повреждённые данные, попробуйте ещё раз.");
+    }
+    return;
+  }
+
+  // токен доступа мог истечь, а токен обновления ещё действует: пробуем продлить сессию без входа
+  post("/api/v1/auth/refresh").then(function (resp) {
+    if (resp.ok) {
+      window.location.replace(next);
+      return;
+    }
+    showButton("");
+  }, function () {
+    showButton("");
+  });
+})();
@@ -0,0 +1,203 @@
+package adminui
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"pkg/adminauth"
+	"server/internal/biz_server/httpserver/handlers"
+	servicehttp "server/internal/biz_server/service_http"
+	"server/internal/domain"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// шаблоны страниц и статика встроены в бинарник: админке не нужны внешние CDN
+//
+//go:embed templates static
+var assets embed.FS
+
+// страницы админки (каждая - отдельный набор шаблонов вместе с layout.html)
+var pageNames = []string{"login", "error", "dashboard", "users", "user", "console", "leads", "content", "settings"}
+
+// UI - страницы админки, которые рендерит сервер (html/template)
+type UI struct {
+	service *servicehttp.BizServiceFacade
+	pages   map[string]*template.Template
+	static  http.FileSystem
+}
+
+// конструктор для страниц админки (шаблоны разбираются сразу: ошибка в шаблоне не даст запустить сервер)
+func New(service *servicehttp.BizServiceFacade) (*UI, error) {
+	pages := make(map[string]*template.Template, len(pageNames))
+	for _, name := range pageNames {
+		tmpl, err := template.New("layout.html").Funcs(templateFuncs).ParseFS(assets, "templates/layout.html", "templates/"+name+".html")
+		if err != nil {
+			return nil, fmt.Errorf("admin ui template %s: %w", name, err)
+		}
+		pages[name] = tmpl
+	}
+
+	static, err := fs.Sub(assets, "static")
+	if err != nil {
+		return nil, err
+	}
+	return &UI{service: service, pages: pages, static: http.FS(static)}, nil
+}
+
+// RegisterRoutes регистрирует страницы админки: /admin (чтение - viewer, изменения - manager)
+func (u *UI) RegisterRoutes(router gin.IRouter) {
+	router.StaticFS("/admin/static", u.static)
+
+	admin := router.Group("/admin", securityHeaders, u.csrf)
+	admin.GET("/login", u.LoginPage)
+	admin.POST("/logout", u.Logout)
+
+	viewer := admin.Group("", u.requireRole(domain.RoleViewer))
+	viewer.GET("", u.Dashboard)
+	viewer.GET("/users", u.Users)
+	viewer.GET("/users/:telegram_id", u.User)
+	viewer.GET("/console", u.Console)
+	viewer.GET("/leads", u.Leads)
+	viewer.GET("/content", u.Content)
+	viewer.GET("/settings", u.Settings)
+
+	manager := admin.Group("", u.requireRole(domain.RoleManager))
+	manager.POST("/users/:telegram_id/messages", u.SendMessage)
+	manager.POST("/users/:telegram_id/handoff", u.OpenHandoff)
+	manager.POST("/users/:telegram_id/handoff/close", u.CloseHandoff)
+	manager.POST("/leads/:id", u.UpdateLead)
+	manager.POST("/content", u.SaveContent)
+	manager.POST("/settings", u.SaveSettings)
+}
+
+// pageData - общие данные всех страниц
+type pageData struct {
+	Title   string
+	Nav     string // активный пункт меню
+	CSRF    string
+	Admin   *adminauth.Claims // nil - вход выключен
+	CanEdit bool              // роль manager и выше (или вход выключен)
+	Notice  string            // сообщение об успехе после перенаправления
+	Error   string            // сообщение об ошибке
+	Data    any               // данные конкретной страницы
+}
+
+// метод заполнения общих данных страницы
+func (u *UI) page(c *gin.Context, nav, title string, data any) pageData {
+	p := pageData{
+		Title:   title,
+		Nav:     nav,
+		CSRF:    c.GetString(csrfKey),
+		CanEdit: true,
+		Notice:  c.Query("notice"),
+		Error:   c.Query("error"),
+		Data:    data,
+	}
+	if claims, ok := c.Get(handlers.ClaimsKey); ok {
+		p.Admin = claims.(*adminauth.Claims)
+		p.CanEdit = domain.AdminRole(p.Admin.Role).Allows(domain.RoleManager)
+	}
+	return p
+}
+
+// метод рендера страницы (сначала в буфер: ошибка шаблона не отдаёт клиенту половину страницы)
+func (u *UI) render(c *gin.Context, status int, name string, data pageData) {
+	var buf bytes.Buffer
+	if err := u.pages[name].ExecuteTemplate(&buf, "layout.html", data); err != nil {
+		slog.ErrorContext(c.Request.Context(), "admin ui render failed", "page", name, "error", err)
+		c.String(http.StatusInternalServerError, "internal error")
+		return
+	}
+	c.Data(status, "text/html; charset=utf-8", buf.Bytes())
+}
+
+// метод рендера страницы ошибки
+func (u *UI) renderError(c *gin.Context, status int, message string) {
+	data := u.page(c, "", http.StatusText(status), nil)
+	data.Error = message
+	u.render(c, status, "error", data)
+	c.Abort()
+}
+
+// функция перенаправления после формы (POST -> redirect -> GET) с сообщением для страницы
+func redirect(c *gin.Context, path, notice, errMessage string) {
+	q := url.Values{}
+	if notice != "" {
+		q.Set("notice", notice)
+	}
+	if errMessage != "" {
+		q.Set("error", errMessage)
+	}
+	if len(q) > 0 {
+		path += "?" + q.Encode()
+	}
+	c.Redirect(http.StatusSeeOther, path)
+}
+
+// подписи статусов заявок
+var leadStatusTitles = map[domain.LeadStatus]string{
+	domain.LeadNew:        "Новая",
+	domain.LeadInProgress: "В работе",
+	domain.LeadWon:        "Записался",
+	domain.LeadLost:       "Отказ",
+}
+
+// статусы заявок в порядке колонок доски
+var leadStatuses = []domain.LeadStatus{domain.LeadNew, domain.LeadInProgress, domain.LeadWon, domain.LeadLost}
+
+// функции шаблонов
+var templateFuncs = template.FuncMap{
+	"datetime": func(t time.Time) string {
+		if t.IsZero() {
+			return "—"
+		}
+		return t.Local().Format("02.01.2006 15:04")
+	},
+	"statusTitle": func(s domain.LeadStatus) string {
+		if title, ok := leadStatusTitles[s]; ok {
+			return title
+		}
+		return string(s)
+	},
+	"statuses": func() []domain.LeadStatus { return leadStatuses },
+	// доля в процентах для воронки (0 при пустом основании)
+	"percent": func(part, base int64) string {
+		if base == 0 {
+			return "0%"
+		}
+		return strconv.FormatInt(part*100/base, 10) + "%"
+	},
+	"handoffReason": func(r domain.HandoffReason) string {
+		if r == domain.HandoffByClient {
+			return "клиент позвал мастера"
+		}
+		return "мастер перехватил чат"
+	},
+	"userName": func(u *domain.User) string {
+		if u == nil {
+			return "—"
+		}
+		return displayName(u.FirstName, u.LastName, u.Username, u.TelegramID)
+	},
+}
+
+// функция имени пользователя для страниц (имя, иначе @username, иначе id)
+func displayName(first, last, username string, telegramID int64) string {
+	switch {
+	case first != "" && last != "":
+		return first + " " + last
+	case first != "":
+		return first
+	case username != "":
+		return "@" + username
+	}
+	return strconv.FormatInt(telegramID, 10)
+}
@@ -0,0 +1,295 @@
+package adminui
+
+import (
+	"context"
+	pb "global_models/grpc/bot"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"pkg/configs"
+	"pkg/middleware"
+	"server/internal/biz_server/repository"
+	servicehttp "server/internal/biz_server/service_http"
+	"server/internal/domain"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fakeSender - бот, который запоминает отправленные сообщения
+type fakeSender struct {
+	sent []*pb.SendMessageRequest
+}
+
+func (f *fakeSender) SendMessage(ctx context.Context, req *pb.SendMessageRequest) (*pb.SendMessageResponse, error) {
+	f.sent = append(f.sent, req)
+	return &pb.SendMessageResponse{Success: true}, nil
+}
+
+// функция создания страниц админки поверх хранилища в памяти
+func newTestUI(t *testing.T, conf *configs.AdminAuthConfig) (*gin.Engine, *repository.MemoryRepository, *fakeSender) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	repo := repository.NewMemoryRepository()
+	auth, err := servicehttp.NewAuthService(repo, conf)
+	if err != nil {
+		t.Fatalf("NewAuthService: %v", err)
+	}
+	sender := &fakeSender{}
+	ui, err := New(servicehttp.NewBizServiceFacade(servicehttp.FacadeDeps{Users: repo, Admin: repo, Auth: auth, Sender: sender}))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	router := gin.New()
+	router.Use(middleware.CORSMiddleware()) // как в server.go: middleware стоит перед всеми маршрутами
+	ui.RegisterRoutes(router)
+	return router, repo, sender
+}
+
+// функция выключенного входа
+func disabledAuth() *configs.AdminAuthConfig {
+	conf := configs.UseDefaultAdminAuthConfig()
+	conf.Enabled = false
+	return conf
+}
+
+// функция запроса страницы с кукой CSRF (token == "" - без куки)
+func do(router *gin.Engine, method, path, token string, form url.Values) *httptest.ResponseRecorder {
+	var req *http.Request
+	if form != nil {
+		req = httptest.NewRequest(method, path, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	} else {
+		req = httptest.NewRequest(method, path, nil)
+	}
+	if token != "" {
+		req.AddCookie(&http.Cookie{Name: csrfCookie, Value: token})
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+// функция CSRF токена, выданного страницей
+func csrfToken(t *testing.T, router *gin.Engine) string {
+	t.Helper()
+	rec := do(router, http.MethodGet, "/admin", "", nil)
+	for _, cookie := range rec.Result().Cookies() {
+		if cookie.Name == csrfCookie {
+			return cookie.Value
+		}
+	}
+	t.Fatalf("no csrf cookie, status %d", rec.Code)
+	return ""
+}
+
+func TestPages(t *testing.T) {
+	router, repo, sender := newTestUI(t, disabledAuth())
+	ctx := context.Background()
+	now := time.Now()
+	if err := repo.CreateUser(ctx, &domain.User{TelegramID: 42, FirstName: "Анна", Username: "anna", IsActive: true, CreatedAt: now, LastSeenAt: now}); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	if _, err := repo.CreateLead(ctx, &domain.Lead{TelegramID: 42, Status: domain.LeadNew, Source: "contacted_yes"}); err != nil {
+		t.Fatalf("CreateLead: %v", err)
+	}
+	token := csrfToken(t, router)
+
+	t.Run("страницы открываются", func(t *testing.T) {
+		pages := map[string]string{
+			"/admin":          "Воронка за сегодня",
+			"/admin/users":    "@anna",
+			"/admin/users/42": "Переписка",
+			"/admin/leads":    "Анна",
+			"/admin/content":  "Главное меню",
+			"/admin/settings": "Часовой пояс",
+			"/admin/console":  "Все чаты ведёт бот",
+		}
+		for path, want := range pages {
+			rec := do(router, http.MethodGet, path, token, nil)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("%s: status %d", path, rec.Code)
+			}
+			if !strings.Contains(rec.Body.String(), want) {
+				t.Errorf("%s: no %q on the page", path, want)
+			}
+			if rec.Header().Get("Content-Security-Policy") == "" {
+				t.Errorf("%s: no Content-Security-Policy", path)
+			}
+		}
+	})
+
+	t.Run("неизвестный пользователь - 404", func(t *testing.T) {
+		if rec := do(router, http.MethodGet, "/admin/users/404", token, nil); rec.Code != http.StatusNotFound {
+			t.Fatalf("status %d, want 404", rec.Code)
+		}
+	})
+
+	t.Run("форма без CSRF токена отклоняется", func(t *testing.T) {
+		rec := do(router, http.MethodPost, "/admin/users/42/messages", token, url.Values{"text": {"Здравствуйте"}})
+		if rec.Code != http.StatusForbidden {
+			t.Fatalf("status %d, want 403", rec.Code)
+		}
+		rec = do(router, http.MethodPost, "/admin/users/42/messages", token, url.Values{"text": {"Здравствуйте"}, csrfField: {"chuzhoy"}})
+		if rec.Code != http.StatusForbidden {
+			t.Fatalf("wrong token: status %d, want 403", rec.Code)
+		}
+		if len(sender.sent) != 0 {
+			t.Fatalf("message sent without csrf token")
+		}
+	})
+
+	t.Run("сообщение клиенту уходит через бота и попадает в переписку", func(t *testing.T) {
+		rec := do(router, http.MethodPost, "/admin/users/42/messages", token, url.Values{"text": {"Здравствуйте!"}, csrfField: {token}})
+		if rec.Code != http.StatusSeeOther || !strings.HasPrefix(rec.Header().Get("Location"), "/admin/users/42?notice=") {
+			t.Fatalf("status %d, location %q", rec.Code, rec.Header().Get("Location"))
+		}
+		if len(sender.sent) != 1 || sender.sent[0].ChatId != 42 || sender.sent[0].Text != "Здравствуйте!" {
+			t.Fatalf("sent %v", sender.sent)
+		}
+		page := do(router, http.MethodGet, "/admin/users/42", token, nil).Body.String()
+		if !strings.Contains(page, "Здравствуйте!") {
+			t.Fatalf("outgoing message is not in the transcript")
+		}
+	})
+
+	t.Run("форма со своего хоста по HTTPS проходит CORS", func(t *testing.T) {
+		form := url.Values{"text": {"С админки"}, csrfField: {token}}
+		req := httptest.NewRequest(http.MethodPost, "https://admin.example.com/admin/users/42/messages", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("Origin", "https://admin.example.com")
+		req.AddCookie(&http.Cookie{Name: csrfCookie, Value: token})
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != http.StatusSeeOther {
+			t.Fatalf("status %d, want 303: %s", rec.Code, rec.Body.String())
+		}
+
+		// чужой сайт по-прежнему отклоняется
+		req = httptest.NewRequest(http.MethodPost, "https://admin.example.com/admin/users/42/messages", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("Origin", "https://evil.example.com")
+		rec = httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != http.StatusForbidden {
+			t.Fatalf("foreign origin: status %d, want 403", rec.Code)
+		}
+	})
+
+	t.Run("мастер перехватывает чат и возвращает его боту", func(t *testing.T) {
+		rec := do(router, http.MethodPost, "/admin/users/42/handoff", token, url.Values{csrfField: {token}})
+		if rec.Code != http.StatusSeeOther || !strings.Contains(rec.Header().Get("Location"), "notice=") {
+			t.Fatalf("status %d, location %q", rec.Code, rec.Header().Get("Location"))
+		}
+		if page := do(router, http.MethodGet, "/admin/users/42", token, nil).Body.String(); !strings.Contains(page, "Вернуть чат боту") {
+			t.Fatalf("no handoff on the user page")
+		}
+		if page := do(router, http.MethodGet, "/admin/console", token, nil).Body.String(); !strings.Contains(page, "мастер перехватил чат") {
+			t.Fatalf("no handoff in the console")
+		}
+
+		rec = do(router, http.MethodPost, "/admin/users/42/handoff/close", token, url.Values{csrfField: {token}})
+		if rec.Code != http.StatusSeeOther || !strings.Contains(rec.Header().Get("Location"), "notice=") {
+			t.Fatalf("close: status %d, location %q", rec.Code, rec.Header().Get("Location"))
+		}
+		rec = do(router, http.MethodPost, "/admin/users/42/handoff/close", token, url.Values{csrfField: {token}})
+		if !strings.Contains(rec.Header().Get("Location"), "error=") {
+			t.Fatalf("second close: location %q", rec.Header().Get("Location"))
+		}
+	})
+
+	t.Run("пустое сообщение - ошибка на странице", func(t *testing.T) {
+		rec := do(router, http.MethodPost, "/admin/users/42/messages", token, url.Values{"text": {"  "}, csrfField: {token}})
+		if rec.Code != http.StatusSeeOther || !strings.Contains(rec.Header().Get("Location"), "error=") {
+			t.Fatalf("status %d, location %q", rec.Code, rec.Header().Get("Location"))
+		}
+	})
+
+	t.Run("текст бота сохраняется и сбрасывается", func(t *testing.T) {
+		form := url.Values{"key": {string(domain.ContentHelp)}, "text": {"Новая справка"}, csrfField: {token}}
+		if rec := do(router, http.MethodPost, "/admin/content", token, form); rec.Code != http.StatusSeeOther {
+			t.Fatalf("save: status %d", rec.Code)
+		}
+		if !strings.Contains(do(router, http.MethodGet, "/admin/content", token, nil).Body.String(), "Новая справка") {
+			t.Fatalf("saved text is not on the page")
+		}
+
+		form.Set("reset", "1")
+		if rec := do(router, http.MethodPost, "/admin/content", token, form); rec.Code != http.StatusSeeOther {
+			t.Fatalf("reset: status %d", rec.Code)
+		}
+		if strings.Contains(do(router, http.MethodGet, "/admin/content", token, nil).Body.String(), "Новая справка") {
+			t.Fatalf("text is not reset")
+		}
+	})
+
+	t.Run("статус заявки меняется на доске", func(t *testing.T) {
+		form := url.Values{"status": {string(domain.LeadInProgress)}, "note": {"позвонить вечером"}, csrfField: {token}}
+		if rec := do(router, http.MethodPost, "/admin/leads/1", token, form); rec.Code != http.StatusSeeOther {
+			t.Fatalf("status %d", rec.Code)
+		}
+		lead, err := repo.GetLead(ctx, 1)
+		if err != nil {
+			t.Fatalf("GetLead: %v", err)
+		}
+		if lead.Status != domain.LeadInProgress || lead.Note != "позвонить вечером" {
+			t.Fatalf("lead %+v", lead)
+		}
+	})
+
+	t.Run("неверный часовой пояс не сохраняется", func(t *testing.T) {
+		form := url.Values{"timezone": {"Mars/Olympus"}, csrfField: {token}}
+		rec := do(router, http.MethodPost, "/admin/settings", token, form)
+		if rec.Code != http.StatusSeeOther || !strings.Contains(rec.Header().Get("Location"), "error=") {
+			t.Fatalf("status %d, location %q", rec.Code, rec.Header().Get("Location"))
+		}
+	})
+}
+
+func TestLoginRedirect(t *testing.T) {
+	t.Setenv("TEST_BOT_TOKEN", "123456:ABC-test-token")
+	t.Setenv("TEST_SESSION_SECRET", "test-session-secret-0123456789abcdef")
+	conf := configs.UseDefaultAdminAuthConfig()
+	conf.BotTokenEnv = "TEST_BOT_TOKEN"
+	conf.SessionSecretEnv = "TEST_SESSION_SECRET"
+	router, _, _ := newTestUI(t, conf)
+
+	t.Run("без входа - на страницу входа", func(t *testing.T) {
+		rec := do(router, http.MethodGet, "/admin/leads?status=new", "", nil)
+		if rec.Code != http.StatusSeeOther {
+			t.Fatalf("status %d, want 303", rec.Code)
+		}
+		if got, want := rec.Header().Get("Location"), "/admin/login?next="+url.QueryEscape("/admin/leads?status=new"); got != want {
+			t.Fatalf("location %q, want %q", got, want)
+		}
+	})
+
+	t.Run("страница входа знает id бота", func(t *testing.T) {
+		rec := do(router, http.MethodGet, "/admin/login?next=/admin/leads", "", nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status %d", rec.Code)
+		}
+		body := rec.Body.String()
+		if !strings.Contains(body, `data-bot-id="123456"`) || !strings.Contains(body, `data-next="/admin/leads"`) {
+			t.Fatalf("login page: %s", body)
+		}
+	})
+
+	t.Run("адрес возврата - только страницы админки", func(t *testing.T) {
+		for next, want := range map[string]string{
+			"/admin/users":         "/admin/users",
+			"//evil.example":       "/admin",
+			"https://evil.example": "/admin",
+			"/admin/login":         "/admin",
+			`/admin\..\x`:          "/admin",
+		} {
+			if got := safeNext(next); got != want {
+				t.Errorf("safeNext(%q) = %q, want %q", next, got, want)
+			}
+		}
+	})
+}
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"server/internal/domain"
+	"time"
+)
+
+// ответы API админки (доменные модели наружу не отдаются)
+
+// pageDTO - положение страницы в выборке
+type pageDTO struct {
+	Page     int   `json:"page"`
+	PageSize int   `json:"page_size"`
+	Total    int64 `json:"total"`
+}
+
+// функция описания страницы
+func toPageDTO(page domain.Page, total int64) pageDTO {
+	return pageDTO{Page: page.Number, PageSize: page.Size, Total: total}
+}
+
+// userDTO - пользователь Telegram
+type userDTO struct {
+	ID         int64     `json:"id"`
+	TelegramID int64     `json:"telegram_id"`
+	Username   string    `json:"username"`
+	FirstName  string    `json:"first_name"`
+	LastName   string    `json:"last_name"`
+	IsActive   bool      `json:"is_active"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+}
+
+// функция преобразования пользователя (nil - nil)
+func toUserDTO(u *domain.User) *userDTO {
+	if u == nil {
+		return nil
+	}
+	return &userDTO{
+		ID:         u.ID,
+		TelegramID: u.TelegramID,
+		Username:   u.Username,
+		FirstName:  u.FirstName,
+		LastName:   u.LastName,
+		IsActive:   u.IsActive,
+		CreatedAt:  u.CreatedAt,
+		LastSeenAt: u.LastSeenAt,
+	}
+}
+
+// messageDTO - сообщение переписки
+type messageDTO struct {
+	ID          int64     `json:"id"`
+	MessageID   int64     `json:"telegram_message_id"`
+	ChatID      int64     `json:"chat_id"`
+	Text        string    `json:"text"`
+	Direction   string    `json:"direction"`
+	Status      string    `json:"status"`
+	IsCommand   bool      `json:"is_command"`
+	CommandName string    `json:"command_name"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// callbackDTO - нажатие inline кнопки
+type callbackDTO struct {
+	ID         int64     `json:"id"`
+	CallbackID string    `json:"callback_id"`
+	TelegramID int64     `json:"telegram_id"`
+	ChatID     int64     `json:"chat_id"`
+	MessageID  int64     `json:"telegram_message_id"`
+	Data       string    `json:"data"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// leadDTO - заявка клиента
+type leadDTO struct {
+	ID         int64     `json:"id"`
+	TelegramID int64     `json:"telegram_id"`
+	Status     string    `json:"status"`
+	Source     string    `json:"source"`
+	Note       string    `json:"note"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+	User       *userDTO  `json:"user"` // null - пользователь не найден
+}
+
+// функция преобразования заявки
+func toLeadDTO(l *domain.Lead) leadDTO {
+	return leadDTO{
+		ID:         l.ID,
+		TelegramID: l.TelegramID,
+		Status:     string(l.Status),
+		Source:     l.Source,
+		Note:       l.Note,
+		CreatedAt:  l.CreatedAt,
+		UpdatedAt:  l.UpdatedAt,
+		User:       toUserDTO(l.User),
+	}
+}
+
+// settingsDTO - настройки бизнеса (и тело PUT /settings)
+type settingsDTO struct {
+	MasterName   string    `json:"master_name" binding:"max=100"`
+	InstagramURL string    `json:"instagram_url" binding:"max=200"`
+	WelcomeText  string    `json:"welcome_text" binding:"max=4096"`
+	NotifyChatID int64     `json:"notify_chat_id"`
+	Timezone     string    `json:"timezone" binding:"max=64"`
+	UpdatedAt    time.Time `json:"updated_at"` // при сохранении игнорируется
+}
+
+// функция преобразования настроек
+func toSettingsDTO(s *domain.BusinessSettings) settingsDTO {
+	return settingsDTO{
+		MasterName:   s.MasterName,
+		InstagramURL: s.InstagramURL,
+		WelcomeText:  s.WelcomeText,
+		NotifyChatID: s.NotifyChatID,
+		Timezone:     s.Timezone,
+		UpdatedAt:    s.UpdatedAt,
+	}
+}
+
+// функция преобразования списков (пустой список отдаётся как [], а не null)
+func mapSlice[T, D any](items []T, convert func(T) D) []D {
+	out := make([]D, 0, len(items))
+	for _, item := range items {
+		out = append(out, convert(item))
+	}
+	return out
+}
+
+// функция преобразования сообщения
+func toMessageDTO(m *domain.Message) messageDTO {
+	return messageDTO{
+		ID:          m.ID,
+		MessageID:   m.MessageID,
+		ChatID:      m.ChatID,
+		Text:        m.Text,
+		Direction:   m.Direction,
+		Status:      m.Status,
+		IsCommand:   m.IsCommand,
+		CommandName: m.CommandName,
+		CreatedAt:   m.CreatedAt,
+	}
+}
+
+// функция преобразования нажатия кнопки
+func toCallbackDTO(cb *domain.CallbackLog) callbackDTO {
+	return callbackDTO{
+		ID:         cb.ID,
+		CallbackID: cb.CallbackID,
+		TelegramID: cb.UserID,
+		ChatID:     cb.ChatID,
+		MessageID:  cb.MessageID,
+		Data:       cb.Data,
+		CreatedAt:  cb.Timestamp,
+	}
+}
@@ -0,0 +1,276 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"pkg/configs"
+	"pkg/scheduler"
+	"server/internal/biz_server/repository"
+	servicehttp "server/internal/biz_server/service_http"
+	"server/internal/domain"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// функция создания роутера с маршрутами админки поверх хранилища в памяти
+func newAdminRouter(t *testing.T) (*gin.Engine, *repository.MemoryRepository) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	sched, err := scheduler.New(scheduler.NewMemoryStore(), noLeader{}, configs.UseDefaultSchedulerConfig())
+	if err != nil {
+		t.Fatalf("scheduler.New: %v", err)
+	}
+	repo := repository.NewMemoryRepository()
+	h := NewBizHandler(servicehttp.NewBizServiceFacade(servicehttp.FacadeDeps{Users: repo, Admin: repo, Auth: disabledAuth(t, repo), Scheduler: sched}))
+
+	router := gin.New()
+	router.HandleMethodNotAllowed = true
+	router.NoRoute(h.NotFound)
+	router.NoMethod(h.MethodNotAllowed)
+	router.GET("/users", h.ListUsers)
+	router.GET("/users/:telegram_id", h.GetUser)
+	router.GET("/users/:telegram_id/messages", h.ListUserMessages)
+	router.GET("/callbacks", h.ListCallbacks)
+	router.GET("/leads", h.ListLeads)
+	router.GET("/leads/:id", h.GetLead)
+	router.PATCH("/leads/:id", h.UpdateLead)
+	router.GET("/settings", h.GetSettings)
+	router.PUT("/settings", h.UpdateSettings)
+	return router, repo
+}
+
+// функция выполнения запроса с JSON телом
+func doJSON(t *testing.T, router *gin.Engine, method, path, body string) (int, map[string]json.RawMessage) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(rec, req)
+	var out map[string]json.RawMessage
+	if err := json.Unmarshal(rec.Body.Bytes(), &out); err != nil {
+		t.Fatalf("%s %s: ответ не JSON: %s", method, path, rec.Body.String())
+	}
+	return rec.Code, out
+}
+
+// функция разбора ошибки из ответа
+func errorOf(t *testing.T, body map[string]json.RawMessage) apiError {
+	t.Helper()
+	var e apiError
+	if err := json.Unmarshal(body["error"], &e); err != nil || e.Code == "" {
+		t.Fatalf("ответ без ошибки в едином формате: %s", body["error"])
+	}
+	return e
+}
+
+func TestAdminHandlers(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("список пользователей со страницами и поиском", func(t *testing.T) {
+		router, repo := newAdminRouter(t)
+		for i, name := range []string{"anna", "boris", "vera"} {
+			now := time.Now().Add(time.Duration(i) * time.Minute)
+			user := &domain.User{TelegramID: int64(100 + i), Username: name, IsActive: true, CreatedAt: now, LastSeenAt: now}
+			if err := repo.CreateUser(ctx, user); err != nil {
+				t.Fatalf("пользователь: %v", err)
+			}
+		}
+
+		code, body := do(t, router, http.MethodGet, "/users?page=2&page_size=2")
+		var users []userDTO
+		var page pageDTO
+		json.Unmarshal(body["users"], &users)
+		json.Unmarshal(body["page"], &page)
+		if code != http.StatusOK || len(users) != 1 || users[0].Username != "anna" || page != (pageDTO{Page: 2, PageSize: 2, Total: 3}) {
+			t.Errorf("GET /users: %d %s %s", code, body["users"], body["page"])
+		}
+
+		_, body = do(t, router, http.MethodGet, "/users?query=BOR")
+		json.Unmarshal(body["users"], &users)
+		if len(users) != 1 || users[0].TelegramID != 101 {
+			t.Errorf("поиск: %s", body["users"])
+		}
+
+		_, body = do(t, router, http.MethodGet, "/users?query=nobody")
+		if string(body["users"]) != "[]" {
+			t.Errorf("пустой список должен быть [], получили %s", body["users"])
+		}
+	})
+
+	t.Run("ошибки запроса в едином формате", func(t *testing.T) {
+		router, _ := newAdminRouter(t)
+
+		code, body := do(t, router, http.MethodGet, "/users?page_size=1000&page=-1")
+		e := errorOf(t, body)
+		if code != http.StatusUnprocessableEntity || e.Code != codeValidation || len(e.Details) != 2 {
+			t.Errorf("валидация: %d %+v", code, e)
+		}
+		for _, d := range e.Details {
+			if d.Field != "page" && d.Field != "page_size" {
+				t.Errorf("поле должно называться как параметр запроса: %+v", d)
+			}
+		}
+
+		code, body = do(t, router, http.MethodGet, "/users?page=9223372036854775807")
+		if e := errorOf(t, body); code != http.StatusUnprocessableEntity || len(e.Details) != 1 || e.Details[0].Field != "page" {
+			t.Errorf("слишком большой номер страницы: %d %+v", code, e)
+		}
+
+		code, body = do(t, router, http.MethodGet, "/users?active=maybe")
+		if e := errorOf(t, body); code != http.StatusBadRequest || e.Code != codeBadRequest {
+			t.Errorf("неверный тип: %d %+v", code, e)
+		}
+
+		code, body = do(t, router, http.MethodGet, "/users/42")
+		if e := errorOf(t, body); code != http.StatusNotFound || e.Code != codeNotFound {
+			t.Errorf("нет пользователя: %d %+v", code, e)
+		}
+
+		code, body = do(t, router, http.MethodGet, "/missing")
+		if e := errorOf(t, body); code != http.StatusNotFound || e.Code != codeNotFound {
+			t.Errorf("нет маршрута: %d %+v", code, e)
+		}
+
+		code, body = do(t, router, http.MethodDelete, "/settings")
+		if e := errorOf(t, body); code != http.StatusMethodNotAllowed || e.Code != codeMethodNotAllowed {
+			t.Errorf("нет метода: %d %+v", code, e)
+		}
+	})
+
+	t.Run("профиль пользователя с перепиской", func(t *testing.T) {
+		router, repo := newAdminRouter(t)
+		now := time.Now()
+		if err := repo.CreateUser(ctx, &domain.User{TelegramID: 20, Username: "anna", CreatedAt: now, LastSeenAt: now}); err != nil {
+			t.Fatalf("пользователь: %v", err)
+		}
+		for i := range 3 {
+			msg := &domain.Message{MessageID: int64(i + 1), ChatID: 20, UserID: 20, Text: "привет", Direction: "incoming"}
+			if err := repo.Save(ctx, msg); err != nil {
+				t.Fatalf("сообщение: %v", err)
+			}
+		}
+		if _, err := repo.CreateLead(ctx, &domain.Lead{TelegramID: 20, Source: "contacted_yes"}); err != nil {
+			t.Fatalf("заявка: %v", err)
+		}
+
+		code, body := do(t, router, http.MethodGet, "/users/20")
+		var messages []messageDTO
+		var leads []leadDTO
+		json.Unmarshal(body["messages"], &messages)
+		json.Unmarshal(body["leads"], &leads)
+		if code != http.StatusOK || len(messages) != 3 || len(leads) != 1 || leads[0].Status != "new" {
+			t.Fatalf("GET /users/20: %d %s", code, body)
+		}
+
+		code, body = do(t, router, http.MethodGet, "/users/20/messages?limit=2")
+		json.Unmarshal(body["messages"], &messages)
+		var next int64
+		json.Unmarshal(body["next_before_id"], &next)
+		if code != http.StatusOK || len(messages) != 2 || next != messages[1].ID {
+			t.Fatalf("первая страница переписки: %d %s", code, body)
+		}
+		_, body = do(t, router, http.MethodGet, "/users/20/messages?limit=2&before_id="+string(body["next_before_id"]))
+		json.Unmarshal(body["messages"], &messages)
+		if len(messages) != 1 || messages[0].ID >= next {
+			t.Errorf("следующая страница переписки: %s", body["messages"])
+		}
+	})
+
+	t.Run("журнал нажатий", func(t *testing.T) {
+		router, repo := newAdminRouter(t)
+		for _, cb := range []*domain.CallbackLog{
+			{CallbackID: "a", UserID: 20, ChatID: 20, Data: "lookup"},
+			{CallbackID: "b", UserID: 21, ChatID: 21, Data: "contacted_yes"},
+		} {
+			if err := repo.SaveCallback(ctx, cb); err != nil {
+				t.Fatalf("колбэк: %v", err)
+			}
+		}
+
+		code, body := do(t, router, http.MethodGet, "/callbacks?data=contacted_yes")
+		var callbacks []callbackDTO
+		json.Unmarshal(body["callbacks"], &callbacks)
+		if code != http.StatusOK || len(callbacks) != 1 || callbacks[0].TelegramID != 21 || callbacks[0].CreatedAt.IsZero() {
+			t.Errorf("GET /callbacks: %d %s", code, body["callbacks"])
+		}
+	})
+
+	t.Run("смена статуса заявки", func(t *testing.T) {
+		router, repo := newAdminRouter(t)
+		first := &domain.Lead{TelegramID: 20}
+		repo.CreateLead(ctx, first)
+
+		code, body := doJSON(t, router, http.MethodPatch, "/leads/1", `{"status":"won","note":"  записалась "}`)
+		var lead leadDTO
+		json.Unmarshal(body["lead"], &lead)
+		if code != http.StatusOK || lead.Status != "won" || lead.Note != "записалась" {
+			t.Fatalf("PATCH /leads/1: %d %s", code, body)
+		}
+
+		code, body = doJSON(t, router, http.MethodPatch, "/leads/1", `{"status":"done"}`)
+		if e := errorOf(t, body); code != http.StatusUnprocessableEntity || e.Details[0].Field != "status" {
+			t.Errorf("неизвестный статус: %d %+v", code, e)
+		}
+		code, body = doJSON(t, router, http.MethodPatch, "/leads/1", `{"status":`)
+		if e := errorOf(t, body); code != http.StatusBadRequest || e.Code != codeBadRequest {
+			t.Errorf("битый JSON: %d %+v", code, e)
+		}
+
+		// вторая открытая заявка того же пользователя
+		repo.CreateLead(ctx, &domain.Lead{TelegramID: 20})
+		code, body = doJSON(t, router, http.MethodPatch, "/leads/1", `{"status":"in_progress"}`)
+		if e := errorOf(t, body); code != http.StatusConflict || e.Code != codeConflict {
+			t.Errorf("повторное открытие: %d %+v", code, e)
+		}
+
+		code, body = do(t, router, http.MethodGet, "/leads?status=new")
+		var leads []leadDTO
+		json.Unmarshal(body["leads"], &leads)
+		if code != http.StatusOK || len(leads) != 1 || leads[0].ID != 2 {
+			t.Errorf("GET /leads?status=new: %d %s", code, body["leads"])
+		}
+		if code, _ := do(t, router, http.MethodGet, "/leads/99"); code != http.StatusNotFound {
+			t.Errorf("нет заявки: %d", code)
+		}
+	})
+
+	t.Run("настройки бизнеса", func(t *testing.T) {
+		router, _ := newAdminRouter(t)
+
+		code, body := do(t, router, http.MethodGet, "/settings")
+		var settings settingsDTO
+		json.Unmarshal(body["settings"], &settings)
+		if code != http.StatusOK || settings.Timezone != "UTC" {
+			t.Errorf("настройки по умолчанию: %d %s", code, body["settings"])
+		}
+
+		code, body = doJSON(t, router, http.MethodPut, "/settings",
+			`{"master_name":"Мастер","instagram_url":"https://instagram.com/master","timezone":"Europe/Moscow","notify_chat_id":42}`)
+		json.Unmarshal(body["settings"], &settings)
+		if code != http.StatusOK || settings.Timezone != "Europe/Moscow" || settings.NotifyChatID != 42 || settings.UpdatedAt.IsZero() {
+			t.Fatalf("PUT /settings: %d %s", code, body)
+		}
+
+		for field, req := range map[string]string{
+			"timezone":      `{"timezone":"Mars/Olympus"}`,
+			"instagram_url": `{"instagram_url":"javascript:alert(1)"}`,
+		} {
+			code, body := doJSON(t, router, http.MethodPut, "/settings", req)
+			if e := errorOf(t, body); code != http.StatusUnprocessableEntity || e.Details[0].Field != field {
+				t.Errorf("%s: %d %+v", field, code, e)
+			}
+		}
+
+		_, body = do(t, router, http.MethodGet, "/settings")
+		json.Unmarshal(body["settings"], &settings)
+		if settings.MasterName != "Мастер" {
+			t.Errorf("неверные настройки не должны сохраняться: %s", body["settings"])
+		}
+	})
+}
@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"net/http"
+	"server/internal/domain"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// формат даты начала периода
+const periodDateLayout = "2006-01-02"
+
+// параметры выдачи воронок и источников
+type analyticsQuery struct {
+	Period string `form:"period" binding:"omitempty,oneof=day week"` // пусто - по дням
+	Limit  int    `form:"limit" binding:"min=0,max=366"`             // сколько последних периодов (0 - по умолчанию)
+}
+
+// метод возвращает период с значением по умолчанию
+func (q analyticsQuery) period() domain.AnalyticsPeriod {
+	if q.Period == "" {
+		return domain.PeriodDay
+	}
+	return domain.AnalyticsPeriod(q.Period)
+}
+
+// параметры выдачи когорт
+type cohortsQuery struct {
+	Limit int `form:"limit" binding:"min=0,max=104"` // сколько последних недель (0 - по умолчанию)
+}
+
+// funnelDTO - воронка за период (время до связи - в секундах)
+type funnelDTO struct {
+	Period               string  `json:"period"`
+	Start                string  `json:"start"`
+	NewUsers             int64   `json:"new_users"`
+	ActiveUsers          int64   `json:"active_users"`
+	Started              int64   `json:"started"`
+	Lookups              int64   `json:"lookups"`
+	ContactedYes         int64   `json:"contacted_yes"`
+	LookupRate           float64 `json:"lookup_rate"`  // lookups / started
+	ContactRate          float64 `json:"contact_rate"` // contacted_yes / lookups
+	Contacts             int64   `json:"contacts"`
+	ContactTimeAvgSec    int64   `json:"contact_time_avg_sec"`
+	ContactTimeMedianSec int64   `json:"contact_time_median_sec"`
+}
+
+// sourceDTO - новые пользователи периода из источника
+type sourceDTO struct {
+	Period         string  `json:"period"`
+	Start          string  `json:"start"`
+	Source         string  `json:"source"`
+	NewUsers       int64   `json:"new_users"`
+	Lookups        int64   `json:"lookups"`
+	ContactedYes   int64   `json:"contacted_yes"`
+	ConversionRate float64 `json:"conversion_rate"` // contacted_yes / new_users
+}
+
+// cohortDTO - недельная когорта (retained[i] - активные на i-й неделе после прихода)
+type cohortDTO struct {
+	Start    string  `json:"start"`
+	Size     int64   `json:"size"`
+	Retained []int64 `json:"retained"`
+}
+
+// функция доли (0 - знаменатель пуст)
+func rate(part, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(part) / float64(total)
+}
+
+// функция преобразования воронки
+func toFunnelDTO(f *domain.FunnelStats) funnelDTO {
+	return funnelDTO{
+		Period:               string(f.Period),
+		Start:                f.Start.Format(periodDateLayout),
+		NewUsers:             f.NewUsers,
+		ActiveUsers:          f.ActiveUsers,
+		Started:              f.Started,
+		Lookups:              f.Lookups,
+		ContactedYes:         f.ContactedYes,
+		LookupRate:           rate(f.Lookups, f.Started),
+		ContactRate:          rate(f.ContactedYes, f.Lookups),
+		Contacts:             f.Contacts,
+		ContactTimeAvgSec:    int64(f.ContactTimeAvg / time.Second),
+		ContactTimeMedianSec: int64(f.ContactTimeMedian / time.Second),
+	}
+}
+
+// функция преобразования источника
+func toSourceDTO(s *domain.SourceStats) sourceDTO {
+	return sourceDTO{
+		Period:         string(s.Period),
+		Start:          s.Start.Format(periodDateLayout),
+		Source:         s.Source,
+		NewUsers:       s.NewUsers,
+		Lookups:        s.Lookups,
+		ContactedYes:   s.ContactedYes,
+		ConversionRate: rate(s.ContactedYes, s.NewUsers),
+	}
+}
+
+// функция преобразования когорты
+func toCohortDTO(c *domain.CohortStats) cohortDTO {
+	retained := c.Retained
+	if retained == nil {
+		retained = []int64{}
+	}
+	return cohortDTO{Start: c.Start.Format(periodDateLayout), Size: c.Size, Retained: retained}
+}
+
+// метод выдачи воронок (?period=day|week&limit=)
+func (h *BizHTTPHandler) GetFunnelStats(c *gin.Context) {
+	var query analyticsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		bindError(c, err)
+		return
+	}
+
+	funnels, err := h.Service.Analytics.Funnels(c.Request.Context(), query.period(), query.Limit)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"funnels": mapSlice(funnels, toFunnelDTO)})
+}
+
+// метод выдачи конверсии по источникам (?period=day|week&limit=)
+func (h *BizHTTPHandler) GetSourceStats(c *gin.Context) {
+	var query analyticsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		bindError(c, err)
+		return
+	}
+
+	sources, err := h.Service.Analytics.Sources(c.Request.Context(), query.period(), query.Limit)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"sources": mapSlice(sources, toSourceDTO)})
+}
+
+// метод выдачи удержания недельных когорт (?limit=)
+func (h *BizHTTPHandler) GetRetention(c *gin.Context) {
+	var query cohortsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		bindError(c, err)
+		return
+	}
+
+	cohorts, err := h.Service.Analytics.Cohorts(c.Request.Context(), query.Limit)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"cohorts": mapSlice(cohorts, toCohortDTO)})
+}
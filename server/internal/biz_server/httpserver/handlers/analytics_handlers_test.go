@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"server/internal/biz_server/repository"
+	servicehttp "server/internal/biz_server/service_http"
+	"server/internal/domain"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// функция создания роутера с маршрутами аналитики и пересчитанными агрегатами:
+// пользователь пришёл по ссылке ?start=vk, познакомился с мастером и согласился на связь
+func newAnalyticsRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	ctx := context.Background()
+
+	repo := repository.NewMemoryRepository()
+	now := time.Now()
+	if err := repo.CreateUser(ctx, &domain.User{TelegramID: 20, IsActive: true, CreatedAt: now, LastSeenAt: now}); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	repo.Save(ctx, &domain.Message{MessageID: 1, ChatID: 20, UserID: 20, Text: "/start vk", Direction: "incoming", CreatedAt: now})
+	for i, data := range []string{domain.FunnelLookup, domain.FunnelContactedYes} {
+		repo.SaveCallback(ctx, &domain.CallbackLog{CallbackID: string(rune('a' + i)), UserID: 20, ChatID: 20, MessageID: 1, Data: data})
+	}
+
+	service := servicehttp.NewBizServiceFacade(servicehttp.FacadeDeps{Users: repo, Admin: repo, Auth: disabledAuth(t, repo)})
+	if _, err := service.Analytics.Refresh(ctx, 7, 4); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	h := NewBizHandler(service)
+	router := gin.New()
+	router.GET("/analytics/funnel", h.GetFunnelStats)
+	router.GET("/analytics/sources", h.GetSourceStats)
+	router.GET("/analytics/retention", h.GetRetention)
+	return router
+}
+
+func TestAnalyticsHandlers(t *testing.T) {
+	router := newAnalyticsRouter(t)
+
+	t.Run("воронка за сегодня", func(t *testing.T) {
+		code, body := do(t, router, http.MethodGet, "/analytics/funnel?limit=1")
+		var funnels []funnelDTO
+		json.Unmarshal(body["funnels"], &funnels)
+		if code != http.StatusOK || len(funnels) != 1 {
+			t.Fatalf("GET /analytics/funnel: %d %s", code, body["funnels"])
+		}
+		f := funnels[0]
+		if f.Period != "day" || f.NewUsers != 1 || f.Started != 1 || f.Lookups != 1 || f.ContactedYes != 1 ||
+			f.LookupRate != 1 || f.ContactRate != 1 || f.Contacts != 1 {
+			t.Errorf("воронка: %+v", f)
+		}
+		if _, err := time.Parse(periodDateLayout, f.Start); err != nil {
+			t.Errorf("дата периода: %q", f.Start)
+		}
+	})
+
+	t.Run("все дни окна, в том числе пустые", func(t *testing.T) {
+		_, body := do(t, router, http.MethodGet, "/analytics/funnel?period=day")
+		var funnels []funnelDTO
+		json.Unmarshal(body["funnels"], &funnels)
+		if len(funnels) != 7 || funnels[0].Start <= funnels[6].Start {
+			t.Errorf("ожидали 7 дней, новые первыми: %s", body["funnels"])
+		}
+	})
+
+	t.Run("неизвестный период - 422", func(t *testing.T) {
+		code, body := do(t, router, http.MethodGet, "/analytics/funnel?period=month")
+		if e := errorOf(t, body); code != http.StatusUnprocessableEntity || e.Code != codeValidation {
+			t.Errorf("period=month: %d %+v", code, e)
+		}
+	})
+
+	t.Run("конверсия по источникам за неделю", func(t *testing.T) {
+		code, body := do(t, router, http.MethodGet, "/analytics/sources?period=week&limit=1")
+		var sources []sourceDTO
+		json.Unmarshal(body["sources"], &sources)
+		if code != http.StatusOK || len(sources) != 1 || sources[0].Source != "vk" || sources[0].ConversionRate != 1 {
+			t.Errorf("GET /analytics/sources: %d %s", code, body["sources"])
+		}
+	})
+
+	t.Run("удержание когорт", func(t *testing.T) {
+		code, body := do(t, router, http.MethodGet, "/analytics/retention")
+		var cohorts []cohortDTO
+		json.Unmarshal(body["cohorts"], &cohorts)
+		if code != http.StatusOK || len(cohorts) != 1 || cohorts[0].Size != 1 || len(cohorts[0].Retained) != 1 || cohorts[0].Retained[0] != 1 {
+			t.Errorf("GET /analytics/retention: %d %s", code, body["cohorts"])
+		}
+	})
+}
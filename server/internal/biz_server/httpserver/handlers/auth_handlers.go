@@ -0,0 +1,259 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"pkg/adminauth"
+	"pkg/configs"
+	servicehttp "server/internal/biz_server/service_http"
+	"server/internal/domain"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// куки сессии администратора: токен доступа отправляется на все запросы (и на страницы админки),
+// токен обновления - только на /api/v1/auth
+const (
+	AccessCookie      = "admin_access"
+	refreshCookie     = "admin_refresh"
+	refreshCookiePath = "/api/v1/auth"
+)
+
+// ClaimsKey - ключ контекста gin с данными токена доступа (нет - вход выключен)
+const ClaimsKey = "admin_claims"
+
+// тело POST /auth/refresh и /auth/logout (клиенты без кук передают токен в теле)
+type refreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// роль администратора в пути запроса
+type setRoleRequest struct {
+	Role string `json:"role" binding:"required,oneof=owner manager viewer"`
+}
+
+// adminDTO - администратор админки
+type adminDTO struct {
+	TelegramID  int64      `json:"telegram_id"`
+	Role        string     `json:"role"`
+	Username    string     `json:"username"`
+	FirstName   string     `json:"first_name"`
+	LastName    string     `json:"last_name"`
+	CreatedAt   time.Time  `json:"created_at"`
+	LastLoginAt *time.Time `json:"last_login_at"`
+}
+
+// функция преобразования администратора
+func toAdminDTO(a *domain.Admin) adminDTO {
+	return adminDTO{
+		TelegramID:  a.TelegramID,
+		Role:        string(a.Role),
+		Username:    a.Username,
+		FirstName:   a.FirstName,
+		LastName:    a.LastName,
+		CreatedAt:   a.CreatedAt,
+		LastLoginAt: a.LastLoginAt,
+	}
+}
+
+// функция чтения полей виджета: значения - строки или числа (id, auth_date), вложенных объектов нет
+func loginFields(c *gin.Context) (map[string]string, error) {
+	body, err := c.GetRawData()
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var raw map[string]any
+	if err := dec.Decode(&raw); err != nil {
+		return nil, err
+	}
+
+	fields := make(map[string]string, len(raw))
+	for key, value := range raw {
+		switch v := value.(type) {
+		case string:
+			fields[key] = v
+		case json.Number:
+			fields[key] = v.String()
+		default:
+			return nil, fmt.Errorf("field %q must be a string or a number", key)
+		}
+	}
+	return fields, nil
+}
+
+// SetSessionCookies ставит куки сессии (tokens == nil - удаляет их)
+func SetSessionCookies(c *gin.Context, conf *configs.AdminAuthConfig, tokens *servicehttp.Tokens) {
+	c.SetSameSite(http.SameSiteStrictMode)
+
+	accessAge, refreshAge := -1, -1
+	access, refresh := "", ""
+	if tokens != nil {
+		access, refresh = tokens.Access, tokens.Refresh
+		accessAge = int(time.Until(tokens.AccessExpiresAt).Seconds())
+		refreshAge = int(time.Until(tokens.RefreshExpiresAt).Seconds())
+	}
+	c.SetCookie(AccessCookie, access, accessAge, "/", conf.CookieDomain, conf.CookieSecure, true)
+	c.SetCookie(refreshCookie, refresh, refreshAge, refreshCookiePath, conf.CookieDomain, conf.CookieSecure, true)
+}
+
+// функция ответа с новой сессией (токены - и в куках для браузера, и в теле для остальных клиентов)
+func (h *BizHTTPHandler) respondSession(c *gin.Context, session *servicehttp.AdminSession) {
+	SetSessionCookies(c, h.Service.Auth.Config(), &session.Tokens)
+	c.JSON(http.StatusOK, gin.H{
+		"admin":              toAdminDTO(session.Admin),
+		"access_token":       session.Tokens.Access,
+		"access_expires_at":  session.Tokens.AccessExpiresAt,
+		"refresh_token":      session.Tokens.Refresh,
+		"refresh_expires_at": session.Tokens.RefreshExpiresAt,
+	})
+}
+
+// функция токена обновления: из тела запроса или из куки
+func refreshTokenOf(c *gin.Context) string {
+	var req refreshRequest
+	if c.Request.ContentLength != 0 && c.ShouldBindJSON(&req) == nil && req.RefreshToken != "" {
+		return req.RefreshToken
+	}
+	token, _ := c.Cookie(refreshCookie)
+	return token
+}
+
+// метод входа по данным Telegram Login Widget
+func (h *BizHTTPHandler) LoginTelegram(c *gin.Context) {
+	fields, err := loginFields(c)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, codeBadRequest, "malformed request: "+err.Error())
+		return
+	}
+
+	session, err := h.Service.Auth.Login(c.Request.Context(), fields, c.Request.UserAgent())
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	h.respondSession(c, session)
+}
+
+// метод обновления токенов по одноразовому токену обновления
+func (h *BizHTTPHandler) RefreshSession(c *gin.Context) {
+	session, err := h.Service.Auth.Refresh(c.Request.Context(), refreshTokenOf(c), c.Request.UserAgent())
+	if err != nil {
+		if errors.Is(err, servicehttp.ErrUnauthorized) {
+			SetSessionCookies(c, h.Service.Auth.Config(), nil)
+		}
+		serviceError(c, err)
+		return
+	}
+	h.respondSession(c, session)
+}
+
+// метод выхода (закрывает сессию и удаляет куки)
+func (h *BizHTTPHandler) Logout(c *gin.Context) {
+	if err := h.Service.Auth.Logout(c.Request.Context(), refreshTokenOf(c)); err != nil {
+		serviceError(c, err)
+		return
+	}
+	SetSessionCookies(c, h.Service.Auth.Config(), nil)
+	c.Status(http.StatusNoContent)
+}
+
+// метод выдачи текущего администратора
+func (h *BizHTTPHandler) CurrentAdmin(c *gin.Context) {
+	claims, ok := c.Get(ClaimsKey)
+	if !ok {
+		// вход выключен - администратора нет
+		respondError(c, http.StatusNotFound, codeNotFound, "admin auth is disabled")
+		return
+	}
+	admin, err := h.Service.Auth.GetAdmin(c.Request.Context(), claims.(*adminauth.Claims).Subject)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"admin": toAdminDTO(admin)})
+}
+
+// метод выдачи списка администраторов
+func (h *BizHTTPHandler) ListAdmins(c *gin.Context) {
+	admins, err := h.Service.Auth.ListAdmins(c.Request.Context())
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"admins": mapSlice(admins, toAdminDTO)})
+}
+
+// метод назначения роли администратору (создаёт администратора, если его нет)
+func (h *BizHTTPHandler) SetAdminRole(c *gin.Context) {
+	var uri telegramIDURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		bindError(c, err)
+		return
+	}
+	var req setRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		bindError(c, err)
+		return
+	}
+
+	admin, err := h.Service.Auth.SetRole(c.Request.Context(), uri.TelegramID, domain.AdminRole(req.Role))
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"admin": toAdminDTO(admin)})
+}
+
+// метод удаления администратора
+func (h *BizHTTPHandler) RemoveAdmin(c *gin.Context) {
+	var uri telegramIDURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		bindError(c, err)
+		return
+	}
+	if err := h.Service.Auth.RemoveAdmin(c.Request.Context(), uri.TelegramID); err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// RequireRole - middleware проверки входа и роли (токен доступа - в заголовке Authorization: Bearer или в куке).
+// При выключенном входе пропускает все запросы
+func (h *BizHTTPHandler) RequireRole(role string) gin.HandlerFunc {
+	required := domain.AdminRole(role)
+	if !required.Valid() {
+		panic(fmt.Sprintf("RequireRole: unknown role %q", role))
+	}
+
+	return func(c *gin.Context) {
+		if !h.Service.Auth.Enabled() {
+			c.Next()
+			return
+		}
+
+		token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok {
+			token, _ = c.Cookie(AccessCookie)
+		}
+		claims, err := h.Service.Auth.Authenticate(token)
+		if err != nil {
+			serviceError(c, err)
+			return
+		}
+		if !domain.AdminRole(claims.Role).Allows(required) {
+			serviceError(c, servicehttp.ErrForbidden)
+			return
+		}
+
+		c.Set(ClaimsKey, claims)
+		c.Next()
+	}
+}
@@ -0,0 +1,273 @@
+package handlers
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"pkg/configs"
+	"pkg/scheduler"
+	"server/internal/biz_server/repository"
+	servicehttp "server/internal/biz_server/service_http"
+	"server/internal/domain"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	testBotToken      = "123456:ABC-test-token"
+	testSessionSecret = "test-session-secret-0123456789abcdef"
+)
+
+// функция создания сервиса с выключенным входом (маршруты открыты)
+func disabledAuth(t *testing.T, repo repository.AdminAuthRepository) *servicehttp.AuthService {
+	t.Helper()
+	conf := configs.UseDefaultAdminAuthConfig()
+	conf.Enabled = false
+	auth, err := servicehttp.NewAuthService(repo, conf)
+	if err != nil {
+		t.Fatalf("NewAuthService: %v", err)
+	}
+	return auth
+}
+
+// функция создания роутера с включённым входом и ролями маршрутов как в сервере
+func newAuthRouter(t *testing.T) (*gin.Engine, *repository.MemoryRepository) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	t.Setenv("TEST_BOT_TOKEN", testBotToken)
+	t.Setenv("TEST_SESSION_SECRET", testSessionSecret)
+
+	conf := configs.UseDefaultAdminAuthConfig()
+	conf.BotTokenEnv = "TEST_BOT_TOKEN"
+	conf.SessionSecretEnv = "TEST_SESSION_SECRET"
+	repo := repository.NewMemoryRepository()
+	auth, err := servicehttp.NewAuthService(repo, conf)
+	if err != nil {
+		t.Fatalf("NewAuthService: %v", err)
+	}
+	if err := auth.EnsureOwners(context.Background(), []int64{1}); err != nil {
+		t.Fatalf("EnsureOwners: %v", err)
+	}
+	sched, err := scheduler.New(scheduler.NewMemoryStore(), noLeader{}, configs.UseDefaultSchedulerConfig())
+	if err != nil {
+		t.Fatalf("scheduler.New: %v", err)
+	}
+	h := NewBizHandler(servicehttp.NewBizServiceFacade(servicehttp.FacadeDeps{Users: repo, Admin: repo, Auth: auth, Scheduler: sched}))
+
+	router := gin.New()
+	router.POST("/auth/telegram", h.LoginTelegram)
+	router.POST("/auth/refresh", h.RefreshSession)
+	router.POST("/auth/logout", h.Logout)
+	router.GET("/auth/me", h.RequireRole("viewer"), h.CurrentAdmin)
+	router.GET("/settings", h.RequireRole("viewer"), h.GetSettings)
+	router.PUT("/settings", h.RequireRole("manager"), h.UpdateSettings)
+	router.GET("/admins", h.RequireRole("owner"), h.ListAdmins)
+	router.PUT("/admins/:telegram_id", h.RequireRole("owner"), h.SetAdminRole)
+	router.DELETE("/admins/:telegram_id", h.RequireRole("owner"), h.RemoveAdmin)
+	return router, repo
+}
+
+// функция данных виджета, подписанных так, как это делает Telegram
+func widgetLogin(telegramID int64, username string) string {
+	fields := map[string]string{
+		"id":         strconv.FormatInt(telegramID, 10),
+		"username":   username,
+		"first_name": "Test",
+		"auth_date":  strconv.FormatInt(time.Now().Unix(), 10),
+	}
+	var lines []string
+	for key, value := range fields {
+		lines = append(lines, key+"="+value)
+	}
+	slices.Sort(lines)
+	secret := sha256.Sum256([]byte(testBotToken))
+	mac := hmac.New(sha256.New, secret[:])
+	mac.Write([]byte(strings.Join(lines, "\n")))
+
+	// id и auth_date виджет присылает числами
+	return `{"id":` + fields["id"] + `,"username":"` + username + `","first_name":"Test","auth_date":` +
+		fields["auth_date"] + `,"hash":"` + hex.EncodeToString(mac.Sum(nil)) + `"}`
+}
+
+// функция выполнения запроса с токеном доступа (пустой - без заголовка)
+func doAuth(t *testing.T, router *gin.Engine, method, path, token, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+// функция входа, возвращает токены доступа и обновления
+func login(t *testing.T, router *gin.Engine, telegramID int64) (string, string) {
+	t.Helper()
+	rec := doAuth(t, router, http.MethodPost, "/auth/telegram", "", widgetLogin(telegramID, "admin"))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("вход %d: %d %s", telegramID, rec.Code, rec.Body.String())
+	}
+	var out struct {
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+	}
+	json.Unmarshal(rec.Body.Bytes(), &out)
+	return out.AccessToken, out.RefreshToken
+}
+
+// функция кода ошибки из ответа
+func errorCode(rec *httptest.ResponseRecorder) string {
+	var body errorBody
+	json.Unmarshal(rec.Body.Bytes(), &body)
+	return body.Error.Code
+}
+
+func TestAuthHandlers(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("вход через виджет и куки сессии", func(t *testing.T) {
+		router, _ := newAuthRouter(t)
+
+		rec := doAuth(t, router, http.MethodPost, "/auth/telegram", "", widgetLogin(1, "owner"))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("вход: %d %s", rec.Code, rec.Body.String())
+		}
+		cookies := map[string]*http.Cookie{}
+		for _, c := range rec.Result().Cookies() {
+			cookies[c.Name] = c
+		}
+		access, refresh := cookies[AccessCookie], cookies[refreshCookie]
+		if access == nil || !access.HttpOnly || !access.Secure || access.SameSite != http.SameSiteStrictMode {
+			t.Errorf("кука доступа: %+v", access)
+		}
+		if refresh == nil || refresh.Path != refreshCookiePath {
+			t.Errorf("кука обновления: %+v", refresh)
+		}
+
+		// токен доступа из куки
+		req := httptest.NewRequest(http.MethodGet, "/auth/me", nil)
+		req.AddCookie(access)
+		me := httptest.NewRecorder()
+		router.ServeHTTP(me, req)
+		if me.Code != http.StatusOK || !strings.Contains(me.Body.String(), `"username":"owner"`) {
+			t.Errorf("/auth/me: %d %s", me.Code, me.Body.String())
+		}
+	})
+
+	t.Run("неверная подпись и чужой пользователь", func(t *testing.T) {
+		router, _ := newAuthRouter(t)
+
+		forged := strings.Replace(widgetLogin(1, "owner"), `"username":"owner"`, `"username":"evil"`, 1)
+		if rec := doAuth(t, router, http.MethodPost, "/auth/telegram", "", forged); rec.Code != http.StatusUnauthorized || errorCode(rec) != codeUnauthorized {
+			t.Errorf("подделка: %d %s", rec.Code, rec.Body.String())
+		}
+		if rec := doAuth(t, router, http.MethodPost, "/auth/telegram", "", widgetLogin(42, "stranger")); rec.Code != http.StatusForbidden || errorCode(rec) != codeForbidden {
+			t.Errorf("не администратор: %d %s", rec.Code, rec.Body.String())
+		}
+		if rec := doAuth(t, router, http.MethodPost, "/auth/telegram", "", `{"id":{}}`); rec.Code != http.StatusBadRequest {
+			t.Errorf("неверное тело: %d", rec.Code)
+		}
+	})
+
+	t.Run("роли маршрутов", func(t *testing.T) {
+		router, repo := newAuthRouter(t)
+		repo.SaveAdmin(ctx, &domain.Admin{TelegramID: 2, Role: domain.RoleViewer})
+		repo.SaveAdmin(ctx, &domain.Admin{TelegramID: 3, Role: domain.RoleManager})
+		viewer, _ := login(t, router, 2)
+		manager, _ := login(t, router, 3)
+		owner, _ := login(t, router, 1)
+
+		settings := `{"business_name":"Студия","timezone":"UTC"}`
+		cases := []struct {
+			name, method, path, token, body string
+			want                            int
+		}{
+			{"без токена", http.MethodGet, "/settings", "", "", http.StatusUnauthorized},
+			{"мусорный токен", http.MethodGet, "/settings", "garbage", "", http.StatusUnauthorized},
+			{"viewer читает", http.MethodGet, "/settings", viewer, "", http.StatusOK},
+			{"viewer не меняет", http.MethodPut, "/settings", viewer, settings, http.StatusForbidden},
+			{"manager меняет", http.MethodPut, "/settings", manager, settings, http.StatusOK},
+			{"manager не управляет администраторами", http.MethodGet, "/admins", manager, "", http.StatusForbidden},
+			{"owner управляет администраторами", http.MethodGet, "/admins", owner, "", http.StatusOK},
+		}
+		for _, tc := range cases {
+			if rec := doAuth(t, router, tc.method, tc.path, tc.token, tc.body); rec.Code != tc.want {
+				t.Errorf("%s: %d, ожидали %d (%s)", tc.name, rec.Code, tc.want, rec.Body.String())
+			}
+		}
+	})
+
+	t.Run("обновление одноразовое, повтор закрывает все сессии", func(t *testing.T) {
+		router, _ := newAuthRouter(t)
+		_, first := login(t, router, 1)
+		_, other := login(t, router, 1)
+
+		rec := doAuth(t, router, http.MethodPost, "/auth/refresh", "", `{"refresh_token":"`+first+`"}`)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("обновление: %d %s", rec.Code, rec.Body.String())
+		}
+		var out struct {
+			RefreshToken string `json:"refresh_token"`
+		}
+		json.Unmarshal(rec.Body.Bytes(), &out)
+		if out.RefreshToken == "" || out.RefreshToken == first {
+			t.Fatalf("токен обновления должен смениться: %q", out.RefreshToken)
+		}
+
+		if rec := doAuth(t, router, http.MethodPost, "/auth/refresh", "", `{"refresh_token":"`+first+`"}`); rec.Code != http.StatusUnauthorized {
+			t.Errorf("повтор старого токена: %d", rec.Code)
+		}
+		for _, token := range []string{out.RefreshToken, other} {
+			if rec := doAuth(t, router, http.MethodPost, "/auth/refresh", "", `{"refresh_token":"`+token+`"}`); rec.Code != http.StatusUnauthorized {
+				t.Errorf("после повтора все сессии закрыты: %d", rec.Code)
+			}
+		}
+	})
+
+	t.Run("выход закрывает сессию", func(t *testing.T) {
+		router, _ := newAuthRouter(t)
+		_, refresh := login(t, router, 1)
+
+		if rec := doAuth(t, router, http.MethodPost, "/auth/logout", "", `{"refresh_token":"`+refresh+`"}`); rec.Code != http.StatusNoContent {
+			t.Fatalf("выход: %d", rec.Code)
+		}
+		if rec := doAuth(t, router, http.MethodPost, "/auth/refresh", "", `{"refresh_token":"`+refresh+`"}`); rec.Code != http.StatusUnauthorized {
+			t.Errorf("обновление после выхода: %d", rec.Code)
+		}
+	})
+
+	t.Run("управление администраторами", func(t *testing.T) {
+		router, _ := newAuthRouter(t)
+		owner, _ := login(t, router, 1)
+
+		if rec := doAuth(t, router, http.MethodPut, "/admins/5", owner, `{"role":"manager"}`); rec.Code != http.StatusOK {
+			t.Errorf("назначение роли: %d %s", rec.Code, rec.Body.String())
+		}
+		if rec := doAuth(t, router, http.MethodPut, "/admins/5", owner, `{"role":"root"}`); rec.Code != http.StatusUnprocessableEntity {
+			t.Errorf("неизвестная роль: %d", rec.Code)
+		}
+		if rec := doAuth(t, router, http.MethodPut, "/admins/1", owner, `{"role":"viewer"}`); rec.Code != http.StatusConflict {
+			t.Errorf("понижение последнего владельца: %d", rec.Code)
+		}
+		if rec := doAuth(t, router, http.MethodDelete, "/admins/1", owner, ""); rec.Code != http.StatusConflict {
+			t.Errorf("удаление последнего владельца: %d", rec.Code)
+		}
+		if rec := doAuth(t, router, http.MethodDelete, "/admins/5", owner, ""); rec.Code != http.StatusNoContent {
+			t.Errorf("удаление: %d", rec.Code)
+		}
+		if rec := doAuth(t, router, http.MethodDelete, "/admins/5", owner, ""); rec.Code != http.StatusNotFound {
+			t.Errorf("удаление несуществующего: %d", rec.Code)
+		}
+	})
+}
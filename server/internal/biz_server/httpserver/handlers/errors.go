@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"pkg/scheduler"
+	"reflect"
+	"server/internal/biz_server/repository"
+	servicehttp "server/internal/biz_server/service_http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// коды ошибок API (поле error.code ответа)
+const (
+	codeBadRequest       = "bad_request"       // запрос не разобран (неверный JSON или тип параметра)
+	codeValidation       = "validation_failed" // значения полей не прошли проверку (см. details)
+	codeUnauthorized     = "unauthorized"      // нет входа или токен недействителен
+	codeForbidden        = "forbidden"         // роли не хватает прав
+	codeNotFound         = "not_found"
+	codeMethodNotAllowed = "method_not_allowed"
+	codeConflict         = "conflict"
+	codeUnavailable      = "unavailable" // бот или события чатов сейчас недоступны
+	codeInternal         = "internal"
+)
+
+// errorBody - ответ с ошибкой: {"error": {...}}
+type errorBody struct {
+	Error apiError `json:"error"`
+}
+
+// apiError - описание ошибки
+type apiError struct {
+	Code    string       `json:"code"`
+	Message string       `json:"message"`
+	Details []fieldError `json:"details,omitempty"`
+}
+
+// fieldError - ошибка в значении поля запроса
+type fieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// в ошибках валидации поля называются так же, как в запросе (json или query), а не как в структуре
+func init() {
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(func(field reflect.StructField) string {
+			for _, tag := range []string{"json", "form", "uri"} {
+				if name, _, _ := strings.Cut(field.Tag.Get(tag), ","); name != "" && name != "-" {
+					return name
+				}
+			}
+			return field.Name
+		})
+	}
+}
+
+// функция ответа с ошибкой
+func respondError(c *gin.Context, status int, code, message string, details ...fieldError) {
+	c.AbortWithStatusJSON(status, errorBody{Error: apiError{Code: code, Message: message, Details: details}})
+}
+
+// функция ответа на ошибку разбора запроса (ShouldBind*)
+func bindError(c *gin.Context, err error) {
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		respondError(c, http.StatusBadRequest, codeBadRequest, "malformed request: "+err.Error())
+		return
+	}
+
+	details := make([]fieldError, 0, len(verrs))
+	for _, fe := range verrs {
+		details = append(details, fieldError{Field: fe.Field(), Message: validationMessage(fe)})
+	}
+	respondError(c, http.StatusUnprocessableEntity, codeValidation, "request validation failed", details...)
+}
+
+// функция текста ошибки валидации поля
+func validationMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "min":
+		return "must be at least " + fe.Param()
+	case "max":
+		return "must be at most " + fe.Param()
+	case "oneof":
+		return "must be one of: " + strings.ReplaceAll(fe.Param(), " ", ", ")
+	case "gt":
+		return "must be greater than " + fe.Param()
+	}
+	return fmt.Sprintf("failed on %q check", fe.Tag())
+}
+
+// функция ответа на ошибку сервисного слоя
+func serviceError(c *gin.Context, err error) {
+	var verr *servicehttp.ValidationError
+	switch {
+	case errors.As(err, &verr):
+		respondError(c, http.StatusUnprocessableEntity, codeValidation, "request validation failed",
+			fieldError{Field: verr.Field, Message: verr.Message})
+	case errors.Is(err, servicehttp.ErrUnauthorized):
+		respondError(c, http.StatusUnauthorized, codeUnauthorized, "authentication required")
+	case errors.Is(err, servicehttp.ErrForbidden):
+		respondError(c, http.StatusForbidden, codeForbidden, "access denied")
+	case errors.Is(err, repository.ErrAdminNotFound):
+		respondError(c, http.StatusNotFound, codeNotFound, "admin not found")
+	case errors.Is(err, servicehttp.ErrLastOwner):
+		respondError(c, http.StatusConflict, codeConflict, "cannot remove or demote the last owner")
+	case errors.Is(err, repository.ErrUserNotFound):
+		respondError(c, http.StatusNotFound, codeNotFound, "user not found")
+	case errors.Is(err, repository.ErrLeadNotFound):
+		respondError(c, http.StatusNotFound, codeNotFound, "lead not found")
+	case errors.Is(err, scheduler.ErrJobNotFound):
+		respondError(c, http.StatusNotFound, codeNotFound, "job not found")
+	case errors.Is(err, repository.ErrHandoffNotFound):
+		respondError(c, http.StatusNotFound, codeNotFound, "handoff not found")
+	case errors.Is(err, servicehttp.ErrDeliveryFailed):
+		respondError(c, http.StatusBadGateway, codeUnavailable, "message was not delivered")
+	case errors.Is(err, servicehttp.ErrEventsUnavailable):
+		respondError(c, http.StatusServiceUnavailable, codeUnavailable, "live chat events are disabled")
+	case errors.Is(err, repository.ErrLeadConflict):
+		respondError(c, http.StatusConflict, codeConflict, "user already has an open lead")
+	default:
+		slog.ErrorContext(c.Request.Context(), "admin request failed", "path", c.FullPath(), "error", err)
+		respondError(c, http.StatusInternalServerError, codeInternal, "internal error")
+	}
+}
+
+// метод ответа на неизвестный маршрут
+func (h *BizHTTPHandler) NotFound(c *gin.Context) {
+	respondError(c, http.StatusNotFound, codeNotFound, "route not found")
+}
+
+// метод ответа на неподдерживаемый метод маршрута
+func (h *BizHTTPHandler) MethodNotAllowed(c *gin.Context) {
+	respondError(c, http.StatusMethodNotAllowed, codeMethodNotAllowed, "method not allowed")
+}
@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"net/http"
+	"pkg/adminauth"
+	"server/internal/domain"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// тело POST /users/:telegram_id/messages
+type sendMessageRequest struct {
+	Text string `json:"text" binding:"required,max=4096"`
+}
+
+// handoffDTO - открытый диалог с оператором
+type handoffDTO struct {
+	ID             int64     `json:"id"`
+	TelegramID     int64     `json:"telegram_id"`
+	Reason         string    `json:"reason"`    // client - клиент позвал мастера, master - мастер перехватил чат
+	OpenedBy       int64     `json:"opened_by"` // telegram_id администратора (0 - клиент или вход выключен)
+	OpenedAt       time.Time `json:"opened_at"`
+	LastActivityAt time.Time `json:"last_activity_at"`
+	User           *userDTO  `json:"user"` // null - не загружался или не найден
+}
+
+// функция преобразования диалога (nil - nil, чат ведёт бот)
+func toHandoffDTO(h *domain.Handoff) *handoffDTO {
+	if h == nil {
+		return nil
+	}
+	return &handoffDTO{
+		ID:             h.ID,
+		TelegramID:     h.TelegramID,
+		Reason:         string(h.Reason),
+		OpenedBy:       h.OpenedBy,
+		OpenedAt:       h.OpenedAt,
+		LastActivityAt: h.LastActivityAt,
+		User:           toUserDTO(h.User),
+	}
+}
+
+// функция telegram_id текущего администратора (0 - вход выключен)
+func currentAdminID(c *gin.Context) int64 {
+	if claims, ok := c.Get(ClaimsKey); ok {
+		return claims.(*adminauth.Claims).Subject
+	}
+	return 0
+}
+
+// метод выдачи открытых диалогов с оператором
+func (h *BizHTTPHandler) ListHandoffs(c *gin.Context) {
+	handoffs, err := h.Service.Handoffs.List(c.Request.Context())
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"handoffs": mapSlice(handoffs, toHandoffDTO)})
+}
+
+// метод перехвата чата оператором: бот перестаёт отвечать клиенту (повторный вызов возвращает открытый диалог)
+func (h *BizHTTPHandler) OpenHandoff(c *gin.Context) {
+	var uri telegramIDURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		bindError(c, err)
+		return
+	}
+
+	handoff, err := h.Service.Handoffs.Open(c.Request.Context(), uri.TelegramID, currentAdminID(c))
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"handoff": toHandoffDTO(handoff)})
+}
+
+// метод возврата чата боту
+func (h *BizHTTPHandler) CloseHandoff(c *gin.Context) {
+	var uri telegramIDURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		bindError(c, err)
+		return
+	}
+	if err := h.Service.Handoffs.Close(c.Request.Context(), uri.TelegramID); err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// метод отправки сообщения клиенту через бота (сообщение не доставлено - 502, но оно сохранено в переписке со статусом failed)
+func (h *BizHTTPHandler) SendUserMessage(c *gin.Context) {
+	var uri telegramIDURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		bindError(c, err)
+		return
+	}
+	var req sendMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		bindError(c, err)
+		return
+	}
+
+	message, err := h.Service.Messaging.Send(c.Request.Context(), uri.TelegramID, req.Text)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": toMessageDTO(message)})
+}
+
+// метод потока событий чата для консоли оператора (text/event-stream)
+func (h *BizHTTPHandler) StreamUserEvents(c *gin.Context) {
+	var uri telegramIDURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		bindError(c, err)
+		return
+	}
+	h.streamEvents(c, uri.TelegramID)
+}
+
+// метод потока событий всех чатов (для списка диалогов консоли)
+func (h *BizHTTPHandler) StreamEvents(c *gin.Context) {
+	h.streamEvents(c, 0)
+}
+
+// метод отдачи событий в формате SSE до отключения клиента.
+// События - "message", "handoff_opened", "handoff_closed" с JSON domain.ChatEvent в data
+func (h *BizHTTPHandler) streamEvents(c *gin.Context, telegramID int64) {
+	events, unsubscribe, err := h.Service.Handoffs.Subscribe(telegramID)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	defer unsubscribe()
+
+	// поток живёт дольше WriteTimeout сервера
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		serviceError(c, err)
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no") // nginx не буферизует поток
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	// пустые комментарии не дают прокси закрыть молчащее соединение
+	keepalive := time.NewTicker(h.Service.Handoffs.KeepaliveInterval())
+	defer keepalive.Stop()
+
+	ctx := c.Request.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-events:
+			c.SSEvent(string(event.Type), event)
+		case <-keepalive.C:
+			if _, err := c.Writer.WriteString(": keepalive\n\n"); err != nil {
+				return
+			}
+		}
+		c.Writer.Flush()
+	}
+}
@@ -0,0 +1,258 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	pb "global_models/grpc/bot"
+	"net/http"
+	"net/http/httptest"
+	"pkg/configs"
+	memorycache "pkg/memory_cache"
+	"server/internal/biz_server/chatevents"
+	"server/internal/biz_server/repository"
+	servicehttp "server/internal/biz_server/service_http"
+	"server/internal/domain"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// recordingSender - бот, который запоминает отправленные сообщения
+type recordingSender struct {
+	mu   sync.Mutex
+	sent []string
+}
+
+func (s *recordingSender) SendMessage(ctx context.Context, req *pb.SendMessageRequest) (*pb.SendMessageResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sent = append(s.sent, req.GetText())
+	return &pb.SendMessageResponse{Success: true}, nil
+}
+
+// метод отправленных сообщений
+func (s *recordingSender) texts() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.sent...)
+}
+
+// handoffEnv - роутер консоли оператора поверх хранилища в памяти
+type handoffEnv struct {
+	router  *gin.Engine
+	service *servicehttp.BizServiceFacade
+	repo    *repository.MemoryRepository
+	sender  *recordingSender
+	events  *chatevents.Bus
+}
+
+// функция создания роутера консоли оператора (withEvents = false - события чатов выключены)
+func newHandoffEnv(t *testing.T, conf *configs.HandoffConfig, withEvents bool) *handoffEnv {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	repo := repository.NewMemoryRepository()
+	user := &domain.User{TelegramID: 42, FirstName: "Анна", IsActive: true, CreatedAt: time.Now(), LastSeenAt: time.Now()}
+	if err := repo.CreateUser(context.Background(), user); err != nil {
+		t.Fatalf("пользователь: %v", err)
+	}
+
+	env := &handoffEnv{repo: repo, sender: &recordingSender{}}
+	if withEvents {
+		ps := memorycache.NewMemoryCache(time.Minute)
+		env.events = chatevents.New(ps, conf.Channel)
+		t.Cleanup(func() {
+			env.events.Close()
+			ps.Close()
+		})
+	}
+	env.service = servicehttp.NewBizServiceFacade(servicehttp.FacadeDeps{
+		Users: repo, Admin: repo, Auth: disabledAuth(t, repo), Sender: env.sender, Events: env.events, HandoffConf: conf,
+	})
+	h := NewBizHandler(env.service)
+
+	router := gin.New()
+	router.GET("/handoffs", h.ListHandoffs)
+	router.POST("/users/:telegram_id/handoff", h.OpenHandoff)
+	router.DELETE("/users/:telegram_id/handoff", h.CloseHandoff)
+	router.POST("/users/:telegram_id/messages", h.SendUserMessage)
+	router.GET("/users/:telegram_id/events", h.StreamUserEvents)
+	router.GET("/events", h.StreamEvents)
+	env.router = router
+	return env
+}
+
+// функция выполнения запроса без разбора ответа
+func doStatus(router *gin.Engine, method, path string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+	return rec
+}
+
+// функция чтения событий SSE: в канал попадают имена событий ("event:<тип>")
+func readEvents(t *testing.T, url string) <-chan string {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		cancel()
+		t.Fatalf("GET %s: %v", url, err)
+	}
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		cancel()
+		t.Fatalf("GET %s: %d %s", url, resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	t.Cleanup(func() {
+		cancel()
+		resp.Body.Close()
+	})
+
+	names := make(chan string, 16)
+	go func() {
+		defer close(names)
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			if name, ok := strings.CutPrefix(scanner.Text(), "event:"); ok {
+				names <- name
+			}
+		}
+	}()
+	return names
+}
+
+// функция ожидания события с именем name (остальные пропускаются)
+func waitEvent(names <-chan string, name string, wait time.Duration) bool {
+	timeout := time.After(wait)
+	for {
+		select {
+		case got, ok := <-names:
+			if !ok {
+				return false
+			}
+			if got == name {
+				return true
+			}
+		case <-timeout:
+			return false
+		}
+	}
+}
+
+func TestHandoffHandlers(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("перехват и возврат чата", func(t *testing.T) {
+		env := newHandoffEnv(t, configs.UseDefaultHandoffConfig(), false)
+
+		code, body := doJSON(t, env.router, http.MethodPost, "/users/42/handoff", "")
+		var handoff handoffDTO
+		json.Unmarshal(body["handoff"], &handoff)
+		if code != http.StatusOK || handoff.TelegramID != 42 || handoff.Reason != "master" {
+			t.Fatalf("POST /handoff: %d %s", code, body["handoff"])
+		}
+
+		_, body = do(t, env.router, http.MethodGet, "/handoffs")
+		var handoffs []handoffDTO
+		json.Unmarshal(body["handoffs"], &handoffs)
+		if len(handoffs) != 1 || handoffs[0].User == nil || handoffs[0].User.FirstName != "Анна" {
+			t.Errorf("GET /handoffs: %s", body["handoffs"])
+		}
+
+		if rec := doStatus(env.router, http.MethodDelete, "/users/42/handoff"); rec.Code != http.StatusNoContent {
+			t.Fatalf("DELETE /handoff: %d %s", rec.Code, rec.Body)
+		}
+		code, body = do(t, env.router, http.MethodDelete, "/users/42/handoff")
+		if e := errorOf(t, body); code != http.StatusNotFound || e.Code != codeNotFound {
+			t.Errorf("повторный возврат: %d %+v", code, e)
+		}
+		code, body = do(t, env.router, http.MethodPost, "/users/7/handoff")
+		if e := errorOf(t, body); code != http.StatusNotFound || e.Code != codeNotFound {
+			t.Errorf("неизвестный пользователь: %d %+v", code, e)
+		}
+		if got := env.sender.texts(); len(got) != 0 {
+			t.Errorf("при перехвате мастером клиенту ничего не отправляется: %q", got)
+		}
+	})
+
+	t.Run("ответ оператора уходит через бота", func(t *testing.T) {
+		env := newHandoffEnv(t, configs.UseDefaultHandoffConfig(), false)
+
+		code, body := doJSON(t, env.router, http.MethodPost, "/users/42/messages", `{"text":"Здравствуйте, это мастер"}`)
+		var message messageDTO
+		json.Unmarshal(body["message"], &message)
+		if code != http.StatusCreated || message.Direction != "outgoing" || message.Status != "sent" {
+			t.Fatalf("POST /messages: %d %s", code, body["message"])
+		}
+		if got := env.sender.texts(); len(got) != 1 || got[0] != "Здравствуйте, это мастер" {
+			t.Errorf("бот отправил %q", got)
+		}
+
+		code, body = doJSON(t, env.router, http.MethodPost, "/users/42/messages", `{"text":""}`)
+		if e := errorOf(t, body); code != http.StatusUnprocessableEntity || e.Code != codeValidation {
+			t.Errorf("пустое сообщение: %d %+v", code, e)
+		}
+	})
+
+	t.Run("события чата приходят в поток консоли", func(t *testing.T) {
+		env := newHandoffEnv(t, configs.UseDefaultHandoffConfig(), true)
+		server := httptest.NewServer(env.router)
+		t.Cleanup(server.Close) // после отключения потоков (cleanup выполняются в обратном порядке)
+
+		chat := readEvents(t, server.URL+"/users/42/events")
+		// шина подписывается на канал в фоне: публикуем, пока событие не дойдёт
+		deadline := time.Now().Add(2 * time.Second)
+		for !waitEvent(chat, "message", 20*time.Millisecond) {
+			if time.Now().After(deadline) {
+				t.Fatal("сообщение не пришло в поток")
+			}
+			env.events.Publish(ctx, domain.ChatEvent{Type: domain.ChatEventMessage, TelegramID: 42, Direction: "incoming", Text: "Привет"})
+		}
+
+		all := readEvents(t, server.URL+"/events")
+		if code, _ := doJSON(t, env.router, http.MethodPost, "/users/42/handoff", ""); code != http.StatusOK {
+			t.Fatalf("POST /handoff: %d", code)
+		}
+		if !waitEvent(chat, "handoff_opened", time.Second) || !waitEvent(all, "handoff_opened", time.Second) {
+			t.Error("открытие диалога не пришло в поток")
+		}
+		if rec := doStatus(env.router, http.MethodDelete, "/users/42/handoff"); rec.Code != http.StatusNoContent {
+			t.Fatalf("DELETE /handoff: %d", rec.Code)
+		}
+		if !waitEvent(chat, "handoff_closed", time.Second) {
+			t.Error("закрытие диалога не пришло в поток")
+		}
+	})
+
+	t.Run("без событий поток недоступен", func(t *testing.T) {
+		env := newHandoffEnv(t, configs.UseDefaultHandoffConfig(), false)
+		code, body := do(t, env.router, http.MethodGet, "/events")
+		if e := errorOf(t, body); code != http.StatusServiceUnavailable || e.Code != codeUnavailable {
+			t.Errorf("GET /events: %d %+v", code, e)
+		}
+	})
+
+	t.Run("неактивный диалог закрывается, позвавший мастера клиент узнаёт об этом", func(t *testing.T) {
+		conf := configs.UseDefaultHandoffConfig()
+		conf.InactivityTimeout = -time.Minute // любой открытый диалог уже неактивен
+		env := newHandoffEnv(t, conf, false)
+
+		if _, err := env.repo.OpenHandoff(ctx, &domain.Handoff{TelegramID: 42, Reason: domain.HandoffByClient}); err != nil {
+			t.Fatalf("OpenHandoff: %v", err)
+		}
+		closed, err := env.service.Handoffs.ExpireIdle(ctx)
+		if err != nil || closed != 1 {
+			t.Fatalf("ExpireIdle: %d %v", closed, err)
+		}
+		if handoff, err := env.service.Handoffs.Get(ctx, 42); err != nil || handoff != nil {
+			t.Errorf("диалог не закрыт: %+v %v", handoff, err)
+		}
+		if got := env.sender.texts(); len(got) != 1 || got[0] != domain.DefaultContent(domain.ContentHandoffEnd) {
+			t.Errorf("клиенту отправлено %q", got)
+		}
+	})
+}
@@ -1,12 +1,8 @@
 package handlers
 
 import (
-	"fmt"
 	"global_models/interf"
-	"net/http"
 	servicehttp "server/internal/biz_server/service_http"
-
-	"github.com/gin-gonic/gin"
 )
 
 // структура хэндлера http сервера основной логики
@@ -20,8 +16,3 @@ func NewBizHandler(service *servicehttp.BizServiceFacade) interf.BizHTTPHandlerI
 		Service: service,
 	}
 }
-
-// тестовый метод слоя хэндлеров
-func (h *BizHTTPHandler) EchoServer(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("Handler layer: %s", h.Service.GetEcho())})
-}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"net/http"
+	servicehttp "server/internal/biz_server/service_http"
+	"server/internal/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// параметры списка заявок
+type listLeadsQuery struct {
+	pageQuery
+	Status     string `form:"status" binding:"omitempty,oneof=new in_progress won lost"`
+	TelegramID int64  `form:"telegram_id" binding:"min=0"`
+}
+
+// заявка в пути запроса
+type leadIDURI struct {
+	ID int64 `uri:"id" binding:"gt=0"`
+}
+
+// тело PATCH /leads/:id (отсутствующее поле не меняется)
+type updateLeadRequest struct {
+	Status *string `json:"status" binding:"omitempty,oneof=new in_progress won lost"`
+	Note   *string `json:"note" binding:"omitempty,max=2000"`
+}
+
+// метод выдачи списка заявок
+func (h *BizHTTPHandler) ListLeads(c *gin.Context) {
+	var query listLeadsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		bindError(c, err)
+		return
+	}
+
+	filter := domain.LeadFilter{Status: domain.LeadStatus(query.Status), TelegramID: query.TelegramID, Page: query.page()}
+	leads, total, err := h.Service.Leads.ListLeads(c.Request.Context(), filter)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"leads": mapSlice(leads, toLeadDTO), "page": toPageDTO(filter.Page, total)})
+}
+
+// метод выдачи заявки
+func (h *BizHTTPHandler) GetLead(c *gin.Context) {
+	var uri leadIDURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		bindError(c, err)
+		return
+	}
+
+	lead, err := h.Service.Leads.GetLead(c.Request.Context(), uri.ID)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"lead": toLeadDTO(lead)})
+}
+
+// метод изменения статуса и заметки заявки
+func (h *BizHTTPHandler) UpdateLead(c *gin.Context) {
+	var uri leadIDURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		bindError(c, err)
+		return
+	}
+	var req updateLeadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		bindError(c, err)
+		return
+	}
+
+	patch := servicehttp.LeadPatch{Note: req.Note}
+	if req.Status != nil {
+		status := domain.LeadStatus(*req.Status)
+		patch.Status = &status
+	}
+	lead, err := h.Service.Leads.UpdateLead(c.Request.Context(), uri.ID, patch)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"lead": toLeadDTO(lead)})
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"net/http"
+	"pkg/scheduler"
+
+	"github.com/gin-gonic/gin"
+)
+
+// метод выдачи списка задач планировщика
+func (h *BizHTTPHandler) ListSchedulerJobs(c *gin.Context) {
+	jobs, err := h.Service.Scheduler.ListJobs(c.Request.Context())
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	if jobs == nil {
+		jobs = []*scheduler.Job{}
+	}
+	c.JSON(http.StatusOK, gin.H{"jobs": jobs})
+}
+
+// параметры выдачи задачи
+type schedulerJobQuery struct {
+	Limit int `form:"limit" binding:"min=0"` // сколько последних запусков (0 - по умолчанию)
+}
+
+// метод выдачи задачи с последними запусками (?limit= - сколько запусков)
+func (h *BizHTTPHandler) GetSchedulerJob(c *gin.Context) {
+	var query schedulerJobQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		bindError(c, err)
+		return
+	}
+
+	job, runs, err := h.Service.Scheduler.GetJob(c.Request.Context(), c.Param("name"), query.Limit)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	if runs == nil {
+		runs = []*scheduler.Run{}
+	}
+	c.JSON(http.StatusOK, gin.H{"job": job, "runs": runs})
+}
+
+// метод постановки задачи на паузу
+func (h *BizHTTPHandler) PauseSchedulerJob(c *gin.Context) {
+	job, err := h.Service.Scheduler.PauseJob(c.Request.Context(), c.Param("name"))
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"job": job})
+}
+
+// метод снятия задачи с паузы
+func (h *BizHTTPHandler) ResumeSchedulerJob(c *gin.Context) {
+	job, err := h.Service.Scheduler.ResumeJob(c.Request.Context(), c.Param("name"))
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"job": job})
+}
+
+// метод запуска задачи вне расписания (202: запуск выполнит лидер при следующей проверке)
+func (h *BizHTTPHandler) TriggerSchedulerJob(c *gin.Context) {
+	job, err := h.Service.Scheduler.TriggerJob(c.Request.Context(), c.Param("name"))
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"job": job})
+}
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"pkg/configs"
+	"pkg/scheduler"
+	"server/internal/biz_server/repository"
+	servicehttp "server/internal/biz_server/service_http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// лидер для тестов: задачи в тестах хэндлеров не запускаются
+type noLeader struct{}
+
+func (noLeader) Do(ctx context.Context, fn func(ctx context.Context, fence int64) error) error {
+	return nil
+}
+
+// функция создания роутера с маршрутами планировщика и одной задачей digest
+func newSchedulerRouter(t *testing.T) (*gin.Engine, *scheduler.Scheduler) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	sched, err := scheduler.New(scheduler.NewMemoryStore(), noLeader{}, configs.UseDefaultSchedulerConfig())
+	if err != nil {
+		t.Fatalf("scheduler.New: %v", err)
+	}
+	sched.Register("digest", func(context.Context, *scheduler.Job) error { return nil })
+	if err := sched.AddCron(context.Background(), "digest", "digest", "0 9 * * *", "Europe/Moscow", nil); err != nil {
+		t.Fatalf("AddCron: %v", err)
+	}
+
+	repo := repository.NewMemoryRepository()
+	h := NewBizHandler(servicehttp.NewBizServiceFacade(servicehttp.FacadeDeps{Users: repo, Admin: repo, Auth: disabledAuth(t, repo), Scheduler: sched}))
+	router := gin.New()
+	router.GET("/jobs", h.ListSchedulerJobs)
+	router.GET("/jobs/:name", h.GetSchedulerJob)
+	router.POST("/jobs/:name/pause", h.PauseSchedulerJob)
+	router.POST("/jobs/:name/resume", h.ResumeSchedulerJob)
+	router.POST("/jobs/:name/trigger", h.TriggerSchedulerJob)
+	return router, sched
+}
+
+// функция выполнения запроса с разбором JSON ответа
+func do(t *testing.T, router *gin.Engine, method, path string) (int, map[string]json.RawMessage) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+	var body map[string]json.RawMessage
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("%s %s: ответ не JSON: %s", method, path, rec.Body.String())
+	}
+	return rec.Code, body
+}
+
+func TestSchedulerHandlers(t *testing.T) {
+	t.Run("список задач", func(t *testing.T) {
+		router, _ := newSchedulerRouter(t)
+		code, body := do(t, router, http.MethodGet, "/jobs")
+		var jobs []scheduler.Job
+		json.Unmarshal(body["jobs"], &jobs)
+		if code != http.StatusOK || len(jobs) != 1 || jobs[0].Name != "digest" || jobs[0].Timezone != "Europe/Moscow" {
+			t.Errorf("GET /jobs: %d %s", code, body["jobs"])
+		}
+	})
+
+	t.Run("пауза, снятие паузы и ручной запуск", func(t *testing.T) {
+		router, sched := newSchedulerRouter(t)
+
+		if code, body := do(t, router, http.MethodPost, "/jobs/digest/pause"); code != http.StatusOK {
+			t.Fatalf("pause: %d %s", code, body["error"])
+		}
+		if job, _ := sched.Get(context.Background(), "digest"); !job.Paused {
+			t.Error("задача не на паузе")
+		}
+		if code, _ := do(t, router, http.MethodPost, "/jobs/digest/resume"); code != http.StatusOK {
+			t.Fatalf("resume: %d", code)
+		}
+		if job, _ := sched.Get(context.Background(), "digest"); job.Paused {
+			t.Error("пауза не снята")
+		}
+
+		code, body := do(t, router, http.MethodPost, "/jobs/digest/trigger")
+		var job scheduler.Job
+		json.Unmarshal(body["job"], &job)
+		if code != http.StatusAccepted || !job.Triggered {
+			t.Errorf("trigger: %d %s", code, body["job"])
+		}
+	})
+
+	t.Run("задача с историей запусков", func(t *testing.T) {
+		router, _ := newSchedulerRouter(t)
+		code, body := do(t, router, http.MethodGet, "/jobs/digest?limit=5")
+		if code != http.StatusOK || string(body["runs"]) != "[]" {
+			t.Errorf("GET /jobs/digest: %d, runs %s", code, body["runs"])
+		}
+		if code, _ := do(t, router, http.MethodGet, "/jobs/digest?limit=abc"); code != http.StatusBadRequest {
+			t.Errorf("неверный limit: %d", code)
+		}
+	})
+
+	t.Run("неизвестная задача - 404", func(t *testing.T) {
+		router, _ := newSchedulerRouter(t)
+		for _, req := range []struct{ method, path string }{
+			{http.MethodGet, "/jobs/missing"},
+			{http.MethodPost, "/jobs/missing/pause"},
+			{http.MethodPost, "/jobs/missing/resume"},
+			{http.MethodPost, "/jobs/missing/trigger"},
+		} {
+			if code, _ := do(t, router, req.method, req.path); code != http.StatusNotFound {
+				t.Errorf("%s %s: %d", req.method, req.path, code)
+			}
+		}
+	})
+}
@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"net/http"
+	"server/internal/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// метод выдачи настроек бизнеса
+func (h *BizHTTPHandler) GetSettings(c *gin.Context) {
+	settings, err := h.Service.Settings.GetSettings(c.Request.Context())
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"settings": toSettingsDTO(settings)})
+}
+
+// метод сохранения настроек бизнеса (заменяются целиком)
+func (h *BizHTTPHandler) UpdateSettings(c *gin.Context) {
+	var req settingsDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		bindError(c, err)
+		return
+	}
+
+	settings := &domain.BusinessSettings{
+		MasterName:   req.MasterName,
+		InstagramURL: req.InstagramURL,
+		WelcomeText:  req.WelcomeText,
+		NotifyChatID: req.NotifyChatID,
+		Timezone:     req.Timezone,
+	}
+	if err := h.Service.Settings.SaveSettings(c.Request.Context(), settings); err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"settings": toSettingsDTO(settings)})
+}
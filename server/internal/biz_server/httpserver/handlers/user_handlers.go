@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"net/http"
+	servicehttp "server/internal/biz_server/service_http"
+	"server/internal/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// параметры постраничной выдачи (0 - по умолчанию)
+type pageQuery struct {
+	Page     int `form:"page" binding:"min=0,max=100000"`
+	PageSize int `form:"page_size" binding:"min=0,max=100"`
+}
+
+// метод возвращает страницу с значениями по умолчанию
+func (q pageQuery) page() domain.Page {
+	return servicehttp.NewPage(q.Page, q.PageSize)
+}
+
+// параметры списка пользователей
+type listUsersQuery struct {
+	pageQuery
+	Query  string `form:"query" binding:"max=100"` // подстрока username/имени/фамилии или telegram_id
+	Active *bool  `form:"active"`
+}
+
+// параметры переписки с пользователем
+type listMessagesQuery struct {
+	BeforeID int64 `form:"before_id" binding:"min=0"` // только сообщения старше (id из предыдущей страницы)
+	Limit    int   `form:"limit" binding:"min=0,max=200"`
+}
+
+// параметры журнала нажатий
+type listCallbacksQuery struct {
+	pageQuery
+	TelegramID int64  `form:"telegram_id" binding:"min=0"`
+	Data       string `form:"data" binding:"max=64"`
+}
+
+// пользователь в пути запроса
+type telegramIDURI struct {
+	TelegramID int64 `uri:"telegram_id" binding:"gt=0"`
+}
+
+// метод выдачи списка пользователей с поиском
+func (h *BizHTTPHandler) ListUsers(c *gin.Context) {
+	var query listUsersQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		bindError(c, err)
+		return
+	}
+
+	filter := domain.UserFilter{Query: query.Query, Active: query.Active, Page: query.page()}
+	users, total, err := h.Service.Admin.ListUsers(c.Request.Context(), filter)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"users": mapSlice(users, toUserDTO), "page": toPageDTO(filter.Page, total)})
+}
+
+// метод выдачи профиля пользователя с последними сообщениями и заявками
+func (h *BizHTTPHandler) GetUser(c *gin.Context) {
+	var uri telegramIDURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		bindError(c, err)
+		return
+	}
+
+	profile, err := h.Service.Admin.GetProfile(c.Request.Context(), uri.TelegramID)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"user":     toUserDTO(profile.User),
+		"messages": mapSlice(profile.Messages, toMessageDTO),
+		"leads":    mapSlice(profile.Leads, toLeadDTO),
+	})
+}
+
+// метод выдачи переписки с пользователем (сначала новые, следующая страница - ?before_id=next_before_id)
+func (h *BizHTTPHandler) ListUserMessages(c *gin.Context) {
+	var uri telegramIDURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		bindError(c, err)
+		return
+	}
+	var query listMessagesQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		bindError(c, err)
+		return
+	}
+
+	messages, err := h.Service.Admin.ListMessages(c.Request.Context(), uri.TelegramID, query.BeforeID, query.Limit)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	var next int64 // 0 - страница пуста, листать дальше нечего
+	if len(messages) > 0 {
+		next = messages[len(messages)-1].ID
+	}
+	c.JSON(http.StatusOK, gin.H{"messages": mapSlice(messages, toMessageDTO), "next_before_id": next})
+}
+
+// метод выдачи журнала нажатий кнопок
+func (h *BizHTTPHandler) ListCallbacks(c *gin.Context) {
+	var query listCallbacksQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		bindError(c, err)
+		return
+	}
+
+	filter := domain.CallbackFilter{TelegramID: query.TelegramID, Data: query.Data, Page: query.page()}
+	callbacks, total, err := h.Service.Admin.ListCallbacks(c.Request.Context(), filter)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"callbacks": mapSlice(callbacks, toCallbackDTO), "page": toPageDTO(filter.Page, total)})
+}
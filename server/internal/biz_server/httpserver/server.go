@@ -3,10 +3,13 @@ package httpserver
 import (
 	"context"
 	"global_models/interf"
-	"log"
+	"log/slog"
 	"net/http"
 	"pkg/configs"
+	"pkg/health"
+	"pkg/metrics"
 	"pkg/middleware"
+	"server/internal/biz_server/httpserver/adminui"
 
 	"github.com/gin-gonic/gin"
 )
@@ -17,10 +20,12 @@ type BizServer struct {
 	router     *gin.Engine                    // роутер gin
 	config     *configs.HttpServerConfig      // базовый конфиг
 	Handler    interf.BizHTTPHandlerInterface // интерфейс слоя хэндлеров
+	health     *health.Checker                // проверки здоровья (/healthz, /readyz)
+	ui         *adminui.UI                    // страницы админки (nil - только API)
 }
 
 // Конструктор для сервера
-func NewBizServer(ctx context.Context, config *configs.HttpServerConfig, handler interf.BizHTTPHandlerInterface) (*BizServer, error) {
+func NewBizServer(ctx context.Context, config *configs.HttpServerConfig, handler interf.BizHTTPHandlerInterface, checker *health.Checker, ui *adminui.UI) (*BizServer, error) {
 	// создаём экземпляр роутера
 	router := gin.Default()
 	err := router.SetTrustedProxies(nil)
@@ -28,12 +33,8 @@ func NewBizServer(ctx context.Context, config *configs.HttpServerConfig, handler
 		return nil, err
 	}
 
-	// Добавляем middleware для проброса контекста
-	router.Use(func(c *gin.Context) {
-		ctx := context.WithValue(c.Request.Context(), "request_id", c.GetHeader("X-Request-ID"))
-		c.Request = c.Request.WithContext(ctx)
-		c.Next()
-	})
+	// Добавляем middleware для correlation ID (X-Request-ID) в контексте запроса
+	router.Use(middleware.RequestIDMiddleware())
 
 	router.Use(middleware.CORSMiddleware()) // используем для всех маршруторв работу с CORS
 
@@ -41,12 +42,86 @@ func NewBizServer(ctx context.Context, config *configs.HttpServerConfig, handler
 		router:  router,
 		config:  config,
 		Handler: handler,
+		health:  checker,
+		ui:      ui,
 	}, nil
 }
 
 // Метод для маршрутизации сервера
 func (a *BizServer) SetUpRoutes() {
-	a.router.GET("/echo", a.Handler.EchoServer) // тестовый ендпоинт
+	// API админки (ошибки - в едином JSON формате {"error": {"code", "message", "details"}})
+	api := a.router.Group("/api/v1")
+
+	// вход в админку через Telegram Login Widget (без токена доступа)
+	authAPI := api.Group("/auth")
+	authAPI.POST("/telegram", a.Handler.LoginTelegram)
+	authAPI.POST("/refresh", a.Handler.RefreshSession)
+	authAPI.POST("/logout", a.Handler.Logout)
+
+	// права по ролям: viewer - чтение, manager - ещё и изменения, owner - ещё и администраторы и планировщик
+	viewer := api.Group("", a.Handler.RequireRole("viewer"))
+	manager := api.Group("", a.Handler.RequireRole("manager"))
+	owner := api.Group("", a.Handler.RequireRole("owner"))
+
+	viewer.GET("/auth/me", a.Handler.CurrentAdmin)
+
+	// пользователи, переписка и журнал нажатий
+	viewer.GET("/users", a.Handler.ListUsers)
+	viewer.GET("/users/:telegram_id", a.Handler.GetUser)
+	viewer.GET("/users/:telegram_id/messages", a.Handler.ListUserMessages)
+	viewer.GET("/callbacks", a.Handler.ListCallbacks)
+
+	// консоль оператора: диалоги с мастером, ответы клиенту и события чатов (SSE)
+	viewer.GET("/handoffs", a.Handler.ListHandoffs)
+	manager.POST("/users/:telegram_id/handoff", a.Handler.OpenHandoff)
+	manager.DELETE("/users/:telegram_id/handoff", a.Handler.CloseHandoff)
+	manager.POST("/users/:telegram_id/messages", a.Handler.SendUserMessage)
+	viewer.GET("/users/:telegram_id/events", a.Handler.StreamUserEvents)
+	viewer.GET("/events", a.Handler.StreamEvents)
+
+	// заявки клиентов
+	viewer.GET("/leads", a.Handler.ListLeads)
+	viewer.GET("/leads/:id", a.Handler.GetLead)
+	manager.PATCH("/leads/:id", a.Handler.UpdateLead)
+
+	// аналитика: воронки, источники и удержание (агрегаты пересчитывает планировщик)
+	viewer.GET("/analytics/funnel", a.Handler.GetFunnelStats)
+	viewer.GET("/analytics/sources", a.Handler.GetSourceStats)
+	viewer.GET("/analytics/retention", a.Handler.GetRetention)
+
+	// настройки бизнеса
+	viewer.GET("/settings", a.Handler.GetSettings)
+	manager.PUT("/settings", a.Handler.UpdateSettings)
+
+	// администраторы админки
+	owner.GET("/admins", a.Handler.ListAdmins)
+	owner.PUT("/admins/:telegram_id", a.Handler.SetAdminRole)
+	owner.DELETE("/admins/:telegram_id", a.Handler.RemoveAdmin)
+
+	// управление задачами планировщика
+	viewer.GET("/scheduler/jobs", a.Handler.ListSchedulerJobs)
+	viewer.GET("/scheduler/jobs/:name", a.Handler.GetSchedulerJob)
+	owner.POST("/scheduler/jobs/:name/pause", a.Handler.PauseSchedulerJob)
+	owner.POST("/scheduler/jobs/:name/resume", a.Handler.ResumeSchedulerJob)
+	owner.POST("/scheduler/jobs/:name/trigger", a.Handler.TriggerSchedulerJob)
+
+	// страницы админки (/admin)
+	if a.ui != nil {
+		a.ui.RegisterRoutes(a.router)
+	}
+
+	// неизвестные маршруты и методы - тоже в формате ошибок API
+	a.router.HandleMethodNotAllowed = true
+	a.router.NoRoute(a.Handler.NotFound)
+	a.router.NoMethod(a.Handler.MethodNotAllowed)
+
+	// пробы для оркестратора
+	if a.health != nil {
+		a.health.RegisterRoutes(a.router)
+	}
+
+	// метрики prometheus
+	metrics.RegisterRoutes(a.router)
 }
 
 // Метод для запуска сервера
@@ -58,7 +133,7 @@ func (a *BizServer) Run() error {
 	}
 	// Используем обычный порт для HTTP
 	a.httpServer.Addr = a.config.Addr()
-	log.Printf("Starting HTTP server on %s", a.config.Addr())
+	slog.Info("starting HTTP server", "addr", a.config.Addr())
 	return a.httpServer.ListenAndServe()
 }
 
@@ -70,6 +145,6 @@ func (a *BizServer) Shutdown(ctx context.Context) error {
 		return err
 	}
 
-	log.Println("Server shutdown completed")
+	slog.Info("HTTP server shutdown completed")
 	return nil
 }
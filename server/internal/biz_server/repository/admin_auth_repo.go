@@ -0,0 +1,188 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"global_models/global_db"
+	"server/internal/domain"
+	"time"
+)
+
+// администраторы админки и их сессии в Postgres
+
+// колонки администратора (порядок совпадает со scanAdmin)
+const adminColumns = `telegram_id, role, username, first_name, last_name, created_at, updated_at, last_login_at`
+
+// колонки сессии (порядок совпадает со scanSession)
+const sessionColumns = `id, telegram_id, token_hash, user_agent, created_at, expires_at, revoked_at`
+
+// функция чтения администратора из строки результата
+func scanAdmin(row global_db.Row) (*domain.Admin, error) {
+	var admin domain.Admin
+	var role string
+	err := row.Scan(&admin.TelegramID, &role, &admin.Username, &admin.FirstName, &admin.LastName,
+		&admin.CreatedAt, &admin.UpdatedAt, &admin.LastLoginAt)
+	if err != nil {
+		return nil, err
+	}
+	admin.Role = domain.AdminRole(role)
+	return &admin, nil
+}
+
+// функция чтения сессии из строки результата
+func scanSession(row global_db.Row) (*domain.AdminSession, error) {
+	var session domain.AdminSession
+	err := row.Scan(&session.ID, &session.TelegramID, &session.TokenHash, &session.UserAgent,
+		&session.CreatedAt, &session.ExpiresAt, &session.RevokedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// GetAdmin возвращает администратора
+func (r *bizDBRepository) GetAdmin(ctx context.Context, telegramID int64) (*domain.Admin, error) {
+	admin, err := scanAdmin(r.Pool.QueryRow(ctx, `SELECT `+adminColumns+` FROM admin_users WHERE telegram_id = $1`, telegramID))
+	if isNoRows(err) {
+		return nil, ErrAdminNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get admin: %w", err)
+	}
+	return admin, nil
+}
+
+// ListAdmins возвращает всех администраторов
+func (r *bizDBRepository) ListAdmins(ctx context.Context) ([]*domain.Admin, error) {
+	rows, err := r.Pool.Query(ctx, `SELECT `+adminColumns+` FROM admin_users ORDER BY telegram_id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list admins: %w", err)
+	}
+	defer rows.Close()
+
+	var admins []*domain.Admin
+	for rows.Next() {
+		admin, err := scanAdmin(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan admin: %w", err)
+		}
+		admins = append(admins, admin)
+	}
+	return admins, rows.Err()
+}
+
+// SaveAdmin создаёт администратора или меняет его роль
+func (r *bizDBRepository) SaveAdmin(ctx context.Context, admin *domain.Admin) error {
+	stored, err := scanAdmin(r.Pool.QueryRow(ctx, `
+        INSERT INTO admin_users (telegram_id, role)
+        VALUES ($1, $2)
+        ON CONFLICT (telegram_id) DO UPDATE SET role = EXCLUDED.role, updated_at = NOW()
+        RETURNING `+adminColumns,
+		admin.TelegramID, string(admin.Role),
+	))
+	if err != nil {
+		return fmt.Errorf("failed to save admin: %w", err)
+	}
+	*admin = *stored
+	return nil
+}
+
+// AddAdminIfMissing создаёт администратора, если его ещё нет
+func (r *bizDBRepository) AddAdminIfMissing(ctx context.Context, admin *domain.Admin) (bool, error) {
+	affected, err := r.Pool.Exec(ctx, `
+        INSERT INTO admin_users (telegram_id, role) VALUES ($1, $2)
+        ON CONFLICT (telegram_id) DO NOTHING`,
+		admin.TelegramID, string(admin.Role),
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to add admin: %w", err)
+	}
+	return affected > 0, nil
+}
+
+// DeleteAdmin удаляет администратора (сессии удаляются каскадно)
+func (r *bizDBRepository) DeleteAdmin(ctx context.Context, telegramID int64) error {
+	affected, err := r.Pool.Exec(ctx, `DELETE FROM admin_users WHERE telegram_id = $1`, telegramID)
+	if err != nil {
+		return fmt.Errorf("failed to delete admin: %w", err)
+	}
+	if affected == 0 {
+		return ErrAdminNotFound
+	}
+	return nil
+}
+
+// RecordLogin обновляет профиль и время входа
+func (r *bizDBRepository) RecordLogin(ctx context.Context, admin *domain.Admin, at time.Time) error {
+	stored, err := scanAdmin(r.Pool.QueryRow(ctx, `
+        UPDATE admin_users SET username = $2, first_name = $3, last_name = $4, last_login_at = $5
+        WHERE telegram_id = $1
+        RETURNING `+adminColumns,
+		admin.TelegramID, admin.Username, admin.FirstName, admin.LastName, at,
+	))
+	if isNoRows(err) {
+		return ErrAdminNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to record admin login: %w", err)
+	}
+	*admin = *stored
+	return nil
+}
+
+// CreateSession сохраняет сессию
+func (r *bizDBRepository) CreateSession(ctx context.Context, session *domain.AdminSession) error {
+	err := r.Pool.QueryRow(ctx, `
+        INSERT INTO admin_sessions (telegram_id, token_hash, user_agent, expires_at)
+        VALUES ($1, $2, $3, $4)
+        RETURNING id, created_at`,
+		session.TelegramID, session.TokenHash, session.UserAgent, session.ExpiresAt,
+	).Scan(&session.ID, &session.CreatedAt)
+	if errors.Is(err, global_db.ErrForeignKeyViolation) {
+		return ErrAdminNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create admin session: %w", err)
+	}
+	return nil
+}
+
+// GetSession возвращает сессию по хэшу токена обновления
+func (r *bizDBRepository) GetSession(ctx context.Context, tokenHash []byte) (*domain.AdminSession, error) {
+	session, err := scanSession(r.Pool.QueryRow(ctx, `SELECT `+sessionColumns+` FROM admin_sessions WHERE token_hash = $1`, tokenHash))
+	if isNoRows(err) {
+		return nil, ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get admin session: %w", err)
+	}
+	return session, nil
+}
+
+// RevokeSession закрывает сессию (только открытую)
+func (r *bizDBRepository) RevokeSession(ctx context.Context, id int64, at time.Time) (bool, error) {
+	affected, err := r.Pool.Exec(ctx, `UPDATE admin_sessions SET revoked_at = $2 WHERE id = $1 AND revoked_at IS NULL`, id, at)
+	if err != nil {
+		return false, fmt.Errorf("failed to revoke admin session: %w", err)
+	}
+	return affected > 0, nil
+}
+
+// RevokeAdminSessions закрывает все открытые сессии администратора
+func (r *bizDBRepository) RevokeAdminSessions(ctx context.Context, telegramID int64, at time.Time) (int64, error) {
+	affected, err := r.Pool.Exec(ctx, `UPDATE admin_sessions SET revoked_at = $2 WHERE telegram_id = $1 AND revoked_at IS NULL`, telegramID, at)
+	if err != nil {
+		return 0, fmt.Errorf("failed to revoke admin sessions: %w", err)
+	}
+	return affected, nil
+}
+
+// DeleteSessionsBefore удаляет истёкшие сессии
+func (r *bizDBRepository) DeleteSessionsBefore(ctx context.Context, before time.Time) (int64, error) {
+	deleted, err := r.Pool.Exec(ctx, `DELETE FROM admin_sessions WHERE expires_at < $1`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete admin sessions: %w", err)
+	}
+	return deleted, nil
+}
@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"os"
+	"pkg/configs"
+	"pkg/migrator"
+	postgresdb "pkg/postgres_db"
+	"server/internal/domain"
+	"server/migrations"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// фабрика чистого хранилища администраторов для одного теста
+type adminAuthRepoFactory func(t *testing.T) AdminAuthRepository
+
+func TestMemoryAdminAuthContract(t *testing.T) {
+	runAdminAuthContract(t, func(t *testing.T) AdminAuthRepository {
+		return NewMemoryRepository()
+	})
+}
+
+func TestPostgresAdminAuthContract(t *testing.T) {
+	dsn := os.Getenv(testPostgresDSNEnv)
+	if dsn == "" {
+		t.Skipf("%s не задан - контракт администраторов на Postgres пропущен", testPostgresDSNEnv)
+	}
+
+	ctx := context.Background()
+
+	m, err := migrator.New(dsn, migrations.FS, configs.UseDefaultMigrationsConfig())
+	if err != nil {
+		t.Fatalf("migrator: %v", err)
+	}
+	defer m.Close()
+	if err := m.Up(ctx); err != nil {
+		t.Fatalf("migrations: %v", err)
+	}
+
+	pool, err := pgxpool.Connect(ctx, dsn)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer pool.Close()
+	adapter := postgresdb.NewPoolAdapter(pool)
+
+	runAdminAuthContract(t, func(t *testing.T) AdminAuthRepository {
+		if _, err := adapter.Exec(ctx, `TRUNCATE admin_users, admin_sessions RESTART IDENTITY CASCADE`); err != nil {
+			t.Fatalf("truncate: %v", err)
+		}
+		return NewBizDBRepository(adapter)
+	})
+}
+
+func runAdminAuthContract(t *testing.T, newRepo adminAuthRepoFactory) {
+	ctx := context.Background()
+
+	t.Run("администраторы и роли", func(t *testing.T) {
+		repo := newRepo(t)
+		if _, err := repo.GetAdmin(ctx, 1); !errors.Is(err, ErrAdminNotFound) {
+			t.Fatalf("нет администратора: %v", err)
+		}
+
+		created, err := repo.AddAdminIfMissing(ctx, &domain.Admin{TelegramID: 1, Role: domain.RoleOwner})
+		if err != nil || !created {
+			t.Fatalf("первый владелец: created=%v, %v", created, err)
+		}
+		created, _ = repo.AddAdminIfMissing(ctx, &domain.Admin{TelegramID: 1, Role: domain.RoleViewer})
+		if admin, _ := repo.GetAdmin(ctx, 1); created || admin.Role != domain.RoleOwner {
+			t.Errorf("повтор не должен менять роль: created=%v, %+v", created, admin)
+		}
+
+		manager := &domain.Admin{TelegramID: 2, Role: domain.RoleViewer}
+		if err := repo.SaveAdmin(ctx, manager); err != nil {
+			t.Fatalf("сохранение: %v", err)
+		}
+		login := &domain.Admin{TelegramID: 2, Username: "anna", FirstName: "Анна"}
+		at := time.Now()
+		if err := repo.RecordLogin(ctx, login, at); err != nil {
+			t.Fatalf("вход: %v", err)
+		}
+		if login.Role != domain.RoleViewer || login.LastLoginAt == nil || !sameTime(*login.LastLoginAt, at) {
+			t.Errorf("вход должен вернуть администратора из хранилища: %+v", login)
+		}
+
+		manager.Role = domain.RoleManager
+		if err := repo.SaveAdmin(ctx, manager); err != nil {
+			t.Fatalf("смена роли: %v", err)
+		}
+		if manager.Username != "anna" {
+			t.Errorf("смена роли не должна стирать профиль: %+v", manager)
+		}
+
+		admins, err := repo.ListAdmins(ctx)
+		if err != nil || len(admins) != 2 || admins[0].TelegramID != 1 || admins[1].Role != domain.RoleManager {
+			t.Fatalf("список: %+v, %v", admins, err)
+		}
+
+		if err := repo.RecordLogin(ctx, &domain.Admin{TelegramID: 3}, at); !errors.Is(err, ErrAdminNotFound) {
+			t.Errorf("вход не администратора: %v", err)
+		}
+		if err := repo.DeleteAdmin(ctx, 3); !errors.Is(err, ErrAdminNotFound) {
+			t.Errorf("удаление несуществующего: %v", err)
+		}
+	})
+
+	t.Run("сессии", func(t *testing.T) {
+		repo := newRepo(t)
+		repo.AddAdminIfMissing(ctx, &domain.Admin{TelegramID: 1, Role: domain.RoleOwner})
+
+		now := time.Now()
+		session := &domain.AdminSession{TelegramID: 1, TokenHash: []byte("hash-1"), UserAgent: "test", ExpiresAt: now.Add(time.Hour)}
+		if err := repo.CreateSession(ctx, session); err != nil || session.ID == 0 {
+			t.Fatalf("создание: %+v, %v", session, err)
+		}
+		other := &domain.AdminSession{TelegramID: 1, TokenHash: []byte("hash-2"), ExpiresAt: now.Add(-time.Hour)}
+		repo.CreateSession(ctx, other)
+
+		got, err := repo.GetSession(ctx, []byte("hash-1"))
+		if err != nil || got.ID != session.ID || got.TelegramID != 1 || got.RevokedAt != nil || !sameTime(got.ExpiresAt, session.ExpiresAt) {
+			t.Fatalf("чтение: %+v, %v", got, err)
+		}
+		if _, err := repo.GetSession(ctx, []byte("missing")); !errors.Is(err, ErrSessionNotFound) {
+			t.Errorf("нет сессии: %v", err)
+		}
+		if err := repo.CreateSession(ctx, &domain.AdminSession{TelegramID: 9, TokenHash: []byte("x"), ExpiresAt: now}); !errors.Is(err, ErrAdminNotFound) {
+			t.Errorf("сессия не администратора: %v", err)
+		}
+
+		// закрыть сессию можно только один раз
+		if revoked, err := repo.RevokeSession(ctx, session.ID, now); err != nil || !revoked {
+			t.Fatalf("закрытие: %v, %v", revoked, err)
+		}
+		if revoked, _ := repo.RevokeSession(ctx, session.ID, now); revoked {
+			t.Error("повторное закрытие должно вернуть false")
+		}
+		if got, _ := repo.GetSession(ctx, []byte("hash-1")); got.RevokedAt == nil {
+			t.Error("закрытая сессия должна читаться с revoked_at")
+		}
+
+		if n, err := repo.RevokeAdminSessions(ctx, 1, now); err != nil || n != 1 {
+			t.Errorf("закрытие всех: %d, %v", n, err)
+		}
+		if n, err := repo.DeleteSessionsBefore(ctx, now); err != nil || n != 1 {
+			t.Errorf("очистка истёкших: %d, %v", n, err)
+		}
+
+		if err := repo.DeleteAdmin(ctx, 1); err != nil {
+			t.Fatalf("удаление: %v", err)
+		}
+		if _, err := repo.GetSession(ctx, []byte("hash-1")); !errors.Is(err, ErrSessionNotFound) {
+			t.Errorf("сессии удаляются вместе с администратором: %v", err)
+		}
+	})
+}
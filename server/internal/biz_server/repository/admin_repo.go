@@ -0,0 +1,347 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"global_models/global_db"
+	"server/internal/domain"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// выборки админки, заявки и настройки бизнеса в Postgres
+
+// whereBuilder собирает условия WHERE с нумерованными параметрами
+type whereBuilder struct {
+	conds []string
+	args  []any
+}
+
+// метод добавляет параметр и возвращает его плейсхолдер ($n)
+func (w *whereBuilder) arg(value any) string {
+	w.args = append(w.args, value)
+	return "$" + strconv.Itoa(len(w.args))
+}
+
+// метод добавляет условие (в тексте - плейсхолдеры, полученные через arg)
+func (w *whereBuilder) add(cond string) {
+	w.conds = append(w.conds, cond)
+}
+
+// метод возвращает WHERE часть запроса (пустая строка - без условий)
+func (w *whereBuilder) sql() string {
+	if len(w.conds) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(w.conds, " AND ")
+}
+
+// функция шаблона ILIKE для подстроки (% и _ в запросе ищутся буквально)
+func containsPattern(query string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return "%" + replacer.Replace(query) + "%"
+}
+
+// метод подсчёта строк запроса с условиями
+func (r *bizDBRepository) count(ctx context.Context, from string, where *whereBuilder) (int64, error) {
+	var total int64
+	err := r.Pool.QueryRow(ctx, `SELECT COUNT(*) FROM `+from+where.sql(), where.args...).Scan(&total)
+	return total, err
+}
+
+// ListUsers возвращает страницу пользователей (сначала недавно активные)
+func (r *bizDBRepository) ListUsers(ctx context.Context, filter domain.UserFilter) ([]*domain.User, int64, error) {
+	where := &whereBuilder{}
+	if filter.Query != "" {
+		pattern := where.arg(containsPattern(filter.Query))
+		cond := "(username ILIKE " + pattern + " OR first_name ILIKE " + pattern + " OR last_name ILIKE " + pattern
+		if telegramID, err := strconv.ParseInt(filter.Query, 10, 64); err == nil {
+			cond += " OR telegram_id = " + where.arg(telegramID)
+		}
+		where.add(cond + ")")
+	}
+	if filter.Active != nil {
+		where.add("is_active = " + where.arg(*filter.Active))
+	}
+
+	total, err := r.count(ctx, "users", where)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count users: %w", err)
+	}
+
+	query := `SELECT id, telegram_id, username, first_name, last_name, is_active, created_at, last_seen_at FROM users` +
+		where.sql() + ` ORDER BY last_seen_at DESC, id DESC LIMIT ` + where.arg(filter.Page.Size) + ` OFFSET ` + where.arg(filter.Page.Offset())
+	rows, err := r.Pool.Query(ctx, query, where.args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list users: %w", err)
+	}
+	defer rows.Close()
+
+	var users []*domain.User
+	for rows.Next() {
+		var user domain.User
+		if err := rows.Scan(&user.ID, &user.TelegramID, &user.Username, &user.FirstName, &user.LastName,
+			&user.IsActive, &user.CreatedAt, &user.LastSeenAt); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan user: %w", err)
+		}
+		users = append(users, &user)
+	}
+	return users, total, rows.Err()
+}
+
+// ListMessages возвращает переписку с пользователем (сначала новые)
+func (r *bizDBRepository) ListMessages(ctx context.Context, filter domain.MessageFilter) ([]*domain.Message, error) {
+	where := &whereBuilder{}
+	where.add("telegram_user_id = " + where.arg(filter.TelegramID))
+	if filter.BeforeID > 0 {
+		where.add("id < " + where.arg(filter.BeforeID))
+	}
+
+	query := `SELECT id, COALESCE(telegram_message_id, 0), telegram_chat_id, telegram_user_id, text, direction, status,
+        is_command, command_name, created_at, updated_at FROM messages` +
+		where.sql() + ` ORDER BY id DESC LIMIT ` + where.arg(filter.Limit)
+	rows, err := r.Pool.Query(ctx, query, where.args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list messages: %w", err)
+	}
+	defer rows.Close()
+
+	var messages []*domain.Message
+	for rows.Next() {
+		var m domain.Message
+		if err := rows.Scan(&m.ID, &m.MessageID, &m.ChatID, &m.UserID, &m.Text, &m.Direction, &m.Status,
+			&m.IsCommand, &m.CommandName, &m.CreatedAt, &m.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan message: %w", err)
+		}
+		messages = append(messages, &m)
+	}
+	return messages, rows.Err()
+}
+
+// ListCallbacks возвращает страницу нажатий кнопок (сначала новые)
+func (r *bizDBRepository) ListCallbacks(ctx context.Context, filter domain.CallbackFilter) ([]*domain.CallbackLog, int64, error) {
+	where := &whereBuilder{}
+	if filter.TelegramID != 0 {
+		where.add("telegram_user_id = " + where.arg(filter.TelegramID))
+	}
+	if filter.Data != "" {
+		where.add("callback_data = " + where.arg(filter.Data))
+	}
+
+	total, err := r.count(ctx, "callback_logs", where)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count callbacks: %w", err)
+	}
+
+	query := `SELECT id, callback_id, telegram_user_id, telegram_chat_id, telegram_message_id, callback_data, created_at
+        FROM callback_logs` + where.sql() + ` ORDER BY id DESC LIMIT ` + where.arg(filter.Page.Size) + ` OFFSET ` + where.arg(filter.Page.Offset())
+	rows, err := r.Pool.Query(ctx, query, where.args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list callbacks: %w", err)
+	}
+	defer rows.Close()
+
+	var callbacks []*domain.CallbackLog
+	for rows.Next() {
+		var cb domain.CallbackLog
+		if err := rows.Scan(&cb.ID, &cb.CallbackID, &cb.UserID, &cb.ChatID, &cb.MessageID, &cb.Data, &cb.Timestamp); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan callback: %w", err)
+		}
+		callbacks = append(callbacks, &cb)
+	}
+	return callbacks, total, rows.Err()
+}
+
+// DashboardStats возвращает сводку с момента since
+func (r *bizDBRepository) DashboardStats(ctx context.Context, since time.Time) (*domain.DashboardStats, error) {
+	stats := &domain.DashboardStats{LeadsByStatus: make(map[domain.LeadStatus]int64)}
+	err := r.Pool.QueryRow(ctx, `
+        SELECT
+            (SELECT COUNT(*) FROM users WHERE created_at >= $1),
+            (SELECT COUNT(*) FROM users WHERE last_seen_at >= $1),
+            (SELECT COUNT(DISTINCT telegram_user_id) FROM messages
+                WHERE direction = 'incoming' AND command_name = '/start' AND created_at >= $1),
+            (SELECT COUNT(DISTINCT telegram_user_id) FROM callback_logs WHERE callback_data = 'lookup' AND created_at >= $1),
+            (SELECT COUNT(DISTINCT telegram_user_id) FROM callback_logs WHERE callback_data = 'contacted_yes' AND created_at >= $1),
+            (SELECT COUNT(*) FROM leads WHERE created_at >= $1)`,
+		since,
+	).Scan(&stats.NewUsers, &stats.ActiveUsers, &stats.Started, &stats.Lookups, &stats.ContactedYes, &stats.NewLeads)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get dashboard stats: %w", err)
+	}
+
+	rows, err := r.Pool.Query(ctx, `SELECT status, COUNT(*) FROM leads GROUP BY status`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count leads by status: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var status string
+		var count int64
+		if err := rows.Scan(&status, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan leads by status: %w", err)
+		}
+		stats.LeadsByStatus[domain.LeadStatus(status)] = count
+	}
+	return stats, rows.Err()
+}
+
+// колонки заявки с профилем пользователя (порядок совпадает со scanLead)
+const leadColumns = `l.id, l.telegram_user_id, l.status, l.source, l.note, l.created_at, l.updated_at,
+    u.id IS NOT NULL, COALESCE(u.id, 0), COALESCE(u.username, ''), COALESCE(u.first_name, ''), COALESCE(u.last_name, ''),
+    COALESCE(u.is_active, FALSE), COALESCE(u.created_at, l.created_at), COALESCE(u.last_seen_at, l.created_at)`
+
+// источник заявок с профилями пользователей
+const leadFrom = `leads l LEFT JOIN users u ON u.telegram_id = l.telegram_user_id`
+
+// функция чтения заявки из строки результата
+func scanLead(row global_db.Row) (*domain.Lead, error) {
+	var lead domain.Lead
+	var status string
+	var hasUser bool
+	var user domain.User
+	err := row.Scan(&lead.ID, &lead.TelegramID, &status, &lead.Source, &lead.Note, &lead.CreatedAt, &lead.UpdatedAt,
+		&hasUser, &user.ID, &user.Username, &user.FirstName, &user.LastName, &user.IsActive, &user.CreatedAt, &user.LastSeenAt)
+	if err != nil {
+		return nil, err
+	}
+	lead.Status = domain.LeadStatus(status)
+	if hasUser {
+		user.TelegramID = lead.TelegramID
+		lead.User = &user
+	}
+	return &lead, nil
+}
+
+// CreateLead создаёт заявку (или возвращает открытую заявку пользователя)
+func (r *bizDBRepository) CreateLead(ctx context.Context, lead *domain.Lead) (bool, error) {
+	var status string
+	err := r.Pool.QueryRow(ctx, `
+        INSERT INTO leads (telegram_user_id, status, source, note)
+        VALUES ($1, 'new', $2, $3)
+        ON CONFLICT (telegram_user_id) WHERE status IN ('new', 'in_progress') DO NOTHING
+        RETURNING id, status, created_at, updated_at`,
+		lead.TelegramID, lead.Source, lead.Note,
+	).Scan(&lead.ID, &status, &lead.CreatedAt, &lead.UpdatedAt)
+	if err == nil {
+		lead.Status = domain.LeadStatus(status)
+		return true, nil
+	}
+	if !isNoRows(err) {
+		return false, fmt.Errorf("failed to create lead: %w", err)
+	}
+
+	// открытая заявка уже есть
+	existing, err := scanLead(r.Pool.QueryRow(ctx, `SELECT `+leadColumns+` FROM `+leadFrom+`
+        WHERE l.telegram_user_id = $1 AND l.status IN ('new', 'in_progress')`, lead.TelegramID))
+	if err != nil {
+		return false, fmt.Errorf("failed to get open lead: %w", err)
+	}
+	*lead = *existing
+	return false, nil
+}
+
+// GetLead возвращает заявку с профилем пользователя
+func (r *bizDBRepository) GetLead(ctx context.Context, id int64) (*domain.Lead, error) {
+	lead, err := scanLead(r.Pool.QueryRow(ctx, `SELECT `+leadColumns+` FROM `+leadFrom+` WHERE l.id = $1`, id))
+	if isNoRows(err) {
+		return nil, ErrLeadNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get lead: %w", err)
+	}
+	return lead, nil
+}
+
+// ListLeads возвращает страницу заявок (сначала новые)
+func (r *bizDBRepository) ListLeads(ctx context.Context, filter domain.LeadFilter) ([]*domain.Lead, int64, error) {
+	where := &whereBuilder{}
+	if filter.Status != "" {
+		where.add("l.status = " + where.arg(string(filter.Status)))
+	}
+	if filter.TelegramID != 0 {
+		where.add("l.telegram_user_id = " + where.arg(filter.TelegramID))
+	}
+
+	total, err := r.count(ctx, "leads l", where)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count leads: %w", err)
+	}
+
+	query := `SELECT ` + leadColumns + ` FROM ` + leadFrom + where.sql() +
+		` ORDER BY l.id DESC LIMIT ` + where.arg(filter.Page.Size) + ` OFFSET ` + where.arg(filter.Page.Offset())
+	rows, err := r.Pool.Query(ctx, query, where.args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list leads: %w", err)
+	}
+	defer rows.Close()
+
+	var leads []*domain.Lead
+	for rows.Next() {
+		lead, err := scanLead(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan lead: %w", err)
+		}
+		leads = append(leads, lead)
+	}
+	return leads, total, rows.Err()
+}
+
+// UpdateLead меняет статус и заметку заявки
+func (r *bizDBRepository) UpdateLead(ctx context.Context, lead *domain.Lead) error {
+	err := r.Pool.QueryRow(ctx, `
+        UPDATE leads SET status = $2, note = $3, updated_at = NOW()
+        WHERE id = $1
+        RETURNING updated_at`,
+		lead.ID, string(lead.Status), lead.Note,
+	).Scan(&lead.UpdatedAt)
+	if isNoRows(err) {
+		return ErrLeadNotFound
+	}
+	if errors.Is(err, global_db.ErrUniqueViolation) {
+		return ErrLeadConflict
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update lead: %w", err)
+	}
+	return nil
+}
+
+// GetSettings возвращает настройки бизнеса
+func (r *bizDBRepository) GetSettings(ctx context.Context) (*domain.BusinessSettings, error) {
+	var s domain.BusinessSettings
+	err := r.Pool.QueryRow(ctx, `
+        SELECT master_name, instagram_url, welcome_text, notify_chat_id, timezone, updated_at
+        FROM business_settings WHERE id`,
+	).Scan(&s.MasterName, &s.InstagramURL, &s.WelcomeText, &s.NotifyChatID, &s.Timezone, &s.UpdatedAt)
+	if isNoRows(err) {
+		return domain.DefaultBusinessSettings(), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get settings: %w", err)
+	}
+	return &s, nil
+}
+
+// SaveSettings сохраняет настройки бизнеса
+func (r *bizDBRepository) SaveSettings(ctx context.Context, s *domain.BusinessSettings) error {
+	err := r.Pool.QueryRow(ctx, `
+        INSERT INTO business_settings (id, master_name, instagram_url, welcome_text, notify_chat_id, timezone, updated_at)
+        VALUES (TRUE, $1, $2, $3, $4, $5, NOW())
+        ON CONFLICT (id) DO UPDATE SET
+            master_name = EXCLUDED.master_name,
+            instagram_url = EXCLUDED.instagram_url,
+            welcome_text = EXCLUDED.welcome_text,
+            notify_chat_id = EXCLUDED.notify_chat_id,
+            timezone = EXCLUDED.timezone,
+            updated_at = EXCLUDED.updated_at
+        RETURNING updated_at`,
+		s.MasterName, s.InstagramURL, s.WelcomeText, s.NotifyChatID, s.Timezone,
+	).Scan(&s.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save settings: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,514 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"os"
+	"pkg/configs"
+	"pkg/migrator"
+	postgresdb "pkg/postgres_db"
+	"server/internal/domain"
+	"server/migrations"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// хранилище с данными бота и выборками админки
+type adminRepo interface {
+	Repositories
+	AdminRepositories
+}
+
+// фабрика чистого хранилища админки для одного теста
+type adminRepoFactory func(t *testing.T) adminRepo
+
+func TestMemoryAdminContract(t *testing.T) {
+	runAdminContract(t, func(t *testing.T) adminRepo {
+		return NewMemoryRepository()
+	})
+}
+
+func TestPostgresAdminContract(t *testing.T) {
+	dsn := os.Getenv(testPostgresDSNEnv)
+	if dsn == "" {
+		t.Skipf("%s не задан - контракт админки на Postgres пропущен", testPostgresDSNEnv)
+	}
+
+	ctx := context.Background()
+
+	m, err := migrator.New(dsn, migrations.FS, configs.UseDefaultMigrationsConfig())
+	if err != nil {
+		t.Fatalf("migrator: %v", err)
+	}
+	defer m.Close()
+	if err := m.Up(ctx); err != nil {
+		t.Fatalf("migrations: %v", err)
+	}
+
+	pool, err := pgxpool.Connect(ctx, dsn)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer pool.Close()
+	adapter := postgresdb.NewPoolAdapter(pool)
+
+	runAdminContract(t, func(t *testing.T) adminRepo {
+		_, err := adapter.Exec(ctx, `TRUNCATE users, messages, callback_logs, leads, business_settings, bot_content, handoffs,
+            analytics_funnel, analytics_sources, analytics_retention RESTART IDENTITY CASCADE`)
+		if err != nil {
+			t.Fatalf("truncate: %v", err)
+		}
+		return NewBizDBRepository(adapter)
+	})
+}
+
+func runAdminContract(t *testing.T, newRepo adminRepoFactory) {
+	ctx := context.Background()
+
+	t.Run("поиск и страницы пользователей", func(t *testing.T) {
+		repo := newRepo(t)
+		base := time.Now().Add(-time.Hour)
+		for i, name := range []string{"anna", "boris", "anastasia_%"} {
+			user := testUser(int64(100 + i))
+			user.Username = name
+			user.LastSeenAt = base.Add(time.Duration(i) * time.Minute)
+			user.IsActive = i != 1
+			if err := repo.CreateUser(ctx, user); err != nil {
+				t.Fatalf("пользователь: %v", err)
+			}
+		}
+
+		users, total, err := repo.ListUsers(ctx, domain.UserFilter{Page: domain.Page{Number: 1, Size: 2}})
+		if err != nil {
+			t.Fatalf("список: %v", err)
+		}
+		if total != 3 || len(users) != 2 || users[0].TelegramID != 102 || users[1].TelegramID != 101 {
+			t.Fatalf("первая страница: всего %d, %+v", total, users)
+		}
+		users, _, _ = repo.ListUsers(ctx, domain.UserFilter{Page: domain.Page{Number: 2, Size: 2}})
+		if len(users) != 1 || users[0].TelegramID != 100 {
+			t.Errorf("вторая страница: %+v", users)
+		}
+
+		users, total, _ = repo.ListUsers(ctx, domain.UserFilter{Query: "ANA", Page: domain.Page{Number: 1, Size: 10}})
+		if total != 1 || users[0].Username != "anastasia_%" {
+			t.Errorf("поиск без учёта регистра: всего %d, %+v", total, users)
+		}
+		_, total, _ = repo.ListUsers(ctx, domain.UserFilter{Query: "_%", Page: domain.Page{Number: 1, Size: 10}})
+		if total != 1 {
+			t.Errorf("спецсимволы ищутся буквально: найдено %d", total)
+		}
+		users, _, _ = repo.ListUsers(ctx, domain.UserFilter{Query: "101", Page: domain.Page{Number: 1, Size: 10}})
+		if len(users) != 1 || users[0].Username != "boris" {
+			t.Errorf("поиск по telegram_id: %+v", users)
+		}
+
+		inactive := false
+		users, total, _ = repo.ListUsers(ctx, domain.UserFilter{Active: &inactive, Page: domain.Page{Number: 1, Size: 10}})
+		if total != 1 || users[0].TelegramID != 101 {
+			t.Errorf("фильтр активности: всего %d, %+v", total, users)
+		}
+	})
+
+	t.Run("переписка постранично по id", func(t *testing.T) {
+		repo := newRepo(t)
+		var ids []int64
+		for i := range 5 {
+			message := testMessage(10, int64(i+1), "текст")
+			if err := repo.Save(ctx, message); err != nil {
+				t.Fatalf("сообщение: %v", err)
+			}
+			ids = append(ids, message.ID)
+		}
+		other := testMessage(11, 1, "чужое")
+		other.UserID = 21
+		if err := repo.Save(ctx, other); err != nil {
+			t.Fatalf("сообщение: %v", err)
+		}
+
+		messages, err := repo.ListMessages(ctx, domain.MessageFilter{TelegramID: 20, Limit: 3})
+		if err != nil {
+			t.Fatalf("переписка: %v", err)
+		}
+		if len(messages) != 3 || messages[0].ID != ids[4] || messages[2].ID != ids[2] {
+			t.Fatalf("первая страница: %+v", messages)
+		}
+		messages, _ = repo.ListMessages(ctx, domain.MessageFilter{TelegramID: 20, BeforeID: messages[2].ID, Limit: 3})
+		if len(messages) != 2 || messages[0].ID != ids[1] || messages[1].ID != ids[0] {
+			t.Errorf("следующая страница: %+v", messages)
+		}
+	})
+
+	t.Run("журнал нажатий с фильтрами", func(t *testing.T) {
+		repo := newRepo(t)
+		for i, data := range []string{"lookup", "contacted_yes", "lookup"} {
+			callback := testCallback("cb-"+string(rune('a'+i)), 10, int64(i+1))
+			callback.Data = data
+			if err := repo.SaveCallback(ctx, callback); err != nil {
+				t.Fatalf("колбэк: %v", err)
+			}
+		}
+
+		callbacks, total, err := repo.ListCallbacks(ctx, domain.CallbackFilter{Data: "lookup", Page: domain.Page{Number: 1, Size: 10}})
+		if err != nil {
+			t.Fatalf("журнал: %v", err)
+		}
+		if total != 2 || callbacks[0].CallbackID != "cb-c" || callbacks[1].CallbackID != "cb-a" {
+			t.Fatalf("фильтр по данным: всего %d, %+v", total, callbacks)
+		}
+		if callbacks[0].Timestamp.IsZero() {
+			t.Error("время нажатия не заполнено")
+		}
+		_, total, _ = repo.ListCallbacks(ctx, domain.CallbackFilter{TelegramID: 21, Page: domain.Page{Number: 1, Size: 10}})
+		if total != 0 {
+			t.Errorf("фильтр по пользователю: найдено %d", total)
+		}
+	})
+
+	t.Run("одна открытая заявка на пользователя", func(t *testing.T) {
+		repo := newRepo(t)
+		if err := repo.CreateUser(ctx, testUser(20)); err != nil {
+			t.Fatalf("пользователь: %v", err)
+		}
+
+		lead := &domain.Lead{TelegramID: 20, Source: "contacted_yes"}
+		created, err := repo.CreateLead(ctx, lead)
+		if err != nil || !created || lead.ID == 0 || lead.Status != domain.LeadNew {
+			t.Fatalf("создание: created=%v, %+v, %v", created, lead, err)
+		}
+
+		dup := &domain.Lead{TelegramID: 20, Source: "contacted_yes"}
+		created, err = repo.CreateLead(ctx, dup)
+		if err != nil || created || dup.ID != lead.ID {
+			t.Fatalf("повтор должен вернуть открытую заявку: created=%v, %+v, %v", created, dup, err)
+		}
+
+		got, err := repo.GetLead(ctx, lead.ID)
+		if err != nil {
+			t.Fatalf("чтение: %v", err)
+		}
+		if got.User == nil || got.User.TelegramID != 20 {
+			t.Errorf("профиль пользователя не подтянут: %+v", got.User)
+		}
+
+		lead.Status, lead.Note = domain.LeadWon, "записалась"
+		if err := repo.UpdateLead(ctx, lead); err != nil {
+			t.Fatalf("закрытие: %v", err)
+		}
+		next := &domain.Lead{TelegramID: 20}
+		if created, err := repo.CreateLead(ctx, next); err != nil || !created {
+			t.Fatalf("после закрытия можно открыть новую: created=%v, %v", created, err)
+		}
+
+		lead.Status = domain.LeadInProgress
+		if err := repo.UpdateLead(ctx, lead); !errors.Is(err, ErrLeadConflict) {
+			t.Errorf("повторное открытие при открытой заявке: %v", err)
+		}
+		if err := repo.UpdateLead(ctx, &domain.Lead{ID: 999, Status: domain.LeadLost}); !errors.Is(err, ErrLeadNotFound) {
+			t.Errorf("несуществующая заявка: %v", err)
+		}
+		if _, err := repo.GetLead(ctx, 999); !errors.Is(err, ErrLeadNotFound) {
+			t.Errorf("несуществующая заявка: %v", err)
+		}
+
+		leads, total, err := repo.ListLeads(ctx, domain.LeadFilter{Status: domain.LeadNew, Page: domain.Page{Number: 1, Size: 10}})
+		if err != nil {
+			t.Fatalf("список: %v", err)
+		}
+		if total != 1 || leads[0].ID != next.ID {
+			t.Errorf("фильтр по статусу: всего %d, %+v", total, leads)
+		}
+		leads, total, _ = repo.ListLeads(ctx, domain.LeadFilter{Page: domain.Page{Number: 1, Size: 10}})
+		if total != 2 || leads[0].ID != next.ID || leads[1].Note != "записалась" {
+			t.Errorf("все заявки: всего %d, %+v", total, leads)
+		}
+	})
+
+	t.Run("сводка за день", func(t *testing.T) {
+		repo := newRepo(t)
+		since := time.Now().Add(-time.Hour)
+
+		old := testUser(19)
+		old.CreatedAt = since.Add(-24 * time.Hour)
+		old.LastSeenAt = old.CreatedAt
+		for _, user := range []*domain.User{old, testUser(20), testUser(21)} {
+			if err := repo.CreateUser(ctx, user); err != nil {
+				t.Fatalf("пользователь: %v", err)
+			}
+		}
+		for i, userID := range []int64{20, 20, 21} {
+			start := testMessage(userID, int64(i+1), "/start")
+			start.UserID = userID
+			repo.Save(ctx, start)
+		}
+		reply := testMessage(20, 0, "/start")
+		reply.Direction = "outgoing"
+		repo.Save(ctx, reply)
+		for i, data := range []string{"lookup", "lookup", "contacted_yes"} {
+			callback := testCallback("cb-"+string(rune('a'+i)), 20, int64(i+1))
+			callback.Data = data
+			repo.SaveCallback(ctx, callback)
+		}
+		lead := &domain.Lead{TelegramID: 20, Source: "contacted_yes"}
+		repo.CreateLead(ctx, lead)
+		repo.CreateLead(ctx, &domain.Lead{TelegramID: 21, Source: "contacted_yes"})
+		lead.Status = domain.LeadWon
+		repo.UpdateLead(ctx, lead)
+
+		stats, err := repo.DashboardStats(ctx, since)
+		if err != nil {
+			t.Fatalf("сводка: %v", err)
+		}
+		want := domain.DashboardStats{NewUsers: 2, ActiveUsers: 2, Started: 2, Lookups: 1, ContactedYes: 1, NewLeads: 2}
+		if stats.NewUsers != want.NewUsers || stats.ActiveUsers != want.ActiveUsers || stats.Started != want.Started ||
+			stats.Lookups != want.Lookups || stats.ContactedYes != want.ContactedYes || stats.NewLeads != want.NewLeads {
+			t.Errorf("получили %+v, ожидали %+v", stats, want)
+		}
+		if stats.LeadsByStatus[domain.LeadNew] != 1 || stats.LeadsByStatus[domain.LeadWon] != 1 {
+			t.Errorf("заявки по статусам: %v", stats.LeadsByStatus)
+		}
+	})
+
+	t.Run("тексты бота", func(t *testing.T) {
+		repo := newRepo(t)
+		content, err := repo.ListContent(ctx)
+		if err != nil || len(content) != 0 {
+			t.Fatalf("до изменений: %v, %v", content, err)
+		}
+
+		for _, text := range []string{"первая версия", "Помогу записаться"} {
+			if err := repo.SaveContent(ctx, domain.ContentHelp, text); err != nil {
+				t.Fatalf("сохранение: %v", err)
+			}
+		}
+		content, _ = repo.ListContent(ctx)
+		if item := content[domain.ContentHelp]; len(content) != 1 || item.Text != "Помогу записаться" || item.UpdatedAt == nil {
+			t.Errorf("изменённый текст: %+v", content)
+		}
+
+		if err := repo.SaveContent(ctx, domain.ContentHelp, ""); err != nil {
+			t.Fatalf("сброс: %v", err)
+		}
+		if content, _ = repo.ListContent(ctx); len(content) != 0 {
+			t.Errorf("пустой текст возвращает текст по умолчанию: %+v", content)
+		}
+	})
+
+	t.Run("один открытый диалог с оператором на чат", func(t *testing.T) {
+		repo := newRepo(t)
+		if err := repo.CreateUser(ctx, testUser(300)); err != nil {
+			t.Fatalf("пользователь: %v", err)
+		}
+		if _, err := repo.GetOpenHandoff(ctx, 300); !errors.Is(err, ErrHandoffNotFound) {
+			t.Fatalf("до открытия ожидали ErrHandoffNotFound, получили %v", err)
+		}
+
+		first := &domain.Handoff{TelegramID: 300, Reason: domain.HandoffByClient}
+		created, err := repo.OpenHandoff(ctx, first)
+		if err != nil || !created || first.ID == 0 || first.OpenedAt.IsZero() || first.ClosedAt != nil {
+			t.Fatalf("открытие: %+v, %v, %v", first, created, err)
+		}
+		second := &domain.Handoff{TelegramID: 300, Reason: domain.HandoffByMaster, OpenedBy: 7}
+		if created, err := repo.OpenHandoff(ctx, second); err != nil || created || second.ID != first.ID || second.Reason != domain.HandoffByClient {
+			t.Fatalf("повторное открытие вернёт открытый диалог: %+v, %v, %v", second, created, err)
+		}
+
+		later := first.LastActivityAt.Add(time.Minute)
+		if err := repo.TouchHandoff(ctx, 300, later); err != nil {
+			t.Fatalf("активность: %v", err)
+		}
+		if err := repo.TouchHandoff(ctx, 300, first.OpenedAt); err != nil {
+			t.Fatalf("активность: %v", err)
+		}
+		open, err := repo.ListOpenHandoffs(ctx)
+		if err != nil || len(open) != 1 || open[0].User == nil || !open[0].LastActivityAt.Equal(later) {
+			t.Fatalf("открытые диалоги (время активности не уходит назад): %+v, %v", open, err)
+		}
+
+		closed, err := repo.CloseHandoff(ctx, 300, domain.HandoffClosedByOperator, later)
+		if err != nil || closed.ID != first.ID || closed.ClosedAt == nil || closed.CloseReason != domain.HandoffClosedByOperator {
+			t.Fatalf("закрытие: %+v, %v", closed, err)
+		}
+		if _, err := repo.CloseHandoff(ctx, 300, domain.HandoffClosedByOperator, later); !errors.Is(err, ErrHandoffNotFound) {
+			t.Errorf("повторное закрытие: ожидали ErrHandoffNotFound, получили %v", err)
+		}
+		if created, err := repo.OpenHandoff(ctx, &domain.Handoff{TelegramID: 300, Reason: domain.HandoffByMaster}); err != nil || !created {
+			t.Errorf("после закрытия открывается новый диалог: %v, %v", created, err)
+		}
+	})
+
+	t.Run("закрытие неактивных диалогов", func(t *testing.T) {
+		repo := newRepo(t)
+		for _, id := range []int64{301, 302} {
+			if _, err := repo.OpenHandoff(ctx, &domain.Handoff{TelegramID: id, Reason: domain.HandoffByClient}); err != nil {
+				t.Fatalf("открытие: %v", err)
+			}
+		}
+		if err := repo.TouchHandoff(ctx, 302, time.Now().Add(time.Hour)); err != nil {
+			t.Fatalf("активность: %v", err)
+		}
+
+		closed, err := repo.CloseIdleHandoffs(ctx, time.Now().Add(time.Minute), time.Now())
+		if err != nil || len(closed) != 1 || closed[0].TelegramID != 301 || closed[0].CloseReason != domain.HandoffClosedByTimeout {
+			t.Fatalf("закрыты по таймауту: %+v, %v", closed, err)
+		}
+		if _, err := repo.GetOpenHandoff(ctx, 302); err != nil {
+			t.Errorf("активный диалог остался открытым: %v", err)
+		}
+	})
+
+	t.Run("исходные данные аналитики", func(t *testing.T) {
+		repo := newRepo(t)
+		since := time.Now().Add(-time.Hour)
+
+		old := testUser(29)
+		old.CreatedAt = since.Add(-24 * time.Hour)
+		old.LastSeenAt = old.CreatedAt
+		for _, user := range []*domain.User{old, testUser(30), testUser(31)} {
+			if err := repo.CreateUser(ctx, user); err != nil {
+				t.Fatalf("пользователь: %v", err)
+			}
+		}
+		for i, m := range []struct {
+			userID int64
+			text   string
+		}{{30, "/start Instagram"}, {30, "/start"}, {31, "/start menu"}, {31, "привет"}} {
+			message := testMessage(m.userID, int64(i+1), m.text)
+			message.UserID = m.userID
+			repo.Save(ctx, message)
+		}
+		for i, c := range []struct {
+			userID int64
+			data   string
+		}{{30, "lookup"}, {30, "contacted_yes"}, {29, "contacted_yes"}, {31, "services"}} {
+			callback := testCallback("an-"+string(rune('a'+i)), c.userID, int64(i+1))
+			callback.UserID, callback.Data = c.userID, c.data
+			repo.SaveCallback(ctx, callback)
+		}
+
+		facts, err := repo.AnalyticsFacts(ctx, since)
+		if err != nil {
+			t.Fatalf("AnalyticsFacts: %v", err)
+		}
+		users := make(map[int64]domain.AnalyticsUser)
+		for _, user := range facts.Users {
+			users[user.TelegramID] = user
+		}
+		// старый пользователь попадает в выборку из-за первого согласия в окне
+		if len(users) != 3 || users[29].FirstContactAt == nil || users[31].FirstContactAt != nil {
+			t.Fatalf("пользователи: %+v", facts.Users)
+		}
+		if users[30].Source != "instagram" || users[31].Source != domain.DirectSource || users[29].Source != domain.DirectSource {
+			t.Errorf("источники по первому /start: %+v", users)
+		}
+
+		steps := make(map[string]int)
+		for _, event := range facts.Events {
+			steps[event.Step]++
+		}
+		if steps[domain.FunnelStart] != 3 || steps[domain.FunnelLookup] != 1 || steps[domain.FunnelContactedYes] != 2 || len(steps) != 3 {
+			t.Errorf("шаги воронки: %v", steps)
+		}
+
+		active := make(map[int64]bool)
+		for _, activity := range facts.Activity {
+			active[activity.TelegramID] = true
+			if !activity.Hour.Equal(activity.Hour.Truncate(time.Hour)) {
+				t.Errorf("активность по часам: %v", activity.Hour)
+			}
+		}
+		if len(active) != 3 {
+			t.Errorf("активные пользователи: %v", active)
+		}
+	})
+
+	t.Run("агрегаты аналитики заменяются по окну", func(t *testing.T) {
+		repo := newRepo(t)
+		date := func(day int) time.Time { return time.Date(2025, 10, day, 0, 0, 0, 0, time.UTC) }
+
+		first := &domain.AnalyticsSnapshot{
+			DayFrom: date(13), WeekFrom: date(6), ComputedAt: time.Now(),
+			Funnels: []*domain.FunnelStats{
+				{Period: domain.PeriodDay, Start: date(13), NewUsers: 1},
+				{Period: domain.PeriodDay, Start: date(14), NewUsers: 2, Contacts: 1, ContactTimeAvg: time.Hour, ContactTimeMedian: time.Hour},
+				{Period: domain.PeriodWeek, Start: date(6), NewUsers: 5},
+			},
+			Sources: []*domain.SourceStats{
+				{Period: domain.PeriodDay, Start: date(14), Source: "vk", NewUsers: 1},
+				{Period: domain.PeriodDay, Start: date(14), Source: "instagram", NewUsers: 2},
+			},
+			Cohorts: []*domain.CohortStats{{Start: date(6), Size: 5, Retained: []int64{5, 2}}},
+		}
+		second := &domain.AnalyticsSnapshot{
+			DayFrom: date(14), WeekFrom: date(13), ComputedAt: time.Now(),
+			Funnels: []*domain.FunnelStats{
+				{Period: domain.PeriodDay, Start: date(14), NewUsers: 3},
+				{Period: domain.PeriodWeek, Start: date(13), NewUsers: 3},
+			},
+			Sources: []*domain.SourceStats{{Period: domain.PeriodDay, Start: date(14), Source: domain.DirectSource, NewUsers: 3}},
+			Cohorts: []*domain.CohortStats{{Start: date(13), Size: 3, Retained: []int64{3}}},
+		}
+		for _, snapshot := range []*domain.AnalyticsSnapshot{first, second} {
+			if err := repo.SaveAnalytics(ctx, snapshot); err != nil {
+				t.Fatalf("SaveAnalytics: %v", err)
+			}
+		}
+
+		days, err := repo.ListFunnelStats(ctx, domain.PeriodDay, 10)
+		if err != nil {
+			t.Fatalf("ListFunnelStats: %v", err)
+		}
+		// 14-е пересчитано, 13-е - до окна второго пересчёта и осталось
+		if len(days) != 2 || !days[0].Start.Equal(date(14)) || days[0].NewUsers != 3 || days[0].Contacts != 0 || days[1].NewUsers != 1 {
+			t.Errorf("дневные воронки: %+v", days)
+		}
+		if limited, _ := repo.ListFunnelStats(ctx, domain.PeriodDay, 1); len(limited) != 1 {
+			t.Errorf("limit: %+v", limited)
+		}
+		weeks, _ := repo.ListFunnelStats(ctx, domain.PeriodWeek, 10)
+		if len(weeks) != 2 || !weeks[0].Start.Equal(date(13)) {
+			t.Errorf("недельные воронки: %+v", weeks)
+		}
+
+		sources, err := repo.ListSourceStats(ctx, domain.PeriodDay, 10)
+		if err != nil {
+			t.Fatalf("ListSourceStats: %v", err)
+		}
+		if len(sources) != 1 || sources[0].Source != domain.DirectSource || sources[0].NewUsers != 3 {
+			t.Errorf("источники пересчитанного дня заменены целиком: %+v", sources)
+		}
+
+		cohorts, err := repo.ListCohorts(ctx, 10)
+		if err != nil {
+			t.Fatalf("ListCohorts: %v", err)
+		}
+		if len(cohorts) != 2 || !cohorts[0].Start.Equal(date(13)) || cohorts[1].Size != 5 ||
+			len(cohorts[1].Retained) != 2 || cohorts[1].Retained[1] != 2 {
+			t.Errorf("когорты: %+v", cohorts)
+		}
+	})
+
+	t.Run("настройки бизнеса", func(t *testing.T) {
+		repo := newRepo(t)
+		settings, err := repo.GetSettings(ctx)
+		if err != nil {
+			t.Fatalf("чтение: %v", err)
+		}
+		if *settings != *domain.DefaultBusinessSettings() {
+			t.Errorf("до сохранения ожидали значения по умолчанию: %+v", settings)
+		}
+
+		want := &domain.BusinessSettings{MasterName: "Мастер", NotifyChatID: 42, Timezone: "Europe/Moscow"}
+		for range 2 {
+			if err := repo.SaveSettings(ctx, want); err != nil {
+				t.Fatalf("сохранение: %v", err)
+			}
+		}
+		got, _ := repo.GetSettings(ctx)
+		if got.MasterName != want.MasterName || got.NotifyChatID != 42 || got.Timezone != want.Timezone || got.UpdatedAt.IsZero() {
+			t.Errorf("получили %+v, ожидали %+v", got, want)
+		}
+	})
+}
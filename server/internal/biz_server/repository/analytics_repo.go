@@ -0,0 +1,244 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"global_models/global_db"
+	"server/internal/domain"
+	"time"
+)
+
+// аналитика в Postgres
+
+// AnalyticsFacts возвращает исходные данные пересчёта с момента since
+func (r *bizDBRepository) AnalyticsFacts(ctx context.Context, since time.Time) (*domain.AnalyticsFacts, error) {
+	facts := &domain.AnalyticsFacts{Since: since}
+
+	// источник - параметр первого /start, время до связи - от появления до первого согласия (за всё время)
+	rows, err := r.Pool.Query(ctx, `
+        WITH first_contact AS (
+            SELECT telegram_user_id, MIN(created_at) AS at FROM callback_logs
+            WHERE callback_data = 'contacted_yes'
+            GROUP BY telegram_user_id
+        ), first_start AS (
+            SELECT DISTINCT ON (telegram_user_id) telegram_user_id, text FROM messages
+            WHERE direction = 'incoming' AND command_name = '/start'
+            ORDER BY telegram_user_id, created_at, id
+        )
+        SELECT u.telegram_id, u.created_at, COALESCE(s.text, ''), c.at
+        FROM users u
+        LEFT JOIN first_contact c ON c.telegram_user_id = u.telegram_id
+        LEFT JOIN first_start s ON s.telegram_user_id = u.telegram_id
+        WHERE u.created_at >= $1 OR c.at >= $1`, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load analytics users: %w", err)
+	}
+	for rows.Next() {
+		var user domain.AnalyticsUser
+		var startText string
+		if err := rows.Scan(&user.TelegramID, &user.CreatedAt, &startText, &user.FirstContactAt); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan analytics user: %w", err)
+		}
+		user.Source = domain.StartSource(startText)
+		facts.Users = append(facts.Users, user)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to load analytics users: %w", err)
+	}
+
+	rows, err = r.Pool.Query(ctx, `
+        SELECT telegram_user_id, 'start', created_at FROM messages
+        WHERE direction = 'incoming' AND command_name = '/start' AND created_at >= $1
+        UNION ALL
+        SELECT telegram_user_id, callback_data, created_at FROM callback_logs
+        WHERE callback_data IN ('lookup', 'contacted_yes') AND created_at >= $1`, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load funnel events: %w", err)
+	}
+	for rows.Next() {
+		var event domain.FunnelEvent
+		if err := rows.Scan(&event.TelegramID, &event.Step, &event.At); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan funnel event: %w", err)
+		}
+		facts.Events = append(facts.Events, event)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to load funnel events: %w", err)
+	}
+
+	// активность по часам: на сервисный слой уходит строка на пользователя в час, а не каждое сообщение
+	rows, err = r.Pool.Query(ctx, `
+        SELECT DISTINCT telegram_user_id, date_trunc('hour', created_at) FROM (
+            SELECT telegram_user_id, created_at FROM messages WHERE direction = 'incoming' AND created_at >= $1
+            UNION ALL
+            SELECT telegram_user_id, created_at FROM callback_logs WHERE created_at >= $1
+        ) activity`, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load user activity: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var activity domain.UserActivity
+		if err := rows.Scan(&activity.TelegramID, &activity.Hour); err != nil {
+			return nil, fmt.Errorf("failed to scan user activity: %w", err)
+		}
+		facts.Activity = append(facts.Activity, activity)
+	}
+	return facts, rows.Err()
+}
+
+// колонки агрегатов для COPY (порядок совпадает со строками в SaveAnalytics)
+var (
+	funnelColumns = []string{
+		"period", "period_start", "new_users", "active_users", "started", "lookups", "contacted_yes",
+		"contacts", "contact_time_avg_sec", "contact_time_median_sec", "computed_at",
+	}
+	sourceColumns    = []string{"period", "period_start", "source", "new_users", "lookups", "contacted_yes", "computed_at"}
+	retentionColumns = []string{"cohort_start", "week_offset", "cohort_size", "retained", "computed_at"}
+)
+
+// SaveAnalytics заменяет агрегаты пересчитанных периодов одной транзакцией
+// (читатели видят либо старые, либо новые агрегаты целиком)
+func (r *bizDBRepository) SaveAnalytics(ctx context.Context, snapshot *domain.AnalyticsSnapshot) error {
+	funnels := make([][]any, 0, len(snapshot.Funnels))
+	for _, f := range snapshot.Funnels {
+		funnels = append(funnels, []any{
+			string(f.Period), f.Start, f.NewUsers, f.ActiveUsers, f.Started, f.Lookups, f.ContactedYes,
+			f.Contacts, int64(f.ContactTimeAvg.Seconds()), int64(f.ContactTimeMedian.Seconds()), snapshot.ComputedAt,
+		})
+	}
+	sources := make([][]any, 0, len(snapshot.Sources))
+	for _, s := range snapshot.Sources {
+		sources = append(sources, []any{string(s.Period), s.Start, s.Source, s.NewUsers, s.Lookups, s.ContactedYes, snapshot.ComputedAt})
+	}
+	var retention [][]any
+	for _, c := range snapshot.Cohorts {
+		for offset, retained := range c.Retained {
+			retention = append(retention, []any{c.Start, offset, c.Size, retained, snapshot.ComputedAt})
+		}
+	}
+
+	return global_db.WithTx(ctx, r.Pool, global_db.TxOptions{}, func(ctx context.Context, tx global_db.Tx) error {
+		// пересчитанные периоды заменяются целиком: источник, пропавший из периода, тоже удаляется
+		for _, table := range []string{"analytics_funnel", "analytics_sources"} {
+			_, err := tx.Exec(ctx, `DELETE FROM `+table+`
+                WHERE (period = 'day' AND period_start >= $1) OR (period = 'week' AND period_start >= $2)`,
+				snapshot.DayFrom, snapshot.WeekFrom,
+			)
+			if err != nil {
+				return fmt.Errorf("failed to clear %s: %w", table, err)
+			}
+		}
+		if _, err := tx.Exec(ctx, `DELETE FROM analytics_retention WHERE cohort_start >= $1`, snapshot.WeekFrom); err != nil {
+			return fmt.Errorf("failed to clear analytics_retention: %w", err)
+		}
+
+		copies := []struct {
+			table   string
+			columns []string
+			rows    [][]any
+		}{
+			{"analytics_funnel", funnelColumns, funnels},
+			{"analytics_sources", sourceColumns, sources},
+			{"analytics_retention", retentionColumns, retention},
+		}
+		for _, c := range copies {
+			if len(c.rows) == 0 {
+				continue
+			}
+			if _, err := tx.CopyFrom(ctx, c.table, c.columns, c.rows); err != nil {
+				return fmt.Errorf("failed to save %s: %w", c.table, err)
+			}
+		}
+		return nil
+	})
+}
+
+// ListFunnelStats возвращает воронки последних limit периодов
+func (r *bizDBRepository) ListFunnelStats(ctx context.Context, period domain.AnalyticsPeriod, limit int) ([]*domain.FunnelStats, error) {
+	rows, err := r.Pool.Query(ctx, `
+        SELECT period_start, new_users, active_users, started, lookups, contacted_yes,
+            contacts, contact_time_avg_sec, contact_time_median_sec
+        FROM analytics_funnel
+        WHERE period = $1
+        ORDER BY period_start DESC
+        LIMIT $2`, string(period), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list funnel stats: %w", err)
+	}
+	defer rows.Close()
+
+	var funnels []*domain.FunnelStats
+	for rows.Next() {
+		f := &domain.FunnelStats{Period: period}
+		var avg, median int64
+		err := rows.Scan(&f.Start, &f.NewUsers, &f.ActiveUsers, &f.Started, &f.Lookups, &f.ContactedYes, &f.Contacts, &avg, &median)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan funnel stats: %w", err)
+		}
+		f.ContactTimeAvg, f.ContactTimeMedian = time.Duration(avg)*time.Second, time.Duration(median)*time.Second
+		funnels = append(funnels, f)
+	}
+	return funnels, rows.Err()
+}
+
+// ListSourceStats возвращает источники последних limit периодов
+func (r *bizDBRepository) ListSourceStats(ctx context.Context, period domain.AnalyticsPeriod, limit int) ([]*domain.SourceStats, error) {
+	rows, err := r.Pool.Query(ctx, `
+        SELECT period_start, source, new_users, lookups, contacted_yes
+        FROM analytics_sources
+        WHERE period = $1 AND period_start IN (
+            SELECT DISTINCT period_start FROM analytics_sources WHERE period = $1
+            ORDER BY period_start DESC LIMIT $2
+        )
+        ORDER BY period_start DESC, new_users DESC, source`, string(period), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list source stats: %w", err)
+	}
+	defer rows.Close()
+
+	var sources []*domain.SourceStats
+	for rows.Next() {
+		s := &domain.SourceStats{Period: period}
+		if err := rows.Scan(&s.Start, &s.Source, &s.NewUsers, &s.Lookups, &s.ContactedYes); err != nil {
+			return nil, fmt.Errorf("failed to scan source stats: %w", err)
+		}
+		sources = append(sources, s)
+	}
+	return sources, rows.Err()
+}
+
+// ListCohorts возвращает последние limit когорт
+func (r *bizDBRepository) ListCohorts(ctx context.Context, limit int) ([]*domain.CohortStats, error) {
+	rows, err := r.Pool.Query(ctx, `
+        SELECT cohort_start, cohort_size, retained
+        FROM analytics_retention
+        WHERE cohort_start IN (
+            SELECT DISTINCT cohort_start FROM analytics_retention ORDER BY cohort_start DESC LIMIT $1
+        )
+        ORDER BY cohort_start DESC, week_offset`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cohorts: %w", err)
+	}
+	defer rows.Close()
+
+	var cohorts []*domain.CohortStats
+	for rows.Next() {
+		var start time.Time
+		var size, retained int64
+		if err := rows.Scan(&start, &size, &retained); err != nil {
+			return nil, fmt.Errorf("failed to scan cohort: %w", err)
+		}
+		// строки когорты идут подряд по week_offset
+		if n := len(cohorts); n == 0 || !cohorts[n-1].Start.Equal(start) {
+			cohorts = append(cohorts, &domain.CohortStats{Start: start, Size: size})
+		}
+		last := cohorts[len(cohorts)-1]
+		last.Retained = append(last.Retained, retained)
+	}
+	return cohorts, rows.Err()
+}
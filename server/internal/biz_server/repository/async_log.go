@@ -0,0 +1,266 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"pkg/configs"
+	"pkg/metrics"
+	"server/internal/domain"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	ErrLogQueueFull = errors.New("message log queue is full")
+	ErrLogClosed    = errors.New("message log is closed")
+)
+
+// метрики фоновой записи журнала (kind - messages / callbacks)
+var (
+	logRecords = metrics.MustRegister(prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "server_message_log_records_total",
+		Help: "Message log records by outcome: written to the database or dropped.",
+	}, []string{"kind", "result"}))
+
+	logQueueDepth = metrics.MustRegister(prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "server_message_log_queue_depth",
+		Help: "Message log records waiting in the in-memory buffer.",
+	}, []string{"kind"}))
+)
+
+// AsyncLogRepository пишет журнал сообщений и колбэков в фоне пачками, не задерживая ответ пользователю.
+// Остальные методы (пользователи) уходят в inner без изменений.
+// Save/SaveCallback не заполняют ID: запись попадает в БД позже, при следующей записи пачки
+type AsyncLogRepository struct {
+	Repositories
+
+	messages  *batchWriter[*domain.Message]
+	callbacks *batchWriter[*domain.CallbackLog]
+}
+
+// конструктор: запускает фоновую запись журнала в inner
+func NewAsyncLogRepository(inner Repositories, conf *configs.MessageLogConfig) *AsyncLogRepository {
+	if conf == nil {
+		conf = configs.UseDefaultMessageLogConfig()
+	}
+	return &AsyncLogRepository{
+		Repositories: inner,
+		messages:     newBatchWriter("messages", inner.SaveMessages, conf),
+		callbacks:    newBatchWriter("callbacks", inner.SaveCallbacks, conf),
+	}
+}
+
+// метод ставит сообщение в очередь записи (время сообщения фиксируется сейчас, а не при записи пачки)
+func (r *AsyncLogRepository) Save(ctx context.Context, message *domain.Message) error {
+	if message.CreatedAt.IsZero() {
+		message.CreatedAt = time.Now()
+	}
+	queued := *message
+	return r.messages.enqueue(ctx, &queued)
+}
+
+// метод ставит колбэк в очередь записи
+func (r *AsyncLogRepository) SaveCallback(ctx context.Context, callback *domain.CallbackLog) error {
+	if callback.Timestamp.IsZero() {
+		callback.Timestamp = time.Now()
+	}
+	queued := *callback
+	return r.callbacks.enqueue(ctx, &queued)
+}
+
+// метод для освобождения ресурсов: дописывает в БД всё, что накопилось в буфере
+// (вызывается до закрытия пула соединений)
+func (r *AsyncLogRepository) Close(ctx context.Context) error {
+	return errors.Join(r.messages.close(ctx), r.callbacks.close(ctx))
+}
+
+// batchWriter - ограниченная очередь записей и фоновая запись её пачками:
+// при наборе batchSize записей или по интервалу. Ошибка записи повторяется до maxRetries раз,
+// затем пачка отбрасывается (журнал не должен останавливать обработку update)
+type batchWriter[T any] struct {
+	kind  string
+	write func(ctx context.Context, batch []T) error
+	conf  *configs.MessageLogConfig
+
+	queue chan T
+	// enqueue держит RLock на время отправки в queue, close берёт Lock после закрытия stop:
+	// так после close в queue не попадёт запись, которую уже никто не дочитает
+	sending sync.RWMutex
+
+	rest      []T // записи, оставшиеся после остановки цикла (дописываются в close)
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// конструктор: запускает фоновую запись
+func newBatchWriter[T any](kind string, write func(ctx context.Context, batch []T) error, conf *configs.MessageLogConfig) *batchWriter[T] {
+	w := &batchWriter[T]{
+		kind:  kind,
+		write: write,
+		conf:  conf,
+		queue: make(chan T, max(conf.QueueSize, 1)),
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+	logQueueDepth.WithLabelValues(kind).Set(0)
+	go w.run()
+	return w
+}
+
+// метод ставит запись в очередь. Если буфер заполнен - ждёт места не дольше EnqueueTimeout,
+// потом запись отбрасывается с ErrLogQueueFull (обработчик update не блокируется надолго)
+func (w *batchWriter[T]) enqueue(ctx context.Context, item T) error {
+	w.sending.RLock()
+	defer w.sending.RUnlock()
+
+	select {
+	case <-w.stop:
+		return ErrLogClosed
+	default:
+	}
+
+	select {
+	case w.queue <- item:
+		w.observeDepth()
+		return nil
+	default:
+	}
+
+	timer := time.NewTimer(w.conf.EnqueueTimeout)
+	defer timer.Stop()
+
+	select {
+	case w.queue <- item:
+		w.observeDepth()
+		return nil
+	case <-timer.C:
+		logRecords.WithLabelValues(w.kind, "dropped").Inc()
+		return ErrLogQueueFull
+	case <-ctx.Done():
+		logRecords.WithLabelValues(w.kind, "dropped").Inc()
+		return ctx.Err()
+	case <-w.stop:
+		return ErrLogClosed
+	}
+}
+
+// фоновый цикл записи
+func (w *batchWriter[T]) run() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.conf.FlushInterval)
+	defer ticker.Stop()
+
+	batchSize := max(w.conf.BatchSize, 1)
+	batch := make([]T, 0, batchSize)
+	failures := 0
+
+	for {
+		if len(batch) >= batchSize {
+			if !w.flush(&batch, &failures) {
+				// БД недоступна - не крутим повторы вхолостую, ждём интервал
+				select {
+				case <-w.stop:
+					w.rest = w.drain(batch)
+					return
+				case <-ticker.C:
+				}
+			}
+			continue
+		}
+
+		select {
+		case item := <-w.queue:
+			batch = append(batch, item)
+			w.observeDepth()
+		case <-ticker.C:
+			if len(batch) > 0 {
+				w.flush(&batch, &failures)
+			}
+		case <-w.stop:
+			w.rest = w.drain(batch)
+			return
+		}
+	}
+}
+
+// метод пишет пачку. false - запись не удалась и пачку нужно повторить
+// (после maxRetries неудач пачка отбрасывается и метод возвращает true)
+func (w *batchWriter[T]) flush(batch *[]T, failures *int) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), w.conf.FlushTimeout)
+	err := w.write(ctx, *batch)
+	cancel()
+
+	if err == nil {
+		logRecords.WithLabelValues(w.kind, "written").Add(float64(len(*batch)))
+		*batch = (*batch)[:0]
+		*failures = 0
+		return true
+	}
+
+	*failures++
+	if *failures < w.conf.MaxRetries {
+		slog.Warn("failed to write message log batch, will retry",
+			"kind", w.kind, "records", len(*batch), "attempt", *failures, "error", err)
+		return false
+	}
+
+	slog.Error("message log batch dropped after retries",
+		"kind", w.kind, "records", len(*batch), "attempts", *failures, "error", err)
+	logRecords.WithLabelValues(w.kind, "dropped").Add(float64(len(*batch)))
+	*batch = (*batch)[:0]
+	*failures = 0
+	return true
+}
+
+// метод забирает из очереди всё, что в ней осталось
+func (w *batchWriter[T]) drain(batch []T) []T {
+	for {
+		select {
+		case item := <-w.queue:
+			batch = append(batch, item)
+		default:
+			w.observeDepth()
+			return batch
+		}
+	}
+}
+
+// метод останавливает фоновую запись и дописывает остаток пачками (в пределах ctx)
+func (w *batchWriter[T]) close(ctx context.Context) error {
+	var errs []error
+	w.closeOnce.Do(func() {
+		close(w.stop)
+		// ждём отправки, начатые до закрытия stop (ожидающие места в буфере выходят по stop)
+		w.sending.Lock()
+		w.sending.Unlock()
+		<-w.done
+		// цикл мог остановиться раньше, чем эти отправки попали в очередь
+		w.rest = w.drain(w.rest)
+
+		batchSize := max(w.conf.BatchSize, 1)
+		for rest := w.rest; len(rest) > 0; {
+			n := min(len(rest), batchSize)
+			if err := w.write(ctx, rest[:n]); err != nil {
+				slog.ErrorContext(ctx, "failed to write message log on shutdown",
+					"kind", w.kind, "records", len(rest), "error", err)
+				logRecords.WithLabelValues(w.kind, "dropped").Add(float64(len(rest)))
+				errs = append(errs, err)
+				break
+			}
+			logRecords.WithLabelValues(w.kind, "written").Add(float64(n))
+			rest = rest[n:]
+		}
+		w.rest = nil
+	})
+	return errors.Join(errs...)
+}
+
+// метод обновляет метрику длины очереди
+func (w *batchWriter[T]) observeDepth() {
+	logQueueDepth.WithLabelValues(w.kind).Set(float64(len(w.queue)))
+}
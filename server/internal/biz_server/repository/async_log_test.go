@@ -0,0 +1,198 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"pkg/configs"
+	"server/internal/domain"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingLog запоминает записанные пачки сообщений (можно сломать запись или задержать её)
+type recordingLog struct {
+	Repositories
+
+	mu      sync.Mutex
+	batches [][]*domain.Message
+	fails   int           // сколько следующих записей вернут ошибку
+	block   chan struct{} // если задан - запись ждёт, пока канал не закроют
+}
+
+func (r *recordingLog) SaveMessages(ctx context.Context, messages []*domain.Message) error {
+	if r.block != nil {
+		<-r.block
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.fails > 0 {
+		r.fails--
+		return errors.New("database is unavailable")
+	}
+	r.batches = append(r.batches, append([]*domain.Message(nil), messages...))
+	return nil
+}
+
+func (r *recordingLog) written() (batches, messages int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, batch := range r.batches {
+		messages += len(batch)
+	}
+	return len(r.batches), messages
+}
+
+func testMessageLogConfig() *configs.MessageLogConfig {
+	return &configs.MessageLogConfig{
+		Async:          true,
+		QueueSize:      100,
+		BatchSize:      10,
+		FlushInterval:  time.Hour,
+		EnqueueTimeout: 10 * time.Millisecond,
+		FlushTimeout:   time.Second,
+		MaxRetries:     3,
+	}
+}
+
+func TestAsyncLogRepository(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("сообщения пишутся пачками по размеру", func(t *testing.T) {
+		store := &recordingLog{Repositories: NewMemoryRepository()}
+		repo := NewAsyncLogRepository(store, testMessageLogConfig())
+
+		for i := range 25 {
+			if err := repo.Save(ctx, testMessage(10, int64(i+1), "текст")); err != nil {
+				t.Fatalf("Save: %v", err)
+			}
+		}
+
+		deadline := time.Now().Add(time.Second)
+		for batches, _ := store.written(); batches < 2 && time.Now().Before(deadline); batches, _ = store.written() {
+			time.Sleep(5 * time.Millisecond)
+		}
+		if batches, messages := store.written(); batches != 2 || messages != 20 {
+			t.Fatalf("до остановки записано %d пачек, %d сообщений; ожидали 2 и 20", batches, messages)
+		}
+
+		if err := repo.Close(ctx); err != nil {
+			t.Fatalf("Close: %v", err)
+		}
+		if _, messages := store.written(); messages != 25 {
+			t.Errorf("после Close записано %d сообщений, ожидали 25", messages)
+		}
+	})
+
+	t.Run("время сообщения фиксируется при постановке в очередь", func(t *testing.T) {
+		store := &recordingLog{Repositories: NewMemoryRepository()}
+		repo := NewAsyncLogRepository(store, testMessageLogConfig())
+
+		msg := &domain.Message{ChatID: 10, Text: "ответ", Direction: "outgoing"}
+		before := time.Now()
+		repo.Save(ctx, msg)
+		repo.Close(ctx)
+
+		if store.batches[0][0].CreatedAt.Before(before) || store.batches[0][0].CreatedAt.After(time.Now()) {
+			t.Errorf("время сообщения %v не совпадает со временем постановки", store.batches[0][0].CreatedAt)
+		}
+	})
+
+	t.Run("ошибка записи повторяется", func(t *testing.T) {
+		store := &recordingLog{Repositories: NewMemoryRepository(), fails: 2}
+		conf := testMessageLogConfig()
+		conf.FlushInterval = 5 * time.Millisecond
+		repo := NewAsyncLogRepository(store, conf)
+		defer repo.Close(ctx)
+
+		repo.Save(ctx, testMessage(10, 1, "текст"))
+
+		deadline := time.Now().Add(time.Second)
+		for _, messages := store.written(); messages == 0 && time.Now().Before(deadline); _, messages = store.written() {
+			time.Sleep(5 * time.Millisecond)
+		}
+		if _, messages := store.written(); messages != 1 {
+			t.Error("сообщение потеряно после ошибок записи")
+		}
+	})
+
+	t.Run("заполненный буфер не блокирует обработчик", func(t *testing.T) {
+		store := &recordingLog{Repositories: NewMemoryRepository(), block: make(chan struct{})}
+		conf := testMessageLogConfig()
+		conf.QueueSize, conf.BatchSize = 2, 1
+		repo := NewAsyncLogRepository(store, conf)
+
+		// первая запись занимает фоновую запись, следующие две - весь буфер
+		var err error
+		start := time.Now()
+		for i := range 10 {
+			if err = repo.Save(ctx, testMessage(10, int64(i+1), "текст")); err != nil {
+				break
+			}
+		}
+		if !errors.Is(err, ErrLogQueueFull) {
+			t.Errorf("ожидали ErrLogQueueFull, получили %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("обработчик ждал буфер %v", elapsed)
+		}
+
+		close(store.block)
+		if err := repo.Close(ctx); err != nil {
+			t.Fatalf("Close: %v", err)
+		}
+		if err := repo.Save(ctx, testMessage(10, 100, "текст")); !errors.Is(err, ErrLogClosed) {
+			t.Errorf("после Close ожидали ErrLogClosed, получили %v", err)
+		}
+	})
+
+	t.Run("принятые до Close записи не теряются при одновременной остановке", func(t *testing.T) {
+		for range 20 {
+			store := &recordingLog{Repositories: NewMemoryRepository()}
+			repo := NewAsyncLogRepository(store, testMessageLogConfig())
+
+			var wg sync.WaitGroup
+			var accepted sync.Map
+			for i := range 50 {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					if repo.Save(ctx, testMessage(10, int64(i+1), "текст")) == nil {
+						accepted.Store(i, true)
+					}
+				}()
+			}
+			if err := repo.Close(ctx); err != nil {
+				t.Fatalf("Close: %v", err)
+			}
+			wg.Wait()
+
+			want := 0
+			accepted.Range(func(any, any) bool { want++; return true })
+			if _, got := store.written(); got != want {
+				t.Fatalf("принято %d записей, записано %d", want, got)
+			}
+		}
+	})
+
+	t.Run("колбэки и пользователи идут в хранилище", func(t *testing.T) {
+		store := NewMemoryRepository()
+		repo := NewAsyncLogRepository(store, testMessageLogConfig())
+
+		if err := repo.CreateUser(ctx, testUser(1)); err != nil {
+			t.Fatalf("CreateUser: %v", err)
+		}
+		if err := repo.SaveCallback(ctx, testCallback("cb-1", 10, 1)); err != nil {
+			t.Fatalf("SaveCallback: %v", err)
+		}
+		if err := repo.Close(ctx); err != nil {
+			t.Fatalf("Close: %v", err)
+		}
+
+		dup := testCallback("cb-1", 10, 1)
+		store.SaveCallback(ctx, dup)
+		if dup.ID != 0 {
+			t.Error("колбэк не записан при Close")
+		}
+	})
+}
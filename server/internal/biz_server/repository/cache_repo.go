@@ -1,8 +1,14 @@
 package repository
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"global_models/global_cache"
+	"server/internal/domain"
+	"strconv"
+	"time"
 )
 
 // создаём репозиторий кэша (тут редис) на базе глобального интерфейса
@@ -29,3 +35,69 @@ func NewBizCacheRepo(cache global_cache.Cache, prefix string) (*bizCacheReposito
 		prefix:     prefix,
 	}, nil
 }
+
+// метод для формирования ключа пользователя: <prefix>:user:<telegram_id>
+func (r *bizCacheRepository) userKey(telegramID int64) string {
+	return r.prefix + ":user:" + strconv.FormatInt(telegramID, 10)
+}
+
+// метод для чтения пользователя из кэша (при промахе - global_cache.ErrNotFound)
+func (r *bizCacheRepository) getUser(ctx context.Context, telegramID int64) (*domain.User, error) {
+	data, err := r.blackCache.GetBytes(ctx, r.userKey(telegramID))
+	if err != nil {
+		return nil, err
+	}
+
+	user := &domain.User{}
+	if err := json.Unmarshal(data, user); err != nil {
+		return nil, fmt.Errorf("failed to decode cached user: %w", err)
+	}
+	return user, nil
+}
+
+// метод для записи пользователя в кэш
+func (r *bizCacheRepository) setUser(ctx context.Context, user *domain.User, ttl time.Duration) error {
+	data, err := json.Marshal(user)
+	if err != nil {
+		return fmt.Errorf("failed to encode user: %w", err)
+	}
+	return r.blackCache.Set(ctx, r.userKey(user.TelegramID), data, ttl)
+}
+
+// метод для удаления пользователя из кэша (после изменения в БД)
+func (r *bizCacheRepository) deleteUser(ctx context.Context, telegramID int64) error {
+	return r.blackCache.Delete(ctx, r.userKey(telegramID))
+}
+
+// метод для чтения пользователя через загрузчик кэша (одна загрузка из БД на ключ, отсутствие пользователя запоминается).
+// ok = false - кэш не умеет загружать (обычный redis), читать нужно через getUser/setUser
+func (r *bizCacheRepository) loadUser(ctx context.Context, telegramID int64, ttl time.Duration,
+	load func(ctx context.Context) (*domain.User, error)) (user *domain.User, ok bool, err error) {
+	loader, ok := r.blackCache.(global_cache.Loader)
+	if !ok {
+		return nil, false, nil
+	}
+
+	data, err := loader.GetOrLoad(ctx, r.userKey(telegramID), ttl, func(ctx context.Context) ([]byte, error) {
+		user, err := load(ctx)
+		if errors.Is(err, ErrUserNotFound) {
+			return nil, global_cache.ErrNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(user)
+	})
+	if errors.Is(err, global_cache.ErrNotFound) {
+		return nil, true, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, true, err
+	}
+
+	user = &domain.User{}
+	if err := json.Unmarshal(data, user); err != nil {
+		return nil, true, fmt.Errorf("failed to decode cached user: %w", err)
+	}
+	return user, true, nil
+}
@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"server/internal/domain"
+)
+
+// тексты бота, изменённые в админке, в Postgres
+
+// ListContent возвращает изменённые тексты
+func (r *bizDBRepository) ListContent(ctx context.Context) (map[domain.ContentKey]domain.ContentItem, error) {
+	rows, err := r.Pool.Query(ctx, `SELECT key, text, updated_at FROM bot_content`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list content: %w", err)
+	}
+	defer rows.Close()
+
+	content := make(map[domain.ContentKey]domain.ContentItem)
+	for rows.Next() {
+		var item domain.ContentItem
+		var key string
+		if err := rows.Scan(&key, &item.Text, &item.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan content: %w", err)
+		}
+		item.Key = domain.ContentKey(key)
+		content[item.Key] = item
+	}
+	return content, rows.Err()
+}
+
+// SaveContent сохраняет текст (пустой текст - удаляет изменение)
+func (r *bizDBRepository) SaveContent(ctx context.Context, key domain.ContentKey, text string) error {
+	var err error
+	if text == "" {
+		_, err = r.Pool.Exec(ctx, `DELETE FROM bot_content WHERE key = $1`, string(key))
+	} else {
+		_, err = r.Pool.Exec(ctx, `
+            INSERT INTO bot_content (key, text, updated_at) VALUES ($1, $2, NOW())
+            ON CONFLICT (key) DO UPDATE SET text = EXCLUDED.text, updated_at = EXCLUDED.updated_at`,
+			string(key), text,
+		)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save content: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,500 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"os"
+	"pkg/configs"
+	"pkg/migrator"
+	postgresdb "pkg/postgres_db"
+	"server/internal/domain"
+	"server/migrations"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// DSN тестовой БД для прогона контракта на Postgres (без неё проверяется только реализация в памяти).
+// ВНИМАНИЕ: таблицы очищаются перед каждым тестом - используйте отдельную БД
+const testPostgresDSNEnv = "TEST_POSTGRES_DSN"
+
+// фабрика чистого хранилища для одного теста
+type repoFactory func(t *testing.T) Repositories
+
+func TestMemoryRepositoryContract(t *testing.T) {
+	runContract(t, func(t *testing.T) Repositories {
+		return NewMemoryRepository()
+	})
+}
+
+// кэш и пакетная запись last_seen_at не должны менять наблюдаемое поведение хранилища
+func TestCachedRepositoryContract(t *testing.T) {
+	runContract(t, func(t *testing.T) Repositories {
+		return newTestBizRepository(t, NewMemoryRepository(), newMapCache())
+	})
+}
+
+func TestPostgresRepositoryContract(t *testing.T) {
+	dsn := os.Getenv(testPostgresDSNEnv)
+	if dsn == "" {
+		t.Skipf("%s не задан - контракт на Postgres пропущен", testPostgresDSNEnv)
+	}
+
+	ctx := context.Background()
+
+	// схема - из тех же миграций, что и в проде
+	m, err := migrator.New(dsn, migrations.FS, configs.UseDefaultMigrationsConfig())
+	if err != nil {
+		t.Fatalf("migrator: %v", err)
+	}
+	defer m.Close()
+	if err := m.Up(ctx); err != nil {
+		t.Fatalf("migrations: %v", err)
+	}
+
+	pool, err := pgxpool.Connect(ctx, dsn)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer pool.Close()
+	adapter := postgresdb.NewPoolAdapter(pool)
+
+	truncate := func(t *testing.T) {
+		if _, err := adapter.Exec(ctx, `TRUNCATE users, messages, callback_logs RESTART IDENTITY CASCADE`); err != nil {
+			t.Fatalf("truncate: %v", err)
+		}
+	}
+
+	t.Run("postgres", func(t *testing.T) {
+		runContract(t, func(t *testing.T) Repositories {
+			truncate(t)
+			return NewBizDBRepository(adapter)
+		})
+	})
+	t.Run("postgres с кэшем", func(t *testing.T) {
+		runContract(t, func(t *testing.T) Repositories {
+			truncate(t)
+			return newTestBizRepository(t, NewBizDBRepository(adapter), newMapCache())
+		})
+	})
+}
+
+// общий контракт хранилищ: любая реализация Repositories должна его проходить
+func runContract(t *testing.T, newRepo repoFactory) {
+	t.Run("пользователи", func(t *testing.T) { testUserContract(t, newRepo) })
+	t.Run("сообщения", func(t *testing.T) { testMessageContract(t, newRepo) })
+	t.Run("колбэки", func(t *testing.T) { testCallbackContract(t, newRepo) })
+}
+
+func testUserContract(t *testing.T, newRepo repoFactory) {
+	ctx := context.Background()
+
+	t.Run("неизвестный пользователь - ErrUserNotFound", func(t *testing.T) {
+		repo := newRepo(t)
+		if _, err := repo.GetUserByTelegramID(ctx, 404); !errors.Is(err, ErrUserNotFound) {
+			t.Errorf("ожидали ErrUserNotFound, получили %v", err)
+		}
+	})
+
+	t.Run("созданный пользователь читается целиком", func(t *testing.T) {
+		repo := newRepo(t)
+		user := testUser(1001)
+		user.LastName = "" // пустые поля сохраняются как пустые строки
+		if err := repo.CreateUser(ctx, user); err != nil {
+			t.Fatalf("CreateUser: %v", err)
+		}
+		if user.ID == 0 {
+			t.Fatal("ID не заполнен")
+		}
+
+		got, err := repo.GetUserByTelegramID(ctx, user.TelegramID)
+		if err != nil {
+			t.Fatalf("GetUserByTelegramID: %v", err)
+		}
+		assertUser(t, got, user)
+	})
+
+	t.Run("повторное создание - ErrUserAlreadyExists", func(t *testing.T) {
+		repo := newRepo(t)
+		if err := repo.CreateUser(ctx, testUser(1002)); err != nil {
+			t.Fatalf("CreateUser: %v", err)
+		}
+		if err := repo.CreateUser(ctx, testUser(1002)); !errors.Is(err, ErrUserAlreadyExists) {
+			t.Errorf("ожидали ErrUserAlreadyExists, получили %v", err)
+		}
+	})
+
+	t.Run("обновление меняет данные, но не дату создания", func(t *testing.T) {
+		repo := newRepo(t)
+		user := testUser(1003)
+		if err := repo.CreateUser(ctx, user); err != nil {
+			t.Fatalf("CreateUser: %v", err)
+		}
+
+		update := *user
+		update.ID = 0
+		update.Username = "renamed"
+		update.FirstName = "Новое имя"
+		update.CreatedAt = time.Now().Add(time.Hour)
+		update.LastSeenAt = user.LastSeenAt.Add(time.Minute)
+		if err := repo.Update(ctx, &update); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+		if update.ID != user.ID {
+			t.Errorf("Update вернул ID %d, ожидали %d", update.ID, user.ID)
+		}
+
+		got, err := repo.GetUserByTelegramID(ctx, user.TelegramID)
+		if err != nil {
+			t.Fatalf("GetUserByTelegramID: %v", err)
+		}
+		want := update
+		want.CreatedAt = user.CreatedAt
+		assertUser(t, got, &want)
+	})
+
+	t.Run("UpsertUser создаёт, затем обновляет профиль", func(t *testing.T) {
+		repo := newRepo(t)
+		user := testUser(1010)
+		created, err := repo.UpsertUser(ctx, user)
+		if err != nil {
+			t.Fatalf("UpsertUser: %v", err)
+		}
+		if !created || user.ID == 0 {
+			t.Fatalf("ожидали нового пользователя с ID, получили created=%v id=%d", created, user.ID)
+		}
+
+		again := testUser(1010)
+		again.Username = "renamed"
+		again.CreatedAt = user.CreatedAt.Add(time.Hour)
+		again.LastSeenAt = user.LastSeenAt.Add(-time.Hour) // запоздавший update не откатывает активность
+		created, err = repo.UpsertUser(ctx, again)
+		if err != nil {
+			t.Fatalf("UpsertUser: %v", err)
+		}
+		if created {
+			t.Error("существующий пользователь отмечен как новый")
+		}
+		if again.ID != user.ID || !sameTime(again.CreatedAt, user.CreatedAt) || !sameTime(again.LastSeenAt, user.LastSeenAt) {
+			t.Errorf("UpsertUser вернул %+v, ожидали id=%d created_at=%v last_seen_at=%v", again, user.ID, user.CreatedAt, user.LastSeenAt)
+		}
+
+		got, err := repo.GetUserByTelegramID(ctx, 1010)
+		if err != nil {
+			t.Fatalf("GetUserByTelegramID: %v", err)
+		}
+		if got.Username != "renamed" {
+			t.Errorf("профиль не обновлён: %+v", got)
+		}
+	})
+
+	t.Run("одновременное первое обращение создаёт одного пользователя", func(t *testing.T) {
+		repo := newRepo(t)
+		const workers = 16
+
+		var wg sync.WaitGroup
+		results := make(chan bool, workers)
+		ids := make(chan int64, workers)
+		for range workers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				user := testUser(1011)
+				created, err := repo.UpsertUser(ctx, user)
+				if err != nil {
+					t.Errorf("UpsertUser: %v", err)
+					return
+				}
+				results <- created
+				ids <- user.ID
+			}()
+		}
+		wg.Wait()
+		close(results)
+		close(ids)
+
+		createdCount := 0
+		for created := range results {
+			if created {
+				createdCount++
+			}
+		}
+		if createdCount != 1 {
+			t.Errorf("новым пользователь отмечен %d раз, ожидали 1", createdCount)
+		}
+
+		first := <-ids
+		for id := range ids {
+			if id != first {
+				t.Errorf("разные ID одного пользователя: %d и %d", first, id)
+			}
+		}
+	})
+
+	t.Run("обновление неизвестного - ErrUserNotFound", func(t *testing.T) {
+		repo := newRepo(t)
+		if err := repo.Update(ctx, testUser(1004)); !errors.Is(err, ErrUserNotFound) {
+			t.Errorf("ожидали ErrUserNotFound, получили %v", err)
+		}
+	})
+
+	t.Run("UpdateLastSeen сдвигает время активности", func(t *testing.T) {
+		repo := newRepo(t)
+		user := testUser(1005)
+		user.LastSeenAt = time.Now().Add(-time.Hour)
+		if err := repo.CreateUser(ctx, user); err != nil {
+			t.Fatalf("CreateUser: %v", err)
+		}
+		if err := repo.UpdateLastSeen(ctx, user.TelegramID); err != nil {
+			t.Fatalf("UpdateLastSeen: %v", err)
+		}
+
+		got, err := repo.GetUserByTelegramID(ctx, user.TelegramID)
+		if err != nil {
+			t.Fatalf("GetUserByTelegramID: %v", err)
+		}
+		if !got.LastSeenAt.After(user.LastSeenAt.Add(30 * time.Minute)) {
+			t.Errorf("last_seen_at не обновлён: %v", got.LastSeenAt)
+		}
+	})
+
+	t.Run("UpdateLastSeenBatch только сдвигает время вперёд", func(t *testing.T) {
+		repo := newRepo(t)
+		fresh, stale := testUser(1006), testUser(1007)
+		for _, u := range []*domain.User{fresh, stale} {
+			if err := repo.CreateUser(ctx, u); err != nil {
+				t.Fatalf("CreateUser: %v", err)
+			}
+		}
+
+		later := fresh.LastSeenAt.Add(time.Minute)
+		err := repo.UpdateLastSeenBatch(ctx, map[int64]time.Time{
+			fresh.TelegramID: later,
+			stale.TelegramID: stale.LastSeenAt.Add(-time.Minute),
+			404:              later, // неизвестный пользователь пропускается
+		})
+		if err != nil {
+			t.Fatalf("UpdateLastSeenBatch: %v", err)
+		}
+
+		got, _ := repo.GetUserByTelegramID(ctx, fresh.TelegramID)
+		if !sameTime(got.LastSeenAt, later) {
+			t.Errorf("last_seen_at = %v, ожидали %v", got.LastSeenAt, later)
+		}
+		got, _ = repo.GetUserByTelegramID(ctx, stale.TelegramID)
+		if !sameTime(got.LastSeenAt, stale.LastSeenAt) {
+			t.Errorf("last_seen_at откатился: %v, ожидали %v", got.LastSeenAt, stale.LastSeenAt)
+		}
+	})
+
+	t.Run("UpdateLastSeen для неизвестного - не ошибка", func(t *testing.T) {
+		repo := newRepo(t)
+		if err := repo.UpdateLastSeen(ctx, 404); err != nil {
+			t.Errorf("неожиданная ошибка: %v", err)
+		}
+	})
+}
+
+func testMessageContract(t *testing.T, newRepo repoFactory) {
+	ctx := context.Background()
+
+	t.Run("повтор (чат, сообщение) не создаёт дубль", func(t *testing.T) {
+		repo := newRepo(t)
+		first := testMessage(10, 1, "привет")
+		if err := repo.Save(ctx, first); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+		if first.ID == 0 {
+			t.Fatal("ID не заполнен")
+		}
+
+		edited := testMessage(10, 1, "привет, исправлено")
+		if err := repo.Save(ctx, edited); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+		if edited.ID != first.ID {
+			t.Errorf("повтор сохранён как новое сообщение: %d != %d", edited.ID, first.ID)
+		}
+	})
+
+	t.Run("тот же id сообщения в другом чате - другое сообщение", func(t *testing.T) {
+		repo := newRepo(t)
+		a, b := testMessage(10, 1, "a"), testMessage(11, 1, "b")
+		if err := repo.Save(ctx, a); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+		if err := repo.Save(ctx, b); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+		if a.ID == b.ID {
+			t.Error("сообщения разных чатов склеились")
+		}
+	})
+
+	t.Run("исходящие без id Telegram сохраняются все", func(t *testing.T) {
+		repo := newRepo(t)
+		ids := make(map[int64]bool)
+		for range 3 {
+			msg := &domain.Message{ChatID: 10, UserID: 20, Text: "ответ", Direction: "outgoing"}
+			if err := repo.Save(ctx, msg); err != nil {
+				t.Fatalf("Save: %v", err)
+			}
+			if msg.CreatedAt.IsZero() {
+				t.Error("время сообщения не заполнено")
+			}
+			ids[msg.ID] = true
+		}
+		if len(ids) != 3 {
+			t.Errorf("ожидали 3 разных сообщения, получили %d", len(ids))
+		}
+	})
+
+	t.Run("пачка с повтором сообщения и исходящими сохраняется", func(t *testing.T) {
+		repo := newRepo(t)
+		batch := []*domain.Message{
+			testMessage(12, 1, "первая версия"),
+			{ChatID: 12, UserID: 20, Text: "ответ", Direction: "outgoing"},
+			testMessage(12, 1, "вторая версия"),
+			{ChatID: 12, UserID: 20, Text: "ответ", Direction: "outgoing"},
+		}
+		if err := repo.SaveMessages(ctx, batch); err != nil {
+			t.Fatalf("SaveMessages: %v", err)
+		}
+		if err := repo.SaveMessages(ctx, nil); err != nil {
+			t.Errorf("пустая пачка: %v", err)
+		}
+	})
+
+	t.Run("пачка обновляет уже сохранённое сообщение", func(t *testing.T) {
+		repo := newRepo(t)
+		first := testMessage(13, 1, "привет")
+		if err := repo.Save(ctx, first); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+		if err := repo.SaveMessages(ctx, []*domain.Message{testMessage(13, 1, "привет, исправлено")}); err != nil {
+			t.Fatalf("SaveMessages: %v", err)
+		}
+
+		again := testMessage(13, 1, "привет, исправлено")
+		if err := repo.Save(ctx, again); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+		if again.ID != first.ID {
+			t.Errorf("пачка создала дубль сообщения: %d != %d", again.ID, first.ID)
+		}
+	})
+}
+
+func testCallbackContract(t *testing.T, newRepo repoFactory) {
+	ctx := context.Background()
+
+	t.Run("колбэк к сохранённому сообщению", func(t *testing.T) {
+		repo := newRepo(t)
+		if err := repo.Save(ctx, testMessage(10, 5, "меню")); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+		cb := testCallback("cb-1", 10, 5)
+		if err := repo.SaveCallback(ctx, cb); err != nil {
+			t.Fatalf("SaveCallback: %v", err)
+		}
+		if cb.ID == 0 {
+			t.Error("ID не заполнен")
+		}
+	})
+
+	t.Run("колбэк без сохранённого сообщения", func(t *testing.T) {
+		repo := newRepo(t)
+		if err := repo.SaveCallback(ctx, testCallback("cb-2", 10, 999)); err != nil {
+			t.Errorf("SaveCallback: %v", err)
+		}
+	})
+
+	t.Run("повтор callback_id - не ошибка и не новая запись", func(t *testing.T) {
+		repo := newRepo(t)
+		if err := repo.SaveCallback(ctx, testCallback("cb-3", 10, 1)); err != nil {
+			t.Fatalf("SaveCallback: %v", err)
+		}
+		dup := testCallback("cb-3", 10, 1)
+		if err := repo.SaveCallback(ctx, dup); err != nil {
+			t.Fatalf("повтор: %v", err)
+		}
+		if dup.ID != 0 {
+			t.Errorf("повтор получил новый ID %d", dup.ID)
+		}
+	})
+
+	t.Run("пачка колбэков с повтором callback_id", func(t *testing.T) {
+		repo := newRepo(t)
+		if err := repo.Save(ctx, testMessage(10, 6, "меню")); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+		batch := []*domain.CallbackLog{
+			testCallback("cb-4", 10, 6),
+			testCallback("cb-5", 10, 999),
+			testCallback("cb-4", 10, 6),
+		}
+		if err := repo.SaveCallbacks(ctx, batch); err != nil {
+			t.Fatalf("SaveCallbacks: %v", err)
+		}
+
+		dup := testCallback("cb-4", 10, 6)
+		if err := repo.SaveCallback(ctx, dup); err != nil {
+			t.Fatalf("повтор: %v", err)
+		}
+		if dup.ID != 0 {
+			t.Errorf("колбэк из пачки не сохранён: повтор получил ID %d", dup.ID)
+		}
+	})
+}
+
+func testUser(telegramID int64) *domain.User {
+	now := time.Now()
+	return &domain.User{
+		TelegramID: telegramID,
+		Username:   "user",
+		FirstName:  "Имя",
+		LastName:   "Фамилия",
+		IsActive:   true,
+		CreatedAt:  now,
+		LastSeenAt: now,
+	}
+}
+
+func testMessage(chatID, messageID int64, text string) *domain.Message {
+	return &domain.Message{
+		MessageID: messageID,
+		ChatID:    chatID,
+		UserID:    20,
+		Text:      text,
+		Direction: "incoming",
+		CreatedAt: time.Now(),
+	}
+}
+
+func testCallback(id string, chatID, messageID int64) *domain.CallbackLog {
+	return &domain.CallbackLog{
+		CallbackID: id,
+		UserID:     20,
+		ChatID:     chatID,
+		MessageID:  messageID,
+		Data:       "lookup",
+	}
+}
+
+// сравнение пользователей (Postgres хранит время с точностью до микросекунд)
+func assertUser(t *testing.T, got, want *domain.User) {
+	t.Helper()
+	if got.ID != want.ID || got.TelegramID != want.TelegramID || got.Username != want.Username ||
+		got.FirstName != want.FirstName || got.LastName != want.LastName || got.IsActive != want.IsActive {
+		t.Errorf("получили %+v, ожидали %+v", got, want)
+	}
+	if !sameTime(got.CreatedAt, want.CreatedAt) || !sameTime(got.LastSeenAt, want.LastSeenAt) {
+		t.Errorf("время: получили %v/%v, ожидали %v/%v", got.CreatedAt, got.LastSeenAt, want.CreatedAt, want.LastSeenAt)
+	}
+}
+
+func sameTime(a, b time.Time) bool {
+	return a.Sub(b).Abs() < time.Millisecond
+}
@@ -1,7 +1,14 @@
 package repository
 
 import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
 	"global_models/global_db"
+	"server/internal/domain"
+	"strings"
+	"time"
 )
 
 // создаём репозиторий базы данных для сервиса авторизации на базе адаптера к pgxpool
@@ -15,3 +22,465 @@ type bizDBRepository struct {
 func NewBizDBRepository(pool global_db.Pool) *bizDBRepository {
 	return &bizDBRepository{Pool: pool}
 }
+
+// сохраняет или обновляет данные в таблице meesges
+func (r *bizDBRepository) Save(ctx context.Context, message *domain.Message) error {
+	// Определяем является ли сообщение командой
+	isCommand, commandName := parseCommand(message.Text)
+
+	// время сообщения не задано (например, у исходящих) - сохраняем время записи
+	if message.CreatedAt.IsZero() {
+		message.CreatedAt = time.Now()
+	}
+
+	// Сохраняем сообщение
+	query := `
+        INSERT INTO messages (
+            telegram_message_id, telegram_chat_id, telegram_user_id,
+            text, direction, status, is_command, command_name, created_at, updated_at
+        ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+        ON CONFLICT (telegram_chat_id, telegram_message_id) 
+        DO UPDATE SET
+            text = EXCLUDED.text,
+            status = EXCLUDED.status,
+            updated_at = EXCLUDED.updated_at
+        RETURNING id
+    `
+
+	var id int64
+	err := r.Pool.QueryRow(ctx, query,
+		telegramMessageIDOf(message),
+		message.ChatID,
+		message.UserID,
+		message.Text,
+		message.Direction,
+		message.Status,
+		isCommand,
+		commandName,
+		message.CreatedAt,
+		message.CreatedAt, // created_at и updated_at
+	).Scan(&id)
+
+	if err != nil {
+		return fmt.Errorf("failed to save message: %w", err)
+	}
+
+	message.ID = id
+	return nil
+}
+
+// SaveCallback сохраняет колбэк и связывает с сообщением
+func (r *bizDBRepository) SaveCallback(ctx context.Context, callback *domain.CallbackLog) error {
+	var id int64
+	err := global_db.WithTx(ctx, r.Pool, global_db.TxOptions{}, func(ctx context.Context, tx global_db.Tx) error {
+		// Находим связанное сообщение (опционально)
+		var messageID *int64
+		err := tx.QueryRow(ctx, `
+            SELECT id FROM messages 
+            WHERE telegram_chat_id = $1 AND telegram_message_id = $2
+        `, callback.ChatID, callback.MessageID).Scan(&messageID)
+
+		if err != nil && !isNoRows(err) {
+			return fmt.Errorf("failed to find related message: %w", err)
+		}
+
+		// Сохраняем колбэк
+		query := `
+            INSERT INTO callback_logs (
+                callback_id, telegram_user_id, telegram_chat_id, 
+                telegram_message_id, callback_data, message_id, created_at
+            ) VALUES ($1, $2, $3, $4, $5, $6, $7)
+            ON CONFLICT (callback_id) DO NOTHING
+            RETURNING id
+        `
+
+		err = tx.QueryRow(ctx, query,
+			callback.CallbackID, callback.UserID, callback.ChatID,
+			callback.MessageID, callback.Data, messageID,
+			time.Now(),
+		).Scan(&id)
+
+		if isNoRows(err) {
+			// Колбэк уже существует, это нормально для повторных обработок
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to save callback: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	callback.ID = id
+	return nil
+}
+
+// колонки временной таблицы пачки сообщений (порядок совпадает со строками COPY)
+var messageBatchColumns = []string{
+	"telegram_message_id", "telegram_chat_id", "telegram_user_id",
+	"text", "direction", "status", "is_command", "command_name", "created_at", "updated_at",
+}
+
+// SaveMessages сохраняет пачку сообщений одной транзакцией: COPY во временную таблицу
+// и один INSERT ... ON CONFLICT из неё (message.ID не заполняется)
+func (r *bizDBRepository) SaveMessages(ctx context.Context, messages []*domain.Message) error {
+	messages = dedupMessages(messages)
+	if len(messages) == 0 {
+		return nil
+	}
+
+	rows := make([][]any, 0, len(messages))
+	for _, message := range messages {
+		if message.CreatedAt.IsZero() {
+			message.CreatedAt = time.Now()
+		}
+		isCommand, commandName := parseCommand(message.Text)
+		rows = append(rows, []any{
+			telegramMessageIDOf(message), message.ChatID, message.UserID,
+			message.Text, message.Direction, message.Status, isCommand, commandName,
+			message.CreatedAt, message.CreatedAt,
+		})
+	}
+
+	// временная таблица живёт до конца транзакции (ON COMMIT DROP), поэтому повтор создаёт её заново
+	return global_db.WithTx(ctx, r.Pool, global_db.TxOptions{}, func(ctx context.Context, tx global_db.Tx) error {
+		// колонки перечислены явно: LIKE messages потянул бы default id и тратил бы значения последовательности
+		_, err := tx.Exec(ctx, `
+            CREATE TEMP TABLE messages_batch (
+                telegram_message_id BIGINT,
+                telegram_chat_id BIGINT,
+                telegram_user_id BIGINT,
+                text TEXT,
+                direction VARCHAR(16),
+                status VARCHAR(16),
+                is_command BOOLEAN,
+                command_name TEXT,
+                created_at TIMESTAMPTZ,
+                updated_at TIMESTAMPTZ
+            ) ON COMMIT DROP
+        `)
+		if err != nil {
+			return fmt.Errorf("failed to create messages batch table: %w", err)
+		}
+
+		if _, err := tx.CopyFrom(ctx, "messages_batch", messageBatchColumns, rows); err != nil {
+			return fmt.Errorf("failed to copy messages: %w", err)
+		}
+
+		_, err = tx.Exec(ctx, `
+            INSERT INTO messages (
+                telegram_message_id, telegram_chat_id, telegram_user_id,
+                text, direction, status, is_command, command_name, created_at, updated_at
+            )
+            SELECT telegram_message_id, telegram_chat_id, telegram_user_id,
+                text, direction, status, is_command, command_name, created_at, updated_at
+            FROM messages_batch
+            ON CONFLICT (telegram_chat_id, telegram_message_id)
+            DO UPDATE SET
+                text = EXCLUDED.text,
+                status = EXCLUDED.status,
+                updated_at = EXCLUDED.updated_at
+        `)
+		if err != nil {
+			return fmt.Errorf("failed to save messages: %w", err)
+		}
+		return nil
+	})
+}
+
+// колонки временной таблицы пачки колбэков
+var callbackBatchColumns = []string{
+	"callback_id", "telegram_user_id", "telegram_chat_id",
+	"telegram_message_id", "callback_data", "created_at",
+}
+
+// SaveCallbacks сохраняет пачку колбэков одной транзакцией и связывает их с сообщениями
+// (повторы callback_id пропускаются, callback.ID не заполняется)
+func (r *bizDBRepository) SaveCallbacks(ctx context.Context, callbacks []*domain.CallbackLog) error {
+	callbacks = dedupCallbacks(callbacks)
+	if len(callbacks) == 0 {
+		return nil
+	}
+
+	rows := make([][]any, 0, len(callbacks))
+	for _, callback := range callbacks {
+		createdAt := callback.Timestamp
+		if createdAt.IsZero() {
+			createdAt = time.Now()
+		}
+		rows = append(rows, []any{
+			callback.CallbackID, callback.UserID, callback.ChatID,
+			callback.MessageID, callback.Data, createdAt,
+		})
+	}
+
+	// временная таблица живёт до конца транзакции (ON COMMIT DROP), поэтому повтор создаёт её заново
+	return global_db.WithTx(ctx, r.Pool, global_db.TxOptions{}, func(ctx context.Context, tx global_db.Tx) error {
+		_, err := tx.Exec(ctx, `
+            CREATE TEMP TABLE callback_logs_batch (
+                callback_id TEXT,
+                telegram_user_id BIGINT,
+                telegram_chat_id BIGINT,
+                telegram_message_id BIGINT,
+                callback_data TEXT,
+                created_at TIMESTAMPTZ
+            ) ON COMMIT DROP
+        `)
+		if err != nil {
+			return fmt.Errorf("failed to create callbacks batch table: %w", err)
+		}
+
+		if _, err := tx.CopyFrom(ctx, "callback_logs_batch", callbackBatchColumns, rows); err != nil {
+			return fmt.Errorf("failed to copy callbacks: %w", err)
+		}
+
+		_, err = tx.Exec(ctx, `
+            INSERT INTO callback_logs (
+                callback_id, telegram_user_id, telegram_chat_id,
+                telegram_message_id, callback_data, message_id, created_at
+            )
+            SELECT b.callback_id, b.telegram_user_id, b.telegram_chat_id,
+                b.telegram_message_id, b.callback_data, m.id, b.created_at
+            FROM callback_logs_batch b
+            LEFT JOIN messages m
+                ON m.telegram_chat_id = b.telegram_chat_id AND m.telegram_message_id = b.telegram_message_id
+            ON CONFLICT (callback_id) DO NOTHING
+        `)
+		if err != nil {
+			return fmt.Errorf("failed to save callbacks: %w", err)
+		}
+		return nil
+	})
+}
+
+// метод для создания и сохранения пользователя в базу
+func (r *bizDBRepository) CreateUser(ctx context.Context, user *domain.User) error {
+	query := `
+        INSERT INTO users (
+            telegram_id, username, first_name, last_name, 
+            is_active, created_at, last_seen_at
+        ) VALUES ($1, $2, $3, $4, $5, $6, $7)
+        ON CONFLICT (telegram_id) DO NOTHING
+        RETURNING id
+    `
+
+	err := r.Pool.QueryRow(ctx, query,
+		user.TelegramID,
+		user.Username,
+		user.FirstName,
+		user.LastName,
+		user.IsActive,
+		user.CreatedAt,
+		user.LastSeenAt,
+	).Scan(&user.ID)
+
+	if err != nil {
+		if isNoRows(err) {
+			// строка не вставлена - пользователь с таким telegram_id уже есть
+			return ErrUserAlreadyExists
+		}
+		return fmt.Errorf("failed to create user: %w", err)
+	}
+
+	return nil
+}
+
+// метод для атомарного создания или обновления пользователя одним запросом
+// (xmax = 0 только у только что вставленной строки - так узнаём, что пользователь новый)
+func (r *bizDBRepository) UpsertUser(ctx context.Context, user *domain.User) (bool, error) {
+	query := `
+        INSERT INTO users (
+            telegram_id, username, first_name, last_name,
+            is_active, created_at, last_seen_at
+        ) VALUES ($1, $2, $3, $4, $5, $6, $7)
+        ON CONFLICT (telegram_id) DO UPDATE SET
+            username = EXCLUDED.username,
+            first_name = EXCLUDED.first_name,
+            last_name = EXCLUDED.last_name,
+            is_active = EXCLUDED.is_active,
+            last_seen_at = GREATEST(users.last_seen_at, EXCLUDED.last_seen_at)
+        RETURNING id, created_at, last_seen_at, (xmax = 0) AS inserted
+    `
+
+	var created bool
+	err := r.Pool.QueryRow(ctx, query,
+		user.TelegramID,
+		user.Username,
+		user.FirstName,
+		user.LastName,
+		user.IsActive,
+		user.CreatedAt,
+		user.LastSeenAt,
+	).Scan(&user.ID, &user.CreatedAt, &user.LastSeenAt, &created)
+
+	if err != nil {
+		return false, fmt.Errorf("failed to upsert user: %w", err)
+	}
+
+	return created, nil
+}
+
+// метод для обновления пользователя в базе (вдруг данные в телеграмме поменялись)
+func (r *bizDBRepository) Update(ctx context.Context, user *domain.User) error {
+	query := `
+        UPDATE users SET
+            username = $2,
+            first_name = $3,
+            last_name = $4,
+            is_active = $5,
+            last_seen_at = $6
+        WHERE telegram_id = $1
+        RETURNING id
+    `
+
+	err := r.Pool.QueryRow(ctx, query,
+		user.TelegramID,
+		user.Username,
+		user.FirstName,
+		user.LastName,
+		user.IsActive,
+		user.LastSeenAt,
+	).Scan(&user.ID)
+
+	if err != nil {
+		if isNoRows(err) {
+			return ErrUserNotFound
+		}
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+
+	return nil
+}
+
+// метод для поиска пользователя по ID из телеграмма
+func (r *bizDBRepository) GetUserByTelegramID(ctx context.Context, telegramID int64) (*domain.User, error) {
+	query := `
+        SELECT id, telegram_id, username, first_name, last_name,
+               is_active, created_at, last_seen_at
+        FROM users
+        WHERE telegram_id = $1
+    `
+
+	user := &domain.User{}
+	var username, lastName sql.NullString
+
+	err := r.Pool.QueryRow(ctx, query, telegramID).Scan(
+		&user.ID,
+		&user.TelegramID,
+		&username,
+		&user.FirstName,
+		&lastName,
+		&user.IsActive,
+		&user.CreatedAt,
+		&user.LastSeenAt,
+	)
+
+	if err != nil {
+		if isNoRows(err) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	user.Username = username.String
+	user.LastName = lastName.String
+
+	return user, nil
+}
+
+// метод обновления времени последнего посещения пользователем по ID из телеграмм
+func (r *bizDBRepository) UpdateLastSeen(ctx context.Context, telegramID int64) error {
+	query := `UPDATE users SET last_seen_at = NOW() WHERE telegram_id = $1`
+
+	_, err := r.Pool.Exec(ctx, query, telegramID)
+	if err != nil {
+		return fmt.Errorf("failed to update last_seen: %w", err)
+	}
+
+	return nil
+}
+
+// метод пакетного обновления времени последнего посещения (одним запросом для всех пользователей).
+// Время только сдвигается вперёд - запоздавшая пачка не откатит более свежее значение
+func (r *bizDBRepository) UpdateLastSeenBatch(ctx context.Context, seen map[int64]time.Time) error {
+	if len(seen) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, 0, len(seen))
+	times := make([]time.Time, 0, len(seen))
+	for id, at := range seen {
+		ids = append(ids, id)
+		times = append(times, at)
+	}
+
+	query := `
+        UPDATE users AS u SET last_seen_at = v.seen_at
+        FROM unnest($1::bigint[], $2::timestamptz[]) AS v(telegram_id, seen_at)
+        WHERE u.telegram_id = v.telegram_id AND u.last_seen_at < v.seen_at
+    `
+
+	if _, err := r.Pool.Exec(ctx, query, ids, times); err != nil {
+		return fmt.Errorf("failed to update last_seen batch: %w", err)
+	}
+
+	return nil
+}
+
+// функция определяет, является ли текст командой, и возвращает её имя ("/start")
+func parseCommand(text string) (bool, string) {
+	if !strings.HasPrefix(text, "/") {
+		return false, ""
+	}
+	return true, strings.Split(text, " ")[0]
+}
+
+// у исходящих сообщений нет id от Telegram - пишем NULL, чтобы не нарушать уникальность (чат, сообщение)
+func telegramMessageIDOf(message *domain.Message) *int64 {
+	if message.MessageID == 0 {
+		return nil
+	}
+	return &message.MessageID
+}
+
+// функция оставляет последнюю версию каждого (чат, telegram_message_id) в пачке:
+// INSERT ... ON CONFLICT DO UPDATE не может изменить одну строку дважды
+func dedupMessages(messages []*domain.Message) []*domain.Message {
+	type key struct{ chatID, messageID int64 }
+	last := make(map[key]int, len(messages))
+	for i, message := range messages {
+		if message.MessageID != 0 {
+			last[key{message.ChatID, message.MessageID}] = i
+		}
+	}
+
+	result := make([]*domain.Message, 0, len(messages))
+	for i, message := range messages {
+		if message.MessageID != 0 && last[key{message.ChatID, message.MessageID}] != i {
+			continue
+		}
+		result = append(result, message)
+	}
+	return result
+}
+
+// функция оставляет первое нажатие каждого callback_id в пачке (повторы игнорируются, как в SaveCallback)
+func dedupCallbacks(callbacks []*domain.CallbackLog) []*domain.CallbackLog {
+	seen := make(map[string]struct{}, len(callbacks))
+	result := make([]*domain.CallbackLog, 0, len(callbacks))
+	for _, callback := range callbacks {
+		if _, ok := seen[callback.CallbackID]; ok {
+			continue
+		}
+		seen[callback.CallbackID] = struct{}{}
+		result = append(result, callback)
+	}
+	return result
+}
+
+// запрос не вернул строк (адаптер БД переводит ошибку драйвера в global_db.ErrNoRows)
+func isNoRows(err error) bool {
+	return errors.Is(err, global_db.ErrNoRows)
+}
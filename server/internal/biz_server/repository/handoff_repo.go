@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"global_models/global_db"
+	"server/internal/domain"
+	"time"
+)
+
+// диалоги с оператором в Postgres
+
+// колонки диалога (порядок совпадает со scanHandoff)
+const handoffColumns = `id, telegram_user_id, reason, opened_by, opened_at, last_activity_at, closed_at, close_reason`
+
+// функция чтения диалога из строки результата
+func scanHandoff(row global_db.Row, extra ...any) (*domain.Handoff, error) {
+	var handoff domain.Handoff
+	var reason, closeReason string
+	dest := append([]any{&handoff.ID, &handoff.TelegramID, &reason, &handoff.OpenedBy,
+		&handoff.OpenedAt, &handoff.LastActivityAt, &handoff.ClosedAt, &closeReason}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+	handoff.Reason = domain.HandoffReason(reason)
+	handoff.CloseReason = domain.HandoffCloseReason(closeReason)
+	return &handoff, nil
+}
+
+// OpenHandoff открывает диалог (или возвращает открытый диалог чата)
+func (r *bizDBRepository) OpenHandoff(ctx context.Context, handoff *domain.Handoff) (bool, error) {
+	created, err := scanHandoff(r.Pool.QueryRow(ctx, `
+        INSERT INTO handoffs (telegram_user_id, reason, opened_by)
+        VALUES ($1, $2, $3)
+        ON CONFLICT (telegram_user_id) WHERE closed_at IS NULL DO NOTHING
+        RETURNING `+handoffColumns,
+		handoff.TelegramID, string(handoff.Reason), handoff.OpenedBy,
+	))
+	if err == nil {
+		*handoff = *created
+		return true, nil
+	}
+	if !isNoRows(err) {
+		return false, fmt.Errorf("failed to open handoff: %w", err)
+	}
+
+	// открытый диалог уже есть
+	existing, err := r.GetOpenHandoff(ctx, handoff.TelegramID)
+	if err != nil {
+		return false, err
+	}
+	*handoff = *existing
+	return false, nil
+}
+
+// GetOpenHandoff возвращает открытый диалог чата
+func (r *bizDBRepository) GetOpenHandoff(ctx context.Context, telegramID int64) (*domain.Handoff, error) {
+	handoff, err := scanHandoff(r.Pool.QueryRow(ctx, `SELECT `+handoffColumns+` FROM handoffs
+        WHERE telegram_user_id = $1 AND closed_at IS NULL`, telegramID))
+	if isNoRows(err) {
+		return nil, ErrHandoffNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get open handoff: %w", err)
+	}
+	return handoff, nil
+}
+
+// ListOpenHandoffs возвращает открытые диалоги с профилями пользователей (сначала недавно активные)
+func (r *bizDBRepository) ListOpenHandoffs(ctx context.Context) ([]*domain.Handoff, error) {
+	rows, err := r.Pool.Query(ctx, `
+        SELECT h.id, h.telegram_user_id, h.reason, h.opened_by, h.opened_at, h.last_activity_at, h.closed_at, h.close_reason,
+            u.id IS NOT NULL, COALESCE(u.id, 0), COALESCE(u.username, ''), COALESCE(u.first_name, ''), COALESCE(u.last_name, ''),
+            COALESCE(u.is_active, FALSE), COALESCE(u.created_at, h.opened_at), COALESCE(u.last_seen_at, h.opened_at)
+        FROM handoffs h LEFT JOIN users u ON u.telegram_id = h.telegram_user_id
+        WHERE h.closed_at IS NULL
+        ORDER BY h.last_activity_at DESC, h.id DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list open handoffs: %w", err)
+	}
+	defer rows.Close()
+
+	var handoffs []*domain.Handoff
+	for rows.Next() {
+		var hasUser bool
+		var user domain.User
+		handoff, err := scanHandoff(rows, &hasUser, &user.ID, &user.Username, &user.FirstName, &user.LastName,
+			&user.IsActive, &user.CreatedAt, &user.LastSeenAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan handoff: %w", err)
+		}
+		if hasUser {
+			user.TelegramID = handoff.TelegramID
+			handoff.User = &user
+		}
+		handoffs = append(handoffs, handoff)
+	}
+	return handoffs, rows.Err()
+}
+
+// TouchHandoff сдвигает время последней активности открытого диалога
+func (r *bizDBRepository) TouchHandoff(ctx context.Context, telegramID int64, at time.Time) error {
+	_, err := r.Pool.Exec(ctx, `
+        UPDATE handoffs SET last_activity_at = $2
+        WHERE telegram_user_id = $1 AND closed_at IS NULL AND last_activity_at < $2`,
+		telegramID, at,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to touch handoff: %w", err)
+	}
+	return nil
+}
+
+// CloseHandoff закрывает открытый диалог чата
+func (r *bizDBRepository) CloseHandoff(ctx context.Context, telegramID int64, reason domain.HandoffCloseReason, at time.Time) (*domain.Handoff, error) {
+	handoff, err := scanHandoff(r.Pool.QueryRow(ctx, `
+        UPDATE handoffs SET closed_at = $2, close_reason = $3
+        WHERE telegram_user_id = $1 AND closed_at IS NULL
+        RETURNING `+handoffColumns,
+		telegramID, at, string(reason),
+	))
+	if isNoRows(err) {
+		return nil, ErrHandoffNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to close handoff: %w", err)
+	}
+	return handoff, nil
+}
+
+// CloseIdleHandoffs закрывает по таймауту диалоги без активности
+func (r *bizDBRepository) CloseIdleHandoffs(ctx context.Context, idleSince, at time.Time) ([]*domain.Handoff, error) {
+	rows, err := r.Pool.Query(ctx, `
+        UPDATE handoffs SET closed_at = $2, close_reason = 'timeout'
+        WHERE closed_at IS NULL AND last_activity_at < $1
+        RETURNING `+handoffColumns,
+		idleSince, at,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to close idle handoffs: %w", err)
+	}
+	defer rows.Close()
+
+	var closed []*domain.Handoff
+	for rows.Next() {
+		handoff, err := scanHandoff(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan handoff: %w", err)
+		}
+		closed = append(closed, handoff)
+	}
+	return closed, rows.Err()
+}
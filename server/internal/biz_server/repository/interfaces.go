@@ -0,0 +1,220 @@
+package repository
+
+import (
+	"context"
+	"server/internal/domain"
+	"time"
+)
+
+// интерфейсы слоя репозитория, от которых зависят сервисы
+// (хранилища: bizDBRepository - Postgres, MemoryRepository - в памяти для тестов;
+// BizRepository добавляет к любому из них кэш пользователей и пакетную запись last_seen_at,
+// AsyncLogRepository - фоновую пакетную запись журнала сообщений и колбэков)
+
+// проверки реализации интерфейсов
+var _ Repositories = (*BizRepository)(nil)
+var _ Repositories = (*bizDBRepository)(nil)
+var _ Repositories = (*MemoryRepository)(nil)
+var _ Repositories = (*AsyncLogRepository)(nil)
+var _ AdminRepositories = (*bizDBRepository)(nil)
+var _ AdminRepositories = (*MemoryRepository)(nil)
+var _ AdminAuthRepository = (*bizDBRepository)(nil)
+var _ AdminAuthRepository = (*MemoryRepository)(nil)
+
+// UserRepository - хранилище пользователей Telegram
+type UserRepository interface {
+	// CreateUser сохраняет нового пользователя и заполняет user.ID.
+	// Если пользователь с таким telegram_id уже есть - ErrUserAlreadyExists
+	CreateUser(ctx context.Context, user *domain.User) error
+
+	// UpsertUser атомарно создаёт пользователя или обновляет его профиль (по telegram_id).
+	// Заполняет user.ID, CreatedAt и LastSeenAt значениями из хранилища (last_seen_at не уходит назад).
+	// created - пользователь создан этим вызовом (при одновременном первом обращении true получит только один)
+	UpsertUser(ctx context.Context, user *domain.User) (created bool, err error)
+
+	// Update обновляет данные пользователя по telegram_id и заполняет user.ID.
+	// Если пользователя нет - ErrUserNotFound
+	Update(ctx context.Context, user *domain.User) error
+
+	// GetUserByTelegramID возвращает пользователя или ErrUserNotFound
+	GetUserByTelegramID(ctx context.Context, telegramID int64) (*domain.User, error)
+
+	// UpdateLastSeen обновляет время последней активности (для неизвестного пользователя - ничего не делает)
+	UpdateLastSeen(ctx context.Context, telegramID int64) error
+
+	// UpdateLastSeenBatch записывает время активности сразу нескольких пользователей.
+	// Время только сдвигается вперёд, неизвестные пользователи пропускаются
+	UpdateLastSeenBatch(ctx context.Context, seen map[int64]time.Time) error
+}
+
+// MessageRepository - хранилище входящих и исходящих сообщений
+type MessageRepository interface {
+	// Save сохраняет сообщение и заполняет message.ID.
+	// Повторное сохранение того же (чат, telegram_message_id) обновляет текст и статус, ID не меняется.
+	// Сообщения без telegram_message_id (исходящие) всегда сохраняются как новые
+	Save(ctx context.Context, message *domain.Message) error
+
+	// SaveMessages сохраняет пачку сообщений с теми же правилами, что и Save
+	// (при повторе одного сообщения в пачке остаётся последняя версия). message.ID может не заполняться
+	SaveMessages(ctx context.Context, messages []*domain.Message) error
+}
+
+// CallbackRepository - журнал нажатий inline кнопок
+type CallbackRepository interface {
+	// SaveCallback сохраняет нажатие и заполняет callback.ID.
+	// Повтор того же callback_id не является ошибкой и ничего не меняет (callback.ID остаётся 0)
+	SaveCallback(ctx context.Context, callback *domain.CallbackLog) error
+
+	// SaveCallbacks сохраняет пачку нажатий с теми же правилами, что и SaveCallback.
+	// callback.ID может не заполняться
+	SaveCallbacks(ctx context.Context, callbacks []*domain.CallbackLog) error
+}
+
+// Repositories - все хранилища сервера основной логики
+type Repositories interface {
+	UserRepository
+	MessageRepository
+	CallbackRepository
+}
+
+// AdminRepository - выборки для админки (читаются из хранилища напрямую, без кэша)
+type AdminRepository interface {
+	// ListUsers возвращает страницу пользователей (сначала недавно активные) и общее число найденных
+	ListUsers(ctx context.Context, filter domain.UserFilter) ([]*domain.User, int64, error)
+
+	// ListMessages возвращает переписку с пользователем: сначала новые, не больше filter.Limit
+	ListMessages(ctx context.Context, filter domain.MessageFilter) ([]*domain.Message, error)
+
+	// ListCallbacks возвращает страницу нажатий кнопок (сначала новые) и общее число найденных
+	ListCallbacks(ctx context.Context, filter domain.CallbackFilter) ([]*domain.CallbackLog, int64, error)
+
+	// DashboardStats возвращает сводку с момента since (заявки по статусам - за всё время)
+	DashboardStats(ctx context.Context, since time.Time) (*domain.DashboardStats, error)
+}
+
+// LeadRepository - заявки клиентов
+type LeadRepository interface {
+	// CreateLead создаёт заявку и заполняет lead. Если у пользователя уже есть открытая заявка,
+	// новая не создаётся: lead заполняется существующей, created = false
+	CreateLead(ctx context.Context, lead *domain.Lead) (created bool, err error)
+
+	// GetLead возвращает заявку (с профилем пользователя) или ErrLeadNotFound
+	GetLead(ctx context.Context, id int64) (*domain.Lead, error)
+
+	// ListLeads возвращает страницу заявок (сначала новые) с профилями пользователей и общее число найденных
+	ListLeads(ctx context.Context, filter domain.LeadFilter) ([]*domain.Lead, int64, error)
+
+	// UpdateLead меняет статус и заметку заявки по lead.ID и заполняет UpdatedAt.
+	// ErrLeadNotFound - заявки нет, ErrLeadConflict - заявка открывается, а у пользователя уже есть открытая
+	UpdateLead(ctx context.Context, lead *domain.Lead) error
+}
+
+// SettingsRepository - настройки бизнеса
+type SettingsRepository interface {
+	// GetSettings возвращает настройки (domain.DefaultBusinessSettings, если их ещё не сохраняли)
+	GetSettings(ctx context.Context) (*domain.BusinessSettings, error)
+
+	// SaveSettings сохраняет настройки целиком и заполняет UpdatedAt
+	SaveSettings(ctx context.Context, settings *domain.BusinessSettings) error
+}
+
+// ContentRepository - тексты бота, изменённые в админке (тексты по умолчанию не хранятся)
+type ContentRepository interface {
+	// ListContent возвращает изменённые тексты
+	ListContent(ctx context.Context) (map[domain.ContentKey]domain.ContentItem, error)
+
+	// SaveContent сохраняет текст, пустой текст возвращает текст по умолчанию
+	SaveContent(ctx context.Context, key domain.ContentKey, text string) error
+}
+
+// HandoffRepository - диалоги клиентов с оператором
+type HandoffRepository interface {
+	// OpenHandoff открывает диалог и заполняет handoff. Если у чата уже есть открытый диалог,
+	// новый не открывается: handoff заполняется существующим, created = false
+	OpenHandoff(ctx context.Context, handoff *domain.Handoff) (created bool, err error)
+
+	// GetOpenHandoff возвращает открытый диалог чата или ErrHandoffNotFound
+	GetOpenHandoff(ctx context.Context, telegramID int64) (*domain.Handoff, error)
+
+	// ListOpenHandoffs возвращает открытые диалоги с профилями пользователей (сначала недавно активные)
+	ListOpenHandoffs(ctx context.Context) ([]*domain.Handoff, error)
+
+	// TouchHandoff сдвигает время последней активности открытого диалога (нет диалога - ничего не делает)
+	TouchHandoff(ctx context.Context, telegramID int64, at time.Time) error
+
+	// CloseHandoff закрывает открытый диалог чата и возвращает его или ErrHandoffNotFound
+	// (из одновременных закрытий диалог вернёт только одно)
+	CloseHandoff(ctx context.Context, telegramID int64, reason domain.HandoffCloseReason, at time.Time) (*domain.Handoff, error)
+
+	// CloseIdleHandoffs закрывает по таймауту диалоги без активности с idleSince и возвращает их
+	CloseIdleHandoffs(ctx context.Context, idleSince, at time.Time) ([]*domain.Handoff, error)
+}
+
+// AnalyticsRepository - исходные данные аналитики и пересчитанные агрегаты
+type AnalyticsRepository interface {
+	// AnalyticsFacts возвращает пользователей, шаги воронки и активность для пересчёта с момента since
+	AnalyticsFacts(ctx context.Context, since time.Time) (*domain.AnalyticsFacts, error)
+
+	// SaveAnalytics заменяет агрегаты пересчитанных периодов (дневные с DayFrom, недельные и когорты с WeekFrom)
+	SaveAnalytics(ctx context.Context, snapshot *domain.AnalyticsSnapshot) error
+
+	// ListFunnelStats возвращает воронки последних limit периодов (сначала новые)
+	ListFunnelStats(ctx context.Context, period domain.AnalyticsPeriod, limit int) ([]*domain.FunnelStats, error)
+
+	// ListSourceStats возвращает источники последних limit периодов (сначала новые периоды, в периоде - по числу новых пользователей)
+	ListSourceStats(ctx context.Context, period domain.AnalyticsPeriod, limit int) ([]*domain.SourceStats, error)
+
+	// ListCohorts возвращает последние limit недельных когорт (сначала новые)
+	ListCohorts(ctx context.Context, limit int) ([]*domain.CohortStats, error)
+}
+
+// AdminRepositories - хранилища админки (bizDBRepository в проде, MemoryRepository в тестах).
+// Сообщения, отправленные из админки, пишутся в переписку напрямую
+type AdminRepositories interface {
+	AdminRepository
+	LeadRepository
+	SettingsRepository
+	ContentRepository
+	HandoffRepository
+	AnalyticsRepository
+	MessageRepository
+}
+
+// AdminAuthRepository - администраторы админки и их сессии
+type AdminAuthRepository interface {
+	// GetAdmin возвращает администратора или ErrAdminNotFound
+	GetAdmin(ctx context.Context, telegramID int64) (*domain.Admin, error)
+
+	// ListAdmins возвращает всех администраторов по telegram_id
+	ListAdmins(ctx context.Context) ([]*domain.Admin, error)
+
+	// SaveAdmin создаёт администратора или меняет его роль и заполняет admin значениями из хранилища
+	// (профиль Telegram не меняется - он обновляется при входе)
+	SaveAdmin(ctx context.Context, admin *domain.Admin) error
+
+	// AddAdminIfMissing создаёт администратора, если его ещё нет (роль существующего не меняется)
+	AddAdminIfMissing(ctx context.Context, admin *domain.Admin) (created bool, err error)
+
+	// DeleteAdmin удаляет администратора вместе с сессиями или возвращает ErrAdminNotFound
+	DeleteAdmin(ctx context.Context, telegramID int64) error
+
+	// RecordLogin обновляет профиль Telegram и время входа администратора (ErrAdminNotFound - его нет)
+	RecordLogin(ctx context.Context, admin *domain.Admin, at time.Time) error
+
+	// CreateSession сохраняет сессию и заполняет session.ID и CreatedAt (ErrAdminNotFound - администратора нет)
+	CreateSession(ctx context.Context, session *domain.AdminSession) error
+
+	// GetSession возвращает сессию по хэшу токена обновления (в том числе закрытую и истёкшую)
+	// или ErrSessionNotFound
+	GetSession(ctx context.Context, tokenHash []byte) (*domain.AdminSession, error)
+
+	// RevokeSession закрывает сессию. revoked = false - сессия уже была закрыта
+	// (из одновременных обновлений по одному токену пройдёт только одно)
+	RevokeSession(ctx context.Context, id int64, at time.Time) (revoked bool, err error)
+
+	// RevokeAdminSessions закрывает все открытые сессии администратора
+	RevokeAdminSessions(ctx context.Context, telegramID int64, at time.Time) (int64, error)
+
+	// DeleteSessionsBefore удаляет сессии, истёкшие раньше before
+	DeleteSessionsBefore(ctx context.Context, before time.Time) (int64, error)
+}
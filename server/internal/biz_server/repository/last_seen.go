@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// хранилище, принимающее last_seen_at пачкой
+type lastSeenStore interface {
+	UpdateLastSeenBatch(ctx context.Context, seen map[int64]time.Time) error
+}
+
+// lastSeenCoalescer копит время активности пользователей в памяти и пишет его в БД одним запросом:
+// по интервалу или раньше, если накопилось batchSize пользователей.
+// Повторная активность одного пользователя до записи схлопывается в одно значение
+type lastSeenCoalescer struct {
+	store     lastSeenStore
+	batchSize int
+
+	mu      sync.Mutex
+	pending map[int64]time.Time // telegram_id -> последнее время активности
+
+	kick      chan struct{} // сигнал "пачка набрана"
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// конструктор: запускает фоновую запись
+func newLastSeenCoalescer(store lastSeenStore, every time.Duration, batchSize int) *lastSeenCoalescer {
+	c := &lastSeenCoalescer{
+		store:     store,
+		batchSize: batchSize,
+		pending:   make(map[int64]time.Time),
+		kick:      make(chan struct{}, 1),
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	go c.run(every)
+	return c
+}
+
+// метод для фиксации активности пользователя
+func (c *lastSeenCoalescer) touch(telegramID int64, at time.Time) {
+	c.mu.Lock()
+	if prev, ok := c.pending[telegramID]; !ok || at.After(prev) {
+		c.pending[telegramID] = at
+	}
+	full := c.batchSize > 0 && len(c.pending) >= c.batchSize
+	c.mu.Unlock()
+
+	if full {
+		select {
+		case c.kick <- struct{}{}:
+		default: // запись уже запрошена
+		}
+	}
+}
+
+// метод возвращает ещё не записанное время активности пользователя
+func (c *lastSeenCoalescer) seen(telegramID int64) (time.Time, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	at, ok := c.pending[telegramID]
+	return at, ok
+}
+
+// метод убирает накопленное время, если в БД уже записано не менее свежее (например, через Update)
+func (c *lastSeenCoalescer) forget(telegramID int64, written time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if at, ok := c.pending[telegramID]; ok && !at.After(written) {
+		delete(c.pending, telegramID)
+	}
+}
+
+// фоновый цикл записи
+func (c *lastSeenCoalescer) run(every time.Duration) {
+	defer close(c.done)
+
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+		case <-c.kick:
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		c.flush(ctx)
+		cancel()
+	}
+}
+
+// метод записывает накопленное в БД; при ошибке значения возвращаются в очередь до следующей попытки
+func (c *lastSeenCoalescer) flush(ctx context.Context) error {
+	c.mu.Lock()
+	if len(c.pending) == 0 {
+		c.mu.Unlock()
+		return nil
+	}
+	batch := c.pending
+	c.pending = make(map[int64]time.Time, len(batch))
+	c.mu.Unlock()
+
+	if err := c.store.UpdateLastSeenBatch(ctx, batch); err != nil {
+		slog.WarnContext(ctx, "failed to flush last_seen batch", "users", len(batch), "error", err)
+
+		c.mu.Lock()
+		for id, at := range batch {
+			if prev, ok := c.pending[id]; !ok || at.After(prev) {
+				c.pending[id] = at
+			}
+		}
+		c.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// метод останавливает фоновую запись и дописывает остаток
+func (c *lastSeenCoalescer) close(ctx context.Context) error {
+	c.closeOnce.Do(func() { close(c.stop) })
+	<-c.done
+	return c.flush(ctx)
+}
@@ -0,0 +1,175 @@
+package repository
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"server/internal/domain"
+	"slices"
+	"time"
+)
+
+// администраторы админки и их сессии в памяти
+
+// GetAdmin возвращает администратора
+func (r *MemoryRepository) GetAdmin(ctx context.Context, telegramID int64) (*domain.Admin, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	admin, ok := r.admins[telegramID]
+	if !ok {
+		return nil, ErrAdminNotFound
+	}
+	return &admin, nil
+}
+
+// ListAdmins возвращает всех администраторов
+func (r *MemoryRepository) ListAdmins(ctx context.Context) ([]*domain.Admin, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var admins []*domain.Admin
+	for _, admin := range r.admins {
+		admins = append(admins, &admin)
+	}
+	slices.SortFunc(admins, func(a, b *domain.Admin) int { return cmp.Compare(a.TelegramID, b.TelegramID) })
+	return admins, nil
+}
+
+// SaveAdmin создаёт администратора или меняет его роль
+func (r *MemoryRepository) SaveAdmin(ctx context.Context, admin *domain.Admin) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	stored, ok := r.admins[admin.TelegramID]
+	if !ok {
+		stored = domain.Admin{TelegramID: admin.TelegramID, CreatedAt: now}
+	}
+	stored.Role = admin.Role
+	stored.UpdatedAt = now
+	r.admins[admin.TelegramID] = stored
+	*admin = stored
+	return nil
+}
+
+// AddAdminIfMissing создаёт администратора, если его ещё нет
+func (r *MemoryRepository) AddAdminIfMissing(ctx context.Context, admin *domain.Admin) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.admins[admin.TelegramID]; ok {
+		return false, nil
+	}
+	now := time.Now()
+	r.admins[admin.TelegramID] = domain.Admin{TelegramID: admin.TelegramID, Role: admin.Role, CreatedAt: now, UpdatedAt: now}
+	return true, nil
+}
+
+// DeleteAdmin удаляет администратора вместе с сессиями
+func (r *MemoryRepository) DeleteAdmin(ctx context.Context, telegramID int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.admins[telegramID]; !ok {
+		return ErrAdminNotFound
+	}
+	delete(r.admins, telegramID)
+	for id, session := range r.sessions {
+		if session.TelegramID == telegramID {
+			delete(r.sessions, id)
+		}
+	}
+	return nil
+}
+
+// RecordLogin обновляет профиль и время входа
+func (r *MemoryRepository) RecordLogin(ctx context.Context, admin *domain.Admin, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.admins[admin.TelegramID]
+	if !ok {
+		return ErrAdminNotFound
+	}
+	stored.Username = admin.Username
+	stored.FirstName = admin.FirstName
+	stored.LastName = admin.LastName
+	stored.LastLoginAt = &at
+	r.admins[admin.TelegramID] = stored
+	*admin = stored
+	return nil
+}
+
+// CreateSession сохраняет сессию
+func (r *MemoryRepository) CreateSession(ctx context.Context, session *domain.AdminSession) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.admins[session.TelegramID]; !ok {
+		return ErrAdminNotFound
+	}
+	r.nextSessionID++
+	session.ID = r.nextSessionID
+	session.CreatedAt = time.Now()
+	r.sessions[session.ID] = *session
+	return nil
+}
+
+// GetSession возвращает сессию по хэшу токена обновления
+func (r *MemoryRepository) GetSession(ctx context.Context, tokenHash []byte) (*domain.AdminSession, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, session := range r.sessions {
+		if bytes.Equal(session.TokenHash, tokenHash) {
+			return &session, nil
+		}
+	}
+	return nil, ErrSessionNotFound
+}
+
+// RevokeSession закрывает сессию (только открытую)
+func (r *MemoryRepository) RevokeSession(ctx context.Context, id int64, at time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	session, ok := r.sessions[id]
+	if !ok || session.RevokedAt != nil {
+		return false, nil
+	}
+	session.RevokedAt = &at
+	r.sessions[id] = session
+	return true, nil
+}
+
+// RevokeAdminSessions закрывает все открытые сессии администратора
+func (r *MemoryRepository) RevokeAdminSessions(ctx context.Context, telegramID int64, at time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var revoked int64
+	for id, session := range r.sessions {
+		if session.TelegramID == telegramID && session.RevokedAt == nil {
+			session.RevokedAt = &at
+			r.sessions[id] = session
+			revoked++
+		}
+	}
+	return revoked, nil
+}
+
+// DeleteSessionsBefore удаляет истёкшие сессии
+func (r *MemoryRepository) DeleteSessionsBefore(ctx context.Context, before time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var deleted int64
+	for id, session := range r.sessions {
+		if session.ExpiresAt.Before(before) {
+			delete(r.sessions, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
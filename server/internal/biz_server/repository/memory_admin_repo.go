@@ -0,0 +1,277 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"server/internal/domain"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// выборки админки, заявки и настройки бизнеса в памяти (порядок и фильтры как в Postgres)
+
+// функция вырезает страницу из отсортированной выборки
+func pageOf[T any](items []T, page domain.Page) []T {
+	offset := page.Offset()
+	if offset >= len(items) {
+		return nil
+	}
+	return items[offset:min(offset+page.Size, len(items))]
+}
+
+// функция поиска подстроки без учёта регистра (как ILIKE '%query%')
+func containsFold(s, query string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(query))
+}
+
+// ListUsers возвращает страницу пользователей (сначала недавно активные)
+func (r *MemoryRepository) ListUsers(ctx context.Context, filter domain.UserFilter) ([]*domain.User, int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	telegramID, byID := int64(0), false
+	if filter.Query != "" {
+		id, err := strconv.ParseInt(filter.Query, 10, 64)
+		telegramID, byID = id, err == nil
+	}
+
+	var users []*domain.User
+	for _, user := range r.users {
+		if filter.Query != "" && !containsFold(user.Username, filter.Query) && !containsFold(user.FirstName, filter.Query) &&
+			!containsFold(user.LastName, filter.Query) && !(byID && user.TelegramID == telegramID) {
+			continue
+		}
+		if filter.Active != nil && user.IsActive != *filter.Active {
+			continue
+		}
+		users = append(users, &user)
+	}
+	slices.SortFunc(users, func(a, b *domain.User) int {
+		if c := b.LastSeenAt.Compare(a.LastSeenAt); c != 0 {
+			return c
+		}
+		return cmp.Compare(b.ID, a.ID)
+	})
+	return pageOf(users, filter.Page), int64(len(users)), nil
+}
+
+// ListMessages возвращает переписку с пользователем (сначала новые)
+func (r *MemoryRepository) ListMessages(ctx context.Context, filter domain.MessageFilter) ([]*domain.Message, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var messages []*domain.Message
+	for _, message := range r.messages {
+		if message.UserID != filter.TelegramID || (filter.BeforeID > 0 && message.ID >= filter.BeforeID) {
+			continue
+		}
+		messages = append(messages, &message)
+	}
+	slices.SortFunc(messages, func(a, b *domain.Message) int { return cmp.Compare(b.ID, a.ID) })
+	return pageOf(messages, domain.Page{Number: 1, Size: filter.Limit}), nil
+}
+
+// ListCallbacks возвращает страницу нажатий кнопок (сначала новые)
+func (r *MemoryRepository) ListCallbacks(ctx context.Context, filter domain.CallbackFilter) ([]*domain.CallbackLog, int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var callbacks []*domain.CallbackLog
+	for _, callback := range r.callbacks {
+		if filter.TelegramID != 0 && callback.UserID != filter.TelegramID {
+			continue
+		}
+		if filter.Data != "" && callback.Data != filter.Data {
+			continue
+		}
+		callbacks = append(callbacks, &callback)
+	}
+	slices.SortFunc(callbacks, func(a, b *domain.CallbackLog) int { return cmp.Compare(b.ID, a.ID) })
+	return pageOf(callbacks, filter.Page), int64(len(callbacks)), nil
+}
+
+// DashboardStats возвращает сводку с момента since
+func (r *MemoryRepository) DashboardStats(ctx context.Context, since time.Time) (*domain.DashboardStats, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stats := &domain.DashboardStats{LeadsByStatus: make(map[domain.LeadStatus]int64)}
+	for _, user := range r.users {
+		if !user.CreatedAt.Before(since) {
+			stats.NewUsers++
+		}
+		if !user.LastSeenAt.Before(since) {
+			stats.ActiveUsers++
+		}
+	}
+
+	started := make(map[int64]struct{})
+	for _, message := range r.messages {
+		if message.Direction == "incoming" && message.CommandName == "/start" && !message.CreatedAt.Before(since) {
+			started[message.UserID] = struct{}{}
+		}
+	}
+	stats.Started = int64(len(started))
+
+	funnel := map[string]map[int64]struct{}{"lookup": {}, "contacted_yes": {}}
+	for _, callback := range r.callbacks {
+		if users, ok := funnel[callback.Data]; ok && !callback.Timestamp.Before(since) {
+			users[callback.UserID] = struct{}{}
+		}
+	}
+	stats.Lookups = int64(len(funnel["lookup"]))
+	stats.ContactedYes = int64(len(funnel["contacted_yes"]))
+
+	for _, lead := range r.leads {
+		if !lead.CreatedAt.Before(since) {
+			stats.NewLeads++
+		}
+		stats.LeadsByStatus[lead.Status]++
+	}
+	return stats, nil
+}
+
+// метод возвращает копию заявки с профилем пользователя (вызывается под мьютексом)
+func (r *MemoryRepository) leadWithUser(lead domain.Lead) *domain.Lead {
+	lead.User = nil
+	if user, ok := r.users[lead.TelegramID]; ok {
+		lead.User = &user
+	}
+	return &lead
+}
+
+// метод поиска открытой заявки пользователя (вызывается под мьютексом)
+func (r *MemoryRepository) openLead(telegramID int64, exceptID int64) (domain.Lead, bool) {
+	for _, lead := range r.leads {
+		if lead.TelegramID == telegramID && lead.ID != exceptID && lead.Status.Open() {
+			return lead, true
+		}
+	}
+	return domain.Lead{}, false
+}
+
+// CreateLead создаёт заявку (или возвращает открытую заявку пользователя)
+func (r *MemoryRepository) CreateLead(ctx context.Context, lead *domain.Lead) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if existing, ok := r.openLead(lead.TelegramID, 0); ok {
+		*lead = *r.leadWithUser(existing)
+		return false, nil
+	}
+
+	now := time.Now()
+	r.nextLeadID++
+	lead.ID = r.nextLeadID
+	lead.Status = domain.LeadNew
+	lead.CreatedAt, lead.UpdatedAt = now, now
+	stored := *lead
+	stored.User = nil
+	r.leads[lead.ID] = stored
+	return true, nil
+}
+
+// GetLead возвращает заявку с профилем пользователя
+func (r *MemoryRepository) GetLead(ctx context.Context, id int64) (*domain.Lead, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	lead, ok := r.leads[id]
+	if !ok {
+		return nil, ErrLeadNotFound
+	}
+	return r.leadWithUser(lead), nil
+}
+
+// ListLeads возвращает страницу заявок (сначала новые)
+func (r *MemoryRepository) ListLeads(ctx context.Context, filter domain.LeadFilter) ([]*domain.Lead, int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var leads []*domain.Lead
+	for _, lead := range r.leads {
+		if filter.Status != "" && lead.Status != filter.Status {
+			continue
+		}
+		if filter.TelegramID != 0 && lead.TelegramID != filter.TelegramID {
+			continue
+		}
+		leads = append(leads, r.leadWithUser(lead))
+	}
+	slices.SortFunc(leads, func(a, b *domain.Lead) int { return cmp.Compare(b.ID, a.ID) })
+	return pageOf(leads, filter.Page), int64(len(leads)), nil
+}
+
+// UpdateLead меняет статус и заметку заявки
+func (r *MemoryRepository) UpdateLead(ctx context.Context, lead *domain.Lead) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.leads[lead.ID]
+	if !ok {
+		return ErrLeadNotFound
+	}
+	if lead.Status.Open() {
+		if _, ok := r.openLead(stored.TelegramID, stored.ID); ok {
+			return ErrLeadConflict
+		}
+	}
+
+	stored.Status = lead.Status
+	stored.Note = lead.Note
+	stored.UpdatedAt = time.Now()
+	r.leads[lead.ID] = stored
+	lead.UpdatedAt = stored.UpdatedAt
+	return nil
+}
+
+// GetSettings возвращает настройки бизнеса
+func (r *MemoryRepository) GetSettings(ctx context.Context) (*domain.BusinessSettings, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.settings == nil {
+		return domain.DefaultBusinessSettings(), nil
+	}
+	settings := *r.settings
+	return &settings, nil
+}
+
+// SaveSettings сохраняет настройки бизнеса
+func (r *MemoryRepository) SaveSettings(ctx context.Context, s *domain.BusinessSettings) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s.UpdatedAt = time.Now()
+	settings := *s
+	r.settings = &settings
+	return nil
+}
+
+// ListContent возвращает изменённые тексты бота
+func (r *MemoryRepository) ListContent(ctx context.Context) (map[domain.ContentKey]domain.ContentItem, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	content := make(map[domain.ContentKey]domain.ContentItem, len(r.content))
+	for key, item := range r.content {
+		content[key] = item
+	}
+	return content, nil
+}
+
+// SaveContent сохраняет текст бота (пустой текст - удаляет изменение)
+func (r *MemoryRepository) SaveContent(ctx context.Context, key domain.ContentKey, text string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if text == "" {
+		delete(r.content, key)
+		return nil
+	}
+	now := time.Now()
+	r.content[key] = domain.ContentItem{Key: key, Text: text, UpdatedAt: &now}
+	return nil
+}
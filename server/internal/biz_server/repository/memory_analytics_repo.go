@@ -0,0 +1,184 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"server/internal/domain"
+	"slices"
+	"time"
+)
+
+// аналитика в памяти
+
+// ключ агрегата периода
+type analyticsKey struct {
+	period domain.AnalyticsPeriod
+	start  time.Time
+}
+
+// AnalyticsFacts возвращает исходные данные пересчёта с момента since
+func (r *MemoryRepository) AnalyticsFacts(ctx context.Context, since time.Time) (*domain.AnalyticsFacts, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	facts := &domain.AnalyticsFacts{Since: since}
+
+	// первый /start и первое согласие на связь каждого пользователя - за всё время
+	firstStart := make(map[int64]domain.Message)
+	for _, message := range r.messages {
+		if message.Direction != "incoming" || message.CommandName != "/start" {
+			continue
+		}
+		first, ok := firstStart[message.UserID]
+		if !ok || message.CreatedAt.Before(first.CreatedAt) || (message.CreatedAt.Equal(first.CreatedAt) && message.ID < first.ID) {
+			firstStart[message.UserID] = message
+		}
+		if !message.CreatedAt.Before(since) {
+			facts.Events = append(facts.Events, domain.FunnelEvent{TelegramID: message.UserID, Step: domain.FunnelStart, At: message.CreatedAt})
+		}
+	}
+	firstContact := make(map[int64]time.Time)
+	for _, callback := range r.callbacks {
+		if callback.Data != domain.FunnelLookup && callback.Data != domain.FunnelContactedYes {
+			continue
+		}
+		if callback.Data == domain.FunnelContactedYes {
+			if first, ok := firstContact[callback.UserID]; !ok || callback.Timestamp.Before(first) {
+				firstContact[callback.UserID] = callback.Timestamp
+			}
+		}
+		if !callback.Timestamp.Before(since) {
+			facts.Events = append(facts.Events, domain.FunnelEvent{TelegramID: callback.UserID, Step: callback.Data, At: callback.Timestamp})
+		}
+	}
+
+	for _, user := range r.users {
+		contact, contacted := firstContact[user.TelegramID]
+		if user.CreatedAt.Before(since) && (!contacted || contact.Before(since)) {
+			continue
+		}
+		au := domain.AnalyticsUser{TelegramID: user.TelegramID, CreatedAt: user.CreatedAt, Source: domain.StartSource(firstStart[user.TelegramID].Text)}
+		if contacted {
+			au.FirstContactAt = &contact
+		}
+		facts.Users = append(facts.Users, au)
+	}
+
+	type activityKey struct {
+		telegramID int64
+		hour       time.Time
+	}
+	active := make(map[activityKey]struct{})
+	for _, message := range r.messages {
+		if message.Direction == "incoming" && !message.CreatedAt.Before(since) {
+			active[activityKey{message.UserID, message.CreatedAt.Truncate(time.Hour)}] = struct{}{}
+		}
+	}
+	for _, callback := range r.callbacks {
+		if !callback.Timestamp.Before(since) {
+			active[activityKey{callback.UserID, callback.Timestamp.Truncate(time.Hour)}] = struct{}{}
+		}
+	}
+	for key := range active {
+		facts.Activity = append(facts.Activity, domain.UserActivity{TelegramID: key.telegramID, Hour: key.hour})
+	}
+	return facts, nil
+}
+
+// SaveAnalytics заменяет агрегаты пересчитанных периодов
+func (r *MemoryRepository) SaveAnalytics(ctx context.Context, snapshot *domain.AnalyticsSnapshot) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// пересчитанные периоды заменяются целиком: источник, пропавший из периода, тоже удаляется
+	replaced := func(key analyticsKey) bool {
+		if key.period == domain.PeriodDay {
+			return !key.start.Before(snapshot.DayFrom)
+		}
+		return !key.start.Before(snapshot.WeekFrom)
+	}
+	for key := range r.funnels {
+		if replaced(key) {
+			delete(r.funnels, key)
+		}
+	}
+	for key := range r.sources {
+		if replaced(key) {
+			delete(r.sources, key)
+		}
+	}
+	for start := range r.cohorts {
+		if !start.Before(snapshot.WeekFrom) {
+			delete(r.cohorts, start)
+		}
+	}
+
+	for _, funnel := range snapshot.Funnels {
+		r.funnels[analyticsKey{funnel.Period, funnel.Start}] = *funnel
+	}
+	for _, source := range snapshot.Sources {
+		key := analyticsKey{source.Period, source.Start}
+		r.sources[key] = append(r.sources[key], *source)
+	}
+	for _, cohort := range snapshot.Cohorts {
+		stored := *cohort
+		stored.Retained = slices.Clone(cohort.Retained)
+		r.cohorts[cohort.Start] = stored
+	}
+	return nil
+}
+
+// ListFunnelStats возвращает воронки последних limit периодов
+func (r *MemoryRepository) ListFunnelStats(ctx context.Context, period domain.AnalyticsPeriod, limit int) ([]*domain.FunnelStats, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var funnels []*domain.FunnelStats
+	for key, funnel := range r.funnels {
+		if key.period == period {
+			funnels = append(funnels, &funnel)
+		}
+	}
+	slices.SortFunc(funnels, func(a, b *domain.FunnelStats) int { return b.Start.Compare(a.Start) })
+	return funnels[:min(limit, len(funnels))], nil
+}
+
+// ListSourceStats возвращает источники последних limit периодов
+func (r *MemoryRepository) ListSourceStats(ctx context.Context, period domain.AnalyticsPeriod, limit int) ([]*domain.SourceStats, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var starts []time.Time
+	for key := range r.sources {
+		if key.period == period {
+			starts = append(starts, key.start)
+		}
+	}
+	slices.SortFunc(starts, func(a, b time.Time) int { return b.Compare(a) })
+
+	var sources []*domain.SourceStats
+	for _, start := range starts[:min(limit, len(starts))] {
+		inPeriod := slices.Clone(r.sources[analyticsKey{period, start}])
+		slices.SortFunc(inPeriod, func(a, b domain.SourceStats) int {
+			return cmp.Or(cmp.Compare(b.NewUsers, a.NewUsers), cmp.Compare(a.Source, b.Source))
+		})
+		for _, source := range inPeriod {
+			sources = append(sources, &source)
+		}
+	}
+	return sources, nil
+}
+
+// ListCohorts возвращает последние limit когорт
+func (r *MemoryRepository) ListCohorts(ctx context.Context, limit int) ([]*domain.CohortStats, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var cohorts []*domain.CohortStats
+	for _, cohort := range r.cohorts {
+		cohort.Retained = slices.Clone(cohort.Retained)
+		cohorts = append(cohorts, &cohort)
+	}
+	slices.SortFunc(cohorts, func(a, b *domain.CohortStats) int { return b.Start.Compare(a.Start) })
+	return cohorts[:min(limit, len(cohorts))], nil
+}
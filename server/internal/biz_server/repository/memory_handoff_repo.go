@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"server/internal/domain"
+	"slices"
+	"time"
+)
+
+// диалоги с оператором в памяти
+
+// метод поиска открытого диалога чата (вызывается под мьютексом)
+func (r *MemoryRepository) openHandoff(telegramID int64) (domain.Handoff, bool) {
+	for _, handoff := range r.handoffs {
+		if handoff.TelegramID == telegramID && handoff.ClosedAt == nil {
+			return handoff, true
+		}
+	}
+	return domain.Handoff{}, false
+}
+
+// OpenHandoff открывает диалог (или возвращает открытый диалог чата)
+func (r *MemoryRepository) OpenHandoff(ctx context.Context, handoff *domain.Handoff) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if existing, ok := r.openHandoff(handoff.TelegramID); ok {
+		*handoff = existing
+		return false, nil
+	}
+
+	now := time.Now()
+	r.nextHandoffID++
+	handoff.ID = r.nextHandoffID
+	handoff.OpenedAt, handoff.LastActivityAt = now, now
+	handoff.ClosedAt, handoff.CloseReason = nil, ""
+	stored := *handoff
+	stored.User = nil
+	r.handoffs[handoff.ID] = stored
+	return true, nil
+}
+
+// GetOpenHandoff возвращает открытый диалог чата
+func (r *MemoryRepository) GetOpenHandoff(ctx context.Context, telegramID int64) (*domain.Handoff, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	handoff, ok := r.openHandoff(telegramID)
+	if !ok {
+		return nil, ErrHandoffNotFound
+	}
+	return &handoff, nil
+}
+
+// ListOpenHandoffs возвращает открытые диалоги (сначала недавно активные)
+func (r *MemoryRepository) ListOpenHandoffs(ctx context.Context) ([]*domain.Handoff, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var handoffs []*domain.Handoff
+	for _, handoff := range r.handoffs {
+		if handoff.ClosedAt != nil {
+			continue
+		}
+		if user, ok := r.users[handoff.TelegramID]; ok {
+			handoff.User = &user
+		}
+		handoffs = append(handoffs, &handoff)
+	}
+	slices.SortFunc(handoffs, func(a, b *domain.Handoff) int {
+		return cmp.Or(b.LastActivityAt.Compare(a.LastActivityAt), cmp.Compare(b.ID, a.ID))
+	})
+	return handoffs, nil
+}
+
+// TouchHandoff сдвигает время последней активности открытого диалога
+func (r *MemoryRepository) TouchHandoff(ctx context.Context, telegramID int64, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if handoff, ok := r.openHandoff(telegramID); ok && at.After(handoff.LastActivityAt) {
+		handoff.LastActivityAt = at
+		r.handoffs[handoff.ID] = handoff
+	}
+	return nil
+}
+
+// CloseHandoff закрывает открытый диалог чата
+func (r *MemoryRepository) CloseHandoff(ctx context.Context, telegramID int64, reason domain.HandoffCloseReason, at time.Time) (*domain.Handoff, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	handoff, ok := r.openHandoff(telegramID)
+	if !ok {
+		return nil, ErrHandoffNotFound
+	}
+	return r.closeHandoff(handoff, reason, at), nil
+}
+
+// CloseIdleHandoffs закрывает по таймауту диалоги без активности
+func (r *MemoryRepository) CloseIdleHandoffs(ctx context.Context, idleSince, at time.Time) ([]*domain.Handoff, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var closed []*domain.Handoff
+	for _, handoff := range r.handoffs {
+		if handoff.ClosedAt == nil && handoff.LastActivityAt.Before(idleSince) {
+			closed = append(closed, r.closeHandoff(handoff, domain.HandoffClosedByTimeout, at))
+		}
+	}
+	slices.SortFunc(closed, func(a, b *domain.Handoff) int { return cmp.Compare(a.ID, b.ID) })
+	return closed, nil
+}
+
+// метод закрытия диалога (вызывается под мьютексом)
+func (r *MemoryRepository) closeHandoff(handoff domain.Handoff, reason domain.HandoffCloseReason, at time.Time) *domain.Handoff {
+	handoff.ClosedAt = &at
+	handoff.CloseReason = reason
+	r.handoffs[handoff.ID] = handoff
+	return &handoff
+}
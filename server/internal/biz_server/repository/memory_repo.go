@@ -0,0 +1,239 @@
+package repository
+
+import (
+	"context"
+	"server/internal/domain"
+	"sync"
+	"time"
+)
+
+// ключ уникальности сообщения (как UNIQUE (telegram_chat_id, telegram_message_id) в БД)
+type messageKey struct {
+	chatID    int64
+	messageID int64
+}
+
+// MemoryRepository - реализация репозиториев в памяти (для тестов сервисов и хэндлеров без Postgres).
+// Ведёт себя так же, как BizRepository - это проверяет общий контрактный тест
+type MemoryRepository struct {
+	mu sync.Mutex
+
+	users     map[int64]domain.User // ключ - telegram_id
+	messages  map[int64]domain.Message
+	messageBy map[messageKey]int64 // (чат, telegram_message_id) -> id
+	callbacks map[string]domain.CallbackLog
+	leads     map[int64]domain.Lead
+	settings  *domain.BusinessSettings // nil - настройки ещё не сохраняли
+	admins    map[int64]domain.Admin   // ключ - telegram_id
+	sessions  map[int64]domain.AdminSession
+	content   map[domain.ContentKey]domain.ContentItem
+	handoffs  map[int64]domain.Handoff
+	funnels   map[analyticsKey]domain.FunnelStats
+	sources   map[analyticsKey][]domain.SourceStats
+	cohorts   map[time.Time]domain.CohortStats // ключ - начало недели когорты
+
+	nextUserID     int64
+	nextMessageID  int64
+	nextCallbackID int64
+	nextLeadID     int64
+	nextSessionID  int64
+	nextHandoffID  int64
+}
+
+// конструктор для репозитория в памяти
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{
+		users:     make(map[int64]domain.User),
+		messages:  make(map[int64]domain.Message),
+		messageBy: make(map[messageKey]int64),
+		callbacks: make(map[string]domain.CallbackLog),
+		leads:     make(map[int64]domain.Lead),
+		admins:    make(map[int64]domain.Admin),
+		sessions:  make(map[int64]domain.AdminSession),
+		content:   make(map[domain.ContentKey]domain.ContentItem),
+		handoffs:  make(map[int64]domain.Handoff),
+		funnels:   make(map[analyticsKey]domain.FunnelStats),
+		sources:   make(map[analyticsKey][]domain.SourceStats),
+		cohorts:   make(map[time.Time]domain.CohortStats),
+	}
+}
+
+// метод для создания пользователя
+func (r *MemoryRepository) CreateUser(ctx context.Context, user *domain.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.users[user.TelegramID]; ok {
+		return ErrUserAlreadyExists
+	}
+
+	r.nextUserID++
+	user.ID = r.nextUserID
+	r.users[user.TelegramID] = *user
+	return nil
+}
+
+// метод для атомарного создания или обновления пользователя
+func (r *MemoryRepository) UpsertUser(ctx context.Context, user *domain.User) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.users[user.TelegramID]
+	if !ok {
+		r.nextUserID++
+		user.ID = r.nextUserID
+		r.users[user.TelegramID] = *user
+		return true, nil
+	}
+
+	stored.Username = user.Username
+	stored.FirstName = user.FirstName
+	stored.LastName = user.LastName
+	stored.IsActive = user.IsActive
+	if user.LastSeenAt.After(stored.LastSeenAt) {
+		stored.LastSeenAt = user.LastSeenAt
+	}
+	r.users[user.TelegramID] = stored
+
+	user.ID = stored.ID
+	user.CreatedAt = stored.CreatedAt
+	user.LastSeenAt = stored.LastSeenAt
+	return false, nil
+}
+
+// метод для обновления пользователя (created_at не меняется, как и в БД)
+func (r *MemoryRepository) Update(ctx context.Context, user *domain.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.users[user.TelegramID]
+	if !ok {
+		return ErrUserNotFound
+	}
+
+	stored.Username = user.Username
+	stored.FirstName = user.FirstName
+	stored.LastName = user.LastName
+	stored.IsActive = user.IsActive
+	stored.LastSeenAt = user.LastSeenAt
+	r.users[user.TelegramID] = stored
+
+	user.ID = stored.ID
+	return nil
+}
+
+// метод для поиска пользователя по ID из телеграмма (возвращается копия)
+func (r *MemoryRepository) GetUserByTelegramID(ctx context.Context, telegramID int64) (*domain.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[telegramID]
+	if !ok {
+		return nil, ErrUserNotFound
+	}
+	return &user, nil
+}
+
+// метод обновления времени последнего посещения
+func (r *MemoryRepository) UpdateLastSeen(ctx context.Context, telegramID int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if user, ok := r.users[telegramID]; ok {
+		user.LastSeenAt = time.Now()
+		r.users[telegramID] = user
+	}
+	return nil
+}
+
+// метод пакетного обновления времени последнего посещения
+func (r *MemoryRepository) UpdateLastSeenBatch(ctx context.Context, seen map[int64]time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for telegramID, at := range seen {
+		if user, ok := r.users[telegramID]; ok && user.LastSeenAt.Before(at) {
+			user.LastSeenAt = at
+			r.users[telegramID] = user
+		}
+	}
+	return nil
+}
+
+// метод для сохранения сообщения
+func (r *MemoryRepository) Save(ctx context.Context, message *domain.Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if message.CreatedAt.IsZero() {
+		message.CreatedAt = time.Now()
+	}
+
+	stored := *message
+	stored.IsCommand, stored.CommandName = parseCommand(message.Text)
+	stored.UpdatedAt = message.CreatedAt
+
+	key := messageKey{chatID: message.ChatID, messageID: message.MessageID}
+	if message.MessageID != 0 {
+		if id, ok := r.messageBy[key]; ok {
+			// повтор сообщения - обновляем только текст и статус
+			existing := r.messages[id]
+			existing.Text = stored.Text
+			existing.Status = stored.Status
+			existing.UpdatedAt = stored.UpdatedAt
+			r.messages[id] = existing
+			message.ID = id
+			return nil
+		}
+	}
+
+	r.nextMessageID++
+	stored.ID = r.nextMessageID
+	r.messages[stored.ID] = stored
+	if message.MessageID != 0 {
+		r.messageBy[key] = stored.ID
+	}
+
+	message.ID = stored.ID
+	return nil
+}
+
+// метод для сохранения колбэка
+func (r *MemoryRepository) SaveCallback(ctx context.Context, callback *domain.CallbackLog) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.callbacks[callback.CallbackID]; ok {
+		// Колбэк уже существует, это нормально для повторных обработок
+		return nil
+	}
+
+	if callback.Timestamp.IsZero() {
+		callback.Timestamp = time.Now()
+	}
+
+	r.nextCallbackID++
+	callback.ID = r.nextCallbackID
+	r.callbacks[callback.CallbackID] = *callback
+	return nil
+}
+
+// метод для сохранения пачки сообщений (по одному, как Save)
+func (r *MemoryRepository) SaveMessages(ctx context.Context, messages []*domain.Message) error {
+	for _, message := range messages {
+		if err := r.Save(ctx, message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// метод для сохранения пачки колбэков
+func (r *MemoryRepository) SaveCallbacks(ctx context.Context, callbacks []*domain.CallbackLog) error {
+	for _, callback := range callbacks {
+		if err := r.SaveCallback(ctx, callback); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -2,26 +2,37 @@ package repository
 
 import (
 	"context"
-	"database/sql"
 	"errors"
 	"fmt"
+	"global_models/global_cache"
+	"log/slog"
+	"pkg/configs"
 	"server/internal/domain"
-	"strings"
 	"time"
-
-	"github.com/jackc/pgx/v4"
 )
 
-var ErrUserNotFound = errors.New("user not found")
+var (
+	ErrUserNotFound      = errors.New("user not found")
+	ErrUserAlreadyExists = errors.New("user already exists")
+	ErrLeadNotFound      = errors.New("lead not found")
+	ErrLeadConflict      = errors.New("user already has an open lead")
+	ErrAdminNotFound     = errors.New("admin not found")
+	ErrSessionNotFound   = errors.New("admin session not found")
+	ErrHandoffNotFound   = errors.New("handoff not found")
+)
 
-// описание структуры слоя репозитория
+// описание структуры слоя репозитория:
+// хранилище (Postgres) + кэш пользователей (redis) + пакетная запись времени активности
 type BizRepository struct {
-	DBRepo    *bizDBRepository
-	CacheRepo *bizCacheRepository
+	DBRepo    Repositories        // основное хранилище (bizDBRepository в проде)
+	CacheRepo *bizCacheRepository // кэш пользователей (read-through)
+
+	conf     *configs.UserCacheConfig
+	lastSeen *lastSeenCoalescer // nil - last_seen_at пишется сразу
 }
 
 // конструктор для слоя репозиторий
-func NewBizRepository(dbRepo *bizDBRepository, cacheRepo *bizCacheRepository) (*BizRepository, error) {
+func NewBizRepository(dbRepo Repositories, cacheRepo *bizCacheRepository, conf *configs.UserCacheConfig) (*BizRepository, error) {
 	// Проверяем обязательные зависимости
 	if dbRepo == nil {
 		return nil, fmt.Errorf("dbRepo is required")
@@ -29,10 +40,29 @@ func NewBizRepository(dbRepo *bizDBRepository, cacheRepo *bizCacheRepository) (*
 	if cacheRepo == nil {
 		return nil, fmt.Errorf("blackListRepo is required")
 	}
-	return &BizRepository{
+	if conf == nil {
+		conf = configs.UseDefaultUserCacheConfig()
+	}
+
+	repo := &BizRepository{
 		DBRepo:    dbRepo,
 		CacheRepo: cacheRepo,
-	}, nil
+		conf:      conf,
+	}
+	if conf.LastSeenFlush > 0 {
+		// пачки пишутся через UpdateLastSeenBatch слоя - с ними сбрасывается кэш
+		repo.lastSeen = newLastSeenCoalescer(repo, conf.LastSeenFlush, conf.LastSeenBatchSize)
+	}
+	return repo, nil
+}
+
+// метод для освобождения ресурсов: дописывает в БД накопленные last_seen_at
+// (вызывается до закрытия пула соединений)
+func (r *BizRepository) Close(ctx context.Context) error {
+	if r.lastSeen == nil {
+		return nil
+	}
+	return r.lastSeen.close(ctx)
 }
 
 // метод для теста
@@ -42,214 +72,149 @@ func (r *BizRepository) Echo() string {
 
 // сохраняет или обновляет данные в таблице meesges
 func (r *BizRepository) Save(ctx context.Context, message *domain.Message) error {
-	// Определяем является ли сообщение командой
-	isCommand := false
-	commandName := ""
-	if len(message.Text) > 0 && message.Text[0] == '/' {
-		isCommand = true
-		parts := strings.Split(message.Text, " ")
-		commandName = parts[0]
-	}
-
-	// Сохраняем сообщение
-	query := `
-        INSERT INTO messages (
-            telegram_message_id, telegram_chat_id, telegram_user_id,
-            text, direction, status, is_command, command_name, created_at, updated_at
-        ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
-        ON CONFLICT (telegram_chat_id, telegram_message_id) 
-        DO UPDATE SET
-            text = EXCLUDED.text,
-            status = EXCLUDED.status,
-            updated_at = EXCLUDED.updated_at
-        RETURNING id
-    `
-
-	var id int64
-	err := r.DBRepo.Pool.QueryRow(ctx, query,
-		message.MessageID,
-		message.ChatID,
-		message.UserID,
-		message.Text,
-		message.Direction,
-		message.Status,
-		isCommand,
-		commandName,
-		message.CreatedAt,
-		message.CreatedAt, // created_at и updated_at
-	).Scan(&id)
-
-	if err != nil {
-		return fmt.Errorf("failed to save message: %w", err)
-	}
-
-	message.ID = id
-	return nil
+	return r.DBRepo.Save(ctx, message)
 }
 
 // SaveCallback сохраняет колбэк и связывает с сообщением
 func (r *BizRepository) SaveCallback(ctx context.Context, callback *domain.CallbackLog) error {
-	// Начинаем транзакцию
-	tx, err := r.DBRepo.Pool.Begin(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
-	}
-	defer tx.Rollback(ctx)
-
-	// Находим связанное сообщение (опционально)
-	var messageID *int64
-	err = tx.QueryRow(ctx, `
-        SELECT id FROM messages 
-        WHERE telegram_chat_id = $1 AND telegram_message_id = $2
-    `, callback.ChatID, callback.MessageID).Scan(&messageID)
-
-	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
-		return fmt.Errorf("failed to find related message: %w", err)
-	}
-
-	// Сохраняем колбэк
-	query := `
-        INSERT INTO callback_logs (
-            callback_id, telegram_user_id, telegram_chat_id, 
-            telegram_message_id, callback_data, message_id, created_at
-        ) VALUES ($1, $2, $3, $4, $5, $6, $7)
-        ON CONFLICT (callback_id) DO NOTHING
-        RETURNING id
-    `
-
-	var id int64
-	err = tx.QueryRow(ctx, query,
-		callback.CallbackID, callback.UserID, callback.ChatID,
-		callback.MessageID, callback.Data, messageID,
-		time.Now(),
-	).Scan(&id)
-
-	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			// Колбэк уже существует, это нормально для повторных обработок
-			return nil
-		}
-		return fmt.Errorf("failed to save callback: %w", err)
-	}
+	return r.DBRepo.SaveCallback(ctx, callback)
+}
 
-	// Коммитим транзакцию
-	if err := tx.Commit(ctx); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
-	}
+// метод для сохранения пачки сообщений
+func (r *BizRepository) SaveMessages(ctx context.Context, messages []*domain.Message) error {
+	return r.DBRepo.SaveMessages(ctx, messages)
+}
 
-	callback.ID = id
-	return nil
+// метод для сохранения пачки колбэков
+func (r *BizRepository) SaveCallbacks(ctx context.Context, callbacks []*domain.CallbackLog) error {
+	return r.DBRepo.SaveCallbacks(ctx, callbacks)
 }
 
-// метод для создания и сохранения пользователя в базу
+// метод для создания и сохранения пользователя в базу (сразу кладём его в кэш - следующий update его прочитает)
 func (r *BizRepository) CreateUser(ctx context.Context, user *domain.User) error {
-	query := `
-        INSERT INTO users (
-            telegram_id, username, first_name, last_name, 
-            is_active, created_at, last_seen_at
-        ) VALUES ($1, $2, $3, $4, $5, $6, $7)
-        RETURNING id
-    `
-
-	err := r.DBRepo.Pool.QueryRow(ctx, query,
-		user.TelegramID,
-		nullString(user.Username),
-		user.FirstName,
-		nullString(user.LastName),
-		user.IsActive,
-		user.CreatedAt,
-		user.LastSeenAt,
-	).Scan(&user.ID)
+	if err := r.DBRepo.CreateUser(ctx, user); err != nil {
+		return err
+	}
+	r.cacheUser(ctx, user)
+	return nil
+}
 
+// метод для атомарного создания или обновления пользователя (запись в кэше сбрасывается)
+func (r *BizRepository) UpsertUser(ctx context.Context, user *domain.User) (bool, error) {
+	created, err := r.DBRepo.UpsertUser(ctx, user)
 	if err != nil {
-		return fmt.Errorf("failed to create user: %w", err)
+		return false, err
 	}
-
-	return nil
+	if r.lastSeen != nil {
+		r.lastSeen.forget(user.TelegramID, user.LastSeenAt)
+	}
+	r.invalidateUser(ctx, user.TelegramID)
+	return created, nil
 }
 
-// метод для обновления пользователя в базе (вдруг данные в телеграмме поменялись)
+// метод для обновления пользователя в базе (запись в кэше сбрасывается)
 func (r *BizRepository) Update(ctx context.Context, user *domain.User) error {
-	query := `
-        UPDATE users SET
-            username = $2,
-            first_name = $3,
-            last_name = $4,
-            is_active = $5,
-            last_seen_at = $6
-        WHERE telegram_id = $1
-        RETURNING id
-    `
-
-	err := r.DBRepo.Pool.QueryRow(ctx, query,
-		user.TelegramID,
-		nullString(user.Username),
-		user.FirstName,
-		nullString(user.LastName),
-		user.IsActive,
-		user.LastSeenAt,
-	).Scan(&user.ID)
+	if err := r.DBRepo.Update(ctx, user); err != nil {
+		return err
+	}
+	if r.lastSeen != nil {
+		r.lastSeen.forget(user.TelegramID, user.LastSeenAt)
+	}
+	r.invalidateUser(ctx, user.TelegramID)
+	return nil
+}
 
+// метод для поиска пользователя по ID из телеграмма: сначала кэш, при промахе - БД (и запись в кэш)
+func (r *BizRepository) GetUserByTelegramID(ctx context.Context, telegramID int64) (*domain.User, error) {
+	user, err := r.readUser(ctx, telegramID)
 	if err != nil {
-		return fmt.Errorf("failed to update user: %w", err)
+		return nil, err
 	}
 
+	// время активности, ещё не записанное в БД, видно сразу
+	if r.lastSeen != nil {
+		if at, ok := r.lastSeen.seen(telegramID); ok && at.After(user.LastSeenAt) {
+			user.LastSeenAt = at
+		}
+	}
+	return user, nil
+}
+
+// метод обновления времени последнего посещения: копится в памяти и пишется в БД пачкой
+func (r *BizRepository) UpdateLastSeen(ctx context.Context, telegramID int64) error {
+	if r.lastSeen == nil {
+		return r.DBRepo.UpdateLastSeen(ctx, telegramID)
+	}
+	r.lastSeen.touch(telegramID, time.Now())
 	return nil
 }
 
-// метод для поиска пользователя по ID из телеграмма
-func (r *BizRepository) GetUserByTelegramID(ctx context.Context, telegramID int64) (*domain.User, error) {
-	query := `
-        SELECT id, telegram_id, username, first_name, last_name,
-               is_active, created_at, last_seen_at
-        FROM users
-        WHERE telegram_id = $1
-    `
-
-	user := &domain.User{}
-	var username, lastName sql.NullString
-
-	err := r.DBRepo.Pool.QueryRow(ctx, query, telegramID).Scan(
-		&user.ID,
-		&user.TelegramID,
-		&username,
-		&user.FirstName,
-		&lastName,
-		&user.IsActive,
-		&user.CreatedAt,
-		&user.LastSeenAt,
-	)
+// метод пакетного обновления времени последнего посещения
+// (кэшированные записи этих пользователей сбрасываются - следующее чтение возьмёт время из БД)
+func (r *BizRepository) UpdateLastSeenBatch(ctx context.Context, seen map[int64]time.Time) error {
+	if err := r.DBRepo.UpdateLastSeenBatch(ctx, seen); err != nil {
+		return err
+	}
+	for telegramID := range seen {
+		r.invalidateUser(ctx, telegramID)
+	}
+	return nil
+}
 
-	if err != nil {
-		// Проверяем, содержит ли ошибка "no rows in result set"
-		if err.Error() == sql.ErrNoRows.Error() || strings.Contains(err.Error(), "no rows") {
-			return nil, ErrUserNotFound
+// метод чтения пользователя: через загрузчик двухуровневого кэша, а если кэш его не поддерживает - кэш, затем БД
+func (r *BizRepository) readUser(ctx context.Context, telegramID int64) (*domain.User, error) {
+	if r.conf.Enabled {
+		user, ok, err := r.CacheRepo.loadUser(ctx, telegramID, r.conf.TTL, func(ctx context.Context) (*domain.User, error) {
+			return r.DBRepo.GetUserByTelegramID(ctx, telegramID)
+		})
+		if ok {
+			return user, err
 		}
-		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
 
-	user.Username = username.String
-	user.LastName = lastName.String
-
+	user, err := r.cachedUser(ctx, telegramID)
+	if err == nil {
+		return user, nil
+	}
+	user, err = r.DBRepo.GetUserByTelegramID(ctx, telegramID)
+	if err != nil {
+		return nil, err
+	}
+	r.cacheUser(ctx, user)
 	return user, nil
 }
 
-// метод обновления времени последнего посещения пользователем по ID из телеграмм
-func (r *BizRepository) UpdateLastSeen(ctx context.Context, telegramID int64) error {
-	query := `UPDATE users SET last_seen_at = NOW() WHERE telegram_id = $1`
+// метод для чтения пользователя из кэша (ошибка - промах или недоступность кэша)
+func (r *BizRepository) cachedUser(ctx context.Context, telegramID int64) (*domain.User, error) {
+	if !r.conf.Enabled {
+		return nil, global_cache.ErrNotFound
+	}
 
-	_, err := r.DBRepo.Pool.Exec(ctx, query, telegramID)
-	if err != nil {
-		return fmt.Errorf("failed to update last_seen: %w", err)
+	user, err := r.CacheRepo.getUser(ctx, telegramID)
+	if err != nil && !errors.Is(err, global_cache.ErrNotFound) {
+		// кэш - только ускорение: при его недоступности читаем из БД
+		slog.WarnContext(ctx, "failed to read cached user", "error", err)
 	}
+	return user, err
+}
 
-	return nil
+// метод для удаления пользователя из кэша после изменения в БД
+func (r *BizRepository) invalidateUser(ctx context.Context, telegramID int64) {
+	if !r.conf.Enabled {
+		return
+	}
+	if err := r.CacheRepo.deleteUser(ctx, telegramID); err != nil {
+		// запись в кэше устареет по TTL
+		slog.WarnContext(ctx, "failed to invalidate cached user", "error", err)
+	}
 }
 
-// Вспомогательная функция
-func nullString(s string) sql.NullString {
-	if s == "" {
-		return sql.NullString{Valid: false}
+// метод для записи пользователя в кэш (ошибка не мешает обработке)
+func (r *BizRepository) cacheUser(ctx context.Context, user *domain.User) {
+	if !r.conf.Enabled {
+		return
+	}
+	if err := r.CacheRepo.setUser(ctx, user, r.conf.TTL); err != nil {
+		slog.WarnContext(ctx, "failed to cache user", "error", err)
 	}
-	return sql.NullString{String: s, Valid: true}
 }
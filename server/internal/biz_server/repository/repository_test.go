@@ -0,0 +1,348 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"global_models/global_cache"
+	"pkg/configs"
+	memorycache "pkg/memory_cache"
+	tieredcache "pkg/tiered_cache"
+	"server/internal/domain"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// mapCache - кэш в памяти для тестов, который можно сделать недоступным
+type mapCache struct {
+	*memorycache.MemoryCache
+	err error // если задана - чтение, запись и удаление возвращают её (кэш недоступен)
+}
+
+func newMapCache() *mapCache {
+	return &mapCache{MemoryCache: memorycache.NewMemoryCache(0)}
+}
+
+func (c *mapCache) Set(ctx context.Context, key string, value []byte, expiration time.Duration) error {
+	if c.err != nil {
+		return c.err
+	}
+	return c.MemoryCache.Set(ctx, key, value, expiration)
+}
+
+func (c *mapCache) GetBytes(ctx context.Context, key string) ([]byte, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	return c.MemoryCache.GetBytes(ctx, key)
+}
+
+func (c *mapCache) Delete(ctx context.Context, key string) error {
+	if c.err != nil {
+		return c.err
+	}
+	return c.MemoryCache.Delete(ctx, key)
+}
+
+// countingStore считает обращения к хранилищу (каждое - запрос к БД)
+type countingStore struct {
+	Repositories
+	calls   atomic.Int64
+	batches atomic.Int64
+}
+
+func (s *countingStore) CreateUser(ctx context.Context, user *domain.User) error {
+	s.calls.Add(1)
+	return s.Repositories.CreateUser(ctx, user)
+}
+
+func (s *countingStore) UpsertUser(ctx context.Context, user *domain.User) (bool, error) {
+	s.calls.Add(1)
+	return s.Repositories.UpsertUser(ctx, user)
+}
+
+func (s *countingStore) Update(ctx context.Context, user *domain.User) error {
+	s.calls.Add(1)
+	return s.Repositories.Update(ctx, user)
+}
+
+func (s *countingStore) GetUserByTelegramID(ctx context.Context, telegramID int64) (*domain.User, error) {
+	s.calls.Add(1)
+	return s.Repositories.GetUserByTelegramID(ctx, telegramID)
+}
+
+func (s *countingStore) UpdateLastSeen(ctx context.Context, telegramID int64) error {
+	s.calls.Add(1)
+	return s.Repositories.UpdateLastSeen(ctx, telegramID)
+}
+
+func (s *countingStore) UpdateLastSeenBatch(ctx context.Context, seen map[int64]time.Time) error {
+	s.calls.Add(1)
+	s.batches.Add(1)
+	return s.Repositories.UpdateLastSeenBatch(ctx, seen)
+}
+
+// слой репозитория с кэшем и пакетной записью (интервал большой - пишет только пачка или Close)
+func newTestBizRepository(tb testing.TB, store Repositories, cache global_cache.Cache) *BizRepository {
+	tb.Helper()
+	return newTestBizRepositoryWithConf(tb, store, cache, &configs.UserCacheConfig{
+		Enabled:           true,
+		TTL:               time.Minute,
+		LastSeenFlush:     time.Hour,
+		LastSeenBatchSize: 1000,
+	})
+}
+
+func newTestBizRepositoryWithConf(tb testing.TB, store Repositories, cache global_cache.Cache, conf *configs.UserCacheConfig) *BizRepository {
+	tb.Helper()
+	cacheRepo, err := NewBizCacheRepo(cache, "test")
+	if err != nil {
+		tb.Fatalf("NewBizCacheRepo: %v", err)
+	}
+	repo, err := NewBizRepository(store, cacheRepo, conf)
+	if err != nil {
+		tb.Fatalf("NewBizRepository: %v", err)
+	}
+	tb.Cleanup(func() { repo.Close(context.Background()) })
+	return repo
+}
+
+func TestBizRepositoryUserCache(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("повторное чтение идёт из кэша", func(t *testing.T) {
+		store := &countingStore{Repositories: NewMemoryRepository()}
+		store.Repositories.CreateUser(ctx, testUser(1))
+		repo := newTestBizRepository(t, store, newMapCache())
+
+		for range 3 {
+			if _, err := repo.GetUserByTelegramID(ctx, 1); err != nil {
+				t.Fatalf("GetUserByTelegramID: %v", err)
+			}
+		}
+		if got := store.calls.Load(); got != 1 {
+			t.Errorf("обращений к хранилищу: %d, ожидали 1", got)
+		}
+	})
+
+	t.Run("Update сбрасывает запись в кэше", func(t *testing.T) {
+		repo := newTestBizRepository(t, NewMemoryRepository(), newMapCache())
+		user := testUser(2)
+		repo.CreateUser(ctx, user)
+		repo.GetUserByTelegramID(ctx, 2)
+
+		user.FirstName = "Другое имя"
+		if err := repo.Update(ctx, user); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+
+		got, err := repo.GetUserByTelegramID(ctx, 2)
+		if err != nil {
+			t.Fatalf("GetUserByTelegramID: %v", err)
+		}
+		if got.FirstName != "Другое имя" {
+			t.Errorf("из кэша прочитаны устаревшие данные: %q", got.FirstName)
+		}
+	})
+
+	t.Run("недоступный кэш не мешает чтению", func(t *testing.T) {
+		cache := newMapCache()
+		cache.err = errors.New("connection refused")
+		repo := newTestBizRepository(t, NewMemoryRepository(), cache)
+		if err := repo.CreateUser(ctx, testUser(3)); err != nil {
+			t.Fatalf("CreateUser: %v", err)
+		}
+		if _, err := repo.GetUserByTelegramID(ctx, 3); err != nil {
+			t.Errorf("GetUserByTelegramID: %v", err)
+		}
+	})
+
+	t.Run("отсутствие пользователя не кэшируется", func(t *testing.T) {
+		repo := newTestBizRepository(t, NewMemoryRepository(), newMapCache())
+		if _, err := repo.GetUserByTelegramID(ctx, 4); !errors.Is(err, ErrUserNotFound) {
+			t.Fatalf("ожидали ErrUserNotFound, получили %v", err)
+		}
+		repo.CreateUser(ctx, testUser(4))
+		if _, err := repo.GetUserByTelegramID(ctx, 4); err != nil {
+			t.Errorf("созданный пользователь не найден: %v", err)
+		}
+	})
+}
+
+func TestBizRepositoryTieredUserCache(t *testing.T) {
+	ctx := context.Background()
+
+	newTiered := func(t *testing.T) global_cache.Cache {
+		remote := memorycache.NewMemoryCache(0)
+		cache, err := tieredcache.New(remote, remote, configs.UseDefaultTieredCacheConfig(), "test")
+		if err != nil {
+			t.Fatalf("tieredcache.New: %v", err)
+		}
+		t.Cleanup(func() { cache.Close(); remote.Close() })
+		return cache
+	}
+
+	t.Run("отсутствующий пользователь запрашивается из БД один раз", func(t *testing.T) {
+		store := &countingStore{Repositories: NewMemoryRepository()}
+		repo := newTestBizRepository(t, store, newTiered(t))
+
+		for range 3 {
+			if _, err := repo.GetUserByTelegramID(ctx, 5); !errors.Is(err, ErrUserNotFound) {
+				t.Fatalf("ожидали ErrUserNotFound, получили %v", err)
+			}
+		}
+		if got := store.calls.Load(); got != 1 {
+			t.Errorf("обращений к хранилищу: %d, ожидали 1", got)
+		}
+
+		// регистрация сбрасывает отметку об отсутствии
+		if _, err := repo.UpsertUser(ctx, testUser(5)); err != nil {
+			t.Fatalf("UpsertUser: %v", err)
+		}
+		if _, err := repo.GetUserByTelegramID(ctx, 5); err != nil {
+			t.Errorf("зарегистрированный пользователь не найден: %v", err)
+		}
+	})
+
+	t.Run("Update сбрасывает запись в кэше", func(t *testing.T) {
+		repo := newTestBizRepository(t, NewMemoryRepository(), newTiered(t))
+		user := testUser(6)
+		repo.CreateUser(ctx, user)
+		repo.GetUserByTelegramID(ctx, 6)
+
+		user.FirstName = "Другое имя"
+		if err := repo.Update(ctx, user); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+		if got, err := repo.GetUserByTelegramID(ctx, 6); err != nil || got.FirstName != "Другое имя" {
+			t.Errorf("из кэша прочитаны устаревшие данные: %+v, %v", got, err)
+		}
+	})
+}
+
+func TestBizRepositoryLastSeen(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("активность копится и пишется одной пачкой", func(t *testing.T) {
+		store := &countingStore{Repositories: NewMemoryRepository()}
+		for id := int64(1); id <= 3; id++ {
+			store.Repositories.CreateUser(ctx, testUser(id))
+		}
+		repo := newTestBizRepository(t, store, newMapCache())
+
+		for range 5 {
+			for id := int64(1); id <= 3; id++ {
+				repo.UpdateLastSeen(ctx, id)
+			}
+		}
+		if got := store.calls.Load(); got != 0 {
+			t.Fatalf("до записи пачки обращений к хранилищу: %d", got)
+		}
+
+		if err := repo.Close(ctx); err != nil {
+			t.Fatalf("Close: %v", err)
+		}
+		if got := store.batches.Load(); got != 1 {
+			t.Errorf("пачек: %d, ожидали 1", got)
+		}
+	})
+
+	t.Run("набранная пачка пишется не дожидаясь интервала", func(t *testing.T) {
+		store := &countingStore{Repositories: NewMemoryRepository()}
+		repo := newTestBizRepositoryWithConf(t, store, newMapCache(), &configs.UserCacheConfig{
+			Enabled:           true,
+			TTL:               time.Minute,
+			LastSeenFlush:     time.Hour,
+			LastSeenBatchSize: 2,
+		})
+
+		repo.UpdateLastSeen(ctx, 1)
+		repo.UpdateLastSeen(ctx, 2)
+
+		deadline := time.Now().Add(time.Second)
+		for store.batches.Load() == 0 && time.Now().Before(deadline) {
+			time.Sleep(5 * time.Millisecond)
+		}
+		if store.batches.Load() == 0 {
+			t.Error("пачка не записана")
+		}
+	})
+
+	t.Run("ошибка записи не теряет активность", func(t *testing.T) {
+		store := &failingBatchStore{Repositories: NewMemoryRepository(), fail: true}
+		user := testUser(5)
+		user.LastSeenAt = time.Now().Add(-time.Hour)
+		store.Repositories.CreateUser(ctx, user)
+		repo := newTestBizRepository(t, store, newMapCache())
+
+		repo.UpdateLastSeen(ctx, 5)
+		if err := repo.lastSeen.flush(ctx); err == nil {
+			t.Fatal("ожидали ошибку записи")
+		}
+
+		store.fail = false
+		if err := repo.Close(ctx); err != nil {
+			t.Fatalf("Close: %v", err)
+		}
+		got, _ := store.Repositories.GetUserByTelegramID(ctx, 5)
+		if !got.LastSeenAt.After(user.LastSeenAt) {
+			t.Error("время активности потеряно после ошибки записи")
+		}
+	})
+}
+
+// failingBatchStore - хранилище, у которого можно сломать пакетную запись
+type failingBatchStore struct {
+	Repositories
+	fail bool
+}
+
+func (s *failingBatchStore) UpdateLastSeenBatch(ctx context.Context, seen map[int64]time.Time) error {
+	if s.fail {
+		return errors.New("database is unavailable")
+	}
+	return s.Repositories.UpdateLastSeenBatch(ctx, seen)
+}
+
+// BenchmarkReturningUserUpdate - обращения к БД на update от уже известного пользователя
+// (то, что делает RegisterOrUpdate: чтение пользователя + отметка активности).
+// Метрика queries/op - сколько запросов к хранилищу приходится на один update
+func BenchmarkReturningUserUpdate(b *testing.B) {
+	ctx := context.Background()
+	const users = 100
+
+	run := func(b *testing.B, repo Repositories, store *countingStore, closeRepo func()) {
+		for id := int64(1); id <= users; id++ {
+			store.Repositories.CreateUser(ctx, testUser(id))
+		}
+
+		b.ResetTimer()
+		for i := 0; b.Loop(); i++ {
+			id := int64(i%users) + 1
+			if _, err := repo.GetUserByTelegramID(ctx, id); err != nil {
+				b.Fatal(err)
+			}
+			if err := repo.UpdateLastSeen(ctx, id); err != nil {
+				b.Fatal(err)
+			}
+		}
+		closeRepo()
+		b.ReportMetric(float64(store.calls.Load())/float64(b.N), "queries/op")
+	}
+
+	b.Run("без кэша", func(b *testing.B) {
+		store := &countingStore{Repositories: NewMemoryRepository()}
+		run(b, store, store, func() {})
+	})
+
+	b.Run("кэш и пакетный last_seen", func(b *testing.B) {
+		store := &countingStore{Repositories: NewMemoryRepository()}
+		repo := newTestBizRepositoryWithConf(b, store, newMapCache(), &configs.UserCacheConfig{
+			Enabled:           true,
+			TTL:               time.Minute,
+			LastSeenFlush:     time.Second,
+			LastSeenBatchSize: 500,
+		})
+		run(b, repo, store, func() { repo.Close(ctx) })
+	})
+}
@@ -0,0 +1,272 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"global_models/global_db"
+	"pkg/scheduler"
+	"time"
+)
+
+// проверка реализации интерфейса
+var _ scheduler.Store = (*SchedulerDBRepository)(nil)
+
+// SchedulerDBRepository - хранилище задач планировщика и истории запусков в Postgres
+type SchedulerDBRepository struct {
+	Pool global_db.Pool
+}
+
+// конструктор для хранилища задач планировщика
+func NewSchedulerDBRepository(pool global_db.Pool) *SchedulerDBRepository {
+	return &SchedulerDBRepository{Pool: pool}
+}
+
+// колонки задачи (порядок совпадает со scanJob)
+const schedulerJobColumns = `name, kind, handler, spec, timezone, payload, paused, triggered,
+    next_run_at, last_run_at, last_status, last_fence, version, created_at, updated_at`
+
+// колонки записи истории (порядок совпадает со scanRun)
+const schedulerRunColumns = `id, job_name, trigger, fence, scheduled_at, started_at, finished_at, status, error`
+
+// функция чтения задачи из строки результата
+func scanJob(row global_db.Row) (*scheduler.Job, error) {
+	var job scheduler.Job
+	var kind, status string
+	var payload []byte
+	err := row.Scan(
+		&job.Name, &kind, &job.Handler, &job.Spec, &job.Timezone, &payload, &job.Paused, &job.Triggered,
+		&job.NextRunAt, &job.LastRunAt, &status, &job.LastFence, &job.Version, &job.CreatedAt, &job.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	job.Kind, job.LastStatus, job.Payload = scheduler.Kind(kind), scheduler.RunStatus(status), payload
+	return &job, nil
+}
+
+// функция чтения записи истории из строки результата
+func scanRun(row global_db.Row) (*scheduler.Run, error) {
+	var run scheduler.Run
+	var trigger, status string
+	err := row.Scan(
+		&run.ID, &run.JobName, &trigger, &run.Fence, &run.ScheduledAt, &run.StartedAt, &run.FinishedAt, &status, &run.Error,
+	)
+	if err != nil {
+		return nil, err
+	}
+	run.Trigger, run.Status = scheduler.Trigger(trigger), scheduler.RunStatus(status)
+	return &run, nil
+}
+
+// функция значения payload для jsonb (пустой и null - NULL)
+func jobPayload(job *scheduler.Job) any {
+	if len(job.Payload) == 0 || string(job.Payload) == "null" {
+		return nil
+	}
+	return string(job.Payload)
+}
+
+// UpsertJob создаёт задачу или обновляет её определение
+func (r *SchedulerDBRepository) UpsertJob(ctx context.Context, job *scheduler.Job) error {
+	// расписание таймера заменяется всегда, периодической задачи - только при смене spec/timezone
+	row := r.Pool.QueryRow(ctx, `
+        INSERT INTO scheduler_jobs (name, kind, handler, spec, timezone, payload, next_run_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+        ON CONFLICT (name) DO UPDATE SET
+            kind = EXCLUDED.kind,
+            handler = EXCLUDED.handler,
+            spec = EXCLUDED.spec,
+            timezone = EXCLUDED.timezone,
+            payload = EXCLUDED.payload,
+            next_run_at = CASE
+                WHEN EXCLUDED.kind = 'once'
+                  OR scheduler_jobs.kind <> EXCLUDED.kind
+                  OR scheduler_jobs.next_run_at IS NULL
+                  OR scheduler_jobs.spec <> EXCLUDED.spec
+                  OR scheduler_jobs.timezone <> EXCLUDED.timezone
+                THEN EXCLUDED.next_run_at
+                ELSE scheduler_jobs.next_run_at
+            END,
+            version = scheduler_jobs.version + 1,
+            updated_at = NOW()
+        RETURNING `+schedulerJobColumns,
+		job.Name, string(job.Kind), job.Handler, job.Spec, job.Timezone, jobPayload(job), job.NextRunAt,
+	)
+	stored, err := scanJob(row)
+	if err != nil {
+		return fmt.Errorf("failed to save scheduler job: %w", err)
+	}
+	*job = *stored
+	return nil
+}
+
+// GetJob возвращает задачу или scheduler.ErrJobNotFound
+func (r *SchedulerDBRepository) GetJob(ctx context.Context, name string) (*scheduler.Job, error) {
+	job, err := scanJob(r.Pool.QueryRow(ctx, `SELECT `+schedulerJobColumns+` FROM scheduler_jobs WHERE name = $1`, name))
+	if isNoRows(err) {
+		return nil, scheduler.ErrJobNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get scheduler job: %w", err)
+	}
+	return job, nil
+}
+
+// ListJobs возвращает все задачи по имени
+func (r *SchedulerDBRepository) ListJobs(ctx context.Context) ([]*scheduler.Job, error) {
+	return r.queryJobs(ctx, `SELECT `+schedulerJobColumns+` FROM scheduler_jobs ORDER BY name`)
+}
+
+// DueJobs возвращает задачи, которые пора запустить (сначала самые просроченные)
+func (r *SchedulerDBRepository) DueJobs(ctx context.Context, now time.Time, limit int) ([]*scheduler.Job, error) {
+	return r.queryJobs(ctx, `
+        SELECT `+schedulerJobColumns+`
+        FROM scheduler_jobs
+        WHERE triggered OR (NOT paused AND next_run_at <= $1)
+        ORDER BY LEAST(COALESCE(next_run_at, $1), $1), name
+        LIMIT $2`,
+		now, limit,
+	)
+}
+
+// метод чтения списка задач
+func (r *SchedulerDBRepository) queryJobs(ctx context.Context, query string, args ...any) ([]*scheduler.Job, error) {
+	rows, err := r.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query scheduler jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []*scheduler.Job
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan scheduler job: %w", err)
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
+
+// ClaimRun атомарно отмечает запуск задачи и записывает его в историю
+func (r *SchedulerDBRepository) ClaimRun(ctx context.Context, job *scheduler.Job, next *time.Time, run *scheduler.Run) (bool, error) {
+	claimed := false
+	err := global_db.WithTx(ctx, r.Pool, global_db.TxOptions{}, func(ctx context.Context, tx global_db.Tx) error {
+		claimed = false
+
+		// версия проверяется в самом UPDATE: из двух экземпляров, прочитавших задачу, отметит только один
+		affected, err := tx.Exec(ctx, `
+            UPDATE scheduler_jobs SET
+                next_run_at = $3,
+                triggered = FALSE,
+                last_run_at = $4,
+                last_fence = $5,
+                version = version + 1,
+                updated_at = NOW()
+            WHERE name = $1 AND version = $2`,
+			job.Name, job.Version, next, run.StartedAt, run.Fence,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to claim scheduler job: %w", err)
+		}
+		if affected == 0 {
+			return nil
+		}
+
+		err = tx.QueryRow(ctx, `
+            INSERT INTO scheduler_runs (job_name, trigger, fence, scheduled_at, started_at, status)
+            VALUES ($1, $2, $3, $4, $5, $6)
+            RETURNING id`,
+			run.JobName, string(run.Trigger), run.Fence, run.ScheduledAt, run.StartedAt, string(run.Status),
+		).Scan(&run.ID)
+		if err != nil {
+			return fmt.Errorf("failed to save scheduler run: %w", err)
+		}
+		claimed = true
+		return nil
+	})
+	return claimed, err
+}
+
+// FinishRun сохраняет результат запуска и last_status задачи
+func (r *SchedulerDBRepository) FinishRun(ctx context.Context, run *scheduler.Run) error {
+	batch := &global_db.Batch{}
+	batch.Queue(`UPDATE scheduler_runs SET finished_at = $2, status = $3, error = $4 WHERE id = $1`,
+		run.ID, run.FinishedAt, string(run.Status), run.Error)
+	batch.Queue(`UPDATE scheduler_jobs SET last_status = $2 WHERE name = $1`, run.JobName, string(run.Status))
+
+	results := r.Pool.SendBatch(ctx, batch)
+	defer results.Close()
+	for range 2 {
+		if _, err := results.Exec(); err != nil {
+			return fmt.Errorf("failed to finish scheduler run: %w", err)
+		}
+	}
+	return nil
+}
+
+// SetPaused ставит задачу на паузу или снимает с неё
+func (r *SchedulerDBRepository) SetPaused(ctx context.Context, name string, paused bool) error {
+	return r.updateJob(ctx, `UPDATE scheduler_jobs SET paused = $2, version = version + 1, updated_at = NOW() WHERE name = $1`, name, paused)
+}
+
+// Trigger запрашивает запуск вне расписания
+func (r *SchedulerDBRepository) Trigger(ctx context.Context, name string) error {
+	return r.updateJob(ctx, `UPDATE scheduler_jobs SET triggered = TRUE, version = version + 1, updated_at = NOW() WHERE name = $1`, name)
+}
+
+// DeleteJob удаляет задачу (история удаляется каскадно)
+func (r *SchedulerDBRepository) DeleteJob(ctx context.Context, name string) error {
+	return r.updateJob(ctx, `DELETE FROM scheduler_jobs WHERE name = $1`, name)
+}
+
+// метод изменения одной задачи (ни одной строки - scheduler.ErrJobNotFound)
+func (r *SchedulerDBRepository) updateJob(ctx context.Context, query string, args ...any) error {
+	affected, err := r.Pool.Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to update scheduler job: %w", err)
+	}
+	if affected == 0 {
+		return scheduler.ErrJobNotFound
+	}
+	return nil
+}
+
+// Runs возвращает последние запуски задачи (limit <= 0 - все)
+func (r *SchedulerDBRepository) Runs(ctx context.Context, name string, limit int) ([]*scheduler.Run, error) {
+	var limitArg any
+	if limit > 0 {
+		limitArg = limit
+	}
+	rows, err := r.Pool.Query(ctx, `
+        SELECT `+schedulerRunColumns+`
+        FROM scheduler_runs
+        WHERE job_name = $1
+        ORDER BY id DESC
+        LIMIT $2`,
+		name, limitArg,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query scheduler runs: %w", err)
+	}
+	defer rows.Close()
+
+	var runs []*scheduler.Run
+	for rows.Next() {
+		run, err := scanRun(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan scheduler run: %w", err)
+		}
+		runs = append(runs, run)
+	}
+	return runs, rows.Err()
+}
+
+// DeleteRunsBefore удаляет историю запусков, начатых раньше before
+func (r *SchedulerDBRepository) DeleteRunsBefore(ctx context.Context, before time.Time) (int64, error) {
+	deleted, err := r.Pool.Exec(ctx, `DELETE FROM scheduler_runs WHERE started_at < $1`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete scheduler runs: %w", err)
+	}
+	return deleted, nil
+}
@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+	"os"
+	"pkg/configs"
+	"pkg/migrator"
+	postgresdb "pkg/postgres_db"
+	"pkg/scheduler"
+	"pkg/scheduler/schedulertest"
+	"server/migrations"
+	"testing"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// хранилище планировщика на Postgres должно вести себя так же, как scheduler.MemoryStore
+func TestSchedulerPostgresContract(t *testing.T) {
+	dsn := os.Getenv(testPostgresDSNEnv)
+	if dsn == "" {
+		t.Skipf("%s не задан - контракт планировщика на Postgres пропущен", testPostgresDSNEnv)
+	}
+
+	ctx := context.Background()
+
+	m, err := migrator.New(dsn, migrations.FS, configs.UseDefaultMigrationsConfig())
+	if err != nil {
+		t.Fatalf("migrator: %v", err)
+	}
+	defer m.Close()
+	if err := m.Up(ctx); err != nil {
+		t.Fatalf("migrations: %v", err)
+	}
+
+	pool, err := pgxpool.Connect(ctx, dsn)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer pool.Close()
+	adapter := postgresdb.NewPoolAdapter(pool)
+
+	schedulertest.RunStoreContract(t, func(t *testing.T) scheduler.Store {
+		if _, err := adapter.Exec(ctx, `TRUNCATE scheduler_jobs, scheduler_runs RESTART IDENTITY CASCADE`); err != nil {
+			t.Fatalf("truncate: %v", err)
+		}
+		return NewSchedulerDBRepository(adapter)
+	})
+}
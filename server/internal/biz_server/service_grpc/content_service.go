@@ -0,0 +1,57 @@
+package servicegrpc
+
+import (
+	"context"
+	"log/slog"
+	"server/internal/biz_server/repository"
+	"server/internal/domain"
+	"sync"
+	"time"
+)
+
+// сколько бот использует прочитанные тексты, прежде чем перечитать их из хранилища
+// (изменения из админки доходят до всех экземпляров сервера не позже, чем через это время)
+const contentTTL = 30 * time.Second
+
+// ========== Content Service ==========
+type ContentService interface {
+	Text(ctx context.Context, key domain.ContentKey) string
+}
+
+// структура сервиса текстов бота
+type contentService struct {
+	content repository.ContentRepository
+	now     func() time.Time
+
+	mu       sync.Mutex
+	saved    map[domain.ContentKey]domain.ContentItem
+	loadedAt time.Time
+}
+
+// конструктор сервиса текстов бота
+func NewContentService(content repository.ContentRepository) ContentService {
+	return &contentService{content: content, now: time.Now}
+}
+
+// метод возвращает текст бота: изменённый в админке или по умолчанию.
+// Если хранилище недоступно - последние прочитанные тексты (или тексты по умолчанию), ответ клиенту не ломается
+func (s *contentService) Text(ctx context.Context, key domain.ContentKey) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.saved == nil || s.now().Sub(s.loadedAt) >= contentTTL {
+		saved, err := s.content.ListContent(ctx)
+		if err != nil {
+			slog.WarnContext(ctx, "failed to load bot content, using cached texts", "error", err)
+		} else {
+			s.saved = saved
+		}
+		// при ошибке тоже ждём contentTTL, чтобы не ходить в хранилище на каждое сообщение
+		s.loadedAt = s.now()
+	}
+
+	if item, ok := s.saved[key]; ok {
+		return item.Text
+	}
+	return domain.DefaultContent(key)
+}
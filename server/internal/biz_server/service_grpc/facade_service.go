@@ -1,6 +1,8 @@
 package servicegrpc
 
 import (
+	"pkg/configs"
+	"server/internal/biz_server/chatevents"
 	"server/internal/biz_server/grpcclient"
 	"server/internal/biz_server/repository"
 )
@@ -10,13 +12,26 @@ type BizServiceFacade struct {
 	Users     UserService
 	Messages  MessageService
 	Responses ResponseGenerator
+	Leads     LeadService
+	Content   ContentService
+	Handoffs  HandoffService
+	Stats     StatsService
 }
 
 // конструктор для GRPC сервиса
-func NewBizServiceFacade(repo *repository.BizRepository, grpcClient *grpcclient.BotGrpcClient) *BizServiceFacade {
+// (repo - любая реализация хранилищ: Postgres в проде, память в тестах; leads - хранилище заявок;
+// content - тексты бота, изменённые в админке; handoffs - диалоги с оператором, events - события для его консоли,
+// handoffConf - таймаут неактивности диалога; stats - агрегаты аналитики для /stats)
+func NewBizServiceFacade(repo repository.Repositories, leads repository.LeadRepository, content repository.ContentRepository,
+	handoffs repository.HandoffRepository, stats StatsRepositories, events *chatevents.Bus, handoffConf *configs.HandoffConfig,
+	grpcClient *grpcclient.BotGrpcClient) *BizServiceFacade {
 	return &BizServiceFacade{
 		Users:     NewUserService(repo),
-		Messages:  NewMessageService(repo, grpcClient),
+		Messages:  NewMessageService(repo, repo, grpcClient),
 		Responses: NewResponseGenerator(),
+		Leads:     NewLeadService(leads),
+		Content:   NewContentService(content),
+		Handoffs:  NewHandoffService(handoffs, events, handoffConf),
+		Stats:     NewStatsService(stats),
 	}
 }
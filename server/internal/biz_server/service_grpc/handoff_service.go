@@ -0,0 +1,151 @@
+package servicegrpc
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"pkg/configs"
+	"pkg/logger"
+	"pkg/tracing"
+	"server/internal/biz_server/chatevents"
+	"server/internal/biz_server/repository"
+	"server/internal/domain"
+	"time"
+)
+
+// ========== Handoff Service ==========
+type HandoffService interface {
+	// Request открывает диалог с оператором по просьбе клиента (created = false - диалог уже открыт)
+	Request(ctx context.Context, telegramID int64) (created bool, err error)
+
+	// Intercept возвращает true, если чат ведёт оператор (бот не отвечает), и продлевает диалог.
+	// Диалог без сообщений дольше inactivity_timeout закрывается здесь же, не дожидаясь планировщика.
+	// При ошибке хранилища возвращает false: пусть лучше ответит бот, чем никто
+	Intercept(ctx context.Context, telegramID int64) bool
+
+	// Publish отправляет сообщение чата в консоль оператора
+	Publish(ctx context.Context, msg *domain.Message)
+}
+
+// структура сервиса диалогов с оператором
+type handoffService struct {
+	repo   repository.HandoffRepository
+	events *chatevents.Bus // nil - консоль оператора не получает события
+	conf   *configs.HandoffConfig
+	now    func() time.Time
+}
+
+// конструктор для сервиса диалогов с оператором
+func NewHandoffService(repo repository.HandoffRepository, events *chatevents.Bus, conf *configs.HandoffConfig) HandoffService {
+	if conf == nil {
+		conf = configs.UseDefaultHandoffConfig()
+	}
+	return &handoffService{repo: repo, events: events, conf: conf, now: time.Now}
+}
+
+// метод открытия диалога по кнопке "Позвать мастера"
+func (s *handoffService) Request(ctx context.Context, telegramID int64) (created bool, err error) {
+	ctx, span := tracing.Start(ctx, "service.RequestHandoff")
+	defer func() { tracing.End(span, err) }()
+
+	if telegramID == 0 {
+		return false, ErrInvalidUser
+	}
+
+	handoff := &domain.Handoff{TelegramID: telegramID, Reason: domain.HandoffByClient}
+	created, err = s.repo.OpenHandoff(ctx, handoff)
+	if err != nil {
+		return false, err
+	}
+
+	// открытый диалог простаивал дольше таймаута: новый запрос не должен в нём потеряться
+	if now := s.now(); !created && s.idle(handoff, now) {
+		s.expire(ctx, telegramID, now)
+		handoff = &domain.Handoff{TelegramID: telegramID, Reason: domain.HandoffByClient}
+		if created, err = s.repo.OpenHandoff(ctx, handoff); err != nil {
+			return false, err
+		}
+	}
+	if created {
+		s.publish(ctx, domain.ChatEvent{
+			Type:       domain.ChatEventHandoffOpened,
+			TelegramID: telegramID,
+			Reason:     string(handoff.Reason),
+			At:         handoff.OpenedAt,
+		})
+	}
+	return created, nil
+}
+
+// метод проверки, ведёт ли чат оператор
+func (s *handoffService) Intercept(ctx context.Context, telegramID int64) bool {
+	if telegramID == 0 {
+		return false
+	}
+	handoff, err := s.repo.GetOpenHandoff(ctx, telegramID)
+	if errors.Is(err, repository.ErrHandoffNotFound) {
+		return false
+	}
+	if err != nil {
+		slog.WarnContext(ctx, "failed to check handoff, bot replies", "user_id", logger.MaskID(telegramID), "error", err)
+		return false
+	}
+
+	// диалог простаивал дольше таймаута: планировщик выключен или ещё не дошёл до него - закрываем сами
+	now := s.now()
+	if s.idle(handoff, now) {
+		s.expire(ctx, telegramID, now)
+		return false
+	}
+
+	// сообщение клиента продлевает диалог (таймаут неактивности считается от него)
+	if err := s.repo.TouchHandoff(ctx, telegramID, now); err != nil {
+		slog.WarnContext(ctx, "failed to touch handoff", "user_id", logger.MaskID(telegramID), "error", err)
+	}
+	return true
+}
+
+// метод проверяет, простаивал ли диалог дольше таймаута неактивности
+func (s *handoffService) idle(handoff *domain.Handoff, now time.Time) bool {
+	return handoff.LastActivityAt.Before(now.Add(-s.conf.InactivityTimeout))
+}
+
+// метод закрытия простаивающего диалога по таймауту (бот сразу отвечает на сообщение клиента сам)
+func (s *handoffService) expire(ctx context.Context, telegramID int64, now time.Time) {
+	handoff, err := s.repo.CloseHandoff(ctx, telegramID, domain.HandoffClosedByTimeout, now)
+	if errors.Is(err, repository.ErrHandoffNotFound) {
+		// диалог уже закрыл планировщик или оператор
+		return
+	}
+	if err != nil {
+		slog.WarnContext(ctx, "failed to close idle handoff", "user_id", logger.MaskID(telegramID), "error", err)
+		return
+	}
+
+	slog.InfoContext(ctx, "handoff closed", "user_id", logger.MaskID(telegramID), "reason", handoff.CloseReason)
+	s.publish(ctx, domain.ChatEvent{
+		Type:       domain.ChatEventHandoffClosed,
+		TelegramID: telegramID,
+		Reason:     string(handoff.CloseReason),
+		At:         *handoff.ClosedAt,
+	})
+}
+
+// метод отправки сообщения в консоль оператора
+func (s *handoffService) Publish(ctx context.Context, msg *domain.Message) {
+	s.publish(ctx, domain.ChatEvent{
+		Type:       domain.ChatEventMessage,
+		TelegramID: msg.UserID,
+		Direction:  msg.Direction,
+		Text:       msg.Text,
+		Status:     msg.Status,
+		At:         msg.TimeStamp,
+	})
+}
+
+// метод публикации события (ошибка не мешает ответу клиенту)
+func (s *handoffService) publish(ctx context.Context, event domain.ChatEvent) {
+	if err := s.events.Publish(ctx, event); err != nil {
+		slog.WarnContext(ctx, "failed to publish chat event", "type", event.Type, "error", err)
+	}
+}
@@ -0,0 +1,122 @@
+package servicegrpc
+
+import (
+	"context"
+	"errors"
+	"pkg/configs"
+	"server/internal/biz_server/repository"
+	"server/internal/domain"
+	"testing"
+	"time"
+)
+
+func TestHandoffService(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("бот отвечает, пока клиент не позвал мастера", func(t *testing.T) {
+		repo := repository.NewMemoryRepository()
+		handoffs := NewHandoffService(repo, nil, nil)
+
+		if handoffs.Intercept(ctx, 42) {
+			t.Fatal("без диалога с оператором чат ведёт бот")
+		}
+
+		created, err := handoffs.Request(ctx, 42)
+		if err != nil || !created {
+			t.Fatalf("Request: created=%v %v", created, err)
+		}
+		if created, err := handoffs.Request(ctx, 42); err != nil || created {
+			t.Errorf("повторная просьба не открывает второй диалог: created=%v %v", created, err)
+		}
+		if !handoffs.Intercept(ctx, 42) {
+			t.Error("во время диалога с оператором бот не отвечает")
+		}
+		if handoffs.Intercept(ctx, 7) {
+			t.Error("диалог одного клиента не влияет на другие чаты")
+		}
+	})
+
+	t.Run("сообщение клиента продлевает диалог", func(t *testing.T) {
+		repo := repository.NewMemoryRepository()
+		handoffs := NewHandoffService(repo, nil, nil)
+		if _, err := handoffs.Request(ctx, 42); err != nil {
+			t.Fatalf("Request: %v", err)
+		}
+		opened, _ := repo.GetOpenHandoff(ctx, 42)
+
+		time.Sleep(5 * time.Millisecond)
+		handoffs.Intercept(ctx, 42)
+		touched, _ := repo.GetOpenHandoff(ctx, 42)
+		if !touched.LastActivityAt.After(opened.LastActivityAt) {
+			t.Errorf("активность не сдвинулась: %v -> %v", opened.LastActivityAt, touched.LastActivityAt)
+		}
+	})
+
+	t.Run("после закрытия диалога бот снова отвечает", func(t *testing.T) {
+		repo := repository.NewMemoryRepository()
+		handoffs := NewHandoffService(repo, nil, nil)
+		if _, err := handoffs.Request(ctx, 42); err != nil {
+			t.Fatalf("Request: %v", err)
+		}
+		if _, err := repo.CloseHandoff(ctx, 42, domain.HandoffClosedByOperator, time.Now()); err != nil {
+			t.Fatalf("CloseHandoff: %v", err)
+		}
+		if handoffs.Intercept(ctx, 42) {
+			t.Error("закрытый диалог не перехватывает сообщения")
+		}
+	})
+
+	t.Run("простаивающий диалог закрывается при сообщении клиента", func(t *testing.T) {
+		repo := repository.NewMemoryRepository()
+		conf := configs.UseDefaultHandoffConfig()
+		handoffs := NewHandoffService(repo, nil, conf).(*handoffService)
+		if _, err := handoffs.Request(ctx, 42); err != nil {
+			t.Fatalf("Request: %v", err)
+		}
+
+		// планировщик не закрыл диалог вовремя (выключен или нет лидера)
+		handoffs.now = func() time.Time { return time.Now().Add(conf.InactivityTimeout + time.Minute) }
+		if handoffs.Intercept(ctx, 42) {
+			t.Fatal("после таймаута неактивности бот снова отвечает")
+		}
+		if _, err := repo.GetOpenHandoff(ctx, 42); !errors.Is(err, repository.ErrHandoffNotFound) {
+			t.Errorf("диалог не закрыт: %v", err)
+		}
+		if created, err := handoffs.Request(ctx, 42); err != nil || !created {
+			t.Errorf("после закрытия по таймауту клиент может снова позвать мастера: created=%v %v", created, err)
+		}
+	})
+
+	t.Run("запрос мастера в простаивающем диалоге открывает новый", func(t *testing.T) {
+		repo := repository.NewMemoryRepository()
+		conf := configs.UseDefaultHandoffConfig()
+		handoffs := NewHandoffService(repo, nil, conf).(*handoffService)
+		if _, err := handoffs.Request(ctx, 42); err != nil {
+			t.Fatalf("Request: %v", err)
+		}
+		stale, err := repo.GetOpenHandoff(ctx, 42)
+		if err != nil {
+			t.Fatalf("GetOpenHandoff: %v", err)
+		}
+
+		// клиент снова зовёт мастера, когда прошлый диалог уже простаивал дольше таймаута
+		handoffs.now = func() time.Time { return time.Now().Add(conf.InactivityTimeout + time.Minute) }
+		if created, err := handoffs.Request(ctx, 42); err != nil || !created {
+			t.Fatalf("запрос потерялся в простаивающем диалоге: created=%v %v", created, err)
+		}
+		fresh, err := repo.GetOpenHandoff(ctx, 42)
+		if err != nil {
+			t.Fatalf("GetOpenHandoff: %v", err)
+		}
+		if fresh.ID == stale.ID {
+			t.Error("открыт тот же простаивающий диалог")
+		}
+	})
+
+	t.Run("без отправителя - ErrInvalidUser", func(t *testing.T) {
+		handoffs := NewHandoffService(repository.NewMemoryRepository(), nil, nil)
+		if _, err := handoffs.Request(ctx, 0); !errors.Is(err, ErrInvalidUser) {
+			t.Errorf("Request(0): %v", err)
+		}
+	})
+}
@@ -0,0 +1,41 @@
+package servicegrpc
+
+import (
+	"context"
+	"pkg/tracing"
+	"server/internal/biz_server/repository"
+	"server/internal/domain"
+)
+
+// ========== Lead Service ==========
+type LeadService interface {
+	// Open создаёт заявку пользователя (повторное согласие возвращает уже открытую, created = false)
+	Open(ctx context.Context, telegramID int64, source string) (lead *domain.Lead, created bool, err error)
+}
+
+// структура сервиса заявок
+type leadService struct {
+	repo repository.LeadRepository
+}
+
+// конструктор для сервиса заявок
+func NewLeadService(repo repository.LeadRepository) LeadService {
+	return &leadService{repo: repo}
+}
+
+// метод открытия заявки по согласию клиента на связь с мастером
+func (s *leadService) Open(ctx context.Context, telegramID int64, source string) (lead *domain.Lead, created bool, err error) {
+	ctx, span := tracing.Start(ctx, "service.OpenLead")
+	defer func() { tracing.End(span, err) }()
+
+	if telegramID == 0 {
+		return nil, false, ErrInvalidUser
+	}
+
+	lead = &domain.Lead{TelegramID: telegramID, Source: source}
+	created, err = s.repo.CreateLead(ctx, lead)
+	if err != nil {
+		return nil, false, err
+	}
+	return lead, created, nil
+}
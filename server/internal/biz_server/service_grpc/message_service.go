@@ -3,6 +3,7 @@ package servicegrpc
 import (
 	"context"
 	"fmt"
+	"pkg/tracing"
 	"server/internal/biz_server/grpcclient"
 	"server/internal/biz_server/repository"
 	"server/internal/domain"
@@ -17,33 +18,41 @@ type MessageService interface {
 
 // структура сервиса сообщений
 type messageService struct {
-	repo       *repository.BizRepository
+	messages   repository.MessageRepository
+	callbacks  repository.CallbackRepository
 	grpcClient *grpcclient.BotGrpcClient
 }
 
 // конструктор сервиса вообщений
-func NewMessageService(repo *repository.BizRepository, grpcClient *grpcclient.BotGrpcClient) MessageService {
+func NewMessageService(messages repository.MessageRepository, callbacks repository.CallbackRepository, grpcClient *grpcclient.BotGrpcClient) MessageService {
 	return &messageService{
-		repo:       repo,
+		messages:   messages,
+		callbacks:  callbacks,
 		grpcClient: grpcClient,
 	}
 }
 
 // метод для проверки и сохданения входящего сообщения (по GRPC) в базу
-func (s *messageService) CheckAndSaveMsg(ctx context.Context, msg *domain.Message) error {
+func (s *messageService) CheckAndSaveMsg(ctx context.Context, msg *domain.Message) (err error) {
+	ctx, span := tracing.Start(ctx, "service.CheckAndSaveMsg")
+	defer func() { tracing.End(span, err) }()
+
 	if msg == nil {
 		return fmt.Errorf("Incoming messgage can not be nil! [error in service layer]")
 	}
 
-	return s.repo.Save(ctx, msg)
+	return s.messages.Save(ctx, msg)
 }
 
-func (s *messageService) CheckAndSaveCallBack(ctx context.Context, callBackLog *domain.CallbackLog) error {
+func (s *messageService) CheckAndSaveCallBack(ctx context.Context, callBackLog *domain.CallbackLog) (err error) {
+	ctx, span := tracing.Start(ctx, "service.CheckAndSaveCallBack")
+	defer func() { tracing.End(span, err) }()
+
 	if callBackLog == nil {
 		return fmt.Errorf("callback log can not be nil")
 	}
 
-	return s.repo.SaveCallback(ctx, callBackLog)
+	return s.callbacks.SaveCallback(ctx, callBackLog)
 }
 
 func (s *messageService) ProcessIncomingMessage(ctx context.Context, req *domain.IncomingMessage) (*domain.MessageResponse, error) {
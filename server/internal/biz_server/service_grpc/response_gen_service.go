@@ -88,6 +88,9 @@ func (s *responseGenerator) getMainMenuKeyboard() *domain.ReplyMarkup {
 			{
 				{Text: "📚 Ознакомиться", CallbackData: "lookup"},
 			},
+			{
+				{Text: domain.HandoffButtonText, CallbackData: domain.HandoffCallback},
+			},
 		},
 	}
 }
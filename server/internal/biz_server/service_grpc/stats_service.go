@@ -0,0 +1,165 @@
+package servicegrpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"pkg/tracing"
+	"server/internal/biz_server/analytics"
+	"server/internal/biz_server/repository"
+	"server/internal/domain"
+	"strings"
+	"time"
+)
+
+// ErrStatsForbidden - /stats прислал не администратор и не из чата мастера
+var ErrStatsForbidden = errors.New("stats are available to admins only")
+
+// сколько источников показывать в /stats
+const statsTopSources = 3
+
+// ========== Stats Service ==========
+type StatsService interface {
+	// Report возвращает сводку аналитики для команды /stats (ErrStatsForbidden - нет доступа)
+	Report(ctx context.Context, telegramID, chatID int64) (string, error)
+}
+
+// StatsRepositories - хранилища для /stats: агрегаты аналитики, настройки (чат мастера) и администраторы
+type StatsRepositories interface {
+	repository.AnalyticsRepository
+	repository.SettingsRepository
+	GetAdmin(ctx context.Context, telegramID int64) (*domain.Admin, error)
+}
+
+// структура сервиса статистики
+type statsService struct {
+	repo StatsRepositories
+	now  func() time.Time
+}
+
+// конструктор для сервиса статистики
+func NewStatsService(repo StatsRepositories) StatsService {
+	return &statsService{repo: repo, now: time.Now}
+}
+
+// метод сводки: воронки за сегодня, вчера, эту и прошлую неделю и главные источники недели
+func (s *statsService) Report(ctx context.Context, telegramID, chatID int64) (text string, err error) {
+	ctx, span := tracing.Start(ctx, "service.StatsReport")
+	defer func() { tracing.End(span, err) }()
+
+	settings, err := s.authorize(ctx, telegramID, chatID)
+	if err != nil {
+		return "", err
+	}
+	loc, err := time.LoadLocation(settings.Timezone)
+	if err != nil {
+		// часовой пояс проверяется при сохранении, сюда попадаем только после удаления зоны из tzdata
+		loc = time.UTC
+	}
+
+	days, err := s.repo.ListFunnelStats(ctx, domain.PeriodDay, 2)
+	if err != nil {
+		return "", err
+	}
+	weeks, err := s.repo.ListFunnelStats(ctx, domain.PeriodWeek, 2)
+	if err != nil {
+		return "", err
+	}
+	sources, err := s.repo.ListSourceStats(ctx, domain.PeriodWeek, 1)
+	if err != nil {
+		return "", err
+	}
+	if len(days) == 0 && len(weeks) == 0 {
+		return "📊 Статистика ещё не посчитана, загляните через час.", nil
+	}
+
+	var b strings.Builder
+	b.WriteString("📊 Статистика\n")
+	// агрегаты могли давно не пересчитываться - подписываем периоды по датам, а не по порядку строк
+	now := s.now()
+	today := analytics.PeriodDate(now, domain.PeriodDay, loc)
+	thisWeek := analytics.PeriodDate(now, domain.PeriodWeek, loc)
+	for _, f := range days {
+		writeFunnel(&b, dayTitle(f.Start, today), f)
+	}
+	for _, f := range weeks {
+		writeFunnel(&b, weekTitle(f.Start, thisWeek), f)
+	}
+	if len(sources) > 0 {
+		b.WriteString("\nИсточники недели:\n")
+		for _, source := range sources[:min(statsTopSources, len(sources))] {
+			fmt.Fprintf(&b, "• %s: %d новых, %d на связь\n", source.Source, source.NewUsers, source.ContactedYes)
+		}
+	}
+	return strings.TrimRight(b.String(), "\n"), nil
+}
+
+// метод проверки доступа: администратор админки (любая роль) или чат уведомлений мастера.
+// Возвращает настройки бизнеса (часовой пояс для подписей периодов)
+func (s *statsService) authorize(ctx context.Context, telegramID, chatID int64) (*domain.BusinessSettings, error) {
+	settings, err := s.repo.GetSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if settings.NotifyChatID != 0 && settings.NotifyChatID == chatID {
+		return settings, nil
+	}
+
+	_, err = s.repo.GetAdmin(ctx, telegramID)
+	if errors.Is(err, repository.ErrAdminNotFound) {
+		return nil, ErrStatsForbidden
+	}
+	if err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// функция подписи дня (today - дата сегодняшнего дня в часовом поясе мастера)
+func dayTitle(start, today time.Time) string {
+	date := start.Format("02.01")
+	switch {
+	case start.Equal(today):
+		return "Сегодня (" + date + ")"
+	case start.Equal(today.AddDate(0, 0, -1)):
+		return "Вчера (" + date + ")"
+	default:
+		return date
+	}
+}
+
+// функция подписи недели (thisWeek - дата начала текущей недели в часовом поясе мастера)
+func weekTitle(start, thisWeek time.Time) string {
+	date := start.Format("02.01")
+	switch {
+	case start.Equal(thisWeek):
+		return "Эта неделя (с " + date + ")"
+	case start.Equal(thisWeek.AddDate(0, 0, -7)):
+		return "Прошлая неделя (с " + date + ")"
+	default:
+		return "Неделя с " + date
+	}
+}
+
+// функция блока воронки периода
+func writeFunnel(b *strings.Builder, title string, f *domain.FunnelStats) {
+	fmt.Fprintf(b, "\n%s\n", title)
+	fmt.Fprintf(b, "Новых: %d, активных: %d\n", f.NewUsers, f.ActiveUsers)
+	fmt.Fprintf(b, "/start %d → мастер %d → на связь %d\n", f.Started, f.Lookups, f.ContactedYes)
+	if f.Contacts > 0 {
+		fmt.Fprintf(b, "До связи: медиана %s, в среднем %s\n", formatDuration(f.ContactTimeMedian), formatDuration(f.ContactTimeAvg))
+	}
+}
+
+// функция длительности для чата ("2 ч 15 мин", "3 дн 4 ч")
+func formatDuration(d time.Duration) string {
+	d = d.Round(time.Minute)
+	switch days, hours, minutes := int(d/(24*time.Hour)), int(d/time.Hour)%24, int(d/time.Minute)%60; {
+	case days > 0:
+		return fmt.Sprintf("%d дн %d ч", days, hours)
+	case hours > 0:
+		return fmt.Sprintf("%d ч %d мин", hours, minutes)
+	default:
+		return fmt.Sprintf("%d мин", minutes)
+	}
+}
@@ -0,0 +1,129 @@
+package servicegrpc
+
+import (
+	"context"
+	"errors"
+	"server/internal/biz_server/repository"
+	"server/internal/domain"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStatsService(t *testing.T) {
+	ctx := context.Background()
+	date := func(day int) time.Time { return time.Date(2025, 10, day, 0, 0, 0, 0, time.UTC) }
+
+	newRepo := func(t *testing.T) *repository.MemoryRepository {
+		t.Helper()
+		repo := repository.NewMemoryRepository()
+		if err := repo.SaveAdmin(ctx, &domain.Admin{TelegramID: 1, Role: domain.RoleViewer}); err != nil {
+			t.Fatalf("SaveAdmin: %v", err)
+		}
+		if err := repo.SaveSettings(ctx, &domain.BusinessSettings{NotifyChatID: -100, Timezone: "UTC"}); err != nil {
+			t.Fatalf("SaveSettings: %v", err)
+		}
+		return repo
+	}
+
+	t.Run("не администратору сводка недоступна", func(t *testing.T) {
+		stats := NewStatsService(newRepo(t))
+		if _, err := stats.Report(ctx, 42, 42); !errors.Is(err, ErrStatsForbidden) {
+			t.Errorf("Report: %v", err)
+		}
+	})
+
+	t.Run("до первого пересчёта", func(t *testing.T) {
+		stats := NewStatsService(newRepo(t))
+		text, err := stats.Report(ctx, 1, 1)
+		if err != nil || !strings.Contains(text, "ещё не посчитана") {
+			t.Errorf("Report: %q %v", text, err)
+		}
+	})
+
+	// агрегаты за 14 и 15 октября 2025 и неделю с понедельника 13 октября
+	saveSnapshot := func(t *testing.T, repo *repository.MemoryRepository) {
+		t.Helper()
+		err := repo.SaveAnalytics(ctx, &domain.AnalyticsSnapshot{
+			DayFrom: date(14), WeekFrom: date(13), ComputedAt: time.Now(),
+			Funnels: []*domain.FunnelStats{
+				{Period: domain.PeriodDay, Start: date(14), NewUsers: 2},
+				{Period: domain.PeriodDay, Start: date(15), NewUsers: 4, ActiveUsers: 6, Started: 4, Lookups: 3, ContactedYes: 1,
+					Contacts: 1, ContactTimeAvg: 2*time.Hour + 15*time.Minute, ContactTimeMedian: 2*time.Hour + 15*time.Minute},
+				{Period: domain.PeriodWeek, Start: date(13), NewUsers: 6},
+			},
+			Sources: []*domain.SourceStats{{Period: domain.PeriodWeek, Start: date(13), Source: "instagram", NewUsers: 5, ContactedYes: 1}},
+		})
+		if err != nil {
+			t.Fatalf("SaveAnalytics: %v", err)
+		}
+	}
+	// функция сервиса со своими часами
+	newStats := func(repo StatsRepositories, now time.Time) *statsService {
+		stats := NewStatsService(repo).(*statsService)
+		stats.now = func() time.Time { return now }
+		return stats
+	}
+	assertReport := func(t *testing.T, text string, want, unwanted []string) {
+		t.Helper()
+		for _, w := range want {
+			if !strings.Contains(text, w) {
+				t.Errorf("в сводке нет %q:\n%s", w, text)
+			}
+		}
+		for _, u := range unwanted {
+			if strings.Contains(text, u) {
+				t.Errorf("в сводке лишнее %q:\n%s", u, text)
+			}
+		}
+	}
+
+	t.Run("сводка администратору и в чат мастера", func(t *testing.T) {
+		repo := newRepo(t)
+		saveSnapshot(t, repo)
+		stats := newStats(repo, time.Date(2025, 10, 15, 12, 0, 0, 0, time.UTC))
+
+		text, err := stats.Report(ctx, 1, 1)
+		if err != nil {
+			t.Fatalf("Report: %v", err)
+		}
+		assertReport(t, text,
+			[]string{"Сегодня (15.10)", "/start 4 → мастер 3 → на связь 1", "медиана 2 ч 15 мин", "Вчера (14.10)", "Эта неделя (с 13.10)", "instagram: 5 новых"},
+			[]string{"Прошлая неделя"})
+
+		if _, err := stats.Report(ctx, 42, -100); err != nil {
+			t.Errorf("в чате уведомлений мастера сводка доступна всем: %v", err)
+		}
+	})
+
+	t.Run("давно не пересчитанные периоды подписаны датами", func(t *testing.T) {
+		repo := newRepo(t)
+		saveSnapshot(t, repo)
+		// понедельник 20 октября: последние строки - за прошлую неделю
+		stats := newStats(repo, time.Date(2025, 10, 20, 12, 0, 0, 0, time.UTC))
+
+		text, err := stats.Report(ctx, 1, 1)
+		if err != nil {
+			t.Fatalf("Report: %v", err)
+		}
+		assertReport(t, text,
+			[]string{"\n15.10\n", "\n14.10\n", "Прошлая неделя (с 13.10)"},
+			[]string{"Сегодня", "Вчера", "Эта неделя"})
+	})
+
+	t.Run("сегодня считается в часовом поясе мастера", func(t *testing.T) {
+		repo := newRepo(t)
+		if err := repo.SaveSettings(ctx, &domain.BusinessSettings{NotifyChatID: -100, Timezone: "Asia/Vladivostok"}); err != nil {
+			t.Fatalf("SaveSettings: %v", err)
+		}
+		saveSnapshot(t, repo)
+		// в UTC ещё 14 октября, во Владивостоке (UTC+10) уже 15-е
+		stats := newStats(repo, time.Date(2025, 10, 14, 20, 0, 0, 0, time.UTC))
+
+		text, err := stats.Report(ctx, 1, 1)
+		if err != nil {
+			t.Fatalf("Report: %v", err)
+		}
+		assertReport(t, text, []string{"Сегодня (15.10)", "Вчера (14.10)"}, nil)
+	})
+}
@@ -4,94 +4,94 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
+	"pkg/logger"
+	"pkg/metrics"
+	"pkg/tracing"
 	"server/internal/biz_server/repository"
 	"server/internal/domain"
 	"time"
 )
 
+// ErrInvalidUser - у update нет отправителя (telegram_id пользователя неизвестен)
+var ErrInvalidUser = errors.New("user telegram id is required")
+
 // ========== User Service ==========
 type UserService interface {
-	RegisterOrUpdate(ctx context.Context, telegramID int64, firstName, lastName, username string) (*domain.User, error)
+	// RegisterOrUpdate регистрирует отправителя update или обновляет его профиль.
+	// profile - пользователь из update (pb.User), created - пользователь обратился впервые
+	RegisterOrUpdate(ctx context.Context, profile *domain.User) (user *domain.User, created bool, err error)
 	GetByTelegramID(ctx context.Context, telegramID int64) (*domain.User, error)
 	UpdateActivity(ctx context.Context, telegramID int64) error
 }
 
 // структура сервиса пользователей
 type userService struct {
-	repo *repository.BizRepository
+	repo repository.UserRepository
 }
 
 // конструктор для сервиса пользователей
-func NewUserService(repo *repository.BizRepository) UserService {
+func NewUserService(repo repository.UserRepository) UserService {
 	return &userService{repo: repo}
 }
 
-// метод для регистрации или обновления текущего пользователя
-func (s *userService) RegisterOrUpdate(ctx context.Context, telegramID int64, firstName, lastName, username string) (*domain.User, error) {
-	user, err := s.repo.GetUserByTelegramID(ctx, telegramID)
-	if err != nil && !errors.Is(err, repository.ErrUserNotFound) {
-		return nil, fmt.Errorf("failed to check user existence: %w", err)
+// метод для регистрации или обновления текущего пользователя.
+// Известный пользователь с неизменным профилем (частый случай) читается из кэша и только отмечает активность,
+// во всех остальных случаях - один атомарный upsert (одновременные первые update не создают дублей)
+func (s *userService) RegisterOrUpdate(ctx context.Context, profile *domain.User) (user *domain.User, created bool, err error) {
+	ctx, span := tracing.Start(ctx, "service.RegisterOrUpdate")
+	defer func() { tracing.End(span, err) }()
+
+	if profile == nil || profile.TelegramID == 0 {
+		return nil, false, ErrInvalidUser
 	}
 
 	now := time.Now()
 
-	if user == nil {
-		// Создаём нового пользователя
-		user = &domain.User{
-			TelegramID: telegramID,
-			FirstName:  firstName,
-			LastName:   lastName,
-			Username:   username,
-			IsActive:   true,
-			CreatedAt:  now,
-			LastSeenAt: now,
-		}
-
-		if err := s.repo.CreateUser(ctx, user); err != nil {
-			return nil, fmt.Errorf("failed to create user: %w", err)
+	existing, err := s.repo.GetUserByTelegramID(ctx, profile.TelegramID)
+	if err != nil && !errors.Is(err, repository.ErrUserNotFound) {
+		return nil, false, fmt.Errorf("failed to check user existence: %w", err)
+	}
+	if err == nil && sameProfile(existing, profile) {
+		if err := s.repo.UpdateLastSeen(ctx, profile.TelegramID); err != nil {
+			return nil, false, fmt.Errorf("failed to update last_seen: %w", err)
 		}
-
-		// Можно залогировать нового пользователя
-		fmt.Printf("🎉 New user registered: %d (@%s)\n", telegramID, username)
-
-		return user, nil
+		existing.LastSeenAt = now
+		return existing, false, nil
 	}
 
-	// Обновление существующего пользователя
-	return s.updateExistingUser(ctx, user, firstName, lastName, username, now)
-}
-
-// Обновление существующего пользователя
-func (s *userService) updateExistingUser(ctx context.Context, user *domain.User, firstName, lastName, username string, now time.Time) (*domain.User, error) {
-	needsUpdate := false
-
-	if user.FirstName != firstName {
-		user.FirstName = firstName
-		needsUpdate = true
+	user = &domain.User{
+		TelegramID: profile.TelegramID,
+		Username:   profile.Username,
+		FirstName:  profile.FirstName,
+		LastName:   profile.LastName,
+		IsActive:   true,
+		CreatedAt:  now,
+		LastSeenAt: now,
 	}
-	if user.LastName != lastName {
-		user.LastName = lastName
-		needsUpdate = true
-	}
-	if user.Username != username {
-		user.Username = username
-		needsUpdate = true
+	created, err = s.repo.UpsertUser(ctx, user)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to save user: %w", err)
 	}
 
-	// Всегда обновляем время активности
-	user.LastSeenAt = now
+	if created {
+		metrics.BusinessEvent(metrics.EventNewUser)
 
-	if needsUpdate {
-		if err := s.repo.Update(ctx, user); err != nil {
-			return nil, fmt.Errorf("failed to update user: %w", err)
-		}
-	} else {
-		if err := s.repo.UpdateLastSeen(ctx, user.TelegramID); err != nil {
-			return nil, fmt.Errorf("failed to update last_seen: %w", err)
-		}
+		// логируем нового пользователя (персональные данные маскируем)
+		slog.InfoContext(ctx, "new user registered",
+			"telegram_id", logger.MaskID(user.TelegramID),
+			"username", logger.MaskName(user.Username))
 	}
 
-	return user, nil
+	return user, created, nil
+}
+
+// функция проверки, что профиль пользователя в Telegram не изменился
+func sameProfile(stored, profile *domain.User) bool {
+	return stored.IsActive &&
+		stored.Username == profile.Username &&
+		stored.FirstName == profile.FirstName &&
+		stored.LastName == profile.LastName
 }
 
 // GetByTelegramID - получение пользователя
@@ -0,0 +1,120 @@
+package servicegrpc
+
+import (
+	"context"
+	"errors"
+	"server/internal/biz_server/repository"
+	"server/internal/domain"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func testProfile(telegramID int64, firstName, lastName, username string) *domain.User {
+	return &domain.User{TelegramID: telegramID, FirstName: firstName, LastName: lastName, Username: username}
+}
+
+func TestRegisterOrUpdate(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("новый пользователь создаётся", func(t *testing.T) {
+		repo := repository.NewMemoryRepository()
+		users := NewUserService(repo)
+
+		user, created, err := users.RegisterOrUpdate(ctx, testProfile(42, "Анна", "", "anna"))
+		if err != nil {
+			t.Fatalf("RegisterOrUpdate: %v", err)
+		}
+		if !created || user.ID == 0 || !user.IsActive {
+			t.Errorf("пользователь не создан: created=%v %+v", created, user)
+		}
+
+		stored, err := repo.GetUserByTelegramID(ctx, 42)
+		if err != nil {
+			t.Fatalf("GetUserByTelegramID: %v", err)
+		}
+		if stored.Username != "anna" {
+			t.Errorf("username = %q", stored.Username)
+		}
+	})
+
+	t.Run("изменившиеся данные обновляются", func(t *testing.T) {
+		repo := repository.NewMemoryRepository()
+		users := NewUserService(repo)
+
+		first, _, err := users.RegisterOrUpdate(ctx, testProfile(42, "Анна", "", "anna"))
+		if err != nil {
+			t.Fatalf("RegisterOrUpdate: %v", err)
+		}
+		second, created, err := users.RegisterOrUpdate(ctx, testProfile(42, "Анна", "Иванова", "anna_i"))
+		if err != nil {
+			t.Fatalf("RegisterOrUpdate: %v", err)
+		}
+		if created || second.ID != first.ID {
+			t.Errorf("создан второй пользователь: created=%v %d != %d", created, second.ID, first.ID)
+		}
+
+		stored, _ := repo.GetUserByTelegramID(ctx, 42)
+		if stored.LastName != "Иванова" || stored.Username != "anna_i" {
+			t.Errorf("данные не обновлены: %+v", stored)
+		}
+	})
+
+	t.Run("неизменный профиль только отмечает активность", func(t *testing.T) {
+		repo := &upsertCountingRepo{UserRepository: repository.NewMemoryRepository()}
+		users := NewUserService(repo)
+
+		for range 3 {
+			if _, _, err := users.RegisterOrUpdate(ctx, testProfile(42, "Анна", "", "anna")); err != nil {
+				t.Fatalf("RegisterOrUpdate: %v", err)
+			}
+		}
+		if got := repo.upserts.Load(); got != 1 {
+			t.Errorf("записей профиля: %d, ожидали 1", got)
+		}
+	})
+
+	t.Run("без отправителя - ErrInvalidUser", func(t *testing.T) {
+		users := NewUserService(repository.NewMemoryRepository())
+		if _, _, err := users.RegisterOrUpdate(ctx, nil); !errors.Is(err, ErrInvalidUser) {
+			t.Errorf("ожидали ErrInvalidUser, получили %v", err)
+		}
+	})
+
+	t.Run("одновременное первое обращение - один новый пользователь", func(t *testing.T) {
+		repo := repository.NewMemoryRepository()
+		users := NewUserService(repo)
+
+		var wg sync.WaitGroup
+		var createdCount atomic.Int64
+		for range 20 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, created, err := users.RegisterOrUpdate(ctx, testProfile(77, "Олег", "", "oleg"))
+				if err != nil {
+					t.Errorf("RegisterOrUpdate: %v", err)
+				}
+				if created {
+					createdCount.Add(1)
+				}
+			}()
+		}
+		wg.Wait()
+
+		if got := createdCount.Load(); got != 1 {
+			t.Errorf("новым пользователь отмечен %d раз, ожидали 1", got)
+		}
+	})
+}
+
+// upsertCountingRepo считает записи профиля
+type upsertCountingRepo struct {
+	repository.UserRepository
+	upserts atomic.Int64
+}
+
+func (r *upsertCountingRepo) UpsertUser(ctx context.Context, user *domain.User) (bool, error) {
+	r.upserts.Add(1)
+	return r.UserRepository.UpsertUser(ctx, user)
+}
@@ -0,0 +1,97 @@
+package servicehttp
+
+import (
+	"context"
+	"server/internal/biz_server/repository"
+	"server/internal/domain"
+)
+
+// размеры страниц выборок админки по умолчанию и максимум
+const (
+	defaultPageSize     = 20
+	maxPageSize         = 100
+	maxPageNumber       = 100000 // дальше смещение (номер * размер) может переполнить int
+	defaultMessageLimit = 50
+	maxMessageLimit     = 200
+	profileMessages     = 20 // последние сообщения в профиле пользователя
+	profileLeads        = 10 // последние заявки в профиле пользователя
+)
+
+// ValidationError - неверное значение поля запроса, которое нельзя проверить без сервиса
+type ValidationError struct {
+	Field   string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// NewPage возвращает страницу выдачи с значениями по умолчанию (number, size <= 0) и ограничением номера и размера
+func NewPage(number, size int) domain.Page {
+	if number <= 0 {
+		number = 1
+	}
+	if size <= 0 {
+		size = defaultPageSize
+	}
+	return domain.Page{Number: min(number, maxPageNumber), Size: min(size, maxPageSize)}
+}
+
+// UserProfile - пользователь с последними сообщениями и заявками
+type UserProfile struct {
+	User     *domain.User
+	Messages []*domain.Message
+	Leads    []*domain.Lead
+}
+
+// сервис пользователей и журналов для админки
+type AdminService struct {
+	users repository.UserRepository // профиль читается через кэш, как и в боте
+	admin repository.AdminRepository
+	leads repository.LeadRepository
+}
+
+// конструктор для сервиса админки
+func NewAdminService(users repository.UserRepository, admin repository.AdminRepository, leads repository.LeadRepository) *AdminService {
+	return &AdminService{users: users, admin: admin, leads: leads}
+}
+
+// метод получения страницы пользователей
+func (s *AdminService) ListUsers(ctx context.Context, filter domain.UserFilter) ([]*domain.User, int64, error) {
+	return s.admin.ListUsers(ctx, filter)
+}
+
+// метод получения профиля пользователя (repository.ErrUserNotFound - пользователя нет)
+func (s *AdminService) GetProfile(ctx context.Context, telegramID int64) (*UserProfile, error) {
+	user, err := s.users.GetUserByTelegramID(ctx, telegramID)
+	if err != nil {
+		return nil, err
+	}
+	messages, err := s.admin.ListMessages(ctx, domain.MessageFilter{TelegramID: telegramID, Limit: profileMessages})
+	if err != nil {
+		return nil, err
+	}
+	leads, _, err := s.leads.ListLeads(ctx, domain.LeadFilter{TelegramID: telegramID, Page: NewPage(1, profileLeads)})
+	if err != nil {
+		return nil, err
+	}
+	return &UserProfile{User: user, Messages: messages, Leads: leads}, nil
+}
+
+// метод получения переписки с пользователем (limit <= 0 - по умолчанию)
+func (s *AdminService) ListMessages(ctx context.Context, telegramID, beforeID int64, limit int) ([]*domain.Message, error) {
+	if limit <= 0 {
+		limit = defaultMessageLimit
+	}
+	return s.admin.ListMessages(ctx, domain.MessageFilter{
+		TelegramID: telegramID,
+		BeforeID:   beforeID,
+		Limit:      min(limit, maxMessageLimit),
+	})
+}
+
+// метод получения страницы журнала нажатий кнопок
+func (s *AdminService) ListCallbacks(ctx context.Context, filter domain.CallbackFilter) ([]*domain.CallbackLog, int64, error) {
+	return s.admin.ListCallbacks(ctx, filter)
+}
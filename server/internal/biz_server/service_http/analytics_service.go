@@ -0,0 +1,77 @@
+package servicehttp
+
+import (
+	"context"
+	"server/internal/biz_server/analytics"
+	"server/internal/biz_server/repository"
+	"server/internal/domain"
+	"time"
+)
+
+// сколько периодов отдавать, если limit не задан
+const defaultAnalyticsLimit = 30
+
+// сервис аналитики: пересчёт агрегатов планировщиком и выборки для админки
+type AnalyticsService struct {
+	repo     repository.AnalyticsRepository
+	settings repository.SettingsRepository
+	now      func() time.Time
+}
+
+// конструктор для сервиса аналитики
+func NewAnalyticsService(repo repository.AnalyticsRepository, settings repository.SettingsRepository) *AnalyticsService {
+	return &AnalyticsService{repo: repo, settings: settings, now: time.Now}
+}
+
+// метод пересчёта агрегатов за days последних дней и weeks последних недель
+func (s *AnalyticsService) Refresh(ctx context.Context, days, weeks int) (*domain.AnalyticsSnapshot, error) {
+	settings, err := s.settings.GetSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	loc, err := time.LoadLocation(settings.Timezone)
+	if err != nil {
+		// часовой пояс проверяется при сохранении, сюда попадаем только после удаления зоны из tzdata
+		loc = time.UTC
+	}
+
+	window := analytics.NewWindow(s.now(), loc, days, weeks)
+	facts, err := s.repo.AnalyticsFacts(ctx, window.Since())
+	if err != nil {
+		return nil, err
+	}
+	snapshot := analytics.Compute(facts, window)
+	if err := s.repo.SaveAnalytics(ctx, snapshot); err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// метод получения воронок последних limit периодов (новые первыми, 0 - по умолчанию)
+func (s *AnalyticsService) Funnels(ctx context.Context, period domain.AnalyticsPeriod, limit int) ([]*domain.FunnelStats, error) {
+	if !period.Valid() {
+		return nil, &ValidationError{Field: "period", Message: "must be day or week"}
+	}
+	return s.repo.ListFunnelStats(ctx, period, analyticsLimit(limit))
+}
+
+// метод получения конверсии по источникам последних limit периодов
+func (s *AnalyticsService) Sources(ctx context.Context, period domain.AnalyticsPeriod, limit int) ([]*domain.SourceStats, error) {
+	if !period.Valid() {
+		return nil, &ValidationError{Field: "period", Message: "must be day or week"}
+	}
+	return s.repo.ListSourceStats(ctx, period, analyticsLimit(limit))
+}
+
+// метод получения удержания последних limit недельных когорт
+func (s *AnalyticsService) Cohorts(ctx context.Context, limit int) ([]*domain.CohortStats, error) {
+	return s.repo.ListCohorts(ctx, analyticsLimit(limit))
+}
+
+// функция числа периодов выборки (0 - по умолчанию)
+func analyticsLimit(limit int) int {
+	if limit <= 0 {
+		return defaultAnalyticsLimit
+	}
+	return limit
+}
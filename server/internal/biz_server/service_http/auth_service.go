@@ -0,0 +1,313 @@
+package servicehttp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"pkg/adminauth"
+	"pkg/configs"
+	"pkg/logger"
+	"server/internal/biz_server/repository"
+	"server/internal/domain"
+	"strings"
+	"time"
+)
+
+// ошибки входа и прав администратора
+var (
+	ErrUnauthorized = errors.New("authentication required") // нет токена, токен неверный или сессия закрыта
+	ErrForbidden    = errors.New("access denied")           // вход выполнен, но прав не хватает (или это не администратор)
+	ErrLastOwner    = errors.New("cannot remove or demote the last owner")
+)
+
+// Tokens - токены сессии администратора
+type Tokens struct {
+	Access           string // токен доступа (JWT)
+	AccessExpiresAt  time.Time
+	Refresh          string // одноразовый токен обновления
+	RefreshExpiresAt time.Time
+}
+
+// AdminSession - администратор с токенами новой сессии
+type AdminSession struct {
+	Admin  *domain.Admin
+	Tokens Tokens
+}
+
+// сервис входа в админку, сессий и управления администраторами
+type AuthService struct {
+	repo   repository.AdminAuthRepository
+	conf   *configs.AdminAuthConfig
+	signer *adminauth.Signer // nil - вход выключен
+	now    func() time.Time
+}
+
+// конструктор для сервиса входа (при включённом входе нужны токен бота и секрет подписи)
+func NewAuthService(repo repository.AdminAuthRepository, conf *configs.AdminAuthConfig) (*AuthService, error) {
+	s := &AuthService{repo: repo, conf: conf, now: time.Now}
+	if !conf.Enabled {
+		return s, nil
+	}
+
+	if conf.BotToken() == "" {
+		return nil, fmt.Errorf("admin auth: bot token is not set (env %s)", conf.BotTokenEnv)
+	}
+	signer, err := adminauth.NewSigner(conf.SessionSecret(), conf.AccessTTL)
+	if err != nil {
+		return nil, fmt.Errorf("admin auth: session secret (env %s): %w", conf.SessionSecretEnv, err)
+	}
+	if conf.RefreshTTL <= 0 {
+		return nil, errors.New("admin auth: refresh ttl must be positive")
+	}
+	s.signer = signer
+	return s, nil
+}
+
+// метод возвращает, включён ли вход (выключен - API админки открыто)
+func (s *AuthService) Enabled() bool {
+	return s.signer != nil
+}
+
+// метод возвращает конфиг входа (параметры кук)
+func (s *AuthService) Config() *configs.AdminAuthConfig {
+	return s.conf
+}
+
+// метод добавления владельцев из конфига (существующим администраторам роль не меняется)
+func (s *AuthService) EnsureOwners(ctx context.Context, telegramIDs []int64) error {
+	for _, id := range telegramIDs {
+		created, err := s.repo.AddAdminIfMissing(ctx, &domain.Admin{TelegramID: id, Role: domain.RoleOwner})
+		if err != nil {
+			return err
+		}
+		if created {
+			slog.InfoContext(ctx, "admin owner added from config", "telegram_id", logger.MaskID(id))
+		}
+	}
+	return nil
+}
+
+// метод входа по данным Telegram Login Widget
+func (s *AuthService) Login(ctx context.Context, fields map[string]string, userAgent string) (*AdminSession, error) {
+	if !s.Enabled() {
+		return nil, ErrForbidden
+	}
+
+	login, err := adminauth.VerifyLogin(fields, s.conf.BotToken(), s.conf.LoginMaxAge, s.now())
+	if err != nil {
+		slog.WarnContext(ctx, "admin login rejected", "error", err)
+		return nil, fmt.Errorf("%w: %v", ErrUnauthorized, err)
+	}
+
+	admin := &domain.Admin{
+		TelegramID: login.TelegramID,
+		Username:   login.Username,
+		FirstName:  login.FirstName,
+		LastName:   login.LastName,
+	}
+	if err := s.repo.RecordLogin(ctx, admin, s.now()); err != nil {
+		if errors.Is(err, repository.ErrAdminNotFound) {
+			slog.WarnContext(ctx, "admin login by unknown user", "telegram_id", logger.MaskID(login.TelegramID))
+			return nil, ErrForbidden
+		}
+		return nil, err
+	}
+
+	tokens, err := s.openSession(ctx, admin, userAgent)
+	if err != nil {
+		return nil, err
+	}
+	slog.InfoContext(ctx, "admin logged in", "telegram_id", logger.MaskID(admin.TelegramID), "role", admin.Role)
+	return &AdminSession{Admin: admin, Tokens: *tokens}, nil
+}
+
+// метод обновления токенов: токен обновления одноразовый, роль перечитывается из хранилища.
+// Повторное использование токена (его украли или клиент повторил запрос) закрывает все сессии администратора
+func (s *AuthService) Refresh(ctx context.Context, refreshToken, userAgent string) (*AdminSession, error) {
+	if !s.Enabled() || refreshToken == "" {
+		return nil, ErrUnauthorized
+	}
+
+	now := s.now()
+	session, err := s.repo.GetSession(ctx, adminauth.HashRefreshToken(refreshToken))
+	if errors.Is(err, repository.ErrSessionNotFound) {
+		return nil, ErrUnauthorized
+	}
+	if err != nil {
+		return nil, err
+	}
+	if session.RevokedAt != nil {
+		if _, err := s.repo.RevokeAdminSessions(ctx, session.TelegramID, now); err != nil {
+			return nil, err
+		}
+		slog.WarnContext(ctx, "admin refresh token reused, all sessions revoked", "telegram_id", logger.MaskID(session.TelegramID))
+		return nil, ErrUnauthorized
+	}
+	if !now.Before(session.ExpiresAt) {
+		return nil, ErrUnauthorized
+	}
+
+	// из одновременных обновлений по одному токену новую сессию получит только одно
+	revoked, err := s.repo.RevokeSession(ctx, session.ID, now)
+	if err != nil {
+		return nil, err
+	}
+	if !revoked {
+		return nil, ErrUnauthorized
+	}
+
+	admin, err := s.repo.GetAdmin(ctx, session.TelegramID)
+	if errors.Is(err, repository.ErrAdminNotFound) {
+		return nil, ErrUnauthorized
+	}
+	if err != nil {
+		return nil, err
+	}
+	tokens, err := s.openSession(ctx, admin, userAgent)
+	if err != nil {
+		return nil, err
+	}
+	return &AdminSession{Admin: admin, Tokens: *tokens}, nil
+}
+
+// метод выхода: закрывает сессию токена обновления (неизвестный токен - не ошибка)
+func (s *AuthService) Logout(ctx context.Context, refreshToken string) error {
+	if !s.Enabled() || refreshToken == "" {
+		return nil
+	}
+	session, err := s.repo.GetSession(ctx, adminauth.HashRefreshToken(refreshToken))
+	if errors.Is(err, repository.ErrSessionNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	_, err = s.repo.RevokeSession(ctx, session.ID, s.now())
+	return err
+}
+
+// метод выхода по сессии токена доступа (на страницы админки токен обновления не отправляется)
+func (s *AuthService) LogoutSession(ctx context.Context, sessionID int64) error {
+	if !s.Enabled() {
+		return nil
+	}
+	_, err := s.repo.RevokeSession(ctx, sessionID, s.now())
+	return err
+}
+
+// метод возвращает id бота для входа через Telegram (часть токена бота до двоеточия, она не секретна)
+func (s *AuthService) LoginBotID() string {
+	id, _, _ := strings.Cut(s.conf.BotToken(), ":")
+	return id
+}
+
+// метод проверки токена доступа (без обращения к хранилищу)
+func (s *AuthService) Authenticate(accessToken string) (*adminauth.Claims, error) {
+	if !s.Enabled() || accessToken == "" {
+		return nil, ErrUnauthorized
+	}
+	claims, err := s.signer.Parse(accessToken, s.now())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnauthorized, err)
+	}
+	return claims, nil
+}
+
+// метод создания сессии и выпуска токенов
+func (s *AuthService) openSession(ctx context.Context, admin *domain.Admin, userAgent string) (*Tokens, error) {
+	now := s.now()
+	refresh, hash, err := adminauth.NewRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+	session := &domain.AdminSession{
+		TelegramID: admin.TelegramID,
+		TokenHash:  hash,
+		UserAgent:  userAgent,
+		ExpiresAt:  now.Add(s.conf.RefreshTTL),
+	}
+	if err := s.repo.CreateSession(ctx, session); err != nil {
+		return nil, err
+	}
+
+	access, claims, err := s.signer.Sign(adminauth.Claims{
+		Subject:   admin.TelegramID,
+		Role:      string(admin.Role),
+		SessionID: session.ID,
+	}, now)
+	if err != nil {
+		return nil, err
+	}
+	return &Tokens{
+		Access:           access,
+		AccessExpiresAt:  claims.Expires(),
+		Refresh:          refresh,
+		RefreshExpiresAt: session.ExpiresAt,
+	}, nil
+}
+
+// метод получения администратора (repository.ErrAdminNotFound - его нет)
+func (s *AuthService) GetAdmin(ctx context.Context, telegramID int64) (*domain.Admin, error) {
+	return s.repo.GetAdmin(ctx, telegramID)
+}
+
+// метод получения всех администраторов
+func (s *AuthService) ListAdmins(ctx context.Context) ([]*domain.Admin, error) {
+	return s.repo.ListAdmins(ctx)
+}
+
+// метод назначения роли (создаёт администратора, если его нет; новая роль действует с обновления токена)
+func (s *AuthService) SetRole(ctx context.Context, telegramID int64, role domain.AdminRole) (*domain.Admin, error) {
+	if !role.Valid() {
+		return nil, &ValidationError{Field: "role", Message: "unknown role"}
+	}
+	if role != domain.RoleOwner {
+		if err := s.checkNotLastOwner(ctx, telegramID); err != nil {
+			return nil, err
+		}
+	}
+
+	admin := &domain.Admin{TelegramID: telegramID, Role: role}
+	if err := s.repo.SaveAdmin(ctx, admin); err != nil {
+		return nil, err
+	}
+	slog.InfoContext(ctx, "admin role changed", "telegram_id", logger.MaskID(telegramID), "role", role)
+	return admin, nil
+}
+
+// метод удаления администратора (его сессии закрываются, выданный токен доступа действует до истечения)
+func (s *AuthService) RemoveAdmin(ctx context.Context, telegramID int64) error {
+	if err := s.checkNotLastOwner(ctx, telegramID); err != nil {
+		return err
+	}
+	if err := s.repo.DeleteAdmin(ctx, telegramID); err != nil {
+		return err
+	}
+	slog.InfoContext(ctx, "admin removed", "telegram_id", logger.MaskID(telegramID))
+	return nil
+}
+
+// метод проверки, что администратор - не единственный владелец (иначе в админку будет некому войти)
+func (s *AuthService) checkNotLastOwner(ctx context.Context, telegramID int64) error {
+	admins, err := s.repo.ListAdmins(ctx)
+	if err != nil {
+		return err
+	}
+	owners, isOwner := 0, false
+	for _, admin := range admins {
+		if admin.Role == domain.RoleOwner {
+			owners++
+			isOwner = isOwner || admin.TelegramID == telegramID
+		}
+	}
+	if isOwner && owners == 1 {
+		return ErrLastOwner
+	}
+	return nil
+}
+
+// метод удаления истёкших сессий (задача планировщика)
+func (s *AuthService) CleanupSessions(ctx context.Context) (int64, error) {
+	return s.repo.DeleteSessionsBefore(ctx, s.now())
+}
@@ -0,0 +1,62 @@
+package servicehttp
+
+import (
+	"context"
+	"log/slog"
+	"server/internal/biz_server/repository"
+	"server/internal/domain"
+	"strings"
+	"unicode/utf8"
+)
+
+// сервис текстов бота (бот видит изменения не сразу - тексты кэшируются, см. servicegrpc.ContentService)
+type ContentService struct {
+	content repository.ContentRepository
+}
+
+// конструктор для сервиса текстов бота
+func NewContentService(content repository.ContentRepository) *ContentService {
+	return &ContentService{content: content}
+}
+
+// метод получения всех текстов бота (неизменённые - по умолчанию)
+func (s *ContentService) List(ctx context.Context) ([]domain.ContentItem, error) {
+	saved, err := s.content.ListContent(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return domain.ContentItems(saved), nil
+}
+
+// метод получения текста бота (при ошибке хранилища - текст по умолчанию)
+func (s *ContentService) Text(ctx context.Context, key domain.ContentKey) string {
+	saved, err := s.content.ListContent(ctx)
+	if err != nil {
+		slog.WarnContext(ctx, "failed to load bot content, using default", "key", key, "error", err)
+		return domain.DefaultContent(key)
+	}
+	if item, ok := saved[key]; ok {
+		return item.Text
+	}
+	return domain.DefaultContent(key)
+}
+
+// метод сохранения текста (пустой текст или текст по умолчанию - сброс изменения)
+func (s *ContentService) Save(ctx context.Context, key domain.ContentKey, text string) error {
+	if !key.Valid() {
+		return &ValidationError{Field: "key", Message: "unknown content key"}
+	}
+	text = strings.TrimSpace(strings.ReplaceAll(text, "\r\n", "\n"))
+	if utf8.RuneCountInString(text) > domain.MaxContentLength {
+		return &ValidationError{Field: "text", Message: "must be at most 4096 characters"}
+	}
+	if text == domain.DefaultContent(key) {
+		text = ""
+	}
+
+	if err := s.content.SaveContent(ctx, key, text); err != nil {
+		return err
+	}
+	slog.InfoContext(ctx, "bot content changed", "key", key, "reset", text == "")
+	return nil
+}
@@ -0,0 +1,47 @@
+package servicehttp
+
+import (
+	"context"
+	"server/internal/biz_server/repository"
+	"server/internal/domain"
+	"time"
+)
+
+// Dashboard - сводка за сегодня (день - по часовому поясу мастера)
+type Dashboard struct {
+	Stats *domain.DashboardStats
+	Since time.Time // начало сегодняшнего дня
+}
+
+// сервис сводки для главной страницы админки
+type DashboardService struct {
+	admin    repository.AdminRepository
+	settings repository.SettingsRepository
+	now      func() time.Time
+}
+
+// конструктор для сервиса сводки
+func NewDashboardService(admin repository.AdminRepository, settings repository.SettingsRepository) *DashboardService {
+	return &DashboardService{admin: admin, settings: settings, now: time.Now}
+}
+
+// метод получения сводки за сегодня
+func (s *DashboardService) Today(ctx context.Context) (*Dashboard, error) {
+	settings, err := s.settings.GetSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	loc, err := time.LoadLocation(settings.Timezone)
+	if err != nil {
+		// часовой пояс проверяется при сохранении, сюда попадаем только после удаления зоны из tzdata
+		loc = time.UTC
+	}
+
+	now := s.now().In(loc)
+	since := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	stats, err := s.admin.DashboardStats(ctx, since)
+	if err != nil {
+		return nil, err
+	}
+	return &Dashboard{Stats: stats, Since: since}, nil
+}
@@ -1,15 +1,56 @@
 package servicehttp
 
-import "fmt"
+import (
+	"pkg/configs"
+	"pkg/scheduler"
+	"server/internal/biz_server/chatevents"
+	"server/internal/biz_server/repository"
+)
 
 // структура для сервисного http слоя
-type BizServiceFacade struct{}
+type BizServiceFacade struct {
+	Admin     *AdminService     // пользователи, переписка и журнал нажатий
+	Dashboard *DashboardService // сводка за сегодня
+	Analytics *AnalyticsService // воронки, источники и удержание
+	Leads     *LeadService      // заявки клиентов
+	Settings  *SettingsService  // настройки бизнеса
+	Content   *ContentService   // тексты бота
+	Messaging *MessagingService // сообщения клиентам через бота
+	Handoffs  *HandoffService   // диалоги с оператором
+	Scheduler *SchedulerService // управление задачами планировщика
+	Auth      *AuthService      // вход в админку и администраторы
+}
 
-// конструктор для сервисного http слоя
-func NewBizServiceFacade() *BizServiceFacade {
-	return &BizServiceFacade{}
+// FacadeDeps - зависимости сервисного http слоя (необязательные поля можно не заполнять)
+type FacadeDeps struct {
+	Users       repository.UserRepository    // хранилище пользователей с кэшем
+	Admin       repository.AdminRepositories // выборки админки напрямую из хранилища
+	Auth        *AuthService                 // вход в админку
+	Scheduler   *scheduler.Scheduler         // nil - планировщик выключен
+	Sender      MessageSender                // отправка сообщений через бота (nil - отправка недоступна)
+	Events      *chatevents.Bus              // события чатов для консоли оператора (nil - выключены)
+	HandoffConf *configs.HandoffConfig       // nil - настройки по умолчанию
 }
 
-func (b *BizServiceFacade) GetEcho() string {
-	return fmt.Sprintf("Hello from http service layer")
+// конструктор для сервисного http слоя
+func NewBizServiceFacade(deps FacadeDeps) *BizServiceFacade {
+	handoffConf := deps.HandoffConf
+	if handoffConf == nil {
+		handoffConf = configs.UseDefaultHandoffConfig()
+	}
+
+	content := NewContentService(deps.Admin)
+	messaging := NewMessagingService(deps.Users, deps.Admin, deps.Admin, deps.Sender, deps.Events)
+	return &BizServiceFacade{
+		Admin:     NewAdminService(deps.Users, deps.Admin, deps.Admin),
+		Dashboard: NewDashboardService(deps.Admin, deps.Admin),
+		Analytics: NewAnalyticsService(deps.Admin, deps.Admin),
+		Leads:     NewLeadService(deps.Admin),
+		Settings:  NewSettingsService(deps.Admin),
+		Content:   content,
+		Messaging: messaging,
+		Handoffs:  NewHandoffService(deps.Admin, deps.Users, content, messaging, deps.Events, handoffConf),
+		Scheduler: NewSchedulerService(deps.Scheduler),
+		Auth:      deps.Auth,
+	}
 }
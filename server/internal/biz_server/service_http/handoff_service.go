@@ -0,0 +1,146 @@
+package servicehttp
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"pkg/configs"
+	"pkg/logger"
+	"server/internal/biz_server/chatevents"
+	"server/internal/biz_server/repository"
+	"server/internal/domain"
+	"time"
+)
+
+// ErrEventsUnavailable - события чатов выключены (консоль оператора работает без обновлений в реальном времени)
+var ErrEventsUnavailable = errors.New("chat events are unavailable")
+
+// сервис диалогов с оператором: пока диалог открыт, бот не отвечает клиенту,
+// а оператор переписывается с ним из консоли админки
+type HandoffService struct {
+	repo      repository.HandoffRepository
+	users     repository.UserRepository
+	content   *ContentService
+	messaging *MessagingService
+	events    *chatevents.Bus // nil - консоль оператора не получает события
+	conf      *configs.HandoffConfig
+	now       func() time.Time
+}
+
+// конструктор для сервиса диалогов с оператором
+func NewHandoffService(repo repository.HandoffRepository, users repository.UserRepository, content *ContentService,
+	messaging *MessagingService, events *chatevents.Bus, conf *configs.HandoffConfig) *HandoffService {
+	return &HandoffService{
+		repo:      repo,
+		users:     users,
+		content:   content,
+		messaging: messaging,
+		events:    events,
+		conf:      conf,
+		now:       time.Now,
+	}
+}
+
+// метод получения открытых диалогов (сначала недавно активные)
+func (s *HandoffService) List(ctx context.Context) ([]*domain.Handoff, error) {
+	return s.repo.ListOpenHandoffs(ctx)
+}
+
+// метод получения открытого диалога чата (nil - чат ведёт бот)
+func (s *HandoffService) Get(ctx context.Context, telegramID int64) (*domain.Handoff, error) {
+	handoff, err := s.repo.GetOpenHandoff(ctx, telegramID)
+	if errors.Is(err, repository.ErrHandoffNotFound) {
+		return nil, nil
+	}
+	return handoff, err
+}
+
+// метод перехвата чата мастером: бот перестаёт отвечать клиенту (клиенту ничего не отправляется).
+// adminID - telegram_id администратора (0 - вход выключен)
+func (s *HandoffService) Open(ctx context.Context, telegramID, adminID int64) (*domain.Handoff, error) {
+	if _, err := s.users.GetUserByTelegramID(ctx, telegramID); err != nil {
+		return nil, err
+	}
+
+	handoff := &domain.Handoff{TelegramID: telegramID, Reason: domain.HandoffByMaster, OpenedBy: adminID}
+	created, err := s.repo.OpenHandoff(ctx, handoff)
+	if err != nil {
+		return nil, err
+	}
+	if created {
+		slog.InfoContext(ctx, "handoff opened by master", "user_id", logger.MaskID(telegramID), "admin_id", logger.MaskID(adminID))
+		s.publish(ctx, domain.ChatEvent{
+			Type:       domain.ChatEventHandoffOpened,
+			TelegramID: telegramID,
+			Reason:     string(handoff.Reason),
+			At:         handoff.OpenedAt,
+		})
+	}
+	return handoff, nil
+}
+
+// метод завершения диалога оператором: бот снова отвечает клиенту
+// (repository.ErrHandoffNotFound - диалог уже закрыт)
+func (s *HandoffService) Close(ctx context.Context, telegramID int64) error {
+	handoff, err := s.repo.CloseHandoff(ctx, telegramID, domain.HandoffClosedByOperator, s.now())
+	if err != nil {
+		return err
+	}
+	s.closed(ctx, handoff)
+	return nil
+}
+
+// метод закрытия диалогов без сообщений дольше inactivity_timeout (задача планировщика)
+func (s *HandoffService) ExpireIdle(ctx context.Context) (int, error) {
+	now := s.now()
+	closed, err := s.repo.CloseIdleHandoffs(ctx, now.Add(-s.conf.InactivityTimeout), now)
+	if err != nil {
+		return 0, err
+	}
+	for _, handoff := range closed {
+		s.closed(ctx, handoff)
+	}
+	return len(closed), nil
+}
+
+// метод уведомлений о закрытом диалоге: консоли - событие, клиенту, который звал мастера, - что бот снова на связи
+func (s *HandoffService) closed(ctx context.Context, handoff *domain.Handoff) {
+	slog.InfoContext(ctx, "handoff closed", "user_id", logger.MaskID(handoff.TelegramID), "reason", handoff.CloseReason)
+	s.publish(ctx, domain.ChatEvent{
+		Type:       domain.ChatEventHandoffClosed,
+		TelegramID: handoff.TelegramID,
+		Reason:     string(handoff.CloseReason),
+		At:         *handoff.ClosedAt,
+	})
+
+	// мастер, перехвативший чат сам, решает, что сказать клиенту, - бот молча возвращается
+	if handoff.Reason != domain.HandoffByClient {
+		return
+	}
+	text := s.content.Text(ctx, domain.ContentHandoffEnd)
+	if _, err := s.messaging.Send(ctx, handoff.TelegramID, text); err != nil {
+		slog.WarnContext(ctx, "failed to notify client about handoff end", "user_id", logger.MaskID(handoff.TelegramID), "error", err)
+	}
+}
+
+// метод подписки консоли на события чата (telegramID == 0 - всех чатов).
+// ErrEventsUnavailable - события выключены
+func (s *HandoffService) Subscribe(telegramID int64) (<-chan domain.ChatEvent, func(), error) {
+	if s.events == nil {
+		return nil, nil, ErrEventsUnavailable
+	}
+	events, unsubscribe := s.events.Subscribe(telegramID)
+	return events, unsubscribe, nil
+}
+
+// метод интервала пустых событий в потоке консоли
+func (s *HandoffService) KeepaliveInterval() time.Duration {
+	return s.conf.KeepaliveInterval
+}
+
+// метод публикации события (ошибка не мешает смене режима чата)
+func (s *HandoffService) publish(ctx context.Context, event domain.ChatEvent) {
+	if err := s.events.Publish(ctx, event); err != nil {
+		slog.WarnContext(ctx, "failed to publish chat event", "type", event.Type, "error", err)
+	}
+}
@@ -0,0 +1,59 @@
+package servicehttp
+
+import (
+	"context"
+	"server/internal/biz_server/repository"
+	"server/internal/domain"
+	"strings"
+)
+
+// LeadPatch - изменение заявки (nil - поле не меняется)
+type LeadPatch struct {
+	Status *domain.LeadStatus
+	Note   *string
+}
+
+// сервис заявок для админки
+type LeadService struct {
+	leads repository.LeadRepository
+}
+
+// конструктор для сервиса заявок
+func NewLeadService(leads repository.LeadRepository) *LeadService {
+	return &LeadService{leads: leads}
+}
+
+// метод получения страницы заявок
+func (s *LeadService) ListLeads(ctx context.Context, filter domain.LeadFilter) ([]*domain.Lead, int64, error) {
+	if filter.Status != "" && !filter.Status.Valid() {
+		return nil, 0, &ValidationError{Field: "status", Message: "unknown lead status"}
+	}
+	return s.leads.ListLeads(ctx, filter)
+}
+
+// метод получения заявки (repository.ErrLeadNotFound - заявки нет)
+func (s *LeadService) GetLead(ctx context.Context, id int64) (*domain.Lead, error) {
+	return s.leads.GetLead(ctx, id)
+}
+
+// метод изменения статуса и заметки заявки (возвращает заявку после изменения)
+func (s *LeadService) UpdateLead(ctx context.Context, id int64, patch LeadPatch) (*domain.Lead, error) {
+	if patch.Status != nil && !patch.Status.Valid() {
+		return nil, &ValidationError{Field: "status", Message: "unknown lead status"}
+	}
+
+	lead, err := s.leads.GetLead(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if patch.Status != nil {
+		lead.Status = *patch.Status
+	}
+	if patch.Note != nil {
+		lead.Note = strings.TrimSpace(*patch.Note)
+	}
+	if err := s.leads.UpdateLead(ctx, lead); err != nil {
+		return nil, err
+	}
+	return lead, nil
+}
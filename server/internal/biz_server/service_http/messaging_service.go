@@ -0,0 +1,99 @@
+package servicehttp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	pb "global_models/grpc/bot"
+	"log/slog"
+	"pkg/logger"
+	"server/internal/biz_server/chatevents"
+	"server/internal/biz_server/repository"
+	"server/internal/domain"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ErrDeliveryFailed - бот не смог отправить сообщение (Telegram отказал или бот недоступен)
+var ErrDeliveryFailed = errors.New("message delivery failed")
+
+// MessageSender - отправка сообщений клиентам через бота (grpcclient.BotGrpcClient)
+type MessageSender interface {
+	SendMessage(ctx context.Context, req *pb.SendMessageRequest) (*pb.SendMessageResponse, error)
+}
+
+// сервис отправки сообщений клиентам из админки
+type MessagingService struct {
+	users    repository.UserRepository
+	messages repository.MessageRepository
+	handoffs repository.HandoffRepository
+	sender   MessageSender
+	events   *chatevents.Bus // nil - консоль оператора не получает события
+}
+
+// конструктор для сервиса отправки сообщений
+func NewMessagingService(users repository.UserRepository, messages repository.MessageRepository, handoffs repository.HandoffRepository,
+	sender MessageSender, events *chatevents.Bus) *MessagingService {
+	return &MessagingService{users: users, messages: messages, handoffs: handoffs, sender: sender, events: events}
+}
+
+// метод отправки сообщения клиенту (личный чат с ботом - это telegram_id пользователя).
+// Сообщение попадает в переписку и при ошибке отправки - со статусом failed
+func (s *MessagingService) Send(ctx context.Context, telegramID int64, text string) (*domain.Message, error) {
+	text = strings.TrimSpace(strings.ReplaceAll(text, "\r\n", "\n"))
+	if text == "" {
+		return nil, &ValidationError{Field: "text", Message: "is required"}
+	}
+	if utf8.RuneCountInString(text) > domain.MaxContentLength {
+		return nil, &ValidationError{Field: "text", Message: "must be at most 4096 characters"}
+	}
+	if _, err := s.users.GetUserByTelegramID(ctx, telegramID); err != nil {
+		return nil, err
+	}
+
+	message := &domain.Message{
+		ChatID:    telegramID,
+		UserID:    telegramID,
+		Text:      text,
+		Direction: "outgoing",
+		Status:    "sent",
+		CreatedAt: time.Now(),
+	}
+	resp, sendErr := s.sender.SendMessage(ctx, &pb.SendMessageRequest{ChatId: telegramID, Text: text})
+	if sendErr == nil && !resp.GetSuccess() {
+		sendErr = errors.New(resp.GetError())
+	}
+	if sendErr != nil {
+		message.Status = "failed"
+	}
+
+	if err := s.messages.Save(ctx, message); err != nil {
+		slog.WarnContext(ctx, "failed to save admin message", "user_id", logger.MaskID(telegramID), "error", err)
+	}
+	s.notify(ctx, message)
+	if sendErr != nil {
+		slog.WarnContext(ctx, "admin message not delivered", "user_id", logger.MaskID(telegramID), "error", sendErr)
+		return message, fmt.Errorf("%w: %v", ErrDeliveryFailed, sendErr)
+	}
+	slog.InfoContext(ctx, "admin message sent", "user_id", logger.MaskID(telegramID))
+	return message, nil
+}
+
+// метод отправки сообщения в консоль оператора (ответ оператора продлевает диалог с ним)
+func (s *MessagingService) notify(ctx context.Context, message *domain.Message) {
+	if err := s.handoffs.TouchHandoff(ctx, message.UserID, message.CreatedAt); err != nil {
+		slog.WarnContext(ctx, "failed to touch handoff", "user_id", logger.MaskID(message.UserID), "error", err)
+	}
+	err := s.events.Publish(ctx, domain.ChatEvent{
+		Type:       domain.ChatEventMessage,
+		TelegramID: message.UserID,
+		Direction:  message.Direction,
+		Text:       message.Text,
+		Status:     message.Status,
+		At:         message.CreatedAt,
+	})
+	if err != nil {
+		slog.WarnContext(ctx, "failed to publish chat event", "error", err)
+	}
+}
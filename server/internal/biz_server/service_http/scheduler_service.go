@@ -0,0 +1,69 @@
+package servicehttp
+
+import (
+	"context"
+	"pkg/scheduler"
+)
+
+// сколько последних запусков отдаётся по умолчанию и максимум
+const (
+	defaultRunsLimit = 20
+	maxRunsLimit     = 200
+)
+
+// сервис управления задачами планировщика (админка)
+type SchedulerService struct {
+	scheduler *scheduler.Scheduler
+}
+
+// конструктор для сервиса планировщика
+func NewSchedulerService(sched *scheduler.Scheduler) *SchedulerService {
+	return &SchedulerService{scheduler: sched}
+}
+
+// метод получения всех задач
+func (s *SchedulerService) ListJobs(ctx context.Context) ([]*scheduler.Job, error) {
+	return s.scheduler.List(ctx)
+}
+
+// метод получения задачи с последними запусками (limit <= 0 - по умолчанию)
+func (s *SchedulerService) GetJob(ctx context.Context, name string, limit int) (*scheduler.Job, []*scheduler.Run, error) {
+	if limit <= 0 {
+		limit = defaultRunsLimit
+	}
+	limit = min(limit, maxRunsLimit)
+
+	job, err := s.scheduler.Get(ctx, name)
+	if err != nil {
+		return nil, nil, err
+	}
+	runs, err := s.scheduler.History(ctx, name, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+	return job, runs, nil
+}
+
+// метод постановки задачи на паузу (возвращает задачу после изменения)
+func (s *SchedulerService) PauseJob(ctx context.Context, name string) (*scheduler.Job, error) {
+	if err := s.scheduler.Pause(ctx, name); err != nil {
+		return nil, err
+	}
+	return s.scheduler.Get(ctx, name)
+}
+
+// метод снятия задачи с паузы
+func (s *SchedulerService) ResumeJob(ctx context.Context, name string) (*scheduler.Job, error) {
+	if err := s.scheduler.Resume(ctx, name); err != nil {
+		return nil, err
+	}
+	return s.scheduler.Get(ctx, name)
+}
+
+// метод запроса запуска задачи вне расписания (выполнит лидер при следующей проверке)
+func (s *SchedulerService) TriggerJob(ctx context.Context, name string) (*scheduler.Job, error) {
+	if err := s.scheduler.Trigger(ctx, name); err != nil {
+		return nil, err
+	}
+	return s.scheduler.Get(ctx, name)
+}
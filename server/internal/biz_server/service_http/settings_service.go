@@ -0,0 +1,46 @@
+package servicehttp
+
+import (
+	"context"
+	"net/url"
+	"server/internal/biz_server/repository"
+	"server/internal/domain"
+	"strings"
+	"time"
+)
+
+// сервис настроек бизнеса
+type SettingsService struct {
+	settings repository.SettingsRepository
+}
+
+// конструктор для сервиса настроек
+func NewSettingsService(settings repository.SettingsRepository) *SettingsService {
+	return &SettingsService{settings: settings}
+}
+
+// метод получения настроек
+func (s *SettingsService) GetSettings(ctx context.Context) (*domain.BusinessSettings, error) {
+	return s.settings.GetSettings(ctx)
+}
+
+// метод сохранения настроек целиком (пустой часовой пояс - UTC)
+func (s *SettingsService) SaveSettings(ctx context.Context, settings *domain.BusinessSettings) error {
+	settings.MasterName = strings.TrimSpace(settings.MasterName)
+	settings.InstagramURL = strings.TrimSpace(settings.InstagramURL)
+	settings.Timezone = strings.TrimSpace(settings.Timezone)
+	if settings.Timezone == "" {
+		settings.Timezone = domain.DefaultBusinessSettings().Timezone
+	}
+
+	if _, err := time.LoadLocation(settings.Timezone); err != nil {
+		return &ValidationError{Field: "timezone", Message: "unknown timezone"}
+	}
+	if settings.InstagramURL != "" {
+		u, err := url.Parse(settings.InstagramURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return &ValidationError{Field: "instagram_url", Message: "must be an http(s) URL"}
+		}
+	}
+	return s.settings.SaveSettings(ctx, settings)
+}
@@ -5,85 +5,225 @@ import (
 	"fmt"
 	"global_models/global_cache"
 	"global_models/global_db"
+	pb "global_models/grpc/bot"
 	"global_models/interf"
+	"io"
+	"log/slog"
+	"pkg/health"
+	"pkg/idempotency"
+	"pkg/jobqueue"
+	"pkg/logger"
+	memorycache "pkg/memory_cache"
+	"pkg/metrics"
+	"pkg/migrator"
 	postgresdb "pkg/postgres_db"
 	"pkg/redis"
+	"pkg/redis/lock"
+	"pkg/scheduler"
+	tieredcache "pkg/tiered_cache"
+	"pkg/tracing"
 	"runtime"
 
 	"server/configs"
+	"server/internal/biz_server/chatevents"
 	"server/internal/biz_server/grpcclient"
 	handlersgrpc "server/internal/biz_server/grpcserver/handlers_grpc"
+	"server/internal/biz_server/httpserver/adminui"
 	"server/internal/biz_server/httpserver/handlers"
 	"server/internal/biz_server/repository"
 	servicegrpc "server/internal/biz_server/service_grpc"
 	servicehttp "server/internal/biz_server/service_http"
+	"server/migrations"
 
 	"server/internal/interfaces"
 	"sync"
+	"time"
 )
 
 // Dependencies содержит все общие зависимости
 type BizServiceDepenencies struct {
 	BizConfig      *configs.BizServiceConfig       // конфиг всего сервера управления ботами
 	BizHTTPHandler interf.BizHTTPHandlerInterface  // интерфейс хэндлера http сервера (глобальный интерфейс)
+	BizAdminUI     *adminui.UI                     // страницы админки, которые рендерит сервер
 	BizGRPCHandler interfaces.GRPCHandlerInterface // интерфейс хэндлера для работы по grpc
+	BizDedup       interfaces.UpdateDeduplicator   // слой идемпотентности для обработки update
+	BizHealth      *health.Checker                 // проверки здоровья (gRPC health, /healthz, /readyz)
+	BizLeader      *lock.Elector                   // выбор лидера: периодические задачи выполняет один экземпляр
+	BizJobs        *jobqueue.Queue                 // очередь фоновых задач (уведомления, рассылки, выгрузки, вебхуки)
+	BizScheduler   *scheduler.Scheduler            // планировщик периодических задач и таймеров (выполняет лидер)
 	bizGRPCClient  *grpcclient.BotGrpcClient       // эт поле зобавлено, чтобы останавливать клиент (освобождение ресурсов)
+	shutdownTrace  tracing.ShutdownFunc            // дописывает накопленные спаны при остановке
 
 	// добавляем поля для логики освобождения ресурсов
-	pgPool         global_db.Pool     // для особождения ресурсов DB
-	redisCacherepo global_cache.Cache // для освобождения ресурсов redis
-	closeOnce      sync.Once          // для того, чтобы функция освобождения ресурсов выполнилась только 1 раз
+	bizRepo        *repository.BizRepository      // для записи накопленных last_seen_at перед закрытием БД
+	messageLog     *repository.AsyncLogRepository // для записи буфера журнала сообщений перед закрытием БД (nil - журнал синхронный)
+	pgPool         global_db.Pool                 // для особождения ресурсов DB
+	tieredCache    *tieredcache.Cache             // для остановки подписки на сбросы (nil - локальный уровень выключен)
+	chatEvents     *chatevents.Bus                // для остановки подписки на события чатов (nil - события выключены)
+	jobsClient     io.Closer                      // отдельное соединение очереди задач (nil - задачи выполняются без redis)
+	redisCacherepo global_cache.Cache             // для освобождения ресурсов redis
+	closeOnce      sync.Once                      // для того, чтобы функция освобождения ресурсов выполнилась только 1 раз
 	closeErr       error
 }
 
 // InitDependencies инициализирует общие зависимости для auth_service
 func InitDependencies(ctx context.Context) (*BizServiceDepenencies, error) {
-	// Получаем количество CPU
-	currentMaxProcs := runtime.GOMAXPROCS(-1)
-	fmt.Printf("Текущее значение GOMAXPROCS: %d\n", currentMaxProcs)
-
 	// Получаем конфигурацию
 	conf, err := configs.LoadConfig()
 	if err != nil {
 		return nil, fmt.Errorf("failed to load config: %w", err)
 	}
 
+	// настраиваем логгер (json/text по ENV, уровень из конфига)
+	logger.Init(conf.LoggerConf)
+
+	// Получаем количество CPU
+	slog.Info("runtime", "gomaxprocs", runtime.GOMAXPROCS(-1))
+
+	// настраиваем трассировку (экспортер из конфига)
+	shutdownTrace, err := tracing.Init(ctx, conf.TracingConf, "biz-server")
+	if err != nil {
+		return nil, fmt.Errorf("failed to init tracing: %w", err)
+	}
+
+	// проверяем схему БД: применяем миграции или отказываемся стартовать на устаревшей схеме (режим из конфига)
+	if err := checkMigrations(ctx, conf); err != nil {
+		return nil, err
+	}
+
 	// создаём экземпляр пула соединений для postgresQL
 	// адаптер к глобальному интерфейсу используется внутри NewPoolWithConfig
 	pgPool, err := postgresdb.NewPoolWithConfig(ctx, conf.PostgresDBConf)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create PostgreSQL repository: %w", err)
 	}
+	// статистика пула и длительность запросов в метриках, каждый запрос к БД - отдельный спан в трейсе update
+	metrics.RegisterPoolStats(pgPool)
+	pgPool = tracing.WrapPool(metrics.WrapPool(pgPool))
 
 	// создаём репозиторий для сохранения информации при работе с ботом
 	bizRepo := repository.NewBizDBRepository(pgPool)
 
-	// создаём экземпляр redis
-	redisCacherepo, err := redis.NewRedisCacheRepository(conf.RedisConf)
+	// создаём экземпляр redis (или кэш в памяти для локального запуска)
+	redisCache, err := newCache(conf)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Black List repository (based om Redis): %w", err)
 	}
+	// длительность команд в метриках, каждая операция с кэшем - отдельный спан в трейсе update
+	redisCacherepo := tracing.WrapCache(metrics.WrapCache(redisCache))
+
+	// редко меняющиеся данные (пользователи) читаются из памяти процесса, сбросы рассылаются через redis pub/sub
+	var userCache global_cache.Cache = redisCacherepo
+	var tiered *tieredcache.Cache
+	if conf.TieredCacheConf.Enabled {
+		bus, _ := redisCache.(global_cache.PubSub)
+		tiered, err = tieredcache.New(redisCacherepo, bus, conf.TieredCacheConf, "server")
+		if err != nil {
+			return nil, fmt.Errorf("failed to create tiered cache: %w", err)
+		}
+		userCache = tiered
+	}
 
 	// создаём репозиторий кэша
-	cache, err := repository.NewBizCacheRepo(redisCacherepo, "server_cache")
+	cache, err := repository.NewBizCacheRepo(userCache, "server_cache")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cache repository: %w", err)
+	}
 
-	// создаём слой репозитория (на базе репозитория Postgres и кэша (на базе redis))
-	repo, err := repository.NewBizRepository(bizRepo, cache)
+	// создаём слой репозитория (на базе репозитория Postgres и кэша (на базе redis)):
+	// пользователи читаются через кэш, время активности пишется в БД пачками
+	repo, err := repository.NewBizRepository(bizRepo, cache, conf.UserCacheConf)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Auth Repository Layer: %w", err)
 	}
 
+	// журнал сообщений и колбэков пишется в фоне пачками (ответ пользователю не ждёт БД)
+	var serviceRepo repository.Repositories = repo
+	var messageLog *repository.AsyncLogRepository
+	if conf.MessageLogConf.Async {
+		messageLog = repository.NewAsyncLogRepository(repo, conf.MessageLogConf)
+		serviceRepo = messageLog
+	}
+
+	// создаём выбор лидера среди экземпляров сервера (блокировка в redis с fencing token)
+	locker, err := lock.NewLocker(redisCacherepo, "server_cache")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create locker: %w", err)
+	}
+	leader, err := lock.NewElector(locker, conf.LeaderConf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create leader elector: %w", err)
+	}
+
+	// создаём планировщик: задачи и история в Postgres, запускает только лидер
+	sched, err := newScheduler(ctx, conf, pgPool, leader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create scheduler: %w", err)
+	}
+
+	// создаём очередь фоновых задач (отдельный клиент redis: обработчики держат соединения в блокирующем чтении)
+	jobs, jobsClient, err := newJobQueue(conf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create job queue: %w", err)
+	}
+
+	// создаём слой идемпотентности (дедупликация update по update_id на базе redis)
+	dedup, err := idempotency.NewGuard(redisCacherepo, conf.IdempotencyConf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create idempotency guard: %w", err)
+	}
+
+	// события чатов для консоли оператора рассылаются через redis pub/sub (консоль может быть открыта на другом экземпляре)
+	var events *chatevents.Bus
+	if bus, ok := redisCache.(global_cache.PubSub); ok {
+		events = chatevents.New(bus, conf.HandoffConf.Channel)
+	} else {
+		slog.Warn("cache has no pub/sub, operator console works without live updates")
+	}
+
 	// создаём экземпляр grpc клиента
 	grpcClient, err := grpcclient.NewBotGrpcClient(conf.GRPCClientConfig)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create grpc client: %w", err)
 	}
 
+	// создаём проверки здоровья: без БД и кэша сервер не готов, недоступность бота только отображаем
+	healthChecker := health.NewChecker(conf.HealthConf, []string{pb.BotService_ServiceDesc.ServiceName},
+		health.Check{Name: "postgres", Critical: true, Fn: pgPool.Ping},
+		health.Check{Name: "redis", Critical: true, Fn: redisCacherepo.Ping},
+		health.Check{Name: "bot_grpc", Critical: false, Fn: grpcClient.CheckHealth},
+	)
+
 	// создаём сервисный слой для grpc
-	serviceGRPC := servicegrpc.NewBizServiceFacade(repo, grpcClient)
+	// (заявки пишутся в Postgres напрямую: их сразу видит админка)
+	serviceGRPC := servicegrpc.NewBizServiceFacade(serviceRepo, bizRepo, bizRepo, bizRepo, bizRepo, events, conf.HandoffConf, grpcClient)
 
-	// создаём сервисный слой для http
-	serviceHTTP := servicehttp.NewBizServiceFacade()
+	// создаём вход в админку (администраторы и сессии - в Postgres)
+	auth, err := newAuthService(ctx, conf, bizRepo, sched)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create admin auth: %w", err)
+	}
+
+	// создаём сервисный слой для http (выборки админки - из Postgres напрямую, профиль пользователя - через кэш)
+	serviceHTTP := servicehttp.NewBizServiceFacade(servicehttp.FacadeDeps{
+		Users:       repo,
+		Admin:       bizRepo,
+		Auth:        auth,
+		Scheduler:   sched,
+		Sender:      grpcClient,
+		Events:      events,
+		HandoffConf: conf.HandoffConf,
+	})
+
+	// диалоги с оператором без сообщений дольше inactivity_timeout закрываются, бот снова отвечает
+	if err := addHandoffExpiry(ctx, conf, serviceHTTP.Handoffs, sched); err != nil {
+		return nil, fmt.Errorf("failed to schedule handoff expiry: %w", err)
+	}
+
+	// агрегаты аналитики (воронки, источники, когорты) пересчитываются по расписанию на лидере
+	if err := addAnalyticsRefresh(ctx, conf, serviceHTTP.Analytics, sched); err != nil {
+		return nil, fmt.Errorf("failed to schedule analytics refresh: %w", err)
+	}
 
 	// создаём слой хэндлера для HTTP
 	bizHTTPHandler := handlers.NewBizHandler(serviceHTTP)
@@ -91,6 +231,12 @@ func InitDependencies(ctx context.Context) (*BizServiceDepenencies, error) {
 		return nil, fmt.Errorf("failed to create bizness http handler")
 	}
 
+	// создаём страницы админки (шаблоны и статика встроены в бинарник)
+	adminUI, err := adminui.New(serviceHTTP)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create admin ui: %w", err)
+	}
+
 	// создаём слой хэндлера для GRPC
 	bizGRPCHandler := handlersgrpc.NewBizGRPCHandler(serviceGRPC)
 	if bizGRPCHandler == nil {
@@ -100,13 +246,149 @@ func InitDependencies(ctx context.Context) (*BizServiceDepenencies, error) {
 	return &BizServiceDepenencies{
 		BizConfig:      conf,
 		BizHTTPHandler: bizHTTPHandler,
+		BizAdminUI:     adminUI,
 		BizGRPCHandler: bizGRPCHandler,
+		BizDedup:       dedup,
+		BizHealth:      healthChecker,
+		BizLeader:      leader,
+		BizJobs:        jobs,
+		BizScheduler:   sched,
 		bizGRPCClient:  grpcClient, // Сохраняем для закрытия
+		shutdownTrace:  shutdownTrace,
+		bizRepo:        repo,
+		messageLog:     messageLog,
+		tieredCache:    tiered,
+		chatEvents:     events,
+		jobsClient:     jobsClient,
 		pgPool:         pgPool,
 		redisCacherepo: redisCacherepo,
 	}, nil
 }
 
+// функция для создания кэша по драйверу из конфига
+func newCache(conf *configs.BizServiceConfig) (global_cache.Cache, error) {
+	if conf.RedisConf.InMemory() {
+		// данные теряются при перезапуске и не видны другим экземплярам сервера
+		slog.Warn("using in-memory cache instead of redis (development only)")
+		return memorycache.NewMemoryCache(time.Minute), nil
+	}
+	return redis.NewRedisCacheRepository(conf.RedisConf)
+}
+
+// функция для создания планировщика и встроенных задач сервера
+func newScheduler(ctx context.Context, conf *configs.BizServiceConfig, pool global_db.Pool, leader *lock.Elector) (*scheduler.Scheduler, error) {
+	sched, err := scheduler.New(repository.NewSchedulerDBRepository(pool), leader, conf.SchedulerConf)
+	if err != nil {
+		return nil, err
+	}
+
+	// очистка истории запусков старше history_retention (каждую ночь)
+	if err := sched.AddCron(ctx, "cleanup_scheduler_history", scheduler.CleanupHistoryHandler, "0 4 * * *", "", nil); err != nil {
+		return nil, err
+	}
+	return sched, nil
+}
+
+// имя обработчика задачи очистки истёкших сессий админки
+const cleanupAdminSessionsHandler = "admin_auth.cleanup_sessions"
+
+// функция для создания сервиса входа в админку: владельцы из конфига и ежедневная очистка истёкших сессий
+func newAuthService(ctx context.Context, conf *configs.BizServiceConfig, repo repository.AdminAuthRepository, sched *scheduler.Scheduler) (*servicehttp.AuthService, error) {
+	auth, err := servicehttp.NewAuthService(repo, conf.AdminAuthConf)
+	if err != nil {
+		return nil, err
+	}
+	if !auth.Enabled() {
+		// API админки открыто всем, кто достучался до порта
+		slog.Warn("admin auth is disabled, admin API is not protected (development only)")
+		return auth, nil
+	}
+
+	if err := auth.EnsureOwners(ctx, conf.AdminAuthConf.OwnerIDs); err != nil {
+		return nil, err
+	}
+	sched.Register(cleanupAdminSessionsHandler, func(ctx context.Context, _ *scheduler.Job) error {
+		deleted, err := auth.CleanupSessions(ctx)
+		if err == nil && deleted > 0 {
+			slog.InfoContext(ctx, "expired admin sessions deleted", "count", deleted)
+		}
+		return err
+	})
+	if err := sched.AddCron(ctx, "cleanup_admin_sessions", cleanupAdminSessionsHandler, "30 4 * * *", "", nil); err != nil {
+		return nil, err
+	}
+	return auth, nil
+}
+
+// имя обработчика задачи закрытия неактивных диалогов с оператором
+const expireHandoffsHandler = "handoff.expire_idle"
+
+// функция для регистрации задачи закрытия неактивных диалогов с оператором
+func addHandoffExpiry(ctx context.Context, conf *configs.BizServiceConfig, handoffs *servicehttp.HandoffService, sched *scheduler.Scheduler) error {
+	sched.Register(expireHandoffsHandler, func(ctx context.Context, _ *scheduler.Job) error {
+		closed, err := handoffs.ExpireIdle(ctx)
+		if err == nil && closed > 0 {
+			slog.InfoContext(ctx, "idle handoffs closed", "count", closed)
+		}
+		return err
+	})
+	return sched.AddCron(ctx, "expire_handoffs", expireHandoffsHandler, conf.HandoffConf.ExpireSpec, "", nil)
+}
+
+// имя обработчика задачи пересчёта аналитики
+const refreshAnalyticsHandler = "analytics.refresh"
+
+// функция для регистрации задачи пересчёта агрегатов аналитики
+func addAnalyticsRefresh(ctx context.Context, conf *configs.BizServiceConfig, analytics *servicehttp.AnalyticsService, sched *scheduler.Scheduler) error {
+	sched.Register(refreshAnalyticsHandler, func(ctx context.Context, _ *scheduler.Job) error {
+		snapshot, err := analytics.Refresh(ctx, conf.AnalyticsConf.Days, conf.AnalyticsConf.Weeks)
+		if err == nil {
+			slog.InfoContext(ctx, "analytics refreshed", "funnels", len(snapshot.Funnels), "sources", len(snapshot.Sources), "cohorts", len(snapshot.Cohorts))
+		}
+		return err
+	})
+	return sched.AddCron(ctx, "refresh_analytics", refreshAnalyticsHandler, conf.AnalyticsConf.RefreshSpec, "", nil)
+}
+
+// функция для создания очереди задач: без redis (кэш в памяти) или с выключенной очередью
+// задачи выполняются сразу в фоне, без повторов
+func newJobQueue(conf *configs.BizServiceConfig) (*jobqueue.Queue, io.Closer, error) {
+	if conf.RedisConf.InMemory() || !conf.JobQueueConf.Enabled {
+		slog.Warn("job queue is disabled, jobs run inline without retries")
+		queue, err := jobqueue.New(nil, conf.JobQueueConf)
+		return queue, nil, err
+	}
+
+	client, err := redis.NewClient(conf.RedisConf)
+	if err != nil {
+		return nil, nil, err
+	}
+	queue, err := jobqueue.New(client, conf.JobQueueConf)
+	if err != nil {
+		client.Close()
+		return nil, nil, err
+	}
+	return queue, client, nil
+}
+
+// функция для проверки миграций при старте (режим off / auto / check)
+func checkMigrations(ctx context.Context, conf *configs.BizServiceConfig) error {
+	if !conf.MigrationsConf.Enabled() {
+		return nil
+	}
+
+	m, err := migrator.New(conf.PostgresDBConf.DSN, migrations.FS, conf.MigrationsConf)
+	if err != nil {
+		return fmt.Errorf("failed to create migrator: %w", err)
+	}
+	defer m.Close()
+
+	if err := m.OnStartup(ctx, conf.MigrationsConf.Mode); err != nil {
+		return fmt.Errorf("migrations check failed: %w", err)
+	}
+	return nil
+}
+
 // метод структуры зависимостей для осбобождения ресурсов
 func (d *BizServiceDepenencies) Close() error {
 	d.closeOnce.Do(func() {
@@ -119,6 +401,66 @@ func (d *BizServiceDepenencies) Close() error {
 			}
 		}
 
+		// останавливаем планировщик и дожидаемся его задач (пока лидерство, БД и redis доступны)
+		if d.BizScheduler != nil {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			if err := d.BizScheduler.Close(ctx); err != nil {
+				errs = append(errs, fmt.Errorf("scheduler: %w", err))
+			}
+			cancel()
+		}
+
+		// отдаём лидерство сразу, чтобы другой экземпляр не ждал истечения блокировки (пока redis доступен)
+		if d.BizLeader != nil {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			if err := d.BizLeader.Close(ctx); err != nil {
+				errs = append(errs, fmt.Errorf("leader election: %w", err))
+			}
+			cancel()
+		}
+
+		// дожидаемся выполняемых фоновых задач (им могут понадобиться БД и redis), новые не берём
+		if d.BizJobs != nil {
+			ctx, cancel := context.WithTimeout(context.Background(), d.BizConfig.JobQueueConf.JobTimeout)
+			if err := d.BizJobs.Close(ctx); err != nil {
+				errs = append(errs, fmt.Errorf("job queue: %w", err))
+			}
+			cancel()
+		}
+		if d.jobsClient != nil {
+			if err := d.jobsClient.Close(); err != nil {
+				errs = append(errs, fmt.Errorf("job queue redis: %w", err))
+			}
+		}
+
+		// дописываем буфер журнала сообщений (пока БД доступна)
+		if d.messageLog != nil {
+			ctx, cancel := context.WithTimeout(context.Background(), d.BizConfig.MessageLogConf.FlushTimeout)
+			if err := d.messageLog.Close(ctx); err != nil {
+				errs = append(errs, fmt.Errorf("message log: %w", err))
+			}
+			cancel()
+		}
+
+		// дописываем накопленное время активности пользователей (пока БД доступна)
+		if d.bizRepo != nil {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			if err := d.bizRepo.Close(ctx); err != nil {
+				errs = append(errs, fmt.Errorf("repository: %w", err))
+			}
+			cancel()
+		}
+
+		// останавливаем подписку на сбросы локального кэша (до закрытия redis)
+		if d.tieredCache != nil {
+			d.tieredCache.Close()
+		}
+
+		// останавливаем подписку на события чатов (до закрытия redis)
+		if d.chatEvents != nil {
+			d.chatEvents.Close()
+		}
+
 		// Закрываем Redis
 		if d.redisCacherepo != nil {
 			if err := d.redisCacherepo.Close(); err != nil {
@@ -133,6 +475,15 @@ func (d *BizServiceDepenencies) Close() error {
 			}
 		}
 
+		// дописываем накопленные спаны (в последнюю очередь, чтобы попали спаны остановки)
+		if d.shutdownTrace != nil {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			if err := d.shutdownTrace(ctx); err != nil {
+				errs = append(errs, fmt.Errorf("tracing: %w", err))
+			}
+			cancel()
+		}
+
 		// проверяем аггрегированные ошибки
 		if len(errs) > 0 {
 			d.closeErr = fmt.Errorf("close errors: %v", errs)
@@ -140,7 +491,7 @@ func (d *BizServiceDepenencies) Close() error {
 	})
 
 	if d.closeErr == nil {
-		fmt.Println("Ресурсы - освобождены")
+		slog.Info("resources released")
 	}
 
 	// если все хоршо, то возвращаем nil
@@ -0,0 +1,109 @@
+package domain
+
+import "time"
+
+// модели и фильтры выборок админки
+
+// Page - страница выдачи (нумерация с 1)
+type Page struct {
+	Number int
+	Size   int
+}
+
+// метод возвращает смещение первой записи страницы
+func (p Page) Offset() int {
+	return (p.Number - 1) * p.Size
+}
+
+// UserFilter - фильтр списка пользователей
+type UserFilter struct {
+	Query  string // поиск по username, имени, фамилии или точному telegram_id
+	Active *bool  // nil - все пользователи
+	Page   Page
+}
+
+// MessageFilter - выборка переписки с пользователем (сначала новые, постранично по id)
+type MessageFilter struct {
+	TelegramID int64
+	BeforeID   int64 // только сообщения с id меньше (0 - с последнего)
+	Limit      int
+}
+
+// CallbackFilter - фильтр журнала нажатий кнопок
+type CallbackFilter struct {
+	TelegramID int64  // 0 - все пользователи
+	Data       string // точное значение callback_data (пустое - все)
+	Page       Page
+}
+
+// LeadStatus - статус заявки
+type LeadStatus string
+
+const (
+	LeadNew        LeadStatus = "new"         // клиент согласился на связь, мастер ещё не ответил
+	LeadInProgress LeadStatus = "in_progress" // мастер связался с клиентом
+	LeadWon        LeadStatus = "won"         // клиент записался
+	LeadLost       LeadStatus = "lost"        // клиент отказался или не ответил
+)
+
+// метод проверки статуса
+func (s LeadStatus) Valid() bool {
+	switch s {
+	case LeadNew, LeadInProgress, LeadWon, LeadLost:
+		return true
+	}
+	return false
+}
+
+// метод возвращает, открыта ли заявка (у пользователя может быть только одна открытая заявка)
+func (s LeadStatus) Open() bool {
+	return s == LeadNew || s == LeadInProgress
+}
+
+// Lead - заявка клиента (согласие на связь с мастером)
+type Lead struct {
+	ID         int64
+	TelegramID int64 // пользователь Telegram
+	Status     LeadStatus
+	Source     string // откуда пришла заявка (callback_data кнопки)
+	Note       string // заметка мастера
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	User       *User // профиль пользователя (заполняется в списках, nil - пользователь не найден)
+}
+
+// LeadFilter - фильтр списка заявок
+type LeadFilter struct {
+	Status     LeadStatus // пустой - все статусы
+	TelegramID int64      // 0 - все пользователи
+	Page       Page
+}
+
+// BusinessSettings - настройки бизнеса (одни на сервер)
+type BusinessSettings struct {
+	MasterName   string // имя мастера в сообщениях бота
+	InstagramURL string // ссылка на аккаунт мастера
+	WelcomeText  string // приветствие нового клиента
+	NotifyChatID int64  // чат мастера для уведомлений о заявках (0 - не уведомлять)
+	Timezone     string // часовой пояс мастера
+	UpdatedAt    time.Time
+}
+
+// DefaultBusinessSettings - настройки, пока их не сохранили
+func DefaultBusinessSettings() *BusinessSettings {
+	return &BusinessSettings{Timezone: "UTC"}
+}
+
+// DashboardStats - сводка для главной страницы админки с начала периода (обычно - с начала дня)
+type DashboardStats struct {
+	NewUsers    int64 // новые пользователи
+	ActiveUsers int64 // пользователи, которые писали боту или нажимали кнопки
+
+	// воронка (уникальные пользователи): /start -> знакомство с мастером -> согласие на связь
+	Started      int64
+	Lookups      int64
+	ContactedYes int64
+
+	NewLeads      int64                // заявки за период
+	LeadsByStatus map[LeadStatus]int64 // все заявки по статусам
+}
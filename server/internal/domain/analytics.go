@@ -0,0 +1,126 @@
+package domain
+
+import (
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// AnalyticsPeriod - период воронки
+type AnalyticsPeriod string
+
+const (
+	PeriodDay  AnalyticsPeriod = "day"
+	PeriodWeek AnalyticsPeriod = "week" // неделя с понедельника
+)
+
+// метод проверки периода
+func (p AnalyticsPeriod) Valid() bool {
+	return p == PeriodDay || p == PeriodWeek
+}
+
+// шаги воронки: команда /start и нажатия кнопок (callback_data)
+const (
+	FunnelStart        = "start"
+	FunnelLookup       = "lookup"
+	FunnelContactedYes = "contacted_yes"
+)
+
+// DirectSource - источник пользователей, открывших бота без параметра ссылки (t.me/<бот>?start=<источник>)
+const DirectSource = "direct"
+
+// максимальная длина источника (параметр /start у Telegram - до 64 символов)
+const maxSourceLength = 64
+
+// параметры /start, которыми бот открывает свои разделы - это навигация, а не источник
+var navigationStartParams = map[string]bool{"menu": true, "help": true}
+
+// StartSource возвращает источник по тексту команды ("/start instagram" -> "instagram")
+func StartSource(text string) string {
+	fields := strings.Fields(text)
+	if len(fields) < 2 {
+		return DirectSource
+	}
+	source := strings.ToLower(fields[1])
+	if navigationStartParams[source] {
+		return DirectSource
+	}
+	if utf8.RuneCountInString(source) > maxSourceLength {
+		source = string([]rune(source)[:maxSourceLength])
+	}
+	return source
+}
+
+// AnalyticsUser - пользователь для пересчёта аналитики
+type AnalyticsUser struct {
+	TelegramID     int64
+	CreatedAt      time.Time
+	Source         string     // источник первого /start (DirectSource - без параметра или /start не сохранён)
+	FirstContactAt *time.Time // первое согласие на связь (nil - не соглашался)
+}
+
+// FunnelEvent - шаг воронки пользователя (FunnelStart, FunnelLookup или FunnelContactedYes)
+type FunnelEvent struct {
+	TelegramID int64
+	Step       string
+	At         time.Time
+}
+
+// UserActivity - пользователь писал боту или нажимал кнопки в течение часа, начинающегося в Hour
+type UserActivity struct {
+	TelegramID int64
+	Hour       time.Time
+}
+
+// AnalyticsFacts - исходные данные пересчёта аналитики с момента Since
+type AnalyticsFacts struct {
+	Since    time.Time
+	Users    []AnalyticsUser // появившиеся с Since и впервые согласившиеся на связь с Since
+	Events   []FunnelEvent   // шаги воронки с Since
+	Activity []UserActivity  // активность с Since (по часам)
+}
+
+// FunnelStats - воронка за период (пользователи считаются уникальными в пределах периода)
+type FunnelStats struct {
+	Period      AnalyticsPeriod
+	Start       time.Time // дата начала периода по часовому поясу мастера (00:00 UTC этой даты)
+	NewUsers    int64
+	ActiveUsers int64
+
+	// воронка: /start -> знакомство с мастером -> согласие на связь
+	Started      int64
+	Lookups      int64
+	ContactedYes int64
+
+	// время от первого обращения к боту до первого согласия на связь (у согласившихся впервые за период)
+	Contacts          int64
+	ContactTimeAvg    time.Duration
+	ContactTimeMedian time.Duration
+}
+
+// SourceStats - новые пользователи периода из одного источника и сколько из них дошли до шагов воронки
+type SourceStats struct {
+	Period       AnalyticsPeriod
+	Start        time.Time
+	Source       string
+	NewUsers     int64
+	Lookups      int64
+	ContactedYes int64
+}
+
+// CohortStats - когорта пользователей, появившихся за неделю, и её удержание
+type CohortStats struct {
+	Start    time.Time // понедельник недели прихода
+	Size     int64
+	Retained []int64 // Retained[i] - активные на i-й неделе после прихода (0 - неделя прихода)
+}
+
+// AnalyticsSnapshot - пересчитанные агрегаты: заменяют сохранённые дневные с DayFrom и недельные с WeekFrom
+type AnalyticsSnapshot struct {
+	DayFrom    time.Time
+	WeekFrom   time.Time
+	Funnels    []*FunnelStats
+	Sources    []*SourceStats
+	Cohorts    []*CohortStats
+	ComputedAt time.Time
+}
@@ -0,0 +1,54 @@
+package domain
+
+import "time"
+
+// администраторы админки и их сессии
+
+// AdminRole - роль администратора (права старшей роли включают права младших)
+type AdminRole string
+
+const (
+	RoleOwner   AdminRole = "owner"   // владелец: всё, включая управление администраторами и планировщиком
+	RoleManager AdminRole = "manager" // менеджер: работа с заявками и настройками бизнеса
+	RoleViewer  AdminRole = "viewer"  // наблюдатель: только чтение
+)
+
+// уровни ролей (неизвестная роль - 0, не даёт никаких прав)
+var roleLevels = map[AdminRole]int{
+	RoleViewer:  1,
+	RoleManager: 2,
+	RoleOwner:   3,
+}
+
+// метод проверки роли
+func (r AdminRole) Valid() bool {
+	return roleLevels[r] > 0
+}
+
+// метод возвращает, даёт ли роль права required
+func (r AdminRole) Allows(required AdminRole) bool {
+	return r.Valid() && roleLevels[r] >= roleLevels[required]
+}
+
+// Admin - администратор (вход по Telegram ID)
+type Admin struct {
+	TelegramID  int64
+	Role        AdminRole
+	Username    string // профиль Telegram обновляется при каждом входе
+	FirstName   string
+	LastName    string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	LastLoginAt *time.Time // nil - ещё не входил
+}
+
+// AdminSession - сессия администратора (токен обновления; хранится только хэш)
+type AdminSession struct {
+	ID         int64
+	TelegramID int64
+	TokenHash  []byte
+	UserAgent  string
+	CreatedAt  time.Time
+	ExpiresAt  time.Time
+	RevokedAt  *time.Time // не nil - токен уже использован или сессия закрыта
+}
@@ -0,0 +1,79 @@
+package domain
+
+import "time"
+
+// тексты бота, которые меняются из админки
+
+// ContentKey - ключ текста бота
+type ContentKey string
+
+const (
+	ContentHelp         ContentKey = "help"          // ответ на кнопку "Помощь"
+	ContentMainMenu     ContentKey = "main_menu"     // заголовок главного меню
+	ContentLookup       ContentKey = "lookup"        // ссылка на мастера и выбор "связался / не готов"
+	ContentContactedYes ContentKey = "contacted_yes" // ответ на согласие связаться с мастером
+	ContentContactedNo  ContentKey = "contacted_no"  // ответ на отказ
+	ContentHandoffStart ContentKey = "handoff_start" // ответ на просьбу позвать мастера (бот замолкает)
+	ContentHandoffEnd   ContentKey = "handoff_end"   // сообщение клиенту, когда бот снова отвечает
+)
+
+// MaxContentLength - ограничение Telegram на длину текста сообщения
+const MaxContentLength = 4096
+
+// описание и текст по умолчанию для каждого ключа (порядок - как на странице админки)
+var defaultContent = []struct {
+	key   ContentKey
+	title string
+	text  string
+}{
+	{ContentMainMenu, "Главное меню", "🏠 Главное меню"},
+	{ContentHelp, "Помощь", "🤖 Я бот-помощник. Доступные команды:\n/help - помощь\n/menu - главное меню"},
+	{ContentLookup, "Знакомство с мастером", "📸 Вот ссылка на Instagram аккаунт мастера:\nПосле просмотра, пожалуйста, выберите вариант:"},
+	{ContentContactedYes, "Согласие на связь", "✅ Отлично! Я передам ваши контакты мастеру. Ожидайте связи в ближайшее время."},
+	{ContentContactedNo, "Отказ от связи", "💭 Жаль! Если передумаете, просто нажмите /start, чтобы вернуться в меню."},
+	{ContentHandoffStart, "Позвать мастера", "🙋 Позвал мастера, он ответит здесь же. Пока вы общаетесь, бот не будет вмешиваться."},
+	{ContentHandoffEnd, "Конец диалога с мастером", "🤖 Диалог с мастером завершён, бот снова на связи. Нажмите /start, чтобы открыть меню."},
+}
+
+// ContentItem - текст бота для админки
+type ContentItem struct {
+	Key       ContentKey
+	Title     string
+	Text      string
+	IsDefault bool       // текст не меняли
+	UpdatedAt *time.Time // nil - текст по умолчанию
+}
+
+// метод проверки ключа
+func (k ContentKey) Valid() bool {
+	for _, item := range defaultContent {
+		if item.key == k {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultContent возвращает текст по умолчанию (пустая строка - неизвестный ключ)
+func DefaultContent(key ContentKey) string {
+	for _, item := range defaultContent {
+		if item.key == key {
+			return item.text
+		}
+	}
+	return ""
+}
+
+// ContentItems собирает все тексты бота: изменённые из saved, остальные - по умолчанию
+func ContentItems(saved map[ContentKey]ContentItem) []ContentItem {
+	items := make([]ContentItem, 0, len(defaultContent))
+	for _, def := range defaultContent {
+		item, ok := saved[def.key]
+		if !ok {
+			item = ContentItem{Key: def.key, Text: def.text, IsDefault: true}
+		}
+		item.Title = def.title
+		items = append(items, item)
+	}
+	return items
+}
@@ -0,0 +1,60 @@
+package domain
+
+import "time"
+
+// диалог клиента с оператором (мастером): пока он открыт, бот не отвечает в этом чате
+
+// кнопки "Позвать мастера": текст кнопки reply клавиатуры шлюза и callback_data inline кнопки меню
+const (
+	HandoffButtonText = "🙋 Позвать мастера"
+	HandoffCallback   = "operator"
+)
+
+// HandoffReason - кто открыл диалог
+type HandoffReason string
+
+const (
+	HandoffByClient HandoffReason = "client" // клиент нажал "Позвать мастера"
+	HandoffByMaster HandoffReason = "master" // мастер перехватил чат в админке
+)
+
+// HandoffCloseReason - почему диалог закрыт
+type HandoffCloseReason string
+
+const (
+	HandoffClosedByOperator HandoffCloseReason = "operator" // оператор завершил диалог
+	HandoffClosedByTimeout  HandoffCloseReason = "timeout"  // в диалоге долго не было сообщений
+)
+
+// Handoff - диалог с оператором
+type Handoff struct {
+	ID             int64
+	TelegramID     int64 // чат клиента (совпадает с telegram_id пользователя)
+	Reason         HandoffReason
+	OpenedBy       int64 // telegram_id администратора (0 - открыл клиент или вход выключен)
+	OpenedAt       time.Time
+	LastActivityAt time.Time          // последнее сообщение клиента или оператора
+	ClosedAt       *time.Time         // nil - диалог открыт
+	CloseReason    HandoffCloseReason // пусто - диалог открыт
+	User           *User              // профиль пользователя (заполняется в списке, nil - пользователь не найден)
+}
+
+// ChatEventType - тип события чата для консоли оператора
+type ChatEventType string
+
+const (
+	ChatEventMessage       ChatEventType = "message"        // сообщение клиента, бота или оператора
+	ChatEventHandoffOpened ChatEventType = "handoff_opened" // бот замолчал, чат ведёт оператор
+	ChatEventHandoffClosed ChatEventType = "handoff_closed" // бот снова отвечает
+)
+
+// ChatEvent - событие чата (рассылается консолям операторов на всех экземплярах сервера)
+type ChatEvent struct {
+	Type       ChatEventType `json:"type"`
+	TelegramID int64         `json:"telegram_id"`
+	Direction  string        `json:"direction,omitempty"` // у сообщений: "incoming" или "outgoing"
+	Text       string        `json:"text,omitempty"`
+	Status     string        `json:"status,omitempty"` // статус сообщения (у исходящих: "sent" или "failed")
+	Reason     string        `json:"reason,omitempty"` // у событий диалога: кто открыл или почему закрыт
+	At         time.Time     `json:"at"`
+}
@@ -16,3 +16,9 @@ type GRPCHandlerInterface interface {
 	//ProcessIncomingMsg - обработка сообщения
 	ProcessIncomingMsg(ctx context.Context, req *pb.SendMessageRequest) (*pb.SendMessageResponse, error)
 }
+
+// интерфейс слоя идемпотентности: fn выполняется не более одного раза для ключа,
+// дубликаты получают сохранённый результат первой обработки
+type UpdateDeduplicator interface {
+	Do(ctx context.Context, key string, fn func(ctx context.Context) (data []byte, keep bool, err error)) ([]byte, error)
+}
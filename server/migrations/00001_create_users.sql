@@ -0,0 +1,17 @@
+-- +goose Up
+-- пользователи Telegram, которые писали боту
+CREATE TABLE IF NOT EXISTS users (
+    id           BIGSERIAL PRIMARY KEY,
+    telegram_id  BIGINT      NOT NULL,
+    username     TEXT        NOT NULL DEFAULT '',
+    first_name   TEXT        NOT NULL DEFAULT '',
+    last_name    TEXT        NOT NULL DEFAULT '',
+    is_active    BOOLEAN     NOT NULL DEFAULT TRUE,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_seen_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT users_telegram_id_key UNIQUE (telegram_id)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,25 @@
+-- +goose Up
+-- входящие и исходящие сообщения.
+-- у исходящих сообщений нет telegram_message_id (NULL) - уникальность (чат, сообщение) на них не распространяется
+CREATE TABLE IF NOT EXISTS messages (
+    id                  BIGSERIAL PRIMARY KEY,
+    telegram_message_id BIGINT,
+    telegram_chat_id    BIGINT      NOT NULL,
+    telegram_user_id    BIGINT      NOT NULL,
+    text                TEXT        NOT NULL DEFAULT '',
+    direction           VARCHAR(16) NOT NULL,
+    status              VARCHAR(16) NOT NULL DEFAULT '',
+    is_command          BOOLEAN     NOT NULL DEFAULT FALSE,
+    command_name        TEXT        NOT NULL DEFAULT '',
+    created_at          TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at          TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT messages_chat_message_key UNIQUE (telegram_chat_id, telegram_message_id),
+    CONSTRAINT messages_direction_check CHECK (direction IN ('incoming', 'outgoing'))
+);
+
+-- история переписки с пользователем
+CREATE INDEX IF NOT EXISTS messages_user_created_idx ON messages (telegram_user_id, created_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS messages;
@@ -0,0 +1,20 @@
+-- +goose Up
+-- нажатия inline кнопок (callback_id уникален - повторная доставка update не создаёт дубль)
+CREATE TABLE IF NOT EXISTS callback_logs (
+    id                  BIGSERIAL PRIMARY KEY,
+    callback_id         TEXT        NOT NULL,
+    telegram_user_id    BIGINT      NOT NULL,
+    telegram_chat_id    BIGINT      NOT NULL,
+    telegram_message_id BIGINT      NOT NULL DEFAULT 0,
+    callback_data       TEXT        NOT NULL DEFAULT '',
+    message_id          BIGINT      REFERENCES messages (id) ON DELETE SET NULL,
+    created_at          TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT callback_logs_callback_id_key UNIQUE (callback_id)
+);
+
+-- события воронки по пользователю
+CREATE INDEX IF NOT EXISTS callback_logs_user_created_idx ON callback_logs (telegram_user_id, created_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS callback_logs;
@@ -0,0 +1,61 @@
+-- +goose Up
+-- раньше пользователь регистрировался с id сообщения вместо id пользователя Telegram:
+-- в users.telegram_id попадал telegram_message_id входящего сообщения (или сообщения с клавиатурой у колбэков).
+-- Переносим такие строки на настоящий telegram_id отправителя и удаляем ошибочные.
+
+-- на случай БД, созданной до миграций: дубликаты и уникальность telegram_id (нужна для ON CONFLICT)
+DELETE FROM users a USING users b WHERE a.telegram_id = b.telegram_id AND a.id > b.id;
+CREATE UNIQUE INDEX IF NOT EXISTS users_telegram_id_key ON users (telegram_id);
+
+-- ошибочные строки: telegram_id совпадает с id сообщения, но не встречается как id пользователя.
+-- Настоящий id определяется, если у сообщений с таким id ровно один отправитель (иначе NULL - строка просто удаляется)
+CREATE TEMP TABLE users_repair ON COMMIT DROP AS
+SELECT u.id AS row_id,
+       CASE WHEN COUNT(DISTINCT s.telegram_user_id) = 1 THEN MIN(s.telegram_user_id) END AS telegram_id
+FROM users u
+JOIN (
+    SELECT telegram_message_id AS wrong_id, telegram_user_id
+    FROM messages
+    WHERE direction = 'incoming' AND telegram_message_id IS NOT NULL
+    UNION ALL
+    SELECT telegram_message_id, telegram_user_id
+    FROM callback_logs
+    WHERE telegram_message_id <> 0
+) s ON s.wrong_id = u.telegram_id
+WHERE u.telegram_id NOT IN (
+    SELECT telegram_user_id FROM messages
+    UNION
+    SELECT telegram_user_id FROM callback_logs
+)
+GROUP BY u.id;
+
+-- переносим профиль (из самой свежей строки) и время первого/последнего обращения
+INSERT INTO users (telegram_id, username, first_name, last_name, is_active, created_at, last_seen_at)
+SELECT DISTINCT ON (r.telegram_id)
+       r.telegram_id, u.username, u.first_name, u.last_name, u.is_active,
+       MIN(u.created_at) OVER w, MAX(u.last_seen_at) OVER w
+FROM users_repair r
+JOIN users u ON u.id = r.row_id
+WHERE r.telegram_id IS NOT NULL
+WINDOW w AS (PARTITION BY r.telegram_id)
+ORDER BY r.telegram_id, u.last_seen_at DESC
+ON CONFLICT (telegram_id) DO UPDATE SET
+    created_at = LEAST(users.created_at, EXCLUDED.created_at),
+    last_seen_at = GREATEST(users.last_seen_at, EXCLUDED.last_seen_at);
+
+DELETE FROM users WHERE id IN (SELECT row_id FROM users_repair);
+
+-- пользователи, от которых есть сообщения или колбэки, но нет строки в users (профиль заполнится при следующем update)
+INSERT INTO users (telegram_id, created_at, last_seen_at)
+SELECT telegram_user_id, MIN(created_at), MAX(created_at)
+FROM (
+    SELECT telegram_user_id, created_at FROM messages
+    UNION ALL
+    SELECT telegram_user_id, created_at FROM callback_logs
+) seen
+GROUP BY telegram_user_id
+ON CONFLICT (telegram_id) DO NOTHING;
+
+-- +goose Down
+-- исправление данных не откатывается
+SELECT 1;
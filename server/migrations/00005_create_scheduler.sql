@@ -0,0 +1,45 @@
+-- +goose Up
+-- задачи планировщика: периодические (cron) и таймеры (один запуск)
+CREATE TABLE IF NOT EXISTS scheduler_jobs (
+    name        TEXT        PRIMARY KEY,
+    kind        VARCHAR(16) NOT NULL,
+    handler     TEXT        NOT NULL,
+    spec        TEXT        NOT NULL DEFAULT '',
+    timezone    TEXT        NOT NULL DEFAULT 'UTC',
+    payload     JSONB,
+    paused      BOOLEAN     NOT NULL DEFAULT FALSE,
+    triggered   BOOLEAN     NOT NULL DEFAULT FALSE, -- запрошен запуск вне расписания
+    next_run_at TIMESTAMPTZ,                        -- NULL - таймер уже сработал
+    last_run_at TIMESTAMPTZ,
+    last_status VARCHAR(16) NOT NULL DEFAULT '',
+    last_fence  BIGINT      NOT NULL DEFAULT 0,     -- fencing token лидера, запустившего задачу последним
+    version     BIGINT      NOT NULL DEFAULT 1,     -- меняется при каждом изменении (защита от двойного запуска)
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT scheduler_jobs_kind_check CHECK (kind IN ('cron', 'once'))
+);
+
+-- поиск задач, время которых пришло
+CREATE INDEX IF NOT EXISTS scheduler_jobs_due_idx ON scheduler_jobs (next_run_at) WHERE NOT paused;
+
+-- история запусков
+CREATE TABLE IF NOT EXISTS scheduler_runs (
+    id           BIGSERIAL   PRIMARY KEY,
+    job_name     TEXT        NOT NULL REFERENCES scheduler_jobs (name) ON DELETE CASCADE,
+    trigger      VARCHAR(16) NOT NULL,
+    fence        BIGINT      NOT NULL DEFAULT 0,
+    scheduled_at TIMESTAMPTZ NOT NULL,
+    started_at   TIMESTAMPTZ NOT NULL,
+    finished_at  TIMESTAMPTZ,
+    status       VARCHAR(16) NOT NULL,
+    error        TEXT        NOT NULL DEFAULT ''
+);
+
+-- последние запуски задачи и очистка старой истории
+CREATE INDEX IF NOT EXISTS scheduler_runs_job_idx ON scheduler_runs (job_name, id DESC);
+CREATE INDEX IF NOT EXISTS scheduler_runs_started_idx ON scheduler_runs (started_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS scheduler_runs;
+DROP TABLE IF EXISTS scheduler_jobs;
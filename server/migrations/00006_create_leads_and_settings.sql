@@ -0,0 +1,42 @@
+-- +goose Up
+-- заявки клиентов (согласие на связь с мастером)
+CREATE TABLE IF NOT EXISTS leads (
+    id               BIGSERIAL PRIMARY KEY,
+    telegram_user_id BIGINT      NOT NULL,
+    status           VARCHAR(16) NOT NULL DEFAULT 'new',
+    source           TEXT        NOT NULL DEFAULT '',
+    note             TEXT        NOT NULL DEFAULT '',
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT leads_status_check CHECK (status IN ('new', 'in_progress', 'won', 'lost'))
+);
+
+-- у пользователя одна открытая заявка: повторное согласие не создаёт дубль
+CREATE UNIQUE INDEX IF NOT EXISTS leads_open_user_key ON leads (telegram_user_id) WHERE status IN ('new', 'in_progress');
+
+-- список заявок по статусу (сначала новые)
+CREATE INDEX IF NOT EXISTS leads_status_created_idx ON leads (status, created_at DESC);
+
+-- настройки бизнеса (одна строка)
+CREATE TABLE IF NOT EXISTS business_settings (
+    id             BOOLEAN     PRIMARY KEY DEFAULT TRUE,
+    master_name    TEXT        NOT NULL DEFAULT '',
+    instagram_url  TEXT        NOT NULL DEFAULT '',
+    welcome_text   TEXT        NOT NULL DEFAULT '',
+    notify_chat_id BIGINT      NOT NULL DEFAULT 0,
+    timezone       TEXT        NOT NULL DEFAULT 'UTC',
+    updated_at     TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT business_settings_single_row CHECK (id)
+);
+
+-- поиск пользователей в админке и журнал нажатий по времени
+CREATE INDEX IF NOT EXISTS users_last_seen_idx ON users (last_seen_at DESC);
+CREATE INDEX IF NOT EXISTS callback_logs_created_idx ON callback_logs (created_at DESC);
+
+-- +goose Down
+DROP INDEX IF EXISTS callback_logs_created_idx;
+DROP INDEX IF EXISTS users_last_seen_idx;
+DROP TABLE IF EXISTS business_settings;
+DROP TABLE IF EXISTS leads;
@@ -0,0 +1,35 @@
+-- +goose Up
+-- администраторы админки (вход через Telegram Login Widget)
+CREATE TABLE IF NOT EXISTS admin_users (
+    telegram_id   BIGINT PRIMARY KEY,
+    role          VARCHAR(16) NOT NULL,
+    username      TEXT        NOT NULL DEFAULT '',
+    first_name    TEXT        NOT NULL DEFAULT '',
+    last_name     TEXT        NOT NULL DEFAULT '',
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_login_at TIMESTAMPTZ,
+
+    CONSTRAINT admin_users_role_check CHECK (role IN ('owner', 'manager', 'viewer'))
+);
+
+-- сессии: токен обновления одноразовый, хранится только его хэш
+CREATE TABLE IF NOT EXISTS admin_sessions (
+    id          BIGSERIAL PRIMARY KEY,
+    telegram_id BIGINT      NOT NULL REFERENCES admin_users (telegram_id) ON DELETE CASCADE,
+    token_hash  BYTEA       NOT NULL,
+    user_agent  TEXT        NOT NULL DEFAULT '',
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at  TIMESTAMPTZ NOT NULL,
+    revoked_at  TIMESTAMPTZ,
+
+    CONSTRAINT admin_sessions_token_hash_key UNIQUE (token_hash)
+);
+
+-- закрытие всех сессий администратора и очистка истёкших
+CREATE INDEX IF NOT EXISTS admin_sessions_telegram_id_idx ON admin_sessions (telegram_id);
+CREATE INDEX IF NOT EXISTS admin_sessions_expires_at_idx ON admin_sessions (expires_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS admin_sessions;
+DROP TABLE IF EXISTS admin_users;
@@ -0,0 +1,16 @@
+-- +goose Up
+-- тексты бота, изменённые в админке (тексты по умолчанию живут в коде)
+CREATE TABLE IF NOT EXISTS bot_content (
+    key        TEXT        PRIMARY KEY,
+    text       TEXT        NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- воронка за день на главной странице админки
+CREATE INDEX IF NOT EXISTS callback_logs_data_created_idx ON callback_logs (callback_data, created_at);
+CREATE INDEX IF NOT EXISTS users_created_idx ON users (created_at);
+
+-- +goose Down
+DROP INDEX IF EXISTS users_created_idx;
+DROP INDEX IF EXISTS callback_logs_data_created_idx;
+DROP TABLE IF EXISTS bot_content;
@@ -0,0 +1,20 @@
+-- +goose Up
+-- диалоги клиентов с оператором: пока диалог открыт, бот не отвечает в чате
+CREATE TABLE IF NOT EXISTS handoffs (
+    id               BIGSERIAL   PRIMARY KEY,
+    telegram_user_id BIGINT      NOT NULL,
+    reason           TEXT        NOT NULL CHECK (reason IN ('client', 'master')),
+    opened_by        BIGINT      NOT NULL DEFAULT 0,
+    opened_at        TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_activity_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    closed_at        TIMESTAMPTZ,
+    close_reason     TEXT        NOT NULL DEFAULT '' CHECK (close_reason IN ('', 'operator', 'timeout'))
+);
+
+-- у чата может быть только один открытый диалог
+CREATE UNIQUE INDEX IF NOT EXISTS handoffs_open_user_idx ON handoffs (telegram_user_id) WHERE closed_at IS NULL;
+-- поиск неактивных диалогов для закрытия по таймауту
+CREATE INDEX IF NOT EXISTS handoffs_open_activity_idx ON handoffs (last_activity_at) WHERE closed_at IS NULL;
+
+-- +goose Down
+DROP TABLE IF EXISTS handoffs;
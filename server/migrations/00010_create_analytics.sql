@@ -0,0 +1,53 @@
+-- +goose Up
+-- агрегаты аналитики: пересчитываются задачей планировщика, админка и /stats читают готовые строки.
+-- period_start и cohort_start - даты по часовому поясу мастера (неделя - с понедельника)
+CREATE TABLE IF NOT EXISTS analytics_funnel (
+    period                  VARCHAR(8)  NOT NULL CHECK (period IN ('day', 'week')),
+    period_start            DATE        NOT NULL,
+    new_users               BIGINT      NOT NULL DEFAULT 0,
+    active_users            BIGINT      NOT NULL DEFAULT 0,
+    started                 BIGINT      NOT NULL DEFAULT 0,
+    lookups                 BIGINT      NOT NULL DEFAULT 0,
+    contacted_yes           BIGINT      NOT NULL DEFAULT 0,
+    contacts                BIGINT      NOT NULL DEFAULT 0,
+    contact_time_avg_sec    BIGINT      NOT NULL DEFAULT 0,
+    contact_time_median_sec BIGINT      NOT NULL DEFAULT 0,
+    computed_at             TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (period, period_start)
+);
+
+-- новые пользователи по источнику (параметр ссылки t.me/<бот>?start=<источник>) и их конверсия
+CREATE TABLE IF NOT EXISTS analytics_sources (
+    period        VARCHAR(8)  NOT NULL CHECK (period IN ('day', 'week')),
+    period_start  DATE        NOT NULL,
+    source        TEXT        NOT NULL,
+    new_users     BIGINT      NOT NULL DEFAULT 0,
+    lookups       BIGINT      NOT NULL DEFAULT 0,
+    contacted_yes BIGINT      NOT NULL DEFAULT 0,
+    computed_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (period, period_start, source)
+);
+
+-- удержание недельных когорт по users.created_at: week_offset 0 - неделя прихода
+CREATE TABLE IF NOT EXISTS analytics_retention (
+    cohort_start DATE        NOT NULL,
+    week_offset  INT         NOT NULL CHECK (week_offset >= 0),
+    cohort_size  BIGINT      NOT NULL,
+    retained     BIGINT      NOT NULL,
+    computed_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (cohort_start, week_offset)
+);
+
+-- пересчёт: первый /start пользователя (источник); шаги воронки по кнопкам читаются по
+-- callback_logs_data_created_idx из 00008
+CREATE INDEX IF NOT EXISTS messages_start_user_idx ON messages (telegram_user_id, created_at)
+    WHERE direction = 'incoming' AND command_name = '/start';
+
+-- +goose Down
+DROP INDEX IF EXISTS messages_start_user_idx;
+DROP TABLE IF EXISTS analytics_retention;
+DROP TABLE IF EXISTS analytics_sources;
+DROP TABLE IF EXISTS analytics_funnel;
@@ -0,0 +1,10 @@
+// Пакет migrations - версионированные SQL миграции схемы сервера основной логики (формат goose).
+// Файлы встраиваются в бинарник, поэтому для запуска миграций исходники не нужны
+package migrations
+
+import "embed"
+
+// FS - встроенные файлы миграций
+//
+//go:embed *.sql
+var FS embed.FS